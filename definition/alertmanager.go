@@ -51,6 +51,22 @@ type Route struct {
 	GroupInterval  *model.Duration `yaml:"group_interval,omitempty" json:"group_interval,omitempty"`
 	RepeatInterval *model.Duration `yaml:"repeat_interval,omitempty" json:"repeat_interval,omitempty"`
 
+	// NotifyOnce, if set, sends a single notification per incident for the alert groups of the route:
+	// after the first successful notification, the group is not notified again until all of its alerts are resolved.
+	// It is not part of the Alertmanager route, and is therefore lost when converting to one.
+	NotifyOnce bool `yaml:"notify_once,omitempty" json:"notify_once,omitempty"`
+
+	// OrderedNotifications, if set, numbers the notifications of the alert groups of the route so that a notification
+	// is never delivered after a later notification of the same group, such as a firing notification retried until
+	// after the resolved notification was delivered. The sequence number is sent by the receivers that support it.
+	// It is not part of the Alertmanager route, and is therefore lost when converting to one.
+	OrderedNotifications bool `yaml:"ordered_notifications,omitempty" json:"ordered_notifications,omitempty"`
+
+	// Enrichers are the names of the enrichers, configured in the Alertmanager, that add annotations to the alerts of
+	// the notifications of the route, after the enrichers of all routes. They are not inherited by the child routes,
+	// and are lost when converting to an Alertmanager route.
+	Enrichers []string `yaml:"enrichers,omitempty" json:"enrichers,omitempty"`
+
 	Provenance Provenance `yaml:"provenance,omitempty" json:"provenance,omitempty"`
 }
 
@@ -234,6 +250,14 @@ func (c *PostableApiAlertingConfig) UnmarshalJSON(b []byte) error {
 }
 
 func (c *PostableApiAlertingConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := c.unmarshal(unmarshal); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// unmarshal decodes the configuration without validating it.
+func (c *PostableApiAlertingConfig) unmarshal(unmarshal func(interface{}) error) error {
 	type plain PostableApiAlertingConfig
 	if err := unmarshal((*plain)(c)); err != nil {
 		return err
@@ -246,11 +270,7 @@ func (c *PostableApiAlertingConfig) UnmarshalYAML(unmarshal func(interface{}) er
 		Receivers *[]*PostableApiReceiver `yaml:"receivers" json:"receivers,omitempty"`
 	}
 
-	if err := unmarshal(&overrides{Receivers: &c.Receivers}); err != nil {
-		return err
-	}
-
-	return c.Validate()
+	return unmarshal(&overrides{Receivers: &c.Receivers})
 }
 
 // Validate ensures that the two routing trees use the correct receiver types.
@@ -606,6 +626,9 @@ func (r *PostableApiReceiver) GetName() string {
 
 type PostableGrafanaReceivers struct {
 	GrafanaManagedReceivers []*PostableGrafanaReceiver `yaml:"grafana_managed_receiver_configs,omitempty" json:"grafana_managed_receiver_configs,omitempty"`
+	// Failover is an ordered list of UIDs of Grafana managed receivers. Each receiver in the list is notified
+	// only if the previous one failed permanently.
+	Failover []string `yaml:"failover,omitempty" json:"failover,omitempty"`
 }
 
 // DecryptSecureSettings returns a map containing the decoded and decrypted secure settings.
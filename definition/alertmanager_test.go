@@ -523,6 +523,71 @@ email_configs:
 	}
 }
 
+func Test_PostableApiReceiver_Unmarshaling_Failover(t *testing.T) {
+	input := `
+name: grafana_managed
+failover: [primary, secondary]
+grafana_managed_receiver_configs:
+  - uid: primary
+    name: grafana_managed
+    type: pagerduty
+  - uid: secondary
+    name: grafana_managed
+    type: pagerduty`
+
+	var r PostableApiReceiver
+	require.NoError(t, yaml.Unmarshal([]byte(input), &r))
+	require.Equal(t, []string{"primary", "secondary"}, r.Failover)
+
+	b, err := json.Marshal(&r)
+	require.NoError(t, err)
+	var fromJSON PostableApiReceiver
+	require.NoError(t, json.Unmarshal(b, &fromJSON))
+	require.Equal(t, []string{"primary", "secondary"}, fromJSON.Failover)
+}
+
+func Test_Route_Unmarshaling_NotifyOnce(t *testing.T) {
+	input := `
+receiver: default
+routes:
+  - receiver: info
+    notify_once: true
+    object_matchers:
+      - [severity, =, info]`
+
+	var r Route
+	require.NoError(t, yaml.Unmarshal([]byte(input), &r))
+	require.False(t, r.NotifyOnce)
+	require.True(t, r.Routes[0].NotifyOnce)
+
+	b, err := json.Marshal(&r)
+	require.NoError(t, err)
+	var fromJSON Route
+	require.NoError(t, json.Unmarshal(b, &fromJSON))
+	require.True(t, fromJSON.Routes[0].NotifyOnce)
+}
+
+func Test_Route_Unmarshaling_OrderedNotifications(t *testing.T) {
+	input := `
+receiver: default
+routes:
+  - receiver: pager
+    ordered_notifications: true
+    object_matchers:
+      - [severity, =, critical]`
+
+	var r Route
+	require.NoError(t, yaml.Unmarshal([]byte(input), &r))
+	require.False(t, r.OrderedNotifications)
+	require.True(t, r.Routes[0].OrderedNotifications)
+
+	b, err := json.Marshal(&r)
+	require.NoError(t, err)
+	var fromJSON Route
+	require.NoError(t, json.Unmarshal(b, &fromJSON))
+	require.True(t, fromJSON.Routes[0].OrderedNotifications)
+}
+
 func Test_ConfigUnmashaling(t *testing.T) {
 	for _, tc := range []struct {
 		desc, input string
@@ -0,0 +1,328 @@
+package definition
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/prometheus/alertmanager/config"
+)
+
+// ChangeType is the type of a change between two configurations.
+type ChangeType string
+
+const (
+	ChangeTypeAdded    ChangeType = "added"
+	ChangeTypeRemoved  ChangeType = "removed"
+	ChangeTypeModified ChangeType = "modified"
+)
+
+// ChangeKind is the kind of the configuration object that changed.
+type ChangeKind string
+
+const (
+	ChangeKindReceiver         ChangeKind = "receiver"
+	ChangeKindRoute            ChangeKind = "route"
+	ChangeKindTimeInterval     ChangeKind = "time_interval"
+	ChangeKindMuteTimeInterval ChangeKind = "mute_time_interval"
+	ChangeKindTemplate         ChangeKind = "template"
+)
+
+// Change is a single change between two configurations.
+type Change struct {
+	Type ChangeType `json:"type" yaml:"type"`
+	Kind ChangeKind `json:"kind" yaml:"kind"`
+	// Name identifies the object. Routes are identified by their path in the routing tree, such as "route.routes[0]".
+	Name string `json:"name" yaml:"name"`
+	// Details describe the modifications of a modified object, if they are known.
+	Details []string `json:"details,omitempty" yaml:"details,omitempty"`
+	// Old and New are the YAML representation of the object before and after the change, with secrets masked.
+	Old string `json:"old,omitempty" yaml:"old,omitempty"`
+	New string `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// ChangeSet is the set of changes between two configurations.
+type ChangeSet struct {
+	Changes []Change `json:"changes" yaml:"changes"`
+}
+
+// IsEmpty returns true if there are no changes.
+func (c ChangeSet) IsEmpty() bool {
+	return len(c.Changes) == 0
+}
+
+// maskedSecret replaces the values of secure settings in the representation of receivers.
+const maskedSecret = "<secret>"
+
+// DiffAlertingConfigs returns the receivers, routes, time intervals and templates that were added, removed or
+// modified in the new configuration. Secure settings of Grafana receivers and secrets of Alertmanager receivers
+// are masked in the representation of the objects, only whether they changed is reported. A nil configuration
+// is treated as an empty one.
+func DiffAlertingConfigs(old, new *PostableApiAlertingConfig) (ChangeSet, error) {
+	if old == nil {
+		old = &PostableApiAlertingConfig{}
+	}
+	if new == nil {
+		new = &PostableApiAlertingConfig{}
+	}
+
+	var cs ChangeSet
+	if err := cs.diffReceivers(old.Receivers, new.Receivers); err != nil {
+		return ChangeSet{}, err
+	}
+	if err := cs.diffRoutes("route", old.Route, new.Route); err != nil {
+		return ChangeSet{}, err
+	}
+	if err := diffNamed(&cs, ChangeKindTimeInterval, old.TimeIntervals, new.TimeIntervals, func(ti config.TimeInterval) string {
+		return ti.Name
+	}); err != nil {
+		return ChangeSet{}, err
+	}
+	if err := diffNamed(&cs, ChangeKindMuteTimeInterval, old.MuteTimeIntervals, new.MuteTimeIntervals, func(mti config.MuteTimeInterval) string {
+		return mti.Name
+	}); err != nil {
+		return ChangeSet{}, err
+	}
+	cs.diffTemplates(old.Templates, new.Templates)
+	return cs, nil
+}
+
+func (cs *ChangeSet) diffReceivers(old, new []*PostableApiReceiver) error {
+	return diffNamed(cs, ChangeKindReceiver, maskReceivers(old), maskReceivers(new), func(r maskedReceiver) string {
+		return r.receiver.Name
+	})
+}
+
+// maskedReceiver is a receiver that is represented with its secure settings masked.
+type maskedReceiver struct {
+	receiver *PostableApiReceiver
+}
+
+func maskReceivers(receivers []*PostableApiReceiver) []maskedReceiver {
+	res := make([]maskedReceiver, 0, len(receivers))
+	for _, r := range receivers {
+		res = append(res, maskedReceiver{receiver: r})
+	}
+	return res
+}
+
+// MarshalYAML returns the receiver with the values of the secure settings of its integrations masked.
+// Secrets of Alertmanager receivers are masked by their own marshaling.
+func (m maskedReceiver) MarshalYAML() (interface{}, error) {
+	cpy := *m.receiver
+	cpy.GrafanaManagedReceivers = make([]*PostableGrafanaReceiver, 0, len(m.receiver.GrafanaManagedReceivers))
+	for _, gr := range m.receiver.GrafanaManagedReceivers {
+		masked := *gr
+		if len(gr.SecureSettings) > 0 {
+			masked.SecureSettings = make(map[string]string, len(gr.SecureSettings))
+			for k := range gr.SecureSettings {
+				masked.SecureSettings[k] = maskedSecret
+			}
+		}
+		cpy.GrafanaManagedReceivers = append(cpy.GrafanaManagedReceivers, &masked)
+	}
+	return &cpy, nil
+}
+
+// details returns the modifications of the integrations of the receiver, including changes of secure settings.
+func (m maskedReceiver) details(other maskedReceiver) []string {
+	var details []string
+	oldByUID := make(map[string]*PostableGrafanaReceiver, len(m.receiver.GrafanaManagedReceivers))
+	for _, gr := range m.receiver.GrafanaManagedReceivers {
+		oldByUID[gr.UID] = gr
+	}
+	newUIDs := make(map[string]struct{}, len(other.receiver.GrafanaManagedReceivers))
+	for _, gr := range other.receiver.GrafanaManagedReceivers {
+		newUIDs[gr.UID] = struct{}{}
+		old, ok := oldByUID[gr.UID]
+		if !ok {
+			details = append(details, fmt.Sprintf("integration %q (%s) added", gr.UID, gr.Type))
+			continue
+		}
+		if old.Type != gr.Type {
+			details = append(details, fmt.Sprintf("integration %q type changed from %s to %s", gr.UID, old.Type, gr.Type))
+		}
+		if old.Name != gr.Name {
+			details = append(details, fmt.Sprintf("integration %q name changed", gr.UID))
+		}
+		if old.DisableResolveMessage != gr.DisableResolveMessage {
+			details = append(details, fmt.Sprintf("integration %q disableResolveMessage changed", gr.UID))
+		}
+		if !jsonEqual(old.Settings, gr.Settings) {
+			details = append(details, fmt.Sprintf("integration %q settings changed", gr.UID))
+		}
+		for _, k := range sortedKeys(old.SecureSettings, gr.SecureSettings) {
+			ov, inOld := old.SecureSettings[k]
+			nv, inNew := gr.SecureSettings[k]
+			switch {
+			case !inOld:
+				details = append(details, fmt.Sprintf("integration %q secure setting %q added", gr.UID, k))
+			case !inNew:
+				details = append(details, fmt.Sprintf("integration %q secure setting %q removed", gr.UID, k))
+			case ov != nv:
+				details = append(details, fmt.Sprintf("integration %q secure setting %q changed", gr.UID, k))
+			}
+		}
+	}
+	for _, gr := range m.receiver.GrafanaManagedReceivers {
+		if _, ok := newUIDs[gr.UID]; !ok {
+			details = append(details, fmt.Sprintf("integration %q (%s) removed", gr.UID, gr.Type))
+		}
+	}
+	if !reflect.DeepEqual(m.receiver.Failover, other.receiver.Failover) {
+		details = append(details, "failover changed")
+	}
+	if !reflect.DeepEqual(m.receiver.Receiver, other.receiver.Receiver) {
+		details = append(details, "alertmanager integrations changed")
+	}
+	return details
+}
+
+func (cs *ChangeSet) diffRoutes(path string, old, new *Route) error {
+	switch {
+	case old == nil && new == nil:
+		return nil
+	case old == nil:
+		return cs.add(ChangeTypeAdded, ChangeKindRoute, path, nil, new)
+	case new == nil:
+		return cs.add(ChangeTypeRemoved, ChangeKindRoute, path, old, nil)
+	}
+
+	oldNode, newNode := *old, *new
+	oldNode.Routes, newNode.Routes = nil, nil
+	if !reflect.DeepEqual(oldNode, newNode) {
+		if err := cs.add(ChangeTypeModified, ChangeKindRoute, path, &oldNode, &newNode); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < len(old.Routes) || i < len(new.Routes); i++ {
+		var o, n *Route
+		if i < len(old.Routes) {
+			o = old.Routes[i]
+		}
+		if i < len(new.Routes) {
+			n = new.Routes[i]
+		}
+		if err := cs.diffRoutes(fmt.Sprintf("%s.routes[%d]", path, i), o, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cs *ChangeSet) diffTemplates(old, new []string) {
+	oldSet := make(map[string]struct{}, len(old))
+	for _, t := range old {
+		oldSet[t] = struct{}{}
+	}
+	newSet := make(map[string]struct{}, len(new))
+	for _, t := range new {
+		newSet[t] = struct{}{}
+		if _, ok := oldSet[t]; !ok {
+			cs.Changes = append(cs.Changes, Change{Type: ChangeTypeAdded, Kind: ChangeKindTemplate, Name: t})
+		}
+	}
+	for _, t := range old {
+		if _, ok := newSet[t]; !ok {
+			cs.Changes = append(cs.Changes, Change{Type: ChangeTypeRemoved, Kind: ChangeKindTemplate, Name: t})
+		}
+	}
+}
+
+// diffNamed adds the changes between two lists of objects identified by name. Objects that are not equal are modified.
+func diffNamed[T any](cs *ChangeSet, kind ChangeKind, old, new []T, name func(T) string) error {
+	oldByName := make(map[string]T, len(old))
+	for i := range old {
+		oldByName[name(old[i])] = old[i]
+	}
+	newNames := make(map[string]struct{}, len(new))
+	for i := range new {
+		n := name(new[i])
+		newNames[n] = struct{}{}
+		o, ok := oldByName[n]
+		if !ok {
+			if err := cs.add(ChangeTypeAdded, kind, n, nil, new[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		var details []string
+		if r, ok := any(o).(maskedReceiver); ok {
+			// Receivers are compared by their details so that formatting of their settings is ignored.
+			if details = r.details(any(new[i]).(maskedReceiver)); len(details) == 0 {
+				continue
+			}
+		} else if reflect.DeepEqual(o, new[i]) {
+			continue
+		}
+		if err := cs.add(ChangeTypeModified, kind, n, o, new[i]); err != nil {
+			return err
+		}
+		cs.Changes[len(cs.Changes)-1].Details = details
+	}
+	for i := range old {
+		n := name(old[i])
+		if _, ok := newNames[n]; !ok {
+			if err := cs.add(ChangeTypeRemoved, kind, n, old[i], nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (cs *ChangeSet) add(t ChangeType, kind ChangeKind, name string, old, new interface{}) error {
+	c := Change{Type: t, Kind: kind, Name: name}
+	var err error
+	if old != nil {
+		if c.Old, err = marshalMasked(old); err != nil {
+			return fmt.Errorf("failed to marshal %s %q: %w", kind, name, err)
+		}
+	}
+	if new != nil {
+		if c.New, err = marshalMasked(new); err != nil {
+			return fmt.Errorf("failed to marshal %s %q: %w", kind, name, err)
+		}
+	}
+	cs.Changes = append(cs.Changes, c)
+	return nil
+}
+
+func marshalMasked(v interface{}) (string, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// jsonEqual returns true if the two raw messages are equal JSON values, ignoring formatting.
+func jsonEqual(a, b RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var av, bv interface{}
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+func sortedKeys(maps ...map[string]string) []string {
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, m := range maps {
+		for k := range m {
+			if _, ok := seen[k]; !ok {
+				seen[k] = struct{}{}
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
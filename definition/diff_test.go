@@ -0,0 +1,122 @@
+package definition
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffAlertingConfigs(t *testing.T) {
+	newConfig := func() *PostableApiAlertingConfig {
+		return &PostableApiAlertingConfig{
+			Config: Config{
+				Route: &Route{
+					Receiver: "default",
+					Routes: []*Route{
+						{Receiver: "slack", GroupByStr: []string{"alertname"}},
+					},
+				},
+				TimeIntervals: []config.TimeInterval{{Name: "weekends"}},
+				Templates:     []string{"a.tmpl"},
+			},
+			Receivers: []*PostableApiReceiver{
+				{
+					Receiver: config.Receiver{Name: "default"},
+					PostableGrafanaReceivers: PostableGrafanaReceivers{
+						GrafanaManagedReceivers: []*PostableGrafanaReceiver{
+							{UID: "email-uid", Name: "default", Type: "email", Settings: RawMessage(`{"addresses":"test@example.com"}`)},
+						},
+					},
+				},
+				{
+					Receiver: config.Receiver{Name: "slack"},
+					PostableGrafanaReceivers: PostableGrafanaReceivers{
+						GrafanaManagedReceivers: []*PostableGrafanaReceiver{
+							{UID: "slack-uid", Name: "slack", Type: "slack", Settings: RawMessage(`{}`), SecureSettings: map[string]string{"url": "old-secret-url"}},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("no changes", func(t *testing.T) {
+		cs, err := DiffAlertingConfigs(newConfig(), newConfig())
+		require.NoError(t, err)
+		require.True(t, cs.IsEmpty())
+	})
+
+	t.Run("nil configurations are empty", func(t *testing.T) {
+		cs, err := DiffAlertingConfigs(nil, nil)
+		require.NoError(t, err)
+		require.True(t, cs.IsEmpty())
+
+		cs, err = DiffAlertingConfigs(nil, newConfig())
+		require.NoError(t, err)
+		for _, c := range cs.Changes {
+			require.Equal(t, ChangeTypeAdded, c.Type)
+		}
+		require.Len(t, cs.Changes, 5)
+	})
+
+	t.Run("reports changes", func(t *testing.T) {
+		old, cfg := newConfig(), newConfig()
+		cfg.Receivers[0].GrafanaManagedReceivers[0].Settings = RawMessage(`{ "addresses": "test@example.com" }`)
+		cfg.Receivers[1].GrafanaManagedReceivers[0].SecureSettings["url"] = "new-secret-url"
+		cfg.Receivers = append(cfg.Receivers, &PostableApiReceiver{Receiver: config.Receiver{Name: "pagerduty"}})
+		cfg.Route.Routes[0].GroupByStr = []string{"alertname", "cluster"}
+		cfg.Route.Routes = append(cfg.Route.Routes, &Route{Receiver: "pagerduty"})
+		cfg.TimeIntervals = nil
+		cfg.MuteTimeIntervals = []config.MuteTimeInterval{{Name: "maintenance"}}
+		cfg.Templates = []string{"b.tmpl"}
+
+		cs, err := DiffAlertingConfigs(old, cfg)
+		require.NoError(t, err)
+
+		type change struct {
+			Type    ChangeType
+			Kind    ChangeKind
+			Name    string
+			Details []string
+		}
+		var changes []change
+		for _, c := range cs.Changes {
+			changes = append(changes, change{Type: c.Type, Kind: c.Kind, Name: c.Name, Details: c.Details})
+		}
+		require.Equal(t, []change{
+			{Type: ChangeTypeModified, Kind: ChangeKindReceiver, Name: "slack", Details: []string{`integration "slack-uid" secure setting "url" changed`}},
+			{Type: ChangeTypeAdded, Kind: ChangeKindReceiver, Name: "pagerduty"},
+			{Type: ChangeTypeModified, Kind: ChangeKindRoute, Name: "route.routes[0]"},
+			{Type: ChangeTypeAdded, Kind: ChangeKindRoute, Name: "route.routes[1]"},
+			{Type: ChangeTypeRemoved, Kind: ChangeKindTimeInterval, Name: "weekends"},
+			{Type: ChangeTypeAdded, Kind: ChangeKindMuteTimeInterval, Name: "maintenance"},
+			{Type: ChangeTypeAdded, Kind: ChangeKindTemplate, Name: "b.tmpl"},
+			{Type: ChangeTypeRemoved, Kind: ChangeKindTemplate, Name: "a.tmpl"},
+		}, changes)
+	})
+
+	t.Run("masks secrets", func(t *testing.T) {
+		old, cfg := newConfig(), newConfig()
+		cfg.Receivers[1].GrafanaManagedReceivers[0].SecureSettings["url"] = "new-secret-url"
+		u, err := url.Parse("https://hooks.slack.com/services/secret")
+		require.NoError(t, err)
+		cfg.Receivers[1].SlackConfigs = []*config.SlackConfig{{APIURL: &config.SecretURL{URL: u}, Channel: "#alerts"}}
+
+		cs, err := DiffAlertingConfigs(old, cfg)
+		require.NoError(t, err)
+		require.Len(t, cs.Changes, 1)
+		require.Equal(t, []string{
+			`integration "slack-uid" secure setting "url" changed`,
+			"alertmanager integrations changed",
+		}, cs.Changes[0].Details)
+		for _, s := range []string{cs.Changes[0].Old, cs.Changes[0].New} {
+			require.Contains(t, s, "<secret>")
+			require.NotContains(t, s, "secret-url")
+			require.NotContains(t, s, "hooks.slack.com")
+		}
+		// The receivers of the configuration are not modified.
+		require.Equal(t, "new-secret-url", cfg.Receivers[1].GrafanaManagedReceivers[0].SecureSettings["url"])
+	})
+}
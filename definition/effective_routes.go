@@ -0,0 +1,175 @@
+package definition
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/common/model"
+)
+
+// RouteWarningCode identifies a surprising interaction of the routes of a routing tree.
+type RouteWarningCode string
+
+const (
+	// RouteWarningIneffectiveContinue is for a route that continues, but has no following sibling to continue to.
+	RouteWarningIneffectiveContinue RouteWarningCode = "ineffective-continue"
+	// RouteWarningShadowed is for a route whose alerts are all matched by a preceding sibling that does not continue.
+	RouteWarningShadowed RouteWarningCode = "shadowed"
+	// RouteWarningDuplicateNotifications is for a route that continues to a following sibling that matches all of
+	// its alerts and notifies the same receiver, so that the receiver is notified twice.
+	RouteWarningDuplicateNotifications RouteWarningCode = "duplicate-notifications"
+)
+
+// RouteWarning is a surprising interaction of a route with the other routes of the routing tree.
+type RouteWarning struct {
+	Code RouteWarningCode `json:"code" yaml:"code"`
+	// Path is the path of the other route of the interaction, if any.
+	Path    string `json:"path,omitempty" yaml:"path,omitempty"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// EffectiveRoute is a route of the routing tree with its options after inheritance from its parents.
+type EffectiveRoute struct {
+	// Path is the path of the route in the routing tree, such as "route.routes[0]".
+	Path string `json:"path" yaml:"path"`
+	// Depth is the depth of the route in the routing tree, 0 for the root route.
+	Depth int `json:"depth" yaml:"depth"`
+	// Leaf is true if the route has no children. The alerts of a route with children are only notified by the
+	// route if none of its children matches them.
+	Leaf bool `json:"leaf" yaml:"leaf"`
+	// Matchers are the matchers of the route and of all of its parents, which an alert must match to reach it.
+	Matchers []string `json:"matchers" yaml:"matchers"`
+	Receiver string   `json:"receiver" yaml:"receiver"`
+	// GroupBy are the labels the alerts of the route are grouped by. If GroupByAll is true, they are grouped by all labels.
+	GroupBy             []string       `json:"group_by" yaml:"group_by"`
+	GroupByAll          bool           `json:"group_by_all" yaml:"group_by_all"`
+	GroupWait           model.Duration `json:"group_wait" yaml:"group_wait"`
+	GroupInterval       model.Duration `json:"group_interval" yaml:"group_interval"`
+	RepeatInterval      model.Duration `json:"repeat_interval" yaml:"repeat_interval"`
+	MuteTimeIntervals   []string       `json:"mute_time_intervals" yaml:"mute_time_intervals"`
+	ActiveTimeIntervals []string       `json:"active_time_intervals" yaml:"active_time_intervals"`
+	Continue            bool           `json:"continue" yaml:"continue"`
+	Warnings            []RouteWarning `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// EffectiveRoutes returns the routes of the routing tree in the order the dispatcher evaluates them: depth-first,
+// each route before its children, and the children in the order of the configuration. Each route has the receiver,
+// grouping and timings it inherits from its parents, and warnings for the interactions of continue with its
+// siblings that are likely to misroute alerts. The routing tree is expected to be valid.
+func EffectiveRoutes(root *Route) []EffectiveRoute {
+	if root == nil {
+		return nil
+	}
+	var routes []EffectiveRoute
+	dr := dispatch.NewRoute(root.AsAMRoute(), nil)
+	routes = appendEffectiveRoutes(routes, "route", 0, nil, dr)
+	if root.Continue {
+		routes[0].Warnings = append(routes[0].Warnings, RouteWarning{
+			Code:    RouteWarningIneffectiveContinue,
+			Message: "continue has no effect on the root route",
+		})
+	}
+	return routes
+}
+
+// appendEffectiveRoutes appends the route r and its children to routes. The matchers are those of the parents of r.
+func appendEffectiveRoutes(routes []EffectiveRoute, path string, depth int, matchers []string, r *dispatch.Route) []EffectiveRoute {
+	matchers = matchers[:len(matchers):len(matchers)]
+	for _, m := range r.Matchers {
+		matchers = append(matchers, m.String())
+	}
+	er := EffectiveRoute{
+		Path:                path,
+		Depth:               depth,
+		Leaf:                len(r.Routes) == 0,
+		Matchers:            matchers,
+		Receiver:            r.RouteOpts.Receiver,
+		GroupByAll:          r.RouteOpts.GroupByAll,
+		GroupWait:           model.Duration(r.RouteOpts.GroupWait),
+		GroupInterval:       model.Duration(r.RouteOpts.GroupInterval),
+		RepeatInterval:      model.Duration(r.RouteOpts.RepeatInterval),
+		MuteTimeIntervals:   r.RouteOpts.MuteTimeIntervals,
+		ActiveTimeIntervals: r.RouteOpts.ActiveTimeIntervals,
+		Continue:            r.Continue,
+	}
+	if er.Matchers == nil {
+		er.Matchers = []string{}
+	}
+	if !er.GroupByAll {
+		er.GroupBy = make([]string, 0, len(r.RouteOpts.GroupBy))
+		for ln := range r.RouteOpts.GroupBy {
+			er.GroupBy = append(er.GroupBy, string(ln))
+		}
+		sort.Strings(er.GroupBy)
+	}
+	routes = append(routes, er)
+
+	for i, child := range r.Routes {
+		childPath := fmt.Sprintf("%s.routes[%d]", path, i)
+		idx := len(routes)
+		routes = appendEffectiveRoutes(routes, childPath, depth+1, matchers, child)
+		routes[idx].Warnings = append(routes[idx].Warnings, siblingWarnings(path, i, r.Routes)...)
+	}
+	return routes
+}
+
+// siblingWarnings returns the warnings of the i-th child of the route at the path, given all of its children.
+func siblingWarnings(path string, i int, siblings []*dispatch.Route) []RouteWarning {
+	var warnings []RouteWarning
+	r := siblings[i]
+	for j := 0; j < i; j++ {
+		if !siblings[j].Continue && matchesAllAlertsOf(siblings[j], r) {
+			warnings = append(warnings, RouteWarning{
+				Code:    RouteWarningShadowed,
+				Path:    fmt.Sprintf("%s.routes[%d]", path, j),
+				Message: fmt.Sprintf("route is never evaluated, all of its alerts are matched by %s.routes[%d] which does not continue", path, j),
+			})
+			break
+		}
+	}
+	if !r.Continue {
+		return warnings
+	}
+	if i == len(siblings)-1 {
+		return append(warnings, RouteWarning{
+			Code:    RouteWarningIneffectiveContinue,
+			Message: "continue has no effect on the last route of its parent",
+		})
+	}
+	if len(r.Routes) > 0 {
+		// The alerts of the route may be notified by its children, to other receivers.
+		return warnings
+	}
+	for j := i + 1; j < len(siblings); j++ {
+		next := siblings[j]
+		if len(next.Routes) == 0 && next.RouteOpts.Receiver == r.RouteOpts.Receiver && matchesAllAlertsOf(next, r) {
+			warnings = append(warnings, RouteWarning{
+				Code: RouteWarningDuplicateNotifications,
+				Path: fmt.Sprintf("%s.routes[%d]", path, j),
+				Message: fmt.Sprintf("receiver %q is notified twice of the alerts of the route, as the route continues to %s.routes[%d] which matches all of them",
+					r.RouteOpts.Receiver, path, j),
+			})
+		}
+		if !next.Continue && matchesAllAlertsOf(next, r) {
+			// The evaluation stops at this sibling for all of the alerts of the route.
+			break
+		}
+	}
+	return warnings
+}
+
+// matchesAllAlertsOf returns true if the route a matches all of the alerts of its sibling b, which is the case if
+// each of the matchers of a is also a matcher of b.
+func matchesAllAlertsOf(a, b *dispatch.Route) bool {
+	matchers := make(map[string]struct{}, len(b.Matchers))
+	for _, m := range b.Matchers {
+		matchers[m.String()] = struct{}{}
+	}
+	for _, m := range a.Matchers {
+		if _, ok := matchers[m.String()]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package definition
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEffectiveRoutes(t *testing.T) {
+	var root Route
+	require.NoError(t, yaml.Unmarshal([]byte(`
+receiver: default
+group_by: [alertname]
+group_wait: 1m
+routes:
+- receiver: team-a
+  continue: true
+  object_matchers:
+  - [team, =, a]
+  - [severity, =, critical]
+- receiver: team-a
+  object_matchers:
+  - [team, =, a]
+- receiver: team-a-warning
+  object_matchers:
+  - [team, =, a]
+  - [severity, =, warning]
+- receiver: team-b
+  continue: true
+  mute_time_intervals: [weekends]
+  object_matchers:
+  - [team, =, b]
+  routes:
+  - group_by: ['...']
+    repeat_interval: 1h
+    object_matchers:
+    - [cluster, =, prod]
+`), &root))
+	require.NoError(t, root.Validate())
+
+	routes := EffectiveRoutes(&root)
+
+	defaults := func(r EffectiveRoute) EffectiveRoute {
+		r.GroupWait = model.Duration(time.Minute)
+		r.GroupInterval = model.Duration(5 * time.Minute)
+		if r.RepeatInterval == 0 {
+			r.RepeatInterval = model.Duration(4 * time.Hour)
+		}
+		if r.GroupBy == nil && !r.GroupByAll {
+			r.GroupBy = []string{"alertname"}
+		}
+		return r
+	}
+	require.Equal(t, []EffectiveRoute{
+		defaults(EffectiveRoute{
+			Path:     "route",
+			Matchers: []string{},
+			Receiver: "default",
+		}),
+		defaults(EffectiveRoute{
+			Path:     "route.routes[0]",
+			Depth:    1,
+			Leaf:     true,
+			Matchers: []string{`severity="critical"`, `team="a"`},
+			Receiver: "team-a",
+			Continue: true,
+			Warnings: []RouteWarning{{
+				Code:    RouteWarningDuplicateNotifications,
+				Path:    "route.routes[1]",
+				Message: `receiver "team-a" is notified twice of the alerts of the route, as the route continues to route.routes[1] which matches all of them`,
+			}},
+		}),
+		defaults(EffectiveRoute{
+			Path:     "route.routes[1]",
+			Depth:    1,
+			Leaf:     true,
+			Matchers: []string{`team="a"`},
+			Receiver: "team-a",
+		}),
+		defaults(EffectiveRoute{
+			Path:     "route.routes[2]",
+			Depth:    1,
+			Leaf:     true,
+			Matchers: []string{`severity="warning"`, `team="a"`},
+			Receiver: "team-a-warning",
+			Warnings: []RouteWarning{{
+				Code:    RouteWarningShadowed,
+				Path:    "route.routes[1]",
+				Message: "route is never evaluated, all of its alerts are matched by route.routes[1] which does not continue",
+			}},
+		}),
+		defaults(EffectiveRoute{
+			Path:              "route.routes[3]",
+			Depth:             1,
+			Matchers:          []string{`team="b"`},
+			Receiver:          "team-b",
+			MuteTimeIntervals: []string{"weekends"},
+			Continue:          true,
+			Warnings: []RouteWarning{{
+				Code:    RouteWarningIneffectiveContinue,
+				Message: "continue has no effect on the last route of its parent",
+			}},
+		}),
+		defaults(EffectiveRoute{
+			Path:           "route.routes[3].routes[0]",
+			Depth:          2,
+			Leaf:           true,
+			Matchers:       []string{`team="b"`, `cluster="prod"`},
+			Receiver:       "team-b",
+			GroupByAll:     true,
+			RepeatInterval: model.Duration(time.Hour),
+		}),
+	}, routes)
+
+	t.Run("continue on the root route", func(t *testing.T) {
+		routes := EffectiveRoutes(&Route{Receiver: "default", Continue: true})
+		require.Len(t, routes, 1)
+		require.Equal(t, []RouteWarning{{
+			Code:    RouteWarningIneffectiveContinue,
+			Message: "continue has no effect on the root route",
+		}}, routes[0].Warnings)
+	})
+
+	t.Run("no routing tree", func(t *testing.T) {
+		require.Nil(t, EffectiveRoutes(nil))
+	})
+}
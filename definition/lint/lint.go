@@ -0,0 +1,320 @@
+// Package lint analyzes Alertmanager configurations for issues that do not make them invalid,
+// but are likely to be mistakes, such as routes that can never be reached.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"text/template/parse"
+
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/pkg/labels"
+
+	"github.com/grafana/alerting/definition"
+	"github.com/grafana/alerting/templates"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+const (
+	// SeverityWarning is for findings that likely change how alerts are notified, contrary to the intent of the configuration.
+	SeverityWarning Severity = "warning"
+	// SeverityInfo is for findings that do not change how alerts are notified, such as unused or redundant configuration.
+	SeverityInfo Severity = "info"
+)
+
+// Code identifies the check that reported a finding.
+type Code string
+
+const (
+	CodeUnreachableRoute    Code = "unreachable-route"
+	CodeUnusedReceiver      Code = "unused-receiver"
+	CodeGroupWaitOverRepeat Code = "group-wait-over-repeat-interval"
+	CodeMatchAllRegex       Code = "match-all-regex"
+	CodeUndefinedTemplate   Code = "undefined-template"
+	CodeInvalidTemplate     Code = "invalid-template"
+)
+
+// Finding is an issue found in a configuration.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Code     Code     `json:"code"`
+	// Path identifies the object of the configuration with the issue. Routes are identified by their path in the
+	// routing tree, such as "route.routes[0]", receivers by "receivers[name]" and templates by "templates[name]".
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Lint returns the issues found in the configuration and the templates it uses. The configuration is expected
+// to be valid, as returned by definition.Load.
+func Lint(cfg *definition.PostableApiAlertingConfig, tmpls []templates.TemplateDefinition) []Finding {
+	var findings []Finding
+	if cfg.Route != nil {
+		route := dispatch.NewRoute(cfg.Route.AsAMRoute(), nil)
+		findings = append(findings, lintRoutes("route", true, cfg.Route, route)...)
+		findings = append(findings, lintUnusedReceivers(cfg)...)
+	}
+	findings = append(findings, lintTemplates(cfg, tmpls)...)
+	return findings
+}
+
+// lintRoutes returns the issues of the route r and its children. The route dr is the dispatch route built from r,
+// which has the matchers and the options of the route after inheritance. The root route is the one at the top of the tree.
+func lintRoutes(path string, root bool, r *definition.Route, dr *dispatch.Route) []Finding {
+	var findings []Finding
+
+	// Only report the routes that set the options, not those inheriting them.
+	setsTiming := root || r.GroupWait != nil || r.RepeatInterval != nil
+	if setsTiming && dr.RouteOpts.GroupWait > dr.RouteOpts.RepeatInterval {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Code:     CodeGroupWaitOverRepeat,
+			Path:     path,
+			Message: fmt.Sprintf("group_wait (%s) is larger than repeat_interval (%s), notifications are repeated before new alerts are added to the group",
+				dr.RouteOpts.GroupWait, dr.RouteOpts.RepeatInterval),
+		})
+	}
+
+	for _, m := range dr.Matchers {
+		if matchesEverything(m) {
+			findings = append(findings, Finding{
+				Severity: SeverityInfo,
+				Code:     CodeMatchAllRegex,
+				Path:     path,
+				Message:  fmt.Sprintf("matcher %s matches any value, including when the label is missing, and can be removed", m),
+			})
+		}
+	}
+
+	for i, child := range dr.Routes {
+		childPath := fmt.Sprintf("%s.routes[%d]", path, i)
+		for j := 0; j < i; j++ {
+			if shadows(dr.Routes[j], child) {
+				findings = append(findings, Finding{
+					Severity: SeverityWarning,
+					Code:     CodeUnreachableRoute,
+					Path:     childPath,
+					Message:  fmt.Sprintf("route is unreachable, all of its alerts are matched by %s.routes[%d] which does not continue", path, j),
+				})
+				break
+			}
+		}
+		findings = append(findings, lintRoutes(childPath, false, r.Routes[i], child)...)
+	}
+	return findings
+}
+
+// shadows returns true if the route a matches all of the alerts of the route b and does not continue.
+// It is the case if each of the matchers of a, other than those matching everything, is also a matcher of b.
+func shadows(a, b *dispatch.Route) bool {
+	if a.Continue {
+		return false
+	}
+	matchers := make(map[string]struct{}, len(b.Matchers))
+	for _, m := range b.Matchers {
+		matchers[m.String()] = struct{}{}
+	}
+	for _, m := range a.Matchers {
+		if matchesEverything(m) {
+			continue
+		}
+		if _, ok := matchers[m.String()]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesEverything returns true if the matcher is a regex matcher that matches any value, such as ".*".
+func matchesEverything(m *labels.Matcher) bool {
+	if m.Type != labels.MatchRegexp {
+		return false
+	}
+	re, err := syntax.Parse(m.Value, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	re = re.Simplify()
+	for re.Op == syntax.OpCapture {
+		re = re.Sub[0]
+	}
+	return re.Op == syntax.OpStar && (re.Sub[0].Op == syntax.OpAnyChar || re.Sub[0].Op == syntax.OpAnyCharNotNL)
+}
+
+// lintUnusedReceivers returns the receivers that are not used by any route.
+func lintUnusedReceivers(cfg *definition.PostableApiAlertingConfig) []Finding {
+	used := make(map[string]struct{})
+	usedReceivers(cfg.Route, used)
+	var findings []Finding
+	for _, r := range cfg.Receivers {
+		if _, ok := used[r.Name]; ok {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityInfo,
+			Code:     CodeUnusedReceiver,
+			Path:     fmt.Sprintf("receivers[%s]", r.Name),
+			Message:  "receiver is not used by any route",
+		})
+	}
+	return findings
+}
+
+// usedReceivers adds the receivers of the route and its children to used. Unlike definition.AllReceivers,
+// it includes the receivers of autogenerated routes.
+func usedReceivers(r *definition.Route, used map[string]struct{}) {
+	if r.Receiver != "" {
+		used[r.Receiver] = struct{}{}
+	}
+	for _, child := range r.Routes {
+		usedReceivers(child, used)
+	}
+}
+
+// lintTemplates returns the templates referenced by the templates and the receivers of the configuration that are not defined.
+func lintTemplates(cfg *definition.PostableApiAlertingConfig, tmpls []templates.TemplateDefinition) []Finding {
+	var findings []Finding
+	defined := make(map[string]struct{})
+	if def, err := templates.DefaultTemplate(); err == nil {
+		_, _ = parseTemplate(def.Name, def.Template, defined)
+	}
+
+	type referrer struct {
+		path string
+		refs []string
+	}
+	var referrers []referrer
+	for _, t := range tmpls {
+		refs, err := parseTemplate(t.Name, t.Template, defined)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Code:     CodeInvalidTemplate,
+				Path:     fmt.Sprintf("templates[%s]", t.Name),
+				Message:  fmt.Sprintf("template cannot be parsed: %s", err),
+			})
+			continue
+		}
+		referrers = append(referrers, referrer{path: fmt.Sprintf("templates[%s]", t.Name), refs: refs})
+	}
+
+	for _, r := range cfg.Receivers {
+		var values []string
+		for _, gr := range r.GrafanaManagedReceivers {
+			var settings interface{}
+			if err := json.Unmarshal(gr.Settings, &settings); err == nil {
+				values = appendStrings(values, settings)
+			}
+		}
+		if b, err := json.Marshal(r.Receiver); err == nil {
+			var settings interface{}
+			if err := json.Unmarshal(b, &settings); err == nil {
+				values = appendStrings(values, settings)
+			}
+		}
+		var refs []string
+		for _, v := range values {
+			// Templates in the receiver can use the templates defined in the template files,
+			// but the templates they define themselves are not shared.
+			r, err := parseTemplate("", v, make(map[string]struct{}))
+			if err != nil {
+				continue
+			}
+			refs = append(refs, r...)
+		}
+		referrers = append(referrers, referrer{path: fmt.Sprintf("receivers[%s]", r.Name), refs: refs})
+	}
+
+	for _, r := range referrers {
+		reported := make(map[string]struct{})
+		for _, name := range r.refs {
+			if _, ok := defined[name]; ok {
+				continue
+			}
+			if _, ok := reported[name]; ok {
+				continue
+			}
+			reported[name] = struct{}{}
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Code:     CodeUndefinedTemplate,
+				Path:     r.path,
+				Message:  fmt.Sprintf("template %q is not defined", name),
+			})
+		}
+	}
+	return findings
+}
+
+// parseTemplate parses the text of a template without checking its functions. It adds the names of the templates
+// it defines to defined, and returns the names of the templates it executes.
+func parseTemplate(name, text string, defined map[string]struct{}) ([]string, error) {
+	trees := make(map[string]*parse.Tree)
+	t := parse.New(name)
+	t.Mode = parse.SkipFuncCheck
+	if _, err := t.Parse(text, "{{", "}}", trees); err != nil {
+		return nil, err
+	}
+	var refs []string
+	names := make([]string, 0, len(trees))
+	for name := range trees {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if n != name {
+			defined[n] = struct{}{}
+		}
+		if tree := trees[n]; tree.Root != nil {
+			refs = appendTemplateNodes(refs, tree.Root)
+		}
+	}
+	return refs, nil
+}
+
+// appendTemplateNodes appends the names of the templates executed in the node and its children.
+func appendTemplateNodes(refs []string, node parse.Node) []string {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return refs
+		}
+		for _, c := range n.Nodes {
+			refs = appendTemplateNodes(refs, c)
+		}
+	case *parse.IfNode:
+		refs = appendTemplateNodes(appendTemplateNodes(refs, n.List), n.ElseList)
+	case *parse.RangeNode:
+		refs = appendTemplateNodes(appendTemplateNodes(refs, n.List), n.ElseList)
+	case *parse.WithNode:
+		refs = appendTemplateNodes(appendTemplateNodes(refs, n.List), n.ElseList)
+	case *parse.TemplateNode:
+		refs = append(refs, n.Name)
+	}
+	return refs
+}
+
+// appendStrings appends the strings in the value decoded from JSON.
+func appendStrings(values []string, v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		values = append(values, v)
+	case []interface{}:
+		for _, e := range v {
+			values = appendStrings(values, e)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			values = appendStrings(values, v[k])
+		}
+	}
+	return values
+}
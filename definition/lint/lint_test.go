@@ -0,0 +1,143 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+	"github.com/grafana/alerting/templates"
+)
+
+func TestLint(t *testing.T) {
+	cfg, err := definition.Load([]byte(`
+route:
+  receiver: default
+  group_wait: 1h
+  repeat_interval: 30m
+  routes:
+  - receiver: team-a
+    repeat_interval: 2h
+    object_matchers:
+    - [team, =, a]
+  - receiver: team-a-critical
+    object_matchers:
+    - [team, =, a]
+    - [severity, =, critical]
+  - receiver: team-b
+    continue: true
+    object_matchers:
+    - [team, =, b]
+    - [cluster, =~, ".*"]
+  - receiver: team-b
+    object_matchers:
+    - [team, =, b]
+receivers:
+- name: default
+  grafana_managed_receiver_configs:
+  - uid: default-uid
+    name: default
+    type: slack
+    settings:
+      title: '{{ template "slack.default.title" . }}'
+      text: '{{ template "custom.message" . }}'
+- name: team-a
+  grafana_managed_receiver_configs:
+  - uid: team-a-uid
+    name: team-a
+    type: email
+    settings:
+      addresses: team-a@example.com
+      message: '{{ template "undefined.message" . }}'
+- name: team-a-critical
+  grafana_managed_receiver_configs:
+  - uid: team-a-critical-uid
+    name: team-a-critical
+    type: email
+    settings:
+      addresses: team-a@example.com
+- name: team-b
+  grafana_managed_receiver_configs:
+  - uid: team-b-uid
+    name: team-b
+    type: email
+    settings:
+      addresses: team-b@example.com
+- name: unused
+  grafana_managed_receiver_configs:
+  - uid: unused-uid
+    name: unused
+    type: email
+    settings:
+      addresses: unused@example.com
+`))
+	require.NoError(t, err)
+
+	findings := Lint(cfg, []templates.TemplateDefinition{
+		{Name: "custom", Template: `{{ define "custom.message" }}{{ if .Alerts }}{{ template "custom.alert" . }}{{ end }}{{ end }}`},
+		{Name: "invalid", Template: `{{ define "broken" }}`},
+	})
+	require.Equal(t, []Finding{
+		{
+			Severity: SeverityWarning,
+			Code:     CodeGroupWaitOverRepeat,
+			Path:     "route",
+			Message:  "group_wait (1h0m0s) is larger than repeat_interval (30m0s), notifications are repeated before new alerts are added to the group",
+		},
+		{
+			Severity: SeverityWarning,
+			Code:     CodeUnreachableRoute,
+			Path:     "route.routes[1]",
+			Message:  "route is unreachable, all of its alerts are matched by route.routes[0] which does not continue",
+		},
+		{
+			Severity: SeverityInfo,
+			Code:     CodeMatchAllRegex,
+			Path:     "route.routes[2]",
+			Message:  `matcher cluster=~".*" matches any value, including when the label is missing, and can be removed`,
+		},
+		{
+			Severity: SeverityInfo,
+			Code:     CodeUnusedReceiver,
+			Path:     "receivers[unused]",
+			Message:  "receiver is not used by any route",
+		},
+		{
+			Severity: SeverityWarning,
+			Code:     CodeInvalidTemplate,
+			Path:     "templates[invalid]",
+			Message:  "template cannot be parsed: template: invalid:1: unexpected EOF",
+		},
+		{
+			Severity: SeverityWarning,
+			Code:     CodeUndefinedTemplate,
+			Path:     "templates[custom]",
+			Message:  `template "custom.alert" is not defined`,
+		},
+		{
+			Severity: SeverityWarning,
+			Code:     CodeUndefinedTemplate,
+			Path:     "receivers[team-a]",
+			Message:  `template "undefined.message" is not defined`,
+		},
+	}, findings)
+}
+
+func TestMatchesEverything(t *testing.T) {
+	for value, expected := range map[string]bool{
+		".*":     true,
+		"(.*)":   true,
+		"(?s).*": true,
+		".+":     false,
+		"a.*":    false,
+		"":       false,
+	} {
+		m, err := labels.NewMatcher(labels.MatchRegexp, "foo", value)
+		require.NoError(t, err)
+		require.Equal(t, expected, matchesEverything(m), value)
+	}
+	m, err := labels.NewMatcher(labels.MatchNotRegexp, "foo", ".*")
+	require.NoError(t, err)
+	require.False(t, matchesEverything(m))
+}
@@ -0,0 +1,116 @@
+package definition
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+	"gopkg.in/yaml.v3"
+)
+
+// ReceiverNameCollisionError is returned when two receivers have names that are equal after normalization,
+// for example "Team-A" and "team-a", or names that differ only by their unicode normalization form.
+type ReceiverNameCollisionError struct {
+	Name       string
+	Other      string
+	Normalized string
+}
+
+func (e ReceiverNameCollisionError) Error() string {
+	if e.Name == e.Other {
+		return fmt.Sprintf("receiver name %q is not unique", e.Name)
+	}
+	return fmt.Sprintf("receiver name %q collides with receiver name %q, both normalize to %q", e.Name, e.Other, e.Normalized)
+}
+
+// NormalizeReceiverName returns the normalized form of a receiver name. Names that are equal after normalization
+// are considered the same receiver by ValidateReceiverNames. The name is converted to the unicode NFKC form,
+// which maps compatibility characters such as full-width letters to their canonical form, and then case folded.
+func NormalizeReceiverName(name string) string {
+	return cases.Fold().String(norm.NFKC.String(name))
+}
+
+// ValidateReceiverNames returns an error for every receiver name that collides with a preceding name in the list.
+// Each error is a ReceiverNameCollisionError.
+func ValidateReceiverNames(names []string) error {
+	seen := make(map[string]string, len(names))
+	var errs []error
+	for _, name := range names {
+		normalized := NormalizeReceiverName(name)
+		if other, ok := seen[normalized]; ok {
+			errs = append(errs, ReceiverNameCollisionError{Name: name, Other: other, Normalized: normalized})
+			continue
+		}
+		seen[normalized] = name
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateReceiverNames checks that no two receivers have names that are equal after normalization.
+// This is not part of Validate, as names that differ only by case are valid in existing configurations.
+func (c *PostableApiAlertingConfig) ValidateReceiverNames() error {
+	names := make([]string, 0, len(c.Receivers))
+	for _, r := range c.Receivers {
+		names = append(names, r.Name)
+	}
+	return ValidateReceiverNames(names)
+}
+
+// NormalizeReceiverNames rewrites receiver references in the routing tree that do not match the name of
+// any receiver exactly, but match a receiver name after normalization, to use the name of that receiver.
+// It returns an error if receiver names collide, as references to them would be ambiguous.
+func (c *PostableApiAlertingConfig) NormalizeReceiverNames() error {
+	if err := c.ValidateReceiverNames(); err != nil {
+		return err
+	}
+	if c.Route == nil {
+		return nil
+	}
+	exact := make(map[string]struct{}, len(c.Receivers))
+	normalized := make(map[string]string, len(c.Receivers))
+	for _, r := range c.Receivers {
+		exact[r.Name] = struct{}{}
+		normalized[NormalizeReceiverName(r.Name)] = r.Name
+	}
+	normalizeRouteReceivers(c.Route, exact, normalized)
+	return nil
+}
+
+func normalizeRouteReceivers(r *Route, exact map[string]struct{}, normalized map[string]string) {
+	if r.Receiver != "" {
+		if _, ok := exact[r.Receiver]; !ok {
+			if name, ok := normalized[NormalizeReceiverName(r.Receiver)]; ok {
+				r.Receiver = name
+			}
+		}
+	}
+	for _, child := range r.Routes {
+		normalizeRouteReceivers(child, exact, normalized)
+	}
+}
+
+// LoadWithNormalizedReceiverNames is like Load, but rejects receiver names that collide after normalization
+// and normalizes the receiver references in the routing tree with NormalizeReceiverNames before the
+// configuration is validated.
+func LoadWithNormalizedReceiverNames(rawCfg []byte) (*PostableApiAlertingConfig, error) {
+	var cfg PostableApiAlertingConfig
+	if err := yaml.Unmarshal(rawCfg, (*normalizingApiAlertingConfig)(&cfg)); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// normalizingApiAlertingConfig normalizes receiver references in the routing tree when unmarshaled.
+type normalizingApiAlertingConfig PostableApiAlertingConfig
+
+func (c *normalizingApiAlertingConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	cfg := (*PostableApiAlertingConfig)(c)
+	if err := cfg.unmarshal(unmarshal); err != nil {
+		return err
+	}
+	if err := cfg.NormalizeReceiverNames(); err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
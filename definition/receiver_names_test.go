@@ -0,0 +1,75 @@
+package definition
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeReceiverName(t *testing.T) {
+	require.Equal(t, "team-a", NormalizeReceiverName("Team-A"))
+	require.Equal(t, "team-a", NormalizeReceiverName("ｔｅａｍ－Ａ"))
+	// "é" as a single code point and as "e" followed by a combining acute accent.
+	require.Equal(t, NormalizeReceiverName("caf\u00e9"), NormalizeReceiverName("cafe\u0301"))
+	require.NotEqual(t, NormalizeReceiverName("team-a"), NormalizeReceiverName("team-b"))
+}
+
+func TestValidateReceiverNames(t *testing.T) {
+	t.Run("no error for distinct names", func(t *testing.T) {
+		require.NoError(t, ValidateReceiverNames([]string{"team-a", "team-b", "ops"}))
+	})
+
+	t.Run("returns a collision error for each colliding name", func(t *testing.T) {
+		err := ValidateReceiverNames([]string{"Team-A", "team-b", "team-a", "ｔｅａｍ－Ａ", "team-b"})
+		require.EqualError(t, err, `receiver name "team-a" collides with receiver name "Team-A", both normalize to "team-a"
+receiver name "ｔｅａｍ－Ａ" collides with receiver name "Team-A", both normalize to "team-a"
+receiver name "team-b" is not unique`)
+
+		var collision ReceiverNameCollisionError
+		require.True(t, errors.As(err, &collision))
+		require.Equal(t, ReceiverNameCollisionError{Name: "team-a", Other: "Team-A", Normalized: "team-a"}, collision)
+	})
+}
+
+func TestLoadWithNormalizedReceiverNames(t *testing.T) {
+	t.Run("rewrites references that match a receiver after normalization", func(t *testing.T) {
+		cfg, err := LoadWithNormalizedReceiverNames([]byte(`
+route:
+  receiver: team-a
+  routes:
+    - receiver: ｔｅａｍ－ｂ
+      matchers:
+        - team = b
+receivers:
+  - name: Team-A
+  - name: team-b
+`))
+		require.NoError(t, err)
+		require.Equal(t, "Team-A", cfg.Route.Receiver)
+		require.Equal(t, "team-b", cfg.Route.Routes[0].Receiver)
+	})
+
+	t.Run("references are not rewritten by Load", func(t *testing.T) {
+		_, err := Load([]byte(`
+route:
+  receiver: team-a
+receivers:
+  - name: Team-A
+`))
+		require.EqualError(t, err, "unexpected receiver (team-a) is undefined")
+	})
+
+	t.Run("fails if receiver names collide", func(t *testing.T) {
+		_, err := LoadWithNormalizedReceiverNames([]byte(`
+route:
+  receiver: Team-A
+receivers:
+  - name: Team-A
+  - name: team-a
+`))
+		var collision ReceiverNameCollisionError
+		require.True(t, errors.As(err, &collision))
+		require.Equal(t, "team-a", collision.Name)
+	})
+}
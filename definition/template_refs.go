@@ -0,0 +1,258 @@
+package definition
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Sources of external templates.
+const (
+	TemplateSourceFile   = "file"
+	TemplateSourceObject = "object"
+	TemplateSourceHTTP   = "http"
+	TemplateSourceHTTPS  = "https"
+)
+
+// maxTemplateSize is the maximum size of an external template.
+const maxTemplateSize = 10 << 20
+
+// TemplateRef is a reference to a template file that is managed outside the configuration, so that large template
+// libraries do not have to be part of it. It is written in the templates of the configuration as
+// <source>://<location>, with an optional #sha256=<hex> suffix that pins the content of the template:
+//
+//   - file://<path> is a file relative to the directory of the file fetcher.
+//   - object://<key> is an object of an object store.
+//   - http://<url> and https://<url> are fetched with HTTP and must be pinned.
+type TemplateRef struct {
+	Source   string
+	Location string
+	// SHA256 is the hex-encoded SHA-256 hash the content of the template must have. It is optional.
+	SHA256 string
+}
+
+func (r TemplateRef) String() string {
+	if r.SHA256 == "" {
+		return r.Source + "://" + r.Location
+	}
+	return r.Source + "://" + r.Location + "#sha256=" + r.SHA256
+}
+
+// IsTemplateRef returns true if the template of the configuration is a reference to an external template.
+func IsTemplateRef(s string) bool {
+	for _, source := range []string{TemplateSourceFile, TemplateSourceObject, TemplateSourceHTTP, TemplateSourceHTTPS} {
+		if strings.HasPrefix(s, source+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTemplateRef parses a reference to an external template.
+func ParseTemplateRef(s string) (TemplateRef, error) {
+	if !IsTemplateRef(s) {
+		return TemplateRef{}, fmt.Errorf("unsupported template reference %q", s)
+	}
+	source, rest, _ := strings.Cut(s, "://")
+	location, fragment, hasFragment := strings.Cut(rest, "#")
+	ref := TemplateRef{Source: source, Location: location}
+	if location == "" {
+		return TemplateRef{}, fmt.Errorf("template reference %q has no location", s)
+	}
+	if hasFragment {
+		hash, ok := strings.CutPrefix(fragment, "sha256=")
+		if !ok {
+			return TemplateRef{}, fmt.Errorf("template reference %q has an invalid fragment, only sha256=<hex> is supported", s)
+		}
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return TemplateRef{}, fmt.Errorf("template reference %q has an invalid SHA-256 hash", s)
+		}
+		ref.SHA256 = strings.ToLower(hash)
+	}
+	if (source == TemplateSourceHTTP || source == TemplateSourceHTTPS) && ref.SHA256 == "" {
+		return TemplateRef{}, fmt.Errorf("template reference %q must pin the SHA-256 hash of the template", s)
+	}
+	return ref, nil
+}
+
+// TemplateFetcher fetches the content of external templates of a source.
+type TemplateFetcher interface {
+	FetchTemplate(ctx context.Context, ref TemplateRef) ([]byte, error)
+}
+
+// FileTemplateFetcher fetches templates from files of a directory.
+type FileTemplateFetcher struct {
+	Dir string
+}
+
+func (f FileTemplateFetcher) FetchTemplate(_ context.Context, ref TemplateRef) ([]byte, error) {
+	path := filepath.Join(f.Dir, filepath.FromSlash(ref.Location))
+	if rel, err := filepath.Rel(f.Dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("template file %q is outside of the template directory", ref.Location)
+	}
+	return os.ReadFile(path)
+}
+
+// HTTPTemplateFetcher fetches templates with HTTP GET requests.
+type HTTPTemplateFetcher struct {
+	// Client is the HTTP client used to fetch templates. If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+func (f HTTPTemplateFetcher) FetchTemplate(ctx context.Context, ref TemplateRef) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref.Source+"://"+ref.Location, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxTemplateSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxTemplateSize {
+		return nil, fmt.Errorf("template is larger than %d bytes", maxTemplateSize)
+	}
+	return b, nil
+}
+
+// ExternalTemplate is the content of an external template.
+type ExternalTemplate struct {
+	// Name is the reference to the template as written in the configuration.
+	Name    string
+	Content string
+	// SHA256 is the hex-encoded SHA-256 hash of the content.
+	SHA256 string
+}
+
+// TemplateResolver resolves the references to external templates with the fetchers of their sources. It remembers
+// the hash of the content of each template it resolved, so that changes of the templates can be detected.
+type TemplateResolver struct {
+	fetchers map[string]TemplateFetcher
+
+	mtx    sync.Mutex
+	hashes map[string]string
+}
+
+// NewTemplateResolver returns a TemplateResolver with the fetchers of each source, such as TemplateSourceFile.
+func NewTemplateResolver(fetchers map[string]TemplateFetcher) *TemplateResolver {
+	return &TemplateResolver{
+		fetchers: fetchers,
+		hashes:   make(map[string]string),
+	}
+}
+
+// Resolve fetches the content of the referenced template. If the reference pins the hash of the template, the
+// content must have the hash.
+func (r *TemplateResolver) Resolve(ctx context.Context, s string) (ExternalTemplate, error) {
+	ref, err := ParseTemplateRef(s)
+	if err != nil {
+		return ExternalTemplate{}, err
+	}
+	t, err := r.fetch(ctx, ref)
+	if err != nil {
+		return ExternalTemplate{}, err
+	}
+	t.Name = s
+	r.mtx.Lock()
+	r.hashes[s] = t.SHA256
+	r.mtx.Unlock()
+	return t, nil
+}
+
+func (r *TemplateResolver) fetch(ctx context.Context, ref TemplateRef) (ExternalTemplate, error) {
+	fetcher, ok := r.fetchers[ref.Source]
+	if !ok {
+		return ExternalTemplate{}, fmt.Errorf("no template fetcher for source %q", ref.Source)
+	}
+	b, err := fetcher.FetchTemplate(ctx, ref)
+	if err != nil {
+		return ExternalTemplate{}, fmt.Errorf("failed to fetch template %s: %w", ref, err)
+	}
+	sum := sha256.Sum256(b)
+	hash := hex.EncodeToString(sum[:])
+	if ref.SHA256 != "" && ref.SHA256 != hash {
+		return ExternalTemplate{}, fmt.Errorf("template %s has SHA-256 hash %s", ref, hash)
+	}
+	return ExternalTemplate{Content: string(b), SHA256: hash}, nil
+}
+
+// ResolveAll resolves the references to external templates of the list of templates of a configuration.
+// Templates that are not references are ignored.
+func (r *TemplateResolver) ResolveAll(ctx context.Context, tmpls []string) ([]ExternalTemplate, error) {
+	var result []ExternalTemplate
+	for _, s := range tmpls {
+		if !IsTemplateRef(s) {
+			continue
+		}
+		t, err := r.Resolve(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// Changed fetches the templates that were resolved and are not pinned, and returns the references of the
+// templates whose content changed since they were resolved. Templates that cannot be fetched are not changed.
+func (r *TemplateResolver) Changed(ctx context.Context) ([]string, error) {
+	r.mtx.Lock()
+	hashes := make(map[string]string, len(r.hashes))
+	for k, v := range r.hashes {
+		hashes[k] = v
+	}
+	r.mtx.Unlock()
+
+	var changed []string
+	var errs []error
+	for s, hash := range hashes {
+		ref, err := ParseTemplateRef(s)
+		if err != nil || ref.SHA256 != "" {
+			continue
+		}
+		t, err := r.fetch(ctx, ref)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if t.SHA256 != hash {
+			changed = append(changed, s)
+		}
+	}
+	sort.Strings(changed)
+	return changed, errors.Join(errs...)
+}
+
+// LoadWithTemplateRefs parses a configuration like Load, and resolves the references to external templates of
+// its templates.
+func LoadWithTemplateRefs(ctx context.Context, rawCfg []byte, r *TemplateResolver) (*PostableApiAlertingConfig, []ExternalTemplate, error) {
+	cfg, err := Load(rawCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpls, err := r.ResolveAll(ctx, cfg.Templates)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, tmpls, nil
+}
@@ -0,0 +1,156 @@
+package definition
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseTemplateRef(t *testing.T) {
+	hash := sha256Hex("test")
+	cases := []struct {
+		name        string
+		ref         string
+		expected    TemplateRef
+		expectedErr string
+	}{
+		{
+			name:     "file",
+			ref:      "file://slack/messages.tmpl",
+			expected: TemplateRef{Source: TemplateSourceFile, Location: "slack/messages.tmpl"},
+		},
+		{
+			name:     "object with hash",
+			ref:      "object://templates/slack.tmpl#sha256=" + strings.ToUpper(hash),
+			expected: TemplateRef{Source: TemplateSourceObject, Location: "templates/slack.tmpl", SHA256: hash},
+		},
+		{
+			name:     "https with hash",
+			ref:      "https://example.com/slack.tmpl#sha256=" + hash,
+			expected: TemplateRef{Source: TemplateSourceHTTPS, Location: "example.com/slack.tmpl", SHA256: hash},
+		},
+		{
+			name:        "https without hash",
+			ref:         "https://example.com/slack.tmpl",
+			expectedErr: `template reference "https://example.com/slack.tmpl" must pin the SHA-256 hash of the template`,
+		},
+		{
+			name:        "invalid hash",
+			ref:         "file://slack.tmpl#sha256=abc",
+			expectedErr: `template reference "file://slack.tmpl#sha256=abc" has an invalid SHA-256 hash`,
+		},
+		{
+			name:        "invalid fragment",
+			ref:         "file://slack.tmpl#md5=abc",
+			expectedErr: `template reference "file://slack.tmpl#md5=abc" has an invalid fragment, only sha256=<hex> is supported`,
+		},
+		{
+			name:        "no location",
+			ref:         "file://",
+			expectedErr: `template reference "file://" has no location`,
+		},
+		{
+			name:        "template name",
+			ref:         "slack",
+			expectedErr: `unsupported template reference "slack"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ref, err := ParseTemplateRef(c.ref)
+			if c.expectedErr != "" {
+				require.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, ref)
+		})
+	}
+}
+
+func TestTemplateResolver(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	write("slack.tmpl", `{{ define "slack" }}v1{{ end }}`)
+
+	const remote = `{{ define "remote" }}remote{{ end }}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(remote))
+	}))
+	t.Cleanup(srv.Close)
+	remoteRef := "http://" + strings.TrimPrefix(srv.URL, "http://") + "/remote.tmpl#sha256=" + sha256Hex(remote)
+
+	r := NewTemplateResolver(map[string]TemplateFetcher{
+		TemplateSourceFile: FileTemplateFetcher{Dir: dir},
+		TemplateSourceHTTP: HTTPTemplateFetcher{},
+	})
+	ctx := context.Background()
+
+	tmpls, err := r.ResolveAll(ctx, []string{"slack", "file://slack.tmpl", remoteRef})
+	require.NoError(t, err)
+	require.Equal(t, []ExternalTemplate{
+		{Name: "file://slack.tmpl", Content: `{{ define "slack" }}v1{{ end }}`, SHA256: sha256Hex(`{{ define "slack" }}v1{{ end }}`)},
+		{Name: remoteRef, Content: remote, SHA256: sha256Hex(remote)},
+	}, tmpls)
+
+	t.Run("changes of templates are detected", func(t *testing.T) {
+		changed, err := r.Changed(ctx)
+		require.NoError(t, err)
+		require.Empty(t, changed)
+
+		write("slack.tmpl", `{{ define "slack" }}v2{{ end }}`)
+		changed, err = r.Changed(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"file://slack.tmpl"}, changed)
+	})
+
+	t.Run("pinned hash must match", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "file://slack.tmpl#sha256="+sha256Hex("other"))
+		require.ErrorContains(t, err, "has SHA-256 hash "+sha256Hex(`{{ define "slack" }}v2{{ end }}`))
+	})
+
+	t.Run("files must be in the directory", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "file://../slack.tmpl")
+		require.ErrorContains(t, err, `template file "../slack.tmpl" is outside of the template directory`)
+	})
+
+	t.Run("sources must have a fetcher", func(t *testing.T) {
+		_, err := r.Resolve(ctx, "object://slack.tmpl")
+		require.EqualError(t, err, `no template fetcher for source "object"`)
+	})
+}
+
+func TestLoadWithTemplateRefs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "slack.tmpl"), []byte(`{{ define "slack" }}{{ end }}`), 0o600))
+	r := NewTemplateResolver(map[string]TemplateFetcher{TemplateSourceFile: FileTemplateFetcher{Dir: dir}})
+
+	cfg, tmpls, err := LoadWithTemplateRefs(context.Background(), []byte(`
+route:
+  receiver: default
+receivers:
+  - name: default
+templates:
+  - local
+  - file://slack.tmpl
+`), r)
+	require.NoError(t, err)
+	require.Equal(t, []string{"local", "file://slack.tmpl"}, cfg.Templates)
+	require.Len(t, tmpls, 1)
+	require.Equal(t, `{{ define "slack" }}{{ end }}`, tmpls[0].Content)
+}
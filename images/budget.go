@@ -0,0 +1,190 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/alerting/logging"
+)
+
+// ResolutionBudget limits the resolution of the images of a notification, so that a slow image store cannot use
+// up the whole notification timeout before the integration is called. Zero values mean no limit.
+type ResolutionBudget struct {
+	// MaxImages is the maximum number of images resolved per notification.
+	MaxImages int
+	// MaxTotalBytes is the maximum total size in bytes of the images resolved per notification. The size of
+	// an image is the size of its file on disk, images that are not on disk do not count towards the limit.
+	MaxTotalBytes int64
+	// MaxDuration is the maximum time spent resolving the images of a notification.
+	MaxDuration time.Duration
+	// Parallelism is the maximum number of images resolved at the same time. Images are resolved one at a time if it is zero.
+	Parallelism int
+}
+
+// Validate returns an error if any of the limits is negative.
+func (b ResolutionBudget) Validate() error {
+	if b.MaxImages < 0 {
+		return errors.New("max images must not be negative")
+	}
+	if b.MaxTotalBytes < 0 {
+		return errors.New("max total bytes must not be negative")
+	}
+	if b.MaxDuration < 0 {
+		return errors.New("max duration must not be negative")
+	}
+	if b.Parallelism < 0 {
+		return errors.New("parallelism must not be negative")
+	}
+	return nil
+}
+
+// IsZero returns true if the budget has no limits and images are resolved one at a time.
+func (b ResolutionBudget) IsZero() bool {
+	return b == ResolutionBudget{}
+}
+
+// ErrResolutionBudgetExhausted is the error for images that are skipped because they do not fit in the ResolutionBudget.
+var ErrResolutionBudgetExhausted = errors.New("image resolution budget exhausted")
+
+type resolutionBudgetKey struct{}
+
+// WithResolutionBudget returns a context with the budget used by WithStoredImages to resolve the images of a notification.
+func WithResolutionBudget(ctx context.Context, b ResolutionBudget) context.Context {
+	return context.WithValue(ctx, resolutionBudgetKey{}, b)
+}
+
+// ResolutionBudgetFromContext returns the budget of the context, if it has one.
+func ResolutionBudgetFromContext(ctx context.Context) (ResolutionBudget, bool) {
+	b, ok := ctx.Value(resolutionBudgetKey{}).(ResolutionBudget)
+	return b, ok
+}
+
+type resolvedImage struct {
+	img  *Image
+	err  error
+	done chan struct{}
+}
+
+// withStoredImagesBudget is WithStoredImages for a context with a resolution budget. Images are resolved
+// in parallel, but forEachFunc is called in the order of the alerts. Once the budget is exhausted, the
+// remaining images are skipped, and passed to fallbackFunc if it is not nil.
+func withStoredImagesBudget(ctx context.Context, l logging.Logger, imageProvider Provider, b ResolutionBudget, forEachFunc forEachImageFunc, fallbackFunc fallbackImageFunc, alerts ...*types.Alert) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if b.MaxDuration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, b.MaxDuration)
+		defer cancel()
+	}
+
+	parallelism := b.Parallelism
+	if parallelism == 0 {
+		parallelism = 1
+	}
+
+	results := make([]resolvedImage, len(alerts))
+	for i := range results {
+		results[i].done = make(chan struct{})
+	}
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism && w < len(alerts); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i].img, results[i].err = resolveImage(ctx, l.New("alert", alerts[i].String()), imageProvider, *alerts[i])
+				close(results[i].done)
+			}
+		}()
+	}
+	go func() {
+		defer close(indices)
+		for i := range alerts {
+			select {
+			case indices <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	// Stop the workers before returning, forEachFunc must not be called after WithStoredImages returns.
+	defer wg.Wait()
+	defer cancel()
+
+	// fallbackFrom passes the alerts with an image token, starting at the index, to fallbackFunc.
+	fallbackFrom := func(from int, err error) {
+		if fallbackFunc == nil {
+			return
+		}
+		for i := from; i < len(alerts); i++ {
+			if getTokenFromAnnotations(alerts[i].Annotations) != "" {
+				fallbackFunc(i, fmt.Errorf("%w: %w", ErrResolutionBudgetExhausted, err))
+			}
+		}
+	}
+
+	var (
+		count int
+		total int64
+	)
+	for index, alert := range alerts {
+		logger := l.New("alert", alert.String())
+		select {
+		case <-results[index].done:
+		case <-ctx.Done():
+			logger.Warn("Image resolution budget exhausted, skipping remaining images", "error", ctx.Err())
+			fallbackFrom(index, ctx.Err())
+			return nil
+		}
+		img, err := results[index].img, results[index].err
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.Warn("Image resolution budget exhausted, skipping remaining images", "error", ctx.Err())
+				fallbackFrom(index, ctx.Err())
+				return nil
+			}
+			if fallbackFunc != nil {
+				fallbackFunc(index, err)
+				continue
+			}
+			if errors.Is(err, ErrImageNotFound) {
+				continue
+			}
+			return err
+		}
+		if img == nil {
+			continue
+		}
+		if b.MaxTotalBytes > 0 && img.Path != "" {
+			if fi, err := os.Stat(img.Path); err == nil {
+				if total+fi.Size() > b.MaxTotalBytes {
+					logger.Debug("Skipping image that exceeds the image resolution budget", "token", img.Token, "size", fi.Size())
+					if fallbackFunc != nil {
+						fallbackFunc(index, fmt.Errorf("%w: image of %d bytes exceeds the remaining size", ErrResolutionBudgetExhausted, fi.Size()))
+					}
+					continue
+				}
+				total += fi.Size()
+			}
+		}
+		if err := forEachFunc(index, *img); err != nil {
+			if errors.Is(err, ErrImagesDone) {
+				return nil
+			}
+			logger.Error("Failed to attach image to notification", "error", err)
+			return err
+		}
+		count++
+		if b.MaxImages > 0 && count >= b.MaxImages {
+			fallbackFrom(index+1, errors.New("maximum number of images reached"))
+			return nil
+		}
+	}
+	return nil
+}
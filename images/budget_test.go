@@ -0,0 +1,113 @@
+package images
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/logging"
+)
+
+// slowProvider is a FakeProvider that waits before returning images and records the maximum number of concurrent calls.
+type slowProvider struct {
+	FakeProvider
+	delay   time.Duration
+	calls   atomic.Int32
+	current atomic.Int32
+	max     atomic.Int32
+}
+
+func (p *slowProvider) GetImage(ctx context.Context, token string) (*Image, error) {
+	p.calls.Add(1)
+	n := p.current.Add(1)
+	defer p.current.Add(-1)
+	for {
+		m := p.max.Load()
+		if n <= m || p.max.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return p.FakeProvider.GetImage(ctx, token)
+}
+
+func TestWithStoredImages_ResolutionBudget(t *testing.T) {
+	dir := t.TempDir()
+	newProvider := func(delay time.Duration) *slowProvider {
+		p := &slowProvider{delay: delay}
+		for _, token := range []string{"a", "b", "c", "d"} {
+			path := filepath.Join(dir, token+".png")
+			require.NoError(t, os.WriteFile(path, make([]byte, 10), 0o600))
+			p.Images = append(p.Images, &Image{Token: token, Path: path})
+		}
+		return p
+	}
+	alerts := []*types.Alert{newAlertWithImage("a"), newAlertWithImage("b"), {}, newAlertWithImage("c"), newAlertWithImage("d")}
+	resolve := func(p Provider, b ResolutionBudget) ([]int, []string) {
+		var (
+			indices []int
+			tokens  []string
+		)
+		ctx := WithResolutionBudget(context.Background(), b)
+		err := WithStoredImages(ctx, &logging.FakeLogger{}, p, func(index int, image Image) error {
+			indices = append(indices, index)
+			tokens = append(tokens, image.Token)
+			return nil
+		}, alerts...)
+		require.NoError(t, err)
+		return indices, tokens
+	}
+
+	t.Run("images are resolved in parallel in the order of the alerts", func(t *testing.T) {
+		p := newProvider(20 * time.Millisecond)
+		indices, tokens := resolve(p, ResolutionBudget{Parallelism: 2})
+		require.Equal(t, []int{0, 1, 3, 4}, indices)
+		require.Equal(t, []string{"a", "b", "c", "d"}, tokens)
+		require.Equal(t, int32(2), p.max.Load())
+	})
+
+	t.Run("images are resolved one at a time without parallelism", func(t *testing.T) {
+		p := newProvider(time.Millisecond)
+		_, tokens := resolve(p, ResolutionBudget{})
+		require.Equal(t, []string{"a", "b", "c", "d"}, tokens)
+		require.Equal(t, int32(1), p.max.Load())
+	})
+
+	t.Run("stops at the max number of images", func(t *testing.T) {
+		p := newProvider(0)
+		_, tokens := resolve(p, ResolutionBudget{MaxImages: 2})
+		require.Equal(t, []string{"a", "b"}, tokens)
+	})
+
+	t.Run("skips images over the max total bytes", func(t *testing.T) {
+		p := newProvider(0)
+		_, tokens := resolve(p, ResolutionBudget{MaxTotalBytes: 25})
+		require.Equal(t, []string{"a", "b"}, tokens)
+	})
+
+	t.Run("skips the remaining images after the max duration", func(t *testing.T) {
+		p := newProvider(30 * time.Millisecond)
+		start := time.Now()
+		_, tokens := resolve(p, ResolutionBudget{MaxDuration: 50 * time.Millisecond})
+		require.Equal(t, []string{"a"}, tokens)
+		require.Less(t, time.Since(start), ProviderTimeout)
+	})
+}
+
+func TestResolutionBudget_Validate(t *testing.T) {
+	require.NoError(t, ResolutionBudget{}.Validate())
+	require.EqualError(t, ResolutionBudget{MaxImages: -1}.Validate(), "max images must not be negative")
+	require.EqualError(t, ResolutionBudget{MaxTotalBytes: -1}.Validate(), "max total bytes must not be negative")
+	require.EqualError(t, ResolutionBudget{MaxDuration: -1}.Validate(), "max duration must not be negative")
+	require.EqualError(t, ResolutionBudget{Parallelism: -1}.Validate(), "parallelism must not be negative")
+}
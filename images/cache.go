@@ -0,0 +1,143 @@
+package images
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"golang.org/x/sync/singleflight"
+)
+
+// CachedProvider is a Provider that caches the raw data of images returned by another Provider,
+// so that an image referenced by many alerts or integrations is only fetched once. Entries are
+// keyed by the image token, expire after the TTL, and the least recently used entries are evicted
+// when the total size of the cached images exceeds the maximum size.
+type CachedProvider struct {
+	provider Provider
+	maxSize  int64
+	ttl      time.Duration
+	now      func() time.Time
+	group    singleflight.Group
+
+	mtx     sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type cachedImage struct {
+	token     string
+	filename  string
+	data      []byte
+	expiresAt time.Time
+}
+
+// NewCachedProvider returns a CachedProvider that caches up to maxSize bytes of images returned
+// by provider for the duration of the ttl.
+func NewCachedProvider(provider Provider, maxSize int64, ttl time.Duration) *CachedProvider {
+	return &CachedProvider{
+		provider: provider,
+		maxSize:  maxSize,
+		ttl:      ttl,
+		now:      time.Now,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// GetImage returns the image with the corresponding token from the underlying provider.
+func (c *CachedProvider) GetImage(ctx context.Context, token string) (*Image, error) {
+	return c.provider.GetImage(ctx, token)
+}
+
+// GetImageURL returns the URL of the image associated with a given alert from the underlying provider.
+func (c *CachedProvider) GetImageURL(ctx context.Context, alert *types.Alert) (string, error) {
+	return c.provider.GetImageURL(ctx, alert)
+}
+
+// GetRawImage returns an io.Reader to read the bytes of the image associated with a given alert.
+// The bytes are read from the cache if present, otherwise they are fetched from the underlying provider
+// and cached. Concurrent requests for the same image are fetched from the underlying provider once.
+func (c *CachedProvider) GetRawImage(ctx context.Context, alert *types.Alert) (io.ReadCloser, string, error) {
+	token, err := getImageURI(alert)
+	if err != nil {
+		return c.provider.GetRawImage(ctx, alert)
+	}
+
+	if img, ok := c.get(token); ok {
+		return io.NopCloser(bytes.NewReader(img.data)), img.filename, nil
+	}
+
+	v, err, _ := c.group.Do(token, func() (interface{}, error) {
+		r, filename, err := c.provider.GetRawImage(ctx, alert)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = r.Close()
+		}()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image: %w", err)
+		}
+		img := &cachedImage{token: token, filename: filename, data: data, expiresAt: c.now().Add(c.ttl)}
+		c.add(img)
+		return img, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	img := v.(*cachedImage)
+	return io.NopCloser(bytes.NewReader(img.data)), img.filename, nil
+}
+
+// Size returns the total size in bytes of the cached images.
+func (c *CachedProvider) Size() int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.size
+}
+
+func (c *CachedProvider) get(token string) (*cachedImage, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	e, ok := c.entries[token]
+	if !ok {
+		return nil, false
+	}
+	img := e.Value.(*cachedImage)
+	if !c.now().Before(img.expiresAt) {
+		c.remove(e)
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return img, true
+}
+
+func (c *CachedProvider) add(img *cachedImage) {
+	size := int64(len(img.data))
+	if size > c.maxSize {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if e, ok := c.entries[img.token]; ok {
+		c.remove(e)
+	}
+	for c.size+size > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+	c.entries[img.token] = c.lru.PushFront(img)
+	c.size += size
+}
+
+func (c *CachedProvider) remove(e *list.Element) {
+	img := c.lru.Remove(e).(*cachedImage)
+	delete(c.entries, img.token)
+	c.size -= int64(len(img.data))
+}
@@ -0,0 +1,124 @@
+package images
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/models"
+)
+
+type countingProvider struct {
+	*FakeProvider
+	calls int
+}
+
+func (p *countingProvider) GetRawImage(ctx context.Context, alert *types.Alert) (io.ReadCloser, string, error) {
+	p.calls++
+	return p.FakeProvider.GetRawImage(ctx, alert)
+}
+
+func newAlertWithImage(token string) *types.Alert {
+	return &types.Alert{
+		Alert: model.Alert{
+			Annotations: model.LabelSet{models.ImageTokenAnnotation: model.LabelValue(token)},
+		},
+	}
+}
+
+func readRawImage(t *testing.T, p Provider, alert *types.Alert) (string, string) {
+	t.Helper()
+	r, filename, err := p.GetRawImage(context.Background(), alert)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, r.Close())
+	}()
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(b), filename
+}
+
+func TestCachedProvider(t *testing.T) {
+	newProvider := func() *countingProvider {
+		return &countingProvider{FakeProvider: &FakeProvider{
+			Images: []*Image{
+				{Token: "test-image-1", Path: "/tmp/test-image-1.png"},
+				{Token: "test-image-2", Path: "/tmp/test-image-2.png"},
+			},
+			Bytes: []byte("image"),
+		}}
+	}
+
+	t.Run("images are fetched once", func(t *testing.T) {
+		provider := newProvider()
+		cache := NewCachedProvider(provider, 100, time.Minute)
+
+		for i := 0; i < 3; i++ {
+			data, filename := readRawImage(t, cache, newAlertWithImage("test-image-1"))
+			require.Equal(t, "image", data)
+			require.Equal(t, "test-image-1.png", filename)
+		}
+		require.Equal(t, 1, provider.calls)
+		require.Equal(t, int64(5), cache.Size())
+	})
+
+	t.Run("images are fetched again after the ttl", func(t *testing.T) {
+		provider := newProvider()
+		cache := NewCachedProvider(provider, 100, time.Minute)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		readRawImage(t, cache, newAlertWithImage("test-image-1"))
+		now = now.Add(30 * time.Second)
+		readRawImage(t, cache, newAlertWithImage("test-image-1"))
+		require.Equal(t, 1, provider.calls)
+
+		now = now.Add(time.Minute)
+		readRawImage(t, cache, newAlertWithImage("test-image-1"))
+		require.Equal(t, 2, provider.calls)
+	})
+
+	t.Run("least recently used images are evicted when the cache is full", func(t *testing.T) {
+		provider := newProvider()
+		cache := NewCachedProvider(provider, 5, time.Minute)
+
+		readRawImage(t, cache, newAlertWithImage("test-image-1"))
+		readRawImage(t, cache, newAlertWithImage("test-image-2"))
+		require.Equal(t, int64(5), cache.Size())
+		require.Equal(t, 2, provider.calls)
+
+		readRawImage(t, cache, newAlertWithImage("test-image-2"))
+		require.Equal(t, 2, provider.calls)
+		readRawImage(t, cache, newAlertWithImage("test-image-1"))
+		require.Equal(t, 3, provider.calls)
+	})
+
+	t.Run("images larger than the cache are not cached", func(t *testing.T) {
+		provider := newProvider()
+		cache := NewCachedProvider(provider, 4, time.Minute)
+
+		readRawImage(t, cache, newAlertWithImage("test-image-1"))
+		readRawImage(t, cache, newAlertWithImage("test-image-1"))
+		require.Equal(t, 2, provider.calls)
+		require.Equal(t, int64(0), cache.Size())
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		provider := newProvider()
+		cache := NewCachedProvider(provider, 100, time.Minute)
+
+		_, _, err := cache.GetRawImage(context.Background(), newAlertWithImage("unknown"))
+		require.ErrorIs(t, err, ErrImageNotFound)
+		_, _, err = cache.GetRawImage(context.Background(), newAlertWithImage("unknown"))
+		require.ErrorIs(t, err, ErrImageNotFound)
+		require.Equal(t, 2, provider.calls)
+
+		_, _, err = cache.GetRawImage(context.Background(), &types.Alert{})
+		require.ErrorIs(t, err, ErrNoImageForAlert)
+	})
+}
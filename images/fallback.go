@@ -0,0 +1,68 @@
+package images
+
+import (
+	"context"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Reasons of image fallbacks.
+const (
+	FallbackReasonNotFound = "not_found"
+	FallbackReasonTimeout  = "timeout"
+	FallbackReasonBudget   = "budget"
+	FallbackReasonUpload   = "upload_failed"
+	FallbackReasonError    = "error"
+)
+
+// FallbackMetrics are the metrics of the images that integrations could not include in notifications, and
+// replaced with a link to the dashboard of the alert.
+type FallbackMetrics struct {
+	// Fallbacks is the number of images replaced with a link by integration and reason.
+	Fallbacks *prometheus.CounterVec
+}
+
+// NewFallbackMetrics creates the metrics of the images replaced with a link.
+func NewFallbackMetrics(r prometheus.Registerer) *FallbackMetrics {
+	return &FallbackMetrics{
+		Fallbacks: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "image_fallbacks_total",
+			Help:      "Number of alert images that could not be resolved or uploaded and were replaced with a link to the dashboard, by integration and reason.",
+		}, []string{"integration", "reason"}),
+	}
+}
+
+type fallbackMetricsKey struct{}
+
+// WithFallbackMetrics returns a context with the metrics updated by RecordFallback.
+func WithFallbackMetrics(ctx context.Context, m *FallbackMetrics) context.Context {
+	return context.WithValue(ctx, fallbackMetricsKey{}, m)
+}
+
+// FallbackReason returns the reason of the error of an image that could not be resolved.
+func FallbackReason(err error) string {
+	switch {
+	case errors.Is(err, ErrImageNotFound):
+		return FallbackReasonNotFound
+	case errors.Is(err, ErrResolutionBudgetExhausted):
+		return FallbackReasonBudget
+	case errors.Is(err, ErrImageUploadFailed):
+		return FallbackReasonUpload
+	case errors.Is(err, context.DeadlineExceeded):
+		return FallbackReasonTimeout
+	default:
+		return FallbackReasonError
+	}
+}
+
+// RecordFallback records in the metrics of the context that the integration replaced an image that could not
+// be resolved with a link. It does nothing if the context has no metrics.
+func RecordFallback(ctx context.Context, integration string, err error) {
+	if m, ok := ctx.Value(fallbackMetricsKey{}).(*FallbackMetrics); ok && m != nil {
+		m.Fallbacks.WithLabelValues(integration, FallbackReason(err)).Inc()
+	}
+}
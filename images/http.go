@@ -0,0 +1,246 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/alerting/logging"
+)
+
+// ErrImageTooLarge is returned when an image is larger than the maximum size allowed.
+var ErrImageTooLarge = errors.New("image is too large")
+
+// URLSigner generates signed URLs to images that expire at the given time.
+type URLSigner interface {
+	SignURL(token string, expiresAt time.Time) (string, error)
+}
+
+// HMACURLSigner signs URLs with an HMAC-SHA256 of the token and the expiry time. The signed URL is the
+// base URL followed by the token, with the expiry time as a Unix timestamp in the "expires" query parameter
+// and the hex encoded signature in the "signature" query parameter.
+type HMACURLSigner struct {
+	baseURL *url.URL
+	key     []byte
+}
+
+// NewHMACURLSigner returns a HMACURLSigner for images served under baseURL.
+func NewHMACURLSigner(baseURL string, key []byte) (*HMACURLSigner, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	if len(key) == 0 {
+		return nil, errors.New("signing key must not be empty")
+	}
+	return &HMACURLSigner{baseURL: u, key: key}, nil
+}
+
+// SignURL returns the signed URL of the image.
+func (s *HMACURLSigner) SignURL(token string, expiresAt time.Time) (string, error) {
+	if token == "" {
+		return "", errors.New("token must not be empty")
+	}
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	u := *s.baseURL
+	u.Path = path.Join(u.Path, token)
+	q := u.Query()
+	q.Set("expires", expires)
+	q.Set("signature", s.signature(token, expires))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Verify returns true if the signature is valid for the token and the expiry time, and the URL has not expired.
+func (s *HMACURLSigner) Verify(token, expires, signature string, now time.Time) bool {
+	ts, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !now.Before(time.Unix(ts, 0)) {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.signature(token, expires)))
+}
+
+func (s *HMACURLSigner) signature(token, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(token))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HTTPProviderConfig is the configuration of a HTTPProvider.
+type HTTPProviderConfig struct {
+	// Signer generates the URLs of the images.
+	Signer URLSigner
+	// Client is the HTTP client used to fetch images. If nil, http.DefaultClient is used.
+	Client *http.Client
+	// URLTTL is for how long the signed URLs are valid.
+	URLTTL time.Duration
+	// MaxSize is the maximum size in bytes of an image. Zero means no limit.
+	MaxSize int64
+	// MaxRetries is the number of times fetching an image is retried after a failed attempt.
+	MaxRetries int
+	// RetryBackoff is the time to wait between attempts.
+	RetryBackoff time.Duration
+}
+
+// HTTPProvider is a Provider that fetches images from a remote image service using signed, expiring URLs.
+// It can be used when the images are not accessible from local storage, such as in a remote Alertmanager.
+type HTTPProvider struct {
+	cfg    HTTPProviderConfig
+	client *http.Client
+	log    logging.Logger
+	now    func() time.Time
+}
+
+// NewHTTPProvider returns a new HTTPProvider.
+func NewHTTPProvider(cfg HTTPProviderConfig, l logging.Logger) (*HTTPProvider, error) {
+	if cfg.Signer == nil {
+		return nil, errors.New("URL signer must be set")
+	}
+	if cfg.URLTTL <= 0 {
+		return nil, errors.New("URL TTL must be positive")
+	}
+	if cfg.MaxSize < 0 {
+		return nil, errors.New("max size must not be negative")
+	}
+	if cfg.MaxRetries < 0 {
+		return nil, errors.New("max retries must not be negative")
+	}
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPProvider{
+		cfg:    cfg,
+		client: client,
+		log:    l,
+		now:    time.Now,
+	}, nil
+}
+
+// GetImage returns an image with the token and a signed URL. Whether the image exists is not checked.
+func (p *HTTPProvider) GetImage(_ context.Context, token string) (*Image, error) {
+	u, err := p.cfg.Signer.SignURL(token, p.now().Add(p.cfg.URLTTL))
+	if err != nil {
+		return nil, err
+	}
+	return &Image{Token: token, URL: u}, nil
+}
+
+// GetImageURL returns a signed URL of the image associated with a given alert.
+func (p *HTTPProvider) GetImageURL(_ context.Context, alert *types.Alert) (string, error) {
+	token, err := getImageURI(alert)
+	if err != nil {
+		return "", err
+	}
+	return p.cfg.Signer.SignURL(token, p.now().Add(p.cfg.URLTTL))
+}
+
+// GetRawImage fetches the image associated with a given alert from the remote image service.
+//   - Returns `ErrImageNotFound` if the image service responds with 404 Not Found.
+//   - Returns `ErrImageTooLarge` if the image is larger than the maximum size.
+//
+// Failed requests are retried, except when the image does not exist or is too large.
+func (p *HTTPProvider) GetRawImage(ctx context.Context, alert *types.Alert) (io.ReadCloser, string, error) {
+	token, err := getImageURI(alert)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var (
+		data        []byte
+		contentType string
+	)
+	for attempt := 0; ; attempt++ {
+		data, contentType, err = p.fetch(ctx, token)
+		if err == nil {
+			break
+		}
+		if errors.Is(err, ErrImageNotFound) || errors.Is(err, ErrImageTooLarge) || attempt >= p.cfg.MaxRetries {
+			return nil, "", err
+		}
+		// Do not wait to retry if the notification would time out before the next attempt.
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < p.cfg.RetryBackoff {
+			return nil, "", err
+		}
+		p.log.Debug("failed to fetch image, retrying", "token", token, "attempt", attempt+1, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-time.After(p.cfg.RetryBackoff):
+		}
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), imageFilename(token, contentType), nil
+}
+
+func (p *HTTPProvider) fetch(ctx context.Context, token string) ([]byte, string, error) {
+	u, err := p.cfg.Signer.SignURL(token, p.now().Add(p.cfg.URLTTL))
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			p.log.Warn("failed to close response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", ErrImageNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	if p.cfg.MaxSize > 0 && resp.ContentLength > p.cfg.MaxSize {
+		return nil, "", ErrImageTooLarge
+	}
+
+	r := io.Reader(resp.Body)
+	if p.cfg.MaxSize > 0 {
+		r = io.LimitReader(resp.Body, p.cfg.MaxSize+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if p.cfg.MaxSize > 0 && int64(len(data)) > p.cfg.MaxSize {
+		return nil, "", ErrImageTooLarge
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// imageFilename returns the filename of the image from its token and content type.
+func imageFilename(token, contentType string) string {
+	name := path.Base(token)
+	if path.Ext(name) != "" {
+		return name
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+			return name + exts[0]
+		}
+	}
+	return name + ".png"
+}
@@ -0,0 +1,149 @@
+package images
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/logging"
+)
+
+func TestHMACURLSigner(t *testing.T) {
+	s, err := NewHMACURLSigner("https://images.example.com/render", []byte("secret"))
+	require.NoError(t, err)
+
+	expiresAt := time.Unix(1700000000, 0)
+	u, err := s.SignURL("test-image", expiresAt)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(u, "https://images.example.com/render/test-image?expires=1700000000&signature="))
+
+	signature := u[strings.Index(u, "signature=")+len("signature="):]
+	require.True(t, s.Verify("test-image", "1700000000", signature, expiresAt.Add(-time.Second)))
+	require.False(t, s.Verify("test-image", "1700000000", signature, expiresAt))
+	require.False(t, s.Verify("other-image", "1700000000", signature, expiresAt.Add(-time.Second)))
+	require.False(t, s.Verify("test-image", "1700000001", signature, expiresAt.Add(-time.Second)))
+
+	_, err = NewHMACURLSigner("https://images.example.com", nil)
+	require.EqualError(t, err, "signing key must not be empty")
+}
+
+func TestHTTPProvider(t *testing.T) {
+	var (
+		failures atomic.Int32
+		requests atomic.Int32
+		signer   *HMACURLSigner
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		token := strings.TrimPrefix(r.URL.Path, "/")
+		if !signer.Verify(token, r.URL.Query().Get("expires"), r.URL.Query().Get("signature"), time.Now()) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if failures.Load() > 0 {
+			failures.Add(-1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch token {
+		case "test-image":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("image"))
+		case "large-image":
+			_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	signer, err := NewHMACURLSigner(server.URL, []byte("secret"))
+	require.NoError(t, err)
+
+	newProvider := func(t *testing.T) *HTTPProvider {
+		p, err := NewHTTPProvider(HTTPProviderConfig{
+			Signer:       signer,
+			URLTTL:       time.Minute,
+			MaxSize:      10,
+			MaxRetries:   2,
+			RetryBackoff: time.Millisecond,
+		}, &logging.FakeLogger{})
+		require.NoError(t, err)
+		return p
+	}
+	getRawImage := func(p *HTTPProvider, token string) (string, string, error) {
+		r, filename, err := p.GetRawImage(context.Background(), newAlertWithImage(token))
+		if err != nil {
+			return "", "", err
+		}
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return string(b), filename, r.Close()
+	}
+
+	t.Run("fetches the image", func(t *testing.T) {
+		data, filename, err := getRawImage(newProvider(t), "test-image")
+		require.NoError(t, err)
+		require.Equal(t, "image", data)
+		require.Equal(t, "test-image.png", filename)
+	})
+
+	t.Run("retries failed requests", func(t *testing.T) {
+		requests.Store(0)
+		failures.Store(2)
+		data, _, err := getRawImage(newProvider(t), "test-image")
+		require.NoError(t, err)
+		require.Equal(t, "image", data)
+		require.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("gives up after the max retries", func(t *testing.T) {
+		requests.Store(0)
+		failures.Store(3)
+		_, _, err := getRawImage(newProvider(t), "test-image")
+		require.EqualError(t, err, "unexpected status code 503")
+		require.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("does not retry if the notification would time out before the next attempt", func(t *testing.T) {
+		requests.Store(0)
+		failures.Store(1)
+		p := newProvider(t)
+		p.cfg.RetryBackoff = time.Minute
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, _, err := p.GetRawImage(ctx, newAlertWithImage("test-image"))
+		require.EqualError(t, err, "unexpected status code 503")
+		require.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("does not retry if the image does not exist", func(t *testing.T) {
+		requests.Store(0)
+		_, _, err := getRawImage(newProvider(t), "unknown-image")
+		require.ErrorIs(t, err, ErrImageNotFound)
+		require.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("fails if the image is too large", func(t *testing.T) {
+		requests.Store(0)
+		_, _, err := getRawImage(newProvider(t), "large-image")
+		require.ErrorIs(t, err, ErrImageTooLarge)
+		require.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("returns signed URLs", func(t *testing.T) {
+		u, err := newProvider(t).GetImageURL(context.Background(), newAlertWithImage("test-image"))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(u, server.URL+"/test-image?expires="))
+
+		_, err = newProvider(t).GetImageURL(context.Background(), &types.Alert{})
+		require.ErrorIs(t, err, ErrNoImageForAlert)
+	})
+}
@@ -27,6 +27,10 @@ var (
 
 	// ErrNoImageForAlert is returned when no image is associated to a given alert.
 	ErrNoImageForAlert = errors.New("no image for alert")
+
+	// ErrImageUploadFailed is wrapped by integrations in the errors of images that were resolved but could not be
+	// uploaded, and are replaced with a link instead.
+	ErrImageUploadFailed = errors.New("failed to upload image")
 )
 
 type Image struct {
@@ -61,3 +61,43 @@ func TestWithStoredImages(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 1, i)
 }
+
+func TestWithStoredImagesOrFallback(t *testing.T) {
+	ctx := context.Background()
+	newAlert := func(token string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Annotations: model.LabelSet{models.ImageTokenAnnotation: model.LabelValue(token)}}}
+	}
+	alerts := []*types.Alert{newAlert("test-image-1"), {}, newAlert("missing"), newAlert("test-image-2")}
+	imageProvider := NewFakeProvider(2)
+
+	var (
+		indices   []int
+		fallbacks []int
+	)
+	err := WithStoredImagesOrFallback(ctx, &logging.FakeLogger{}, imageProvider, func(index int, _ Image) error {
+		indices = append(indices, index)
+		return nil
+	}, func(index int, err error) {
+		require.ErrorIs(t, err, ErrImageNotFound)
+		require.Equal(t, FallbackReasonNotFound, FallbackReason(err))
+		fallbacks = append(fallbacks, index)
+	}, alerts...)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 3}, indices)
+	assert.Equal(t, []int{2}, fallbacks)
+
+	t.Run("images that do not fit in the budget fall back", func(t *testing.T) {
+		ctx := WithResolutionBudget(ctx, ResolutionBudget{MaxImages: 1})
+		indices, fallbacks = nil, nil
+		err := WithStoredImagesOrFallback(ctx, &logging.FakeLogger{}, imageProvider, func(index int, _ Image) error {
+			indices = append(indices, index)
+			return nil
+		}, func(index int, err error) {
+			require.Equal(t, FallbackReasonBudget, FallbackReason(err))
+			fallbacks = append(fallbacks, index)
+		}, alerts...)
+		require.NoError(t, err)
+		assert.Equal(t, []int{0}, indices)
+		assert.Equal(t, []int{2, 3}, fallbacks)
+	})
+}
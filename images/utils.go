@@ -7,6 +7,8 @@ import (
 
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/common/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 
 	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/models"
@@ -17,24 +19,39 @@ const (
 	ProviderTimeout = 500 * time.Millisecond
 )
 
+var tracer = otel.Tracer("github.com/grafana/alerting/images")
+
 type forEachImageFunc func(index int, image Image) error
 
-// getImage returns the image for the alert or an error. It returns a nil
-// image if the alert does not have an image token or the image does not exist.
+// fallbackImageFunc is called for an alert whose image could not be resolved.
+type fallbackImageFunc func(index int, err error)
+
+// resolveImage returns the image for the alert or an error. It returns a nil
+// image if the alert does not have an image token or images are unavailable,
+// and ErrImageNotFound if the image does not exist.
 //
 //nolint:revive
-func getImage(ctx context.Context, l logging.Logger, imageProvider Provider, alert types.Alert) (*Image, error) {
+func resolveImage(ctx context.Context, l logging.Logger, imageProvider Provider, alert types.Alert) (*Image, error) {
 	token := getTokenFromAnnotations(alert.Annotations)
 	if token == "" {
 		return nil, nil
 	}
 
+	ctx, span := tracer.Start(ctx, "images.GetImage")
+	defer span.End()
+
 	ctx, cancelFunc := context.WithTimeout(ctx, ProviderTimeout)
 	defer cancelFunc()
 
 	img, err := imageProvider.GetImage(ctx, token)
-	if errors.Is(err, ErrImageNotFound) || errors.Is(err, ErrImagesUnavailable) {
+	if err != nil && !errors.Is(err, ErrImagesUnavailable) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if errors.Is(err, ErrImagesUnavailable) {
 		return nil, nil
+	} else if errors.Is(err, ErrImageNotFound) {
+		return nil, err
 	} else if err != nil {
 		l.Warn("failed to get image with token", "token", token, "error", err)
 		return nil, err
@@ -50,11 +67,37 @@ func getImage(ctx context.Context, l logging.Logger, imageProvider Provider, ale
 // the error and not iterate the remaining alerts. A forEachFunc can return ErrImagesDone
 // to stop the iteration of remaining alerts if the intended image or maximum number of
 // images have been found.
+//
+// If the context has a ResolutionBudget, images are resolved within the limits of the budget
+// and the images that do not fit in it are skipped.
 func WithStoredImages(ctx context.Context, l logging.Logger, imageProvider Provider, forEachFunc forEachImageFunc, alerts ...*types.Alert) error {
+	return withStoredImages(ctx, l, imageProvider, forEachFunc, nil, alerts...)
+}
+
+// WithStoredImagesOrFallback is like WithStoredImages, but instead of skipping the alerts that have an image
+// token whose image could not be resolved, it calls fallbackFunc with the index of the alert and the error,
+// and continues with the remaining alerts. The error is ErrImageNotFound if the image does not exist, and
+// wraps ErrResolutionBudgetExhausted if the image was skipped because of the ResolutionBudget of the context.
+// Integrations use it to link to the dashboard of the alert in place of its image.
+func WithStoredImagesOrFallback(ctx context.Context, l logging.Logger, imageProvider Provider, forEachFunc forEachImageFunc, fallbackFunc fallbackImageFunc, alerts ...*types.Alert) error {
+	return withStoredImages(ctx, l, imageProvider, forEachFunc, fallbackFunc, alerts...)
+}
+
+func withStoredImages(ctx context.Context, l logging.Logger, imageProvider Provider, forEachFunc forEachImageFunc, fallbackFunc fallbackImageFunc, alerts ...*types.Alert) error {
+	if b, ok := ResolutionBudgetFromContext(ctx); ok {
+		return withStoredImagesBudget(ctx, l, imageProvider, b, forEachFunc, fallbackFunc, alerts...)
+	}
 	for index, alert := range alerts {
 		logger := l.New("alert", alert.String())
-		img, err := getImage(ctx, logger, imageProvider, *alert)
+		img, err := resolveImage(ctx, logger, imageProvider, *alert)
 		if err != nil {
+			if fallbackFunc != nil {
+				fallbackFunc(index, err)
+				continue
+			}
+			if errors.Is(err, ErrImageNotFound) {
+				continue
+			}
 			return err
 		} else if img != nil {
 			if err := forEachFunc(index, *img); err != nil {
@@ -0,0 +1,12 @@
+//go:build integration
+
+// Package integrationtests contains end-to-end tests that send notifications with the real notifiers to the
+// services started by docker-compose.yml, and verify what the services received. They are a module of their own,
+// which uses the parent module through a replace directive, so that `go test ./...` in the parent module does not
+// build them. The package is only built with the integration build tag:
+//
+//	docker compose -f integrationtests/docker-compose.yml up -d --wait
+//	cd integrationtests && go test -tags integration -count 1 ./...
+//
+// The addresses of the services can be changed with environment variables, see env.go.
+package integrationtests
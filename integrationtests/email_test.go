@@ -0,0 +1,63 @@
+//go:build integration
+
+package integrationtests
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/email"
+)
+
+// mailHogMessages is the response of the search API of MailHog.
+type mailHogMessages struct {
+	Total int `json:"total"`
+	Items []struct {
+		Content struct {
+			Headers map[string][]string `json:"Headers"`
+			Body    string              `json:"Body"`
+		} `json:"Content"`
+	} `json:"items"`
+}
+
+func TestEmail(t *testing.T) {
+	to := uniqueName(t) + "@example.com"
+	cfg, err := email.NewConfig(json.RawMessage(`{
+		"addresses": "` + to + `",
+		"subject": "{{ .CommonLabels.alertname }}"
+	}`))
+	require.NoError(t, err)
+	sender, err := receivers.NewEmailSenderFactory(receivers.EmailSenderConfig{
+		ContentTypes:   []string{"text/html"},
+		ExternalURL:    "http://localhost:3000",
+		FromAddress:    "alerting@example.com",
+		FromName:       "Grafana",
+		Host:           SMTPAddress,
+		StartTLSPolicy: "NoStartTLS",
+	})(receivers.Metadata{UID: t.Name()})
+	require.NoError(t, err)
+	n := email.New(cfg, receivers.Metadata{UID: t.Name()}, newTemplate(t), sender, &images.UnavailableProvider{}, &logging.FakeLogger{})
+
+	ok, err := n.Notify(notifyContext(t), firingAlert(t))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var msgs mailHogMessages
+	require.Eventually(t, func() bool {
+		getJSON(t, MailHogURL+"/api/v2/search?kind=to&query="+url.QueryEscape(to), &msgs)
+		return msgs.Total > 0
+	}, waitFor, waitFor/100)
+	require.Len(t, msgs.Items, 1)
+	headers := msgs.Items[0].Content.Headers
+	assert.Equal(t, []string{t.Name()}, headers["Subject"])
+	assert.Equal(t, []string{to}, headers["To"])
+	assert.Contains(t, headers["From"][0], "alerting@example.com")
+	assert.Contains(t, headers["Content-Type"][0], "multipart/")
+}
@@ -0,0 +1,22 @@
+//go:build integration
+
+package integrationtests
+
+import "os"
+
+// The environment variables with the addresses of the services, and their defaults for the ports published by
+// docker-compose.yml.
+var (
+	SMTPAddress   = envOrDefault("INTEGRATION_SMTP_ADDRESS", "localhost:1025")
+	MailHogURL    = envOrDefault("INTEGRATION_MAILHOG_URL", "http://localhost:8025")
+	MQTTBrokerURL = envOrDefault("INTEGRATION_MQTT_BROKER_URL", "tcp://localhost:1883")
+	KafkaRestURL  = envOrDefault("INTEGRATION_KAFKA_REST_URL", "http://localhost:8082")
+	FakeServerURL = envOrDefault("INTEGRATION_FAKE_SERVER_URL", "http://localhost:8080")
+)
+
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
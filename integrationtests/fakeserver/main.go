@@ -0,0 +1,81 @@
+// Command fakeserver records the HTTP requests it receives, so that the integration tests can verify what the
+// notifiers sent. It is the echo server of webhooks, and fakes the APIs of Slack and PagerDuty:
+//   - /slack/... responds like a Slack incoming webhook.
+//   - /pagerduty/... responds like the PagerDuty events API.
+//   - GET /_requests?path=... returns the requests received on the path.
+//   - Any other path responds with the body of the request.
+//
+// It only uses the standard library, so that it can be built without the dependencies of the module.
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+type request struct {
+	Method  string      `json:"method"`
+	Path    string      `json:"path"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body"`
+}
+
+type server struct {
+	mtx      sync.Mutex
+	requests map[string][]request
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/_requests" {
+		s.mtx.Lock()
+		reqs := s.requests[r.URL.Query().Get("path")]
+		s.mtx.Unlock()
+		if reqs == nil {
+			reqs = []request{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(reqs)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mtx.Lock()
+	s.requests[r.URL.Path] = append(s.requests[r.URL.Path], request{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Headers: r.Header,
+		Body:    string(body),
+	})
+	s.mtx.Unlock()
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/slack/"):
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("ok"))
+	case strings.HasPrefix(r.URL.Path, "/pagerduty/"):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"success","message":"Event processed","dedup_key":"fake"}`))
+	default:
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		_, _ = w.Write(body)
+	}
+}
+
+func main() {
+	addr := ":8080"
+	if v := os.Getenv("LISTEN_ADDRESS"); v != "" {
+		addr = v
+	}
+	log.Printf("listening on %s", addr)
+	log.Fatal(http.ListenAndServe(addr, &server{requests: make(map[string][]request)}))
+}
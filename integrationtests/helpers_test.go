@@ -0,0 +1,144 @@
+//go:build integration
+
+package integrationtests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+// waitFor is how long the tests wait for the services to receive the notifications.
+const waitFor = 30 * time.Second
+
+// httpSender is the WebhookSender of the tests. It sends the requests like the WebhookSender of Grafana does.
+type httpSender struct{}
+
+func (httpSender) SendWebhook(ctx context.Context, cmd *receivers.SendWebhookSettings) error {
+	method := cmd.HTTPMethod
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, cmd.URL, strings.NewReader(cmd.Body))
+	if err != nil {
+		return err
+	}
+	if cmd.ContentType == "" {
+		cmd.ContentType = "application/json"
+	}
+	req.Header.Set("Content-Type", cmd.ContentType)
+	req.Header.Set("User-Agent", "Grafana")
+	if cmd.User != "" && cmd.Password != "" {
+		req.SetBasicAuth(cmd.User, cmd.Password)
+	}
+	for k, v := range cmd.HTTPHeader {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := receivers.NewTLSClient(cmd.TLSConfig).Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	body = receivers.DecodeResponseBody(resp.Header, body)
+	if cmd.Validation != nil {
+		return cmd.Validation(body, resp.StatusCode)
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook response status %v", resp.Status)
+	}
+	return nil
+}
+
+func newTemplate(t *testing.T) *templates.Template {
+	t.Helper()
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost:3000")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+	return tmpl
+}
+
+func notifyContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx := notify.WithGroupKey(context.Background(), "{}:{alertname=\""+t.Name()+"\"}")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": model.LabelValue(t.Name())})
+	return notify.WithReceiverName(ctx, "integration-tests")
+}
+
+func firingAlert(t *testing.T) *types.Alert {
+	return &types.Alert{Alert: model.Alert{
+		Labels:      model.LabelSet{"alertname": model.LabelValue(t.Name()), "severity": "critical"},
+		Annotations: model.LabelSet{"summary": "integration test alert"},
+		StartsAt:    time.Now().Add(-time.Minute),
+		EndsAt:      time.Now().Add(time.Hour),
+	}}
+}
+
+// uniqueName returns a name that is unique to the run of the test, so that the tests do not see the
+// notifications of earlier runs.
+func uniqueName(t *testing.T) string {
+	return strings.ToLower(strings.NewReplacer("/", "-", "_", "-").Replace(t.Name())) + "-" + fmt.Sprint(time.Now().UnixNano())
+}
+
+// fakeServerRequest is a request received by the fake server.
+type fakeServerRequest struct {
+	Method  string      `json:"method"`
+	Path    string      `json:"path"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body"`
+}
+
+// fakeServerRequests returns the requests that the fake server received on the path.
+func fakeServerRequests(t *testing.T, path string) []fakeServerRequest {
+	t.Helper()
+	var reqs []fakeServerRequest
+	getJSON(t, FakeServerURL+"/_requests?path="+url.QueryEscape(path), &reqs)
+	return reqs
+}
+
+func getJSON(t *testing.T, u string, v any) {
+	t.Helper()
+	resp, err := http.Get(u) //nolint:gosec
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
+
+func doJSON(t *testing.T, method, u, contentType string, body any) *http.Response {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		require.NoError(t, err)
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, u, r)
+	require.NoError(t, err)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
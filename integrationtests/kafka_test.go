@@ -0,0 +1,119 @@
+//go:build integration
+
+package integrationtests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/kafka"
+	receiversTesting "github.com/grafana/alerting/receivers/testing"
+)
+
+func TestKafka(t *testing.T) {
+	for _, apiVersion := range []string{"v2", "v3"} {
+		t.Run(apiVersion, func(t *testing.T) {
+			topic := uniqueName(t)
+			createKafkaTopic(t, topic)
+
+			cfg, err := kafka.NewConfig(json.RawMessage(`{
+				"kafkaRestProxy": "`+KafkaRestURL+`",
+				"kafkaTopic": "`+topic+`",
+				"apiVersion": "`+apiVersion+`",
+				"description": "{{ .CommonLabels.alertname }}"
+			}`), receiversTesting.DecryptForTesting(nil))
+			require.NoError(t, err)
+			n := kafka.New(cfg, receivers.Metadata{UID: t.Name()}, newTemplate(t), httpSender{}, &images.UnavailableProvider{}, &logging.FakeLogger{})
+
+			ok, err := n.Notify(notifyContext(t), firingAlert(t))
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			records := consumeKafka(t, topic)
+			require.Len(t, records, 1)
+			var record struct {
+				Description string `json:"description"`
+				AlertState  string `json:"alert_state"`
+				IncidentKey string `json:"incident_key"`
+			}
+			require.NoError(t, json.Unmarshal(records[0], &record))
+			assert.Equal(t, t.Name(), record.Description)
+			assert.Equal(t, "alerting", record.AlertState)
+			assert.NotEmpty(t, record.IncidentKey)
+		})
+	}
+}
+
+// createKafkaTopic creates the topic with the v3 API of the REST proxy. Unlike the v2 API, the v3 API does not
+// create the topics that records are produced to.
+func createKafkaTopic(t *testing.T, topic string) {
+	t.Helper()
+	var clusters struct {
+		Data []struct {
+			ClusterID string `json:"cluster_id"`
+		} `json:"data"`
+	}
+	getJSON(t, KafkaRestURL+"/v3/clusters", &clusters)
+	require.NotEmpty(t, clusters.Data)
+
+	resp := doJSON(t, http.MethodPost, KafkaRestURL+"/v3/clusters/"+clusters.Data[0].ClusterID+"/topics", "application/json", map[string]any{
+		"topic_name":         topic,
+		"partitions_count":   1,
+		"replication_factor": 1,
+	})
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+// consumeKafka reads the records of the topic with a consumer of the v2 API of the REST proxy, and returns their
+// values once there are any.
+func consumeKafka(t *testing.T, topic string) []json.RawMessage {
+	t.Helper()
+	const contentType = "application/vnd.kafka.v2+json"
+	group := KafkaRestURL + "/consumers/" + topic
+
+	resp := doJSON(t, http.MethodPost, group, contentType, map[string]string{
+		"name":              topic,
+		"format":            "json",
+		"auto.offset.reset": "earliest",
+	})
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	instance := group + "/instances/" + topic
+	t.Cleanup(func() {
+		resp := doJSON(t, http.MethodDelete, instance, contentType, nil)
+		_ = resp.Body.Close()
+	})
+
+	resp = doJSON(t, http.MethodPost, instance+"/subscription", contentType, map[string][]string{"topics": {topic}})
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	var values []json.RawMessage
+	require.Eventually(t, func() bool {
+		req, err := http.NewRequest(http.MethodGet, instance+"/records", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", "application/vnd.kafka.json.v2+json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var records []struct {
+			Value json.RawMessage `json:"value"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&records))
+		for _, r := range records {
+			values = append(values, r.Value)
+		}
+		return len(values) > 0
+	}, waitFor, waitFor/30)
+	return values
+}
@@ -0,0 +1,81 @@
+//go:build integration
+
+package integrationtests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	mqttLib "github.com/at-wat/mqtt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/mqtt"
+	receiversTesting "github.com/grafana/alerting/receivers/testing"
+)
+
+func TestMQTT(t *testing.T) {
+	topic := "alerts/" + uniqueName(t)
+	messages := subscribeMQTT(t, topic)
+
+	cfg, err := mqtt.NewConfig(json.RawMessage(`{
+		"brokerUrl": "`+MQTTBrokerURL+`",
+		"topic": "`+topic+`",
+		"qos": 1
+	}`), receiversTesting.DecryptForTesting(nil))
+	require.NoError(t, err)
+	n := mqtt.New(cfg, receivers.Metadata{UID: t.Name()}, newTemplate(t), &logging.FakeLogger{}, nil)
+
+	ok, err := n.Notify(notifyContext(t), firingAlert(t))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var payload []byte
+	require.Eventually(t, func() bool {
+		select {
+		case payload = <-messages:
+			return true
+		default:
+			return false
+		}
+	}, waitFor, waitFor/100)
+
+	var msg struct {
+		Status   string `json:"status"`
+		GroupKey string `json:"groupKey"`
+		Alerts   []struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"alerts"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &msg))
+	assert.Equal(t, "firing", msg.Status)
+	assert.NotEmpty(t, msg.GroupKey)
+	require.Len(t, msg.Alerts, 1)
+	assert.Equal(t, t.Name(), msg.Alerts[0].Labels["alertname"])
+}
+
+// subscribeMQTT subscribes to the topic, and returns the channel of the payloads of the messages published to it.
+func subscribeMQTT(t *testing.T, topic string) <-chan []byte {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), waitFor)
+	defer cancel()
+
+	cli, err := (&mqttLib.URLDialer{URL: MQTTBrokerURL}).DialContext(ctx)
+	require.NoError(t, err)
+	_, err = cli.Connect(ctx, uniqueName(t), mqttLib.WithCleanSession(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cli.Disconnect(context.Background()) })
+
+	messages := make(chan []byte, 10)
+	cli.Handle(mqttLib.HandlerFunc(func(m *mqttLib.Message) {
+		if m.Topic == topic {
+			messages <- m.Payload
+		}
+	}))
+	_, err = cli.Subscribe(ctx, mqttLib.Subscription{Topic: topic, QoS: mqttLib.QoS1})
+	require.NoError(t, err)
+	return messages
+}
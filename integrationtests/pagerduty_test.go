@@ -0,0 +1,51 @@
+//go:build integration
+
+package integrationtests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/pagerduty"
+	receiversTesting "github.com/grafana/alerting/receivers/testing"
+)
+
+func TestPagerDuty(t *testing.T) {
+	path := "/pagerduty/" + uniqueName(t)
+	cfg, err := pagerduty.NewConfig(json.RawMessage(`{
+		"integrationKey": "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4",
+		"url": "`+FakeServerURL+path+`",
+		"severity": "warning",
+		"summary": "{{ .CommonLabels.alertname }}"
+	}`), receiversTesting.DecryptForTesting(nil))
+	require.NoError(t, err)
+	n := pagerduty.New(cfg, receivers.Metadata{UID: t.Name()}, newTemplate(t), httpSender{}, &images.UnavailableProvider{}, &logging.FakeLogger{})
+
+	ok, err := n.Notify(notifyContext(t), firingAlert(t))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	reqs := fakeServerRequests(t, path)
+	require.Len(t, reqs, 1)
+	var msg struct {
+		RoutingKey  string `json:"routing_key"`
+		EventAction string `json:"event_action"`
+		DedupKey    string `json:"dedup_key"`
+		Payload     struct {
+			Summary  string `json:"summary"`
+			Severity string `json:"severity"`
+		} `json:"payload"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(reqs[0].Body), &msg))
+	assert.Equal(t, "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4", msg.RoutingKey)
+	assert.Equal(t, "trigger", msg.EventAction)
+	assert.NotEmpty(t, msg.DedupKey)
+	assert.Equal(t, t.Name(), msg.Payload.Summary)
+	assert.Equal(t, "warning", msg.Payload.Severity)
+}
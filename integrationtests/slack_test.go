@@ -0,0 +1,48 @@
+//go:build integration
+
+package integrationtests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/slack"
+	receiversTesting "github.com/grafana/alerting/receivers/testing"
+)
+
+func TestSlack_IncomingWebhook(t *testing.T) {
+	path := "/slack/" + uniqueName(t)
+	cfg, err := slack.NewConfig(json.RawMessage(`{
+		"url": "`+FakeServerURL+path+`",
+		"title": "{{ .CommonLabels.alertname }}",
+		"text": "{{ .CommonAnnotations.summary }}"
+	}`), receiversTesting.DecryptForTesting(nil))
+	require.NoError(t, err)
+	n := slack.New(cfg, receivers.Metadata{UID: t.Name()}, newTemplate(t), httpSender{}, &images.UnavailableProvider{}, &logging.FakeLogger{}, "test")
+
+	ok, err := n.Notify(notifyContext(t), firingAlert(t))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	reqs := fakeServerRequests(t, path)
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "POST", reqs[0].Method)
+	assert.Empty(t, reqs[0].Headers.Get("Authorization"))
+
+	var msg struct {
+		Attachments []struct {
+			Title string `json:"title"`
+			Text  string `json:"text"`
+		} `json:"attachments"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(reqs[0].Body), &msg))
+	require.Len(t, msg.Attachments, 1)
+	assert.Equal(t, t.Name(), msg.Attachments[0].Title)
+	assert.Equal(t, "integration test alert", msg.Attachments[0].Text)
+}
@@ -0,0 +1,53 @@
+//go:build integration
+
+package integrationtests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	receiversTesting "github.com/grafana/alerting/receivers/testing"
+	"github.com/grafana/alerting/receivers/webhook"
+)
+
+func TestWebhook(t *testing.T) {
+	path := "/webhook/" + uniqueName(t)
+	cfg, err := webhook.NewConfig(json.RawMessage(`{
+		"url": "`+FakeServerURL+path+`",
+		"username": "user",
+		"password": "password"
+	}`), receiversTesting.DecryptForTesting(nil))
+	require.NoError(t, err)
+	n := webhook.New(cfg, receivers.Metadata{UID: t.Name()}, newTemplate(t), httpSender{}, &images.UnavailableProvider{}, &logging.FakeLogger{}, 1)
+
+	ok, err := n.Notify(notifyContext(t), firingAlert(t))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	reqs := fakeServerRequests(t, path)
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "POST", reqs[0].Method)
+	assert.Equal(t, "application/json", reqs[0].Headers.Get("Content-Type"))
+	assert.Equal(t, "Basic dXNlcjpwYXNzd29yZA==", reqs[0].Headers.Get("Authorization"))
+
+	var msg struct {
+		Receiver string `json:"receiver"`
+		Status   string `json:"status"`
+		OrgID    int64  `json:"orgId"`
+		Alerts   []struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"alerts"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(reqs[0].Body), &msg))
+	assert.Equal(t, "integration-tests", msg.Receiver)
+	assert.Equal(t, "firing", msg.Status)
+	assert.EqualValues(t, 1, msg.OrgID)
+	require.Len(t, msg.Alerts, 1)
+	assert.Equal(t, t.Name(), msg.Alerts[0].Labels["alertname"])
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Keys of the fields of the log lines of notifications. Use them instead of ad-hoc key names so that the log lines
+// of a notification can be found by the same keys in all integrations.
+const (
+	KeyTenant         = "tenant"
+	KeyReceiver       = "receiver"
+	KeyIntegration    = "integration"
+	KeyIntegrationUID = "integration_uid"
+	KeyGroupKey       = "group_key"
+	// KeyConfigGeneration and KeyConfigVersion identify the configuration of the Alertmanager that sent the notification.
+	KeyConfigGeneration = "config_generation"
+	KeyConfigVersion    = "config_version"
+)
+
+type contextFieldsKey struct{}
+
+// WithContextFields returns a context with the key/value pairs added to the fields of the context. The fields are
+// added to the log lines of the loggers returned by FromContext and of the handlers returned by NewContextHandler.
+func WithContextFields(ctx context.Context, keyvals ...interface{}) context.Context {
+	if len(keyvals) == 0 {
+		return ctx
+	}
+	fields := ContextFields(ctx)
+	merged := make([]interface{}, 0, len(fields)+len(keyvals))
+	merged = append(merged, fields...)
+	merged = append(merged, keyvals...)
+	return context.WithValue(ctx, contextFieldsKey{}, merged)
+}
+
+// ContextFields returns the key/value pairs of the fields of the context.
+func ContextFields(ctx context.Context) []interface{} {
+	fields, _ := ctx.Value(contextFieldsKey{}).([]interface{})
+	return fields
+}
+
+// FromContext returns a Logger that has the context of l plus the fields of the context.
+func FromContext(ctx context.Context, l Logger) Logger {
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.New(fields...)
+}
+
+// contextHandler is a slog.Handler that adds the fields of the context to the records.
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler returns a slog.Handler that adds the fields of the context of the records to them, for the
+// code that logs with slog and a context directly.
+func NewContextHandler(h slog.Handler) slog.Handler {
+	return contextHandler{Handler: h}
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if fields := ContextFields(ctx); len(fields) > 0 {
+		r = r.Clone()
+		r.Add(fields...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
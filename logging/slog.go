@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// SlogLogger is a Logger that writes structured, leveled log lines with a slog.Logger. The fields of the context of
+// the notification, added with WithContextFields, are added to the log lines of the loggers returned by FromContext.
+type SlogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger returns a Logger that writes to l.
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	return &SlogLogger{l: l}
+}
+
+// NewSlogLoggerFactory returns a LoggerFactory of loggers that write to l, with the name of the logger in the field
+// "logger".
+func NewSlogLoggerFactory(l *slog.Logger) LoggerFactory {
+	return func(loggerName string, ctx ...interface{}) Logger {
+		return NewSlogLogger(l.With(append([]interface{}{"logger", loggerName}, ctx...)...))
+	}
+}
+
+// New returns a new contextual Logger that has this logger's context plus the given context.
+func (s *SlogLogger) New(ctx ...interface{}) Logger {
+	return &SlogLogger{l: s.l.With(ctx...)}
+}
+
+// Log logs the key/value pairs in the style of go-kit loggers, with the level from the key "level" and the message
+// from the key "msg". Lines without level are logged with info level.
+func (s *SlogLogger) Log(keyvals ...interface{}) error {
+	lvl := slog.LevelInfo
+	msg := ""
+	attrs := make([]interface{}, 0, len(keyvals))
+	for i := 0; i < len(keyvals); i += 2 {
+		var v interface{}
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		switch fmt.Sprint(keyvals[i]) {
+		case "level":
+			lvl = parseLevel(fmt.Sprint(v))
+		case "msg":
+			msg = fmt.Sprint(v)
+		default:
+			attrs = append(attrs, keyvals[i], v)
+		}
+	}
+	s.l.Log(context.Background(), lvl, msg, attrs...)
+	return nil
+}
+
+// Debug logs a message with debug level and key/value pairs, if any.
+func (s *SlogLogger) Debug(msg string, ctx ...interface{}) {
+	s.l.Debug(msg, ctx...)
+}
+
+// Info logs a message with info level and key/value pairs, if any.
+func (s *SlogLogger) Info(msg string, ctx ...interface{}) {
+	s.l.Info(msg, ctx...)
+}
+
+// Warn logs a message with warning level and key/value pairs, if any.
+func (s *SlogLogger) Warn(msg string, ctx ...interface{}) {
+	s.l.Warn(msg, ctx...)
+}
+
+// Error logs a message with error level and key/value pairs, if any.
+func (s *SlogLogger) Error(msg string, ctx ...interface{}) {
+	s.l.Error(msg, ctx...)
+}
+
+func parseLevel(lvl string) slog.Level {
+	switch lvl {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
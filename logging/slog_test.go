@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var res []map[string]interface{}
+	for _, b := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(b, &line))
+		delete(line, "time")
+		res = append(res, line)
+	}
+	buf.Reset()
+	return res
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	factory := NewSlogLoggerFactory(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	l := factory("ngalert.notifier.webhook", KeyIntegrationUID, "uid")
+
+	l.Debug("debug", "a", 1)
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+	require.Equal(t, []map[string]interface{}{
+		{"level": "DEBUG", "msg": "debug", "logger": "ngalert.notifier.webhook", "integration_uid": "uid", "a": float64(1)},
+		{"level": "INFO", "msg": "info", "logger": "ngalert.notifier.webhook", "integration_uid": "uid"},
+		{"level": "WARN", "msg": "warn", "logger": "ngalert.notifier.webhook", "integration_uid": "uid"},
+		{"level": "ERROR", "msg": "error", "logger": "ngalert.notifier.webhook", "integration_uid": "uid"},
+	}, readLines(t, &buf))
+
+	t.Run("Log parses level and message", func(t *testing.T) {
+		require.NoError(t, l.Log("level", "warn", "msg", "go-kit", "err", "failed"))
+		require.NoError(t, l.Log("msg", "no level"))
+		require.Equal(t, []map[string]interface{}{
+			{"level": "WARN", "msg": "go-kit", "logger": "ngalert.notifier.webhook", "integration_uid": "uid", "err": "failed"},
+			{"level": "INFO", "msg": "no level", "logger": "ngalert.notifier.webhook", "integration_uid": "uid"},
+		}, readLines(t, &buf))
+	})
+
+	t.Run("FromContext adds the fields of the context", func(t *testing.T) {
+		ctx := WithContextFields(context.Background(), KeyTenant, 1)
+		ctx = WithContextFields(ctx, KeyGroupKey, "{}:{}")
+
+		FromContext(ctx, l).Info("sent")
+		FromContext(context.Background(), l).Info("sent")
+		require.Equal(t, []map[string]interface{}{
+			{"level": "INFO", "msg": "sent", "logger": "ngalert.notifier.webhook", "integration_uid": "uid", "tenant": float64(1), "group_key": "{}:{}"},
+			{"level": "INFO", "msg": "sent", "logger": "ngalert.notifier.webhook", "integration_uid": "uid"},
+		}, readLines(t, &buf))
+	})
+}
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewContextHandler(slog.NewJSONHandler(&buf, nil))).With(KeyReceiver, "team-a")
+
+	ctx := WithContextFields(context.Background(), KeyGroupKey, "{}:{}")
+	l.InfoContext(ctx, "sent")
+	l.Info("sent")
+	require.Equal(t, []map[string]interface{}{
+		{"level": "INFO", "msg": "sent", "receiver": "team-a", "group_key": "{}:{}"},
+		{"level": "INFO", "msg": "sent", "receiver": "team-a"},
+	}, readLines(t, &buf))
+}
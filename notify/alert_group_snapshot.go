@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	v2 "github.com/prometheus/alertmanager/api/v2"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// AlertGroupsFilter selects the alert groups and alerts of GetAlertGroupSnapshots.
+type AlertGroupsFilter struct {
+	// Active, Silenced and Inhibited select the alerts by their state.
+	Active    bool
+	Silenced  bool
+	Inhibited bool
+	// Matchers select the alerts by their labels, such as `team="a"`.
+	Matchers []string
+	// Receivers is a regular expression that selects the groups by their receiver. All receivers are selected if empty.
+	Receivers string
+}
+
+// AlertGroupSnapshot is the state of an aggregation group of the dispatcher.
+type AlertGroupSnapshot struct {
+	GroupKey string `json:"groupKey"`
+	// Path is the path of the route of the group in the routing tree, such as "route.routes[0]".
+	Path     string         `json:"path"`
+	Receiver string         `json:"receiver"`
+	Labels   model.LabelSet `json:"labels"`
+	Alerts   GettableAlerts `json:"alerts"`
+	// Integrations are the integrations of the receiver.
+	Integrations []ReceiverIntegration `json:"integrations"`
+	// LastNotified is the last time any integration of the receiver was notified about the group. It is zero
+	// if the group was not notified yet.
+	LastNotified time.Time `json:"lastNotified"`
+	// NextFlush is the estimated time of the next flush of the group.
+	NextFlush time.Time `json:"nextFlush"`
+}
+
+// GetAlertGroupSnapshots returns the current aggregation groups of the dispatcher that have alerts selected by the
+// filter, with the metadata of their receivers and the times they were notified and are flushed next. Groups are
+// ordered by the position of their route in the routing tree and then by their labels, and alerts by fingerprint.
+func (am *GrafanaAlertmanager) GetAlertGroupSnapshots(filter AlertGroupsFilter) ([]AlertGroupSnapshot, error) {
+	matchers, err := parseFilter(filter.Matchers)
+	if err != nil {
+		level.Error(am.logger).Log("msg", "failed to parse matchers", "err", err)
+		return nil, fmt.Errorf("%s: %w", err.Error(), ErrGetAlertGroupsBadPayload)
+	}
+
+	receiverFilter, err := parseReceivers(filter.Receivers)
+	if err != nil {
+		level.Error(am.logger).Log("msg", "failed to compile receiver regex", "err", err)
+		return nil, fmt.Errorf("%s: %w", err.Error(), ErrGetAlertGroupsBadPayload)
+	}
+
+	am.reloadConfigMtx.RLock()
+	defer am.reloadConfigMtx.RUnlock()
+
+	res := []AlertGroupSnapshot{}
+	if am.route == nil || am.dispatcher == nil {
+		return res, nil
+	}
+
+	af := am.alertFilter(matchers, filter.Silenced, filter.Inhibited, filter.Active)
+	paths := routePaths(am.route)
+	now := am.clock.Now()
+
+	// The receivers of each alert are collected over all routes, so that they are complete like in GetAlertGroups.
+	alertReceivers := make(map[model.Fingerprint][]string)
+	var groupAlerts [][]*types.Alert
+	am.route.Walk(func(r *dispatch.Route) {
+		if err != nil {
+			return
+		}
+		if receiverFilter != nil && !receiverFilter.MatchString(r.RouteOpts.Receiver) {
+			return
+		}
+		groups, receivers := am.dispatcher.Groups(func(route *dispatch.Route) bool { return route == r }, af)
+		for fp, recv := range receivers {
+			alertReceivers[fp] = append(alertReceivers[fp], recv...)
+		}
+		for _, g := range groups {
+			s := AlertGroupSnapshot{
+				GroupKey:     fmt.Sprintf("%s:%s", r.Key(), g.Labels),
+				Path:         paths[r],
+				Receiver:     g.Receiver,
+				Labels:       g.Labels,
+				Integrations: am.receiverIntegrations(g.Receiver),
+			}
+			s.LastNotified, err = am.lastNotified(g.Receiver, s.GroupKey)
+			if err != nil {
+				return
+			}
+			s.NextFlush = nextFlush(r, g, g.Alerts[0], s.LastNotified, now)
+			res = append(res, s)
+			groupAlerts = append(groupAlerts, g.Alerts)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, recv := range alertReceivers {
+		sort.Strings(recv)
+	}
+	for i := range res {
+		res[i].Alerts = make(GettableAlerts, 0, len(groupAlerts[i]))
+		for _, a := range groupAlerts[i] {
+			fp := a.Fingerprint()
+			res[i].Alerts = append(res[i].Alerts, v2.AlertToOpenAPIAlert(a, am.marker.Status(fp), alertReceivers[fp]))
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+)
+
+func TestGetAlertGroupSnapshots(t *testing.T) {
+	am, _ := setupAMTest(t)
+
+	groups, err := am.GetAlertGroupSnapshots(AlertGroupsFilter{Active: true})
+	require.NoError(t, err)
+	require.Empty(t, groups)
+
+	teamA, err := labels.NewMatcher(labels.MatchEqual, "team", "a")
+	require.NoError(t, err)
+	hour := model.Duration(time.Hour)
+	require.NoError(t, am.ApplyConfig(&previewConfig{
+		grafanaRoutingTreeConfig: grafanaRoutingTreeConfig{route: &definition.Route{
+			Receiver:       "default",
+			GroupByStr:     []string{"alertname"},
+			GroupBy:        []model.LabelName{"alertname"},
+			GroupWait:      &hour,
+			GroupInterval:  &hour,
+			RepeatInterval: &hour,
+			Routes: []*definition.Route{
+				{Receiver: "team-a", ObjectMatchers: definition.ObjectMatchers{teamA}, Continue: true},
+				{Receiver: "default", ObjectMatchers: definition.ObjectMatchers{teamA}},
+			},
+		}},
+		receivers: []string{"default", "team-a", "unused"},
+	}))
+
+	startsAt := time.Now()
+	require.NoError(t, am.PutAlerts(PostableAlerts{
+		{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "a", "team": "a"}}},
+		{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "b", "team": "b"}}},
+	}))
+	require.Eventually(t, func() bool {
+		groups, err := am.GetAlertGroupSnapshots(AlertGroupsFilter{Active: true})
+		require.NoError(t, err)
+		return len(groups) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	groups, err = am.GetAlertGroupSnapshots(AlertGroupsFilter{Active: true})
+	require.NoError(t, err)
+
+	require.Equal(t, "route", groups[0].Path)
+	require.Equal(t, "default", groups[0].Receiver)
+	require.Equal(t, `{}:{alertname="b"}`, groups[0].GroupKey)
+	require.Equal(t, model.LabelSet{"alertname": "b"}, groups[0].Labels)
+	require.Len(t, groups[0].Alerts, 1)
+	require.Equal(t, []ReceiverIntegration{{UID: "default-uid", Name: "webhook", SendResolved: true}}, groups[0].Integrations)
+	require.True(t, groups[0].LastNotified.IsZero())
+	require.WithinDuration(t, startsAt.Add(time.Hour), groups[0].NextFlush, time.Minute)
+
+	require.Equal(t, "route.routes[0]", groups[1].Path)
+	require.Equal(t, "team-a", groups[1].Receiver)
+	require.Equal(t, `{}/{team="a"}:{alertname="a"}`, groups[1].GroupKey)
+	require.Equal(t, []string{"default", "team-a"}, receiverNames(groups[1].Alerts[0].Receivers))
+
+	require.Equal(t, "route.routes[1]", groups[2].Path)
+	require.Equal(t, "default", groups[2].Receiver)
+	require.Equal(t, `{}/{team="a"}:{alertname="a"}`, groups[2].GroupKey)
+
+	t.Run("filter by receiver", func(t *testing.T) {
+		groups, err := am.GetAlertGroupSnapshots(AlertGroupsFilter{Active: true, Receivers: "team-.*"})
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		require.Equal(t, "team-a", groups[0].Receiver)
+	})
+
+	t.Run("filter by labels", func(t *testing.T) {
+		groups, err := am.GetAlertGroupSnapshots(AlertGroupsFilter{Active: true, Matchers: []string{`alertname="b"`}})
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		require.Equal(t, model.LabelSet{"alertname": "b"}, groups[0].Labels)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := am.GetAlertGroupSnapshots(AlertGroupsFilter{Matchers: []string{`alertname=~"(`}})
+		require.ErrorIs(t, err, ErrGetAlertGroupsBadPayload)
+	})
+}
+
+func receiverNames(receivers []*Receiver) []string {
+	res := make([]string, 0, len(receivers))
+	for _, r := range receivers {
+		res = append(res, *r.Name)
+	}
+	return res
+}
@@ -62,7 +62,7 @@ func (am *GrafanaAlertmanager) GetAlerts(active, silenced, inhibited bool, filte
 	defer alerts.Close()
 
 	alertFilter := am.alertFilter(matchers, silenced, inhibited, active)
-	now := time.Now()
+	now := am.clock.Now()
 
 	am.reloadConfigMtx.RLock()
 	for a := range alerts.Next() {
@@ -0,0 +1,341 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// DefaultAnalyticsInterval is how often notification statistics are exported by default.
+const DefaultAnalyticsInterval = time.Hour
+
+// SeverityMixed is the severity of the statistics of notifications whose alerts have different severities.
+const SeverityMixed = "mixed"
+
+// analyticsFailureReasons are the failure reasons exported as columns, in order.
+var analyticsFailureReasons = []string{
+	FailureReasonTimeout,
+	FailureReasonClientError,
+	FailureReasonServerError,
+	FailureReasonTemplate,
+	FailureReasonOther,
+}
+
+// NotificationStats are the aggregated notification attempts of an integration of a receiver for the alerts of a
+// severity during a window.
+type NotificationStats struct {
+	WindowStart time.Time
+	WindowEnd   time.Time
+	OrgID       int64
+	Receiver    string
+	Integration string
+	// Severity is the value of the severity label of the alerts of the notifications, SeverityMixed if the alerts
+	// of a notification have different severities, or empty if they have none.
+	Severity string
+	Attempts int
+	// Failures are the failed attempts by reason, see FailureReason.
+	Failures map[string]int
+	// Alerts is the total number of alerts of the attempts.
+	Alerts     int
+	LatencySum time.Duration
+	LatencyMax time.Duration
+}
+
+// Successes returns the number of attempts that did not fail.
+func (s NotificationStats) Successes() int {
+	n := s.Attempts
+	for _, f := range s.Failures {
+		n -= f
+	}
+	return n
+}
+
+// AnalyticsEncoder encodes the statistics of a window into a file.
+type AnalyticsEncoder interface {
+	// Encode writes the statistics to w.
+	Encode(w io.Writer, stats []NotificationStats) error
+	// Extension is the extension of the files written by the encoder, such as "csv".
+	Extension() string
+}
+
+// AnalyticsSink stores the exported files, such as in an object store.
+type AnalyticsSink interface {
+	// Put stores the content of the file with the name.
+	Put(ctx context.Context, name string, r io.Reader) error
+}
+
+// WriterSink is an AnalyticsSink that writes the content of all files, one after the other, to an io.Writer.
+type WriterSink struct {
+	mtx sync.Mutex
+	W   io.Writer
+}
+
+func (s *WriterSink) Put(_ context.Context, _ string, r io.Reader) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	_, err := io.Copy(s.W, r)
+	return err
+}
+
+// CSVEncoder encodes statistics as CSV with a header, one row per receiver, integration and severity.
+type CSVEncoder struct{}
+
+func (CSVEncoder) Extension() string { return "csv" }
+
+func (CSVEncoder) Encode(w io.Writer, stats []NotificationStats) error {
+	cw := csv.NewWriter(w)
+	header := []string{"window_start", "window_end", "org_id", "receiver", "integration", "severity", "attempts", "successes"}
+	for _, reason := range analyticsFailureReasons {
+		header = append(header, "failures_"+reason)
+	}
+	header = append(header, "alerts", "latency_avg_seconds", "latency_max_seconds")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, s := range stats {
+		var avg float64
+		if s.Attempts > 0 {
+			avg = s.LatencySum.Seconds() / float64(s.Attempts)
+		}
+		row := []string{
+			s.WindowStart.UTC().Format(time.RFC3339),
+			s.WindowEnd.UTC().Format(time.RFC3339),
+			strconv.FormatInt(s.OrgID, 10),
+			s.Receiver,
+			s.Integration,
+			s.Severity,
+			strconv.Itoa(s.Attempts),
+			strconv.Itoa(s.Successes()),
+		}
+		for _, reason := range analyticsFailureReasons {
+			row = append(row, strconv.Itoa(s.Failures[reason]))
+		}
+		row = append(row,
+			strconv.Itoa(s.Alerts),
+			strconv.FormatFloat(avg, 'f', -1, 64),
+			strconv.FormatFloat(s.LatencyMax.Seconds(), 'f', -1, 64),
+		)
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// AnalyticsOptions configure the periodic export of notification statistics.
+type AnalyticsOptions struct {
+	// Sink stores the exported files. It is required.
+	Sink AnalyticsSink
+	// Encoder encodes the files. It defaults to CSVEncoder.
+	Encoder AnalyticsEncoder
+	// Interval is the length of the windows of the statistics. It defaults to DefaultAnalyticsInterval.
+	Interval time.Duration
+}
+
+func (o *AnalyticsOptions) Validate() error {
+	if o.Sink == nil {
+		return errors.New("sink must be present")
+	}
+	if o.Interval < 0 {
+		return errors.New("interval must not be negative")
+	}
+	return nil
+}
+
+type analyticsKey struct {
+	receiver    string
+	integration string
+	severity    string
+}
+
+// analyticsExporter aggregates the notification attempts of integrations, and exports the statistics of each
+// window. The statistics of windows that fail to be exported are added to the next window.
+type analyticsExporter struct {
+	orgID    int64
+	sink     AnalyticsSink
+	encoder  AnalyticsEncoder
+	interval time.Duration
+	logger   log.Logger
+	clock    clock.Clock
+
+	mtx         sync.Mutex
+	windowStart time.Time
+	stats       map[analyticsKey]*NotificationStats
+}
+
+func newAnalyticsExporter(orgID int64, opts AnalyticsOptions, clk clock.Clock, logger log.Logger) *analyticsExporter {
+	e := &analyticsExporter{
+		orgID:    orgID,
+		sink:     opts.Sink,
+		encoder:  opts.Encoder,
+		interval: opts.Interval,
+		logger:   logger,
+		clock:    clk,
+		stats:    make(map[analyticsKey]*NotificationStats),
+	}
+	if e.encoder == nil {
+		e.encoder = CSVEncoder{}
+	}
+	if e.interval == 0 {
+		e.interval = DefaultAnalyticsInterval
+	}
+	e.windowStart = e.clock.Now()
+	return e
+}
+
+// Wrap returns an integration whose notification attempts are aggregated by the exporter.
+func (e *analyticsExporter) Wrap(integration *notify.Integration, receiver string) *notify.Integration {
+	n := &analyticsNotifier{
+		upstream:    integration,
+		exporter:    e,
+		receiver:    receiver,
+		integration: integration.Name(),
+	}
+	return notify.NewIntegration(n, integration, integration.Name(), integration.Index(), receiver)
+}
+
+func (e *analyticsExporter) record(receiver, integration string, alerts []*types.Alert, latency time.Duration, err error) {
+	key := analyticsKey{receiver: receiver, integration: integration, severity: severityOf(alerts)}
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	s, ok := e.stats[key]
+	if !ok {
+		s = &NotificationStats{Receiver: receiver, Integration: integration, Severity: key.severity, Failures: make(map[string]int)}
+		e.stats[key] = s
+	}
+	s.Attempts++
+	s.Alerts += len(alerts)
+	s.LatencySum += latency
+	if latency > s.LatencyMax {
+		s.LatencyMax = latency
+	}
+	if err != nil {
+		s.Failures[FailureReason(err)]++
+	}
+}
+
+// export exports the statistics of the window that ends now. Windows without notification attempts are not exported.
+func (e *analyticsExporter) export(ctx context.Context) error {
+	end := e.clock.Now()
+	e.mtx.Lock()
+	start, stats := e.windowStart, e.stats
+	e.windowStart, e.stats = end, make(map[analyticsKey]*NotificationStats)
+	e.mtx.Unlock()
+
+	if len(stats) == 0 {
+		return nil
+	}
+	rows := make([]NotificationStats, 0, len(stats))
+	for _, s := range stats {
+		r := *s
+		r.WindowStart, r.WindowEnd, r.OrgID = start, end, e.orgID
+		rows = append(rows, r)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Receiver != rows[j].Receiver {
+			return rows[i].Receiver < rows[j].Receiver
+		}
+		if rows[i].Integration != rows[j].Integration {
+			return rows[i].Integration < rows[j].Integration
+		}
+		return rows[i].Severity < rows[j].Severity
+	})
+
+	var buf bytes.Buffer
+	err := e.encoder.Encode(&buf, rows)
+	if err == nil {
+		name := fmt.Sprintf("notifications/%d/%s-%s.%s", e.orgID, start.UTC().Format("20060102T150405Z"), end.UTC().Format("20060102T150405Z"), e.encoder.Extension())
+		err = e.sink.Put(ctx, name, &buf)
+	}
+	if err != nil {
+		e.restore(start, stats)
+		return err
+	}
+	return nil
+}
+
+// restore adds the statistics of a window that failed to be exported to the current window.
+func (e *analyticsExporter) restore(start time.Time, stats map[analyticsKey]*NotificationStats) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.windowStart = start
+	for key, s := range stats {
+		cur, ok := e.stats[key]
+		if !ok {
+			e.stats[key] = s
+			continue
+		}
+		cur.Attempts += s.Attempts
+		cur.Alerts += s.Alerts
+		cur.LatencySum += s.LatencySum
+		if s.LatencyMax > cur.LatencyMax {
+			cur.LatencyMax = s.LatencyMax
+		}
+		for reason, n := range s.Failures {
+			cur.Failures[reason] += n
+		}
+	}
+}
+
+// run exports the statistics at each interval until stopc is closed, and then exports the last window.
+func (e *analyticsExporter) run(stopc <-chan struct{}) {
+	t := e.clock.Ticker(e.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := e.export(context.Background()); err != nil {
+				level.Error(e.logger).Log("msg", "Failed to export notification statistics, retrying at the next interval", "err", err)
+			}
+		case <-stopc:
+			if err := e.export(context.Background()); err != nil {
+				level.Error(e.logger).Log("msg", "Failed to export notification statistics", "err", err)
+			}
+			return
+		}
+	}
+}
+
+// severityOf returns the severity of the alerts of a notification.
+func severityOf(alerts []*types.Alert) string {
+	var severity string
+	for i, a := range alerts {
+		s := string(a.Labels[model.LabelName("severity")])
+		if i > 0 && s != severity {
+			return SeverityMixed
+		}
+		severity = s
+	}
+	return severity
+}
+
+// analyticsNotifier wraps a notify.Notifier and aggregates its notification attempts.
+type analyticsNotifier struct {
+	upstream    notify.Notifier
+	exporter    *analyticsExporter
+	receiver    string
+	integration string
+}
+
+// Notify implements the Notifier interface.
+func (n *analyticsNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	start := n.exporter.clock.Now()
+	retry, err := n.upstream.Notify(ctx, alerts...)
+	n.exporter.record(n.receiver, n.integration, alerts, n.exporter.clock.Now().Sub(start), err)
+	return retry, err
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAnalyticsSink struct {
+	err   error
+	files map[string]string
+}
+
+func (s *fakeAnalyticsSink) Put(_ context.Context, name string, r io.Reader) error {
+	if s.err != nil {
+		return s.err
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.files[name] = string(b)
+	return nil
+}
+
+func TestAnalyticsExporter(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	clk := clock.NewMock()
+	clk.Set(start)
+	sink := &fakeAnalyticsSink{files: map[string]string{}}
+	exporter := newAnalyticsExporter(1, AnalyticsOptions{Sink: sink}, clk, log.NewNopLogger())
+	exporter.windowStart = start
+	require.Equal(t, DefaultAnalyticsInterval, exporter.interval)
+
+	critical := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "severity": "critical"}}}
+	warning := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2", "severity": "warning"}}}
+
+	notifier := &fakeFailingNotifier{}
+	integration := exporter.Wrap(notify.NewIntegration(notifier, sendResolved(true), "webhook", 0, "receiver"), "receiver")
+	require.Equal(t, "webhook", integration.Name())
+
+	ctx := context.Background()
+	_, err := integration.Notify(ctx, critical)
+	require.NoError(t, err)
+	_, err = integration.Notify(ctx, critical, warning)
+	require.NoError(t, err)
+	notifier.err = context.DeadlineExceeded
+	_, err = integration.Notify(ctx, critical)
+	require.Error(t, err)
+
+	// Windows that fail to be exported are added to the next window.
+	clk.Add(time.Hour)
+	sink.err = errors.New("unavailable")
+	require.ErrorIs(t, exporter.export(ctx), sink.err)
+	notifier.err = errors.New("failed")
+	_, err = integration.Notify(ctx, critical)
+	require.Error(t, err)
+
+	clk.Add(time.Hour)
+	sink.err = nil
+	require.NoError(t, exporter.export(ctx))
+	require.Equal(t, map[string]string{
+		"notifications/1/20240501T100000Z-20240501T120000Z.csv": "window_start,window_end,org_id,receiver,integration,severity,attempts,successes," +
+			"failures_timeout,failures_4xx,failures_5xx,failures_template_error,failures_other,alerts,latency_avg_seconds,latency_max_seconds\n" +
+			"2024-05-01T10:00:00Z,2024-05-01T12:00:00Z,1,receiver,webhook,critical,3,1,1,0,0,0,1,3,0,0\n" +
+			"2024-05-01T10:00:00Z,2024-05-01T12:00:00Z,1,receiver,webhook,mixed,1,1,0,0,0,0,0,2,0,0\n",
+	}, sink.files)
+
+	// Windows without notification attempts are not exported.
+	clk.Add(time.Hour)
+	require.NoError(t, exporter.export(ctx))
+	require.Len(t, sink.files, 1)
+}
+
+func TestSeverityOf(t *testing.T) {
+	alert := func(severity string) *types.Alert {
+		a := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert"}}}
+		if severity != "" {
+			a.Labels["severity"] = model.LabelValue(severity)
+		}
+		return a
+	}
+	require.Equal(t, "", severityOf(nil))
+	require.Equal(t, "", severityOf([]*types.Alert{alert("")}))
+	require.Equal(t, "critical", severityOf([]*types.Alert{alert("critical"), alert("critical")}))
+	require.Equal(t, SeverityMixed, severityOf([]*types.Alert{alert("critical"), alert("")}))
+}
+
+func TestGrafanaAlertmanagerConfig_ValidateAnalytics(t *testing.T) {
+	cfg := &GrafanaAlertmanagerConfig{Silences: newFakeMaintanenceOptions(t), Nflog: newFakeMaintanenceOptions(t)}
+	cfg.Analytics = &AnalyticsOptions{}
+	require.ErrorContains(t, cfg.Validate(), "invalid analytics options: sink must be present")
+	cfg.Analytics = &AnalyticsOptions{Sink: &WriterSink{W: io.Discard}, Interval: -time.Minute}
+	require.ErrorContains(t, cfg.Validate(), "invalid analytics options: interval must not be negative")
+}
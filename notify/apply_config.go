@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/dispatch"
+
+	"github.com/grafana/alerting/templates"
+)
+
+// Components of a configuration, reported by ConfigError.
+const (
+	ConfigComponentTemplates      = "templates"
+	ConfigComponentResolveTimeout = "resolve_timeout"
+	ConfigComponentRoute          = "route"
+	ConfigComponentReceiver       = "receiver"
+)
+
+// ConfigError is an error of a component of a configuration, which prevents the configuration from being applied.
+type ConfigError struct {
+	// Component is the component with the error, such as ConfigComponentReceiver.
+	Component string `json:"component"`
+	// Name is the name of the component if there can be several of them, such as the name of the receiver.
+	Name string `json:"name,omitempty"`
+	Err  error  `json:"-"`
+}
+
+func (e *ConfigError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("invalid %s: %s", e.Component, e.Err)
+	}
+	return fmt.Sprintf("invalid %s %q: %s", e.Component, e.Name, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyConfigDryRun builds the templates, routes and receivers of the configuration like ApplyConfig, without
+// replacing those of the applied configuration. It returns the errors of all of the components that would prevent
+// the configuration from being applied, in the order they are built, or nil if it would be applied. Inhibition rules
+// and time intervals are validated when the configuration is loaded, and cannot fail to build.
+func (am *GrafanaAlertmanager) ApplyConfigDryRun(cfg Configuration) []*ConfigError {
+	_, errs := am.buildConfigSet(cfg, false)
+	return errs
+}
+
+// configSet is the working set of the components built from a configuration. The applied configuration is only
+// replaced once all of them are built, so that a configuration that fails to apply leaves the previous one running.
+type configSet struct {
+	templates           []templates.TemplateDefinition
+	template            *templates.Template
+	resolveTimeout      time.Duration
+	route               *dispatch.Route
+	notifyOnceRouteKeys map[string]struct{}
+	integrations        map[string][]*Integration
+	failover            map[string][]string
+}
+
+// buildConfigSet builds the components of the configuration. If failFast is set, it stops at the first error.
+// Otherwise it builds all of them and returns all of the errors.
+func (am *GrafanaAlertmanager) buildConfigSet(cfg Configuration, failFast bool) (*configSet, []*ConfigError) {
+	set := &configSet{resolveTimeout: am.defaultResolveTimeout}
+	var errs []*ConfigError
+	fail := func(component, name string, err error) bool {
+		errs = append(errs, &ConfigError{Component: component, Name: name, Err: err})
+		return failFast
+	}
+
+	var err error
+	set.templates, set.template, err = am.buildTemplate(cfg.Templates())
+	if err != nil && fail(ConfigComponentTemplates, "", err) {
+		return nil, errs
+	}
+
+	if c, ok := cfg.(ResolveTimeoutConfiguration); ok && c.ResolveTimeout() != 0 {
+		if c.ResolveTimeout() < 0 && fail(ConfigComponentResolveTimeout, "", errors.New("resolve timeout must not be negative")) {
+			return nil, errs
+		}
+		set.resolveTimeout = c.ResolveTimeout()
+	}
+
+	if err := validateRouteEnrichers(cfg, am.routeEnrichers); err != nil && fail(ConfigComponentRoute, "", err) {
+		return nil, errs
+	}
+	set.route, set.notifyOnceRouteKeys = buildRoutingTree(cfg)
+
+	apiReceivers := cfg.Receivers()
+	nameToReceiver := make(map[string]*APIReceiver, len(apiReceivers))
+	for _, receiver := range apiReceivers {
+		if existing, ok := nameToReceiver[receiver.Name]; ok {
+			itypes := make([]string, 0, len(existing.GrafanaIntegrations.Integrations))
+			for _, i := range existing.GrafanaIntegrations.Integrations {
+				itypes = append(itypes, i.Type)
+			}
+			level.Warn(am.logger).Log("msg", "receiver with same name is defined multiple times. Only the last one will be used", "receiver_name", receiver.Name, "overwritten_integrations", itypes)
+		}
+		nameToReceiver[receiver.Name] = receiver
+	}
+	names := make([]string, 0, len(nameToReceiver))
+	for name := range nameToReceiver {
+		names = append(names, name)
+	}
+	// The receivers are built in the order of their names, so that the errors are reported in a stable order.
+	sort.Strings(names)
+
+	set.integrations = make(map[string][]*Integration, len(names))
+	set.failover = make(map[string][]string, len(names))
+	// The integrations cannot be built without the template.
+	if set.template == nil {
+		return nil, errs
+	}
+	for _, name := range names {
+		apiReceiver := nameToReceiver[name]
+		set.failover[name] = apiReceiver.Failover
+		integrations, err := cfg.BuildReceiverIntegrationsFunc()(apiReceiver, set.template)
+		if err != nil {
+			if fail(ConfigComponentReceiver, name, err) {
+				return nil, errs
+			}
+			continue
+		}
+		set.integrations[name] = integrations
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return set, nil
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+// dryRunConfig is a previewConfig whose receivers fail to build with the errors of failing.
+type dryRunConfig struct {
+	previewConfig
+	tmpls          []templates.TemplateDefinition
+	resolveTimeout time.Duration
+	failing        map[string]error
+}
+
+func (c *dryRunConfig) Templates() []templates.TemplateDefinition { return c.tmpls }
+
+func (c *dryRunConfig) ResolveTimeout() time.Duration { return c.resolveTimeout }
+
+func (c *dryRunConfig) BuildReceiverIntegrationsFunc() func(*APIReceiver, *templates.Template) ([]*Integration, error) {
+	build := c.previewConfig.BuildReceiverIntegrationsFunc()
+	return func(r *APIReceiver, tmpl *templates.Template) ([]*Integration, error) {
+		if err := c.failing[r.Name]; err != nil {
+			return nil, err
+		}
+		return build(r, tmpl)
+	}
+}
+
+func TestApplyConfigDryRun(t *testing.T) {
+	am, _ := setupAMTest(t)
+	valid := previewConfig{
+		grafanaRoutingTreeConfig: grafanaRoutingTreeConfig{route: &definition.Route{Receiver: "default"}},
+		receivers:                []string{"default", "team-a", "team-b"},
+	}
+	require.Empty(t, am.ApplyConfigDryRun(&valid))
+	require.Equal(t, receivers.ConfigGeneration{}, am.ConfigGeneration(), "a dry run should not apply the configuration")
+	require.Nil(t, am.dispatcher)
+
+	require.NoError(t, am.PutAlerts(amv2.PostableAlerts{{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "test"}}}}))
+	require.NoError(t, am.ApplyConfig(&valid))
+	// The dispatcher must be running before it is stopped when the Alertmanager stops.
+	require.Eventually(t, func() bool {
+		groups, err := am.GetAlertGroupSnapshots(AlertGroupsFilter{Active: true})
+		require.NoError(t, err)
+		return len(groups) == 1
+	}, time.Second, 10*time.Millisecond)
+	dispatcher := am.dispatcher
+	applied := am.GetReceivers()
+
+	errA, errB := errors.New("invalid URL"), errors.New("missing token")
+	t.Run("receivers", func(t *testing.T) {
+		cfg := &dryRunConfig{previewConfig: valid, failing: map[string]error{"team-b": errB, "team-a": errA}}
+		errs := am.ApplyConfigDryRun(cfg)
+		require.Len(t, errs, 2)
+		require.Equal(t, &ConfigError{Component: ConfigComponentReceiver, Name: "team-a", Err: errA}, errs[0])
+		require.Equal(t, &ConfigError{Component: ConfigComponentReceiver, Name: "team-b", Err: errB}, errs[1])
+		require.EqualError(t, errs[0], `invalid receiver "team-a": invalid URL`)
+	})
+
+	t.Run("templates and resolve timeout", func(t *testing.T) {
+		cfg := &dryRunConfig{
+			previewConfig:  valid,
+			tmpls:          []templates.TemplateDefinition{{Name: "broken", Template: `{{ define "broken" }}`}},
+			resolveTimeout: -time.Minute,
+			failing:        map[string]error{"team-a": errA},
+		}
+		errs := am.ApplyConfigDryRun(cfg)
+		require.Len(t, errs, 2, "the receivers cannot be built without the templates")
+		require.Equal(t, ConfigComponentTemplates, errs[0].Component)
+		require.Equal(t, ConfigComponentResolveTimeout, errs[1].Component)
+		require.EqualError(t, errs[1], "invalid resolve_timeout: resolve timeout must not be negative")
+	})
+
+	t.Run("failed configurations are not applied", func(t *testing.T) {
+		cfg := &dryRunConfig{previewConfig: valid, failing: map[string]error{"team-b": errB}}
+		err := am.ApplyConfig(cfg)
+		require.ErrorIs(t, err, errB)
+		var configErr *ConfigError
+		require.ErrorAs(t, err, &configErr)
+		require.Equal(t, &ConfigError{Component: ConfigComponentReceiver, Name: "team-b", Err: errB}, configErr)
+		require.Same(t, dispatcher, am.dispatcher)
+		require.Equal(t, applied, am.GetReceivers())
+		require.Equal(t, receivers.ConfigGeneration{Generation: 1}, am.ConfigGeneration())
+	})
+}
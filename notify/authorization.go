@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// NotificationAuthorizer authorizes each notification before an integration sends it, such as with a policy engine
+// that does not allow alerts labeled confidential=true to be sent to external services.
+type NotificationAuthorizer interface {
+	// AuthorizeNotification returns nil if the integration of the receiver can send the alerts. It returns a
+	// NotificationVetoError to veto the notification, which is then not sent. Other errors fail the notification,
+	// which is attempted again with the next notification of its group.
+	AuthorizeNotification(ctx context.Context, receiver NotificationReceiver, alerts []*types.Alert) error
+}
+
+// NotificationAuthorizerFunc is a function that implements NotificationAuthorizer.
+type NotificationAuthorizerFunc func(ctx context.Context, receiver NotificationReceiver, alerts []*types.Alert) error
+
+func (f NotificationAuthorizerFunc) AuthorizeNotification(ctx context.Context, receiver NotificationReceiver, alerts []*types.Alert) error {
+	return f(ctx, receiver, alerts)
+}
+
+// NotificationReceiver is the destination of a notification: the integration of a receiver.
+type NotificationReceiver struct {
+	Name        string
+	Integration IntegrationMetadata
+}
+
+// NotificationVetoError is the error of a NotificationAuthorizer that vetoes a notification.
+type NotificationVetoError struct {
+	// Reason is the reason of the veto in the metrics and the delivery history, such as "confidential".
+	// It must have few possible values.
+	Reason string
+	// Err, if set, explains the veto.
+	Err error
+}
+
+func (e *NotificationVetoError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("notification vetoed: %s", e.Reason)
+	}
+	return fmt.Sprintf("notification vetoed: %s: %s", e.Reason, e.Err)
+}
+
+func (e *NotificationVetoError) Unwrap() error {
+	return e.Err
+}
+
+// authorizationStage drops the notifications that are vetoed by the authorizer. Vetoed notifications are not
+// logged in the notification log, so they are authorized again with the next notification of their group.
+type authorizationStage struct {
+	authorizer  NotificationAuthorizer
+	integration *notify.Integration
+	recorder    *DeliveryRecorder
+	vetoes      *prometheus.CounterVec
+}
+
+func (s *authorizationStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	receiver, _ := notify.ReceiverName(ctx)
+	err := s.authorizer.AuthorizeNotification(ctx, NotificationReceiver{
+		Name: receiver,
+		Integration: IntegrationMetadata{
+			Name:         s.integration.Name(),
+			Index:        s.integration.Index(),
+			SendResolved: s.integration.SendResolved(),
+		},
+	}, alerts)
+	if err == nil {
+		return ctx, alerts, nil
+	}
+	var veto *NotificationVetoError
+	if !errors.As(err, &veto) {
+		return ctx, nil, fmt.Errorf("failed to authorize notification: %w", err)
+	}
+
+	reason := veto.Reason
+	if reason == "" {
+		reason = "unknown"
+	}
+	s.vetoes.WithLabelValues(s.integration.Name(), reason).Inc()
+	level.Warn(l).Log("msg", "Notification vetoed", "receiver", receiver, "integration", s.integration.Name(), "reason", reason, "err", err)
+	if s.recorder != nil {
+		groupKey, _ := notify.GroupKey(ctx)
+		fps := make([]model.Fingerprint, 0, len(alerts))
+		for _, a := range alerts {
+			fps = append(fps, a.Fingerprint())
+		}
+		s.recorder.Record(context.WithoutCancel(ctx), DeliveryRecord{
+			Timestamp:         time.Now(),
+			Receiver:          receiver,
+			Integration:       s.integration.Name(),
+			IntegrationIndex:  s.integration.Index(),
+			GroupKey:          groupKey,
+			AlertFingerprints: fps,
+			Outcome:           DeliveryOutcomeVetoed,
+			Error:             err.Error(),
+		})
+	}
+	return ctx, nil, nil
+}
@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizationStage(t *testing.T) {
+	alerts := []*types.Alert{{Alert: model.Alert{
+		Labels: model.LabelSet{"alertname": "alert1", "confidential": "true"},
+		EndsAt: time.Now().Add(time.Hour),
+	}}}
+	ctx := notify.WithGroupKey(context.Background(), "group-key")
+	ctx = notify.WithReceiverName(ctx, "receiver")
+	integration := notify.NewIntegration(&fakeFailingNotifier{}, sendResolved(true), "slack", 1, "receiver")
+
+	// The authorizer does not allow confidential alerts to be sent to Slack.
+	var received []NotificationReceiver
+	authorizer := NotificationAuthorizerFunc(func(_ context.Context, receiver NotificationReceiver, alerts []*types.Alert) error {
+		received = append(received, receiver)
+		for _, a := range alerts {
+			if a.Labels["confidential"] == "true" && receiver.Integration.Name == "slack" {
+				return &NotificationVetoError{Reason: "confidential", Err: errors.New("confidential alerts cannot be sent to external services")}
+			}
+		}
+		return nil
+	})
+	newStage := func(authorizer NotificationAuthorizer, integration *notify.Integration) (*authorizationStage, *MemoryDeliveryStore) {
+		store := NewMemoryDeliveryStore(10)
+		return &authorizationStage{
+			authorizer:  authorizer,
+			integration: integration,
+			recorder:    NewDeliveryRecorder(store, log.NewNopLogger()),
+			vetoes:      prometheus.NewCounterVec(prometheus.CounterOpts{Name: "vetoes"}, []string{"integration", "reason"}),
+		}, store
+	}
+
+	t.Run("vetoed notifications are dropped and recorded", func(t *testing.T) {
+		stage, store := newStage(authorizer, integration)
+		_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Empty(t, res)
+		require.Equal(t, NotificationReceiver{
+			Name:        "receiver",
+			Integration: IntegrationMetadata{Name: "slack", Index: 1, SendResolved: true},
+		}, received[len(received)-1])
+		require.Equal(t, 1.0, testutil.ToFloat64(stage.vetoes.WithLabelValues("slack", "confidential")))
+
+		records, err := store.Query(context.Background(), DeliveryHistoryFilter{})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		require.Equal(t, DeliveryOutcomeVetoed, records[0].Outcome)
+		require.Equal(t, "group-key", records[0].GroupKey)
+		require.Equal(t, "notification vetoed: confidential: confidential alerts cannot be sent to external services", records[0].Error)
+	})
+
+	t.Run("authorized notifications are sent", func(t *testing.T) {
+		integration := notify.NewIntegration(&fakeFailingNotifier{}, sendResolved(true), "webhook", 0, "receiver")
+		stage, store := newStage(authorizer, integration)
+		_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, alerts, res)
+		records, err := store.Query(context.Background(), DeliveryHistoryFilter{})
+		require.NoError(t, err)
+		require.Empty(t, records)
+	})
+
+	t.Run("notifications fail if they cannot be authorized", func(t *testing.T) {
+		authErr := errors.New("policy engine unavailable")
+		stage, _ := newStage(NotificationAuthorizerFunc(func(context.Context, NotificationReceiver, []*types.Alert) error {
+			return authErr
+		}), integration)
+		_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.ErrorIs(t, err, authErr)
+		require.Empty(t, res)
+	})
+}
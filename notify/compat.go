@@ -9,6 +9,7 @@ import (
 func PostableAPIReceiverToAPIReceiver(r *definition.PostableApiReceiver) *APIReceiver {
 	integrations := GrafanaIntegrations{
 		Integrations: make([]*GrafanaIntegrationConfig, 0, len(r.GrafanaManagedReceivers)),
+		Failover:     r.Failover,
 	}
 	for _, p := range r.GrafanaManagedReceivers {
 		integrations.Integrations = append(integrations.Integrations, &GrafanaIntegrationConfig{
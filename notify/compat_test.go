@@ -23,12 +23,14 @@ func TestPostableApiReceiverToApiReceiver(t *testing.T) {
 				Settings:              definition.RawMessage{'b', 'y', 't', 'e', 's'},
 				SecureSettings:        map[string]string{"key": "value"},
 			}},
+			Failover: []string{"abc"},
 		},
 	}
 	receiver := PostableAPIReceiverToAPIReceiver(postableReceiver)
 
 	require.Equal(t, "test", receiver.Name)
 	require.Equal(t, 1, len(receiver.GrafanaIntegrations.Integrations))
+	require.Equal(t, []string{"abc"}, receiver.GrafanaIntegrations.Failover)
 
 	i := receiver.GrafanaIntegrations.Integrations[0]
 	require.Equal(t, "abc", i.UID)
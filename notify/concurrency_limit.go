@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// concurrencyLimiter limits the number of notifications that are sent at the same time by the integrations of
+// each type, so that bursts of notifications do not open many connections to rate-limited APIs. Notifications
+// wait for their turn, for no longer than the timeout of the notification.
+type concurrencyLimiter struct {
+	semaphores map[string]chan struct{}
+	queued     *prometheus.HistogramVec
+}
+
+func newConcurrencyLimiter(limits map[string]int, queued *prometheus.HistogramVec) *concurrencyLimiter {
+	semaphores := make(map[string]chan struct{}, len(limits))
+	for integration, limit := range limits {
+		semaphores[integration] = make(chan struct{}, limit)
+	}
+	return &concurrencyLimiter{semaphores: semaphores, queued: queued}
+}
+
+// Wrap returns an integration whose notification attempts are limited by the limit of the type of the
+// integration. If the type has no limit, the integration is returned as is.
+func (l *concurrencyLimiter) Wrap(integration *notify.Integration, receiver string) *notify.Integration {
+	sem, ok := l.semaphores[integration.Name()]
+	if !ok {
+		return integration
+	}
+	n := &limitingNotifier{
+		upstream: integration,
+		sem:      sem,
+		queued:   l.queued.WithLabelValues(integration.Name()),
+	}
+	return notify.NewIntegration(n, integration, integration.Name(), integration.Index(), receiver)
+}
+
+// limitingNotifier wraps a notify.Notifier and waits for a free slot of the semaphore before each notification attempt.
+type limitingNotifier struct {
+	upstream notify.Notifier
+	sem      chan struct{}
+	queued   prometheus.Observer
+}
+
+// Notify implements the Notifier interface.
+func (n *limitingNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	start := time.Now()
+	select {
+	case n.sem <- struct{}{}:
+	case <-ctx.Done():
+		n.queued.Observe(time.Since(start).Seconds())
+		return true, ctx.Err()
+	}
+	n.queued.Observe(time.Since(start).Seconds())
+	defer func() { <-n.sem }()
+	return n.upstream.Notify(ctx, alerts...)
+}
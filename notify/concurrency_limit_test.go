@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingNotifier records the maximum number of concurrent notifications, which wait until release is closed.
+type blockingNotifier struct {
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+	release     chan struct{}
+}
+
+func (n *blockingNotifier) Notify(_ context.Context, _ ...*types.Alert) (bool, error) {
+	cur := n.inFlight.Add(1)
+	defer n.inFlight.Add(-1)
+	for {
+		max := n.maxInFlight.Load()
+		if cur <= max || n.maxInFlight.CompareAndSwap(max, cur) {
+			break
+		}
+	}
+	<-n.release
+	return false, nil
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := NewGrafanaAlertmanagerMetrics(reg, log.NewNopLogger())
+	l := newConcurrencyLimiter(map[string]int{"jira": 2}, m.notificationQueuedSeconds)
+
+	notifier := &blockingNotifier{release: make(chan struct{})}
+	jira := l.Wrap(notify.NewIntegration(notifier, sendResolved(true), "jira", 0, "receiver"), "receiver")
+	other := notify.NewIntegration(notifier, sendResolved(true), "webhook", 0, "receiver")
+	require.Same(t, other, l.Wrap(other, "receiver"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := jira.Notify(context.Background())
+			require.NoError(t, err)
+		}()
+	}
+	require.Eventually(t, func() bool { return notifier.inFlight.Load() == 2 }, time.Second, time.Millisecond)
+
+	// Notifications wait for a free slot for no longer than their timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	retry, err := jira.Notify(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.True(t, retry)
+
+	close(notifier.release)
+	wg.Wait()
+	require.Equal(t, int32(2), notifier.maxInFlight.Load())
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() == "grafana_alerting_alertmanager_notification_queued_seconds" {
+			require.Len(t, mf.GetMetric(), 1)
+			require.Equal(t, uint64(6), mf.GetMetric()[0].GetHistogram().GetSampleCount())
+			return
+		}
+	}
+	require.Fail(t, "queued time is not observed")
+}
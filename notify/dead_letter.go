@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// DeadLetterHandler is invoked with the notifications that could not be delivered by an integration,
+// either because it exhausted its retries or because it failed with an unrecoverable error.
+// It can be used to persist failed notifications for later replay.
+type DeadLetterHandler interface {
+	// HandleDeadLetter is called synchronously from the notification pipeline of the integration
+	// and must not block for long. The context is not canceled when the notification times out.
+	HandleDeadLetter(ctx context.Context, letter DeadLetter)
+}
+
+// DeadLetterHandlerFunc is a function that implements DeadLetterHandler.
+type DeadLetterHandlerFunc func(ctx context.Context, letter DeadLetter)
+
+func (f DeadLetterHandlerFunc) HandleDeadLetter(ctx context.Context, letter DeadLetter) {
+	f(ctx, letter)
+}
+
+// DeadLetter describes a notification that could not be delivered.
+type DeadLetter struct {
+	GroupKey    string
+	GroupLabels model.LabelSet
+	Receiver    string
+	Integration IntegrationMetadata
+	Alerts      []*types.Alert
+	// Err is the last error returned by the integration.
+	Err error
+}
+
+// IntegrationMetadata identifies the integration of a receiver.
+type IntegrationMetadata struct {
+	Name         string
+	Index        int
+	SendResolved bool
+}
+
+// deadLetterStage executes the wrapped stage and passes the alerts to the handler if it fails.
+type deadLetterStage struct {
+	stage       notify.Stage
+	integration *notify.Integration
+	handler     DeadLetterHandler
+}
+
+func newDeadLetterStage(stage notify.Stage, integration *notify.Integration, handler DeadLetterHandler) *deadLetterStage {
+	return &deadLetterStage{
+		stage:       stage,
+		integration: integration,
+		handler:     handler,
+	}
+}
+
+func (s *deadLetterStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	ctx, res, err := s.stage.Exec(ctx, l, alerts...)
+	if err == nil {
+		return ctx, res, nil
+	}
+
+	groupKey, _ := notify.GroupKey(ctx)
+	groupLabels, _ := notify.GroupLabels(ctx)
+	receiver, _ := notify.ReceiverName(ctx)
+	s.handler.HandleDeadLetter(context.WithoutCancel(ctx), DeadLetter{
+		GroupKey:    groupKey,
+		GroupLabels: groupLabels,
+		Receiver:    receiver,
+		Integration: IntegrationMetadata{
+			Name:         s.integration.Name(),
+			Index:        s.integration.Index(),
+			SendResolved: s.integration.SendResolved(),
+		},
+		Alerts: append([]*types.Alert(nil), alerts...),
+		Err:    err,
+	})
+	return ctx, res, err
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/featurecontrol"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFailingNotifier struct {
+	err error
+}
+
+func (f *fakeFailingNotifier) Notify(_ context.Context, _ ...*types.Alert) (bool, error) {
+	return false, f.err
+}
+
+type sendResolved bool
+
+func (s sendResolved) SendResolved() bool {
+	return bool(s)
+}
+
+func TestDeadLetterStage(t *testing.T) {
+	metrics := notify.NewMetrics(prometheus.NewRegistry(), featurecontrol.NoopFlags{})
+	alerts := []*types.Alert{{Alert: model.Alert{
+		Labels: model.LabelSet{"alertname": "alert1"},
+		EndsAt: time.Now().Add(time.Hour),
+	}}}
+
+	ctx := notify.WithGroupKey(context.Background(), "group-key")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	ctx = notify.WithReceiverName(ctx, "receiver")
+
+	t.Run("handler is called with the failed notification", func(t *testing.T) {
+		notifyErr := errors.New("unrecoverable")
+		integration := notify.NewIntegration(&fakeFailingNotifier{err: notifyErr}, sendResolved(true), "webhook", 1, "receiver")
+
+		var letters []DeadLetter
+		stage := newDeadLetterStage(notify.NewRetryStage(integration, "receiver", metrics), integration, DeadLetterHandlerFunc(func(_ context.Context, letter DeadLetter) {
+			letters = append(letters, letter)
+		}))
+
+		_, _, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.ErrorIs(t, err, notifyErr)
+		require.Len(t, letters, 1)
+		require.Equal(t, DeadLetter{
+			GroupKey:    "group-key",
+			GroupLabels: model.LabelSet{"alertname": "alert1"},
+			Receiver:    "receiver",
+			Integration: IntegrationMetadata{Name: "webhook", Index: 1, SendResolved: true},
+			Alerts:      alerts,
+			Err:         err,
+		}, letters[0])
+	})
+
+	t.Run("handler is not called when the notification succeeds", func(t *testing.T) {
+		integration := notify.NewIntegration(&fakeFailingNotifier{}, sendResolved(true), "webhook", 1, "receiver")
+
+		called := false
+		stage := newDeadLetterStage(notify.NewRetryStage(integration, "receiver", metrics), integration, DeadLetterHandlerFunc(func(_ context.Context, _ DeadLetter) {
+			called = true
+		}))
+
+		_, _, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.False(t, called)
+	})
+}
@@ -0,0 +1,244 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alerting/receivers"
+)
+
+var ErrDeliveryHistoryDisabled = errors.New("delivery history is not enabled")
+
+// DeliveryOutcome is the outcome of a notification attempt.
+type DeliveryOutcome string
+
+const (
+	DeliveryOutcomeSuccess DeliveryOutcome = "success"
+	DeliveryOutcomeFailure DeliveryOutcome = "failure"
+	// DeliveryOutcomeVetoed is the outcome of the notifications vetoed by the NotificationAuthorizer, which are not sent.
+	DeliveryOutcomeVetoed DeliveryOutcome = "vetoed"
+)
+
+// DeliveryRecord describes a single attempt of an integration to deliver a notification.
+type DeliveryRecord struct {
+	Timestamp         time.Time
+	Receiver          string
+	Integration       string
+	IntegrationIndex  int
+	GroupKey          string
+	AlertFingerprints []model.Fingerprint
+	Duration          time.Duration
+	Outcome           DeliveryOutcome
+	// Retry tells whether the attempt failed with an error that is going to be retried.
+	Retry bool
+	Error string
+	// ConfigGeneration and ConfigVersion identify the configuration that sent the notification.
+	ConfigGeneration uint64
+	ConfigVersion    string
+}
+
+// DeliveryHistoryFilter selects delivery records. Zero values match all records.
+type DeliveryHistoryFilter struct {
+	Receiver    string
+	Integration string
+	Outcome     DeliveryOutcome
+	// Fingerprint selects the records of the notifications that included the alert.
+	Fingerprint model.Fingerprint
+	// Since and Until select the records of the attempts started in the interval [Since, Until).
+	Since time.Time
+	Until time.Time
+	// Limit is the maximum number of records returned, the most recent first.
+	Limit int
+}
+
+// Matches returns true if the record is selected by the filter.
+func (f DeliveryHistoryFilter) Matches(r DeliveryRecord) bool {
+	if f.Receiver != "" && f.Receiver != r.Receiver {
+		return false
+	}
+	if f.Integration != "" && f.Integration != r.Integration {
+		return false
+	}
+	if f.Outcome != "" && f.Outcome != r.Outcome {
+		return false
+	}
+	if !f.Since.IsZero() && r.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !r.Timestamp.Before(f.Until) {
+		return false
+	}
+	if f.Fingerprint != 0 {
+		found := false
+		for _, fp := range r.AlertFingerprints {
+			if fp == f.Fingerprint {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// DeliveryStore persists delivery records.
+type DeliveryStore interface {
+	// Record stores the record of a notification attempt.
+	Record(ctx context.Context, r DeliveryRecord) error
+	// Query returns the records selected by the filter, the most recent first.
+	Query(ctx context.Context, f DeliveryHistoryFilter) ([]DeliveryRecord, error)
+}
+
+// MemoryDeliveryStore is a DeliveryStore that keeps the most recent records in memory.
+type MemoryDeliveryStore struct {
+	mtx     sync.RWMutex
+	records []DeliveryRecord
+	next    int
+	full    bool
+}
+
+// NewMemoryDeliveryStore returns a DeliveryStore that keeps up to capacity records in memory,
+// discarding the oldest records first.
+func NewMemoryDeliveryStore(capacity int) *MemoryDeliveryStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &MemoryDeliveryStore{records: make([]DeliveryRecord, capacity)}
+}
+
+func (s *MemoryDeliveryStore) Record(_ context.Context, r DeliveryRecord) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.records[s.next] = r
+	s.next = (s.next + 1) % len(s.records)
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+func (s *MemoryDeliveryStore) Query(_ context.Context, f DeliveryHistoryFilter) ([]DeliveryRecord, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	n := s.next
+	if s.full {
+		n = len(s.records)
+	}
+	var res []DeliveryRecord
+	for i := 1; i <= n; i++ {
+		r := s.records[(s.next-i+len(s.records))%len(s.records)]
+		if !f.Matches(r) {
+			continue
+		}
+		res = append(res, r)
+	}
+	// Records are added as attempts finish, so make sure the most recent attempts come first.
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Timestamp.After(res[j].Timestamp)
+	})
+	if f.Limit > 0 && len(res) > f.Limit {
+		res = res[:f.Limit]
+	}
+	return res, nil
+}
+
+// DeliveryRecorder records the notification attempts of integrations into a DeliveryStore.
+type DeliveryRecorder struct {
+	store  DeliveryStore
+	logger log.Logger
+}
+
+func NewDeliveryRecorder(store DeliveryStore, logger log.Logger) *DeliveryRecorder {
+	return &DeliveryRecorder{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Wrap returns an integration that records every notification attempt of the given integration.
+func (r *DeliveryRecorder) Wrap(integration *notify.Integration, receiver string) *notify.Integration {
+	n := &recordingNotifier{
+		upstream:    integration,
+		recorder:    r,
+		receiver:    receiver,
+		integration: integration.Name(),
+		index:       integration.Index(),
+	}
+	return notify.NewIntegration(n, integration, integration.Name(), integration.Index(), receiver)
+}
+
+// Record stores the record, logging the error if it cannot be stored.
+func (r *DeliveryRecorder) Record(ctx context.Context, rec DeliveryRecord) {
+	if err := r.store.Record(ctx, rec); err != nil {
+		level.Error(r.logger).Log("msg", "Failed to record notification delivery", "receiver", rec.Receiver, "integration", rec.Integration, "err", err)
+	}
+}
+
+// Query returns the records selected by the filter, the most recent first.
+func (r *DeliveryRecorder) Query(ctx context.Context, f DeliveryHistoryFilter) ([]DeliveryRecord, error) {
+	return r.store.Query(ctx, f)
+}
+
+// recordingNotifier wraps a notify.Notifier and records each of its notification attempts.
+type recordingNotifier struct {
+	upstream    notify.Notifier
+	recorder    *DeliveryRecorder
+	receiver    string
+	integration string
+	index       int
+}
+
+// Notify implements the Notifier interface.
+func (n *recordingNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	start := time.Now()
+	retry, err := n.upstream.Notify(ctx, alerts...)
+
+	groupKey, _ := notify.GroupKey(ctx)
+	fps := make([]model.Fingerprint, 0, len(alerts))
+	for _, a := range alerts {
+		fps = append(fps, a.Fingerprint())
+	}
+	rec := DeliveryRecord{
+		Timestamp:         start,
+		Receiver:          n.receiver,
+		Integration:       n.integration,
+		IntegrationIndex:  n.index,
+		GroupKey:          groupKey,
+		AlertFingerprints: fps,
+		Duration:          time.Since(start),
+		Outcome:           DeliveryOutcomeSuccess,
+	}
+	if g, ok := receivers.ConfigGenerationFromContext(ctx); ok {
+		rec.ConfigGeneration = g.Generation
+		rec.ConfigVersion = g.Version
+	}
+	if err != nil {
+		rec.Outcome = DeliveryOutcomeFailure
+		rec.Retry = retry
+		rec.Error = err.Error()
+	}
+	n.recorder.Record(context.WithoutCancel(ctx), rec)
+
+	return retry, err
+}
+
+// GetDeliveryHistory returns the records of the notification attempts selected by the filter, the most recent first.
+// It returns ErrDeliveryHistoryDisabled if the Alertmanager was not configured with a DeliveryStore.
+func (am *GrafanaAlertmanager) GetDeliveryHistory(ctx context.Context, filter DeliveryHistoryFilter) ([]DeliveryRecord, error) {
+	if am.deliveryRecorder == nil {
+		return nil, ErrDeliveryHistoryDisabled
+	}
+	return am.deliveryRecorder.Query(ctx, filter)
+}
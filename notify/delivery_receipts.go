@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// deliveryReceiptSize is the size of an encoded receipt: the hash of the notification, the time the receipt was
+// recorded and the time it holds until in Unix nanoseconds, and its state.
+const deliveryReceiptSize = sha256.Size + 8 + 8 + 1
+
+// The states of the receipts. The other members of the cluster hold off notifications that are being sent or were
+// delivered, and send the ones that failed.
+const (
+	receiptSending byte = iota + 1
+	receiptDelivered
+	receiptFailed
+)
+
+type deliveryReceipt struct {
+	at    time.Time
+	until time.Time
+	state byte
+}
+
+// deliveryReceipts are the receipts of the notifications sent by the members of the cluster, gossiped before the
+// first attempt of the integrations. The notification log is only gossiped once an integration succeeds, so a member
+// whose peer wait ends while the integration of another member is still retrying sends the notification again.
+// With the receipts, the member holds off the notification instead, until the receipt expires or the integration of
+// the other member fails. Receipts expire at the deadline of the notification, after which the notification log is
+// the source of truth for deduplication.
+type deliveryReceipts struct {
+	window time.Duration
+	now    func() time.Time
+
+	mtx       sync.Mutex
+	receipts  map[[sha256.Size]byte]deliveryReceipt
+	broadcast func([]byte)
+}
+
+// newDeliveryReceipts returns the receipts. Receipts of notifications without a deadline expire after the window.
+func newDeliveryReceipts(window time.Duration, now func() time.Time) *deliveryReceipts {
+	return &deliveryReceipts{
+		window:    window,
+		now:       now,
+		receipts:  make(map[[sha256.Size]byte]deliveryReceipt),
+		broadcast: func([]byte) {},
+	}
+}
+
+// SetBroadcast sets the function that gossips the receipts to the other members of the cluster.
+func (r *deliveryReceipts) SetBroadcast(f func([]byte)) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.broadcast = f
+}
+
+// record records the state of the notification, which holds until the time, and gossips it.
+func (r *deliveryReceipts) record(key [sha256.Size]byte, state byte, until time.Time) {
+	now := r.now()
+	rcpt := deliveryReceipt{at: now, until: until, state: state}
+	r.mtx.Lock()
+	r.gc(now)
+	r.receipts[key] = rcpt
+	broadcast := r.broadcast
+	r.mtx.Unlock()
+	broadcast(appendDeliveryReceipt(nil, key, rcpt))
+}
+
+// holdOff returns whether the notification is being sent or was delivered by a member of the cluster, and its
+// receipt has not expired.
+func (r *deliveryReceipts) holdOff(key [sha256.Size]byte) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	rcpt, ok := r.receipts[key]
+	return ok && rcpt.state != receiptFailed && !r.now().After(rcpt.until)
+}
+
+// gc deletes the receipts that expired.
+func (r *deliveryReceipts) gc(now time.Time) {
+	for k, rcpt := range r.receipts {
+		if now.After(rcpt.until) {
+			delete(r.receipts, k)
+		}
+	}
+}
+
+// MarshalBinary implements the cluster.State interface.
+func (r *deliveryReceipts) MarshalBinary() ([]byte, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.gc(r.now())
+	b := make([]byte, 0, len(r.receipts)*deliveryReceiptSize)
+	for k, rcpt := range r.receipts {
+		b = appendDeliveryReceipt(b, k, rcpt)
+	}
+	return b, nil
+}
+
+// Merge implements the cluster.State interface. Receipts that expired are ignored, and receipts replace the
+// receipts of the same notification recorded before them.
+func (r *deliveryReceipts) Merge(b []byte) error {
+	if len(b)%deliveryReceiptSize != 0 {
+		return errors.New("invalid delivery receipts")
+	}
+	now := r.now()
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for ; len(b) > 0; b = b[deliveryReceiptSize:] {
+		var k [sha256.Size]byte
+		copy(k[:], b)
+		rcpt := deliveryReceipt{
+			at:    time.Unix(0, int64(binary.BigEndian.Uint64(b[sha256.Size:]))),
+			until: time.Unix(0, int64(binary.BigEndian.Uint64(b[sha256.Size+8:]))),
+			state: b[sha256.Size+16],
+		}
+		if rcpt.state < receiptSending || rcpt.state > receiptFailed {
+			return errors.New("invalid delivery receipts")
+		}
+		if now.After(rcpt.until) {
+			continue
+		}
+		if prev, ok := r.receipts[k]; !ok || rcpt.at.After(prev.at) {
+			r.receipts[k] = rcpt
+		}
+	}
+	return nil
+}
+
+func appendDeliveryReceipt(b []byte, key [sha256.Size]byte, rcpt deliveryReceipt) []byte {
+	b = append(b, key[:]...)
+	b = binary.BigEndian.AppendUint64(b, uint64(rcpt.at.UnixNano()))
+	b = binary.BigEndian.AppendUint64(b, uint64(rcpt.until.UnixNano()))
+	return append(b, rcpt.state)
+}
+
+// deliveryReceiptKey returns the hash of the notification of the integration, which identifies the alert group and
+// the alerts of the notification like the key of the notification lock.
+func deliveryReceiptKey(recv *nflogpb.Receiver, gkey string, firing, resolved []uint64) [sha256.Size]byte {
+	return sha256.Sum256([]byte(notificationLockKey(0, recv, gkey, firing, resolved)))
+}
+
+// deliveryReceiptStage drops the notification if another member of the cluster is sending it or delivered it, and
+// otherwise records that it is sending the notification before sending it with the stage, and whether it was
+// delivered once the stage is done.
+type deliveryReceiptStage struct {
+	stage    notify.Stage
+	receipts *deliveryReceipts
+	recv     *nflogpb.Receiver
+	skipped  prometheus.Counter
+}
+
+func (s *deliveryReceiptStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	gkey, ok := notify.GroupKey(ctx)
+	if !ok {
+		return s.stage.Exec(ctx, l, alerts...)
+	}
+	firing, _ := notify.FiringAlerts(ctx)
+	resolved, _ := notify.ResolvedAlerts(ctx)
+
+	key := deliveryReceiptKey(s.recv, gkey, firing, resolved)
+	if s.receipts.holdOff(key) {
+		level.Debug(l).Log("msg", "Notification is sent by another member of the cluster, skipping", "group_key", gkey)
+		s.skipped.Inc()
+		return ctx, nil, nil
+	}
+
+	// The receipt holds until the notification is given up on, which is the deadline of its retries.
+	until, ok := ctx.Deadline()
+	if !ok {
+		until = s.receipts.now().Add(s.receipts.window)
+	}
+	s.receipts.record(key, receiptSending, until)
+	ctx, res, err := s.stage.Exec(ctx, l, alerts...)
+	if err != nil {
+		s.receipts.record(key, receiptFailed, until)
+		return ctx, res, err
+	}
+	s.receipts.record(key, receiptDelivered, until)
+	return ctx, res, nil
+}
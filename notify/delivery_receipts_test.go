@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryReceipts(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	clk := func() time.Time { return now }
+	r := newDeliveryReceipts(15*time.Second, clk)
+	var broadcasts [][]byte
+	r.SetBroadcast(func(b []byte) { broadcasts = append(broadcasts, b) })
+
+	key1 := sha256.Sum256([]byte("1"))
+	key2 := sha256.Sum256([]byte("2"))
+	r.record(key1, receiptSending, now.Add(15*time.Second))
+	require.True(t, r.holdOff(key1))
+	require.False(t, r.holdOff(key2))
+	require.Len(t, broadcasts, 1)
+
+	// The receipts are merged by the other members of the cluster.
+	peer := newDeliveryReceipts(15*time.Second, clk)
+	require.NoError(t, peer.Merge(broadcasts[0]))
+	require.True(t, peer.holdOff(key1))
+
+	// Later receipts replace the receipts of the same notification, and failed notifications are not held off.
+	now = now.Add(time.Second)
+	r.record(key1, receiptFailed, now.Add(14*time.Second))
+	require.False(t, r.holdOff(key1))
+	require.NoError(t, peer.Merge(broadcasts[1]))
+	require.False(t, peer.holdOff(key1))
+	require.NoError(t, peer.Merge(broadcasts[0]))
+	require.False(t, peer.holdOff(key1))
+
+	now = now.Add(9 * time.Second)
+	r.record(key2, receiptDelivered, now.Add(15*time.Second))
+	b, err := r.MarshalBinary()
+	require.NoError(t, err)
+	full := newDeliveryReceipts(15*time.Second, clk)
+	require.NoError(t, full.Merge(b))
+	require.False(t, full.holdOff(key1))
+	require.True(t, full.holdOff(key2))
+
+	// Receipts are deleted once they expire.
+	now = now.Add(10 * time.Second)
+	late := newDeliveryReceipts(15*time.Second, clk)
+	require.NoError(t, late.Merge(broadcasts[0]))
+	require.False(t, late.holdOff(key1))
+	b, err = r.MarshalBinary()
+	require.NoError(t, err)
+	require.Len(t, b, deliveryReceiptSize)
+
+	require.EqualError(t, r.Merge([]byte("invalid")), "invalid delivery receipts")
+	require.EqualError(t, r.Merge(make([]byte, deliveryReceiptSize)), "invalid delivery receipts")
+}
+
+// blockingStage is a stage that blocks until it is given the error of its execution, such as an integration that
+// retries.
+type blockingStage struct {
+	started chan struct{}
+	errc    chan error
+}
+
+func newBlockingStage() *blockingStage {
+	return &blockingStage{started: make(chan struct{}, 1), errc: make(chan error)}
+}
+
+func (s *blockingStage) Exec(ctx context.Context, _ log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	s.started <- struct{}{}
+	if err := <-s.errc; err != nil {
+		return ctx, nil, err
+	}
+	return ctx, alerts, nil
+}
+
+func TestDeliveryReceiptStage(t *testing.T) {
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+	recv := &nflogpb.Receiver{GroupName: "receiver", Integration: "webhook", Idx: 1}
+
+	ctx := notify.WithGroupKey(context.Background(), "group-key")
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	// Two members of a cluster, whose receipts are gossiped to each other.
+	receipts1 := newDeliveryReceipts(15*time.Second, time.Now)
+	receipts2 := newDeliveryReceipts(15*time.Second, time.Now)
+	receipts1.SetBroadcast(func(b []byte) { require.NoError(t, receipts2.Merge(b)) })
+	receipts2.SetBroadcast(func(b []byte) { require.NoError(t, receipts1.Merge(b)) })
+
+	// exec sends the notification of the firing alerts with the stage of the member.
+	exec := func(receipts *deliveryReceipts, next notify.Stage, skipped prometheus.Counter, firing ...uint64) ([]*types.Alert, error) {
+		stage := &deliveryReceiptStage{stage: next, receipts: receipts, recv: recv, skipped: skipped}
+		_, res, err := stage.Exec(notify.WithFiringAlerts(ctx, firing), log.NewNopLogger(), alerts...)
+		return res, err
+	}
+
+	t.Run("notifications are held off while another member retries them, and sent once it fails", func(t *testing.T) {
+		blocking := newBlockingStage()
+		errc := make(chan error, 1)
+		go func() {
+			_, err := exec(receipts1, blocking, prometheus.NewCounter(prometheus.CounterOpts{}), 1)
+			errc <- err
+		}()
+		<-blocking.started
+
+		// The peer wait of the second member ends while the first member retries.
+		next := &fakeStage{}
+		skipped := prometheus.NewCounter(prometheus.CounterOpts{})
+		res, err := exec(receipts2, next, skipped, 1)
+		require.NoError(t, err)
+		require.Empty(t, res)
+		require.Zero(t, next.calls)
+		require.Equal(t, 1.0, testutil.ToFloat64(skipped))
+
+		// The first member gives up, so the second member sends the notification on its next attempt.
+		blocking.errc <- errors.New("failed")
+		require.Error(t, <-errc)
+		res, err = exec(receipts2, next, skipped, 1)
+		require.NoError(t, err)
+		require.Equal(t, alerts, res)
+		require.Equal(t, 1, next.calls)
+	})
+
+	t.Run("notifications delivered by another member are not sent", func(t *testing.T) {
+		res, err := exec(receipts1, &fakeStage{}, prometheus.NewCounter(prometheus.CounterOpts{}), 2)
+		require.NoError(t, err)
+		require.Equal(t, alerts, res)
+
+		next := &fakeStage{}
+		skipped := prometheus.NewCounter(prometheus.CounterOpts{})
+		res, err = exec(receipts2, next, skipped, 2)
+		require.NoError(t, err)
+		require.Empty(t, res)
+		require.Zero(t, next.calls)
+		require.Equal(t, 1.0, testutil.ToFloat64(skipped))
+
+		// Notifications with other alerts are sent.
+		res, err = exec(receipts2, next, skipped, 2, 3)
+		require.NoError(t, err)
+		require.Equal(t, alerts, res)
+		require.Equal(t, 1, next.calls)
+	})
+
+	t.Run("receipts hold until the deadline of the notification", func(t *testing.T) {
+		deadline, _ := ctx.Deadline()
+		_, err := exec(receipts1, &fakeStage{}, prometheus.NewCounter(prometheus.CounterOpts{}), 4)
+		require.NoError(t, err)
+		rcpt := receipts2.receipts[deliveryReceiptKey(recv, "group-key", []uint64{4}, nil)]
+		require.Equal(t, receiptDelivered, rcpt.state)
+		require.Equal(t, deadline.UnixNano(), rcpt.until.UnixNano())
+	})
+}
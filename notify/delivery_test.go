@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/receivers"
+)
+
+func TestMemoryDeliveryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryDeliveryStore(3)
+	for i, rec := range []DeliveryRecord{
+		{Receiver: "a", Integration: "email", Outcome: DeliveryOutcomeSuccess},
+		{Receiver: "a", Integration: "slack", Outcome: DeliveryOutcomeFailure, AlertFingerprints: []model.Fingerprint{1}},
+		{Receiver: "b", Integration: "email", Outcome: DeliveryOutcomeSuccess, AlertFingerprints: []model.Fingerprint{1, 2}},
+		{Receiver: "b", Integration: "slack", Outcome: DeliveryOutcomeFailure},
+	} {
+		rec.Timestamp = now.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, store.Record(ctx, rec))
+	}
+
+	integrations := func(recs []DeliveryRecord) []string {
+		res := make([]string, 0, len(recs))
+		for _, r := range recs {
+			res = append(res, r.Receiver+"/"+r.Integration)
+		}
+		return res
+	}
+
+	tests := []struct {
+		name     string
+		filter   DeliveryHistoryFilter
+		expected []string
+	}{
+		{
+			name:     "oldest records are discarded",
+			expected: []string{"b/slack", "b/email", "a/slack"},
+		},
+		{
+			name:     "by receiver",
+			filter:   DeliveryHistoryFilter{Receiver: "b"},
+			expected: []string{"b/slack", "b/email"},
+		},
+		{
+			name:     "by integration and outcome",
+			filter:   DeliveryHistoryFilter{Integration: "slack", Outcome: DeliveryOutcomeFailure},
+			expected: []string{"b/slack", "a/slack"},
+		},
+		{
+			name:     "by fingerprint",
+			filter:   DeliveryHistoryFilter{Fingerprint: 1},
+			expected: []string{"b/email", "a/slack"},
+		},
+		{
+			name:     "by time",
+			filter:   DeliveryHistoryFilter{Since: now.Add(time.Minute), Until: now.Add(3 * time.Minute)},
+			expected: []string{"b/email", "a/slack"},
+		},
+		{
+			name:     "with limit",
+			filter:   DeliveryHistoryFilter{Limit: 1},
+			expected: []string{"b/slack"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs, err := store.Query(ctx, tt.filter)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, integrations(recs))
+		})
+	}
+}
+
+func TestDeliveryRecorder(t *testing.T) {
+	store := NewMemoryDeliveryStore(10)
+	recorder := NewDeliveryRecorder(store, log.NewNopLogger())
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+	ctx := notify.WithGroupKey(context.Background(), "group-key")
+	ctx = receivers.WithConfigGeneration(ctx, receivers.ConfigGeneration{Generation: 3, Version: "v3"})
+
+	notifier := &fakeFailingNotifier{}
+	integration := recorder.Wrap(notify.NewIntegration(notifier, sendResolved(true), "webhook", 2, "receiver"), "receiver")
+	require.Equal(t, "webhook", integration.Name())
+	require.Equal(t, 2, integration.Index())
+
+	_, err := integration.Notify(ctx, alert)
+	require.NoError(t, err)
+	notifier.err = errors.New("failed")
+	_, err = integration.Notify(ctx, alert)
+	require.Error(t, err)
+
+	recs, err := recorder.Query(ctx, DeliveryHistoryFilter{})
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	for _, r := range recs {
+		require.Equal(t, "receiver", r.Receiver)
+		require.Equal(t, "webhook", r.Integration)
+		require.Equal(t, 2, r.IntegrationIndex)
+		require.Equal(t, "group-key", r.GroupKey)
+		require.Equal(t, []model.Fingerprint{alert.Fingerprint()}, r.AlertFingerprints)
+		require.False(t, r.Timestamp.IsZero())
+		require.Equal(t, uint64(3), r.ConfigGeneration)
+		require.Equal(t, "v3", r.ConfigVersion)
+	}
+	require.Equal(t, DeliveryOutcomeFailure, recs[0].Outcome)
+	require.Equal(t, "failed", recs[0].Error)
+	require.Equal(t, DeliveryOutcomeSuccess, recs[1].Outcome)
+	require.Empty(t, recs[1].Error)
+}
+
+func TestGetDeliveryHistory(t *testing.T) {
+	am, _ := setupAMTest(t)
+	_, err := am.GetDeliveryHistory(context.Background(), DeliveryHistoryFilter{})
+	require.ErrorIs(t, err, ErrDeliveryHistoryDisabled)
+
+	store := NewMemoryDeliveryStore(10)
+	require.NoError(t, store.Record(context.Background(), DeliveryRecord{Receiver: "a"}))
+	am.deliveryRecorder = NewDeliveryRecorder(store, log.NewNopLogger())
+	recs, err := am.GetDeliveryHistory(context.Background(), DeliveryHistoryFilter{})
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+}
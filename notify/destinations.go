@@ -0,0 +1,252 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/wecom"
+)
+
+// DestinationKind is the kind of the destination of an integration.
+type DestinationKind string
+
+const (
+	// DestinationURL is the host and path of the URL that an integration sends notifications to.
+	DestinationURL DestinationKind = "url"
+	// DestinationSlackChannel is the Slack channel that an integration posts to with a token. Only channel IDs identify
+	// a channel, channels with the same name in different workspaces have the same fingerprint.
+	DestinationSlackChannel DestinationKind = "slack_channel"
+	// DestinationEmail is an email address.
+	DestinationEmail DestinationKind = "email"
+	// DestinationChat is a chat, room or user of a messaging service, such as a Telegram chat or a Webex room.
+	DestinationChat DestinationKind = "chat"
+	// DestinationKey is a routing or integration key of an incident management service, such as PagerDuty.
+	DestinationKey DestinationKind = "key"
+	// DestinationTopic is a topic of a message broker, such as Kafka, MQTT or SNS.
+	DestinationTopic DestinationKind = "topic"
+)
+
+// DestinationFingerprint is the fingerprint of a destination of an integration. Integrations that send notifications
+// to the same destination have the same fingerprint, even in different tenants and with different types, such as a
+// webhook and an OnCall integration with the same URL.
+type DestinationFingerprint struct {
+	Receiver        string          `json:"receiver"`
+	IntegrationUID  string          `json:"integrationUid"`
+	IntegrationType string          `json:"integrationType"`
+	Kind            DestinationKind `json:"kind"`
+	Fingerprint     string          `json:"fingerprint"`
+}
+
+// DestinationFingerprinter computes the fingerprints of the destinations of integrations, so that operators can find
+// the same destination configured in many tenants without reading the destinations, which can contain secrets.
+// Fingerprints are keyed hashes, so that destinations with few possible values, such as Slack channel IDs, cannot be
+// found by hashing all of them without the key. Fingerprints computed with different keys cannot be compared.
+type DestinationFingerprinter struct {
+	key []byte
+}
+
+// NewDestinationFingerprinter returns a DestinationFingerprinter that hashes destinations with the key.
+func NewDestinationFingerprinter(key []byte) (*DestinationFingerprinter, error) {
+	if len(key) == 0 {
+		return nil, errors.New("key must not be empty")
+	}
+	return &DestinationFingerprinter{key: key}, nil
+}
+
+// Fingerprints returns the fingerprints of the destinations of the integrations of the receiver. Integrations can
+// have several destinations, such as the addresses of an email integration, and integrations whose destinations are
+// not identified, such as blackhole integrations, have none.
+func (f *DestinationFingerprinter) Fingerprints(cfg GrafanaReceiverConfig) []DestinationFingerprint {
+	var res []DestinationFingerprint
+	add := func(meta receivers.Metadata, kind DestinationKind, value string) {
+		if value == "" {
+			return
+		}
+		res = append(res, DestinationFingerprint{
+			Receiver:        cfg.Name,
+			IntegrationUID:  meta.UID,
+			IntegrationType: meta.Type,
+			Kind:            kind,
+			Fingerprint:     f.fingerprint(kind, value),
+		})
+	}
+	addURL := func(meta receivers.Metadata, u string) {
+		add(meta, DestinationURL, normalizeDestinationURL(u))
+	}
+
+	for _, c := range cfg.AlertmanagerConfigs {
+		for _, u := range c.Settings.URLs {
+			addURL(c.Metadata, u.String())
+		}
+	}
+	for _, c := range cfg.DingdingConfigs {
+		addURL(c.Metadata, c.Settings.URL)
+	}
+	for _, c := range cfg.DiscordConfigs {
+		addURL(c.Metadata, c.Settings.WebhookURL)
+	}
+	for _, c := range cfg.EmailConfigs {
+		for _, a := range c.Settings.Addresses {
+			add(c.Metadata, DestinationEmail, strings.ToLower(strings.TrimSpace(a)))
+		}
+	}
+	for _, c := range cfg.FeishuConfigs {
+		addURL(c.Metadata, c.Settings.URL)
+	}
+	for _, c := range cfg.GooglechatConfigs {
+		addURL(c.Metadata, c.Settings.URL)
+	}
+	for _, c := range cfg.KafkaConfigs {
+		add(c.Metadata, DestinationTopic, joinDestination(normalizeDestinationURL(c.Settings.Endpoint), c.Settings.Topic))
+	}
+	for _, c := range cfg.MatrixConfigs {
+		add(c.Metadata, DestinationChat, joinDestination(c.Settings.RoomID, normalizeDestinationURL(c.Settings.HomeserverURL)))
+	}
+	for _, c := range cfg.MqttConfigs {
+		add(c.Metadata, DestinationTopic, joinDestination(normalizeDestinationURL(c.Settings.BrokerURL), c.Settings.Topic))
+	}
+	for _, c := range cfg.OnCallConfigs {
+		addURL(c.Metadata, c.Settings.URL)
+	}
+	for _, c := range cfg.OpsgenieConfigs {
+		add(c.Metadata, DestinationKey, c.Settings.APIKey)
+	}
+	for _, c := range cfg.PagerdutyConfigs {
+		add(c.Metadata, DestinationKey, c.Settings.Key)
+	}
+	for _, c := range cfg.PushoverConfigs {
+		add(c.Metadata, DestinationChat, c.Settings.UserKey)
+	}
+	for _, c := range cfg.SensugoConfigs {
+		addURL(c.Metadata, c.Settings.URL)
+	}
+	for _, c := range cfg.SlackConfigs {
+		if c.Settings.Token == "" {
+			addURL(c.Metadata, c.Settings.URL)
+			continue
+		}
+		add(c.Metadata, DestinationSlackChannel, c.Settings.Recipient)
+	}
+	for _, c := range cfg.SNSConfigs {
+		for _, v := range []string{c.Settings.TopicARN, c.Settings.TargetARN, c.Settings.PhoneNumber} {
+			add(c.Metadata, DestinationTopic, v)
+		}
+	}
+	for _, c := range cfg.TeamsConfigs {
+		addURL(c.Metadata, c.Settings.URL)
+	}
+	for _, c := range cfg.TelegramConfigs {
+		add(c.Metadata, DestinationChat, joinDestination(c.Settings.ChatID, c.Settings.MessageThreadID))
+	}
+	for _, c := range cfg.ThreemaConfigs {
+		add(c.Metadata, DestinationChat, c.Settings.RecipientID)
+	}
+	for _, c := range cfg.VictoropsConfigs {
+		addURL(c.Metadata, c.Settings.URL)
+	}
+	for _, c := range cfg.WebexConfigs {
+		add(c.Metadata, DestinationChat, c.Settings.RoomID)
+	}
+	for _, c := range cfg.WebhookConfigs {
+		addURL(c.Metadata, c.Settings.URL)
+	}
+	for _, c := range cfg.WecomConfigs {
+		if c.Settings.Channel == wecom.DefaultChannelType {
+			addURL(c.Metadata, c.Settings.URL)
+			continue
+		}
+		add(c.Metadata, DestinationChat, joinDestination(c.Settings.CorpID, c.Settings.AgentID, c.Settings.ToUser))
+	}
+	for _, c := range cfg.ZendutyConfigs {
+		add(c.Metadata, DestinationKey, c.Settings.IntegrationKey)
+	}
+	return res
+}
+
+func (f *DestinationFingerprinter) fingerprint(kind DestinationKind, value string) string {
+	h := hmac.New(sha256.New, f.key)
+	h.Write([]byte(kind))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeDestinationURL returns the host and path of the URL, so that URLs that differ only in their scheme,
+// default port, credentials, query or trailing slash are the same destination. URLs that cannot be parsed are
+// returned as they are.
+func normalizeDestinationURL(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return s
+	}
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host = net.JoinHostPort(host, port)
+	}
+	return host + strings.TrimSuffix(u.EscapedPath(), "/")
+}
+
+// joinDestination joins the parts of a destination, and returns an empty destination if its first part is empty.
+func joinDestination(parts ...string) string {
+	if len(parts) == 0 || parts[0] == "" {
+		return ""
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// SharedDestination is a destination configured in several tenants.
+type SharedDestination struct {
+	Kind        DestinationKind `json:"kind"`
+	Fingerprint string          `json:"fingerprint"`
+	Tenants     []string        `json:"tenants"`
+}
+
+// SharedDestinations returns the destinations configured in at least minTenants of the tenants, ordered by the
+// number of tenants, most shared first. The fingerprints of all tenants must be computed with the same key.
+func SharedDestinations(byTenant map[string][]DestinationFingerprint, minTenants int) []SharedDestination {
+	type destination struct {
+		kind        DestinationKind
+		fingerprint string
+	}
+	tenants := make(map[destination]map[string]struct{})
+	for tenant, fps := range byTenant {
+		for _, fp := range fps {
+			d := destination{kind: fp.Kind, fingerprint: fp.Fingerprint}
+			if tenants[d] == nil {
+				tenants[d] = make(map[string]struct{})
+			}
+			tenants[d][tenant] = struct{}{}
+		}
+	}
+
+	var res []SharedDestination
+	for d, ts := range tenants {
+		if len(ts) < minTenants {
+			continue
+		}
+		shared := SharedDestination{Kind: d.kind, Fingerprint: d.fingerprint, Tenants: make([]string, 0, len(ts))}
+		for t := range ts {
+			shared.Tenants = append(shared.Tenants, t)
+		}
+		sort.Strings(shared.Tenants)
+		res = append(res, shared)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if len(res[i].Tenants) != len(res[j].Tenants) {
+			return len(res[i].Tenants) > len(res[j].Tenants)
+		}
+		return res[i].Fingerprint < res[j].Fingerprint
+	})
+	return res
+}
@@ -0,0 +1,94 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// Enricher adds annotations to alerts. It is the same as notify.Enricher.
+type Enricher interface {
+	Enrich(ctx context.Context, alerts []*types.Alert) ([]model.LabelSet, error)
+}
+
+// CachingEnricher caches the annotations that an enricher returns for each alert, so that alerts that are notified
+// again, or to several receivers, do not call the enricher until the TTL expires. Only the alerts whose annotations
+// are not cached are passed to the enricher.
+//
+// Annotations are cached by the labels of the alerts, so the enricher must not depend on the annotations or the
+// times of the alerts.
+type CachingEnricher struct {
+	upstream Enricher
+	ttl      time.Duration
+	now      func() time.Time
+
+	mtx     sync.Mutex
+	entries map[model.Fingerprint]cacheEntry
+}
+
+type cacheEntry struct {
+	annotations model.LabelSet
+	expiresAt   time.Time
+}
+
+// NewCachingEnricher returns a new CachingEnricher that caches the annotations of e for ttl.
+func NewCachingEnricher(e Enricher, ttl time.Duration) (*CachingEnricher, error) {
+	if ttl <= 0 {
+		return nil, errors.New("TTL must be positive")
+	}
+	return &CachingEnricher{
+		upstream: e,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[model.Fingerprint]cacheEntry),
+	}, nil
+}
+
+// Enrich returns the cached annotations of the alerts, and the annotations of the enricher for the others.
+// Errors of the enricher are not cached.
+func (c *CachingEnricher) Enrich(ctx context.Context, alerts []*types.Alert) ([]model.LabelSet, error) {
+	now := c.now()
+	res := make([]model.LabelSet, len(alerts))
+	var missing []*types.Alert
+	var missingIdx []int
+
+	c.mtx.Lock()
+	for i, a := range alerts {
+		if e, ok := c.entries[a.Labels.Fingerprint()]; ok && now.Before(e.expiresAt) {
+			res[i] = e.annotations
+			continue
+		}
+		missing = append(missing, a)
+		missingIdx = append(missingIdx, i)
+	}
+	c.mtx.Unlock()
+	if len(missing) == 0 {
+		return res, nil
+	}
+
+	annotations, err := c.upstream.Enrich(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(annotations) != len(missing) {
+		return nil, fmt.Errorf("expected annotations for %d alerts, got %d", len(missing), len(annotations))
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for fp, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, fp)
+		}
+	}
+	for j, a := range missing {
+		c.entries[a.Labels.Fingerprint()] = cacheEntry{annotations: annotations[j], expiresAt: now.Add(c.ttl)}
+		res[missingIdx[j]] = annotations[j]
+	}
+	return res, nil
+}
@@ -0,0 +1,157 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticEnricher(t *testing.T) {
+	e, err := NewStaticEnricher("service", map[model.LabelValue]model.LabelSet{
+		"api": {"owner": "team-a", "runbook_url": "http://runbooks/api"},
+		"db":  {"owner": "team-b"},
+	})
+	require.NoError(t, err)
+
+	res, err := e.Enrich(context.Background(), []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"service": "api"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"service": "db"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"service": "cache"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "test"}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []model.LabelSet{
+		{"owner": "team-a", "runbook_url": "http://runbooks/api"},
+		{"owner": "team-b"},
+		nil,
+		nil,
+	}, res)
+
+	_, err = NewStaticEnricher("invalid label", nil)
+	require.EqualError(t, err, "invalid label name")
+}
+
+func TestHTTPEnricher(t *testing.T) {
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}, Annotations: model.LabelSet{"summary": "s1"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2"}}},
+	}
+
+	t.Run("returns the annotations of the response", func(t *testing.T) {
+		var req httpEnrichRequest
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			_, _ = w.Write([]byte(`{"annotations": [{"owner": "team-a"}, {"owner": "team-b"}]}`))
+		}))
+		t.Cleanup(srv.Close)
+
+		e, err := NewHTTPEnricher(HTTPConfig{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+		require.NoError(t, err)
+		res, err := e.Enrich(context.Background(), alerts)
+		require.NoError(t, err)
+		require.Equal(t, []model.LabelSet{{"owner": "team-a"}, {"owner": "team-b"}}, res)
+
+		require.Len(t, req.Alerts, 2)
+		require.Equal(t, alerts[0].Labels, req.Alerts[0].Labels)
+		require.Equal(t, alerts[0].Annotations, req.Alerts[0].Annotations)
+	})
+
+	t.Run("returns error for unexpected status codes", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("failed"))
+		}))
+		t.Cleanup(srv.Close)
+
+		e, err := NewHTTPEnricher(HTTPConfig{URL: srv.URL})
+		require.NoError(t, err)
+		_, err = e.Enrich(context.Background(), alerts)
+		require.EqualError(t, err, "unexpected status code 500: failed")
+	})
+
+	t.Run("returns error on timeout", func(t *testing.T) {
+		done := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-done:
+			}
+		}))
+		t.Cleanup(srv.Close)
+		t.Cleanup(func() { close(done) })
+
+		e, err := NewHTTPEnricher(HTTPConfig{URL: srv.URL, Timeout: 10 * time.Millisecond})
+		require.NoError(t, err)
+		_, err = e.Enrich(context.Background(), alerts)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		_, err := NewHTTPEnricher(HTTPConfig{})
+		require.EqualError(t, err, "URL must be set")
+		_, err = NewHTTPEnricher(HTTPConfig{URL: "http://localhost", Timeout: -1})
+		require.EqualError(t, err, "timeout must not be negative")
+	})
+}
+
+type countingEnricher struct {
+	calls [][]*types.Alert
+	err   error
+}
+
+func (e *countingEnricher) Enrich(_ context.Context, alerts []*types.Alert) ([]model.LabelSet, error) {
+	e.calls = append(e.calls, alerts)
+	if e.err != nil {
+		return nil, e.err
+	}
+	res := make([]model.LabelSet, 0, len(alerts))
+	for _, a := range alerts {
+		res = append(res, model.LabelSet{"owner": "owner-of-" + a.Labels["service"]})
+	}
+	return res, nil
+}
+
+func TestCachingEnricher(t *testing.T) {
+	api := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"service": "api"}}}
+	db := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"service": "db"}}}
+
+	upstream := &countingEnricher{}
+	e, err := NewCachingEnricher(upstream, time.Minute)
+	require.NoError(t, err)
+	now := time.Now()
+	e.now = func() time.Time { return now }
+
+	res, err := e.Enrich(context.Background(), []*types.Alert{api})
+	require.NoError(t, err)
+	require.Equal(t, []model.LabelSet{{"owner": "owner-of-api"}}, res)
+
+	// Only the alerts that are not cached are passed to the enricher.
+	res, err = e.Enrich(context.Background(), []*types.Alert{db, api})
+	require.NoError(t, err)
+	require.Equal(t, []model.LabelSet{{"owner": "owner-of-db"}, {"owner": "owner-of-api"}}, res)
+	require.Equal(t, [][]*types.Alert{{api}, {db}}, upstream.calls)
+
+	// Expired annotations are fetched again, and errors are not cached.
+	now = now.Add(time.Minute)
+	upstream.err = errors.New("failed")
+	_, err = e.Enrich(context.Background(), []*types.Alert{api})
+	require.ErrorIs(t, err, upstream.err)
+	upstream.err = nil
+	_, err = e.Enrich(context.Background(), []*types.Alert{api})
+	require.NoError(t, err)
+	require.Len(t, upstream.calls, 4)
+	require.Len(t, e.entries, 1, "expired entries should be removed")
+
+	_, err = NewCachingEnricher(upstream, 0)
+	require.EqualError(t, err, "TTL must be positive")
+}
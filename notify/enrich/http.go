@@ -0,0 +1,124 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// DefaultHTTPTimeout is the timeout of the requests of an HTTPEnricher if no timeout is configured.
+const DefaultHTTPTimeout = 5 * time.Second
+
+// HTTPConfig is the configuration of an HTTPEnricher.
+type HTTPConfig struct {
+	// URL is the URL the alerts are sent to.
+	URL string
+	// Headers are added to each request, such as for authorization.
+	Headers map[string]string
+	// Timeout is the timeout of each request. It defaults to DefaultHTTPTimeout.
+	Timeout time.Duration
+	// Client is the HTTP client used to send requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// HTTPEnricher gets the annotations to add to alerts from an HTTP service. The alerts are sent in a POST request:
+//
+//	{"alerts": [{"labels": {...}, "annotations": {...}, "startsAt": "...", "endsAt": "..."}]}
+//
+// The service responds with the annotations to add to each alert, in the same order:
+//
+//	{"annotations": [{...}]}
+type HTTPEnricher struct {
+	cfg    HTTPConfig
+	client *http.Client
+}
+
+// NewHTTPEnricher returns a new HTTPEnricher.
+func NewHTTPEnricher(cfg HTTPConfig) (*HTTPEnricher, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("URL must be set")
+	}
+	if cfg.Timeout < 0 {
+		return nil, errors.New("timeout must not be negative")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultHTTPTimeout
+	}
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPEnricher{cfg: cfg, client: client}, nil
+}
+
+type httpEnrichRequest struct {
+	Alerts []httpEnrichAlert `json:"alerts"`
+}
+
+type httpEnrichAlert struct {
+	Labels      model.LabelSet `json:"labels"`
+	Annotations model.LabelSet `json:"annotations"`
+	StartsAt    time.Time      `json:"startsAt"`
+	EndsAt      time.Time      `json:"endsAt"`
+}
+
+type httpEnrichResponse struct {
+	Annotations []model.LabelSet `json:"annotations"`
+}
+
+// Enrich sends the alerts to the service and returns the annotations of its response.
+func (h *HTTPEnricher) Enrich(ctx context.Context, alerts []*types.Alert) ([]model.LabelSet, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+	defer cancel()
+
+	body := httpEnrichRequest{Alerts: make([]httpEnrichAlert, 0, len(alerts))}
+	for _, a := range alerts {
+		body.Alerts = append(body.Alerts, httpEnrichAlert{
+			Labels:      a.Labels,
+			Annotations: a.Annotations,
+			StartsAt:    a.StartsAt,
+			EndsAt:      a.EndsAt,
+		})
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.cfg.URL, bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Grafana")
+	for k, v := range h.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, data)
+	}
+
+	var res httpEnrichResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return res.Annotations, nil
+}
@@ -0,0 +1,42 @@
+// Package enrich provides enrichers that add annotations to the alerts of notifications.
+package enrich
+
+import (
+	"context"
+	"errors"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// StaticEnricher adds annotations to alerts based on the value of one of their labels, such as a runbook URL for
+// each value of the alertname label, or the owner of each value of the service label.
+type StaticEnricher struct {
+	label       model.LabelName
+	annotations map[model.LabelValue]model.LabelSet
+}
+
+// NewStaticEnricher returns a new StaticEnricher that adds the annotations mapped to the value of the label of an alert.
+func NewStaticEnricher(label model.LabelName, annotations map[model.LabelValue]model.LabelSet) (*StaticEnricher, error) {
+	if !label.IsValid() {
+		return nil, errors.New("invalid label name")
+	}
+	for _, a := range annotations {
+		if err := a.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &StaticEnricher{label: label, annotations: annotations}, nil
+}
+
+// Enrich returns the annotations mapped to the value of the label of each alert. Alerts without the label,
+// or with an unmapped value, get no annotations.
+func (s *StaticEnricher) Enrich(_ context.Context, alerts []*types.Alert) ([]model.LabelSet, error) {
+	res := make([]model.LabelSet, len(alerts))
+	for i, a := range alerts {
+		if v, ok := a.Labels[s.label]; ok {
+			res[i] = s.annotations[v]
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,199 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alerting/definition"
+)
+
+// DefaultEnrichmentTimeout is for how long an enricher can run if no timeout is configured.
+const DefaultEnrichmentTimeout = 10 * time.Second
+
+// Enricher adds annotations to alerts before they are sent to receivers, such as runbook URLs, owners,
+// or metadata from a CMDB. Only annotations can be added, as labels identify alerts and their groups.
+type Enricher interface {
+	// Enrich returns the annotations to add to each of the alerts, in the same order as the alerts. The alerts must
+	// not be modified. Annotations that the alerts already have are overwritten, and empty values remove them.
+	Enrich(ctx context.Context, alerts []*types.Alert) ([]model.LabelSet, error)
+}
+
+// EnricherFunc is an Enricher that is a function.
+type EnricherFunc func(ctx context.Context, alerts []*types.Alert) ([]model.LabelSet, error)
+
+func (f EnricherFunc) Enrich(ctx context.Context, alerts []*types.Alert) ([]model.LabelSet, error) {
+	return f(ctx, alerts)
+}
+
+// EnrichmentFailurePolicy is what happens to a notification when one of its enrichers fails or times out.
+type EnrichmentFailurePolicy string
+
+const (
+	// EnrichmentFailureSkip skips the enricher, the notification is sent without its annotations. It is the default.
+	EnrichmentFailureSkip EnrichmentFailurePolicy = "skip"
+	// EnrichmentFailureAbort does not send the notification. The alert group is notified again at its next flush.
+	EnrichmentFailureAbort EnrichmentFailurePolicy = "abort"
+)
+
+func (p EnrichmentFailurePolicy) Validate() error {
+	switch p {
+	case "", EnrichmentFailureSkip, EnrichmentFailureAbort:
+		return nil
+	default:
+		return fmt.Errorf("unknown enrichment failure policy %q", p)
+	}
+}
+
+// namedEnricher is an enricher with the name it is logged with.
+type namedEnricher struct {
+	name string
+	Enricher
+}
+
+// routeEnrichers are the enrichers of the alert groups of a route, see definition.Route.Enrichers.
+type routeEnrichers struct {
+	routeKey  string
+	enrichers []namedEnricher
+}
+
+// validateRouteEnrichers returns an error if a route of the Grafana routing tree of the configuration references
+// an enricher that is not in enrichers.
+func validateRouteEnrichers(cfg Configuration, enrichers map[string]Enricher) error {
+	c, ok := cfg.(GrafanaRoutingTreeConfiguration)
+	if !ok {
+		return nil
+	}
+	var validate func(r *definition.Route) error
+	validate = func(r *definition.Route) error {
+		for _, name := range r.Enrichers {
+			if _, ok := enrichers[name]; !ok {
+				return fmt.Errorf("route with receiver %q references unknown enricher %q", r.Receiver, name)
+			}
+		}
+		for _, child := range r.Routes {
+			if err := validate(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return validate(c.GrafanaRoutingTree())
+}
+
+// buildRouteEnrichers returns the enrichers of the routes of the tree r, built from the Grafana routing tree of the
+// configuration, in the order of the tree. The names of the enrichers must be valid, see validateRouteEnrichers.
+func buildRouteEnrichers(cfg Configuration, r *dispatch.Route, enrichers map[string]Enricher) []routeEnrichers {
+	c, ok := cfg.(GrafanaRoutingTreeConfiguration)
+	if !ok {
+		return nil
+	}
+	var res []routeEnrichers
+	var build func(gr *definition.Route, r *dispatch.Route)
+	build = func(gr *definition.Route, r *dispatch.Route) {
+		if len(gr.Enrichers) > 0 {
+			re := routeEnrichers{routeKey: r.Key()}
+			for _, name := range gr.Enrichers {
+				re.enrichers = append(re.enrichers, namedEnricher{name: name, Enricher: enrichers[name]})
+			}
+			res = append(res, re)
+		}
+		for i := range gr.Routes {
+			build(gr.Routes[i], r.Routes[i])
+		}
+	}
+	build(c.GrafanaRoutingTree(), r)
+	return res
+}
+
+// enrichmentStage enriches the alerts of a notification with each enricher in turn, the enrichers of all routes
+// followed by the enrichers of the route of the alert group. The alerts are copied, as they are shared with the
+// dispatcher and other receivers. An enricher that fails or times out is skipped, unless the failure policy aborts
+// the notification.
+//
+// Like the routes that notify once, routes with the same key share their enrichers, see notifyOnceStage.
+type enrichmentStage struct {
+	enrichers []namedEnricher
+	routes    []routeEnrichers
+	timeout   time.Duration
+	policy    EnrichmentFailurePolicy
+}
+
+func newEnrichmentStage(enrichers []Enricher, routes []routeEnrichers, timeout time.Duration, policy EnrichmentFailurePolicy) *enrichmentStage {
+	named := make([]namedEnricher, 0, len(enrichers))
+	for i, e := range enrichers {
+		named = append(named, namedEnricher{name: strconv.Itoa(i), Enricher: e})
+	}
+	return &enrichmentStage{enrichers: named, routes: routes, timeout: timeout, policy: policy}
+}
+
+func (s *enrichmentStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	if len(alerts) == 0 {
+		return ctx, alerts, nil
+	}
+	enrichers := s.enrichers
+	if gkey, ok := notify.GroupKey(ctx); ok {
+		for _, r := range s.routes {
+			if strings.HasPrefix(gkey, r.routeKey+":") {
+				enrichers = append(enrichers[:len(enrichers):len(enrichers)], r.enrichers...)
+			}
+		}
+	}
+	if len(enrichers) == 0 {
+		return ctx, alerts, nil
+	}
+
+	res := make([]*types.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		c := *a
+		c.Annotations = a.Annotations.Clone()
+		res = append(res, &c)
+	}
+	for _, e := range enrichers {
+		annotations, err := s.enrich(ctx, e, res)
+		if err != nil {
+			if s.policy == EnrichmentFailureAbort {
+				return ctx, nil, fmt.Errorf("failed to enrich alerts with enricher %s: %w", e.name, err)
+			}
+			level.Warn(l).Log("msg", "Failed to enrich alerts, skipping enricher", "enricher", e.name, "err", err)
+			continue
+		}
+		for j, a := range res {
+			for name, value := range annotations[j] {
+				if value == "" {
+					delete(a.Annotations, name)
+				} else {
+					a.Annotations[name] = value
+				}
+			}
+		}
+	}
+	return ctx, res, nil
+}
+
+func (s *enrichmentStage) enrich(ctx context.Context, e Enricher, alerts []*types.Alert) ([]model.LabelSet, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	annotations, err := e.Enrich(ctx, alerts)
+	if err != nil {
+		return nil, err
+	}
+	if len(annotations) != len(alerts) {
+		return nil, fmt.Errorf("expected annotations for %d alerts, got %d", len(alerts), len(annotations))
+	}
+	for _, a := range annotations {
+		if err := a.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid annotations: %w", err)
+		}
+	}
+	return annotations, nil
+}
@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+)
+
+func TestEnrichmentStage(t *testing.T) {
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}, Annotations: model.LabelSet{"summary": "s1", "description": "d1"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2"}}},
+	}
+
+	t.Run("annotations of enrichers are added in order", func(t *testing.T) {
+		stage := newEnrichmentStage([]Enricher{
+			EnricherFunc(func(_ context.Context, _ []*types.Alert) ([]model.LabelSet, error) {
+				return []model.LabelSet{{"runbook_url": "http://runbook/1", "description": ""}, {"runbook_url": "http://runbook/2"}}, nil
+			}),
+			EnricherFunc(func(_ context.Context, alerts []*types.Alert) ([]model.LabelSet, error) {
+				// Later enrichers see the annotations of earlier ones.
+				require.Equal(t, model.LabelValue("http://runbook/1"), alerts[0].Annotations["runbook_url"])
+				return []model.LabelSet{{"summary": "enriched"}, nil}, nil
+			}),
+		}, nil, time.Second, "")
+
+		_, res, err := stage.Exec(context.Background(), log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, model.LabelSet{"summary": "enriched", "runbook_url": "http://runbook/1"}, res[0].Annotations)
+		require.Equal(t, model.LabelSet{"runbook_url": "http://runbook/2"}, res[1].Annotations)
+		require.Equal(t, alerts[0].Labels, res[0].Labels)
+
+		// The alerts are not modified.
+		require.Equal(t, model.LabelSet{"summary": "s1", "description": "d1"}, alerts[0].Annotations)
+		require.Nil(t, alerts[1].Annotations)
+	})
+
+	t.Run("failing enrichers are skipped", func(t *testing.T) {
+		stage := newEnrichmentStage([]Enricher{
+			EnricherFunc(func(_ context.Context, _ []*types.Alert) ([]model.LabelSet, error) {
+				return nil, errors.New("failed")
+			}),
+			EnricherFunc(func(ctx context.Context, _ []*types.Alert) ([]model.LabelSet, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}),
+			EnricherFunc(func(_ context.Context, _ []*types.Alert) ([]model.LabelSet, error) {
+				return []model.LabelSet{{"owner": "team-a"}}, nil
+			}),
+			EnricherFunc(func(_ context.Context, _ []*types.Alert) ([]model.LabelSet, error) {
+				return []model.LabelSet{{"owner": "team-b"}, {"invalid name": "value"}}, nil
+			}),
+			EnricherFunc(func(_ context.Context, _ []*types.Alert) ([]model.LabelSet, error) {
+				return []model.LabelSet{{"team": "team-c"}, {"team": "team-d"}}, nil
+			}),
+		}, nil, 10*time.Millisecond, "")
+
+		_, res, err := stage.Exec(context.Background(), log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, model.LabelSet{"summary": "s1", "description": "d1", "team": "team-c"}, res[0].Annotations)
+		require.Equal(t, model.LabelSet{"team": "team-d"}, res[1].Annotations)
+	})
+
+	t.Run("abort policy fails the notification", func(t *testing.T) {
+		failing := errors.New("failed")
+		stage := newEnrichmentStage([]Enricher{
+			EnricherFunc(func(_ context.Context, _ []*types.Alert) ([]model.LabelSet, error) {
+				return nil, failing
+			}),
+		}, nil, time.Second, EnrichmentFailureAbort)
+
+		_, res, err := stage.Exec(context.Background(), log.NewNopLogger(), alerts...)
+		require.ErrorIs(t, err, failing)
+		require.Nil(t, res)
+	})
+
+	t.Run("enrichers of the route of the group are added", func(t *testing.T) {
+		owner := EnricherFunc(func(_ context.Context, alerts []*types.Alert) ([]model.LabelSet, error) {
+			return make([]model.LabelSet, len(alerts)), nil
+		})
+		runbook := EnricherFunc(func(_ context.Context, _ []*types.Alert) ([]model.LabelSet, error) {
+			return []model.LabelSet{{"runbook_url": "http://runbook/1"}, nil}, nil
+		})
+		stage := newEnrichmentStage([]Enricher{owner}, []routeEnrichers{
+			{routeKey: `{}/{severity="info"}`, enrichers: []namedEnricher{{name: "runbook", Enricher: runbook}}},
+		}, time.Second, "")
+
+		ctx := notify.WithGroupKey(context.Background(), `{}/{severity="info"}:{alertname="alert1"}`)
+		_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, model.LabelValue("http://runbook/1"), res[0].Annotations["runbook_url"])
+
+		ctx = notify.WithGroupKey(context.Background(), `{}/{severity="critical"}:{alertname="alert1"}`)
+		_, res, err = stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.NotContains(t, res[0].Annotations, model.LabelName("runbook_url"))
+		require.Len(t, stage.enrichers, 1, "enrichers of all routes should not be modified")
+	})
+}
+
+func TestRouteEnrichers(t *testing.T) {
+	informational, err := labels.NewMatcher(labels.MatchEqual, "severity", "info")
+	require.NoError(t, err)
+	runbook := EnricherFunc(func(_ context.Context, alerts []*types.Alert) ([]model.LabelSet, error) {
+		return make([]model.LabelSet, len(alerts)), nil
+	})
+	cfg := &grafanaRoutingTreeConfig{
+		route: &definition.Route{
+			Receiver: "default",
+			Routes: []*definition.Route{
+				{Receiver: "info", Enrichers: []string{"runbook"}, ObjectMatchers: definition.ObjectMatchers{informational}},
+			},
+		},
+	}
+
+	require.NoError(t, validateRouteEnrichers(cfg, map[string]Enricher{"runbook": runbook}))
+	require.EqualError(t, validateRouteEnrichers(cfg, nil), `route with receiver "info" references unknown enricher "runbook"`)
+
+	route, _ := buildRoutingTree(cfg)
+	res := buildRouteEnrichers(cfg, route, map[string]Enricher{"runbook": runbook})
+	require.Len(t, res, 1)
+	require.Equal(t, `{}/{severity="info"}`, res[0].routeKey)
+	require.Equal(t, "runbook", res[0].enrichers[0].name)
+}
@@ -3,19 +3,23 @@ package notify
 import (
 	"fmt"
 
-	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
 
 	"github.com/grafana/alerting/images"
 	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/notify/nfstatus"
 	"github.com/grafana/alerting/receivers"
 	"github.com/grafana/alerting/receivers/alertmanager"
+	"github.com/grafana/alerting/receivers/blackhole"
 	"github.com/grafana/alerting/receivers/dinding"
 	"github.com/grafana/alerting/receivers/discord"
+	"github.com/grafana/alerting/receivers/echo"
 	"github.com/grafana/alerting/receivers/email"
+	"github.com/grafana/alerting/receivers/feishu"
 	"github.com/grafana/alerting/receivers/googlechat"
 	"github.com/grafana/alerting/receivers/kafka"
 	"github.com/grafana/alerting/receivers/line"
+	"github.com/grafana/alerting/receivers/matrix"
 	"github.com/grafana/alerting/receivers/mqtt"
 	"github.com/grafana/alerting/receivers/oncall"
 	"github.com/grafana/alerting/receivers/opsgenie"
@@ -31,6 +35,7 @@ import (
 	"github.com/grafana/alerting/receivers/webex"
 	"github.com/grafana/alerting/receivers/webhook"
 	"github.com/grafana/alerting/receivers/wecom"
+	"github.com/grafana/alerting/receivers/zenduty"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -46,18 +51,18 @@ func BuildReceiverIntegrations(
 	orgID int64,
 	version string,
 ) ([]*Integration, error) {
-	type notificationChannel interface {
-		notify.Notifier
-		notify.ResolvedSender
-	}
 	var (
 		integrations []*Integration
 		errors       types.MultiError
 		nl           = func(meta receivers.Metadata) logging.Logger {
-			return logger("ngalert.notifier."+meta.Type, "notifierUID", meta.UID)
+			return logger("ngalert.notifier."+meta.Type, logging.KeyReceiver, meta.Name, logging.KeyIntegration, meta.Type, logging.KeyIntegrationUID, meta.UID)
 		}
-		ci = func(idx int, cfg receivers.Metadata, n notificationChannel) {
-			i := NewIntegration(n, n, cfg.Type, idx, cfg.Name)
+		ci = func(idx int, cfg receivers.Metadata, retryPolicy *RetryPolicy, n NotificationChannel) {
+			opts := []nfstatus.IntegrationOption{nfstatus.WithUID(cfg.UID)}
+			if retryPolicy != nil {
+				opts = append(opts, nfstatus.WithRetryPolicy(retryPolicy))
+			}
+			i := NewIntegration(n, n, cfg.Type, idx, cfg.Name, opts...)
 			integrations = append(integrations, i)
 		}
 		nw = func(cfg receivers.Metadata) receivers.WebhookSender {
@@ -67,18 +72,24 @@ func BuildReceiverIntegrations(
 				return nil // return nil to simplify the construction code. This works because constructor in notifiers do not check the argument for nil.
 				// This does not cause misconfigured notifiers because it populates `errors`, which causes the function to return nil integrations and non-nil error.
 			}
-			return w
+			return receivers.NewTracingWebhookSender(w)
 		}
 	)
 	// Range through each notification channel in the receiver and create an integration for it.
 	for i, cfg := range receiver.AlertmanagerConfigs {
-		ci(i, cfg.Metadata, alertmanager.New(cfg.Settings, cfg.Metadata, img, nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, alertmanager.New(cfg.Settings, cfg.Metadata, img, nl(cfg.Metadata)))
+	}
+	for i, cfg := range receiver.BlackholeConfigs {
+		ci(i, cfg.Metadata, cfg.RetryPolicy, blackhole.New(cfg.Settings, cfg.Metadata, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.DingdingConfigs {
-		ci(i, cfg.Metadata, dinding.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, dinding.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.DiscordConfigs {
-		ci(i, cfg.Metadata, discord.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), version))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, discord.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), version))
+	}
+	for i, cfg := range receiver.EchoConfigs {
+		ci(i, cfg.Metadata, cfg.RetryPolicy, echo.New(cfg.Settings, cfg.Metadata, tmpl, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.EmailConfigs {
 		mailCli, e := newEmailSender(cfg.Metadata)
@@ -86,61 +97,94 @@ func BuildReceiverIntegrations(
 			errors.Add(fmt.Errorf("unable to build email client for %s notifier %s (UID: %s): %w ", cfg.Type, cfg.Name, cfg.UID, e))
 			continue
 		}
-		ci(i, cfg.Metadata, email.New(cfg.Settings, cfg.Metadata, tmpl, mailCli, img, nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, email.New(cfg.Settings, cfg.Metadata, tmpl, receivers.NewTracingEmailSender(mailCli), img, nl(cfg.Metadata)))
+	}
+	for i, cfg := range receiver.FeishuConfigs {
+		ci(i, cfg.Metadata, cfg.RetryPolicy, feishu.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.GooglechatConfigs {
-		ci(i, cfg.Metadata, googlechat.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), version))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, googlechat.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), version))
 	}
 	for i, cfg := range receiver.KafkaConfigs {
-		ci(i, cfg.Metadata, kafka.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, kafka.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.LineConfigs {
-		ci(i, cfg.Metadata, line.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, line.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), nl(cfg.Metadata)))
+	}
+	for i, cfg := range receiver.MatrixConfigs {
+		ci(i, cfg.Metadata, cfg.RetryPolicy, matrix.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.MqttConfigs {
-		ci(i, cfg.Metadata, mqtt.New(cfg.Settings, cfg.Metadata, tmpl, nl(cfg.Metadata), nil))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, mqtt.New(cfg.Settings, cfg.Metadata, tmpl, nl(cfg.Metadata), nil))
 	}
 	for i, cfg := range receiver.OnCallConfigs {
-		ci(i, cfg.Metadata, oncall.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), orgID))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, oncall.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), orgID))
 	}
 	for i, cfg := range receiver.OpsgenieConfigs {
-		ci(i, cfg.Metadata, opsgenie.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, opsgenie.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.PagerdutyConfigs {
-		ci(i, cfg.Metadata, pagerduty.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, pagerduty.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.PushoverConfigs {
-		ci(i, cfg.Metadata, pushover.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, pushover.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.SensugoConfigs {
-		ci(i, cfg.Metadata, sensugo.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, sensugo.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.SNSConfigs {
-		ci(i, cfg.Metadata, sns.New(cfg.Settings, cfg.Metadata, tmpl, nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, sns.New(cfg.Settings, cfg.Metadata, tmpl, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.SlackConfigs {
-		ci(i, cfg.Metadata, slack.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), version))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, slack.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), version))
 	}
 	for i, cfg := range receiver.TeamsConfigs {
-		ci(i, cfg.Metadata, teams.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, teams.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.TelegramConfigs {
-		ci(i, cfg.Metadata, telegram.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, telegram.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.ThreemaConfigs {
-		ci(i, cfg.Metadata, threema.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, threema.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.VictoropsConfigs {
-		ci(i, cfg.Metadata, victorops.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), version))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, victorops.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), version))
 	}
 	for i, cfg := range receiver.WebhookConfigs {
-		ci(i, cfg.Metadata, webhook.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), orgID))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, webhook.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), orgID))
 	}
 	for i, cfg := range receiver.WecomConfigs {
-		ci(i, cfg.Metadata, wecom.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), nl(cfg.Metadata)))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, wecom.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), nl(cfg.Metadata)))
 	}
 	for i, cfg := range receiver.WebexConfigs {
-		ci(i, cfg.Metadata, webex.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), orgID))
+		ci(i, cfg.Metadata, cfg.RetryPolicy, webex.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata), orgID))
+	}
+	for i, cfg := range receiver.ZendutyConfigs {
+		ci(i, cfg.Metadata, cfg.RetryPolicy, zenduty.New(cfg.Settings, cfg.Metadata, tmpl, nw(cfg.Metadata), img, nl(cfg.Metadata)))
+	}
+	// The integrations are numbered by type, like those of the built-in types.
+	customIdx := make(map[string]int)
+	for _, cfg := range receiver.CustomConfigs {
+		i := customIdx[cfg.Type]
+		customIdx[cfg.Type]++
+		t, ok := lookupIntegrationType(cfg.Type)
+		if !ok {
+			errors.Add(fmt.Errorf("integration type %s of notifier %s (UID: %s) is not registered", cfg.Type, cfg.Name, cfg.UID))
+			continue
+		}
+		n, e := t.factory.New(cfg.Settings, cfg.Metadata, IntegrationDependencies{
+			Template:      tmpl,
+			WebhookSender: nw(cfg.Metadata),
+			Images:        img,
+			Logger:        nl(cfg.Metadata),
+			OrgID:         orgID,
+			Version:       version,
+		})
+		if e != nil {
+			errors.Add(fmt.Errorf("unable to build %s notifier %s (UID: %s): %w", cfg.Type, cfg.Name, cfg.UID, e))
+			continue
+		}
+		ci(i, cfg.Metadata, cfg.RetryPolicy, n)
 	}
 	if errors.Len() > 0 {
 		return nil, &errors
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// failoverStage executes its stages in order until one of them succeeds. It is used to notify the integrations
+// of a failover chain, where each integration is notified only if the previous one failed.
+//
+// Failover happens only if the stage of an integration fails while the context is still active, that is, when the
+// integration fails with an unrecoverable error or gives up according to its retry policy. If the notification
+// times out while an integration is retrying, there is no time left to notify the next integration.
+type failoverStage struct {
+	stages []notify.Stage
+	names  []string
+}
+
+func (f *failoverStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	var errs []error
+	for i, s := range f.stages {
+		resCtx, res, err := s.Exec(ctx, l, alerts...)
+		if err == nil {
+			return resCtx, res, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil || i == len(f.stages)-1 {
+			break
+		}
+		level.Warn(l).Log("msg", "Notification failed, failing over to the next integration", "integration", f.names[i], "next", f.names[i+1], "err", err)
+	}
+	return ctx, nil, errors.Join(errs...)
+}
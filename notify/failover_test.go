@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/receivers"
+)
+
+type fakeStage struct {
+	err   error
+	calls int
+	// cancel is called when the stage is executed, if set.
+	cancel context.CancelFunc
+}
+
+func (s *fakeStage) Exec(ctx context.Context, _ log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	s.calls++
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if s.err != nil {
+		return ctx, nil, s.err
+	}
+	return ctx, alerts, nil
+}
+
+func TestFailoverStage(t *testing.T) {
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+
+	t.Run("next stage is not executed if the first one succeeds", func(t *testing.T) {
+		primary, secondary := &fakeStage{}, &fakeStage{}
+		f := &failoverStage{stages: []notify.Stage{primary, secondary}, names: []string{"primary", "secondary"}}
+		_, res, err := f.Exec(context.Background(), log.NewNopLogger(), alert)
+		require.NoError(t, err)
+		require.Equal(t, []*types.Alert{alert}, res)
+		require.Equal(t, 1, primary.calls)
+		require.Equal(t, 0, secondary.calls)
+	})
+
+	t.Run("next stage is executed if the first one fails", func(t *testing.T) {
+		primary, secondary := &fakeStage{err: errors.New("primary failed")}, &fakeStage{}
+		f := &failoverStage{stages: []notify.Stage{primary, secondary}, names: []string{"primary", "secondary"}}
+		_, res, err := f.Exec(context.Background(), log.NewNopLogger(), alert)
+		require.NoError(t, err)
+		require.Equal(t, []*types.Alert{alert}, res)
+		require.Equal(t, 1, primary.calls)
+		require.Equal(t, 1, secondary.calls)
+	})
+
+	t.Run("errors of all stages are returned if all of them fail", func(t *testing.T) {
+		primary, secondary := &fakeStage{err: errors.New("primary failed")}, &fakeStage{err: errors.New("secondary failed")}
+		f := &failoverStage{stages: []notify.Stage{primary, secondary}, names: []string{"primary", "secondary"}}
+		_, _, err := f.Exec(context.Background(), log.NewNopLogger(), alert)
+		require.ErrorContains(t, err, "primary failed")
+		require.ErrorContains(t, err, "secondary failed")
+	})
+
+	t.Run("next stage is not executed if the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		primary, secondary := &fakeStage{err: errors.New("primary failed"), cancel: cancel}, &fakeStage{}
+		f := &failoverStage{stages: []notify.Stage{primary, secondary}, names: []string{"primary", "secondary"}}
+		_, _, err := f.Exec(ctx, log.NewNopLogger(), alert)
+		require.ErrorContains(t, err, "primary failed")
+		require.Equal(t, 0, secondary.calls)
+	})
+}
+
+func TestCreateReceiverStage_Failover(t *testing.T) {
+	am, _ := setupAMTest(t)
+	newIntegration := func(uid string, idx int) *Integration {
+		return NewIntegration(&fakeNotifier{}, &fakeNotifier{}, "webhook", idx, "receiver", WithUID(uid))
+	}
+	integrations := []*Integration{
+		newIntegration("secondary", 0),
+		newIntegration("other", 1),
+		newIntegration("primary", 2),
+	}
+
+	stage := am.createReceiverStage("receiver", integrations, []string{"primary", "missing", "secondary"}, am.waitFunc, am.notificationLog, receivers.ConfigGeneration{})
+	fs, ok := stage.(notify.FanoutStage)
+	require.True(t, ok)
+	// One stage for the integration outside the chain and one for the chain.
+	require.Len(t, fs, 2)
+
+	chain := fs[1].(notify.MultiStage)
+	require.Len(t, chain, 2)
+	f, ok := chain[1].(*failoverStage)
+	require.True(t, ok)
+	require.Len(t, f.stages, 2)
+	require.Equal(t, []string{"webhook[2]", "webhook[0]"}, f.names)
+}
@@ -0,0 +1,306 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/go-openapi/strfmt"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// FlapSilenceCreatedBy is the creator of the silences of flapping alerts.
+	FlapSilenceCreatedBy = "grafana-alerting-flap-detection"
+	// FlappingAlertName is the alertname of the notifications sent to the receiver of FlapDetectionOptions.
+	FlappingAlertName = "AlertFlapping"
+
+	// maxFlapSilences is the number of silences of flapping alerts kept for auditing.
+	maxFlapSilences = 100
+	// flapNotificationTimeout is the timeout of the notifications about silenced flapping alerts.
+	flapNotificationTimeout = 30 * time.Second
+)
+
+// FlapDetectionOptions configure the silencing of flapping alerts. An alert flaps when it fires again after it was
+// resolved. Alerts that flap more than MaxFlaps times within Window are silenced for SilenceDuration, so that their
+// receivers are not paged over and over while the rule is being fixed.
+type FlapDetectionOptions struct {
+	MaxFlaps        int
+	Window          time.Duration
+	SilenceDuration time.Duration
+	// Receiver, if set, is the receiver notified about each silenced flapping alert. The notifications go through the
+	// pipeline of the receiver, so that the members of a cluster do not send the same notification.
+	Receiver string
+	// Audit, if set, is called with the record of each silence created for a flapping alert, once its receiver was
+	// notified, so that the records can be kept by the embedding application. The records returned by
+	// GetFlapSilences are kept in memory and are lost when the process restarts.
+	Audit func(FlapSilence)
+}
+
+func (o *FlapDetectionOptions) Validate() error {
+	if o.MaxFlaps <= 0 {
+		return errors.New("max flaps must be greater than zero")
+	}
+	if o.Window <= 0 {
+		return errors.New("window must be greater than zero")
+	}
+	if o.SilenceDuration <= 0 {
+		return errors.New("silence duration must be greater than zero")
+	}
+	return nil
+}
+
+// FlapSilence is the audit record of a silence created for a flapping alert.
+type FlapSilence struct {
+	SilenceID   string
+	Fingerprint model.Fingerprint
+	Labels      model.LabelSet
+	// Flaps are the times the alert fired again after it was resolved, within the window.
+	Flaps    []time.Time
+	StartsAt time.Time
+	EndsAt   time.Time
+	// Receiver is the receiver notified about the silence, if any.
+	Receiver string
+	// NotifyError is the error of the notification of the receiver, if any.
+	NotifyError string
+}
+
+// flapDetector keeps track of the flaps of the alerts within the window, and of the most recent silences of flapping
+// alerts. Both are kept in memory only: they are neither gossiped nor snapshotted, so they are lost when the process
+// restarts, and each member of a cluster has its own. The records can be persisted with FlapDetectionOptions.Audit.
+type flapDetector struct {
+	opts FlapDetectionOptions
+
+	mtx      sync.Mutex
+	flaps    map[model.Fingerprint][]time.Time
+	silences []FlapSilence
+}
+
+func newFlapDetector(opts FlapDetectionOptions) *flapDetector {
+	return &flapDetector{
+		opts:  opts,
+		flaps: make(map[model.Fingerprint][]time.Time),
+	}
+}
+
+// observe records the alert that replaces old, and returns the flaps within the window if the alert flapped more
+// than the maximum. The flaps of the alert are then forgotten, so that it is silenced only once.
+func (d *flapDetector) observe(old, alert *types.Alert, now time.Time) []time.Time {
+	fp := alert.Fingerprint()
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	flaps := d.inWindow(d.flaps[fp], now)
+	if old != nil && !old.EndsAt.After(now) && alert.EndsAt.After(now) {
+		flaps = append(flaps, now)
+	}
+	if len(flaps) > d.opts.MaxFlaps {
+		delete(d.flaps, fp)
+		return flaps
+	}
+	if len(flaps) == 0 {
+		delete(d.flaps, fp)
+	} else {
+		d.flaps[fp] = flaps
+	}
+	return nil
+}
+
+// gc forgets the flaps that are out of the window, and the alerts without flaps within the window, such as the
+// alerts that were resolved and garbage collected.
+func (d *flapDetector) gc(now time.Time) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for fp, flaps := range d.flaps {
+		if flaps = d.inWindow(flaps, now); len(flaps) == 0 {
+			delete(d.flaps, fp)
+		} else {
+			d.flaps[fp] = flaps
+		}
+	}
+}
+
+// inWindow returns the flaps that are within the window.
+func (d *flapDetector) inWindow(flaps []time.Time, now time.Time) []time.Time {
+	i := 0
+	for i < len(flaps) && !flaps[i].After(now.Add(-d.opts.Window)) {
+		i++
+	}
+	return flaps[i:]
+}
+
+func (d *flapDetector) record(s FlapSilence) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.silences = append(d.silences, s)
+	if len(d.silences) > maxFlapSilences {
+		d.silences = d.silences[len(d.silences)-maxFlapSilences:]
+	}
+}
+
+func (d *flapDetector) setNotifyError(silenceID string, err error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for i := range d.silences {
+		if d.silences[i].SilenceID == silenceID {
+			d.silences[i].NotifyError = err.Error()
+		}
+	}
+}
+
+// audit passes the record of the silence to the audit function of the options, if any.
+func (d *flapDetector) audit(s FlapSilence, notifyErr error) {
+	if d.opts.Audit == nil {
+		return
+	}
+	if notifyErr != nil {
+		s.NotifyError = notifyErr.Error()
+	}
+	d.opts.Audit(s)
+}
+
+// GetFlapSilences returns the most recent silences created for flapping alerts by this Alertmanager, oldest first.
+// It is empty if flap detection is not configured. The records are kept in memory only, see FlapDetectionOptions.Audit.
+func (am *GrafanaAlertmanager) GetFlapSilences() []FlapSilence {
+	if am.flapDetector == nil {
+		return nil
+	}
+	am.flapDetector.mtx.Lock()
+	defer am.flapDetector.mtx.Unlock()
+	return append([]FlapSilence(nil), am.flapDetector.silences...)
+}
+
+// silenceFlappingAlert silences the alert, which flapped at the times of flaps, for the silence duration and notifies
+// the receiver of the options about it. Each member of a cluster detects the flaps of the alert, so the alert is not
+// silenced again if it already is, such as by another member.
+func (am *GrafanaAlertmanager) silenceFlappingAlert(alert *types.Alert, flaps []time.Time, now time.Time) {
+	opts := am.flapDetector.opts
+	logger := am.logger
+	if id, err := am.flapSilenceOf(alert); err != nil {
+		level.Warn(logger).Log("msg", "Failed to query the silences of flapping alerts", "err", err)
+	} else if id != "" {
+		level.Debug(logger).Log("msg", "Flapping alert is already silenced", "alert", alert, "silence_id", id)
+		return
+	}
+
+	startsAt, endsAt := strfmt.DateTime(now), strfmt.DateTime(now.Add(opts.SilenceDuration))
+	createdBy := FlapSilenceCreatedBy
+	comment := fmt.Sprintf("Alert fired again after it was resolved %d times in %s", len(flaps), opts.Window)
+	matchers := make(amv2.Matchers, 0, len(alert.Labels))
+	for name, value := range alert.Labels {
+		name, value, isEqual, isRegex := string(name), string(value), true, false
+		matchers = append(matchers, &amv2.Matcher{Name: &name, Value: &value, IsEqual: &isEqual, IsRegex: &isRegex})
+	}
+
+	silenceID, err := am.CreateSilence(&PostableSilence{Silence: amv2.Silence{
+		Matchers:  matchers,
+		StartsAt:  &startsAt,
+		EndsAt:    &endsAt,
+		CreatedBy: &createdBy,
+		Comment:   &comment,
+	}})
+	if err != nil {
+		am.Metrics.flapSilences.WithLabelValues(am.tenantString(), "error").Inc()
+		level.Error(logger).Log("msg", "Failed to silence flapping alert", "alert", alert, "flaps", len(flaps), "err", err)
+		return
+	}
+	am.Metrics.flapSilences.WithLabelValues(am.tenantString(), "success").Inc()
+	level.Warn(logger).Log("msg", "Silenced flapping alert", "alert", alert, "labels", alert.Labels, "silence_id", silenceID,
+		"flaps", len(flaps), "window", opts.Window, "ends_at", time.Time(endsAt), "receiver", opts.Receiver)
+
+	record := FlapSilence{
+		SilenceID:   silenceID,
+		Fingerprint: alert.Fingerprint(),
+		Labels:      alert.Labels.Clone(),
+		Flaps:       flaps,
+		StartsAt:    time.Time(startsAt),
+		EndsAt:      time.Time(endsAt),
+		Receiver:    opts.Receiver,
+	}
+	am.flapDetector.record(record)
+
+	if opts.Receiver == "" {
+		am.flapDetector.audit(record, nil)
+		return
+	}
+	notification := &types.Alert{Alert: model.Alert{
+		Labels: alert.Labels.Merge(model.LabelSet{
+			model.AlertNameLabel: FlappingAlertName,
+			"flapping_alertname": alert.Labels[model.AlertNameLabel],
+		}),
+		Annotations: model.LabelSet{
+			"summary":    model.LabelValue(comment + ", silenced until " + time.Time(endsAt).UTC().Format(time.RFC3339)),
+			"silence_id": model.LabelValue(silenceID),
+		},
+		StartsAt: now,
+		EndsAt:   time.Time(endsAt),
+	}}
+	if am.stopped() {
+		return
+	}
+	am.wg.Add(1)
+	go func() {
+		defer am.wg.Done()
+		err := am.notifyFlapSilence(opts.Receiver, notification, opts.SilenceDuration)
+		if err != nil {
+			level.Error(logger).Log("msg", "Failed to notify about silenced flapping alert", "receiver", opts.Receiver, "silence_id", silenceID, "err", err)
+			am.flapDetector.setNotifyError(silenceID, err)
+		}
+		am.flapDetector.audit(record, err)
+	}()
+}
+
+// flapSilenceOf returns the ID of the active or pending silence of the flapping alert, or an empty string if there
+// is none. Silences of flapping alerts have one matcher for each label of the alert.
+func (am *GrafanaAlertmanager) flapSilenceOf(alert *types.Alert) (string, error) {
+	sils, _, err := am.silences.Query(
+		silence.QState(types.SilenceStateActive, types.SilenceStatePending),
+		silence.QMatches(alert.Labels),
+	)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range sils {
+		if s.CreatedBy == FlapSilenceCreatedBy && len(s.Matchers) == len(alert.Labels) {
+			return s.Id, nil
+		}
+	}
+	return "", nil
+}
+
+// notifyFlapSilence sends the notification about a silenced flapping alert through the pipeline of the receiver.
+// The group key of the notification only depends on the alert, so that the notification log deduplicates the
+// notifications of the members of a cluster, and of the same alert until the silence ends.
+func (am *GrafanaAlertmanager) notifyFlapSilence(receiver string, alert *types.Alert, silenceDuration time.Duration) error {
+	am.reloadConfigMtx.RLock()
+	stage, ok := am.receiverStages[receiver]
+	am.reloadConfigMtx.RUnlock()
+	if !ok {
+		return fmt.Errorf("receiver %q does not exist", receiver)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flapNotificationTimeout)
+	defer cancel()
+	done := ctx.Done()
+	go func() {
+		select {
+		case <-am.stopc:
+			cancel()
+		case <-done:
+		}
+	}()
+	ctx = notify.WithGroupKey(ctx, fmt.Sprintf("%s:%s:%s", receiver, FlappingAlertName, alert.Fingerprint()))
+	ctx = notify.WithGroupLabels(ctx, alert.Labels)
+	ctx = notify.WithReceiverName(ctx, receiver)
+	ctx = notify.WithRepeatInterval(ctx, silenceDuration)
+	ctx = am.withClock(ctx)
+	_, _, err := stage.Exec(ctx, am.logger, alert)
+	return err
+}
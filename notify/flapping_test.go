@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+	"github.com/grafana/alerting/templates"
+)
+
+func TestFlapDetector(t *testing.T) {
+	d := newFlapDetector(FlapDetectionOptions{MaxFlaps: 2, Window: 10 * time.Minute, SilenceDuration: time.Hour})
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	labels := model.LabelSet{"alertname": "test"}
+	firing := &types.Alert{Alert: model.Alert{Labels: labels, EndsAt: now.Add(time.Hour)}}
+	resolved := &types.Alert{Alert: model.Alert{Labels: labels, EndsAt: now.Add(-time.Minute)}}
+
+	// New alerts and alerts that keep firing do not flap.
+	require.Nil(t, d.observe(nil, firing, now))
+	require.Nil(t, d.observe(firing, firing, now))
+	require.Nil(t, d.observe(firing, resolved, now))
+
+	require.Nil(t, d.observe(resolved, firing, now))
+	require.Nil(t, d.observe(resolved, firing, now.Add(time.Minute)))
+	// Flaps out of the window are forgotten.
+	require.Nil(t, d.observe(resolved, firing, now.Add(10*time.Minute)))
+	require.Equal(t, []time.Time{now.Add(time.Minute), now.Add(10 * time.Minute)}, d.flaps[firing.Fingerprint()])
+
+	flaps := d.observe(resolved, firing, now.Add(10*time.Minute+30*time.Second))
+	require.Equal(t, []time.Time{now.Add(time.Minute), now.Add(10 * time.Minute), now.Add(10*time.Minute + 30*time.Second)}, flaps)
+	require.Empty(t, d.flaps)
+
+	// The flaps of alerts that are no longer observed are forgotten once they are out of the window.
+	require.Nil(t, d.observe(resolved, firing, now))
+	require.Len(t, d.flaps, 1)
+	d.gc(now.Add(5 * time.Minute))
+	require.Len(t, d.flaps, 1)
+	d.gc(now.Add(10 * time.Minute))
+	require.Empty(t, d.flaps)
+}
+
+func TestFlapDetectionOptions_Validate(t *testing.T) {
+	cfg := &GrafanaAlertmanagerConfig{Silences: newFakeMaintanenceOptions(t), Nflog: newFakeMaintanenceOptions(t)}
+	cfg.FlapDetection = &FlapDetectionOptions{Window: time.Minute, SilenceDuration: time.Minute}
+	require.ErrorContains(t, cfg.Validate(), "invalid flap detection options: max flaps must be greater than zero")
+	cfg.FlapDetection = &FlapDetectionOptions{MaxFlaps: 1, SilenceDuration: time.Minute}
+	require.ErrorContains(t, cfg.Validate(), "invalid flap detection options: window must be greater than zero")
+	cfg.FlapDetection = &FlapDetectionOptions{MaxFlaps: 1, Window: time.Minute}
+	require.ErrorContains(t, cfg.Validate(), "invalid flap detection options: silence duration must be greater than zero")
+	cfg.FlapDetection = &FlapDetectionOptions{MaxFlaps: 1, Window: time.Minute, SilenceDuration: time.Minute}
+	require.NoError(t, cfg.Validate())
+}
+
+func TestPutAlerts_SilencesFlappingAlerts(t *testing.T) {
+	am, _ := setupAMTest(t)
+	am.flapDetector = newFlapDetector(FlapDetectionOptions{MaxFlaps: 1, Window: time.Hour, SilenceDuration: time.Hour, Receiver: "ops"})
+
+	put := func(firing bool) {
+		endsAt := strfmt.DateTime(time.Now().Add(-time.Second))
+		if firing {
+			endsAt = strfmt.DateTime(time.Now().Add(time.Hour))
+		}
+		require.NoError(t, am.PutAlerts(PostableAlerts{{
+			StartsAt: strfmt.DateTime(time.Now().Add(-time.Minute)),
+			EndsAt:   endsAt,
+			Alert:    amv2.Alert{Labels: amv2.LabelSet{"alertname": "flapping", "team": "a"}},
+		}}))
+	}
+	put(true)
+	put(false)
+	put(true)
+	require.Empty(t, am.GetFlapSilences())
+	put(false)
+	put(true)
+
+	flapSilences := am.GetFlapSilences()
+	require.Len(t, flapSilences, 1)
+	require.Len(t, flapSilences[0].Flaps, 2)
+	require.Equal(t, model.LabelSet{"alertname": "flapping", "team": "a"}, flapSilences[0].Labels)
+	require.Equal(t, "ops", flapSilences[0].Receiver)
+	require.Equal(t, 1.0, testutil.ToFloat64(am.Metrics.flapSilences.WithLabelValues(am.tenantString(), "success")))
+
+	silence, err := am.GetSilence(flapSilences[0].SilenceID)
+	require.NoError(t, err)
+	require.Equal(t, FlapSilenceCreatedBy, *silence.CreatedBy)
+	require.Equal(t, "Alert fired again after it was resolved 2 times in 1h0m0s", *silence.Comment)
+	require.Len(t, silence.Matchers, 2)
+
+	// The receiver does not exist, so its notification fails.
+	require.Eventually(t, func() bool {
+		return am.GetFlapSilences()[0].NotifyError != ""
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, `receiver "ops" does not exist`, am.GetFlapSilences()[0].NotifyError)
+}
+
+func TestSilenceFlappingAlert(t *testing.T) {
+	am, _ := setupAMTest(t)
+	interval := model.Duration(time.Hour)
+	cfg := &templatesConfig{
+		grafanaRoutingTreeConfig: grafanaRoutingTreeConfig{route: &definition.Route{
+			Receiver:       "default",
+			GroupWait:      &interval,
+			GroupInterval:  &interval,
+			RepeatInterval: &interval,
+		}},
+		tmpls: []templates.TemplateDefinition{{Name: "msg", Template: `{{ define "msg" }}{{ .CommonLabels.alertname }}{{ end }}`}},
+		sent:  make(chan string, 10),
+	}
+	require.NoError(t, am.ApplyConfig(cfg))
+
+	audited := make(chan FlapSilence, 10)
+	am.flapDetector = newFlapDetector(FlapDetectionOptions{
+		MaxFlaps:        1,
+		Window:          time.Hour,
+		SilenceDuration: time.Hour,
+		Receiver:        "default",
+		Audit:           func(s FlapSilence) { audited <- s },
+	})
+	now := time.Now()
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "flapping", "team": "a"}, EndsAt: now.Add(time.Hour)}}
+	flaps := []time.Time{now.Add(-time.Minute), now}
+
+	am.silenceFlappingAlert(alert, flaps, now)
+	require.Equal(t, FlappingAlertName, <-cfg.sent)
+	record := <-audited
+	require.Equal(t, alert.Fingerprint(), record.Fingerprint)
+	require.Empty(t, record.NotifyError)
+
+	// The alert is not silenced again while it is silenced, such as by another member of the cluster.
+	am.silenceFlappingAlert(alert, flaps, now)
+	require.Len(t, am.GetFlapSilences(), 1)
+	sils, err := am.ListSilences(nil)
+	require.NoError(t, err)
+	require.Len(t, sils, 1)
+
+	// The notification log deduplicates the notifications about the same alert until the silence ends.
+	notification := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": FlappingAlertName, "team": "a"}, EndsAt: now.Add(time.Hour)}}
+	require.NoError(t, am.notifyFlapSilence("default", notification, time.Hour))
+	require.NoError(t, am.notifyFlapSilence("default", notification, time.Hour))
+	require.Len(t, cfg.sent, 1)
+}
@@ -10,9 +10,11 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	tmpltext "text/template"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/go-openapi/strfmt"
@@ -34,18 +36,27 @@ import (
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/grafana/alerting/cluster"
+	"github.com/grafana/alerting/definition"
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/notify/nfstatus"
 
 	"github.com/grafana/alerting/models"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/discord"
+	"github.com/grafana/alerting/receivers/matrix"
+	"github.com/grafana/alerting/receivers/opsgenie"
+	"github.com/grafana/alerting/receivers/slack"
 	"github.com/grafana/alerting/templates"
 )
 
 const (
-	// defaultResolveTimeout is the default timeout used for resolving an alert
+	// DefaultResolveTimeout is the default timeout used for resolving an alert
 	// if the end time is not specified.
-	defaultResolveTimeout = 5 * time.Minute
+	DefaultResolveTimeout = 5 * time.Minute
 	// memoryAlertsGCInterval is the interval at which we'll remove resolved alerts from memory.
 	memoryAlertsGCInterval = 30 * time.Minute
 	// snapshotPlaceholder is not a real snapshot file and will not be used, a non-empty string is required to run the maintenance function on shutdown.
@@ -73,7 +84,7 @@ type GrafanaAlertmanager struct {
 	tenantID int64
 
 	marker      types.Marker
-	alerts      *mem.Alerts
+	alerts      alertProvider
 	route       *dispatch.Route
 	peer        ClusterPeer
 	peerTimeout time.Duration
@@ -81,19 +92,44 @@ type GrafanaAlertmanager struct {
 	// wg is for dispatcher, inhibitor, silences and notifications
 	// Across configuration changes dispatcher and inhibitor are completely replaced, however, silences, notification log and alerts remain the same.
 	// stopc is used to let silences and notifications know we are done.
-	wg    sync.WaitGroup
-	stopc chan struct{}
+	wg       sync.WaitGroup
+	stopc    chan struct{}
+	stopOnce sync.Once
+	running  atomic.Bool
+
+	// startOnRun defers the start of the goroutines to Run. started is set once they are started, with reloadConfigMtx.
+	startOnRun bool
+	started    bool
+
+	// silencesOpts and nflogOpts configure the maintenance of silences and the notification log.
+	// deletedSilencesOpts configures the snapshots of the deleted silences, and is optional.
+	silencesOpts        MaintenanceOptions
+	nflogOpts           MaintenanceOptions
+	deletedSilencesOpts MaintenanceOptions
+
+	// shutdownErrs are the errors of the maintenance run when the Alertmanager stops, which Run returns.
+	shutdownErrsMtx sync.Mutex
+	shutdownErrs    []error
 
 	notificationLog *nflog.Log
-	dispatcher      *dispatch.Dispatcher
-	inhibitor       *inhibit.Inhibitor
-	silencer        *silence.Silencer
-	silences        *silence.Silences
+	// notificationLogRetention is how long entries of the notification log are kept.
+	notificationLogRetention time.Duration
+	dispatcher               *dispatch.Dispatcher
+	inhibitor                *inhibit.Inhibitor
+	silencer                 *indexedSilencer
+	silences                 *silence.Silences
+	silenceIndex             *silenceIndex
+	deletedSilences          *deletedSilences
 
 	// timeIntervals is the set of all time_intervals and mute_time_intervals from
 	// the configuration.
 	timeIntervals map[string][]timeinterval.TimeInterval
 
+	// notifyOnceRouteKeys is the set of the keys of the routes that notify once per incident.
+	notifyOnceRouteKeys map[string]struct{}
+	// orderedRouteKeys is the set of the keys of the routes whose notifications are ordered.
+	orderedRouteKeys map[string]struct{}
+
 	stageMetrics      *notify.Metrics
 	dispatcherMetrics *dispatch.DispatcherMetrics
 
@@ -102,10 +138,106 @@ type GrafanaAlertmanager struct {
 	config          []byte
 	receivers       []*nfstatus.Receiver
 
+	// configGeneration is the generation of the configuration that is applied, see ConfigGeneration.
+	configGeneration atomic.Pointer[receivers.ConfigGeneration]
+
+	// clock is the clock of the Alertmanager. customClock is whether it was configured, in which case the
+	// maintenance runs on it and the notifications are stamped with its time.
+	clock       clock.Clock
+	customClock bool
+
 	// buildReceiverIntegrationsFunc builds the integrations for a receiver based on its APIReceiver configuration and the current parsed template.
 	buildReceiverIntegrationsFunc func(next *APIReceiver, tmpl *templates.Template) ([]*Integration, error)
 	externalURL                   string
 
+	// deadLetterHandler receives the notifications that integrations failed to deliver. It is optional.
+	deadLetterHandler DeadLetterHandler
+
+	// deliveryRecorder records the notification attempts of the integrations. It is optional.
+	deliveryRecorder *DeliveryRecorder
+
+	// analytics exports the statistics of the notification attempts of the integrations. It is optional.
+	analytics *analyticsExporter
+
+	// flapDetector silences the alerts that flap. It is optional.
+	flapDetector *flapDetector
+
+	// loadTracker tracks the notifications in the pipeline and emits the load signals. It is optional.
+	loadTracker *loadTracker
+	// receiverStages are the stages of the receivers of the configuration, without the stages that mute alerts.
+	receiverStages map[string]notify.Stage
+
+	// suppressions tracks the time alerts spend suppressed. The marker records silences and inhibitions into it.
+	suppressions *suppressionTracker
+
+	// imageResolutionBudget limits the resolution of the images of each notification.
+	imageResolutionBudget images.ResolutionBudget
+
+	// slackThreads stores the threads of the Slack integrations in update mode "thread". It is optional.
+	slackThreads slack.ThreadStore
+
+	// matrixThreads stores the threads of the Matrix integrations. It is optional.
+	matrixThreads matrix.ThreadStore
+
+	// concurrencyLimiter limits the notifications sent at the same time by integrations of each type. It is optional.
+	concurrencyLimiter *concurrencyLimiter
+
+	// template is the template of the notifications. It is replaced by ApplyConfig and ApplyTemplates, and passed
+	// to the receivers in the context of each notification, see templates.WithTemplate.
+	template atomic.Pointer[templates.Template]
+
+	// resolveTimeout is the timeout after which alerts without an end time are resolved if they are not updated.
+	// It is replaced by ApplyConfig if the configuration has one, see ResolveTimeoutConfiguration.
+	resolveTimeout atomic.Int64
+	// defaultResolveTimeout is the resolve timeout of configurations that do not have one.
+	defaultResolveTimeout time.Duration
+
+	// templateResolver resolves the references to external templates of the configuration. It is optional.
+	templateResolver *definition.TemplateResolver
+
+	// snapshotResults are the results of loading the snapshots of silences and the notification log at startup.
+	snapshotResults []SnapshotLoadResult
+
+	// secretResolver resolves the secret references of receivers built by BuildReceiverConfiguration. It is optional.
+	secretResolver *SecretReferenceResolver
+
+	// emailSenderFactory creates the email senders that route emails by the domains of the recipients. It is optional.
+	emailSenderFactory func(receivers.Metadata) (receivers.EmailSender, error)
+
+	// annotationLimits configures how large annotations are passed to templates.
+	annotationLimits templates.AnnotationLimits
+
+	// templateLimits limit the execution of the templates of each notification.
+	templateLimits templates.ExecutionLimits
+
+	// integrationMetrics are the metrics of each receiver or integration. They are optional.
+	integrationMetrics *integrationMetrics
+
+	// discordWebhookMetrics enables the metrics of the requests sent to each Discord webhook.
+	discordWebhookMetrics bool
+
+	// notificationLock is acquired before sending each notification. It is optional.
+	notificationLock    NotificationLock
+	notificationLockTTL time.Duration
+
+	// deliveryReceipts are the receipts of the notifications sent by the members of the cluster. It is optional.
+	deliveryReceipts *deliveryReceipts
+
+	// quietHours are the personal quiet hours of the receivers. They are optional.
+	quietHours *QuietHoursTable
+
+	// notificationAuthorizer authorizes each notification before it is sent. It is optional.
+	notificationAuthorizer NotificationAuthorizer
+
+	// featureFlags are passed to the receivers in the context of each notification. They are optional.
+	featureFlags receivers.FeatureFlags
+
+	// enrichers add annotations to the alerts of notifications before they are sent to receivers.
+	enrichers               []Enricher
+	routeEnrichers          map[string]Enricher
+	enrichmentTimeout       time.Duration
+	enrichmentFailurePolicy EnrichmentFailurePolicy
+
 	// templates contains the template name -> template contents for each user-defined template.
 	templates []templates.TemplateDefinition
 }
@@ -129,13 +261,23 @@ type MaintenanceOptions interface {
 	MaintenanceFunc(state State) (int64, error)
 }
 
-var NewIntegration = nfstatus.NewIntegration
+var (
+	ErrAlertmanagerAlreadyRunning = errors.New("alertmanager is already running")
+	ErrAlertmanagerStopped        = errors.New("alertmanager is stopped")
+)
+
+var (
+	NewIntegration  = nfstatus.NewIntegration
+	WithUID         = nfstatus.WithUID
+	WithRetryPolicy = nfstatus.WithRetryPolicy
+)
 
 type InhibitRule = config.InhibitRule
 type MuteTimeInterval = config.MuteTimeInterval
 type TimeInterval = config.TimeInterval
 type Route = config.Route
 type Integration = nfstatus.Integration
+type RetryPolicy = nfstatus.RetryPolicy
 type DispatcherLimits = dispatch.Limits
 type Notifier = notify.Notifier
 
@@ -159,6 +301,21 @@ type Configuration interface {
 	Raw() []byte
 }
 
+// ResolveTimeoutConfiguration is a Configuration that overrides the resolve timeout of the Alertmanager, see
+// GrafanaAlertmanagerConfig.ResolveTimeout. A zero timeout is the resolve timeout of the Alertmanager.
+type ResolveTimeoutConfiguration interface {
+	Configuration
+	ResolveTimeout() time.Duration
+}
+
+// VersionedConfiguration is a Configuration with a version supplied by the embedding application, such as the ID of
+// the revision of the configuration in its store. The version is reported with the generation of the configuration,
+// see GrafanaAlertmanager.ConfigGeneration.
+type VersionedConfiguration interface {
+	Configuration
+	Version() string
+}
+
 type Limits struct {
 	MaxSilences         int
 	MaxSilenceSizeBytes int
@@ -173,6 +330,137 @@ type GrafanaAlertmanagerConfig struct {
 	Nflog    MaintenanceOptions
 
 	Limits Limits
+
+	// DeletedSilenceRetention is for how long deleted silences can be restored, see
+	// GrafanaAlertmanager.RestoreSilence. It defaults to DefaultDeletedSilenceRetention.
+	DeletedSilenceRetention time.Duration
+	// DeletedSilences, if set, snapshots the deleted silences in their own snapshot, so that they can still be
+	// restored once all the members of the cluster restart. Its retention is not used, see DeletedSilenceRetention.
+	DeletedSilences MaintenanceOptions
+
+	// ResolveTimeout is the timeout after which alerts without an end time are resolved if they are not updated.
+	// Tenants whose rules are evaluated less often than the timeout need a longer one, otherwise their alerts
+	// are resolved and fire again between evaluations. It defaults to DefaultResolveTimeout.
+	ResolveTimeout time.Duration
+
+	// DeadLetterHandler, if set, is invoked with the notifications that an integration failed to deliver.
+	DeadLetterHandler DeadLetterHandler
+
+	// DeliveryStore, if set, is used to record every notification attempt of the integrations.
+	DeliveryStore DeliveryStore
+
+	// ImageResolutionBudget limits the resolution of the images of each notification. By default, there are no limits.
+	ImageResolutionBudget images.ResolutionBudget
+
+	// NotificationLock, if set, is acquired before sending each notification, so that members of the cluster
+	// do not send the same notification when the notification log is not gossiped in time. It is released if the
+	// notification fails.
+	NotificationLock NotificationLock
+	// NotificationLockTTL is for how long the lock of a notification is held. It defaults to DefaultNotificationLockTTL.
+	NotificationLockTTL time.Duration
+
+	// DeliveryReceipts gossips a receipt of each notification before the first attempt of an integration to send it,
+	// and members of the cluster hold off the notifications that other members are sending or delivered, unless they
+	// failed. It prevents duplicate notifications while integrations retry, as the notification log is only gossiped
+	// once they succeed.
+	DeliveryReceipts bool
+
+	// QuietHours, if set, are the personal quiet hours consulted for each notification, see QuietHours.
+	QuietHours *QuietHoursTable
+
+	// NotificationAuthorizer, if set, authorizes each notification of the integrations before it is sent, and can
+	// veto it. Vetoes are counted by reason, and recorded in the delivery history if there is a DeliveryStore.
+	NotificationAuthorizer NotificationAuthorizer
+
+	// FeatureFlags, if set, are passed to the receivers in the context of each notification, see receivers.FeatureFlagsFromContext.
+	FeatureFlags receivers.FeatureFlags
+
+	// Enrichers add annotations to the alerts of each notification before it is sent to the receiver, after
+	// silences, time intervals and inhibitions are applied. They run in order.
+	Enrichers []Enricher
+	// EnrichmentTimeout is for how long each enricher can run. It defaults to DefaultEnrichmentTimeout.
+	EnrichmentTimeout time.Duration
+	// RouteEnrichers are the enrichers that routes of the Grafana routing tree reference by name, see
+	// definition.Route.Enrichers, such as an enrich.HTTPEnricher that looks up the owners of services in a CMDB.
+	RouteEnrichers map[string]Enricher
+	// EnrichmentFailurePolicy is what happens to a notification when one of its enrichers fails or times out.
+	// It defaults to EnrichmentFailureSkip.
+	EnrichmentFailurePolicy EnrichmentFailurePolicy
+
+	// AnnotationLimits configures how large annotations are passed to templates. By default, all annotations are passed as is.
+	// If the limits have no metrics, they are registered with the registerer of the Alertmanager metrics.
+	AnnotationLimits templates.AnnotationLimits
+
+	// TemplateLimits limit the execution of the templates of each notification. By default, templates are not limited.
+	TemplateLimits templates.ExecutionLimits
+
+	// IntegrationMetrics enable the metrics of the notifications of each receiver or integration, which tell which of
+	// the integrations of a type fail. By default, the metrics of notifications are only by integration type.
+	IntegrationMetrics IntegrationMetricsOptions
+
+	// DiscordWebhookMetrics enables the metrics of the requests sent to each Discord webhook. As each webhook is a
+	// series of the metrics, and templated URLs can send to any number of webhooks, they are disabled by default.
+	DiscordWebhookMetrics bool
+
+	// SlackThreadStore, if set, stores the threads of the alert groups of the Slack integrations in update mode
+	// "thread". By default, the threads are kept in memory, and are lost when the process restarts.
+	SlackThreadStore slack.ThreadStore
+
+	// MatrixThreadStore, if set, stores the threads of the alert groups of the Matrix integrations. By default, the
+	// threads are kept in memory, and are lost when the process restarts.
+	MatrixThreadStore matrix.ThreadStore
+
+	// MaxInFlightNotifications is the maximum number of notifications sent at the same time by the integrations
+	// of each type, such as {"jira": 4}. Other notifications of the type wait until one of them is sent.
+	// Types without a limit are not limited.
+	MaxInFlightNotifications map[string]int
+
+	// SecretResolvers resolve the settings of receivers that reference a secret, such as $ref:vault:path#key, by provider.
+	// References are resolved when receivers are built with GrafanaAlertmanager.BuildReceiverConfiguration.
+	SecretResolvers map[string]SecretResolver
+	// SecretCacheTTL is for how long resolved secrets are cached. It defaults to DefaultSecretCacheTTL.
+	SecretCacheTTL time.Duration
+
+	// TemplateResolver, if set, resolves the templates of the configuration that have no content and whose name is
+	// a reference to an external template, see definition.TemplateRef. Their content is embedded when the
+	// configuration is applied.
+	TemplateResolver *definition.TemplateResolver
+
+	// SnapshotRecovery configures what happens when the initial state of silences or the notification log cannot
+	// be loaded. By default, the Alertmanager fails to be created.
+	SnapshotRecovery SnapshotRecoveryOptions
+
+	// Analytics, if set, periodically exports the statistics of the notification attempts of the integrations by
+	// receiver and severity, for offline analysis without high cardinality metrics.
+	Analytics *AnalyticsOptions
+
+	// FlapDetection, if set, silences the alerts that fire again after they were resolved too many times.
+	FlapDetection *FlapDetectionOptions
+
+	// AlertStore, if set, bounds the number of alerts kept in memory by spilling cold alerts to disk. By default,
+	// all the alerts are kept in memory.
+	AlertStore *AlertStoreOptions
+
+	// LoadSignals, if set, periodically emits the load of the notification pipeline: the pending alert groups,
+	// and the number and age of the notifications in the pipeline.
+	LoadSignals *LoadSignalOptions
+
+	// EmailRouting, if set, configures multiple SMTP senders and which of them send the emails of each recipient
+	// domain. See EmailSenderFactory.
+	EmailRouting *receivers.EmailRoutingConfig
+
+	// MaintenanceClock, if set, runs the maintenance of silences and the notification log, the export of analytics
+	// and the retries of integrations, and is the time of the notifications, of silences and of the alerts, so that
+	// tests can drive them with a clock.Mock instead of waiting. It does not drive the dispatcher, which waits for
+	// group_wait and group_interval on the system clock.
+	MaintenanceClock clock.Clock
+
+	// StartOnRun defers the start of the goroutines of the Alertmanager to Run, so that its lifecycle is managed by
+	// the service that runs it: the maintenance of silences and the notification log, the export of analytics and the
+	// load signals, and the dispatcher and inhibitor of the applied configurations. By default, NewGrafanaAlertmanager
+	// starts the maintenance and ApplyConfig starts the dispatcher and inhibitor, and Run only stops the Alertmanager
+	// once its context is canceled.
+	StartOnRun bool
 }
 
 func (c *GrafanaAlertmanagerConfig) Validate() error {
@@ -184,35 +472,214 @@ func (c *GrafanaAlertmanagerConfig) Validate() error {
 		return errors.New("notification log maintenance options must be present")
 	}
 
+	if err := c.ImageResolutionBudget.Validate(); err != nil {
+		return fmt.Errorf("invalid image resolution budget: %w", err)
+	}
+
+	if c.ResolveTimeout < 0 {
+		return errors.New("resolve timeout must not be negative")
+	}
+
+	if c.DeletedSilenceRetention < 0 {
+		return errors.New("deleted silence retention must not be negative")
+	}
+
+	if c.NotificationLockTTL < 0 {
+		return errors.New("notification lock TTL must not be negative")
+	}
+
+	if c.EnrichmentTimeout < 0 {
+		return errors.New("enrichment timeout must not be negative")
+	}
+
+	if err := c.EnrichmentFailurePolicy.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.AnnotationLimits.Validate(); err != nil {
+		return fmt.Errorf("invalid annotation limits: %w", err)
+	}
+
+	if err := c.TemplateLimits.Validate(); err != nil {
+		return fmt.Errorf("invalid template limits: %w", err)
+	}
+
+	if err := c.IntegrationMetrics.Validate(); err != nil {
+		return fmt.Errorf("invalid integration metrics: %w", err)
+	}
+
+	for integration, limit := range c.MaxInFlightNotifications {
+		if limit <= 0 {
+			return fmt.Errorf("max in-flight notifications of integration %q must be positive", integration)
+		}
+	}
+
+	if c.SecretCacheTTL < 0 {
+		return errors.New("secret cache TTL must not be negative")
+	}
+
+	if err := c.SnapshotRecovery.Validate(); err != nil {
+		return fmt.Errorf("invalid snapshot recovery options: %w", err)
+	}
+
+	if c.Analytics != nil {
+		if err := c.Analytics.Validate(); err != nil {
+			return fmt.Errorf("invalid analytics options: %w", err)
+		}
+	}
+
+	if c.FlapDetection != nil {
+		if err := c.FlapDetection.Validate(); err != nil {
+			return fmt.Errorf("invalid flap detection options: %w", err)
+		}
+	}
+
+	if c.AlertStore != nil {
+		if err := c.AlertStore.Validate(); err != nil {
+			return fmt.Errorf("invalid alert store options: %w", err)
+		}
+	}
+
+	if c.LoadSignals != nil {
+		if err := c.LoadSignals.Validate(); err != nil {
+			return fmt.Errorf("invalid load signal options: %w", err)
+		}
+	}
+
+	if c.EmailRouting != nil {
+		if err := c.EmailRouting.Validate(); err != nil {
+			return fmt.Errorf("invalid email routing: %w", err)
+		}
+	}
+
 	return nil
 }
 
 // NewGrafanaAlertmanager creates a new Grafana-specific Alertmanager.
+// It starts the maintenance of silences and the notification log, unless StartOnRun is set in the configuration.
 func NewGrafanaAlertmanager(tenantKey string, tenantID int64, config *GrafanaAlertmanagerConfig, peer ClusterPeer, logger log.Logger, m *GrafanaAlertmanagerMetrics) (*GrafanaAlertmanager, error) {
 	// TODO: Remove the context.
 	am := &GrafanaAlertmanager{
-		stopc:             make(chan struct{}),
-		logger:            log.With(logger, "component", "alertmanager", tenantKey, tenantID),
-		marker:            types.NewMarker(m.Registerer),
-		stageMetrics:      notify.NewMetrics(m.Registerer, featurecontrol.NoopFlags{}),
-		dispatcherMetrics: dispatch.NewDispatcherMetrics(false, m.Registerer),
-		peer:              peer,
-		peerTimeout:       config.PeerTimeout,
-		Metrics:           m,
-		tenantID:          tenantID,
-		externalURL:       config.ExternalURL,
+		stopc:               make(chan struct{}),
+		logger:              log.With(logger, "component", "alertmanager", tenantKey, tenantID),
+		marker:              types.NewMarker(m.Registerer),
+		stageMetrics:        notify.NewMetrics(m.Registerer, featurecontrol.NoopFlags{}),
+		dispatcherMetrics:   dispatch.NewDispatcherMetrics(false, m.Registerer),
+		peer:                peer,
+		peerTimeout:         config.PeerTimeout,
+		Metrics:             m,
+		tenantID:            tenantID,
+		externalURL:         config.ExternalURL,
+		deadLetterHandler:   config.DeadLetterHandler,
+		silencesOpts:        config.Silences,
+		deletedSilencesOpts: config.DeletedSilences,
+		nflogOpts:           config.Nflog,
+		startOnRun:          config.StartOnRun,
+
+		imageResolutionBudget:   config.ImageResolutionBudget,
+		annotationLimits:        config.AnnotationLimits,
+		templateLimits:          config.TemplateLimits,
+		discordWebhookMetrics:   config.DiscordWebhookMetrics,
+		defaultResolveTimeout:   config.ResolveTimeout,
+		slackThreads:            config.SlackThreadStore,
+		matrixThreads:           config.MatrixThreadStore,
+		notificationLock:        config.NotificationLock,
+		notificationLockTTL:     config.NotificationLockTTL,
+		quietHours:              config.QuietHours,
+		notificationAuthorizer:  config.NotificationAuthorizer,
+		featureFlags:            config.FeatureFlags,
+		enrichers:               config.Enrichers,
+		enrichmentTimeout:       config.EnrichmentTimeout,
+		routeEnrichers:          config.RouteEnrichers,
+		enrichmentFailurePolicy: config.EnrichmentFailurePolicy,
+		templateResolver:        config.TemplateResolver,
+		clock:                   config.MaintenanceClock,
+		customClock:             config.MaintenanceClock != nil,
 	}
 
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
 
-	var err error
+	if am.clock == nil {
+		am.clock = clock.New()
+	}
+
+	am.suppressions = newSuppressionTracker(m.alertSuppressedSeconds.MustCurryWith(prometheus.Labels{"org": am.tenantString()}))
+	am.suppressions.now = am.clock.Now
+	am.marker = &suppressionMarker{Marker: am.marker, tracker: am.suppressions}
+
+	if am.annotationLimits.LazySize > 0 && am.annotationLimits.Metrics == nil {
+		am.annotationLimits.Metrics = templates.NewAnnotationMetrics(m.Registerer)
+	}
+
+	if config.IntegrationMetrics.Label != "" {
+		am.integrationMetrics = newIntegrationMetrics(m.Registerer, config.IntegrationMetrics)
+	}
+
+	if am.defaultResolveTimeout == 0 {
+		am.defaultResolveTimeout = DefaultResolveTimeout
+	}
+	am.resolveTimeout.Store(int64(am.defaultResolveTimeout))
+
+	if am.notificationLockTTL == 0 {
+		am.notificationLockTTL = DefaultNotificationLockTTL
+	}
+
+	if am.enrichmentTimeout == 0 {
+		am.enrichmentTimeout = DefaultEnrichmentTimeout
+	}
+
+	if config.DeliveryStore != nil {
+		am.deliveryRecorder = NewDeliveryRecorder(config.DeliveryStore, am.logger)
+	}
+
+	if config.Analytics != nil {
+		am.analytics = newAnalyticsExporter(tenantID, *config.Analytics, am.clock, am.logger)
+	}
+
+	if config.FlapDetection != nil {
+		am.flapDetector = newFlapDetector(*config.FlapDetection)
+	}
+
+	if config.LoadSignals != nil {
+		am.loadTracker = newLoadTracker(*config.LoadSignals, am.clock)
+	}
+
+	if len(config.MaxInFlightNotifications) > 0 {
+		am.concurrencyLimiter = newConcurrencyLimiter(config.MaxInFlightNotifications, m.notificationQueuedSeconds)
+	}
+
+	if len(config.SecretResolvers) > 0 {
+		am.secretResolver = NewSecretReferenceResolver(config.SecretResolvers, config.SecretCacheTTL)
+		am.secretResolver.now = am.clock.Now
+	}
+
+	if config.EmailRouting != nil {
+		am.emailSenderFactory = receivers.NewEmailRouterFactory(*config.EmailRouting)
+	}
+
+	// The snapshots are checked before the components are created, as they register their metrics.
+	silencesState, err := am.loadSnapshot(config.SnapshotRecovery, SnapshotKindSilences, config.Silences.InitialState(), decodeSilencesSnapshot)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize the silencing component of alerting: %w", err)
+	}
+	nflogState, err := am.loadSnapshot(config.SnapshotRecovery, SnapshotKindNflog, config.Nflog.InitialState(), decodeNflogSnapshot)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize the notification log component of alerting: %w", err)
+	}
+	var deletedSilencesState string
+	if config.DeletedSilences != nil {
+		deletedSilencesState, err = am.loadSnapshot(config.SnapshotRecovery, SnapshotKindDeletedSilences, config.DeletedSilences.InitialState(), decodeDeletedSilencesSnapshot)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize the deleted silences of alerting: %w", err)
+		}
+	}
 
 	// Initialize silences
 	am.silences, err = silence.New(silence.Options{
 		Metrics:        m.Registerer,
-		SnapshotReader: strings.NewReader(config.Silences.InitialState()),
+		SnapshotReader: strings.NewReader(silencesState),
 		Retention:      config.Silences.Retention(),
 		Limits: silence.Limits{
 			MaxSilences:         func() int { return config.Limits.MaxSilences },
@@ -222,10 +689,15 @@ func NewGrafanaAlertmanager(tenantKey string, tenantID int64, config *GrafanaAle
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize the silencing component of alerting: %w", err)
 	}
+	am.silenceIndex, err = newSilenceIndex(am.silences, am.logger)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize the silence index: %w", err)
+	}
 
 	// Initialize the notification log
+	am.notificationLogRetention = config.Nflog.Retention()
 	am.notificationLog, err = nflog.New(nflog.Options{
-		SnapshotReader: strings.NewReader(config.Nflog.InitialState()),
+		SnapshotReader: strings.NewReader(nflogState),
 		Retention:      config.Nflog.Retention(),
 		Logger:         logger,
 		Metrics:        m.Registerer,
@@ -237,45 +709,67 @@ func NewGrafanaAlertmanager(tenantKey string, tenantID int64, config *GrafanaAle
 	c := am.peer.AddState(fmt.Sprintf("notificationlog:%d", am.tenantID), am.notificationLog, m.Registerer)
 	am.notificationLog.SetBroadcast(c.Broadcast)
 
-	c = am.peer.AddState(fmt.Sprintf("silences:%d", am.tenantID), am.silences, m.Registerer)
+	c = am.peer.AddState(fmt.Sprintf("silences:%d", am.tenantID), am.silenceIndex, m.Registerer)
 	am.silences.SetBroadcast(c.Broadcast)
 
-	am.wg.Add(1)
-	go func() {
-		am.notificationLog.Maintenance(config.Nflog.MaintenanceFrequency(), snapshotPlaceholder, am.stopc, func() (int64, error) {
-			if _, err := am.notificationLog.GC(); err != nil {
-				level.Error(am.logger).Log("notification log garbage collection", "err", err)
-			}
-
-			return config.Nflog.MaintenanceFunc(am.notificationLog)
-		})
-		am.wg.Done()
-	}()
-
-	am.wg.Add(1)
-	go func() {
-		am.silences.Maintenance(config.Silences.MaintenanceFrequency(), snapshotPlaceholder, am.stopc, func() (int64, error) {
-			// Delete silences older than the retention period.
-			if _, err := am.silences.GC(); err != nil {
-				level.Error(am.logger).Log("silence garbage collection", "err", err)
-				// Don't return here - we need to snapshot our state first.
-			}
+	deletedSilenceRetention := config.DeletedSilenceRetention
+	if deletedSilenceRetention == 0 {
+		deletedSilenceRetention = DefaultDeletedSilenceRetention
+	}
+	am.deletedSilences = newDeletedSilences(deletedSilenceRetention, am.clock.Now)
+	if deletedSilencesState != "" {
+		if err := am.deletedSilences.Merge([]byte(deletedSilencesState)); err != nil {
+			return nil, fmt.Errorf("unable to initialize the deleted silences of alerting: %w", err)
+		}
+	}
+	c = am.peer.AddState(fmt.Sprintf("deletedsilences:%d", am.tenantID), am.deletedSilences, m.Registerer)
+	am.deletedSilences.SetBroadcast(c.Broadcast)
 
-			// Snapshot our silences to the Grafana KV store
-			return config.Silences.MaintenanceFunc(am.silences)
-		})
-		am.wg.Done()
-	}()
+	if config.DeliveryReceipts {
+		am.deliveryReceipts = newDeliveryReceipts(am.peerTimeout, am.clock.Now)
+		c = am.peer.AddState(fmt.Sprintf("deliveryreceipts:%d", am.tenantID), am.deliveryReceipts, m.Registerer)
+		am.deliveryReceipts.SetBroadcast(c.Broadcast)
+	}
 
 	// Initialize in-memory alerts
-	am.alerts, err = mem.NewAlerts(context.Background(), am.marker, memoryAlertsGCInterval, config.AlertStoreCallback, am.logger, m.Registerer)
+	callback := &resolveTimeoutCallback{AlertStoreCallback: config.AlertStoreCallback, expired: m.resolveTimeoutExpired.WithLabelValues(am.tenantString())}
+	if config.AlertStore != nil {
+		am.alerts, err = newSpillingAlerts(*config.AlertStore, am.marker, memoryAlertsGCInterval, callback, am.clock, am.logger, m.Registerer)
+	} else {
+		am.alerts, err = mem.NewAlerts(context.Background(), am.marker, memoryAlertsGCInterval, callback, am.logger, m.Registerer)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize the alert provider component of alerting: %w", err)
 	}
 
+	if !am.startOnRun {
+		am.started = true
+		am.startMaintenance()
+	}
+
 	return am, nil
 }
 
+// BuildReceiverConfiguration parses, decrypts and validates the APIReceiver like BuildReceiverConfiguration,
+// and resolves the settings that reference a secret with the secret resolvers of the Alertmanager.
+func (am *GrafanaAlertmanager) BuildReceiverConfiguration(ctx context.Context, api *APIReceiver, decode DecodeSecretsFn, decrypt GetDecryptedValueFn) (GrafanaReceiverConfig, error) {
+	if am.secretResolver != nil {
+		ctx = WithSecretResolver(ctx, am.secretResolver)
+	}
+	return BuildReceiverConfiguration(ctx, api, decode, decrypt)
+}
+
+// SecretResolver returns the resolver of secret references of the Alertmanager, or nil if it has no secret resolvers.
+func (am *GrafanaAlertmanager) SecretResolver() *SecretReferenceResolver {
+	return am.secretResolver
+}
+
+// EmailSenderFactory returns the factory of the email senders configured by EmailRouting, to build the integrations
+// with BuildReceiverIntegrations, or nil if the Alertmanager has no email routing.
+func (am *GrafanaAlertmanager) EmailSenderFactory() func(receivers.Metadata) (receivers.EmailSender, error) {
+	return am.emailSenderFactory
+}
+
 func (am *GrafanaAlertmanager) Ready() bool {
 	// We consider AM as ready only when the config has been
 	// applied at least once successfully. Until then, some objects
@@ -290,22 +784,214 @@ func (am *GrafanaAlertmanager) ready() bool {
 	return am.config != nil
 }
 
-func (am *GrafanaAlertmanager) StopAndWait() {
-	if am.dispatcher != nil {
-		am.dispatcher.Stop()
+// Run runs the Alertmanager until the context is canceled, and then stops it in order: first the dispatcher and
+// inhibitor, so that no new notifications are sent, then the alerts, and finally the maintenance, which takes a last
+// snapshot of silences and the notification log. It returns once everything has stopped, with the errors of the last
+// snapshot. Run must be called only once.
+//
+// If StartOnRun is set in the configuration, Run starts the maintenance, and the dispatcher and inhibitor of the
+// applied configuration, if any. Configurations applied while Run runs start their own dispatcher and inhibitor.
+// ApplyConfig must then not be called concurrently with Run, other than with the lock of WithLock.
+func (am *GrafanaAlertmanager) Run(ctx context.Context) error {
+	if !am.running.CompareAndSwap(false, true) {
+		return ErrAlertmanagerAlreadyRunning
+	}
+	if am.stopped() {
+		return ErrAlertmanagerStopped
 	}
 
-	if am.inhibitor != nil {
-		am.inhibitor.Stop()
+	am.reloadConfigMtx.Lock()
+	if !am.started {
+		am.started = true
+		am.startMaintenance()
+		if am.dispatcher != nil {
+			am.startDispatcher()
+		}
+	}
+	am.reloadConfigMtx.Unlock()
+
+	select {
+	case <-ctx.Done():
+	case <-am.stopc:
 	}
+	am.StopAndWait()
+
+	am.shutdownErrsMtx.Lock()
+	defer am.shutdownErrsMtx.Unlock()
+	return errors.Join(am.shutdownErrs...)
+}
+
+// startMaintenance starts the maintenance of silences, deleted silences and the notification log, the export of
+// analytics and the load signals, which run until the Alertmanager stops.
+func (am *GrafanaAlertmanager) startMaintenance() {
+	nflogMaintenance := am.withShutdownError("notification log", func() (int64, error) {
+		if _, err := am.notificationLog.GC(); err != nil {
+			level.Error(am.logger).Log("notification log garbage collection", "err", err)
+		}
+
+		return am.nflogOpts.MaintenanceFunc(am.notificationLog)
+	})
+	silencesMaintenance := am.withShutdownError("silences", func() (int64, error) {
+		// Delete silences older than the retention period.
+		if _, err := am.silenceIndex.GC(); err != nil {
+			level.Error(am.logger).Log("silence garbage collection", "err", err)
+			// Don't return here - we need to snapshot our state first.
+		}
+		am.deletedSilences.GC()
+		if am.flapDetector != nil {
+			am.flapDetector.gc(am.clock.Now())
+		}
+
+		// Snapshot our silences to the Grafana KV store
+		return am.silencesOpts.MaintenanceFunc(am.silences)
+	})
 
-	am.alerts.Close()
+	am.wg.Add(1)
+	go func() {
+		defer am.wg.Done()
+		if am.customClock {
+			am.runMaintenance("notification log", am.nflogOpts.MaintenanceFrequency(), nflogMaintenance)
+			return
+		}
+		am.notificationLog.Maintenance(am.nflogOpts.MaintenanceFrequency(), snapshotPlaceholder, am.stopc, nflogMaintenance)
+	}()
 
-	close(am.stopc)
+	am.wg.Add(1)
+	go func() {
+		defer am.wg.Done()
+		if am.customClock {
+			am.runMaintenance("silences", am.silencesOpts.MaintenanceFrequency(), silencesMaintenance)
+			return
+		}
+		am.silences.Maintenance(am.silencesOpts.MaintenanceFrequency(), snapshotPlaceholder, am.stopc, silencesMaintenance)
+	}()
+
+	if am.deletedSilencesOpts != nil {
+		deletedSilencesMaintenance := am.withShutdownError("deleted silences", func() (int64, error) {
+			return am.deletedSilencesOpts.MaintenanceFunc(am.deletedSilences)
+		})
+		am.wg.Add(1)
+		go func() {
+			defer am.wg.Done()
+			am.runMaintenance("deleted silences", am.deletedSilencesOpts.MaintenanceFrequency(), deletedSilencesMaintenance)
+		}()
+	}
+
+	if am.analytics != nil {
+		am.wg.Add(1)
+		go func() {
+			defer am.wg.Done()
+			am.analytics.run(am.stopc)
+		}()
+	}
+
+	if am.loadTracker != nil {
+		am.wg.Add(1)
+		go func() {
+			defer am.wg.Done()
+			am.loadTracker.run(am.stopc, am.pendingGroups)
+		}()
+	}
+}
+
+// pendingGroups returns the number of alert groups of the dispatcher with alerts.
+func (am *GrafanaAlertmanager) pendingGroups() int {
+	am.reloadConfigMtx.RLock()
+	defer am.reloadConfigMtx.RUnlock()
+	if am.dispatcher == nil {
+		return 0
+	}
+	groups, _ := am.dispatcher.Groups(
+		func(*dispatch.Route) bool { return true },
+		func(*types.Alert, time.Time) bool { return true },
+	)
+	return len(groups)
+}
+
+// withShutdownError returns the maintenance, whose errors are kept for Run once the Alertmanager is stopped, as they
+// are the errors of the last snapshot.
+func (am *GrafanaAlertmanager) withShutdownError(component string, maintenance func() (int64, error)) func() (int64, error) {
+	return func() (int64, error) {
+		n, err := maintenance()
+		if err != nil && am.stopped() {
+			am.shutdownErrsMtx.Lock()
+			am.shutdownErrs = append(am.shutdownErrs, fmt.Errorf("%s: %w", component, err))
+			am.shutdownErrsMtx.Unlock()
+		}
+		return n, err
+	}
+}
+
+// startDispatcher starts the dispatcher and the inhibitor of the applied configuration.
+func (am *GrafanaAlertmanager) startDispatcher() {
+	dispatcher, inhibitor := am.dispatcher, am.inhibitor
+	am.wg.Add(1)
+	go func() {
+		defer am.wg.Done()
+		dispatcher.Run()
+	}()
+
+	am.wg.Add(1)
+	go func() {
+		defer am.wg.Done()
+		inhibitor.Run()
+	}()
+}
+
+// runMaintenance runs the maintenance on the clock of the Alertmanager at each interval until the Alertmanager
+// stops, and then once more, like the maintenance of the upstream silences and notification log, which can only
+// run on the system clock.
+func (am *GrafanaAlertmanager) runMaintenance(component string, interval time.Duration, maintenance func() (int64, error)) {
+	if interval == 0 {
+		level.Error(am.logger).Log("msg", "interval is missing - not running maintenance", "component", component)
+		return
+	}
+	t := am.clock.Ticker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if _, err := maintenance(); err != nil {
+				level.Error(am.logger).Log("msg", "Running maintenance failed", "component", component, "err", err)
+			}
+		case <-am.stopc:
+			if _, err := maintenance(); err != nil {
+				level.Error(am.logger).Log("msg", "Creating shutdown snapshot failed", "component", component, "err", err)
+			}
+			return
+		}
+	}
+}
+
+// StopAndWait stops the Alertmanager and waits for all of its goroutines to finish.
+// It is called by Run when its context is canceled, and is safe to call more than once.
+func (am *GrafanaAlertmanager) StopAndWait() {
+	am.stopOnce.Do(func() {
+		if am.dispatcher != nil {
+			am.dispatcher.Stop()
+		}
+
+		if am.inhibitor != nil {
+			am.inhibitor.Stop()
+		}
+
+		am.alerts.Close()
+
+		close(am.stopc)
+	})
 
 	am.wg.Wait()
 }
 
+func (am *GrafanaAlertmanager) stopped() bool {
+	select {
+	case <-am.stopc:
+		return true
+	default:
+		return false
+	}
+}
+
 // GetReceivers returns the receivers configured as part of the current configuration.
 // It is safe to call concurrently.
 func (am *GrafanaAlertmanager) GetReceivers() []models.Receiver {
@@ -353,6 +1039,8 @@ type job struct {
 	Config       *GrafanaIntegrationConfig
 	ReceiverName string
 	Notifier     notify.Notifier
+	// Alerts are the test alerts with the annotations of the integration.
+	Alerts []*types.Alert
 }
 
 // result contains the receiver that was tested and a non-nil error if the test failed
@@ -360,9 +1048,12 @@ type result struct {
 	Config       *GrafanaIntegrationConfig
 	ReceiverName string
 	Error        error
+	// ResolvedStatus is the status of the resolved notification, if one was sent, and ResolvedError its error.
+	ResolvedStatus string
+	ResolvedError  error
 }
 
-func newTestReceiversResult(alert types.Alert, results []result, receivers []*APIReceiver, notifiedAt time.Time) (*TestReceiversResult, int) {
+func newTestReceiversResult(alerts []types.Alert, results []result, receivers []*APIReceiver, notifiedAt time.Time) (*TestReceiversResult, int) {
 	var numBadRequests, numTimeouts, numUnknownErrors int
 
 	m := make(map[string]TestReceiverResult)
@@ -398,16 +1089,32 @@ func newTestReceiversResult(alert types.Alert, results []result, receivers []*AP
 			errString = err.Error()
 		}
 
+		var resolvedErrString string
+		if next.ResolvedError != nil {
+			err := ProcessIntegrationError(next.Config, next.ResolvedError)
+			if errors.As(err, &receiverTimeoutErr) {
+				numTimeouts++
+			} else {
+				numUnknownErrors++
+			}
+			resolvedErrString = err.Error()
+		}
+
 		tmp.Configs = append(tmp.Configs, TestIntegrationConfigResult{
-			Name:   next.Config.Name,
-			UID:    next.Config.UID,
-			Status: status,
-			Error:  errString,
+			Name:           next.Config.Name,
+			UID:            next.Config.UID,
+			Status:         status,
+			Error:          errString,
+			ResolvedStatus: next.ResolvedStatus,
+			ResolvedError:  resolvedErrString,
 		})
 		m[next.ReceiverName] = tmp
 	}
 	v := new(TestReceiversResult)
-	v.Alert = alert
+	if len(alerts) > 0 {
+		v.Alert = alerts[0]
+	}
+	v.Alerts = alerts
 	v.Receivers = make([]TestReceiverResult, 0, len(receivers))
 	v.NotifedAt = notifiedAt
 	for _, next := range m {
@@ -436,6 +1143,30 @@ func newTestReceiversResult(alert types.Alert, results []result, receivers []*AP
 	return v, returnCode
 }
 
+// testResolved sends a resolved notification of the test alerts to the integrations that open incidents, with the
+// same group key as the firing notification so that the incident that it opened is closed.
+func testResolved(ctx context.Context, next job) (string, error) {
+	if _, ok := resolvingIntegrationTypes[next.Config.Type]; !ok {
+		return "", nil
+	}
+	if next.Config.DisableResolveMessage {
+		return "skipped", nil
+	}
+	now := time.Now()
+	resolved := make([]*types.Alert, 0, len(next.Alerts))
+	for _, alert := range next.Alerts {
+		alert := *alert
+		if !alert.Resolved() {
+			alert.EndsAt = now
+		}
+		resolved = append(resolved, &alert)
+	}
+	if _, err := next.Notifier.Notify(ctx, resolved...); err != nil {
+		return "failed", err
+	}
+	return "ok", nil
+}
+
 func TestReceivers(
 	ctx context.Context,
 	c TestReceiversConfigBodyParams,
@@ -444,7 +1175,11 @@ func TestReceivers(
 	externalURL string) (*TestReceiversResult, int, error) {
 
 	now := time.Now() // The start time of the test
-	testAlert := newTestAlert(c, now, now)
+	testAlerts, err := newTestAlerts(c, now, now)
+	if err != nil {
+		return nil, 0, err
+	}
+	groupLabels := testGroupLabels(testAlerts)
 
 	tmpl, err := templateFromContent(tmpls, externalURL)
 	if err != nil {
@@ -477,6 +1212,7 @@ func TestReceivers(
 					Config:       intg,
 					ReceiverName: receiver.Name,
 					Notifier:     integrations[0],
+					Alerts:       testAlertsForIntegration(testAlerts, c.IntegrationAnnotations[intg.UID]),
 				})
 			}
 		}
@@ -487,7 +1223,7 @@ func TestReceivers(
 	}
 
 	if len(jobs) == 0 {
-		res, status := newTestReceiversResult(testAlert, invalid, c.Receivers, now)
+		res, status := newTestReceiversResult(testAlerts, invalid, c.Receivers, now)
 		return res, status, nil
 	}
 
@@ -507,15 +1243,17 @@ func TestReceivers(
 	for i := 0; i < numWorkers; i++ {
 		g.Go(func() error {
 			for next := range workCh {
-				ctx = notify.WithGroupKey(ctx, fmt.Sprintf("%s-%s-%d", next.ReceiverName, testAlert.Labels.Fingerprint(), now.Unix()))
-				ctx = notify.WithGroupLabels(ctx, testAlert.Labels)
+				ctx = notify.WithGroupKey(ctx, fmt.Sprintf("%s-%s-%d", next.ReceiverName, groupLabels.Fingerprint(), now.Unix()))
+				ctx = notify.WithGroupLabels(ctx, groupLabels)
 				ctx = notify.WithReceiverName(ctx, next.ReceiverName)
 				v := result{
 					Config:       next.Config,
 					ReceiverName: next.ReceiverName,
 				}
-				if _, err := next.Notifier.Notify(ctx, &testAlert); err != nil {
+				if _, err := next.Notifier.Notify(ctx, next.Alerts...); err != nil {
 					v.Error = err
+				} else if c.Resolve {
+					v.ResolvedStatus, v.ResolvedError = testResolved(ctx, next)
 				}
 				resultCh <- v
 			}
@@ -535,19 +1273,47 @@ func TestReceivers(
 		results = append(results, next)
 	}
 
-	res, status := newTestReceiversResult(testAlert, append(invalid, results...), c.Receivers, now)
+	res, status := newTestReceiversResult(testAlerts, append(invalid, results...), c.Receivers, now)
 	return res, status, nil
 }
 
 func TestTemplate(ctx context.Context, c TestTemplatesConfigBodyParams, tmpls []templates.TemplateDefinition, externalURL string, logger log.Logger) (*TestTemplatesResults, error) {
+	diagnostics, err := DiagnoseTemplate(ctx, c, tmpls, externalURL, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var results TestTemplatesResults
+	if diagnostics.Error != nil {
+		results.Errors = append(results.Errors, TestTemplatesErrorResult{
+			Kind:  diagnostics.Error.Kind,
+			Error: diagnostics.Error.Message,
+		})
+	}
+	for _, def := range diagnostics.Definitions {
+		if def.Error != nil {
+			results.Errors = append(results.Errors, TestTemplatesErrorResult{
+				Name:  def.Name,
+				Kind:  def.Error.Kind,
+				Error: def.Error.Message,
+			})
+		} else {
+			results.Results = append(results.Results, TestTemplatesResult{
+				Name: def.Name,
+				Text: def.Text,
+			})
+		}
+	}
+	return &results, nil
+}
+
+// DiagnoseTemplate renders each top-level definition of the given template string against the given alerts, like
+// TestTemplate, and returns how long each definition took to render and the positions of the errors, such as for
+// the live preview of a template editor.
+func DiagnoseTemplate(ctx context.Context, c TestTemplatesConfigBodyParams, tmpls []templates.TemplateDefinition, externalURL string, logger log.Logger) (*TemplateDiagnostics, error) {
 	definitions, err := parseTestTemplate(c.Name, c.Template)
 	if err != nil {
-		return &TestTemplatesResults{
-			Errors: []TestTemplatesErrorResult{{
-				Kind:  InvalidTemplate,
-				Error: err.Error(),
-			}},
-		}, nil
+		return &TemplateDiagnostics{Error: newTemplateError(InvalidTemplate, err)}, nil
 	}
 
 	// Recreate the current template replacing the definition blocks that are being tested. This is so that any blocks that were removed don't get defined.
@@ -587,25 +1353,24 @@ func TestTemplate(ctx context.Context, c TestTemplatesConfigBodyParams, tmpls []
 	data := templates.ExtendData(promTmplData, logger)
 
 	// Iterate over each definition in the template and evaluate it.
-	var results TestTemplatesResults
+	diagnostics := &TemplateDiagnostics{Definitions: make([]TemplateDefinitionDiagnostics, 0, len(definitions))}
 	for _, def := range definitions {
 		var buf bytes.Buffer
+		start := time.Now()
 		err := newTextTmpl.ExecuteTemplate(&buf, def, data)
+		res := TemplateDefinitionDiagnostics{
+			Name:     def,
+			Duration: time.Since(start),
+		}
 		if err != nil {
-			results.Errors = append(results.Errors, TestTemplatesErrorResult{
-				Name:  def,
-				Kind:  ExecutionError,
-				Error: err.Error(),
-			})
+			res.Error = newTemplateError(ExecutionError, err)
 		} else {
-			results.Results = append(results.Results, TestTemplatesResult{
-				Name: def,
-				Text: buf.String(),
-			})
+			res.Text = buf.String()
 		}
+		diagnostics.Definitions = append(diagnostics.Definitions, res)
 	}
 
-	return &results, nil
+	return diagnostics, nil
 }
 
 func (am *GrafanaAlertmanager) ExternalURL() string {
@@ -618,6 +1383,16 @@ func (am *GrafanaAlertmanager) ConfigHash() [16]byte {
 	return am.configHash
 }
 
+// ConfigGeneration returns the generation of the configuration that is applied. The generation is incremented every
+// time a configuration is applied, starting from 1, and is sent with the notifications and recorded in their logs
+// and delivery records. It is zero before the first configuration is applied.
+func (am *GrafanaAlertmanager) ConfigGeneration() receivers.ConfigGeneration {
+	if g := am.configGeneration.Load(); g != nil {
+		return *g
+	}
+	return receivers.ConfigGeneration{}
+}
+
 func (am *GrafanaAlertmanager) WithReadLock(fn func()) {
 	am.reloadConfigMtx.RLock()
 	defer am.reloadConfigMtx.RUnlock()
@@ -641,48 +1416,44 @@ func (am *GrafanaAlertmanager) buildTimeIntervals(timeIntervals []config.TimeInt
 	return muteTimes
 }
 
-// ApplyConfig applies a new configuration by re-initializing all components using the configuration provided.
-// It is not safe to call concurrently.
-func (am *GrafanaAlertmanager) ApplyConfig(cfg Configuration) (err error) {
-	am.templates = cfg.Templates()
-
-	seen := make(map[string]struct{})
-	tmpls := make([]string, 0, len(am.templates))
-	for _, tc := range am.templates {
-		if _, ok := seen[tc.Name]; ok {
-			level.Warn(am.logger).Log("msg", "template with same name is defined multiple times, skipping...", "template_name", tc.Name)
-			continue
+// resolveTemplates embeds the content of the templates that reference an external template.
+func (am *GrafanaAlertmanager) resolveTemplates(tmpls []templates.TemplateDefinition) ([]templates.TemplateDefinition, error) {
+	if am.templateResolver == nil {
+		return tmpls, nil
+	}
+	result := make([]templates.TemplateDefinition, 0, len(tmpls))
+	for _, tc := range tmpls {
+		if tc.Template == "" && definition.IsTemplateRef(tc.Name) {
+			t, err := am.templateResolver.Resolve(context.Background(), tc.Name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve template %q: %w", tc.Name, err)
+			}
+			level.Debug(am.logger).Log("msg", "Resolved external template", "template_name", tc.Name, "sha256", t.SHA256)
+			tc.Template = t.Content
 		}
-		tmpls = append(tmpls, tc.Template)
-		seen[tc.Name] = struct{}{}
+		result = append(result, tc)
 	}
+	return result, nil
+}
 
-	tmpl, err := templateFromContent(tmpls, am.ExternalURL())
-	if err != nil {
-		return err
+// ApplyConfig applies a new configuration by re-initializing all components using the configuration provided.
+// The components are replaced only once all of them are built, so the previous configuration keeps running if the
+// configuration fails to apply, see ApplyConfigDryRun. The error of the component that fails to build is a
+// *ConfigError. It is not safe to call concurrently.
+func (am *GrafanaAlertmanager) ApplyConfig(cfg Configuration) (err error) {
+	if am.stopped() {
+		return ErrAlertmanagerStopped
 	}
 
-	// Finally, build the integrations map using the receiver configuration and templates.
-	apiReceivers := cfg.Receivers()
-	nameToReceiver := make(map[string]*APIReceiver, len(apiReceivers))
-	for _, receiver := range apiReceivers {
-		if existing, ok := nameToReceiver[receiver.Name]; ok {
-			itypes := make([]string, 0, len(existing.GrafanaIntegrations.Integrations))
-			for _, i := range existing.GrafanaIntegrations.Integrations {
-				itypes = append(itypes, i.Type)
-			}
-			level.Warn(am.logger).Log("msg", "receiver with same name is defined multiple times. Only the last one will be used", "receiver_name", receiver.Name, "overwritten_integrations", itypes)
-		}
-		nameToReceiver[receiver.Name] = receiver
-	}
-	integrationsMap := make(map[string][]*Integration, len(apiReceivers))
-	for name, apiReceiver := range nameToReceiver {
-		integrations, err := cfg.BuildReceiverIntegrationsFunc()(apiReceiver, tmpl)
-		if err != nil {
-			return err
-		}
-		integrationsMap[name] = integrations
+	set, errs := am.buildConfigSet(cfg, true)
+	if len(errs) > 0 {
+		return errs[0]
 	}
+	integrationsMap, failoverMap := set.integrations, set.failover
+
+	// The running pipeline reads the templates, so they are replaced only once the configuration is known to be valid.
+	am.templates = set.templates
+	am.template.Store(set.template)
 
 	// Now, let's put together our notification pipeline
 	routingStage := make(notify.RoutingStage, len(integrationsMap))
@@ -696,22 +1467,60 @@ func (am *GrafanaAlertmanager) ApplyConfig(cfg Configuration) (err error) {
 
 	am.inhibitor = inhibit.NewInhibitor(am.alerts, cfg.InhibitRules(), am.marker, am.logger)
 	am.timeIntervals = am.buildTimeIntervals(cfg.TimeIntervals(), cfg.MuteTimeIntervals())
-	am.silencer = silence.NewSilencer(am.silences, am.marker, am.logger)
+	am.silencer = newIndexedSilencer(am.silenceIndex, am.marker, am.clock.Now)
 
 	meshStage := notify.NewGossipSettleStage(am.peer)
-	inhibitionStage := notify.NewMuteStage(am.inhibitor, am.stageMetrics)
-	timeMuteStage := notify.NewTimeMuteStage(timeinterval.NewIntervener(am.timeIntervals), am.stageMetrics)
-	silencingStage := notify.NewMuteStage(am.silencer, am.stageMetrics)
-
-	am.route = dispatch.NewRoute(cfg.RoutingTree(), nil)
+	inhibitionStage := newTracingStage("notify.Inhibit", notify.NewMuteStage(am.inhibitor, am.stageMetrics))
+	timeMuteStage := newTracingStage("notify.TimeMute", &timeMuteTrackingStage{
+		stage:   notify.NewTimeMuteStage(timeinterval.NewIntervener(am.timeIntervals), am.stageMetrics),
+		tracker: am.suppressions,
+	})
+	silencingStage := newTracingStage("notify.Silence", notify.NewMuteStage(am.silencer, am.stageMetrics))
+
+	am.resolveTimeout.Store(int64(set.resolveTimeout))
+	oldRoute := am.route
+	am.route, am.notifyOnceRouteKeys = set.route, set.notifyOnceRouteKeys
+	// Groups whose keys changed, such as because group_by changed, keep the notification log of the groups they
+	// come from, so that alerts that were already notified are not notified again.
+	if n := am.migrateGroups(oldRoute, am.route, integrationsMap); n > 0 {
+		level.Info(am.logger).Log("msg", "Migrated notification log entries to the new alert groups", "entries", n)
+	}
+	routeEnrichers := buildRouteEnrichers(cfg, am.route, am.routeEnrichers)
+	am.orderedRouteKeys = buildOrderedRouteKeys(cfg, am.route)
 	am.dispatcher = dispatch.NewDispatcher(am.alerts, am.route, routingStage, am.marker, am.timeoutFunc, cfg.DispatcherLimits(), am.logger, am.dispatcherMetrics)
 
+	generation := receivers.ConfigGeneration{Generation: am.ConfigGeneration().Generation + 1}
+	if c, ok := cfg.(VersionedConfiguration); ok {
+		generation.Version = c.Version()
+	}
+
+	if am.integrationMetrics != nil {
+		am.integrationMetrics.setValues(am.tenantString(), integrationMetricsValues(am.integrationMetrics.label, integrationsMap))
+	}
+
 	// TODO: This has not been upstreamed yet. Should be aligned when https://github.com/prometheus/alertmanager/pull/3016 is merged.
 	var receivers []*nfstatus.Receiver
+	receiverStages := make(map[string]notify.Stage, len(integrationsMap))
 	activeReceivers := GetActiveReceiversMap(am.route)
 	for name := range integrationsMap {
-		stage := am.createReceiverStage(name, nfstatus.GetIntegrations(integrationsMap[name]), am.waitFunc, am.notificationLog)
-		routingStage[name] = notify.MultiStage{meshStage, silencingStage, timeMuteStage, inhibitionStage, stage}
+		stage := am.createReceiverStage(name, integrationsMap[name], failoverMap[name], am.waitFunc, am.notificationLog, generation)
+		if len(am.enrichers) > 0 || len(routeEnrichers) > 0 {
+			stage = notify.MultiStage{newEnrichmentStage(am.enrichers, routeEnrichers, am.enrichmentTimeout, am.enrichmentFailurePolicy), stage}
+		}
+		if am.quietHours != nil {
+			stage = notify.MultiStage{&quietHoursStage{table: am.quietHours}, stage}
+		}
+		receiverStages[name] = stage
+		pipeline := notify.MultiStage{meshStage, silencingStage, timeMuteStage, inhibitionStage, stage}
+		if am.customClock {
+			// The time of the notification is the flush of its group by the dispatcher, which uses the system clock.
+			pipeline = append(notify.MultiStage{contextStage(am.withClock)}, pipeline...)
+		}
+		var receiverStage notify.Stage = pipeline
+		if am.loadTracker != nil {
+			receiverStage = am.loadTracker.Wrap(receiverStage)
+		}
+		routingStage[name] = newTracingStage("notify.Receiver", receiverStage, attribute.String("receiver", name))
 		_, isActive := activeReceivers[name]
 
 		receivers = append(receivers, nfstatus.NewReceiver(name, isActive, integrationsMap[name]))
@@ -721,22 +1530,17 @@ func (am *GrafanaAlertmanager) ApplyConfig(cfg Configuration) (err error) {
 	am.setInhibitionRulesMetrics(cfg.InhibitRules())
 
 	am.receivers = receivers
+	am.receiverStages = receiverStages
 	am.buildReceiverIntegrationsFunc = cfg.BuildReceiverIntegrationsFunc()
 
-	am.wg.Add(1)
-	go func() {
-		defer am.wg.Done()
-		am.dispatcher.Run()
-	}()
-
-	am.wg.Add(1)
-	go func() {
-		defer am.wg.Done()
-		am.inhibitor.Run()
-	}()
+	if am.started {
+		am.startDispatcher()
+	}
 
 	am.configHash = cfg.Hash()
 	am.config = cfg.Raw()
+	am.configGeneration.Store(&generation)
+	level.Info(am.logger).Log("msg", "Applied configuration", logging.KeyConfigGeneration, generation.Generation, logging.KeyConfigVersion, generation.Version)
 
 	return nil
 }
@@ -763,11 +1567,25 @@ func (am *GrafanaAlertmanager) setReceiverMetrics(receivers []*nfstatus.Receiver
 
 // PutAlerts receives the alerts and then sends them through the corresponding route based on whenever the alert has a receiver embedded or not
 func (am *GrafanaAlertmanager) PutAlerts(postableAlerts amv2.PostableAlerts) error {
-	now := time.Now()
-	alerts, validationErr := PostableAlertsToAlertmanagerAlerts(postableAlerts, now)
+	now := am.clock.Now()
+	alerts, validationErr := postableAlertsToAlertmanagerAlerts(postableAlerts, now, am.ResolveTimeout())
 
 	// Register metrics.
 	for _, a := range alerts {
+		old, err := am.alerts.Get(a.Fingerprint())
+		if err != nil {
+			old = nil
+		}
+		// Alerts that come back after they were resolved by the resolve timeout are replaced, not deleted,
+		// so they are counted here.
+		if old != nil && old.Timeout && !old.EndsAt.After(now) {
+			am.Metrics.resolveTimeoutExpired.WithLabelValues(am.tenantString()).Inc()
+		}
+		if am.flapDetector != nil {
+			if flaps := am.flapDetector.observe(old, a, now); flaps != nil {
+				am.silenceFlappingAlert(a, flaps, now)
+			}
+		}
 		if a.EndsAt.After(now) {
 			am.Metrics.Firing().Inc()
 		} else {
@@ -796,9 +1614,19 @@ func (am *GrafanaAlertmanager) PutAlerts(postableAlerts amv2.PostableAlerts) err
 	return nil
 }
 
+// ResolveTimeout returns the timeout after which alerts without an end time are resolved if they are not updated.
+func (am *GrafanaAlertmanager) ResolveTimeout() time.Duration {
+	return time.Duration(am.resolveTimeout.Load())
+}
+
 // PostableAlertsToAlertmanagerAlerts converts the PostableAlerts to a slice of *types.Alert.
 // It sets `StartsAt` and `EndsAt`, ignores empty and namespace UID labels, and captures validation errors for each skipped alert.
+// Alerts without an end time are resolved after DefaultResolveTimeout.
 func PostableAlertsToAlertmanagerAlerts(postableAlerts amv2.PostableAlerts, now time.Time) ([]*types.Alert, *AlertValidationError) {
+	return postableAlertsToAlertmanagerAlerts(postableAlerts, now, DefaultResolveTimeout)
+}
+
+func postableAlertsToAlertmanagerAlerts(postableAlerts amv2.PostableAlerts, now time.Time, resolveTimeout time.Duration) ([]*types.Alert, *AlertValidationError) {
 	alerts := make([]*types.Alert, 0, len(postableAlerts))
 	var validationErr *AlertValidationError
 	for _, a := range postableAlerts {
@@ -840,7 +1668,7 @@ func PostableAlertsToAlertmanagerAlerts(postableAlerts amv2.PostableAlerts, now
 		// is marked resolved if it is not updated.
 		if alert.EndsAt.IsZero() {
 			alert.Timeout = true
-			alert.EndsAt = now.Add(defaultResolveTimeout)
+			alert.EndsAt = now.Add(resolveTimeout)
 		}
 
 		if err := alert.Validate(); err != nil {
@@ -876,26 +1704,177 @@ func (e AlertValidationError) Error() string {
 	return errMsg
 }
 
-// createReceiverStage creates a pipeline of stages for a receiver.
-func (am *GrafanaAlertmanager) createReceiverStage(name string, integrations []*notify.Integration, wait func() time.Duration, notificationLog notify.NotificationLog) notify.Stage {
+// createReceiverStage creates a pipeline of stages for a receiver. The integrations in the failover chain, identified by their UIDs,
+// are notified one after the other until one succeeds, while the rest of the integrations are notified in parallel.
+func (am *GrafanaAlertmanager) createReceiverStage(name string, integrations []*Integration, failover []string, wait func() time.Duration, notificationLog notify.NotificationLog, generation receivers.ConfigGeneration) notify.Stage {
+	chainPos := make(map[string]int, len(failover))
+	for i, uid := range failover {
+		chainPos[uid] = i
+	}
+	chain := make([]notify.Stage, len(failover))
+	chainNames := make([]string, len(failover))
+
 	var fs notify.FanoutStage
-	for i := range integrations {
-		recv := &nflogpb.Receiver{
-			GroupName:   name,
-			Integration: integrations[i].Name(),
-			Idx:         uint32(integrations[i].Index()),
+	for _, integration := range integrations {
+		s := am.createIntegrationStage(name, integration.UID(), integration.Integration(), notificationLog, generation)
+		if pos, ok := chainPos[integration.UID()]; ok && integration.UID() != "" {
+			chain[pos] = s
+			chainNames[pos] = integration.String()
+			continue
 		}
-		var s notify.MultiStage
-		s = append(s, notify.NewWaitStage(wait))
-		s = append(s, notify.NewDedupStage(integrations[i], notificationLog, recv))
-		s = append(s, notify.NewRetryStage(integrations[i], name, am.stageMetrics))
-		s = append(s, notify.NewSetNotifiesStage(notificationLog, recv))
+		fs = append(fs, notify.MultiStage{notify.NewWaitStage(wait), s})
+	}
 
-		fs = append(fs, s)
+	f := &failoverStage{}
+	for i := range chain {
+		if chain[i] == nil {
+			level.Warn(am.logger).Log("msg", "Integration in failover chain not found, skipping", "receiver", name, "uid", failover[i])
+			continue
+		}
+		f.stages = append(f.stages, chain[i])
+		f.names = append(f.names, chainNames[i])
+	}
+	if len(f.stages) > 0 {
+		fs = append(fs, notify.MultiStage{notify.NewWaitStage(wait), f})
 	}
 	return fs
 }
 
+// createIntegrationStage creates the stages that notify a single integration.
+// The notifications are sent with the generation of the configuration the stages are created for.
+func (am *GrafanaAlertmanager) createIntegrationStage(name, uid string, integration *notify.Integration, notificationLog notify.NotificationLog, generation receivers.ConfigGeneration) notify.Stage {
+	if am.deliveryRecorder != nil {
+		integration = am.deliveryRecorder.Wrap(integration, name)
+	}
+	if am.analytics != nil {
+		integration = am.analytics.Wrap(integration, name)
+	}
+	integration = wrapMetrics(integration, name, am.tenantString(), am.Metrics)
+	if am.integrationMetrics != nil {
+		integration = am.integrationMetrics.wrap(integration, name, uid, am.tenantString())
+	}
+	if am.concurrencyLimiter != nil {
+		integration = am.concurrencyLimiter.Wrap(integration, name)
+	}
+	integration = wrapTracing(integration, name)
+	recv := &nflogpb.Receiver{
+		GroupName:   name,
+		Integration: integration.Name(),
+		Idx:         uint32(integration.Index()),
+	}
+	if len(am.orderedRouteKeys) > 0 {
+		integration = wrapOrdering(integration, name, notificationLog, recv)
+	}
+	var s notify.MultiStage
+	// The receiver and the integration are fields of the loggers of the integrations, see BuildReceiverIntegrations.
+	s = append(s, contextStage(func(ctx context.Context) context.Context {
+		gkey, _ := notify.ExtractGroupKey(ctx)
+		ctx = logging.WithContextFields(ctx, logging.KeyTenant, am.tenantID, logging.KeyGroupKey, gkey, logging.KeyConfigGeneration, generation.Generation)
+		if generation.Version != "" {
+			ctx = logging.WithContextFields(ctx, logging.KeyConfigVersion, generation.Version)
+		}
+		return receivers.WithConfigGeneration(ctx, generation)
+	}))
+	if am.featureFlags != nil {
+		s = append(s, contextStage(func(ctx context.Context) context.Context {
+			return receivers.WithFeatureFlags(ctx, am.featureFlags)
+		}))
+	}
+	s = append(s, contextStage(func(ctx context.Context) context.Context {
+		return templates.WithTemplate(ctx, am.template.Load())
+	}))
+	if integration.Name() == "opsgenie" {
+		s = append(s, contextStage(func(ctx context.Context) context.Context {
+			return opsgenie.WithBatchMetrics(ctx, am.Metrics.opsgenieBatch)
+		}))
+	}
+	if integration.Name() == "discord" && am.discordWebhookMetrics {
+		s = append(s, contextStage(func(ctx context.Context) context.Context {
+			return discord.WithMetrics(ctx, am.Metrics.discord)
+		}))
+	}
+	switch integration.Name() {
+	case "discord", "feishu", "slack", "telegram":
+		s = append(s, contextStage(func(ctx context.Context) context.Context {
+			return images.WithFallbackMetrics(ctx, am.Metrics.imageFallback)
+		}))
+	}
+	if integration.Name() == "slack" && am.slackThreads != nil {
+		s = append(s, contextStage(func(ctx context.Context) context.Context {
+			return slack.WithThreadStore(ctx, am.slackThreads)
+		}))
+	}
+	if integration.Name() == "matrix" && am.matrixThreads != nil {
+		s = append(s, contextStage(func(ctx context.Context) context.Context {
+			return matrix.WithThreadStore(ctx, am.matrixThreads)
+		}))
+	}
+	if !am.imageResolutionBudget.IsZero() {
+		s = append(s, contextStage(func(ctx context.Context) context.Context {
+			return images.WithResolutionBudget(ctx, am.imageResolutionBudget)
+		}))
+	}
+	if am.annotationLimits.LazySize > 0 {
+		s = append(s, contextStage(func(ctx context.Context) context.Context {
+			return templates.WithAnnotationLimits(ctx, am.annotationLimits)
+		}))
+	}
+	if am.templateLimits != (templates.ExecutionLimits{}) {
+		s = append(s, contextStage(func(ctx context.Context) context.Context {
+			return templates.WithExecutionLimits(ctx, am.templateLimits)
+		}))
+	}
+	if len(am.notifyOnceRouteKeys) > 0 {
+		s = append(s, &notifyOnceStage{routeKeys: am.notifyOnceRouteKeys, nflog: notificationLog, recv: recv})
+	}
+	s = append(s, notify.NewDedupStage(integration, notificationLog, recv))
+	var retry notify.Stage = notify.NewRetryStage(integration, name, am.stageMetrics)
+	if len(am.orderedRouteKeys) > 0 {
+		retry = &orderingStage{stage: retry, routeKeys: am.orderedRouteKeys, nflog: notificationLog, recv: recv}
+	}
+	if am.deadLetterHandler != nil {
+		retry = newDeadLetterStage(retry, integration, am.deadLetterHandler)
+	}
+	var deliver notify.MultiStage
+	if am.notificationAuthorizer != nil {
+		deliver = append(deliver, &authorizationStage{
+			authorizer:  am.notificationAuthorizer,
+			integration: integration,
+			recorder:    am.deliveryRecorder,
+			vetoes:      am.Metrics.notificationVetoes.MustCurryWith(prometheus.Labels{"org": am.tenantString()}),
+		})
+	}
+	deliver = append(deliver, newTracingStage("notify.Retry", retry, integrationAttributes(name, integration)...))
+	var deliverStage notify.Stage = deliver
+	if am.deliveryReceipts != nil {
+		deliverStage = &deliveryReceiptStage{
+			stage:    deliverStage,
+			receipts: am.deliveryReceipts,
+			recv:     recv,
+			skipped:  am.Metrics.deliveryReceiptSkips.WithLabelValues(am.tenantString(), integration.Name()),
+		}
+	}
+	if am.notificationLock != nil {
+		deliverStage = &notificationLockStage{stage: deliverStage, lock: am.notificationLock, ttl: am.notificationLockTTL, tenantID: am.tenantID, recv: recv}
+	}
+	s = append(s, deliverStage)
+	s = append(s, notify.NewSetNotifiesStage(notificationLog, recv))
+	return newTracingStage("notify.Integration", s, integrationAttributes(name, integration)...)
+}
+
+// withClock sets the time of the notification to the time of the clock of the Alertmanager, and the clock that the
+// integrations wait on between retries.
+func (am *GrafanaAlertmanager) withClock(ctx context.Context) context.Context {
+	return nfstatus.WithClock(notify.WithNow(ctx, am.clock.Now()), am.clock)
+}
+
+// contextStage adds values to the context of the notification, such as the options of the integrations.
+func contextStage(fn func(context.Context) context.Context) notify.Stage {
+	return notify.StageFunc(func(ctx context.Context, _ log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		return fn(ctx), alerts, nil
+	})
+}
+
 func (am *GrafanaAlertmanager) waitFunc() time.Duration {
 	return time.Duration(am.peer.Position()) * am.peerTimeout
 }
@@ -5,6 +5,10 @@ import (
 	"github.com/prometheus/alertmanager/api/metrics"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/receivers/discord"
+	"github.com/grafana/alerting/receivers/opsgenie"
 )
 
 const namespace = "grafana"
@@ -18,6 +22,21 @@ type GrafanaAlertmanagerMetrics struct {
 	configuredReceivers       *prometheus.GaugeVec
 	configuredIntegrations    *prometheus.GaugeVec
 	configuredInhibitionRules *prometheus.GaugeVec
+	opsgenieBatch             *opsgenie.BatchMetrics
+	discord                   *discord.Metrics
+	imageFallback             *images.FallbackMetrics
+	notificationQueuedSeconds *prometheus.HistogramVec
+	notificationDuration      *prometheus.HistogramVec
+	notificationPayloadBytes  *prometheus.HistogramVec
+	notificationAlerts        *prometheus.HistogramVec
+	notificationFailures      *prometheus.CounterVec
+	snapshotLoads             *prometheus.CounterVec
+	snapshotSizeBytes         *prometheus.GaugeVec
+	resolveTimeoutExpired     *prometheus.CounterVec
+	notificationVetoes        *prometheus.CounterVec
+	alertSuppressedSeconds    *prometheus.CounterVec
+	flapSilences              *prometheus.CounterVec
+	deliveryReceiptSkips      *prometheus.CounterVec
 }
 
 // NewGrafanaAlertmanagerMetrics creates a set of metrics for the Alertmanager.
@@ -43,5 +62,84 @@ func NewGrafanaAlertmanagerMetrics(r prometheus.Registerer, l log.Logger) *Grafa
 			Name:      "alertmanager_inhibition_rules",
 			Help:      "Number of configured inhibition rules.",
 		}, []string{"org"}),
+		opsgenieBatch: opsgenie.NewBatchMetrics(r),
+		discord:       discord.NewMetrics(r),
+		imageFallback: images.NewFallbackMetrics(r),
+		notificationQueuedSeconds: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_notification_queued_seconds",
+			Help:      "Time notifications waited for the concurrency limit of their integration type.",
+			Buckets:   []float64{.01, .1, 1, 5, 10, 30, 60},
+		}, []string{"integration"}),
+		notificationDuration: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_notification_duration_seconds",
+			Help:      "Duration of the notification attempts of the integrations.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"org", "integration"}),
+		notificationPayloadBytes: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_notification_payload_bytes",
+			Help:      "Size of the requests sent by each notification attempt of the integrations.",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 8),
+		}, []string{"org", "integration"}),
+		notificationAlerts: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_notification_alerts",
+			Help:      "Number of alerts in each notification attempt of the integrations.",
+			Buckets:   []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000},
+		}, []string{"org", "integration"}),
+		notificationFailures: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_notification_failures_total",
+			Help:      "Number of failed notification attempts of the integrations by reason: timeout, 4xx, 5xx, template_error or other.",
+		}, []string{"org", "integration", "reason"}),
+		snapshotLoads: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_state_snapshot_loads_total",
+			Help:      "Number of snapshots of silences and the notification log loaded at startup by result: loaded, started_empty, restored_from_backup or failed.",
+		}, []string{"org", "kind", "result"}),
+		snapshotSizeBytes: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_state_snapshot_size_bytes",
+			Help:      "Size of the snapshots of silences and the notification log loaded at startup.",
+		}, []string{"org", "kind"}),
+		resolveTimeoutExpired: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_alerts_resolved_by_timeout_total",
+			Help:      "Number of alerts resolved because they were not updated within the resolve timeout.",
+		}, []string{"org"}),
+		notificationVetoes: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_notifications_vetoed_total",
+			Help:      "Number of notifications of the integrations vetoed by the notification authorizer, by reason.",
+		}, []string{"org", "integration", "reason"}),
+		alertSuppressedSeconds: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_alert_suppressed_seconds_total",
+			Help:      "Total time alerts spent suppressed by silences, inhibitions and mute time intervals, by reason. It is counted when the alerts stop being suppressed.",
+		}, []string{"org", "reason"}),
+		flapSilences: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_flapping_alerts_silenced_total",
+			Help:      "Number of silences created for flapping alerts, by result.",
+		}, []string{"org", "result"}),
+		deliveryReceiptSkips: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_notifications_skipped_by_receipt_total",
+			Help:      "Number of notifications of the integrations not sent because another member of the cluster was sending or delivered them.",
+		}, []string{"org", "integration"}),
 	}
 }
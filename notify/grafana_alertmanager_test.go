@@ -5,14 +5,19 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"os"
+	"path/filepath"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/go-kit/log"
 	"github.com/go-openapi/strfmt"
 	amv2 "github.com/prometheus/alertmanager/api/v2/models"
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/alertmanager/provider/mem"
 	"github.com/prometheus/alertmanager/types"
@@ -21,7 +26,11 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/definition"
+	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/notify/nfstatus"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
 )
 
 func setupAMTest(t *testing.T) (*GrafanaAlertmanager, *prometheus.Registry) {
@@ -35,9 +44,238 @@ func setupAMTest(t *testing.T) (*GrafanaAlertmanager, *prometheus.Registry) {
 
 	am, err := NewGrafanaAlertmanager("org", 1, grafanaConfig, &NilPeer{}, log.NewNopLogger(), m)
 	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = am.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
 	return am, reg
 }
 
+func TestRun(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := NewGrafanaAlertmanagerMetrics(reg, log.NewNopLogger())
+	silencesOpts, nflogOpts := newFakeMaintanenceOptions(t), newFakeMaintanenceOptions(t)
+	clk := clock.NewMock()
+	am, err := NewGrafanaAlertmanager("org", 1, &GrafanaAlertmanagerConfig{
+		Silences:         silencesOpts,
+		Nflog:            nflogOpts,
+		MaintenanceClock: clk,
+		StartOnRun:       true,
+	}, &NilPeer{}, log.NewNopLogger(), m)
+	require.NoError(t, err)
+
+	// Nothing runs until Run is called.
+	clk.Add(2 * silencesOpts.MaintenanceFrequency())
+	require.Zero(t, silencesOpts.calls.Load())
+	require.Zero(t, nflogOpts.calls.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- am.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		clk.Add(silencesOpts.MaintenanceFrequency())
+		return silencesOpts.calls.Load() > 0 && nflogOpts.calls.Load() > 0
+	}, time.Second, 10*time.Millisecond)
+	require.ErrorIs(t, am.Run(ctx), ErrAlertmanagerAlreadyRunning)
+
+	cancel()
+	select {
+	case err := <-errc:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context was canceled")
+	}
+
+	// A final snapshot is taken on shutdown, and nothing runs afterwards.
+	calls := silencesOpts.calls.Load()
+	clk.Add(2 * silencesOpts.MaintenanceFrequency())
+	require.Equal(t, calls, silencesOpts.calls.Load())
+
+	require.ErrorIs(t, am.ApplyConfig(nil), ErrAlertmanagerStopped)
+	am.StopAndWait()
+}
+
+// runConfig is a Configuration with a single receiver, whose integration sends the names of the alerts of each
+// notification to sent.
+type runConfig struct {
+	interval model.Duration
+	sent     chan string
+}
+
+func (c *runConfig) DispatcherLimits() DispatcherLimits        { return nil }
+func (c *runConfig) InhibitRules() []InhibitRule               { return nil }
+func (c *runConfig) TimeIntervals() []TimeInterval             { return nil }
+func (c *runConfig) MuteTimeIntervals() []MuteTimeInterval     { return nil }
+func (c *runConfig) Templates() []templates.TemplateDefinition { return nil }
+func (c *runConfig) Hash() [16]byte                            { return [16]byte{} }
+func (c *runConfig) Raw() []byte                               { return []byte("{}") }
+
+func (c *runConfig) Receivers() []*APIReceiver {
+	return []*APIReceiver{{ConfigReceiver: ConfigReceiver{Name: "default"}}}
+}
+
+func (c *runConfig) RoutingTree() *Route {
+	return &Route{Receiver: "default", GroupWait: &c.interval, GroupInterval: &c.interval, RepeatInterval: &c.interval}
+}
+
+func (c *runConfig) BuildReceiverIntegrationsFunc() func(*APIReceiver, *templates.Template) ([]*Integration, error) {
+	return func(r *APIReceiver, _ *templates.Template) ([]*Integration, error) {
+		n := notifierFunc(func(_ context.Context, alerts ...*types.Alert) (bool, error) {
+			select {
+			case c.sent <- alerts[0].Name():
+			default:
+			}
+			return false, nil
+		})
+		return []*Integration{NewIntegration(n, sendResolved(true), "webhook", 0, r.Name)}, nil
+	}
+}
+
+type notifierFunc func(context.Context, ...*types.Alert) (bool, error)
+
+func (f notifierFunc) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	return f(ctx, alerts...)
+}
+
+func TestRun_StartsDispatcher(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := NewGrafanaAlertmanagerMetrics(reg, log.NewNopLogger())
+	am, err := NewGrafanaAlertmanager("org", 1, &GrafanaAlertmanagerConfig{
+		Silences:   newFakeMaintanenceOptions(t),
+		Nflog:      newFakeMaintanenceOptions(t),
+		StartOnRun: true,
+	}, &NilPeer{}, log.NewNopLogger(), m)
+	require.NoError(t, err)
+
+	cfg := &runConfig{interval: model.Duration(10 * time.Millisecond), sent: make(chan string, 1)}
+	require.NoError(t, am.ApplyConfig(cfg))
+	require.NoError(t, am.PutAlerts(amv2.PostableAlerts{{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "Alert1"}}}}))
+
+	// The dispatcher of the applied configuration does not run until Run is called, so the alert is not in a group.
+	require.Zero(t, am.pendingGroups())
+	require.Empty(t, cfg.sent)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- am.Run(ctx)
+	}()
+	select {
+	case name := <-cfg.sent:
+		require.Equal(t, "Alert1", name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification was sent after Run was called")
+	}
+	cancel()
+	require.NoError(t, <-errc)
+}
+
+func TestRun_ShutdownSnapshotError(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := NewGrafanaAlertmanagerMetrics(reg, log.NewNopLogger())
+	silencesOpts := newFakeMaintanenceOptions(t)
+	silencesOpts.err = errors.New("unavailable")
+	am, err := NewGrafanaAlertmanager("org", 1, &GrafanaAlertmanagerConfig{
+		Silences: silencesOpts,
+		Nflog:    newFakeMaintanenceOptions(t),
+	}, &NilPeer{}, log.NewNopLogger(), m)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.EqualError(t, am.Run(ctx), "silences: unavailable")
+}
+
+func TestNewGrafanaAlertmanager_StartsMaintenance(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := NewGrafanaAlertmanagerMetrics(reg, log.NewNopLogger())
+	silencesOpts, nflogOpts := newFakeMaintanenceOptions(t), newFakeMaintanenceOptions(t)
+	clk := clock.NewMock()
+	am, err := NewGrafanaAlertmanager("org", 1, &GrafanaAlertmanagerConfig{
+		Silences:         silencesOpts,
+		Nflog:            nflogOpts,
+		MaintenanceClock: clk,
+	}, &NilPeer{}, log.NewNopLogger(), m)
+	require.NoError(t, err)
+
+	// The maintenance runs without Run.
+	require.Eventually(t, func() bool {
+		clk.Add(silencesOpts.MaintenanceFrequency())
+		return silencesOpts.calls.Load() > 0 && nflogOpts.calls.Load() > 0
+	}, time.Second, 10*time.Millisecond)
+
+	// A final snapshot is taken when the Alertmanager stops, and nothing runs afterwards.
+	am.StopAndWait()
+	calls := silencesOpts.calls.Load()
+	clk.Add(2 * silencesOpts.MaintenanceFrequency())
+	require.Equal(t, calls, silencesOpts.calls.Load())
+	require.Positive(t, calls)
+}
+
+func TestRun_Clock(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := NewGrafanaAlertmanagerMetrics(reg, log.NewNopLogger())
+	silencesOpts, nflogOpts := newFakeMaintanenceOptions(t), newFakeMaintanenceOptions(t)
+	clk := clock.NewMock()
+	clk.Set(time.Now().Truncate(time.Second))
+	am, err := NewGrafanaAlertmanager("org", 1, &GrafanaAlertmanagerConfig{
+		Silences:         silencesOpts,
+		Nflog:            nflogOpts,
+		MaintenanceClock: clk,
+	}, &NilPeer{}, log.NewNopLogger(), m)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- am.Run(ctx)
+	}()
+
+	// The maintenance only runs when the clock advances by its frequency.
+	clk.Add(silencesOpts.MaintenanceFrequency() - time.Millisecond)
+	require.Zero(t, silencesOpts.calls.Load())
+	require.Zero(t, nflogOpts.calls.Load())
+	require.Eventually(t, func() bool {
+		clk.Add(silencesOpts.MaintenanceFrequency())
+		return silencesOpts.calls.Load() > 0 && nflogOpts.calls.Load() > 0
+	}, time.Second, 10*time.Millisecond)
+
+	// Alerts are received at the time of the clock.
+	require.NoError(t, am.PutAlerts(amv2.PostableAlerts{{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "Alert1"}}}}))
+	iter := am.alerts.GetPending()
+	var alerts []*types.Alert
+	for a := range iter.Next() {
+		alerts = append(alerts, a)
+	}
+	iter.Close()
+	require.Len(t, alerts, 1)
+	require.Equal(t, clk.Now(), alerts[0].StartsAt)
+	require.Equal(t, clk.Now().Add(DefaultResolveTimeout), alerts[0].EndsAt)
+
+	// Notifications are sent at the time of the clock, and integrations wait on it between retries.
+	nctx := am.withClock(context.Background())
+	now, ok := notify.Now(nctx)
+	require.True(t, ok)
+	require.Equal(t, clk.Now(), now)
+	require.Same(t, clk, nfstatus.ClockFromContext(nctx))
+
+	// A final snapshot is taken on shutdown.
+	calls := silencesOpts.calls.Load()
+	cancel()
+	require.NoError(t, <-errc)
+	require.Equal(t, calls+1, silencesOpts.calls.Load())
+}
+
 func TestPutAlert(t *testing.T) {
 	am, _ := setupAMTest(t)
 
@@ -112,7 +350,7 @@ func TestPutAlert(t *testing.T) {
 							Annotations:  model.LabelSet{"msg": "Alert3 annotation"},
 							Labels:       model.LabelSet{"alertname": "Alert3"},
 							StartsAt:     startTime,
-							EndsAt:       now.Add(defaultResolveTimeout),
+							EndsAt:       now.Add(DefaultResolveTimeout),
 							GeneratorURL: "http://localhost/url3",
 						},
 						UpdatedAt: now,
@@ -122,7 +360,7 @@ func TestPutAlert(t *testing.T) {
 							Annotations:  model.LabelSet{"msg": "Alert4 annotation"},
 							Labels:       model.LabelSet{"alertname": "Alert4"},
 							StartsAt:     now,
-							EndsAt:       now.Add(defaultResolveTimeout),
+							EndsAt:       now.Add(DefaultResolveTimeout),
 							GeneratorURL: "http://localhost/url4",
 						},
 						UpdatedAt: now,
@@ -150,7 +388,7 @@ func TestPutAlert(t *testing.T) {
 							Annotations:  model.LabelSet{"msg": "Alert4 annotation"},
 							Labels:       model.LabelSet{"alertname": "Alert4"},
 							StartsAt:     now,
-							EndsAt:       now.Add(defaultResolveTimeout),
+							EndsAt:       now.Add(DefaultResolveTimeout),
 							GeneratorURL: "http://localhost/url1",
 						},
 						UpdatedAt: now,
@@ -178,7 +416,7 @@ func TestPutAlert(t *testing.T) {
 							Annotations:  model.LabelSet{"Dashboard URL": "http://localhost:3000"},
 							Labels:       model.LabelSet{"alertname": "Alert4", "Spaced Label": "works"},
 							StartsAt:     now,
-							EndsAt:       now.Add(defaultResolveTimeout),
+							EndsAt:       now.Add(DefaultResolveTimeout),
 							GeneratorURL: "http://localhost/url1",
 						},
 						UpdatedAt: now,
@@ -202,7 +440,7 @@ func TestPutAlert(t *testing.T) {
 							Labels:       model.LabelSet{"alertname$": "Alert1", "az3-- __...++!!!£@@312312": "1"},
 							Annotations:  model.LabelSet{},
 							StartsAt:     now,
-							EndsAt:       now.Add(defaultResolveTimeout),
+							EndsAt:       now.Add(DefaultResolveTimeout),
 							GeneratorURL: "",
 						},
 						UpdatedAt: now,
@@ -227,7 +465,7 @@ func TestPutAlert(t *testing.T) {
 							Labels:       model.LabelSet{"alertname": "Alert4"},
 							Annotations:  model.LabelSet{"az3-- __...++!!!£@@312312": "Alert4 annotation"},
 							StartsAt:     now,
-							EndsAt:       now.Add(defaultResolveTimeout),
+							EndsAt:       now.Add(DefaultResolveTimeout),
 							GeneratorURL: "",
 						},
 						UpdatedAt: now,
@@ -667,12 +905,12 @@ func TestSilenceCleanup(t *testing.T) {
 
 func TestStatusForTestReceivers(t *testing.T) {
 	t.Run("assert HTTP 400 Status Bad Request for no receivers", func(t *testing.T) {
-		_, status := newTestReceiversResult(types.Alert{}, []result{}, []*APIReceiver{}, time.Now())
+		_, status := newTestReceiversResult(nil, []result{}, []*APIReceiver{}, time.Now())
 		require.Equal(t, http.StatusBadRequest, status)
 	})
 
 	t.Run("assert HTTP 400 Bad Request when all invalid receivers", func(t *testing.T) {
-		_, status := newTestReceiversResult(types.Alert{}, []result{
+		_, status := newTestReceiversResult(nil, []result{
 			{
 				ReceiverName: "receiver 1",
 				Config:       &GrafanaIntegrationConfig{Name: "integration 1"},
@@ -715,7 +953,7 @@ func TestStatusForTestReceivers(t *testing.T) {
 	})
 
 	t.Run("assert HTTP 408 Request Timeout when all receivers timed out", func(t *testing.T) {
-		_, status := newTestReceiversResult(types.Alert{}, []result{
+		_, status := newTestReceiversResult(nil, []result{
 			{
 				ReceiverName: "receiver 1",
 				Config:       &GrafanaIntegrationConfig{Name: "integration 1"},
@@ -758,7 +996,7 @@ func TestStatusForTestReceivers(t *testing.T) {
 	})
 
 	t.Run("assert 207 Multi Status for different errors", func(t *testing.T) {
-		_, status := newTestReceiversResult(types.Alert{}, []result{
+		_, status := newTestReceiversResult(nil, []result{
 			{
 				ReceiverName: "receiver 1",
 				Config:       &GrafanaIntegrationConfig{Name: "integration 1"},
@@ -801,7 +1039,7 @@ func TestStatusForTestReceivers(t *testing.T) {
 	})
 
 	t.Run("assert 200 for no errors", func(t *testing.T) {
-		_, status := newTestReceiversResult(types.Alert{}, []result{
+		_, status := newTestReceiversResult(nil, []result{
 			{
 				ReceiverName: "receiver 1",
 				Config:       &GrafanaIntegrationConfig{Name: "integration 1"},
@@ -835,3 +1073,233 @@ func TestStatusForTestReceivers(t *testing.T) {
 		require.Equal(t, http.StatusOK, status)
 	})
 }
+
+// resolvedRecorder records whether the notified alerts are resolved, and fails to notify resolved alerts if err is set.
+type resolvedRecorder struct {
+	mtx      sync.Mutex
+	resolved []bool
+	err      error
+}
+
+func (r *resolvedRecorder) Notify(_ context.Context, alerts ...*types.Alert) (bool, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.resolved = append(r.resolved, alerts[0].Resolved())
+	if alerts[0].Resolved() {
+		return false, r.err
+	}
+	return false, nil
+}
+
+func TestTestReceivers_Resolve(t *testing.T) {
+	recorders := map[string]*resolvedRecorder{
+		"pagerduty": {},
+		"opsgenie":  {err: errors.New("failed to close alert")},
+		"slack":     {},
+		"webhook":   {},
+	}
+	build := func(r *APIReceiver, _ *templates.Template) ([]*nfstatus.Integration, error) {
+		intg := r.Integrations[0]
+		return []*nfstatus.Integration{nfstatus.NewIntegration(recorders[intg.UID], sendResolved(true), intg.Type, 0, intg.Name)}, nil
+	}
+	receiver := &APIReceiver{
+		ConfigReceiver: ConfigReceiver{Name: "receiver"},
+		GrafanaIntegrations: GrafanaIntegrations{Integrations: []*GrafanaIntegrationConfig{
+			{UID: "pagerduty", Name: "pagerduty", Type: "pagerduty"},
+			{UID: "opsgenie", Name: "opsgenie", Type: "opsgenie"},
+			{UID: "slack", Name: "slack", Type: "slack"},
+			{UID: "webhook", Name: "webhook", Type: "webhook", DisableResolveMessage: true},
+		}},
+	}
+
+	res, status, err := TestReceivers(context.Background(), TestReceiversConfigBodyParams{
+		Receivers: []*APIReceiver{receiver},
+		Resolve:   true,
+	}, nil, build, "http://localhost")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusMultiStatus, status)
+
+	results := map[string]TestIntegrationConfigResult{}
+	for _, c := range res.Receivers[0].Configs {
+		results[c.UID] = c
+	}
+	require.Equal(t, TestIntegrationConfigResult{Name: "pagerduty", UID: "pagerduty", Status: "ok", ResolvedStatus: "ok"}, results["pagerduty"])
+	require.Equal(t, TestIntegrationConfigResult{Name: "opsgenie", UID: "opsgenie", Status: "ok", ResolvedStatus: "failed", ResolvedError: "failed to close alert"}, results["opsgenie"])
+	// Integrations that do not open incidents are not sent resolved notifications.
+	require.Equal(t, TestIntegrationConfigResult{Name: "slack", UID: "slack", Status: "ok"}, results["slack"])
+	require.Equal(t, TestIntegrationConfigResult{Name: "webhook", UID: "webhook", Status: "ok", ResolvedStatus: "skipped"}, results["webhook"])
+
+	require.Equal(t, []bool{false, true}, recorders["pagerduty"].resolved)
+	require.Equal(t, []bool{false, true}, recorders["opsgenie"].resolved)
+	require.Equal(t, []bool{false}, recorders["slack"].resolved)
+	require.Equal(t, []bool{false}, recorders["webhook"].resolved)
+
+	t.Run("resolved notifications are only sent if requested", func(t *testing.T) {
+		recorders["pagerduty"].resolved = nil
+		_, status, err := TestReceivers(context.Background(), TestReceiversConfigBodyParams{
+			Receivers: []*APIReceiver{receiver},
+		}, nil, build, "http://localhost")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []bool{false}, recorders["pagerduty"].resolved)
+	})
+}
+
+type alertsRecorder struct {
+	mtx    sync.Mutex
+	alerts [][]*types.Alert
+}
+
+func (r *alertsRecorder) Notify(_ context.Context, alerts ...*types.Alert) (bool, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.alerts = append(r.alerts, alerts)
+	return false, nil
+}
+
+func TestTestReceivers_Alerts(t *testing.T) {
+	recorders := map[string]*alertsRecorder{"slack": {}, "email": {}}
+	build := func(r *APIReceiver, _ *templates.Template) ([]*nfstatus.Integration, error) {
+		intg := r.Integrations[0]
+		return []*nfstatus.Integration{nfstatus.NewIntegration(recorders[intg.UID], sendResolved(true), intg.Type, 0, intg.Name)}, nil
+	}
+	receiver := &APIReceiver{
+		ConfigReceiver: ConfigReceiver{Name: "receiver"},
+		GrafanaIntegrations: GrafanaIntegrations{Integrations: []*GrafanaIntegrationConfig{
+			{UID: "slack", Name: "slack", Type: "slack"},
+			{UID: "email", Name: "email", Type: "email"},
+		}},
+	}
+
+	res, status, err := TestReceivers(context.Background(), TestReceiversConfigBodyParams{
+		Receivers: []*APIReceiver{receiver},
+		Alerts: []*TestReceiversConfigAlertParams{
+			{Labels: model.LabelSet{"instance": "a"}},
+			{Labels: model.LabelSet{"instance": "b"}, Annotations: model.LabelSet{"summary": "b is down"}, Status: model.AlertResolved},
+		},
+		IntegrationAnnotations: map[string]model.LabelSet{"email": {"summary": "overridden"}},
+	}, nil, build, "http://localhost")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, res.Alerts, 2)
+	require.Equal(t, res.Alerts[0], res.Alert)
+
+	summaries := func(alerts []*types.Alert) map[model.LabelValue]model.LabelValue {
+		res := map[model.LabelValue]model.LabelValue{}
+		for _, a := range alerts {
+			res[a.Labels["instance"]] = a.Annotations["summary"]
+		}
+		return res
+	}
+	require.Len(t, recorders["slack"].alerts, 1)
+	slack := recorders["slack"].alerts[0]
+	require.Equal(t, map[model.LabelValue]model.LabelValue{"a": "Notification test", "b": "b is down"}, summaries(slack))
+	require.Equal(t, model.AlertFiring, slack[0].Status())
+	require.Equal(t, model.AlertResolved, slack[1].Status())
+
+	require.Len(t, recorders["email"].alerts, 1)
+	require.Equal(t, map[model.LabelValue]model.LabelValue{"a": "overridden", "b": "overridden"}, summaries(recorders["email"].alerts[0]))
+	// The annotations of the result are not overridden.
+	require.Equal(t, model.LabelValue("b is down"), res.Alerts[1].Annotations["summary"])
+
+	t.Run("invalid status", func(t *testing.T) {
+		_, _, err := TestReceivers(context.Background(), TestReceiversConfigBodyParams{
+			Receivers: []*APIReceiver{receiver},
+			Alerts:    []*TestReceiversConfigAlertParams{{Status: "pending"}},
+		}, nil, build, "http://localhost")
+		require.ErrorContains(t, err, `invalid status "pending" of test alert 0`)
+	})
+}
+
+func TestResolveTemplates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "slack.tmpl"), []byte(`{{ define "slack" }}{{ end }}`), 0o600))
+	am := &GrafanaAlertmanager{
+		logger:           log.NewNopLogger(),
+		templateResolver: definition.NewTemplateResolver(map[string]definition.TemplateFetcher{definition.TemplateSourceFile: definition.FileTemplateFetcher{Dir: dir}}),
+	}
+
+	tmpls, err := am.resolveTemplates([]templates.TemplateDefinition{
+		{Name: "local", Template: `{{ define "local" }}{{ end }}`},
+		{Name: "file://slack.tmpl"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []templates.TemplateDefinition{
+		{Name: "local", Template: `{{ define "local" }}{{ end }}`},
+		{Name: "file://slack.tmpl", Template: `{{ define "slack" }}{{ end }}`},
+	}, tmpls)
+
+	_, err = am.resolveTemplates([]templates.TemplateDefinition{{Name: "file://missing.tmpl"}})
+	require.ErrorContains(t, err, `failed to resolve template "file://missing.tmpl"`)
+}
+
+func TestGrafanaAlertmanager_EmailRouting(t *testing.T) {
+	am, _ := setupAMTest(t)
+	require.Nil(t, am.EmailSenderFactory())
+
+	cfg := &GrafanaAlertmanagerConfig{
+		Silences:     newFakeMaintanenceOptions(t),
+		Nflog:        newFakeMaintanenceOptions(t),
+		EmailRouting: &receivers.EmailRoutingConfig{Senders: map[string]receivers.EmailSenderConfig{"internal": {}}},
+	}
+	require.EqualError(t, cfg.Validate(), "invalid email routing: default senders must be present")
+	cfg.EmailRouting.Default = []string{"internal"}
+	require.NoError(t, cfg.Validate())
+
+	am, err := NewGrafanaAlertmanager("org", 1, cfg, &NilPeer{}, log.NewNopLogger(), NewGrafanaAlertmanagerMetrics(prometheus.NewPedanticRegistry(), log.NewNopLogger()))
+	require.NoError(t, err)
+	require.NotNil(t, am.EmailSenderFactory())
+	sender, err := am.EmailSenderFactory()(receivers.Metadata{})
+	require.NoError(t, err)
+	require.NotNil(t, sender)
+}
+
+// versionedConfig is a Configuration with a version.
+type versionedConfig struct {
+	previewConfig
+	version string
+}
+
+func (c *versionedConfig) Version() string { return c.version }
+
+func TestGrafanaAlertmanager_ConfigGeneration(t *testing.T) {
+	am, _ := setupAMTest(t)
+	require.Equal(t, receivers.ConfigGeneration{}, am.ConfigGeneration())
+
+	cfg := previewConfig{
+		grafanaRoutingTreeConfig: grafanaRoutingTreeConfig{route: &definition.Route{Receiver: "default"}},
+		receivers:                []string{"default"},
+	}
+	// The dispatcher of a configuration must be running before it is stopped, by the next configuration or when the
+	// Alertmanager stops.
+	waitForDispatcher := func() {
+		require.Eventually(t, func() bool {
+			groups, err := am.GetAlertGroupSnapshots(AlertGroupsFilter{Active: true})
+			require.NoError(t, err)
+			return len(groups) == 1
+		}, time.Second, 10*time.Millisecond)
+	}
+	require.NoError(t, am.PutAlerts(amv2.PostableAlerts{{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "test"}}}}))
+
+	require.NoError(t, am.ApplyConfig(&cfg))
+	waitForDispatcher()
+	require.Equal(t, receivers.ConfigGeneration{Generation: 1}, am.ConfigGeneration())
+	require.NoError(t, am.ApplyConfig(&versionedConfig{previewConfig: cfg, version: "v2"}))
+	waitForDispatcher()
+	require.Equal(t, receivers.ConfigGeneration{Generation: 2, Version: "v2"}, am.ConfigGeneration())
+
+	// Configurations that fail to apply do not change the generation.
+	invalid := &resolveTimeoutConfig{previewConfig: cfg, resolveTimeout: -time.Minute}
+	require.Error(t, am.ApplyConfig(invalid))
+	require.Equal(t, receivers.ConfigGeneration{Generation: 2, Version: "v2"}, am.ConfigGeneration())
+
+	// Notifications are sent with the generation of the configuration of their stages.
+	integration := NewIntegration(&fakeNotifier{}, &fakeNotifier{}, "webhook", 0, "default")
+	stage := am.createIntegrationStage("default", "", integration.Integration(), am.notificationLog, am.ConfigGeneration())
+	ctx, _, err := stage.(*tracingStage).stage.(notify.MultiStage)[0].Exec(context.Background(), log.NewNopLogger())
+	require.NoError(t, err)
+	g, ok := receivers.ConfigGenerationFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, receivers.ConfigGeneration{Generation: 2, Version: "v2"}, g)
+	require.Subset(t, logging.ContextFields(ctx), []interface{}{logging.KeyConfigGeneration, uint64(2), logging.KeyConfigVersion, "v2"})
+}
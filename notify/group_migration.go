@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// alertGroupRef identifies the aggregation group of a receiver, as the dispatcher does.
+type alertGroupRef struct {
+	receiver string
+	groupKey string
+	route    *dispatch.Route
+}
+
+// groupFiringAlerts returns the fingerprints of the firing alerts of each aggregation group the dispatcher creates
+// for the route tree.
+func groupFiringAlerts(route *dispatch.Route, alerts []*types.Alert, now time.Time) map[alertGroupRef]map[model.Fingerprint]struct{} {
+	groups := make(map[alertGroupRef]map[model.Fingerprint]struct{})
+	for _, a := range alerts {
+		if a.ResolvedAt(now) {
+			continue
+		}
+		for _, r := range route.Match(a.Labels) {
+			groupLabels := model.LabelSet{}
+			for name, value := range a.Labels {
+				if _, ok := r.RouteOpts.GroupBy[name]; ok || r.RouteOpts.GroupByAll {
+					groupLabels[name] = value
+				}
+			}
+			ref := alertGroupRef{receiver: r.RouteOpts.Receiver, groupKey: fmt.Sprintf("%s:%s", r.Key(), groupLabels), route: r}
+			if groups[ref] == nil {
+				groups[ref] = make(map[model.Fingerprint]struct{})
+			}
+			groups[ref][a.Fingerprint()] = struct{}{}
+		}
+	}
+	return groups
+}
+
+// migrateGroups copies the notification log entries of the aggregation groups of the old route tree to the groups of
+// the new route tree whose keys changed, such as when group_by is changed. The entries of an old group are copied to
+// a new group of the same receiver if all the firing alerts of the new group are in the old group, so that the new
+// group is not notified again about alerts that the old group already notified about. It returns the number of
+// copied entries.
+func (am *GrafanaAlertmanager) migrateGroups(oldRoute, newRoute *dispatch.Route, integrations map[string][]*Integration) int {
+	if oldRoute == nil {
+		return 0
+	}
+	now := am.clock.Now()
+	it := am.alerts.GetPending()
+	defer it.Close()
+	var alerts []*types.Alert
+	for a := range it.Next() {
+		alerts = append(alerts, a)
+	}
+	oldGroups := groupFiringAlerts(oldRoute, alerts, now)
+	newGroups := groupFiringAlerts(newRoute, alerts, now)
+
+	oldKeys := make(map[string]struct{}, len(oldGroups))
+	for ref := range oldGroups {
+		oldKeys[ref.groupKey] = struct{}{}
+	}
+
+	var buf bytes.Buffer
+	migrated := 0
+	for ref, fps := range newGroups {
+		if _, ok := oldKeys[ref.groupKey]; ok {
+			continue
+		}
+		// Prefer the smallest old group that has all the alerts of the new group.
+		var from alertGroupRef
+		fromSize := 0
+		for oldRef, oldFps := range oldGroups {
+			if oldRef.receiver != ref.receiver || len(oldFps) < len(fps) || (fromSize > 0 && len(oldFps) >= fromSize) {
+				continue
+			}
+			if isFingerprintSubset(fps, oldFps) {
+				from, fromSize = oldRef, len(oldFps)
+			}
+		}
+		if fromSize == 0 {
+			continue
+		}
+
+		expiry := am.notificationLogRetention
+		if repeat := 2 * ref.route.RouteOpts.RepeatInterval; repeat > 0 && repeat < expiry {
+			expiry = repeat
+		}
+		for _, i := range integrations[ref.receiver] {
+			recv := &nflogpb.Receiver{GroupName: ref.receiver, Integration: i.Name(), Idx: uint32(i.Index())}
+			if _, err := am.notificationLog.Query(nflog.QGroupKey(ref.groupKey), nflog.QReceiver(recv)); err == nil {
+				continue
+			}
+			entries, err := am.notificationLog.Query(nflog.QGroupKey(from.groupKey), nflog.QReceiver(recv))
+			if err != nil || len(entries) == 0 {
+				continue
+			}
+			// The entry keeps the time of the last notification, so that repeat intervals are not reset.
+			entry := *entries[0]
+			entry.GroupKey = []byte(ref.groupKey)
+			if _, err := pbutil.WriteDelimited(&buf, &nflogpb.MeshEntry{Entry: &entry, ExpiresAt: entry.Timestamp.Add(expiry)}); err != nil {
+				level.Error(am.logger).Log("msg", "Failed to migrate notification log entry", "receiver", ref.receiver, "integration", i.Name(), "err", err)
+				continue
+			}
+			level.Debug(am.logger).Log("msg", "Migrating notification log entry", "receiver", ref.receiver, "integration", i.Name(), "from", from.groupKey, "to", ref.groupKey)
+			migrated++
+		}
+	}
+	if buf.Len() == 0 {
+		return 0
+	}
+	if err := am.notificationLog.Merge(buf.Bytes()); err != nil {
+		level.Error(am.logger).Log("msg", "Failed to migrate notification log entries", "err", err)
+		return 0
+	}
+	return migrated
+}
+
+func isFingerprintSubset(subset, set map[model.Fingerprint]struct{}) bool {
+	for fp := range subset {
+		if _, ok := set[fp]; !ok {
+			return false
+		}
+	}
+	return true
+}
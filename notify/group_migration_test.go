@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+)
+
+func TestApplyConfig_MigratesNotificationLogOfGroups(t *testing.T) {
+	am, _ := setupAMTest(t)
+	hour := model.Duration(time.Hour)
+	config := func(groupBy ...model.LabelName) *previewConfig {
+		groupByStr := make([]string, 0, len(groupBy))
+		for _, l := range groupBy {
+			groupByStr = append(groupByStr, string(l))
+		}
+		return &previewConfig{
+			grafanaRoutingTreeConfig: grafanaRoutingTreeConfig{route: &definition.Route{
+				Receiver:       "default",
+				GroupByStr:     groupByStr,
+				GroupBy:        groupBy,
+				GroupWait:      &hour,
+				GroupInterval:  &hour,
+				RepeatInterval: &hour,
+			}},
+			receivers: []string{"default"},
+		}
+	}
+	// The dispatcher cannot be stopped before it runs, so each configuration is applied once it groups the alerts.
+	waitForGroups := func() {
+		require.Eventually(t, func() bool {
+			groups, _ := am.dispatcher.Groups(func(*dispatch.Route) bool { return true }, func(*types.Alert, time.Time) bool { return true })
+			return len(groups) > 0
+		}, time.Second, 10*time.Millisecond)
+	}
+	recv := &nflogpb.Receiver{GroupName: "default", Integration: "webhook", Idx: 0}
+	entry := func(groupKey string) *nflogpb.Entry {
+		entries, err := am.notificationLog.Query(nflog.QGroupKey(groupKey), nflog.QReceiver(recv))
+		if err != nil {
+			return nil
+		}
+		return entries[0]
+	}
+
+	require.NoError(t, am.ApplyConfig(config("alertname", "team")))
+	require.NoError(t, am.PutAlerts(PostableAlerts{
+		{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "test", "team": "a", "instance": "1"}}},
+		{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "test", "team": "a", "instance": "2"}}},
+		{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "test", "team": "b", "instance": "1"}}},
+	}))
+	waitForGroups()
+	teamA := `{}:{alertname="test", team="a"}`
+	require.NoError(t, am.notificationLog.Log(recv, teamA, []uint64{1, 2}, nil, 0))
+	notified := entry(teamA)
+	require.NotNil(t, notified)
+
+	// The groups of team a are split by instance, and both keep the notification log of their group.
+	require.NoError(t, am.ApplyConfig(config("alertname", "team", "instance")))
+	waitForGroups()
+	for _, key := range []string{`{}:{alertname="test", instance="1", team="a"}`, `{}:{alertname="test", instance="2", team="a"}`} {
+		e := entry(key)
+		require.NotNil(t, e, key)
+		require.Equal(t, []byte(key), e.GroupKey)
+		require.True(t, notified.Timestamp.Equal(e.Timestamp))
+		require.Equal(t, notified.FiringAlerts, e.FiringAlerts)
+	}
+	// The group of team b was not notified.
+	require.Nil(t, entry(`{}:{alertname="test", instance="1", team="b"}`))
+
+	// Groups with alerts of different groups are notified again.
+	require.NoError(t, am.ApplyConfig(config("alertname")))
+	waitForGroups()
+	require.Nil(t, entry(`{}:{alertname="test"}`))
+}
@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alerting/definition"
+)
+
+// AlertGroupPreview is an alert group that the dispatcher would create for a routing tree.
+type AlertGroupPreview struct {
+	// GroupKey is the key of the group, as used by the dispatcher and the notification log.
+	GroupKey string `json:"groupKey"`
+	// Path is the path of the route of the group in the routing tree, such as "route.routes[0]".
+	Path        string         `json:"path"`
+	Receiver    string         `json:"receiver"`
+	GroupLabels model.LabelSet `json:"groupLabels"`
+	Alerts      []*types.Alert `json:"alerts"`
+}
+
+// PreviewGroups returns the alert groups that the dispatcher would create for the alerts with the routing tree of
+// the configuration, without dispatching them. Groups are ordered by the position of their route in the routing
+// tree and then by group key, and alerts by their order in the input. It can be used to preview the effect of
+// changes to the routing tree, such as to group_by, before applying them. The configuration is expected to be valid.
+func PreviewGroups(cfg *definition.PostableApiAlertingConfig, alerts []*types.Alert) ([]AlertGroupPreview, error) {
+	if cfg == nil || cfg.Route == nil {
+		return nil, errors.New("configuration has no routing tree")
+	}
+
+	route := dispatch.NewRoute(cfg.Route.AsAMRoute(), nil)
+	routes := make(map[*dispatch.Route]routeInfo)
+	indexRoutes("route", cfg.Route, route, routes)
+
+	groups := make(map[*dispatch.Route]map[model.Fingerprint]*AlertGroupPreview)
+	for _, a := range alerts {
+		if err := a.Labels.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid labels of alert %s: %w", a.Labels, err)
+		}
+		for _, r := range route.Match(a.Labels) {
+			labels := groupLabels(a.Labels, r)
+			fp := labels.Fingerprint()
+			routeGroups, ok := groups[r]
+			if !ok {
+				routeGroups = make(map[model.Fingerprint]*AlertGroupPreview)
+				groups[r] = routeGroups
+			}
+			g, ok := routeGroups[fp]
+			if !ok {
+				g = &AlertGroupPreview{
+					GroupKey:    fmt.Sprintf("%s:%s", r.Key(), labels),
+					Path:        routes[r].path,
+					Receiver:    r.RouteOpts.Receiver,
+					GroupLabels: labels,
+				}
+				routeGroups[fp] = g
+			}
+			g.Alerts = append(g.Alerts, a)
+		}
+	}
+
+	var res []AlertGroupPreview
+	route.Walk(func(r *dispatch.Route) {
+		start := len(res)
+		for _, g := range groups[r] {
+			res = append(res, *g)
+		}
+		routeGroups := res[start:]
+		sort.Slice(routeGroups, func(i, j int) bool {
+			return routeGroups[i].GroupKey < routeGroups[j].GroupKey
+		})
+	})
+	return res, nil
+}
+
+// groupLabels returns the labels of the group of an alert with the labels in the route, the same as the dispatcher.
+func groupLabels(labels model.LabelSet, r *dispatch.Route) model.LabelSet {
+	res := model.LabelSet{}
+	for ln, lv := range labels {
+		if _, ok := r.RouteOpts.GroupBy[ln]; ok || r.RouteOpts.GroupByAll {
+			res[ln] = lv
+		}
+	}
+	return res
+}
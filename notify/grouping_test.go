@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+)
+
+func TestPreviewGroups(t *testing.T) {
+	cfg, err := definition.Load([]byte(`
+route:
+  receiver: default
+  group_by: [alertname]
+  routes:
+  - receiver: team-a
+    group_by: [alertname, cluster]
+    continue: true
+    object_matchers:
+    - [team, =, a]
+  - receiver: all
+    group_by: ['...']
+    object_matchers:
+    - [team, =, a]
+receivers:
+- name: default
+- name: team-a
+- name: all
+`))
+	require.NoError(t, err)
+
+	alert := func(labels model.LabelSet) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: labels}}
+	}
+	a1 := alert(model.LabelSet{"alertname": "a", "team": "a", "cluster": "prod"})
+	a2 := alert(model.LabelSet{"alertname": "a", "team": "a", "cluster": "dev"})
+	a3 := alert(model.LabelSet{"alertname": "a", "team": "a", "cluster": "prod", "instance": "1"})
+	b1 := alert(model.LabelSet{"alertname": "b", "team": "b"})
+	b2 := alert(model.LabelSet{"alertname": "b", "team": "c"})
+
+	groups, err := PreviewGroups(cfg, []*types.Alert{a1, a2, a3, b1, b2})
+	require.NoError(t, err)
+	require.Equal(t, []AlertGroupPreview{{
+		GroupKey:    `{}:{alertname="b"}`,
+		Path:        "route",
+		Receiver:    "default",
+		GroupLabels: model.LabelSet{"alertname": "b"},
+		Alerts:      []*types.Alert{b1, b2},
+	}, {
+		GroupKey:    `{}/{team="a"}:{alertname="a", cluster="dev"}`,
+		Path:        "route.routes[0]",
+		Receiver:    "team-a",
+		GroupLabels: model.LabelSet{"alertname": "a", "cluster": "dev"},
+		Alerts:      []*types.Alert{a2},
+	}, {
+		GroupKey:    `{}/{team="a"}:{alertname="a", cluster="prod"}`,
+		Path:        "route.routes[0]",
+		Receiver:    "team-a",
+		GroupLabels: model.LabelSet{"alertname": "a", "cluster": "prod"},
+		Alerts:      []*types.Alert{a1, a3},
+	}, {
+		GroupKey:    `{}/{team="a"}:{alertname="a", cluster="dev", team="a"}`,
+		Path:        "route.routes[1]",
+		Receiver:    "all",
+		GroupLabels: a2.Labels,
+		Alerts:      []*types.Alert{a2},
+	}, {
+		GroupKey:    `{}/{team="a"}:{alertname="a", cluster="prod", instance="1", team="a"}`,
+		Path:        "route.routes[1]",
+		Receiver:    "all",
+		GroupLabels: a3.Labels,
+		Alerts:      []*types.Alert{a3},
+	}, {
+		GroupKey:    `{}/{team="a"}:{alertname="a", cluster="prod", team="a"}`,
+		Path:        "route.routes[1]",
+		Receiver:    "all",
+		GroupLabels: a1.Labels,
+		Alerts:      []*types.Alert{a1},
+	}}, groups)
+
+	t.Run("invalid labels are rejected", func(t *testing.T) {
+		_, err := PreviewGroups(cfg, []*types.Alert{alert(model.LabelSet{"alertname": "\xff"})})
+		require.ErrorContains(t, err, "invalid labels of alert")
+	})
+}
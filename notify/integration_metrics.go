@@ -0,0 +1,278 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+// Reasons of failed notifications.
+const (
+	FailureReasonTimeout     = "timeout"
+	FailureReasonClientError = "4xx"
+	FailureReasonServerError = "5xx"
+	FailureReasonTemplate    = "template_error"
+	FailureReasonOther       = "other"
+)
+
+// FailureReason returns the reason of the error of a notification attempt.
+func FailureReason(err error) string {
+	var tmplErr templates.ExecutionError
+	if errors.As(err, &tmplErr) {
+		return FailureReasonTemplate
+	}
+	var timeoutErr interface{ Timeout() bool }
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &timeoutErr) && timeoutErr.Timeout() {
+		return FailureReasonTimeout
+	}
+	var reasonErr *notify.ErrorWithReason
+	if errors.As(err, &reasonErr) {
+		switch reasonErr.Reason {
+		case notify.ClientErrorReason:
+			return FailureReasonClientError
+		case notify.ServerErrorReason:
+			return FailureReasonServerError
+		case notify.ContextDeadlineExceededReason:
+			return FailureReasonTimeout
+		}
+	}
+	return FailureReasonOther
+}
+
+// wrapMetrics returns an integration whose notification attempts are observed by the metrics of the tenant.
+func wrapMetrics(integration *notify.Integration, receiver, tenant string, m *GrafanaAlertmanagerMetrics) *notify.Integration {
+	n := &measuringNotifier{
+		upstream: integration,
+		tenant:   tenant,
+		name:     integration.Name(),
+		duration: m.notificationDuration.WithLabelValues(tenant, integration.Name()),
+		payload:  m.notificationPayloadBytes,
+		alerts:   m.notificationAlerts.WithLabelValues(tenant, integration.Name()),
+		failures: m.notificationFailures,
+	}
+	return notify.NewIntegration(n, integration, integration.Name(), integration.Index(), receiver)
+}
+
+// measuringNotifier wraps a notify.Notifier and observes the duration, the size of the requests and the alerts
+// of each notification attempt, and the reason of the attempts that fail.
+type measuringNotifier struct {
+	upstream notify.Notifier
+	tenant   string
+	name     string
+	duration prometheus.Observer
+	payload  *prometheus.HistogramVec
+	alerts   prometheus.Observer
+	failures *prometheus.CounterVec
+}
+
+// Notify implements the Notifier interface.
+func (n *measuringNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	// Integrations that do not report the size of their requests, such as email, are not observed.
+	var payload atomic.Int64
+	var observed atomic.Bool
+	ctx = receivers.WithPayloadObserver(ctx, func(bytes int) {
+		payload.Add(int64(bytes))
+		observed.Store(true)
+	})
+
+	start := time.Now()
+	retry, err := n.upstream.Notify(ctx, alerts...)
+	n.duration.Observe(time.Since(start).Seconds())
+	n.alerts.Observe(float64(len(alerts)))
+	if observed.Load() {
+		n.payload.WithLabelValues(n.tenant, n.name).Observe(float64(payload.Load()))
+	}
+	if err != nil {
+		n.failures.WithLabelValues(n.tenant, n.name, FailureReason(err)).Inc()
+	}
+	return retry, err
+}
+
+// IntegrationMetricsLabel is the label that identifies the integrations in the metrics of each integration.
+type IntegrationMetricsLabel string
+
+const (
+	// IntegrationMetricsLabelReceiver identifies the integrations by the name of their receiver.
+	IntegrationMetricsLabelReceiver IntegrationMetricsLabel = "receiver"
+	// IntegrationMetricsLabelUID identifies the integrations by their UID.
+	IntegrationMetricsLabelUID IntegrationMetricsLabel = "integration_uid"
+)
+
+// DefaultIntegrationMetricsMaxValues is the default maximum number of values of the label of the metrics of each
+// integration.
+const DefaultIntegrationMetricsMaxValues = 100
+
+// IntegrationMetricsOverflowValue is the value of the label of the integrations over the maximum number of values.
+const IntegrationMetricsOverflowValue = "__other__"
+
+// IntegrationMetricsOptions enable metrics of the notifications of each receiver or integration, in addition to the
+// metrics of each integration type. As each value of the label is a series of each metric, the number of values is
+// limited, and the notifications of the other receivers or integrations are counted under IntegrationMetricsOverflowValue.
+type IntegrationMetricsOptions struct {
+	// Label is the label that identifies the integrations. The metrics are disabled if it is empty.
+	Label IntegrationMetricsLabel
+	// MaxValues is the maximum number of values of the label. It defaults to DefaultIntegrationMetricsMaxValues.
+	MaxValues int
+}
+
+func (o IntegrationMetricsOptions) Validate() error {
+	switch o.Label {
+	case "", IntegrationMetricsLabelReceiver, IntegrationMetricsLabelUID:
+	default:
+		return fmt.Errorf("unknown label %q, must be %q or %q", o.Label, IntegrationMetricsLabelReceiver, IntegrationMetricsLabelUID)
+	}
+	if o.MaxValues < 0 {
+		return errors.New("max values must not be negative")
+	}
+	return nil
+}
+
+// integrationMetrics are the metrics of the notifications of each receiver or integration.
+type integrationMetrics struct {
+	label     IntegrationMetricsLabel
+	maxValues int
+
+	mtx    sync.RWMutex
+	values map[string]struct{}
+
+	notifications *prometheus.CounterVec
+	failures      *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+}
+
+func newIntegrationMetrics(r prometheus.Registerer, opts IntegrationMetricsOptions) *integrationMetrics {
+	if opts.MaxValues == 0 {
+		opts.MaxValues = DefaultIntegrationMetricsMaxValues
+	}
+	labels := []string{"org", "integration", string(opts.Label)}
+	return &integrationMetrics{
+		label:     opts.Label,
+		maxValues: opts.MaxValues,
+		values:    make(map[string]struct{}),
+		notifications: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_integration_notifications_total",
+			Help:      "Number of notification attempts of each " + string(opts.Label) + ".",
+		}, labels),
+		failures: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_integration_notifications_failed_total",
+			Help:      "Number of failed notification attempts of each " + string(opts.Label) + " by reason: timeout, 4xx, 5xx, template_error or other.",
+		}, append(labels, "reason")),
+		latency: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "alertmanager_integration_notification_latency_seconds",
+			Help:      "Latency of the notification attempts of each " + string(opts.Label) + ".",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, labels),
+	}
+}
+
+// setValues sets the values of the label of the integrations of the configuration. The values that are still in
+// use keep their series, and the series of the values that are no longer in use are deleted. The new values are
+// added in order up to the maximum number of values.
+func (m *integrationMetrics) setValues(tenant string, values []string) {
+	sort.Strings(values)
+	inUse := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		inUse[v] = struct{}{}
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for v := range m.values {
+		if _, ok := inUse[v]; ok {
+			continue
+		}
+		delete(m.values, v)
+		l := prometheus.Labels{"org": tenant, string(m.label): v}
+		m.notifications.DeletePartialMatch(l)
+		m.failures.DeletePartialMatch(l)
+		m.latency.DeletePartialMatch(l)
+	}
+	for _, v := range values {
+		if len(m.values) >= m.maxValues {
+			break
+		}
+		m.values[v] = struct{}{}
+	}
+}
+
+// labelValue returns the value of the label of the integration, or IntegrationMetricsOverflowValue if the value is
+// over the maximum number of values.
+func (m *integrationMetrics) labelValue(receiver, uid string) string {
+	v := receiver
+	if m.label == IntegrationMetricsLabelUID {
+		v = uid
+	}
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	if _, ok := m.values[v]; ok {
+		return v
+	}
+	return IntegrationMetricsOverflowValue
+}
+
+// wrap returns an integration whose notification attempts are observed by the metrics of the receiver or
+// integration.
+func (m *integrationMetrics) wrap(integration *notify.Integration, receiver, uid, tenant string) *notify.Integration {
+	l := prometheus.Labels{"org": tenant, "integration": integration.Name(), string(m.label): m.labelValue(receiver, uid)}
+	n := &labeledMeasuringNotifier{
+		upstream:      integration,
+		notifications: m.notifications.With(l),
+		failures:      m.failures.MustCurryWith(l),
+		latency:       m.latency.With(l),
+	}
+	return notify.NewIntegration(n, integration, integration.Name(), integration.Index(), receiver)
+}
+
+// labeledMeasuringNotifier wraps a notify.Notifier and observes the notification attempts of a receiver or
+// integration.
+type labeledMeasuringNotifier struct {
+	upstream      notify.Notifier
+	notifications prometheus.Counter
+	failures      *prometheus.CounterVec
+	latency       prometheus.Observer
+}
+
+// Notify implements the Notifier interface.
+func (n *labeledMeasuringNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	start := time.Now()
+	retry, err := n.upstream.Notify(ctx, alerts...)
+	n.latency.Observe(time.Since(start).Seconds())
+	n.notifications.Inc()
+	if err != nil {
+		n.failures.WithLabelValues(FailureReason(err)).Inc()
+	}
+	return retry, err
+}
+
+// integrationMetricsValues returns the values of the label of the integrations by receiver.
+func integrationMetricsValues(label IntegrationMetricsLabel, integrations map[string][]*Integration) []string {
+	values := make([]string, 0, len(integrations))
+	for receiver, is := range integrations {
+		if label == IntegrationMetricsLabelReceiver {
+			values = append(values, receiver)
+			continue
+		}
+		for _, i := range is {
+			values = append(values, i.UID())
+		}
+	}
+	return values
+}
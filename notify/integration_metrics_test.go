@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+func TestFailureReason(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{err: fmt.Errorf("failed: %w", context.DeadlineExceeded), expected: FailureReasonTimeout},
+		{err: fmt.Errorf("failed to send request: %w", timeoutError{}), expected: FailureReasonTimeout},
+		{err: notify.NewErrorWithReason(notify.ContextDeadlineExceededReason, errors.New("failed")), expected: FailureReasonTimeout},
+		{err: notify.NewErrorWithReason(notify.GetFailureReasonFromStatusCode(400), errors.New("failed")), expected: FailureReasonClientError},
+		{err: notify.NewErrorWithReason(notify.GetFailureReasonFromStatusCode(503), errors.New("failed")), expected: FailureReasonServerError},
+		{err: templates.ExecutionError{Err: errors.New("failed")}, expected: FailureReasonTemplate},
+		{err: notify.NewErrorWithReason(notify.DefaultReason, errors.New("failed")), expected: FailureReasonOther},
+		{err: errors.New("failed"), expected: FailureReasonOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			require.Equal(t, tt.expected, FailureReason(tt.err))
+		})
+	}
+}
+
+type payloadNotifier struct {
+	payloads []int
+	err      error
+}
+
+func (n *payloadNotifier) Notify(ctx context.Context, _ ...*types.Alert) (bool, error) {
+	for _, p := range n.payloads {
+		receivers.ObservePayload(ctx, p)
+	}
+	return false, n.err
+}
+
+func TestWrapMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := NewGrafanaAlertmanagerMetrics(reg, log.NewNopLogger())
+
+	ok := wrapMetrics(notify.NewIntegration(&payloadNotifier{payloads: []int{100, 200}}, sendResolved(true), "slack", 0, "team-a"), "team-a", "1", m)
+	_, err := ok.Notify(context.Background(), &types.Alert{}, &types.Alert{})
+	require.NoError(t, err)
+
+	// Integrations that do not report the size of their requests are not observed.
+	failing := wrapMetrics(notify.NewIntegration(&payloadNotifier{err: notify.NewErrorWithReason(notify.ServerErrorReason, errors.New("failed"))}, sendResolved(true), "email", 0, "team-a"), "team-a", "1", m)
+	_, err = failing.Notify(context.Background(), &types.Alert{})
+	require.Error(t, err)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+# HELP grafana_alerting_alertmanager_notification_failures_total Number of failed notification attempts of the integrations by reason: timeout, 4xx, 5xx, template_error or other.
+# TYPE grafana_alerting_alertmanager_notification_failures_total counter
+grafana_alerting_alertmanager_notification_failures_total{integration="email",org="1",reason="5xx"} 1
+# HELP grafana_alerting_alertmanager_notification_payload_bytes Size of the requests sent by each notification attempt of the integrations.
+# TYPE grafana_alerting_alertmanager_notification_payload_bytes histogram
+grafana_alerting_alertmanager_notification_payload_bytes_bucket{integration="slack",org="1",le="256"} 0
+grafana_alerting_alertmanager_notification_payload_bytes_bucket{integration="slack",org="1",le="1024"} 1
+grafana_alerting_alertmanager_notification_payload_bytes_bucket{integration="slack",org="1",le="4096"} 1
+grafana_alerting_alertmanager_notification_payload_bytes_bucket{integration="slack",org="1",le="16384"} 1
+grafana_alerting_alertmanager_notification_payload_bytes_bucket{integration="slack",org="1",le="65536"} 1
+grafana_alerting_alertmanager_notification_payload_bytes_bucket{integration="slack",org="1",le="262144"} 1
+grafana_alerting_alertmanager_notification_payload_bytes_bucket{integration="slack",org="1",le="1.048576e+06"} 1
+grafana_alerting_alertmanager_notification_payload_bytes_bucket{integration="slack",org="1",le="4.194304e+06"} 1
+grafana_alerting_alertmanager_notification_payload_bytes_bucket{integration="slack",org="1",le="+Inf"} 1
+grafana_alerting_alertmanager_notification_payload_bytes_sum{integration="slack",org="1"} 300
+grafana_alerting_alertmanager_notification_payload_bytes_count{integration="slack",org="1"} 1
+`), "grafana_alerting_alertmanager_notification_failures_total", "grafana_alerting_alertmanager_notification_payload_bytes"))
+
+	require.Equal(t, 2, testutil.CollectAndCount(m.notificationDuration))
+	require.Equal(t, 2, testutil.CollectAndCount(m.notificationAlerts))
+}
+
+func TestIntegrationMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m := newIntegrationMetrics(reg, IntegrationMetricsOptions{Label: IntegrationMetricsLabelReceiver, MaxValues: 2})
+	m.setValues("1", []string{"team-c", "team-b", "team-a"})
+
+	notifyAs := func(receiver string, err error) {
+		i := m.wrap(notify.NewIntegration(&payloadNotifier{err: err}, sendResolved(true), "slack", 0, receiver), receiver, "", "1")
+		_, _ = i.Notify(context.Background(), &types.Alert{})
+	}
+	notifyAs("team-a", nil)
+	notifyAs("team-b", notify.NewErrorWithReason(notify.ClientErrorReason, errors.New("failed")))
+	// Receivers over the maximum number of values are counted together.
+	notifyAs("team-c", nil)
+
+	expected := func(values ...string) string {
+		var b bytes.Buffer
+		b.WriteString("# HELP grafana_alerting_alertmanager_integration_notifications_failed_total Number of failed notification attempts of each receiver by reason: timeout, 4xx, 5xx, template_error or other.\n")
+		b.WriteString("# TYPE grafana_alerting_alertmanager_integration_notifications_failed_total counter\n")
+		for _, v := range values {
+			if v == "team-b" {
+				b.WriteString(`grafana_alerting_alertmanager_integration_notifications_failed_total{integration="slack",org="1",reason="4xx",receiver="team-b"} 1` + "\n")
+			}
+		}
+		b.WriteString("# HELP grafana_alerting_alertmanager_integration_notifications_total Number of notification attempts of each receiver.\n")
+		b.WriteString("# TYPE grafana_alerting_alertmanager_integration_notifications_total counter\n")
+		for _, v := range values {
+			b.WriteString(`grafana_alerting_alertmanager_integration_notifications_total{integration="slack",org="1",receiver="` + v + `"} 1` + "\n")
+		}
+		return b.String()
+	}
+	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(expected(IntegrationMetricsOverflowValue, "team-a", "team-b")),
+		"grafana_alerting_alertmanager_integration_notifications_total", "grafana_alerting_alertmanager_integration_notifications_failed_total"))
+	require.Equal(t, 3, testutil.CollectAndCount(m.latency))
+
+	// The series of removed receivers are deleted, and their values are given to other receivers.
+	m.setValues("1", []string{"team-c", "team-b"})
+	require.Equal(t, "team-c", m.labelValue("team-c", ""))
+	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(expected(IntegrationMetricsOverflowValue, "team-b")),
+		"grafana_alerting_alertmanager_integration_notifications_total", "grafana_alerting_alertmanager_integration_notifications_failed_total"))
+}
+
+func TestIntegrationMetricsOptions_Validate(t *testing.T) {
+	require.NoError(t, IntegrationMetricsOptions{}.Validate())
+	require.NoError(t, IntegrationMetricsOptions{Label: IntegrationMetricsLabelUID, MaxValues: 10}.Validate())
+	require.ErrorContains(t, IntegrationMetricsOptions{Label: "name"}.Validate(), `unknown label "name"`)
+	require.ErrorContains(t, IntegrationMetricsOptions{Label: IntegrationMetricsLabelReceiver, MaxValues: -1}.Validate(), "max values must not be negative")
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/alertmanager/notify"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+// builtinIntegrationTypes are the types of the integrations of this module, which cannot be registered.
+var builtinIntegrationTypes = map[string]struct{}{
+	"prometheus-alertmanager": {}, "blackhole": {}, "dingding": {}, "discord": {}, "echo": {}, "email": {},
+	"feishu": {}, "googlechat": {}, "kafka": {}, "line": {}, "matrix": {}, "mqtt": {}, "opsgenie": {},
+	"pagerduty": {}, "oncall": {}, "pushover": {}, "sensugo": {}, "slack": {}, "sns": {}, "teams": {},
+	"telegram": {}, "threema": {}, "victorops": {}, "webhook": {}, "wecom": {}, "webex": {}, "zenduty": {},
+}
+
+// NotificationChannel is the notifier of an integration, see IntegrationFactory.
+type NotificationChannel interface {
+	notify.Notifier
+	notify.ResolvedSender
+}
+
+// IntegrationDependencies are the dependencies of the notifiers of the integrations of a receiver.
+type IntegrationDependencies struct {
+	Template *templates.Template
+	// WebhookSender sends the HTTP requests of the integration, with the tracing of the built-in integrations.
+	WebhookSender receivers.WebhookSender
+	Images        images.Provider
+	Logger        logging.Logger
+	OrgID         int64
+	Version       string
+}
+
+// IntegrationFactory builds the integrations of a custom integration type, see RegisterIntegrationType.
+type IntegrationFactory struct {
+	// NewConfig parses and validates the settings of an integration, by BuildReceiverConfiguration. The secure
+	// settings are read with decrypt, which decrypts them and resolves their secret references like for the
+	// built-in integrations.
+	NewConfig func(settings json.RawMessage, decrypt receivers.DecryptFunc) (interface{}, error)
+	// New returns the notifier of an integration with the settings returned by NewConfig, by
+	// BuildReceiverIntegrations.
+	New func(settings interface{}, meta receivers.Metadata, deps IntegrationDependencies) (NotificationChannel, error)
+}
+
+// IntegrationSchema describes the settings of an integration type, such as to render the form of its integrations.
+type IntegrationSchema struct {
+	Type        string                    `json:"type"`
+	Name        string                    `json:"name"`
+	Description string                    `json:"description,omitempty"`
+	Options     []IntegrationSchemaOption `json:"options"`
+}
+
+// IntegrationSchemaOption is a setting of an integration type.
+type IntegrationSchemaOption struct {
+	// PropertyName is the key of the setting in the settings, or in the secure settings if Secure is set.
+	PropertyName string `json:"propertyName"`
+	Label        string `json:"label"`
+	Description  string `json:"description,omitempty"`
+	Required     bool   `json:"required,omitempty"`
+	Secure       bool   `json:"secure,omitempty"`
+}
+
+type integrationType struct {
+	factory IntegrationFactory
+	schema  IntegrationSchema
+}
+
+var (
+	integrationTypesMtx sync.RWMutex
+	integrationTypes    = make(map[string]integrationType)
+)
+
+// RegisterIntegrationType registers a custom integration type, so that the integrations of the type are built by
+// BuildReceiverConfiguration and BuildReceiverIntegrations like those of the built-in types, with the same
+// secure settings, retry policy, status and metrics. Types are case-insensitive, and a type cannot be registered
+// twice or be the type of a built-in integration. It is meant to be called when the embedding application starts,
+// before configurations are applied.
+func RegisterIntegrationType(typeName string, factory IntegrationFactory, schema IntegrationSchema) error {
+	key := strings.ToLower(typeName)
+	if key == "" {
+		return errors.New("integration type must be present")
+	}
+	if _, ok := builtinIntegrationTypes[key]; ok {
+		return fmt.Errorf("integration type %q is a built-in type", typeName)
+	}
+	if factory.NewConfig == nil || factory.New == nil {
+		return fmt.Errorf("factory of integration type %q must have NewConfig and New", typeName)
+	}
+	schema.Type = typeName
+
+	integrationTypesMtx.Lock()
+	defer integrationTypesMtx.Unlock()
+	if _, ok := integrationTypes[key]; ok {
+		return fmt.Errorf("integration type %q is already registered", typeName)
+	}
+	integrationTypes[key] = integrationType{factory: factory, schema: schema}
+	return nil
+}
+
+// RegisteredIntegrationTypes returns the schemas of the custom integration types, ordered by type.
+func RegisteredIntegrationTypes() []IntegrationSchema {
+	integrationTypesMtx.RLock()
+	defer integrationTypesMtx.RUnlock()
+	res := make([]IntegrationSchema, 0, len(integrationTypes))
+	for _, t := range integrationTypes {
+		res = append(res, t.schema)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Type < res[j].Type })
+	return res
+}
+
+// lookupIntegrationType returns the custom integration type, if it is registered.
+func lookupIntegrationType(typeName string) (integrationType, bool) {
+	integrationTypesMtx.RLock()
+	defer integrationTypesMtx.RUnlock()
+	t, ok := integrationTypes[strings.ToLower(typeName)]
+	return t, ok
+}
@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+type pagerConfig struct {
+	Team  string `json:"team"`
+	Token string `json:"-"`
+}
+
+type pagerNotifier struct {
+	*receivers.Base
+	cfg  pagerConfig
+	deps IntegrationDependencies
+}
+
+func (n *pagerNotifier) Notify(context.Context, ...*types.Alert) (bool, error) { return false, nil }
+
+func (n *pagerNotifier) SendResolved() bool { return !n.GetDisableResolveMessage() }
+
+var pagerFactory = IntegrationFactory{
+	NewConfig: func(settings json.RawMessage, decrypt receivers.DecryptFunc) (interface{}, error) {
+		var cfg pagerConfig
+		if err := json.Unmarshal(settings, &cfg); err != nil {
+			return nil, err
+		}
+		if cfg.Team == "" {
+			return nil, errors.New("team must be present")
+		}
+		cfg.Token = decrypt("token", "")
+		return cfg, nil
+	},
+	New: func(settings interface{}, meta receivers.Metadata, deps IntegrationDependencies) (NotificationChannel, error) {
+		return &pagerNotifier{Base: receivers.NewBase(meta), cfg: settings.(pagerConfig), deps: deps}, nil
+	},
+}
+
+func TestRegisterIntegrationType(t *testing.T) {
+	t.Cleanup(func() {
+		integrationTypesMtx.Lock()
+		delete(integrationTypes, "pager")
+		integrationTypesMtx.Unlock()
+	})
+	schema := IntegrationSchema{Name: "Pager", Options: []IntegrationSchemaOption{
+		{PropertyName: "team", Label: "Team", Required: true},
+		{PropertyName: "token", Label: "Token", Required: true, Secure: true},
+	}}
+	require.NoError(t, RegisterIntegrationType("Pager", pagerFactory, schema))
+
+	schema.Type = "Pager"
+	require.Equal(t, []IntegrationSchema{schema}, RegisteredIntegrationTypes())
+
+	require.EqualError(t, RegisterIntegrationType("pager", pagerFactory, schema), `integration type "pager" is already registered`)
+	require.EqualError(t, RegisterIntegrationType("Slack", pagerFactory, schema), `integration type "Slack" is a built-in type`)
+	require.EqualError(t, RegisterIntegrationType("", pagerFactory, schema), "integration type must be present")
+	require.EqualError(t, RegisterIntegrationType("other", IntegrationFactory{}, schema), `factory of integration type "other" must have NewConfig and New`)
+
+	api := &APIReceiver{
+		ConfigReceiver: ConfigReceiver{Name: "receiver"},
+		GrafanaIntegrations: GrafanaIntegrations{Integrations: []*GrafanaIntegrationConfig{
+			{UID: "pager-1", Name: "receiver", Type: "pager", Settings: json.RawMessage(`{"team":"a"}`), SecureSettings: map[string]string{"token": "secret"}},
+			{UID: "pager-2", Name: "receiver", Type: "pager", Settings: json.RawMessage(`{"team":"b"}`), DisableResolveMessage: true},
+		}},
+	}
+	cfg, err := BuildReceiverConfiguration(context.Background(), api, NoopDecode, NoopDecrypt)
+	require.NoError(t, err)
+	require.Len(t, cfg.CustomConfigs, 2)
+	require.Equal(t, pagerConfig{Team: "a", Token: "secret"}, cfg.CustomConfigs[0].Settings)
+
+	tmpl := templates.ForTests(t)
+	webhookSender := receivers.MockNotificationService()
+	integrations, err := BuildReceiverIntegrations(cfg, tmpl, &images.FakeProvider{},
+		func(string, ...interface{}) logging.Logger { return &logging.FakeLogger{} },
+		func(receivers.Metadata) (receivers.WebhookSender, error) { return webhookSender, nil },
+		nil, 1, "v1")
+	require.NoError(t, err)
+	require.Len(t, integrations, 2)
+	for i, integration := range integrations {
+		require.Equal(t, "pager", integration.Name())
+		require.Equal(t, i, integration.Index())
+	}
+	require.True(t, integrations[0].SendResolved())
+	require.False(t, integrations[1].SendResolved())
+
+	t.Run("invalid settings", func(t *testing.T) {
+		api := &APIReceiver{
+			ConfigReceiver: ConfigReceiver{Name: "receiver"},
+			GrafanaIntegrations: GrafanaIntegrations{Integrations: []*GrafanaIntegrationConfig{
+				{UID: "pager-1", Name: "receiver", Type: "pager", Settings: json.RawMessage(`{}`)},
+			}},
+		}
+		_, err := BuildReceiverConfiguration(context.Background(), api, NoopDecode, NoopDecrypt)
+		require.EqualError(t, err, `failed to validate integration "receiver" (UID pager-1) of type "pager": team must be present`)
+	})
+}
+
+func TestBuiltinIntegrationTypes(t *testing.T) {
+	for typ := range AllKnownConfigsForTesting {
+		require.Contains(t, builtinIntegrationTypes, typ)
+	}
+}
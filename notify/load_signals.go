@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// DefaultLoadSignalInterval is how often load signals are emitted by default.
+const DefaultLoadSignalInterval = time.Second
+
+// LoadSignal is the load of the notification pipeline at a point in time, for embedders that scale workers or
+// shed load faster than metrics are scraped.
+type LoadSignal struct {
+	Time time.Time
+	// PendingGroups is the number of alert groups with alerts, which are notified at each group interval.
+	PendingGroups int
+	// QueueDepth is the number of notifications in the pipeline, that are waiting or being sent.
+	QueueDepth int
+	// BacklogAge is for how long the oldest notification in the pipeline has been in it, or zero if there are none.
+	BacklogAge time.Duration
+}
+
+// LoadSignalHandler is invoked with each load signal. The next signal is emitted after it returns, so it should
+// not block, such as by sending the signal to a buffered channel without waiting.
+type LoadSignalHandler func(LoadSignal)
+
+// LoadSignalOptions configure the load signals of the notification pipeline.
+type LoadSignalOptions struct {
+	// Handler is invoked with each signal. It is required.
+	Handler LoadSignalHandler
+	// Interval is how often signals are emitted. It defaults to DefaultLoadSignalInterval.
+	Interval time.Duration
+}
+
+func (o *LoadSignalOptions) Validate() error {
+	if o.Handler == nil {
+		return errors.New("handler must be present")
+	}
+	if o.Interval < 0 {
+		return errors.New("interval must not be negative")
+	}
+	return nil
+}
+
+// loadTracker tracks the notifications in the pipeline, and emits the load signals.
+type loadTracker struct {
+	handler  LoadSignalHandler
+	interval time.Duration
+	clock    clock.Clock
+
+	mtx      sync.Mutex
+	nextID   uint64
+	inFlight map[uint64]time.Time
+}
+
+func newLoadTracker(opts LoadSignalOptions, clk clock.Clock) *loadTracker {
+	t := &loadTracker{
+		handler:  opts.Handler,
+		interval: opts.Interval,
+		clock:    clk,
+		inFlight: make(map[uint64]time.Time),
+	}
+	if t.interval == 0 {
+		t.interval = DefaultLoadSignalInterval
+	}
+	return t
+}
+
+// Wrap returns the stage, whose notifications are tracked while it runs.
+func (t *loadTracker) Wrap(stage notify.Stage) notify.Stage {
+	return notify.StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		t.mtx.Lock()
+		id := t.nextID
+		t.nextID++
+		t.inFlight[id] = t.clock.Now()
+		t.mtx.Unlock()
+
+		defer func() {
+			t.mtx.Lock()
+			delete(t.inFlight, id)
+			t.mtx.Unlock()
+		}()
+		return stage.Exec(ctx, l, alerts...)
+	})
+}
+
+// signal returns the current load signal, with the number of pending groups.
+func (t *loadTracker) signal(pendingGroups int) LoadSignal {
+	s := LoadSignal{Time: t.clock.Now(), PendingGroups: pendingGroups}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	s.QueueDepth = len(t.inFlight)
+	for _, start := range t.inFlight {
+		if age := s.Time.Sub(start); age > s.BacklogAge {
+			s.BacklogAge = age
+		}
+	}
+	return s
+}
+
+// run emits the load signals at each interval until stopc is closed. pendingGroups returns the number of pending
+// groups.
+func (t *loadTracker) run(stopc <-chan struct{}, pendingGroups func() int) {
+	ticker := t.clock.Ticker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.handler(t.signal(pendingGroups()))
+		case <-stopc:
+			return
+		}
+	}
+}
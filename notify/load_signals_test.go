@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-kit/log"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+	"github.com/grafana/alerting/templates"
+)
+
+func TestLoadTracker(t *testing.T) {
+	clk := clock.NewMock()
+	tracker := newLoadTracker(LoadSignalOptions{Handler: func(LoadSignal) {}}, clk)
+	require.Equal(t, DefaultLoadSignalInterval, tracker.interval)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	stage := tracker.Wrap(notify.StageFunc(func(ctx context.Context, _ log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		started <- struct{}{}
+		<-release
+		return ctx, alerts, nil
+	}))
+
+	done := make(chan struct{}, 2)
+	exec := func() {
+		_, _, _ = stage.Exec(context.Background(), log.NewNopLogger())
+		done <- struct{}{}
+	}
+	go exec()
+	<-started
+	clk.Add(time.Minute)
+	go exec()
+	<-started
+	clk.Add(time.Second)
+
+	s := tracker.signal(3)
+	require.Equal(t, LoadSignal{Time: clk.Now(), PendingGroups: 3, QueueDepth: 2, BacklogAge: time.Minute + time.Second}, s)
+
+	close(release)
+	<-done
+	<-done
+	require.Equal(t, LoadSignal{Time: clk.Now()}, tracker.signal(0))
+}
+
+func TestLoadSignals(t *testing.T) {
+	signals := make(chan LoadSignal, 100)
+	reg := prometheus.NewPedanticRegistry()
+	am, err := NewGrafanaAlertmanager("org", 1, &GrafanaAlertmanagerConfig{
+		Silences: newFakeMaintanenceOptions(t),
+		Nflog:    newFakeMaintanenceOptions(t),
+		LoadSignals: &LoadSignalOptions{
+			Handler: func(s LoadSignal) {
+				select {
+				case signals <- s:
+				default:
+				}
+			},
+			Interval: 10 * time.Millisecond,
+		},
+	}, &NilPeer{}, log.NewNopLogger(), NewGrafanaAlertmanagerMetrics(reg, log.NewNopLogger()))
+	require.NoError(t, err)
+	t.Cleanup(am.StopAndWait)
+
+	interval := model.Duration(time.Hour)
+	require.NoError(t, am.ApplyConfig(&templatesConfig{
+		grafanaRoutingTreeConfig: grafanaRoutingTreeConfig{route: &definition.Route{
+			Receiver:      "default",
+			GroupBy:       []model.LabelName{"alertname"},
+			GroupWait:     &interval,
+			GroupInterval: &interval,
+		}},
+		tmpls: []templates.TemplateDefinition{{Name: "msg", Template: `{{ define "msg" }}v1{{ end }}`}},
+		sent:  make(chan string, 1),
+	}))
+	require.NoError(t, am.PutAlerts(amv2.PostableAlerts{
+		{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "Alert1"}}},
+		{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "Alert2"}}},
+	}))
+
+	require.Eventually(t, func() bool {
+		s := <-signals
+		return s.PendingGroups == 2 && s.QueueDepth == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestLoadSignalOptions_Validate(t *testing.T) {
+	require.EqualError(t, (&LoadSignalOptions{}).Validate(), "handler must be present")
+	require.EqualError(t, (&LoadSignalOptions{Handler: func(LoadSignal) {}, Interval: -time.Second}).Validate(), "interval must not be negative")
+	require.NoError(t, (&LoadSignalOptions{Handler: func(LoadSignal) {}}).Validate())
+}
@@ -0,0 +1,68 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/alerting/notify"
+)
+
+// EtcdClient is the client of etcd used by EtcdLock, provided by the embedder with its own etcd library, such as an
+// adapter of the lease and KV APIs of go.etcd.io/etcd/client/v3.
+type EtcdClient interface {
+	// Grant grants a lease that expires after the TTL in seconds, and returns its ID.
+	Grant(ctx context.Context, ttl int64) (int64, error)
+	// CreateWithLease creates the key with the value attached to the lease, in a transaction that only succeeds if the
+	// key does not exist, such as If(Compare(CreateRevision(key), "=", 0)).Then(OpPut(key, value, WithLease(lease))).
+	// It returns whether the key was created.
+	CreateWithLease(ctx context.Context, key string, value []byte, lease int64) (bool, error)
+	// Revoke revokes the lease, which deletes the keys attached to it.
+	Revoke(ctx context.Context, lease int64) error
+}
+
+// EtcdLock is a notify.NotificationLock whose locks are keys of etcd attached to a lease with the TTL of the lock.
+// Each lock has its own lease, so that members only release their own locks by revoking the lease.
+type EtcdLock struct {
+	client EtcdClient
+	prefix string
+	held   *held
+}
+
+var _ notify.NotificationLock = (*EtcdLock)(nil)
+
+// NewEtcdLock returns an EtcdLock whose keys have the prefix, such as "alerting/lock/".
+func NewEtcdLock(client EtcdClient, prefix string) *EtcdLock {
+	return &EtcdLock{client: client, prefix: prefix, held: newHeld()}
+}
+
+// TryAcquire creates the key of the lock if it does not exist, attached to a new lease with the TTL. The TTL is
+// rounded up to seconds, the granularity of the leases of etcd.
+func (e *EtcdLock) TryAcquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	lease, err := e.client.Grant(ctx, seconds)
+	if err != nil {
+		return false, err
+	}
+	ok, err := e.client.CreateWithLease(ctx, e.prefix+key, nil, lease)
+	if err != nil || !ok {
+		// The lease is not needed, it would otherwise be kept until it expires.
+		if rerr := e.client.Revoke(context.WithoutCancel(ctx), lease); err == nil {
+			err = rerr
+		}
+		return false, err
+	}
+	e.held.add(key, heldLock{lease: lease}, time.Duration(seconds)*time.Second)
+	return true, nil
+}
+
+// Release revokes the lease of the lock if it is still held by this member, which deletes its key.
+func (e *EtcdLock) Release(ctx context.Context, key string) error {
+	l, ok := e.held.remove(key)
+	if !ok {
+		return nil
+	}
+	return e.client.Revoke(ctx, l.lease)
+}
@@ -0,0 +1,54 @@
+// Package lock provides implementations of the notification lock of the Alertmanager backed by stores shared by the
+// members of a cluster, such as Redis or etcd. The clients of the stores are provided by the embedder with its own
+// libraries, through adapters of the few commands that the locks use.
+package lock
+
+import (
+	"sync"
+	"time"
+)
+
+// held are the locks acquired by a member, by key, so that a member only releases its own locks. Locks are
+// forgotten once they expire, as the store releases them.
+type held struct {
+	mtx   sync.Mutex
+	locks map[string]heldLock
+	now   func() time.Time
+}
+
+// heldLock is a lock of the member: the token of a RedisLock or the lease of an EtcdLock.
+type heldLock struct {
+	token     []byte
+	lease     int64
+	expiresAt time.Time
+}
+
+func newHeld() *held {
+	return &held{locks: make(map[string]heldLock), now: time.Now}
+}
+
+// add records the lock of the key, held for the duration of the TTL.
+func (h *held) add(key string, l heldLock, ttl time.Duration) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	now := h.now()
+	for k, l := range h.locks {
+		if !now.Before(l.expiresAt) {
+			delete(h.locks, k)
+		}
+	}
+	l.expiresAt = now.Add(ttl)
+	h.locks[key] = l
+}
+
+// remove forgets the lock of the key, and returns it if it is held.
+func (h *held) remove(key string) (heldLock, bool) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	l, ok := h.locks[key]
+	delete(h.locks, key)
+	if !ok || !h.now().Before(l.expiresAt) {
+		return heldLock{}, false
+	}
+	return l, true
+}
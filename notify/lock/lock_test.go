@@ -0,0 +1,245 @@
+package lock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisLock(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeRedisClient()
+	// Two members of a cluster, whose locks are in the same Redis.
+	lock1 := NewRedisLock(client, "alerting:lock:")
+	lock2 := NewRedisLock(client, "alerting:lock:")
+	lock1.held.now = client.clock
+	lock2.held.now = client.clock
+
+	ok, err := lock1.TryAcquire(ctx, "key", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Contains(t, client.values, "alerting:lock:key")
+	require.Equal(t, time.Minute, client.values["alerting:lock:key"].ttl)
+
+	ok, err = lock2.TryAcquire(ctx, "key", time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Members do not release the locks of other members.
+	require.NoError(t, lock2.Release(ctx, "key"))
+	require.Contains(t, client.values, "alerting:lock:key")
+
+	require.NoError(t, lock1.Release(ctx, "key"))
+	require.NotContains(t, client.values, "alerting:lock:key")
+	ok, err = lock2.TryAcquire(ctx, "key", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// A lock that expired and was acquired by another member is not released by the member that held it before.
+	client.now = client.now.Add(time.Minute)
+	ok, err = lock1.TryAcquire(ctx, "key", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, lock2.Release(ctx, "key"))
+	require.Contains(t, client.values, "alerting:lock:key")
+	require.Equal(t, 1, client.deletes)
+
+	client.err = errors.New("unavailable")
+	_, err = lock2.TryAcquire(ctx, "other", time.Minute)
+	require.EqualError(t, err, "unavailable")
+	require.EqualError(t, lock1.Release(ctx, "key"), "unavailable")
+}
+
+func TestEtcdLock(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeEtcdClient()
+	// Two members of a cluster, whose locks are in the same etcd.
+	lock1 := NewEtcdLock(client, "alerting/lock/")
+	lock2 := NewEtcdLock(client, "alerting/lock/")
+	lock1.held.now = client.clock
+	lock2.held.now = client.clock
+
+	ok, err := lock1.TryAcquire(ctx, "key", 1500*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, ok)
+	lease := client.keys["alerting/lock/key"]
+	require.EqualValues(t, 2, client.leases[lease].ttl)
+
+	// The lease of a lock that is not acquired is revoked.
+	ok, err = lock2.TryAcquire(ctx, "key", time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Len(t, client.leases, 1)
+
+	// Members do not release the locks of other members.
+	require.NoError(t, lock2.Release(ctx, "key"))
+	require.Contains(t, client.keys, "alerting/lock/key")
+
+	require.NoError(t, lock1.Release(ctx, "key"))
+	require.NotContains(t, client.keys, "alerting/lock/key")
+	require.Empty(t, client.leases)
+	ok, err = lock2.TryAcquire(ctx, "key", time.Second)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// A lock that expired and was acquired by another member is not released by the member that held it before.
+	client.now = client.now.Add(time.Second)
+	ok, err = lock1.TryAcquire(ctx, "key", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, lock2.Release(ctx, "key"))
+	require.Contains(t, client.keys, "alerting/lock/key")
+
+	client.err = errors.New("unavailable")
+	_, err = lock2.TryAcquire(ctx, "other", time.Minute)
+	require.EqualError(t, err, "unavailable")
+	require.EqualError(t, lock1.Release(ctx, "key"), "unavailable")
+}
+
+type fakeRedisValue struct {
+	value     []byte
+	ttl       time.Duration
+	expiresAt time.Time
+}
+
+// fakeRedisClient is a RedisClient that stores the keys in a map, and expires them with its clock.
+type fakeRedisClient struct {
+	mtx     sync.Mutex
+	now     time.Time
+	values  map[string]fakeRedisValue
+	deletes int
+	err     error
+}
+
+func newFakeRedisClient() *fakeRedisClient {
+	return &fakeRedisClient{now: time.Now(), values: map[string]fakeRedisValue{}}
+}
+
+func (c *fakeRedisClient) clock() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+func (c *fakeRedisClient) expire() {
+	for k, v := range c.values {
+		if !c.now.Before(v.expiresAt) {
+			delete(c.values, k)
+		}
+	}
+}
+
+func (c *fakeRedisClient) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.err != nil {
+		return false, c.err
+	}
+	c.expire()
+	if _, ok := c.values[key]; ok {
+		return false, nil
+	}
+	c.values[key] = fakeRedisValue{value: value, ttl: ttl, expiresAt: c.now.Add(ttl)}
+	return true, nil
+}
+
+func (c *fakeRedisClient) CompareAndDelete(_ context.Context, key string, value []byte) (bool, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.err != nil {
+		return false, c.err
+	}
+	c.expire()
+	if v, ok := c.values[key]; !ok || !bytes.Equal(v.value, value) {
+		return false, nil
+	}
+	delete(c.values, key)
+	c.deletes++
+	return true, nil
+}
+
+type fakeEtcdLease struct {
+	ttl       int64
+	expiresAt time.Time
+}
+
+// fakeEtcdClient is an EtcdClient that stores the keys and leases in maps, and expires the leases with its clock.
+type fakeEtcdClient struct {
+	mtx    sync.Mutex
+	now    time.Time
+	nextID int64
+	leases map[int64]fakeEtcdLease
+	keys   map[string]int64
+	err    error
+}
+
+func newFakeEtcdClient() *fakeEtcdClient {
+	return &fakeEtcdClient{now: time.Now(), leases: map[int64]fakeEtcdLease{}, keys: map[string]int64{}}
+}
+
+func (c *fakeEtcdClient) clock() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+func (c *fakeEtcdClient) expire() {
+	for id, l := range c.leases {
+		if !c.now.Before(l.expiresAt) {
+			c.revoke(id)
+		}
+	}
+}
+
+func (c *fakeEtcdClient) revoke(id int64) {
+	delete(c.leases, id)
+	for k, lease := range c.keys {
+		if lease == id {
+			delete(c.keys, k)
+		}
+	}
+}
+
+func (c *fakeEtcdClient) Grant(_ context.Context, ttl int64) (int64, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+	c.nextID++
+	c.leases[c.nextID] = fakeEtcdLease{ttl: ttl, expiresAt: c.now.Add(time.Duration(ttl) * time.Second)}
+	return c.nextID, nil
+}
+
+func (c *fakeEtcdClient) CreateWithLease(_ context.Context, key string, _ []byte, lease int64) (bool, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.err != nil {
+		return false, c.err
+	}
+	c.expire()
+	if _, ok := c.keys[key]; ok {
+		return false, nil
+	}
+	if _, ok := c.leases[lease]; !ok {
+		return false, errors.New("lease not found")
+	}
+	c.keys[key] = lease
+	return true, nil
+}
+
+func (c *fakeEtcdClient) Revoke(_ context.Context, lease int64) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.expire()
+	c.revoke(lease)
+	return nil
+}
@@ -0,0 +1,65 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/grafana/alerting/notify"
+)
+
+// RedisCompareAndDeleteScript is a Lua script that deletes the key KEYS[1] if its value is ARGV[1], which can be run
+// with EVAL to implement the CompareAndDelete method of RedisClient.
+const RedisCompareAndDeleteScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+
+// RedisClient is the client of Redis used by RedisLock, provided by the embedder with its own Redis library, such
+// as an adapter of the SET and EVAL commands of github.com/redis/go-redis.
+type RedisClient interface {
+	// SetNX sets the value of the key if it does not exist, with the TTL as its expiry, like SET key value NX PX ttl.
+	// It returns whether the key was set.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// CompareAndDelete deletes the key if its value is the value, such as with RedisCompareAndDeleteScript.
+	// It returns whether the key was deleted.
+	CompareAndDelete(ctx context.Context, key string, value []byte) (bool, error)
+}
+
+// RedisLock is a notify.NotificationLock whose locks are keys of Redis set with SET NX PX. The value of each key is a
+// random token of the member that set it, so that members only release their own locks.
+type RedisLock struct {
+	client RedisClient
+	prefix string
+	held   *held
+}
+
+var _ notify.NotificationLock = (*RedisLock)(nil)
+
+// NewRedisLock returns a RedisLock whose keys have the prefix, such as "alerting:lock:".
+func NewRedisLock(client RedisClient, prefix string) *RedisLock {
+	return &RedisLock{client: client, prefix: prefix, held: newHeld()}
+}
+
+// TryAcquire sets the key of the lock if it does not exist, with the TTL as its expiry.
+func (r *RedisLock) TryAcquire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return false, err
+	}
+	token = []byte(hex.EncodeToString(token))
+	ok, err := r.client.SetNX(ctx, r.prefix+key, token, ttl)
+	if err != nil || !ok {
+		return false, err
+	}
+	r.held.add(key, heldLock{token: token}, ttl)
+	return true, nil
+}
+
+// Release deletes the key of the lock if it is still held by this member.
+func (r *RedisLock) Release(ctx context.Context, key string) error {
+	l, ok := r.held.remove(key)
+	if !ok {
+		return nil
+	}
+	_, err := r.client.CompareAndDelete(ctx, r.prefix+key, l.token)
+	return err
+}
@@ -0,0 +1,563 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// DefaultIdleCheckInterval is how often the Alertmanagers of a MultitenantManager are checked for idleness by default.
+const DefaultIdleCheckInterval = time.Minute
+
+// ErrManagerStopped is returned by the MultitenantManager once it is stopped.
+var ErrManagerStopped = errors.New("multitenant manager is stopped")
+
+// MultitenantManagerOptions configure a MultitenantManager.
+type MultitenantManagerOptions struct {
+	// AlertmanagerConfig returns the configuration of the Alertmanager of the tenant, such as the maintenance options
+	// that load and store the silences and the notification log of the tenant. It is required.
+	AlertmanagerConfig func(tenantID int64) (*GrafanaAlertmanagerConfig, error)
+	// TenantKey is the key of the tenant in the logs of the Alertmanagers. It defaults to "org".
+	TenantKey string
+	// Peer is the cluster peer of the Alertmanagers. By default, the Alertmanagers are not clustered.
+	Peer ClusterPeer
+	// IdleTimeout is the time after which the Alertmanager of a tenant is stopped if it is not used and has no alert
+	// groups. Its configuration is kept, and applied again when the tenant is used. Zero disables idle shutdown.
+	IdleTimeout time.Duration
+	// IdleCheckInterval is how often idle Alertmanagers are checked. It defaults to DefaultIdleCheckInterval.
+	IdleCheckInterval time.Duration
+}
+
+func (o *MultitenantManagerOptions) Validate() error {
+	if o.AlertmanagerConfig == nil {
+		return errors.New("alertmanager config function must be present")
+	}
+	if o.IdleTimeout < 0 {
+		return errors.New("idle timeout must not be negative")
+	}
+	if o.IdleCheckInterval < 0 {
+		return errors.New("idle check interval must not be negative")
+	}
+	return nil
+}
+
+// MultitenantManager owns the Alertmanagers of many tenants. The Alertmanager of a tenant is created when the tenant
+// is first used, with the configuration last applied to the tenant, and stopped once it is idle. The metrics of the
+// Alertmanagers are registered in a registry per tenant, and summed in the registerer of the manager, where the
+// metrics with an "org" label keep the value of each tenant.
+type MultitenantManager struct {
+	opts    MultitenantManagerOptions
+	logger  log.Logger
+	now     func() time.Time
+	metrics *multitenantMetrics
+
+	collector *tenantMetricsCollector
+
+	// mtx guards the tenants, their configurations and their locks. It is only held to read and update them, and not
+	// while an Alertmanager is created, configured or stopped.
+	mtx     sync.Mutex
+	stopped bool
+	tenants map[int64]*tenantAlertmanager
+	configs map[int64]Configuration
+	// locks are the locks of the tenants in use. The lock of a tenant is held while its Alertmanager is created,
+	// configured or stopped, so that a tenant has a single Alertmanager at a time and the other tenants are not blocked.
+	locks map[int64]*tenantLock
+	// creations are the Alertmanagers being created, which are waited for once the manager is stopped.
+	creations sync.WaitGroup
+}
+
+// tenantLock is the lock of a tenant. It is removed from the manager once it is no longer referenced.
+type tenantLock struct {
+	sync.Mutex
+	// refs is the number of callers holding or waiting for the lock, with the mutex of the manager.
+	refs int
+}
+
+// tenantAlertmanager is the running Alertmanager of a tenant.
+type tenantAlertmanager struct {
+	am       *GrafanaAlertmanager
+	registry *prometheus.Registry
+	cancel   context.CancelFunc
+	done     chan struct{}
+	// lastUsed is when the tenant was last used, with the mutex of the manager.
+	lastUsed time.Time
+}
+
+type multitenantMetrics struct {
+	tenants       prometheus.Gauge
+	creations     prometheus.Counter
+	idleShutdowns prometheus.Counter
+}
+
+// NewMultitenantManager returns a manager of the Alertmanagers of the tenants. Its own metrics and the sum of the
+// metrics of the Alertmanagers are registered with r. Run must be called to stop idle Alertmanagers, and to stop all
+// of them once it returns.
+func NewMultitenantManager(opts MultitenantManagerOptions, r prometheus.Registerer, logger log.Logger) (*MultitenantManager, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.TenantKey == "" {
+		opts.TenantKey = "org"
+	}
+	if opts.Peer == nil {
+		opts.Peer = &NilPeer{}
+	}
+	if opts.IdleCheckInterval == 0 {
+		opts.IdleCheckInterval = DefaultIdleCheckInterval
+	}
+	m := &MultitenantManager{
+		opts:    opts,
+		logger:  log.With(logger, "component", "multitenant-alertmanager"),
+		now:     time.Now,
+		tenants: make(map[int64]*tenantAlertmanager),
+		configs: make(map[int64]Configuration),
+		locks:   make(map[int64]*tenantLock),
+		metrics: &multitenantMetrics{
+			tenants: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "alertmanager_tenants",
+				Help:      "Number of tenants with a running Alertmanager.",
+			}),
+			creations: promauto.With(r).NewCounter(prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "alertmanager_tenant_creations_total",
+				Help:      "Number of Alertmanagers of tenants that were created.",
+			}),
+			idleShutdowns: promauto.With(r).NewCounter(prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "alertmanager_tenant_idle_shutdowns_total",
+				Help:      "Number of Alertmanagers of tenants that were stopped because they were idle.",
+			}),
+		},
+	}
+	m.collector = newTenantMetricsCollector(m.logger)
+	if r != nil {
+		if err := r.Register(m.collector); err != nil {
+			return nil, fmt.Errorf("failed to register the metrics of the tenants: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// Alertmanager returns the Alertmanager of the tenant, which is created if it is not running. An Alertmanager that
+// is created is configured with the configuration last applied to the tenant, if any.
+func (m *MultitenantManager) Alertmanager(tenantID int64) (*GrafanaAlertmanager, error) {
+	l := m.lockTenant(tenantID)
+	defer m.unlockTenant(tenantID, l)
+	t, err := m.tenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return t.am, nil
+}
+
+// ApplyConfig applies the configuration to the Alertmanager of the tenant, which is created if it is not running.
+// The configuration is kept, and applied again if the Alertmanager is created again after it was stopped.
+func (m *MultitenantManager) ApplyConfig(tenantID int64, cfg Configuration) error {
+	l := m.lockTenant(tenantID)
+	defer m.unlockTenant(tenantID, l)
+	t, err := m.tenant(tenantID)
+	if err != nil {
+		return err
+	}
+	t.am.WithLock(func() {
+		err = t.am.ApplyConfig(cfg)
+	})
+	if err != nil {
+		return err
+	}
+	m.mtx.Lock()
+	m.configs[tenantID] = cfg
+	m.mtx.Unlock()
+	return nil
+}
+
+// PutAlerts puts the alerts in the Alertmanager of the tenant, which is created if it is not running.
+func (m *MultitenantManager) PutAlerts(tenantID int64, alerts amv2.PostableAlerts) error {
+	am, err := m.Alertmanager(tenantID)
+	if err != nil {
+		return err
+	}
+	return am.PutAlerts(alerts)
+}
+
+// RemoveTenant stops the Alertmanager of the tenant, if it is running, and forgets its configuration.
+func (m *MultitenantManager) RemoveTenant(tenantID int64) {
+	l := m.lockTenant(tenantID)
+	defer m.unlockTenant(tenantID, l)
+	m.mtx.Lock()
+	t := m.tenants[tenantID]
+	delete(m.tenants, tenantID)
+	delete(m.configs, tenantID)
+	m.metrics.tenants.Set(float64(len(m.tenants)))
+	m.mtx.Unlock()
+	if t != nil {
+		m.stopTenant(t)
+	}
+}
+
+// Tenants returns the tenants with a running Alertmanager, in ascending order.
+func (m *MultitenantManager) Tenants() []int64 {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	res := make([]int64, 0, len(m.tenants))
+	for id := range m.tenants {
+		res = append(res, id)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+// Run stops the idle Alertmanagers until the context is canceled, and then stops all of the Alertmanagers. The
+// manager cannot be used once Run returns.
+func (m *MultitenantManager) Run(ctx context.Context) error {
+	var tick <-chan time.Time
+	if m.opts.IdleTimeout > 0 {
+		ticker := time.NewTicker(m.opts.IdleCheckInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-tick:
+			m.stopIdle()
+		case <-ctx.Done():
+			m.mtx.Lock()
+			m.stopped = true
+			tenants := m.tenants
+			m.tenants = make(map[int64]*tenantAlertmanager)
+			m.metrics.tenants.Set(0)
+			m.mtx.Unlock()
+
+			var wg sync.WaitGroup
+			for _, t := range tenants {
+				wg.Add(1)
+				go func(t *tenantAlertmanager) {
+					defer wg.Done()
+					m.stopTenant(t)
+				}(t)
+			}
+			wg.Wait()
+			m.creations.Wait()
+			return nil
+		}
+	}
+}
+
+// lockTenant acquires the lock of the tenant.
+func (m *MultitenantManager) lockTenant(tenantID int64) *tenantLock {
+	m.mtx.Lock()
+	l, ok := m.locks[tenantID]
+	if !ok {
+		l = &tenantLock{}
+		m.locks[tenantID] = l
+	}
+	l.refs++
+	m.mtx.Unlock()
+	l.Lock()
+	return l
+}
+
+// unlockTenant releases the lock of the tenant, and removes it once it is no longer referenced.
+func (m *MultitenantManager) unlockTenant(tenantID int64, l *tenantLock) {
+	l.Unlock()
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	l.refs--
+	if l.refs == 0 {
+		delete(m.locks, tenantID)
+	}
+}
+
+// tenant returns the running Alertmanager of the tenant, which is created if it is not running. It must be called
+// with the lock of the tenant, and without the mutex.
+func (m *MultitenantManager) tenant(tenantID int64) (*tenantAlertmanager, error) {
+	m.mtx.Lock()
+	if m.stopped {
+		m.mtx.Unlock()
+		return nil, ErrManagerStopped
+	}
+	if t, ok := m.tenants[tenantID]; ok {
+		t.lastUsed = m.now()
+		m.mtx.Unlock()
+		return t, nil
+	}
+	c, hasConfig := m.configs[tenantID]
+	m.creations.Add(1)
+	m.mtx.Unlock()
+	defer m.creations.Done()
+
+	cfg, err := m.opts.AlertmanagerConfig(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the configuration of the Alertmanager of tenant %d: %w", tenantID, err)
+	}
+	reg := prometheus.NewRegistry()
+	am, err := NewGrafanaAlertmanager(m.opts.TenantKey, tenantID, cfg, m.opts.Peer, m.logger, NewGrafanaAlertmanagerMetrics(reg, m.logger))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the Alertmanager of tenant %d: %w", tenantID, err)
+	}
+	if hasConfig {
+		am.WithLock(func() {
+			err = am.ApplyConfig(c)
+		})
+		if err != nil {
+			am.StopAndWait()
+			return nil, fmt.Errorf("failed to apply the configuration of tenant %d: %w", tenantID, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &tenantAlertmanager{am: am, registry: reg, cancel: cancel, done: make(chan struct{}), lastUsed: m.now()}
+	go func() {
+		defer close(t.done)
+		if err := am.Run(ctx); err != nil {
+			level.Error(m.logger).Log("msg", "Alertmanager of tenant stopped with an error", m.opts.TenantKey, tenantID, "err", err)
+		}
+	}()
+	m.collector.addRegistry(reg)
+
+	m.mtx.Lock()
+	if m.stopped {
+		m.mtx.Unlock()
+		m.stopTenant(t)
+		return nil, ErrManagerStopped
+	}
+	m.tenants[tenantID] = t
+	m.metrics.creations.Inc()
+	m.metrics.tenants.Set(float64(len(m.tenants)))
+	m.mtx.Unlock()
+	level.Debug(m.logger).Log("msg", "Created the Alertmanager of tenant", m.opts.TenantKey, tenantID)
+	return t, nil
+}
+
+// stopIdle stops the Alertmanagers that were not used for the idle timeout and have no alert groups.
+func (m *MultitenantManager) stopIdle() {
+	for _, id := range m.Tenants() {
+		m.stopIfIdle(id)
+	}
+}
+
+// stopIfIdle stops the Alertmanager of the tenant if it was not used for the idle timeout and has no alert groups.
+func (m *MultitenantManager) stopIfIdle(tenantID int64) {
+	l := m.lockTenant(tenantID)
+	defer m.unlockTenant(tenantID, l)
+	m.mtx.Lock()
+	t, ok := m.tenants[tenantID]
+	if !ok || m.now().Sub(t.lastUsed) < m.opts.IdleTimeout || t.am.pendingGroups() > 0 {
+		m.mtx.Unlock()
+		return
+	}
+	delete(m.tenants, tenantID)
+	m.metrics.tenants.Set(float64(len(m.tenants)))
+	m.mtx.Unlock()
+
+	level.Debug(m.logger).Log("msg", "Stopping the idle Alertmanager of tenant", m.opts.TenantKey, tenantID)
+	m.stopTenant(t)
+	m.metrics.idleShutdowns.Inc()
+}
+
+// stopTenant stops the Alertmanager of a tenant, and keeps the final values of its metrics in the sums of the
+// metrics of the tenants.
+func (m *MultitenantManager) stopTenant(t *tenantAlertmanager) {
+	t.stop()
+	m.collector.removeRegistry(t.registry)
+}
+
+// stop stops the Alertmanager and waits until it is stopped.
+func (t *tenantAlertmanager) stop() {
+	t.cancel()
+	<-t.done
+}
+
+// tenantMetricsCollector collects the sum of the metrics of the registries of the tenants. The samples of a metric
+// with the same labels are summed. The quantiles of summaries cannot be summed, and are dropped.
+//
+// A registry is soft-removed once the Alertmanager of its tenant is stopped: the final values of its counters,
+// histograms and summaries are kept in the sums, so that they do not go backwards, while its gauges are dropped.
+type tenantMetricsCollector struct {
+	logger log.Logger
+
+	mtx        sync.Mutex
+	registries map[*prometheus.Registry]struct{}
+	// removed are the sums of the final values of the removed registries.
+	removed map[string]*summedFamily
+}
+
+func newTenantMetricsCollector(logger log.Logger) *tenantMetricsCollector {
+	return &tenantMetricsCollector{
+		logger:     logger,
+		registries: make(map[*prometheus.Registry]struct{}),
+		removed:    make(map[string]*summedFamily),
+	}
+}
+
+// addRegistry adds the registry of a tenant to the sums.
+func (c *tenantMetricsCollector) addRegistry(r *prometheus.Registry) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.registries[r] = struct{}{}
+}
+
+// removeRegistry soft-removes the registry of a tenant from the sums.
+func (c *tenantMetricsCollector) removeRegistry(r *prometheus.Registry) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if _, ok := c.registries[r]; !ok {
+		return
+	}
+	delete(c.registries, r)
+	mfs, err := r.Gather()
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "Failed to gather the final metrics of a tenant", "err", err)
+	}
+	for _, mf := range mfs {
+		if mf.GetType() == dto.MetricType_GAUGE || mf.GetType() == dto.MetricType_UNTYPED {
+			continue
+		}
+		addFamily(c.removed, mf)
+	}
+}
+
+// Describe implements prometheus.Collector. It describes no metrics, as the metrics of the tenants are only known
+// once they are collected.
+func (c *tenantMetricsCollector) Describe(chan<- *prometheus.Desc) {}
+
+type summedFamily struct {
+	help   string
+	typ    dto.MetricType
+	series map[string]*summedSeries
+}
+
+type summedSeries struct {
+	labels  prometheus.Labels
+	value   float64
+	count   uint64
+	sum     float64
+	buckets map[float64]uint64
+}
+
+func (c *tenantMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	// The mutex is held while the registries are gathered, so that a registry that is removed meanwhile is not
+	// summed twice.
+	c.mtx.Lock()
+	families := make(map[string]*summedFamily, len(c.removed))
+	for name, f := range c.removed {
+		families[name] = f.clone()
+	}
+	for r := range c.registries {
+		mfs, err := r.Gather()
+		if err != nil {
+			level.Warn(c.logger).Log("msg", "Failed to gather the metrics of a tenant", "err", err)
+		}
+		for _, mf := range mfs {
+			addFamily(families, mf)
+		}
+	}
+	c.mtx.Unlock()
+
+	for name, f := range families {
+		for _, s := range f.series {
+			desc := prometheus.NewDesc(name, f.help, nil, s.labels)
+			var (
+				metric prometheus.Metric
+				err    error
+			)
+			switch f.typ {
+			case dto.MetricType_COUNTER:
+				metric, err = prometheus.NewConstMetric(desc, prometheus.CounterValue, s.value)
+			case dto.MetricType_GAUGE:
+				metric, err = prometheus.NewConstMetric(desc, prometheus.GaugeValue, s.value)
+			case dto.MetricType_HISTOGRAM:
+				metric, err = prometheus.NewConstHistogram(desc, s.count, s.sum, s.buckets)
+			case dto.MetricType_SUMMARY:
+				metric, err = prometheus.NewConstSummary(desc, s.count, s.sum, nil)
+			default:
+				metric, err = prometheus.NewConstMetric(desc, prometheus.UntypedValue, s.value)
+			}
+			if err != nil {
+				level.Warn(c.logger).Log("msg", "Failed to sum the metric of the tenants", "metric", name, "err", err)
+				continue
+			}
+			ch <- metric
+		}
+	}
+}
+
+// addFamily adds the samples of the metric family to the family with the same name. The samples are dropped if the
+// family has another type.
+func addFamily(families map[string]*summedFamily, mf *dto.MetricFamily) {
+	f, ok := families[mf.GetName()]
+	if !ok {
+		f = &summedFamily{help: mf.GetHelp(), typ: mf.GetType(), series: make(map[string]*summedSeries)}
+		families[mf.GetName()] = f
+	}
+	if f.typ != mf.GetType() {
+		return
+	}
+	for _, metric := range mf.GetMetric() {
+		addSample(f, metric)
+	}
+}
+
+// clone returns a copy of the family, whose samples can be added to without changing f.
+func (f *summedFamily) clone() *summedFamily {
+	res := &summedFamily{help: f.help, typ: f.typ, series: make(map[string]*summedSeries, len(f.series))}
+	for key, s := range f.series {
+		c := *s
+		c.buckets = make(map[float64]uint64, len(s.buckets))
+		for b, v := range s.buckets {
+			c.buckets[b] = v
+		}
+		res.series[key] = &c
+	}
+	return res
+}
+
+// addSample adds the sample of the metric to the series of the family with the same labels.
+func addSample(f *summedFamily, metric *dto.Metric) {
+	labels := make(prometheus.Labels, len(metric.GetLabel()))
+	pairs := make([]string, 0, len(metric.GetLabel()))
+	for _, lp := range metric.GetLabel() {
+		labels[lp.GetName()] = lp.GetValue()
+		pairs = append(pairs, lp.GetName()+"="+lp.GetValue())
+	}
+	// The labels of the samples of the registry are sorted by name.
+	key := strings.Join(pairs, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &summedSeries{labels: labels, buckets: make(map[float64]uint64)}
+		f.series[key] = s
+	}
+	switch f.typ {
+	case dto.MetricType_COUNTER:
+		s.value += metric.GetCounter().GetValue()
+	case dto.MetricType_GAUGE:
+		s.value += metric.GetGauge().GetValue()
+	case dto.MetricType_HISTOGRAM:
+		h := metric.GetHistogram()
+		s.count += h.GetSampleCount()
+		s.sum += h.GetSampleSum()
+		for _, b := range h.GetBucket() {
+			s.buckets[b.GetUpperBound()] += b.GetCumulativeCount()
+		}
+	case dto.MetricType_SUMMARY:
+		s.count += metric.GetSummary().GetSampleCount()
+		s.sum += metric.GetSummary().GetSampleSum()
+	default:
+		s.value += metric.GetUntyped().GetValue()
+	}
+}
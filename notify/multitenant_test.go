@@ -0,0 +1,211 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+)
+
+func TestMultitenantManager(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	m, err := NewMultitenantManager(MultitenantManagerOptions{
+		AlertmanagerConfig: func(int64) (*GrafanaAlertmanagerConfig, error) {
+			return &GrafanaAlertmanagerConfig{Silences: newFakeMaintanenceOptions(t), Nflog: newFakeMaintanenceOptions(t)}, nil
+		},
+		IdleTimeout: time.Minute,
+		// The idle Alertmanagers are stopped by the test.
+		IdleCheckInterval: time.Hour,
+	}, reg, log.NewNopLogger())
+	require.NoError(t, err)
+
+	var (
+		nowMtx sync.Mutex
+		now    = time.Now()
+	)
+	m.now = func() time.Time {
+		nowMtx.Lock()
+		defer nowMtx.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		nowMtx.Lock()
+		defer nowMtx.Unlock()
+		now = now.Add(d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, m.Run(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Empty(t, m.Tenants())
+
+	cfg := &previewConfig{
+		grafanaRoutingTreeConfig: grafanaRoutingTreeConfig{route: &definition.Route{Receiver: "default"}},
+		receivers:                []string{"default"},
+	}
+	require.NoError(t, m.ApplyConfig(1, cfg))
+	require.NoError(t, m.PutAlerts(2, amv2.PostableAlerts{
+		{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "a"}}},
+		{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "b"}}},
+	}))
+	require.Equal(t, []int64{1, 2}, m.Tenants())
+
+	t.Run("metrics are summed", func(t *testing.T) {
+		require.NoError(t, m.PutAlerts(1, amv2.PostableAlerts{{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "a"}}}}))
+		require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP alertmanager_alerts_received_total The total number of received alerts.
+# TYPE alertmanager_alerts_received_total counter
+alertmanager_alerts_received_total{status="firing",version="v2"} 3
+alertmanager_alerts_received_total{status="resolved",version="v2"} 0
+# HELP grafana_alerting_alertmanager_tenants Number of tenants with a running Alertmanager.
+# TYPE grafana_alerting_alertmanager_tenants gauge
+grafana_alerting_alertmanager_tenants 2
+# HELP grafana_alerting_alertmanager_receivers Number of configured receivers by state. It is considered active if used within a route.
+# TYPE grafana_alerting_alertmanager_receivers gauge
+grafana_alerting_alertmanager_receivers{org="1",state="active"} 1
+grafana_alerting_alertmanager_receivers{org="1",state="inactive"} 0
+`), "alertmanager_alerts_received_total", "grafana_alerting_alertmanager_tenants", "grafana_alerting_alertmanager_receivers"))
+	})
+
+	t.Run("idle Alertmanagers are stopped", func(t *testing.T) {
+		am, err := m.Alertmanager(1)
+		require.NoError(t, err)
+		// The alert group of tenant 1 keeps its Alertmanager running.
+		require.Eventually(t, func() bool { return am.pendingGroups() == 1 }, time.Second, 10*time.Millisecond)
+
+		m.stopIdle()
+		require.Equal(t, []int64{1, 2}, m.Tenants(), "the Alertmanagers were used within the idle timeout")
+
+		advance(time.Minute)
+		m.stopIdle()
+		require.Equal(t, []int64{1}, m.Tenants())
+		require.Equal(t, 1.0, testutil.ToFloat64(m.metrics.idleShutdowns))
+
+		// The counters of the stopped Alertmanager keep their final values.
+		expected := `
+# HELP alertmanager_alerts_received_total The total number of received alerts.
+# TYPE alertmanager_alerts_received_total counter
+alertmanager_alerts_received_total{status="firing",version="v2"} 3
+alertmanager_alerts_received_total{status="resolved",version="v2"} 0
+`
+		require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "alertmanager_alerts_received_total"))
+
+		// The Alertmanager is created again, without alerts.
+		am, err = m.Alertmanager(2)
+		require.NoError(t, err)
+		require.Empty(t, am.GetReceivers())
+		require.Equal(t, 3.0, testutil.ToFloat64(m.metrics.creations))
+		require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "alertmanager_alerts_received_total"))
+	})
+
+	t.Run("configurations are applied again", func(t *testing.T) {
+		m.RemoveTenant(2)
+		require.Equal(t, []int64{1}, m.Tenants())
+
+		first, err := m.Alertmanager(1)
+		require.NoError(t, err)
+		m.mtx.Lock()
+		stopped := m.tenants[1]
+		delete(m.tenants, 1)
+		m.mtx.Unlock()
+		m.stopTenant(stopped)
+
+		am, err := m.Alertmanager(1)
+		require.NoError(t, err)
+		require.NotSame(t, first, am)
+		require.Len(t, am.GetReceivers(), 1)
+		require.Equal(t, "default", am.GetReceivers()[0].Name)
+	})
+
+	t.Run("the manager cannot be used once stopped", func(t *testing.T) {
+		cancel()
+		<-done
+		require.Empty(t, m.Tenants())
+		_, err := m.Alertmanager(1)
+		require.ErrorIs(t, err, ErrManagerStopped)
+	})
+}
+
+func TestMultitenantManager_CreatesTenantsConcurrently(t *testing.T) {
+	var (
+		block   = make(chan struct{})
+		created = make(chan int64, 10)
+	)
+	m, err := NewMultitenantManager(MultitenantManagerOptions{
+		AlertmanagerConfig: func(tenantID int64) (*GrafanaAlertmanagerConfig, error) {
+			created <- tenantID
+			if tenantID == 1 {
+				<-block
+			}
+			return &GrafanaAlertmanagerConfig{Silences: newFakeMaintanenceOptions(t), Nflog: newFakeMaintanenceOptions(t)}, nil
+		},
+	}, prometheus.NewRegistry(), log.NewNopLogger())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, m.Run(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	var (
+		wg   sync.WaitGroup
+		ams  [2]*GrafanaAlertmanager
+		errs [2]error
+	)
+	for i := range ams {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ams[i], errs[i] = m.Alertmanager(1)
+		}(i)
+	}
+	require.Equal(t, int64(1), <-created)
+
+	// The other tenants are not blocked while the Alertmanager of tenant 1 is created.
+	require.Empty(t, m.Tenants())
+	_, err = m.Alertmanager(2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), <-created)
+	require.Equal(t, []int64{2}, m.Tenants())
+
+	close(block)
+	wg.Wait()
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	require.Same(t, ams[0], ams[1], "the Alertmanager of a tenant is created once")
+	require.Empty(t, created)
+	require.Equal(t, []int64{1, 2}, m.Tenants())
+}
+
+func TestMultitenantManagerOptions_Validate(t *testing.T) {
+	_, err := NewMultitenantManager(MultitenantManagerOptions{}, nil, log.NewNopLogger())
+	require.EqualError(t, err, "alertmanager config function must be present")
+	_, err = NewMultitenantManager(MultitenantManagerOptions{
+		AlertmanagerConfig: func(int64) (*GrafanaAlertmanagerConfig, error) { return nil, nil },
+		IdleTimeout:        -time.Second,
+	}, nil, log.NewNopLogger())
+	require.EqualError(t, err, "idle timeout must not be negative")
+}
@@ -15,18 +15,53 @@ import (
 type Integration struct {
 	status      *statusCaptureNotifier
 	integration *notify.Integration
+	uid         string
+}
+
+// IntegrationOption configures optional behavior of an Integration.
+type IntegrationOption func(*integrationOptions)
+
+type integrationOptions struct {
+	retryPolicy *RetryPolicy
+	uid         string
+}
+
+// WithRetryPolicy makes the integration retry failed notifications according to the policy
+// instead of the default retry behavior of the notification pipeline.
+func WithRetryPolicy(policy *RetryPolicy) IntegrationOption {
+	return func(o *integrationOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithUID sets the UID of the configuration the integration was created from.
+func WithUID(uid string) IntegrationOption {
+	return func(o *integrationOptions) {
+		o.uid = uid
+	}
 }
 
 // NewIntegration returns a new integration.
-func NewIntegration(notifier notify.Notifier, rs notify.ResolvedSender, name string, idx int, receiverName string) *Integration {
+func NewIntegration(notifier notify.Notifier, rs notify.ResolvedSender, name string, idx int, receiverName string, opts ...IntegrationOption) *Integration {
+	var o integrationOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Wrap the provided Notifier with our own, which will capture notification attempt errors.
 	status := &statusCaptureNotifier{upstream: notifier}
 
-	integration := notify.NewIntegration(status, rs, name, idx, receiverName)
+	var n notify.Notifier = status
+	if o.retryPolicy != nil {
+		n = &retryNotifier{upstream: status, policy: *o.retryPolicy}
+	}
+
+	integration := notify.NewIntegration(n, rs, name, idx, receiverName)
 
 	return &Integration{
 		status:      status,
 		integration: integration,
+		uid:         o.uid,
 	}
 }
 
@@ -52,6 +87,11 @@ func (i *Integration) Name() string {
 	return i.integration.Name()
 }
 
+// UID returns the UID of the configuration the integration was created from, if it is known.
+func (i *Integration) UID() string {
+	return i.uid
+}
+
 // Index returns the index of the integration.
 func (i *Integration) Index() int {
 	return i.integration.Index()
@@ -67,6 +107,11 @@ func (i *Integration) GetReport() (time.Time, model.Duration, error) {
 	return i.status.GetReport()
 }
 
+// Status returns the status of the notification attempts of the integration.
+func (i *Integration) Status() Status {
+	return i.status.Status()
+}
+
 // GetIntegrations is a convenience function to unwrap all the notify.GetIntegrations
 // from a slice of nfstatus.Integration.
 func GetIntegrations(integrations []*Integration) []*notify.Integration {
@@ -77,6 +122,15 @@ func GetIntegrations(integrations []*Integration) []*notify.Integration {
 	return result
 }
 
+// Status is the status of the notification attempts of an integration. Times are zero if there was no such attempt.
+type Status struct {
+	LastNotifyAttempt         time.Time
+	LastNotifyAttemptDuration model.Duration
+	LastNotifyAttemptError    error
+	// LastSuccessfulNotify is the time of the last notification attempt that succeeded.
+	LastSuccessfulNotify time.Time
+}
+
 // statusCaptureNotifier is used to wrap a notify.Notifer and capture information about attempts.
 type statusCaptureNotifier struct {
 	upstream notify.Notifier
@@ -85,6 +139,7 @@ type statusCaptureNotifier struct {
 	lastNotifyAttempt         time.Time
 	lastNotifyAttemptDuration model.Duration
 	lastNotifyAttemptError    error
+	lastSuccessfulNotify      time.Time
 }
 
 // Notify implements the Notifier interface.
@@ -99,6 +154,9 @@ func (n *statusCaptureNotifier) Notify(ctx context.Context, alerts ...*types.Ale
 	n.lastNotifyAttempt = start
 	n.lastNotifyAttemptDuration = model.Duration(duration)
 	n.lastNotifyAttemptError = err
+	if err == nil {
+		n.lastSuccessfulNotify = start
+	}
 
 	return retry, err
 }
@@ -110,3 +168,16 @@ func (n *statusCaptureNotifier) GetReport() (time.Time, model.Duration, error) {
 
 	return n.lastNotifyAttempt, n.lastNotifyAttemptDuration, n.lastNotifyAttemptError
 }
+
+// Status returns the status of the notification attempts.
+func (n *statusCaptureNotifier) Status() Status {
+	n.mtx.RLock()
+	defer n.mtx.RUnlock()
+
+	return Status{
+		LastNotifyAttempt:         n.lastNotifyAttempt,
+		LastNotifyAttemptDuration: n.lastNotifyAttemptDuration,
+		LastNotifyAttemptError:    n.lastNotifyAttemptError,
+		LastSuccessfulNotify:      n.lastSuccessfulNotify,
+	}
+}
@@ -57,6 +57,8 @@ func TestIntegration(t *testing.T) {
 	assert.NotEqual(t, time.Time{}, lastAttempt)
 	assert.NotEqual(t, model.Duration(0), lastDuration)
 	assert.Equal(t, nil, lastError)
+	lastSuccess := integration.Status().LastSuccessfulNotify
+	assert.Equal(t, lastAttempt, lastSuccess)
 
 	// Check retry is propagated correctly.
 	notifier.retry = true
@@ -75,4 +77,11 @@ func TestIntegration(t *testing.T) {
 	assert.NotEqual(t, time.Time{}, lastAttempt)
 	assert.NotEqual(t, model.Duration(0), lastDuration)
 	assert.Equal(t, "An error", lastError.Error())
+
+	// Check the status keeps the last successful notification.
+	status := integration.Status()
+	assert.Equal(t, lastAttempt, status.LastNotifyAttempt)
+	assert.Equal(t, notifier.err, status.LastNotifyAttemptError)
+	assert.True(t, status.LastSuccessfulNotify.After(lastSuccess), "the retried notification succeeded")
+	assert.True(t, status.LastNotifyAttempt.After(status.LastSuccessfulNotify))
 }
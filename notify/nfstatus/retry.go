@@ -0,0 +1,122 @@
+package nfstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	DefaultRetryInitialBackoff = model.Duration(500 * time.Millisecond)
+	DefaultRetryMaxBackoff     = model.Duration(time.Minute)
+)
+
+// RetryPolicy configures how an integration retries a failed notification. Without a policy,
+// integrations use the retry behavior of the notification pipeline, which retries with exponential
+// backoff until the notification times out.
+//
+// Retries are still bounded by the timeout of the notification, which depends on the group interval.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first. Zero means no limit.
+	MaxAttempts int `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty"`
+	// InitialBackoff is the time to wait after the first failed attempt. It doubles after every attempt.
+	InitialBackoff model.Duration `json:"initialBackoff,omitempty" yaml:"initialBackoff,omitempty"`
+	// MaxBackoff is the maximum time to wait between attempts.
+	MaxBackoff model.Duration `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty"`
+	// Jitter randomizes each backoff by up to the given fraction of it, in either direction. It must be between 0 and 1.
+	Jitter float64 `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+}
+
+// Validate checks that the values of the policy are within range.
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 0 {
+		return errors.New("max attempts must not be negative")
+	}
+	if p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		return errors.New("backoff must not be negative")
+	}
+	if p.MaxBackoff > 0 && p.InitialBackoff > p.MaxBackoff {
+		return errors.New("initial backoff must not be greater than max backoff")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return errors.New("jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// Backoff returns the time to wait after the given failed attempt, starting from 1.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	initial, limit := time.Duration(p.InitialBackoff), time.Duration(p.MaxBackoff)
+	if initial == 0 {
+		initial = time.Duration(DefaultRetryInitialBackoff)
+	}
+	if limit == 0 {
+		limit = time.Duration(DefaultRetryMaxBackoff)
+	}
+	if limit < initial {
+		limit = initial
+	}
+
+	d := initial
+	for i := 1; i < attempt && d < limit; i++ {
+		d *= 2
+	}
+	if d > limit {
+		d = limit
+	}
+	if p.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d)) // #nosec G404 -- jitter does not need a secure source.
+	}
+	return d
+}
+
+// retryNotifier retries the notifications of the wrapped notifier according to a RetryPolicy.
+// Once the policy gives up, the error is reported as unrecoverable so that the notification
+// pipeline does not retry it again.
+type retryNotifier struct {
+	upstream notify.Notifier
+	policy   RetryPolicy
+}
+
+// Notify implements the Notifier interface.
+func (n *retryNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		retry, err := n.upstream.Notify(ctx, alerts...)
+		if err == nil || !retry {
+			return retry, err
+		}
+		if n.policy.MaxAttempts > 0 && attempt >= n.policy.MaxAttempts {
+			return false, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		t := ClockFromContext(ctx).Timer(n.policy.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return retry, err
+		case <-t.C:
+		}
+	}
+}
+
+type clockKey struct{}
+
+// WithClock returns a context with the clock that integrations wait on between retries.
+func WithClock(ctx context.Context, c clock.Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+// ClockFromContext returns the clock in the context, or the system clock if there is none.
+func ClockFromContext(ctx context.Context) clock.Clock {
+	if c, ok := ctx.Value(clockKey{}).(clock.Clock); ok && c != nil {
+		return c
+	}
+	return clock.New()
+}
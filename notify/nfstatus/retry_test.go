@@ -0,0 +1,142 @@
+package nfstatus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingNotifier struct {
+	attempts int
+	// failures is the number of attempts that fail before the notifier succeeds.
+	failures int
+	retry    bool
+}
+
+// signalingNotifier always fails, and signals each attempt.
+type signalingNotifier struct {
+	attempts chan struct{}
+}
+
+func (n *signalingNotifier) Notify(_ context.Context, _ ...*types.Alert) (bool, error) {
+	n.attempts <- struct{}{}
+	return true, errors.New("failed")
+}
+
+func (n *countingNotifier) Notify(_ context.Context, _ ...*types.Alert) (bool, error) {
+	n.attempts++
+	if n.attempts <= n.failures {
+		return n.retry, errors.New("failed")
+	}
+	return false, nil
+}
+
+func TestRetryPolicy_Validate(t *testing.T) {
+	assert.NoError(t, RetryPolicy{}.Validate())
+	assert.NoError(t, RetryPolicy{MaxAttempts: 3, InitialBackoff: model.Duration(time.Second), MaxBackoff: model.Duration(time.Minute), Jitter: 0.5}.Validate())
+	assert.Error(t, RetryPolicy{MaxAttempts: -1}.Validate())
+	assert.Error(t, RetryPolicy{InitialBackoff: model.Duration(-time.Second)}.Validate())
+	assert.Error(t, RetryPolicy{InitialBackoff: model.Duration(time.Minute), MaxBackoff: model.Duration(time.Second)}.Validate())
+	assert.Error(t, RetryPolicy{Jitter: 1.5}.Validate())
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: model.Duration(time.Second), MaxBackoff: model.Duration(5 * time.Second)}
+	assert.Equal(t, time.Second, p.Backoff(1))
+	assert.Equal(t, 2*time.Second, p.Backoff(2))
+	assert.Equal(t, 4*time.Second, p.Backoff(3))
+	assert.Equal(t, 5*time.Second, p.Backoff(4))
+	assert.Equal(t, 5*time.Second, p.Backoff(100))
+
+	assert.Equal(t, time.Duration(DefaultRetryInitialBackoff), RetryPolicy{}.Backoff(1))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.Backoff(2)
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.LessOrEqual(t, d, 3*time.Second)
+	}
+}
+
+func TestIntegrationWithRetryPolicy(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: model.Duration(time.Millisecond)}
+
+	t.Run("retries until success", func(t *testing.T) {
+		notifier := &countingNotifier{failures: 2, retry: true}
+		integration := NewIntegration(notifier, &fakeResolvedSender{}, "foo", 0, "bar", WithRetryPolicy(policy))
+		retry, err := integration.Notify(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, retry)
+		assert.Equal(t, 3, notifier.attempts)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		notifier := &countingNotifier{failures: 5, retry: true}
+		integration := NewIntegration(notifier, &fakeResolvedSender{}, "foo", 0, "bar", WithRetryPolicy(policy))
+		retry, err := integration.Notify(context.Background())
+		assert.ErrorContains(t, err, "giving up after 3 attempts")
+		assert.False(t, retry)
+		assert.Equal(t, 3, notifier.attempts)
+
+		_, _, lastErr := integration.GetReport()
+		assert.EqualError(t, lastErr, "failed")
+	})
+
+	t.Run("does not retry unrecoverable errors", func(t *testing.T) {
+		notifier := &countingNotifier{failures: 5, retry: false}
+		integration := NewIntegration(notifier, &fakeResolvedSender{}, "foo", 0, "bar", WithRetryPolicy(policy))
+		_, err := integration.Notify(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, 1, notifier.attempts)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		notifier := &countingNotifier{failures: 5, retry: true}
+		integration := NewIntegration(notifier, &fakeResolvedSender{}, "foo", 0, "bar", WithRetryPolicy(&RetryPolicy{InitialBackoff: model.Duration(time.Hour), MaxBackoff: model.Duration(time.Hour)}))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		retry, err := integration.Notify(ctx)
+		assert.Error(t, err)
+		assert.True(t, retry)
+		assert.Equal(t, 1, notifier.attempts)
+	})
+
+	t.Run("waits on the clock of the context", func(t *testing.T) {
+		notifier := &signalingNotifier{attempts: make(chan struct{}, 5)}
+		integration := NewIntegration(notifier, &fakeResolvedSender{}, "foo", 0, "bar", WithRetryPolicy(&RetryPolicy{MaxAttempts: 2, InitialBackoff: model.Duration(time.Hour)}))
+		clk := clock.NewMock()
+		done := make(chan error, 1)
+		go func() {
+			_, err := integration.Notify(WithClock(context.Background(), clk))
+			done <- err
+		}()
+
+		<-notifier.attempts
+		select {
+		case <-notifier.attempts:
+			t.Fatal("retried before the backoff elapsed on the clock")
+		case <-time.After(10 * time.Millisecond):
+		}
+		// The timer may not exist yet when the clock is first advanced.
+		assert.Eventually(t, func() bool {
+			clk.Add(time.Hour)
+			return len(notifier.attempts) == 1
+		}, time.Second, 10*time.Millisecond)
+		assert.ErrorContains(t, <-done, "giving up after 2 attempts")
+	})
+
+	t.Run("uses the default retry behavior without a policy", func(t *testing.T) {
+		notifier := &countingNotifier{failures: 5, retry: true}
+		integration := NewIntegration(notifier, &fakeResolvedSender{}, "foo", 0, "bar")
+		retry, err := integration.Notify(context.Background())
+		assert.Error(t, err)
+		assert.True(t, retry)
+		assert.Equal(t, 1, notifier.attempts)
+	})
+}
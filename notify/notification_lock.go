@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+)
+
+// DefaultNotificationLockTTL is for how long a notification is locked if no TTL is configured.
+const DefaultNotificationLockTTL = time.Minute
+
+// NotificationLock is a lock shared by the members of a cluster. Before sending a notification, members acquire
+// the lock for the notification so that the same notification is not sent by more than one member, for example
+// when the notification log is not gossiped in time during peer churn. It complements the notification log,
+// which remains the source of truth for deduplication. Package lock provides implementations backed by Redis and
+// etcd, with clients of the stores provided by the embedder.
+type NotificationLock interface {
+	// TryAcquire acquires the lock for the key for the duration of the TTL. It returns false if the lock is held by
+	// another member. Locks of notifications that are sent are not released, they expire after the TTL.
+	TryAcquire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release releases the lock for the key. It is called when the notification fails to be sent, so that another
+	// member can send it.
+	Release(ctx context.Context, key string) error
+}
+
+// notificationLockStage drops the notification if another member of the cluster acquired the lock for the same
+// notification, and otherwise sends it with the stage. The lock is released if the notification fails, so that
+// the next member sends it after its peer wait, as it does without a lock. The notification is sent if the lock
+// cannot be acquired because of an error, so that an unavailable lock store does not stop notifications.
+type notificationLockStage struct {
+	stage    notify.Stage
+	lock     NotificationLock
+	ttl      time.Duration
+	tenantID int64
+	recv     *nflogpb.Receiver
+}
+
+func (s *notificationLockStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	gkey, ok := notify.GroupKey(ctx)
+	if !ok {
+		return s.stage.Exec(ctx, l, alerts...)
+	}
+	firing, _ := notify.FiringAlerts(ctx)
+	resolved, _ := notify.ResolvedAlerts(ctx)
+
+	key := notificationLockKey(s.tenantID, s.recv, gkey, firing, resolved)
+	acquired, err := s.lock.TryAcquire(ctx, key, s.ttl)
+	if err != nil {
+		level.Warn(l).Log("msg", "Failed to acquire notification lock, sending notification", "group_key", gkey, "err", err)
+		return s.stage.Exec(ctx, l, alerts...)
+	}
+	if !acquired {
+		level.Debug(l).Log("msg", "Notification is locked by another member of the cluster, skipping", "group_key", gkey)
+		return ctx, nil, nil
+	}
+
+	ctx, res, err := s.stage.Exec(ctx, l, alerts...)
+	if err != nil {
+		if rerr := s.lock.Release(context.WithoutCancel(ctx), key); rerr != nil {
+			level.Warn(l).Log("msg", "Failed to release notification lock", "group_key", gkey, "err", rerr)
+		}
+	}
+	return ctx, res, err
+}
+
+// notificationLockKey returns the key of the lock of a notification. It identifies the integration, the alert group,
+// and the alerts of the notification, so that notifications for different alerts of the same group are not locked.
+func notificationLockKey(tenantID int64, recv *nflogpb.Receiver, gkey string, firing, resolved []uint64) string {
+	h := sha256.New()
+	b := make([]byte, 8)
+	for _, alerts := range [][]uint64{firing, resolved} {
+		binary.BigEndian.PutUint64(b, uint64(len(alerts)))
+		h.Write(b)
+		for _, a := range alerts {
+			binary.BigEndian.PutUint64(b, a)
+			h.Write(b)
+		}
+	}
+	return fmt.Sprintf("%d/%s/%s/%d/%s/%s", tenantID, recv.GroupName, recv.Integration, recv.Idx, gkey, hex.EncodeToString(h.Sum(nil)))
+}
+
+// MemoryNotificationLock is a NotificationLock for a single process. It can be used in tests, or to share the locks
+// of the Alertmanagers of several tenants in the same process.
+type MemoryNotificationLock struct {
+	mtx   sync.Mutex
+	locks map[string]time.Time
+	now   func() time.Time
+}
+
+// NewMemoryNotificationLock returns a new MemoryNotificationLock.
+func NewMemoryNotificationLock() *MemoryNotificationLock {
+	return &MemoryNotificationLock{
+		locks: make(map[string]time.Time),
+		now:   time.Now,
+	}
+}
+
+// TryAcquire acquires the lock for the key if it is not held or has expired.
+func (m *MemoryNotificationLock) TryAcquire(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	now := m.now()
+	for k, expiresAt := range m.locks {
+		if !now.Before(expiresAt) {
+			delete(m.locks, k)
+		}
+	}
+	if _, ok := m.locks[key]; ok {
+		return false, nil
+	}
+	m.locks[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Release releases the lock for the key.
+func (m *MemoryNotificationLock) Release(_ context.Context, key string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.locks, key)
+	return nil
+}
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNotificationLock struct {
+	acquired bool
+	err      error
+	keys     []string
+	released []string
+}
+
+func (f *fakeNotificationLock) TryAcquire(_ context.Context, key string, _ time.Duration) (bool, error) {
+	f.keys = append(f.keys, key)
+	return f.acquired, f.err
+}
+
+func (f *fakeNotificationLock) Release(_ context.Context, key string) error {
+	f.released = append(f.released, key)
+	return nil
+}
+
+func TestNotificationLockStage(t *testing.T) {
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+	recv := &nflogpb.Receiver{GroupName: "receiver", Integration: "webhook", Idx: 1}
+
+	ctx := notify.WithGroupKey(context.Background(), "group-key")
+	ctx = notify.WithFiringAlerts(ctx, []uint64{1, 2})
+	ctx = notify.WithResolvedAlerts(ctx, []uint64{3})
+
+	t.Run("alerts are passed on when the lock is acquired", func(t *testing.T) {
+		l := &fakeNotificationLock{acquired: true}
+		stage := &notificationLockStage{stage: &fakeStage{}, lock: l, ttl: time.Minute, tenantID: 1, recv: recv}
+		_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, alerts, res)
+		require.Len(t, l.keys, 1)
+	})
+
+	t.Run("alerts are dropped when the lock is held", func(t *testing.T) {
+		l := &fakeNotificationLock{acquired: false}
+		next := &fakeStage{}
+		stage := &notificationLockStage{stage: next, lock: l, ttl: time.Minute, tenantID: 1, recv: recv}
+		_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Empty(t, res)
+		require.Zero(t, next.calls)
+	})
+
+	t.Run("the lock is released when the notification fails", func(t *testing.T) {
+		l := &fakeNotificationLock{acquired: true}
+		stage := &notificationLockStage{stage: &fakeStage{err: errors.New("failed")}, lock: l, ttl: time.Minute, tenantID: 1, recv: recv}
+		_, _, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.Error(t, err)
+		require.Equal(t, l.keys, l.released)
+	})
+
+	t.Run("the lock is not released when the notification is sent", func(t *testing.T) {
+		l := &fakeNotificationLock{acquired: true}
+		stage := &notificationLockStage{stage: &fakeStage{}, lock: l, ttl: time.Minute, tenantID: 1, recv: recv}
+		_, _, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Empty(t, l.released)
+	})
+
+	t.Run("alerts are passed on when the lock fails", func(t *testing.T) {
+		l := &fakeNotificationLock{err: errors.New("unavailable")}
+		stage := &notificationLockStage{stage: &fakeStage{}, lock: l, ttl: time.Minute, tenantID: 1, recv: recv}
+		_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, alerts, res)
+	})
+
+	t.Run("notifications of the same group are locked separately", func(t *testing.T) {
+		l := NewMemoryNotificationLock()
+		stage := &notificationLockStage{stage: &fakeStage{}, lock: l, ttl: time.Minute, tenantID: 1, recv: recv}
+
+		_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, alerts, res)
+
+		// The same notification, such as one sent by another member, is dropped.
+		_, res, err = stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Empty(t, res)
+
+		// A notification for different alerts is not.
+		_, res, err = stage.Exec(notify.WithResolvedAlerts(ctx, []uint64{2, 3}), log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, alerts, res)
+
+		// Nor is a notification that failed on another member.
+		failed := &notificationLockStage{stage: &fakeStage{err: errors.New("failed")}, lock: l, ttl: time.Minute, tenantID: 1, recv: recv}
+		failedCtx := notify.WithResolvedAlerts(ctx, []uint64{4})
+		_, _, err = failed.Exec(failedCtx, log.NewNopLogger(), alerts...)
+		require.Error(t, err)
+		_, res, err = stage.Exec(failedCtx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, alerts, res)
+
+		// Neither is a notification for another integration.
+		other := &notificationLockStage{stage: &fakeStage{}, lock: l, ttl: time.Minute, tenantID: 1, recv: &nflogpb.Receiver{GroupName: "receiver", Integration: "webhook", Idx: 2}}
+		_, res, err = other.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		require.Equal(t, alerts, res)
+	})
+}
+
+func TestMemoryNotificationLock(t *testing.T) {
+	now := time.Now()
+	l := NewMemoryNotificationLock()
+	l.now = func() time.Time { return now }
+
+	ok, err := l.TryAcquire(context.Background(), "key", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = l.TryAcquire(context.Background(), "key", time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = l.TryAcquire(context.Background(), "other", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	now = now.Add(time.Minute)
+	ok, err = l.TryAcquire(context.Background(), "key", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, l.locks, 1)
+}
@@ -0,0 +1,252 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+var ErrNotificationPreviewUnavailable = errors.New("unable to preview notifications as alertmanager has no configuration yet")
+
+// AlertNotificationPreview is the chain of decisions that the Alertmanager makes for an alert, from routing to the
+// integrations that are notified.
+type AlertNotificationPreview struct {
+	Fingerprint string         `json:"fingerprint"`
+	Labels      model.LabelSet `json:"labels"`
+	// Received is true if the alert was received by the Alertmanager, otherwise the preview is for the alert as if it was received now.
+	Received bool `json:"received"`
+	// SilencedBy are the IDs of the active silences that silence the alert.
+	SilencedBy []string `json:"silencedBy"`
+	// InhibitedBy are the fingerprints of the alerts that inhibit the alert.
+	InhibitedBy []string `json:"inhibitedBy"`
+	// Routes are the routes the alert matches, in the order the dispatcher notifies them.
+	Routes []RouteNotificationPreview `json:"routes"`
+}
+
+// Suppressed returns true if the alert is silenced or inhibited, so that it is not notified by any route.
+func (p AlertNotificationPreview) Suppressed() bool {
+	return len(p.SilencedBy) > 0 || len(p.InhibitedBy) > 0
+}
+
+// RouteNotificationPreview describes how the alert is notified by a route.
+type RouteNotificationPreview struct {
+	// Path is the path of the route in the routing tree, such as "route.routes[0]".
+	Path        string         `json:"path"`
+	Receiver    string         `json:"receiver"`
+	GroupKey    string         `json:"groupKey"`
+	GroupLabels model.LabelSet `json:"groupLabels"`
+	// GroupExists is true if the group already exists, otherwise the alert would create it.
+	GroupExists bool `json:"groupExists"`
+	// InGroup is true if the alert is already part of the group.
+	InGroup bool `json:"inGroup"`
+	// LastNotified is the last time any integration of the receiver was notified about the group. It is zero
+	// if the group was not notified yet.
+	LastNotified time.Time `json:"lastNotified"`
+	// NextFlush is the estimated time of the next flush of the group. Notifications of flushes are deduplicated,
+	// so a flush does not send a notification unless the alerts of the group changed or the repeat interval passed.
+	NextFlush time.Time `json:"nextFlush"`
+	// MutedBy are the mute time intervals of the route that are active now.
+	MutedBy []string `json:"mutedBy"`
+	// Integrations are the integrations of the receiver.
+	Integrations []ReceiverIntegration `json:"integrations"`
+}
+
+// ReceiverIntegration describes an integration of a receiver.
+type ReceiverIntegration struct {
+	UID          string `json:"uid"`
+	Name         string `json:"name"`
+	SendResolved bool   `json:"sendResolved"`
+}
+
+// PreviewNotifications returns the decisions that the Alertmanager makes for the alert with its current
+// configuration and state: the routes the alert matches, the groups it joins, when the groups are flushed next,
+// whether it is silenced, inhibited or muted, and the integrations that are notified. The alert can be previewed
+// before or after it is received.
+func (am *GrafanaAlertmanager) PreviewNotifications(postableAlert PostableAlert) (*AlertNotificationPreview, error) {
+	now := am.clock.Now()
+	alerts, validationErr := postableAlertsToAlertmanagerAlerts(PostableAlerts{&postableAlert}, now, am.ResolveTimeout())
+	if validationErr != nil {
+		return nil, validationErr
+	}
+	alert := alerts[0]
+	fp := alert.Fingerprint()
+
+	am.reloadConfigMtx.RLock()
+	defer am.reloadConfigMtx.RUnlock()
+
+	if am.route == nil || am.dispatcher == nil {
+		return nil, ErrNotificationPreviewUnavailable
+	}
+
+	res := &AlertNotificationPreview{
+		Fingerprint: fp.String(),
+		Labels:      alert.Labels,
+		SilencedBy:  []string{},
+		InhibitedBy: []string{},
+		Routes:      []RouteNotificationPreview{},
+	}
+	if stored, err := am.alerts.Get(fp); err == nil {
+		res.Received = true
+		alert = stored
+	}
+
+	activeIDs, _, _ := am.silenceIndex.Match(alert.Labels, now)
+	res.SilencedBy = append(res.SilencedBy, activeIDs...)
+	sort.Strings(res.SilencedBy)
+
+	// The inhibitor records the inhibition of the alert in the marker. It is removed again for alerts that were
+	// not received, so that the preview does not leave a status behind.
+	am.inhibitor.Mutes(alert.Labels)
+	res.InhibitedBy = append(res.InhibitedBy, am.marker.Status(fp).InhibitedBy...)
+	if !res.Received {
+		am.suppressions.discard(fp)
+		am.marker.Delete(fp)
+	}
+
+	paths := routePaths(am.route)
+	intervener := timeinterval.NewIntervener(am.timeIntervals)
+	var err error
+	for _, r := range am.route.Match(alert.Labels) {
+		labels := groupLabels(alert.Labels, r)
+		p := RouteNotificationPreview{
+			Path:        paths[r],
+			Receiver:    r.RouteOpts.Receiver,
+			GroupKey:    fmt.Sprintf("%s:%s", r.Key(), labels),
+			GroupLabels: labels,
+			MutedBy:     []string{},
+		}
+
+		for _, name := range r.RouteOpts.MuteTimeIntervals {
+			muted, err := intervener.Mutes([]string{name}, now)
+			if err != nil {
+				return nil, err
+			}
+			if muted {
+				p.MutedBy = append(p.MutedBy, name)
+			}
+		}
+
+		p.Integrations = am.receiverIntegrations(p.Receiver)
+		p.LastNotified, err = am.lastNotified(p.Receiver, p.GroupKey)
+		if err != nil {
+			return nil, err
+		}
+
+		var group *dispatch.AlertGroup
+		groups, _ := am.dispatcher.Groups(
+			func(route *dispatch.Route) bool { return route == r },
+			func(*types.Alert, time.Time) bool { return true },
+		)
+		for _, g := range groups {
+			if g.Labels.Equal(labels) {
+				group = g
+				break
+			}
+		}
+		if group != nil {
+			p.GroupExists = true
+			for _, a := range group.Alerts {
+				if a.Fingerprint() == fp {
+					p.InGroup = true
+					break
+				}
+			}
+		}
+		p.NextFlush = nextFlush(r, group, alert, p.LastNotified, now)
+
+		res.Routes = append(res.Routes, p)
+	}
+	return res, nil
+}
+
+// receiverIntegrations returns the metadata of the integrations of the receiver.
+func (am *GrafanaAlertmanager) receiverIntegrations(receiver string) []ReceiverIntegration {
+	res := []ReceiverIntegration{}
+	for _, recv := range am.receivers {
+		if recv.Name() != receiver {
+			continue
+		}
+		for _, i := range recv.Integrations() {
+			res = append(res, ReceiverIntegration{UID: i.UID(), Name: i.Name(), SendResolved: i.SendResolved()})
+		}
+	}
+	return res
+}
+
+// lastNotified returns the last time any integration of the receiver was notified about the group, according to
+// the notification log. It is zero if the group was not notified yet.
+func (am *GrafanaAlertmanager) lastNotified(receiver, groupKey string) (time.Time, error) {
+	var last time.Time
+	for _, recv := range am.receivers {
+		if recv.Name() != receiver {
+			continue
+		}
+		for _, i := range recv.Integrations() {
+			entries, err := am.notificationLog.Query(nflog.QGroupKey(groupKey), nflog.QReceiver(&nflogpb.Receiver{
+				GroupName:   receiver,
+				Integration: i.Name(),
+				Idx:         uint32(i.Index()),
+			}))
+			if err != nil && !errors.Is(err, nflog.ErrNotFound) {
+				return time.Time{}, err
+			}
+			for _, e := range entries {
+				if e.Timestamp.After(last) {
+					last = e.Timestamp
+				}
+			}
+		}
+	}
+	return last, nil
+}
+
+// nextFlush estimates the next time the dispatcher flushes the group of the route, which is nil if the group does
+// not exist yet. Groups are flushed for the first time after the group wait, unless their alerts started more than
+// the group wait ago, and then every group interval.
+func nextFlush(r *dispatch.Route, group *dispatch.AlertGroup, alert *types.Alert, lastNotified time.Time, now time.Time) time.Time {
+	if !lastNotified.IsZero() {
+		next := lastNotified.Add(r.RouteOpts.GroupInterval)
+		if r.RouteOpts.GroupInterval > 0 && next.Before(now) {
+			next = next.Add(now.Sub(next).Truncate(r.RouteOpts.GroupInterval) + r.RouteOpts.GroupInterval)
+		}
+		return next
+	}
+	first := alert.StartsAt
+	if group != nil {
+		for _, a := range group.Alerts {
+			if a.StartsAt.Before(first) {
+				first = a.StartsAt
+			}
+		}
+	}
+	next := first.Add(r.RouteOpts.GroupWait)
+	if group == nil && now.Add(r.RouteOpts.GroupWait).Before(next) {
+		next = now.Add(r.RouteOpts.GroupWait)
+	}
+	if next.Before(now) {
+		return now
+	}
+	return next
+}
+
+// routePaths returns the paths of the routes of the routing tree, such as "route.routes[0]".
+func routePaths(root *dispatch.Route) map[*dispatch.Route]string {
+	paths := make(map[*dispatch.Route]string)
+	var walk func(path string, r *dispatch.Route)
+	walk = func(path string, r *dispatch.Route) {
+		paths[r] = path
+		for i, child := range r.Routes {
+			walk(fmt.Sprintf("%s.routes[%d]", path, i), child)
+		}
+	}
+	walk("route", root)
+	return paths
+}
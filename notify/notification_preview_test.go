@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+	"github.com/grafana/alerting/templates"
+)
+
+// previewConfig is a Configuration with a Grafana routing tree, receivers with a webhook integration each, and mute
+// time intervals.
+type previewConfig struct {
+	grafanaRoutingTreeConfig
+	receivers         []string
+	muteTimeIntervals []MuteTimeInterval
+}
+
+func (c *previewConfig) MuteTimeIntervals() []MuteTimeInterval { return c.muteTimeIntervals }
+
+func (c *previewConfig) Receivers() []*APIReceiver {
+	res := make([]*APIReceiver, 0, len(c.receivers))
+	for _, name := range c.receivers {
+		res = append(res, &APIReceiver{ConfigReceiver: ConfigReceiver{Name: name}})
+	}
+	return res
+}
+
+func (c *previewConfig) BuildReceiverIntegrationsFunc() func(*APIReceiver, *templates.Template) ([]*Integration, error) {
+	return func(r *APIReceiver, _ *templates.Template) ([]*Integration, error) {
+		return []*Integration{NewIntegration(&fakeNotifier{}, &fakeNotifier{}, "webhook", 0, r.Name, WithUID(r.Name+"-uid"))}, nil
+	}
+}
+
+func TestPreviewNotifications(t *testing.T) {
+	am, _ := setupAMTest(t)
+
+	alert := PostableAlert{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "test", "team": "a"}}}
+	_, err := am.PreviewNotifications(alert)
+	require.ErrorIs(t, err, ErrNotificationPreviewUnavailable)
+
+	teamA, err := labels.NewMatcher(labels.MatchEqual, "team", "a")
+	require.NoError(t, err)
+	teamB, err := labels.NewMatcher(labels.MatchEqual, "team", "b")
+	require.NoError(t, err)
+	hour := model.Duration(time.Hour)
+	require.NoError(t, am.ApplyConfig(&previewConfig{
+		grafanaRoutingTreeConfig: grafanaRoutingTreeConfig{route: &definition.Route{
+			Receiver:       "default",
+			GroupByStr:     []string{"alertname"},
+			GroupBy:        []model.LabelName{"alertname"},
+			GroupWait:      &hour,
+			GroupInterval:  &hour,
+			RepeatInterval: &hour,
+			Routes: []*definition.Route{
+				{Receiver: "other", ObjectMatchers: definition.ObjectMatchers{teamB}},
+				{Receiver: "team-a", ObjectMatchers: definition.ObjectMatchers{teamA}, MuteTimeIntervals: []string{"always"}, Continue: true},
+				{Receiver: "default", ObjectMatchers: definition.ObjectMatchers{teamA}},
+			},
+		}},
+		receivers:         []string{"default", "other", "team-a"},
+		muteTimeIntervals: []MuteTimeInterval{{Name: "always", TimeIntervals: []timeinterval.TimeInterval{{}}}},
+	}))
+
+	t.Run("alert that was not received", func(t *testing.T) {
+		now := time.Now()
+		p, err := am.PreviewNotifications(alert)
+		require.NoError(t, err)
+		require.False(t, p.Received)
+		require.False(t, p.Suppressed())
+		require.Len(t, p.Routes, 2)
+
+		require.Equal(t, "route.routes[1]", p.Routes[0].Path)
+		require.Equal(t, "team-a", p.Routes[0].Receiver)
+		require.Equal(t, model.LabelSet{"alertname": "test"}, p.Routes[0].GroupLabels)
+		require.Equal(t, `{}/{team="a"}:{alertname="test"}`, p.Routes[0].GroupKey)
+		require.False(t, p.Routes[0].GroupExists)
+		require.Equal(t, []string{"always"}, p.Routes[0].MutedBy)
+		require.Equal(t, []ReceiverIntegration{{UID: "team-a-uid", Name: "webhook", SendResolved: true}}, p.Routes[0].Integrations)
+		require.WithinDuration(t, now.Add(time.Hour), p.Routes[0].NextFlush, time.Minute)
+
+		require.Equal(t, "route.routes[2]", p.Routes[1].Path)
+		require.Equal(t, "default", p.Routes[1].Receiver)
+		require.Empty(t, p.Routes[1].MutedBy)
+
+		// The preview does not leave a status behind.
+		require.Zero(t, am.marker.Count())
+	})
+
+	t.Run("received alert", func(t *testing.T) {
+		silenceID, err := am.CreateSilence(&PostableSilence{
+			Silence: amv2.Silence{
+				Comment:   ptr("This is a comment"),
+				CreatedBy: ptr("test"),
+				EndsAt:    ptr(strfmt.DateTime(time.Now().Add(time.Hour))),
+				Matchers: amv2.Matchers{{
+					IsEqual: ptr(true),
+					IsRegex: ptr(false),
+					Name:    ptr("team"),
+					Value:   ptr("a"),
+				}},
+				StartsAt: ptr(strfmt.DateTime(time.Now())),
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, am.PutAlerts(PostableAlerts{&alert}))
+
+		require.Eventually(t, func() bool {
+			p, err := am.PreviewNotifications(alert)
+			require.NoError(t, err)
+			return p.Routes[0].GroupExists
+		}, time.Second, 10*time.Millisecond)
+
+		p, err := am.PreviewNotifications(alert)
+		require.NoError(t, err)
+		require.True(t, p.Received)
+		require.True(t, p.Suppressed())
+		require.Equal(t, []string{silenceID}, p.SilencedBy)
+		require.True(t, p.Routes[0].InGroup)
+		require.True(t, p.Routes[0].LastNotified.IsZero())
+	})
+}
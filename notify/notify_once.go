@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/alerting/definition"
+)
+
+// GrafanaRoutingTreeConfiguration can be implemented by a Configuration to provide the Grafana routing tree, which supports
+// route options that the Alertmanager routing tree does not, such as notify_once. If implemented, the Alertmanager routing tree
+// is built from the Grafana routing tree and RoutingTree is not used.
+type GrafanaRoutingTreeConfiguration interface {
+	GrafanaRoutingTree() *definition.Route
+}
+
+// buildRoutingTree builds the routing tree of the configuration and returns it with the keys of its routes that notify once.
+func buildRoutingTree(cfg Configuration) (*dispatch.Route, map[string]struct{}) {
+	keys := make(map[string]struct{})
+	c, ok := cfg.(GrafanaRoutingTreeConfiguration)
+	if !ok {
+		return dispatch.NewRoute(cfg.RoutingTree(), nil), keys
+	}
+	grafanaRoute := c.GrafanaRoutingTree()
+	route := dispatch.NewRoute(grafanaRoute.AsAMRoute(), nil)
+	notifyOnceRouteKeys(grafanaRoute, route, keys)
+	return route, keys
+}
+
+// notifyOnceRouteKeys adds the keys of the routes of the tree r, built from the Grafana routing tree gr, that notify once.
+func notifyOnceRouteKeys(gr *definition.Route, r *dispatch.Route, keys map[string]struct{}) {
+	if gr.NotifyOnce {
+		keys[r.Key()] = struct{}{}
+	}
+	for i := range gr.Routes {
+		notifyOnceRouteKeys(gr.Routes[i], r.Routes[i], keys)
+	}
+}
+
+// notifyOnceStage suppresses the notifications of alert groups of notify once routes, after the integration has been
+// notified about firing alerts of the group, until all of the alerts of the group are resolved. It relies on the
+// notification log, so the suppression is kept across restarts and shared by all members of the cluster.
+//
+// Route keys are not unique, routes with the same matchers at the same level of the tree have the same key. Alert
+// groups of such routes share the same entries in the notification log and are suppressed if any of the routes
+// notifies once.
+type notifyOnceStage struct {
+	routeKeys map[string]struct{}
+	nflog     notify.NotificationLog
+	recv      *nflogpb.Receiver
+}
+
+func (n *notifyOnceStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	gkey, ok := notify.GroupKey(ctx)
+	if !ok || !matchesRouteKey(n.routeKeys, gkey) {
+		return ctx, alerts, nil
+	}
+
+	// Let the notification that all alerts are resolved through, it ends the incident.
+	firing := false
+	for _, a := range alerts {
+		if !a.Resolved() {
+			firing = true
+			break
+		}
+	}
+	if !firing {
+		return ctx, alerts, nil
+	}
+
+	entries, err := n.nflog.Query(nflog.QGroupKey(gkey), nflog.QReceiver(n.recv))
+	if err != nil && !errors.Is(err, nflog.ErrNotFound) {
+		return ctx, nil, err
+	}
+	if len(entries) == 1 && len(entries[0].FiringAlerts) > 0 {
+		level.Debug(l).Log("msg", "Alert group was already notified, suppressing notification until it is resolved", "group_key", gkey)
+		return ctx, nil, nil
+	}
+	return ctx, alerts, nil
+}
+
+// matchesRouteKey returns true if the group key belongs to one of the routes with the keys. Group keys are the route
+// key followed by a colon and the labels of the group.
+func matchesRouteKey(keys map[string]struct{}, gkey string) bool {
+	for key := range keys {
+		if strings.HasPrefix(gkey, key+":") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+	"github.com/grafana/alerting/templates"
+)
+
+// grafanaRoutingTreeConfig is a Configuration with a Grafana routing tree.
+type grafanaRoutingTreeConfig struct {
+	route *definition.Route
+}
+
+func (c *grafanaRoutingTreeConfig) DispatcherLimits() DispatcherLimits { return nil }
+func (c *grafanaRoutingTreeConfig) InhibitRules() []InhibitRule        { return nil }
+func (c *grafanaRoutingTreeConfig) TimeIntervals() []TimeInterval      { return nil }
+func (c *grafanaRoutingTreeConfig) MuteTimeIntervals() []MuteTimeInterval {
+	return nil
+}
+func (c *grafanaRoutingTreeConfig) Receivers() []*APIReceiver { return nil }
+func (c *grafanaRoutingTreeConfig) BuildReceiverIntegrationsFunc() func(*APIReceiver, *templates.Template) ([]*Integration, error) {
+	return nil
+}
+func (c *grafanaRoutingTreeConfig) Templates() []templates.TemplateDefinition { return nil }
+func (c *grafanaRoutingTreeConfig) Hash() [16]byte                            { return [16]byte{} }
+func (c *grafanaRoutingTreeConfig) Raw() []byte                               { return nil }
+func (c *grafanaRoutingTreeConfig) GrafanaRoutingTree() *definition.Route     { return c.route }
+
+func (c *grafanaRoutingTreeConfig) RoutingTree() *Route {
+	panic("RoutingTree must not be used if GrafanaRoutingTree is implemented")
+}
+
+func TestNotifyOnceRouteKeys(t *testing.T) {
+	informational, err := labels.NewMatcher(labels.MatchEqual, "severity", "info")
+	require.NoError(t, err)
+	critical, err := labels.NewMatcher(labels.MatchEqual, "severity", "critical")
+	require.NoError(t, err)
+
+	gr := &definition.Route{
+		Receiver: "default",
+		Routes: []*definition.Route{
+			{Receiver: "info", NotifyOnce: true, ObjectMatchers: definition.ObjectMatchers{informational}},
+			{Receiver: "critical", ObjectMatchers: definition.ObjectMatchers{critical}},
+		},
+	}
+	r := dispatch.NewRoute(gr.AsAMRoute(), nil)
+
+	keys := make(map[string]struct{})
+	notifyOnceRouteKeys(gr, r, keys)
+	require.Equal(t, map[string]struct{}{`{}/{severity="info"}`: {}}, keys)
+}
+
+func TestNotifyOnceStage(t *testing.T) {
+	l, err := nflog.New(nflog.Options{Retention: time.Hour})
+	require.NoError(t, err)
+	recv := &nflogpb.Receiver{GroupName: "info", Integration: "webhook"}
+	s := &notifyOnceStage{
+		routeKeys: map[string]struct{}{`{}/{severity="info"}`: {}},
+		nflog:     l,
+		recv:      recv,
+	}
+
+	const gkey = `{}/{severity="info"}:{alertname="test"}`
+	firing := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "test"}, EndsAt: time.Now().Add(time.Hour)}}
+	resolved := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "test"}, EndsAt: time.Now().Add(-time.Hour)}}
+	exec := func(gkey string, alerts ...*types.Alert) []*types.Alert {
+		ctx := notify.WithGroupKey(context.Background(), gkey)
+		_, res, err := s.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		return res
+	}
+
+	// The first notification of the incident is sent.
+	require.Equal(t, []*types.Alert{firing}, exec(gkey, firing))
+
+	// Subsequent notifications are suppressed once the integration was notified about firing alerts.
+	require.NoError(t, l.Log(recv, gkey, []uint64{1}, nil, time.Hour))
+	require.Empty(t, exec(gkey, firing))
+	require.Empty(t, exec(gkey, firing, resolved))
+
+	// Groups of other routes are not suppressed.
+	const otherKey = `{}/{severity="critical"}:{alertname="test"}`
+	require.NoError(t, l.Log(recv, otherKey, []uint64{1}, nil, time.Hour))
+	require.Equal(t, []*types.Alert{firing}, exec(otherKey, firing))
+
+	// The notification that the group is resolved is sent.
+	require.Equal(t, []*types.Alert{resolved}, exec(gkey, resolved))
+
+	// The next incident is notified once the resolved notification was logged.
+	require.NoError(t, l.Log(recv, gkey, nil, []uint64{1}, time.Hour))
+	require.Equal(t, []*types.Alert{firing}, exec(gkey, firing))
+}
+
+func TestBuildRoutingTree(t *testing.T) {
+	informational, err := labels.NewMatcher(labels.MatchEqual, "severity", "info")
+	require.NoError(t, err)
+	cfg := &grafanaRoutingTreeConfig{
+		route: &definition.Route{
+			Receiver: "default",
+			Routes: []*definition.Route{
+				{Receiver: "info", NotifyOnce: true, ObjectMatchers: definition.ObjectMatchers{informational}},
+			},
+		},
+	}
+	route, keys := buildRoutingTree(cfg)
+	require.Equal(t, "default", route.RouteOpts.Receiver)
+	require.Len(t, route.Routes, 1)
+	require.Equal(t, "info", route.Routes[0].RouteOpts.Receiver)
+	require.Equal(t, map[string]struct{}{`{}/{severity="info"}`: {}}, keys)
+}
@@ -0,0 +1,159 @@
+package notify
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/alerting/definition"
+	"github.com/grafana/alerting/receivers"
+)
+
+// errNotificationSuperseded is returned by an attempt to send a notification when a later notification of the
+// alert group was delivered to the integration in the meantime.
+var errNotificationSuperseded = errors.New("notification was superseded by a later notification of the alert group")
+
+// buildOrderedRouteKeys returns the keys of the routes of the tree r, built from the Grafana routing tree of the
+// configuration, that have ordered notifications.
+func buildOrderedRouteKeys(cfg Configuration, r *dispatch.Route) map[string]struct{} {
+	keys := make(map[string]struct{})
+	c, ok := cfg.(GrafanaRoutingTreeConfiguration)
+	if !ok {
+		return keys
+	}
+	var build func(gr *definition.Route, r *dispatch.Route)
+	build = func(gr *definition.Route, r *dispatch.Route) {
+		if gr.OrderedNotifications {
+			keys[r.Key()] = struct{}{}
+		}
+		for i := range gr.Routes {
+			build(gr.Routes[i], r.Routes[i])
+		}
+	}
+	build(c.GrafanaRoutingTree(), r)
+	return keys
+}
+
+// sequenceReceiver returns the receiver of the entries of the notification log that keep the sequence numbers of the
+// notifications of recv. Entries of the notification log have no room for them, so they are kept in entries of their
+// own, with the sequence number as their only firing alert. Like any other entry, they are gossiped to the other
+// members of the cluster and kept across restarts.
+func sequenceReceiver(recv *nflogpb.Receiver) *nflogpb.Receiver {
+	return &nflogpb.Receiver{
+		GroupName:   recv.GroupName,
+		Integration: recv.Integration + "/sequence",
+		Idx:         recv.Idx,
+	}
+}
+
+// lastSequence returns the sequence number of the last notification of the alert group that was delivered to the
+// integration by any member of the cluster, or 0 if there is none.
+func lastSequence(l notify.NotificationLog, recv *nflogpb.Receiver, gkey string) (uint64, error) {
+	entries, err := l.Query(nflog.QGroupKey(gkey), nflog.QReceiver(sequenceReceiver(recv)))
+	if errors.Is(err, nflog.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) != 1 || len(entries[0].FiringAlerts) != 1 {
+		return 0, nil
+	}
+	return entries[0].FiringAlerts[0], nil
+}
+
+// orderingStage numbers the notifications of alert groups of routes with ordered notifications, so that a
+// notification is never delivered after a later notification of the same group, such as a firing notification that
+// is retried until after the resolved notification was delivered by another member of the cluster. It wraps the
+// stage that retries the notification, whose integration must be wrapped with wrapOrdering.
+//
+// The sequence number of a notification is one more than the sequence number of the last notification of the group
+// that was delivered, so members of the cluster that send the same notification give it the same number. Before each
+// attempt, the notification is dropped if a notification with a greater sequence number was delivered in the
+// meantime. An attempt that is already in flight is not stopped, so receivers also send the sequence number to their
+// destination, see receivers.NotificationSequenceFromContext.
+//
+// Like the routes that notify once, routes with the same key share their sequence numbers, see notifyOnceStage.
+type orderingStage struct {
+	stage     notify.Stage
+	routeKeys map[string]struct{}
+	nflog     notify.NotificationLog
+	recv      *nflogpb.Receiver
+}
+
+func (s *orderingStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	gkey, ok := notify.GroupKey(ctx)
+	if !ok || !matchesRouteKey(s.routeKeys, gkey) {
+		return s.stage.Exec(ctx, l, alerts...)
+	}
+	repeat, ok := notify.RepeatInterval(ctx)
+	if !ok {
+		return ctx, nil, errors.New("repeat interval missing")
+	}
+
+	last, err := lastSequence(s.nflog, s.recv, gkey)
+	if err != nil {
+		return ctx, nil, err
+	}
+	seq := last + 1
+	ctx, res, err := s.stage.Exec(receivers.WithNotificationSequence(ctx, seq), l, alerts...)
+	if errors.Is(err, errNotificationSuperseded) {
+		level.Debug(l).Log("msg", "Notification was superseded by a later notification of the alert group, dropping it", "group_key", gkey, "sequence", seq)
+		return ctx, nil, nil
+	}
+	if err != nil || len(res) == 0 {
+		return ctx, res, err
+	}
+
+	// Another member of the cluster may have delivered a later notification while this one was retried.
+	if last, err := lastSequence(s.nflog, s.recv, gkey); err == nil && last >= seq {
+		return ctx, res, nil
+	}
+	// The notification was delivered, failing to log its sequence number only weakens the ordering of the next ones.
+	if err := s.nflog.Log(sequenceReceiver(s.recv), gkey, []uint64{seq}, nil, 2*repeat); err != nil {
+		level.Warn(l).Log("msg", "Failed to log the sequence number of the notification", "group_key", gkey, "sequence", seq, "err", err)
+	}
+	return ctx, res, nil
+}
+
+// wrapOrdering wraps the integration so that attempts to send notifications that were superseded by a later
+// notification of their alert group fail with errNotificationSuperseded, see orderingStage.
+func wrapOrdering(integration *notify.Integration, receiver string, l notify.NotificationLog, recv *nflogpb.Receiver) *notify.Integration {
+	n := &orderingNotifier{
+		upstream: integration,
+		nflog:    l,
+		recv:     recv,
+	}
+	return notify.NewIntegration(n, integration, integration.Name(), integration.Index(), receiver)
+}
+
+// orderingNotifier wraps a notify.Notifier and checks the sequence number of the notification before each attempt.
+type orderingNotifier struct {
+	upstream notify.Notifier
+	nflog    notify.NotificationLog
+	recv     *nflogpb.Receiver
+}
+
+// Notify implements the Notifier interface.
+func (n *orderingNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	seq, ok := receivers.NotificationSequenceFromContext(ctx)
+	if !ok {
+		return n.upstream.Notify(ctx, alerts...)
+	}
+	gkey, ok := notify.GroupKey(ctx)
+	if !ok {
+		return n.upstream.Notify(ctx, alerts...)
+	}
+	// If the notification log cannot be queried, the notification is sent rather than held back.
+	if last, err := lastSequence(n.nflog, n.recv, gkey); err == nil && last > seq {
+		return false, errNotificationSuperseded
+	}
+	return n.upstream.Notify(ctx, alerts...)
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/featurecontrol"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+	"github.com/grafana/alerting/receivers"
+)
+
+// sequenceNotifier records the sequence numbers of the notifications it is asked to send. If set, before is called
+// before each attempt and its error is returned instead of sending the notification.
+type sequenceNotifier struct {
+	sequences []uint64
+	before    func() error
+}
+
+func (n *sequenceNotifier) Notify(ctx context.Context, _ ...*types.Alert) (bool, error) {
+	if n.before != nil {
+		if err := n.before(); err != nil {
+			return true, err
+		}
+	}
+	seq, _ := receivers.NotificationSequenceFromContext(ctx)
+	n.sequences = append(n.sequences, seq)
+	return false, nil
+}
+
+func TestBuildOrderedRouteKeys(t *testing.T) {
+	informational, err := labels.NewMatcher(labels.MatchEqual, "severity", "info")
+	require.NoError(t, err)
+	critical, err := labels.NewMatcher(labels.MatchEqual, "severity", "critical")
+	require.NoError(t, err)
+
+	gr := &definition.Route{
+		Receiver: "default",
+		Routes: []*definition.Route{
+			{Receiver: "info", ObjectMatchers: definition.ObjectMatchers{informational}},
+			{Receiver: "critical", OrderedNotifications: true, ObjectMatchers: definition.ObjectMatchers{critical}},
+		},
+	}
+	r := dispatch.NewRoute(gr.AsAMRoute(), nil)
+
+	keys := buildOrderedRouteKeys(&grafanaRoutingTreeConfig{route: gr}, r)
+	require.Equal(t, map[string]struct{}{`{}/{severity="critical"}`: {}}, keys)
+}
+
+func TestOrderingStage(t *testing.T) {
+	metrics := notify.NewMetrics(prometheus.NewRegistry(), featurecontrol.NoopFlags{})
+	l, err := nflog.New(nflog.Options{Retention: time.Hour})
+	require.NoError(t, err)
+	recv := &nflogpb.Receiver{GroupName: "critical", Integration: "webhook"}
+
+	const gkey = `{}/{severity="critical"}:{alertname="test"}`
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "test"}, EndsAt: time.Now().Add(time.Hour)}}
+
+	newStage := func(n *sequenceNotifier) notify.Stage {
+		integration := notify.NewIntegration(n, sendResolved(true), "webhook", 0, "critical")
+		integration = wrapOrdering(integration, "critical", l, recv)
+		return &orderingStage{
+			stage:     notify.NewRetryStage(integration, "critical", metrics),
+			routeKeys: map[string]struct{}{`{}/{severity="critical"}`: {}},
+			nflog:     l,
+			recv:      recv,
+		}
+	}
+	exec := func(s notify.Stage, gkey string) []*types.Alert {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		ctx = notify.WithGroupKey(ctx, gkey)
+		ctx = notify.WithRepeatInterval(ctx, time.Hour)
+		_, res, err := s.Exec(ctx, log.NewNopLogger(), alert)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("notifications are numbered in the order they are delivered", func(t *testing.T) {
+		n := &sequenceNotifier{}
+		s := newStage(n)
+		require.Equal(t, []*types.Alert{alert}, exec(s, gkey))
+		require.Equal(t, []*types.Alert{alert}, exec(s, gkey))
+		require.Equal(t, []uint64{1, 2}, n.sequences)
+
+		seq, err := lastSequence(l, recv, gkey)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), seq)
+	})
+
+	t.Run("notification is dropped if a later notification was delivered while it was retried", func(t *testing.T) {
+		const gkey = `{}/{severity="critical"}:{alertname="retried"}`
+		n := &sequenceNotifier{}
+		n.before = func() error {
+			// Another member of the cluster delivers the next notification of the group.
+			require.NoError(t, l.Log(sequenceReceiver(recv), gkey, []uint64{2}, nil, time.Hour))
+			n.before = nil
+			return errors.New("temporary failure")
+		}
+		require.Empty(t, exec(newStage(n), gkey))
+		require.Empty(t, n.sequences)
+
+		seq, err := lastSequence(l, recv, gkey)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), seq)
+	})
+
+	t.Run("notifications of other routes are not numbered", func(t *testing.T) {
+		const gkey = `{}/{severity="info"}:{alertname="test"}`
+		n := &sequenceNotifier{}
+		require.Equal(t, []*types.Alert{alert}, exec(newStage(n), gkey))
+		require.Equal(t, []uint64{0}, n.sequences)
+
+		_, err := l.Query(nflog.QGroupKey(gkey), nflog.QReceiver(sequenceReceiver(recv)))
+		require.ErrorIs(t, err, nflog.ErrNotFound)
+	})
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// QuietHours are personal quiet hours. Alerts with a value of the label, such as the user that is on call,
+// are not sent to the receivers during the quiet hours of the value. It is meant for receivers that notify a
+// single person, such as a Telegram chat or an email address, while receivers of teams still receive all alerts.
+type QuietHours struct {
+	// Label is the label of alerts that the quiet hours are keyed by, such as oncall_user.
+	Label model.LabelName
+	// Receivers are the names of the receivers the quiet hours apply to.
+	Receivers []string
+	// Windows are the quiet hours of each value of the label.
+	Windows map[model.LabelValue][]timeinterval.TimeInterval
+}
+
+// QuietHoursTable holds the quiet hours of an Alertmanager. It is consulted for each notification, so that quiet
+// hours can be changed without applying a new configuration.
+type QuietHoursTable struct {
+	mtx       sync.RWMutex
+	label     model.LabelName
+	receivers map[string]struct{}
+	windows   map[model.LabelValue][]timeinterval.TimeInterval
+}
+
+// NewQuietHoursTable returns a new QuietHoursTable without quiet hours.
+func NewQuietHoursTable() *QuietHoursTable {
+	return &QuietHoursTable{}
+}
+
+// Set replaces the quiet hours of the table.
+func (t *QuietHoursTable) Set(q QuietHours) error {
+	if !q.Label.IsValid() {
+		return errors.New("invalid label name")
+	}
+	receivers := make(map[string]struct{}, len(q.Receivers))
+	for _, r := range q.Receivers {
+		receivers[r] = struct{}{}
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.label = q.Label
+	t.receivers = receivers
+	t.windows = q.Windows
+	return nil
+}
+
+// Mutes returns true if an alert with the labels is in quiet hours for the receiver at the time.
+func (t *QuietHoursTable) Mutes(receiver string, labels model.LabelSet, now time.Time) bool {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	if _, ok := t.receivers[receiver]; !ok {
+		return false
+	}
+	v, ok := labels[t.label]
+	if !ok {
+		return false
+	}
+	for _, ti := range t.windows[v] {
+		if ti.ContainsTime(now.UTC()) {
+			return true
+		}
+	}
+	return false
+}
+
+// quietHoursStage removes the alerts that are in quiet hours for the receiver of the notification. Removed alerts
+// are sent once the quiet hours are over, with the next notification of their group.
+type quietHoursStage struct {
+	table *QuietHoursTable
+}
+
+func (s *quietHoursStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	receiver, ok := notify.ReceiverName(ctx)
+	if !ok {
+		return ctx, alerts, nil
+	}
+	now, ok := notify.Now(ctx)
+	if !ok {
+		now = time.Now()
+	}
+	res := make([]*types.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		if !s.table.Mutes(receiver, a.Labels, now) {
+			res = append(res, a)
+		}
+	}
+	if len(res) < len(alerts) {
+		level.Debug(l).Log("msg", "Alerts not sent, they are in quiet hours", "receiver", receiver, "alerts", len(alerts)-len(res))
+	}
+	return ctx, res, nil
+}
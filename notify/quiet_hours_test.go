@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietHoursStage(t *testing.T) {
+	// Alice is quiet between 22:00 and 07:00 UTC.
+	nights := []timeinterval.TimeInterval{{Times: []timeinterval.TimeRange{
+		{StartMinute: 22 * 60, EndMinute: 24 * 60},
+		{StartMinute: 0, EndMinute: 7 * 60},
+	}}}
+	table := NewQuietHoursTable()
+	require.NoError(t, table.Set(QuietHours{
+		Label:     "oncall_user",
+		Receivers: []string{"alice-telegram", "bob-email"},
+		Windows:   map[model.LabelValue][]timeinterval.TimeInterval{"alice": nights},
+	}))
+	stage := &quietHoursStage{table: table}
+
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "a", "oncall_user": "alice"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "b", "oncall_user": "bob"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "c"}}},
+	}
+	exec := func(receiver string, now time.Time) []*types.Alert {
+		ctx := notify.WithReceiverName(context.Background(), receiver)
+		ctx = notify.WithNow(ctx, now)
+		_, res, err := stage.Exec(ctx, log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		return res
+	}
+	night := time.Date(2024, 6, 7, 23, 30, 0, 0, time.UTC)
+	day := time.Date(2024, 6, 7, 12, 0, 0, 0, time.UTC)
+
+	require.Equal(t, alerts[1:], exec("alice-telegram", night))
+	require.Equal(t, alerts, exec("alice-telegram", day))
+	// Team receivers receive all alerts.
+	require.Equal(t, alerts, exec("team-slack", night))
+
+	t.Run("quiet hours can be changed", func(t *testing.T) {
+		require.NoError(t, table.Set(QuietHours{
+			Label:     "oncall_user",
+			Receivers: []string{"alice-telegram"},
+			Windows:   map[model.LabelValue][]timeinterval.TimeInterval{"bob": nights},
+		}))
+		require.Equal(t, []*types.Alert{alerts[0], alerts[2]}, exec("alice-telegram", night))
+		require.Equal(t, alerts, exec("bob-email", night))
+
+		require.EqualError(t, table.Set(QuietHours{Label: "invalid label"}), "invalid label name")
+	})
+}
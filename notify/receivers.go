@@ -17,12 +17,16 @@ import (
 
 	"github.com/grafana/alerting/receivers"
 	"github.com/grafana/alerting/receivers/alertmanager"
+	"github.com/grafana/alerting/receivers/blackhole"
 	"github.com/grafana/alerting/receivers/dinding"
 	"github.com/grafana/alerting/receivers/discord"
+	"github.com/grafana/alerting/receivers/echo"
 	"github.com/grafana/alerting/receivers/email"
+	"github.com/grafana/alerting/receivers/feishu"
 	"github.com/grafana/alerting/receivers/googlechat"
 	"github.com/grafana/alerting/receivers/kafka"
 	"github.com/grafana/alerting/receivers/line"
+	"github.com/grafana/alerting/receivers/matrix"
 	"github.com/grafana/alerting/receivers/mqtt"
 	"github.com/grafana/alerting/receivers/oncall"
 	"github.com/grafana/alerting/receivers/opsgenie"
@@ -38,6 +42,7 @@ import (
 	"github.com/grafana/alerting/receivers/webex"
 	"github.com/grafana/alerting/receivers/webhook"
 	"github.com/grafana/alerting/receivers/wecom"
+	"github.com/grafana/alerting/receivers/zenduty"
 )
 
 const (
@@ -49,7 +54,10 @@ var (
 )
 
 type TestReceiversResult struct {
-	Alert     types.Alert          `json:"alert"`
+	// Alert is the first alert of the test notification, see Alerts.
+	Alert types.Alert `json:"alert"`
+	// Alerts are the alerts of the test notification, before the annotations of each integration were overridden.
+	Alerts    []types.Alert        `json:"alerts"`
 	Receivers []TestReceiverResult `json:"receivers"`
 	NotifedAt time.Time            `json:"notifiedAt"`
 }
@@ -64,6 +72,11 @@ type TestIntegrationConfigResult struct {
 	UID    string `json:"uid"`
 	Status string `json:"status"`
 	Error  string `json:"error"`
+	// ResolvedStatus and ResolvedError are the result of the resolved notification, if one was sent, see
+	// TestReceiversConfigBodyParams.Resolve. ResolvedStatus is "skipped" if the integration does not send
+	// resolved notifications.
+	ResolvedStatus string `json:"resolvedStatus,omitempty"`
+	ResolvedError  string `json:"resolvedError,omitempty"`
 }
 
 type GrafanaIntegrationConfig struct {
@@ -73,6 +86,8 @@ type GrafanaIntegrationConfig struct {
 	DisableResolveMessage bool              `json:"disableResolveMessage" yaml:"disableResolveMessage"`
 	Settings              json.RawMessage   `json:"settings" yaml:"settings"`
 	SecureSettings        map[string]string `json:"secureSettings" yaml:"secureSettings"`
+	// RetryPolicy overrides how the integration retries failed notifications. It is optional.
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty" yaml:"retryPolicy,omitempty"`
 }
 
 type ConfigReceiver = config.Receiver
@@ -84,16 +99,39 @@ type APIReceiver struct {
 
 type GrafanaIntegrations struct {
 	Integrations []*GrafanaIntegrationConfig `yaml:"grafana_managed_receiver_configs,omitempty" json:"grafana_managed_receiver_configs,omitempty"`
+	// Failover is an ordered list of UIDs of integrations. Each integration in the list is notified only if
+	// the previous one failed permanently. Integrations that are not in the list are always notified.
+	Failover []string `yaml:"failover,omitempty" json:"failover,omitempty"`
 }
 
 type TestReceiversConfigBodyParams struct {
 	Alert     *TestReceiversConfigAlertParams `yaml:"alert,omitempty" json:"alert,omitempty"`
 	Receivers []*APIReceiver                  `yaml:"receivers,omitempty" json:"receivers,omitempty"`
+	// Resolve also sends a resolved notification of the test alert after the firing one to the integrations
+	// that open incidents, such as PagerDuty and Opsgenie, so that closing the incident can be tested too.
+	Resolve bool `yaml:"resolve,omitempty" json:"resolve,omitempty"`
+	// Alerts, if set, are the alerts of the test notification instead of the single alert of Alert, such as to
+	// test templates that range over alerts or depend on their status or labels.
+	Alerts []*TestReceiversConfigAlertParams `yaml:"alerts,omitempty" json:"alerts,omitempty"`
+	// IntegrationAnnotations override the annotations of the test alerts for the integrations with the UIDs of the
+	// keys, such as to test the templates of the annotations of one integration.
+	IntegrationAnnotations map[string]model.LabelSet `yaml:"integration_annotations,omitempty" json:"integrationAnnotations,omitempty"`
+}
+
+// resolvingIntegrationTypes are the types of the integrations that open incidents that are closed by resolved
+// notifications. Resolved test notifications are sent to them if TestReceiversConfigBodyParams.Resolve is set.
+var resolvingIntegrationTypes = map[string]struct{}{
+	"opsgenie":  {},
+	"oncall":    {},
+	"pagerduty": {},
+	"webhook":   {},
 }
 
 type TestReceiversConfigAlertParams struct {
 	Annotations model.LabelSet `yaml:"annotations,omitempty" json:"annotations,omitempty"`
 	Labels      model.LabelSet `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// Status is the status of the test alert, firing by default. Resolved test alerts end when the test starts.
+	Status model.AlertStatus `yaml:"status,omitempty" json:"status,omitempty"`
 }
 
 type IntegrationTimeoutError struct {
@@ -118,7 +156,26 @@ func (am *GrafanaAlertmanager) TestReceivers(ctx context.Context, c TestReceiver
 	return TestReceivers(ctx, c, tmpls, am.buildReceiverIntegrationsFunc, am.ExternalURL())
 }
 
-func newTestAlert(c TestReceiversConfigBodyParams, startsAt, updatedAt time.Time) types.Alert {
+// newTestAlerts returns the alerts of the test notification, see TestReceiversConfigBodyParams.Alerts.
+func newTestAlerts(c TestReceiversConfigBodyParams, startsAt, updatedAt time.Time) ([]types.Alert, error) {
+	if len(c.Alerts) == 0 {
+		return []types.Alert{newTestAlert(c.Alert, startsAt, updatedAt)}, nil
+	}
+	alerts := make([]types.Alert, 0, len(c.Alerts))
+	for i, params := range c.Alerts {
+		if params != nil {
+			switch params.Status {
+			case "", model.AlertFiring, model.AlertResolved:
+			default:
+				return nil, fmt.Errorf("invalid status %q of test alert %d", params.Status, i)
+			}
+		}
+		alerts = append(alerts, newTestAlert(params, startsAt, updatedAt))
+	}
+	return alerts, nil
+}
+
+func newTestAlert(params *TestReceiversConfigAlertParams, startsAt, updatedAt time.Time) types.Alert {
 	var (
 		defaultAnnotations = model.LabelSet{
 			"summary":          "Notification test",
@@ -139,22 +196,55 @@ func newTestAlert(c TestReceiversConfigBodyParams, startsAt, updatedAt time.Time
 		UpdatedAt: updatedAt,
 	}
 
-	if c.Alert != nil {
-		if c.Alert.Annotations != nil {
-			for k, v := range c.Alert.Annotations {
+	if params != nil {
+		if params.Annotations != nil {
+			for k, v := range params.Annotations {
 				alert.Annotations[k] = v
 			}
 		}
-		if c.Alert.Labels != nil {
-			for k, v := range c.Alert.Labels {
+		if params.Labels != nil {
+			for k, v := range params.Labels {
 				alert.Labels[k] = v
 			}
 		}
+		if params.Status == model.AlertResolved {
+			alert.EndsAt = startsAt
+		}
 	}
 
 	return alert
 }
 
+// testAlertsForIntegration returns copies of the test alerts with the annotations of the integration, see
+// TestReceiversConfigBodyParams.IntegrationAnnotations.
+func testAlertsForIntegration(alerts []types.Alert, annotations model.LabelSet) []*types.Alert {
+	res := make([]*types.Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		if len(annotations) > 0 {
+			alert.Annotations = alert.Annotations.Merge(annotations)
+		}
+		res = append(res, &alert)
+	}
+	return res
+}
+
+// testGroupLabels returns the labels that all the test alerts have in common, which are the group labels of the
+// test notification.
+func testGroupLabels(alerts []types.Alert) model.LabelSet {
+	if len(alerts) == 0 {
+		return model.LabelSet{}
+	}
+	res := alerts[0].Labels.Clone()
+	for _, alert := range alerts[1:] {
+		for k, v := range res {
+			if alert.Labels[k] != v {
+				delete(res, k)
+			}
+		}
+	}
+	return res
+}
+
 func ProcessIntegrationError(config *GrafanaIntegrationConfig, err error) error {
 	if err == nil {
 		return nil
@@ -184,12 +274,16 @@ func ProcessIntegrationError(config *GrafanaIntegrationConfig, err error) error
 type GrafanaReceiverConfig struct {
 	Name                string
 	AlertmanagerConfigs []*NotifierConfig[alertmanager.Config]
+	BlackholeConfigs    []*NotifierConfig[blackhole.Config]
 	DingdingConfigs     []*NotifierConfig[dinding.Config]
 	DiscordConfigs      []*NotifierConfig[discord.Config]
+	EchoConfigs         []*NotifierConfig[echo.Config]
 	EmailConfigs        []*NotifierConfig[email.Config]
+	FeishuConfigs       []*NotifierConfig[feishu.Config]
 	GooglechatConfigs   []*NotifierConfig[googlechat.Config]
 	KafkaConfigs        []*NotifierConfig[kafka.Config]
 	LineConfigs         []*NotifierConfig[line.Config]
+	MatrixConfigs       []*NotifierConfig[matrix.Config]
 	OpsgenieConfigs     []*NotifierConfig[opsgenie.Config]
 	MqttConfigs         []*NotifierConfig[mqtt.Config]
 	PagerdutyConfigs    []*NotifierConfig[pagerduty.Config]
@@ -205,12 +299,16 @@ type GrafanaReceiverConfig struct {
 	WebhookConfigs      []*NotifierConfig[webhook.Config]
 	WecomConfigs        []*NotifierConfig[wecom.Config]
 	WebexConfigs        []*NotifierConfig[webex.Config]
+	ZendutyConfigs      []*NotifierConfig[zenduty.Config]
+	// CustomConfigs are the configurations of the integrations of custom types, see RegisterIntegrationType.
+	CustomConfigs []*NotifierConfig[interface{}]
 }
 
 // NotifierConfig represents parsed GrafanaIntegrationConfig.
 type NotifierConfig[T interface{}] struct {
 	receivers.Metadata
-	Settings T
+	Settings    T
+	RetryPolicy *RetryPolicy
 }
 
 // DecodeSecretsFn is a function used to decode a map of secrets before creating a receiver.
@@ -257,6 +355,20 @@ func NoopDecrypt(_ context.Context, sjd map[string][]byte, key string, fallback
 	return fallback
 }
 
+type savedReceiverValidationKey struct{}
+
+// WithSavedReceiverValidation returns a context in which BuildReceiverConfiguration also runs the checks of receivers
+// that are being saved, such as the shape of Discord webhook URLs and PagerDuty integration keys. They are not run when existing receivers are
+// loaded, since those may have been saved before the checks were added.
+func WithSavedReceiverValidation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, savedReceiverValidationKey{}, true)
+}
+
+func savedReceiverValidationFromContext(ctx context.Context) bool {
+	v, _ := ctx.Value(savedReceiverValidationKey{}).(bool)
+	return v
+}
+
 // BuildReceiverConfiguration parses, decrypts and validates the APIReceiver.
 func BuildReceiverConfiguration(ctx context.Context, api *APIReceiver, decode DecodeSecretsFn, decrypt GetDecryptedValueFn) (GrafanaReceiverConfig, error) {
 	result := GrafanaReceiverConfig{
@@ -271,18 +383,72 @@ func BuildReceiverConfiguration(ctx context.Context, api *APIReceiver, decode De
 			}
 		}
 	}
+	if err := validateFailover(api); err != nil {
+		return GrafanaReceiverConfig{}, err
+	}
 	return result, nil
 }
 
+// validateFailover checks that the failover chain of the receiver references each of its integrations at most once.
+// Integrations without a UID cannot be referenced.
+func validateFailover(api *APIReceiver) error {
+	uids := make(map[string]struct{}, len(api.Integrations))
+	for _, i := range api.Integrations {
+		if i.UID != "" {
+			uids[i.UID] = struct{}{}
+		}
+	}
+	seen := make(map[string]struct{}, len(api.Failover))
+	for _, uid := range api.Failover {
+		if uid == "" {
+			return errors.New("invalid failover chain: integrations must be referenced by a non-empty UID")
+		}
+		if _, ok := uids[uid]; !ok {
+			return fmt.Errorf("invalid failover chain: integration with UID %q does not exist in receiver %q", uid, api.Name)
+		}
+		if _, ok := seen[uid]; ok {
+			return fmt.Errorf("invalid failover chain: integration with UID %q is listed more than once", uid)
+		}
+		seen[uid] = struct{}{}
+	}
+	return nil
+}
+
 // parseNotifier parses receivers and populates the corresponding field in GrafanaReceiverConfig. Returns an error if the configuration cannot be parsed.
-func parseNotifier(ctx context.Context, result *GrafanaReceiverConfig, receiver *GrafanaIntegrationConfig, decode DecodeSecretsFn, decrypt GetDecryptedValueFn) error {
+// If the context has a secret resolver, see WithSecretResolver, the settings that reference a secret are resolved.
+func parseNotifier(ctx context.Context, result *GrafanaReceiverConfig, receiver *GrafanaIntegrationConfig, decode DecodeSecretsFn, decrypt GetDecryptedValueFn) (err error) {
 	secureSettings, err := decode(receiver.SecureSettings)
 	if err != nil {
 		return err
 	}
 
+	resolver := secretResolverFromContext(ctx)
+	var resolveErr error
+	defer func() {
+		// The configuration is invalid because of the secret that cannot be resolved, rather than because it is missing.
+		if resolveErr != nil {
+			err = resolveErr
+		}
+	}()
 	decryptFn := func(key string, fallback string) string {
-		return decrypt(ctx, secureSettings, key, fallback)
+		value := decrypt(ctx, secureSettings, key, fallback)
+		if resolver == nil || !IsSecretRef(value) {
+			return value
+		}
+		secret, err := resolver.Resolve(ctx, value)
+		if err != nil {
+			if resolveErr == nil {
+				resolveErr = fmt.Errorf("invalid secret reference in %s: %w", key, err)
+			}
+			return ""
+		}
+		return secret
+	}
+
+	if receiver.RetryPolicy != nil {
+		if err := receiver.RetryPolicy.Validate(); err != nil {
+			return fmt.Errorf("invalid retry policy: %w", err)
+		}
 	}
 
 	switch strings.ToLower(receiver.Type) {
@@ -292,8 +458,14 @@ func parseNotifier(ctx context.Context, result *GrafanaReceiverConfig, receiver
 			return err
 		}
 		result.AlertmanagerConfigs = append(result.AlertmanagerConfigs, newNotifierConfig(receiver, cfg))
+	case "blackhole":
+		cfg, err := blackhole.NewConfig(receiver.Settings)
+		if err != nil {
+			return err
+		}
+		result.BlackholeConfigs = append(result.BlackholeConfigs, newNotifierConfig(receiver, cfg))
 	case "dingding":
-		cfg, err := dinding.NewConfig(receiver.Settings)
+		cfg, err := dinding.NewConfig(receiver.Settings, decryptFn)
 		if err != nil {
 			return err
 		}
@@ -303,13 +475,30 @@ func parseNotifier(ctx context.Context, result *GrafanaReceiverConfig, receiver
 		if err != nil {
 			return err
 		}
+		if savedReceiverValidationFromContext(ctx) {
+			if err := discord.ValidateWebhookURL(cfg.WebhookURL); err != nil {
+				return err
+			}
+		}
 		result.DiscordConfigs = append(result.DiscordConfigs, newNotifierConfig(receiver, cfg))
+	case "echo":
+		cfg, err := echo.NewConfig(receiver.Settings)
+		if err != nil {
+			return err
+		}
+		result.EchoConfigs = append(result.EchoConfigs, newNotifierConfig(receiver, cfg))
 	case "email":
 		cfg, err := email.NewConfig(receiver.Settings)
 		if err != nil {
 			return err
 		}
 		result.EmailConfigs = append(result.EmailConfigs, newNotifierConfig(receiver, cfg))
+	case "feishu":
+		cfg, err := feishu.NewConfig(receiver.Settings, decryptFn)
+		if err != nil {
+			return err
+		}
+		result.FeishuConfigs = append(result.FeishuConfigs, newNotifierConfig(receiver, cfg))
 	case "googlechat":
 		cfg, err := googlechat.NewConfig(receiver.Settings, decryptFn)
 		if err != nil {
@@ -328,6 +517,12 @@ func parseNotifier(ctx context.Context, result *GrafanaReceiverConfig, receiver
 			return err
 		}
 		result.LineConfigs = append(result.LineConfigs, newNotifierConfig(receiver, cfg))
+	case "matrix":
+		cfg, err := matrix.NewConfig(receiver.Settings, decryptFn)
+		if err != nil {
+			return err
+		}
+		result.MatrixConfigs = append(result.MatrixConfigs, newNotifierConfig(receiver, cfg))
 	case "mqtt":
 		cfg, err := mqtt.NewConfig(receiver.Settings, decryptFn)
 		if err != nil {
@@ -345,6 +540,11 @@ func parseNotifier(ctx context.Context, result *GrafanaReceiverConfig, receiver
 		if err != nil {
 			return err
 		}
+		if savedReceiverValidationFromContext(ctx) {
+			if err := pagerduty.ValidateRoutingKey(cfg.Key); err != nil {
+				return err
+			}
+		}
 		result.PagerdutyConfigs = append(result.PagerdutyConfigs, newNotifierConfig(receiver, cfg))
 	case "oncall":
 		cfg, err := oncall.NewConfig(receiver.Settings, decryptFn)
@@ -377,7 +577,7 @@ func parseNotifier(ctx context.Context, result *GrafanaReceiverConfig, receiver
 		}
 		result.SNSConfigs = append(result.SNSConfigs, newNotifierConfig(receiver, cfg))
 	case "teams":
-		cfg, err := teams.NewConfig(receiver.Settings)
+		cfg, err := teams.NewConfig(receiver.Settings, decryptFn)
 		if err != nil {
 			return err
 		}
@@ -395,7 +595,7 @@ func parseNotifier(ctx context.Context, result *GrafanaReceiverConfig, receiver
 		}
 		result.ThreemaConfigs = append(result.ThreemaConfigs, newNotifierConfig(receiver, cfg))
 	case "victorops":
-		cfg, err := victorops.NewConfig(receiver.Settings)
+		cfg, err := victorops.NewConfig(receiver.Settings, decryptFn)
 		if err != nil {
 			return err
 		}
@@ -418,8 +618,22 @@ func parseNotifier(ctx context.Context, result *GrafanaReceiverConfig, receiver
 			return err
 		}
 		result.WebexConfigs = append(result.WebexConfigs, newNotifierConfig(receiver, cfg))
+	case "zenduty":
+		cfg, err := zenduty.NewConfig(receiver.Settings, decryptFn)
+		if err != nil {
+			return err
+		}
+		result.ZendutyConfigs = append(result.ZendutyConfigs, newNotifierConfig(receiver, cfg))
 	default:
-		return fmt.Errorf("notifier %s is not supported", receiver.Type)
+		t, ok := lookupIntegrationType(receiver.Type)
+		if !ok {
+			return fmt.Errorf("notifier %s is not supported", receiver.Type)
+		}
+		cfg, err := t.factory.NewConfig(receiver.Settings, decryptFn)
+		if err != nil {
+			return err
+		}
+		result.CustomConfigs = append(result.CustomConfigs, newNotifierConfig(receiver, cfg))
 	}
 	return nil
 }
@@ -443,7 +657,8 @@ func newNotifierConfig[T interface{}](receiver *GrafanaIntegrationConfig, settin
 			Type:                  receiver.Type,
 			DisableResolveMessage: receiver.DisableResolveMessage,
 		},
-		Settings: settings,
+		Settings:    settings,
+		RetryPolicy: receiver.RetryPolicy,
 	}
 }
 
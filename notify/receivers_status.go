@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"time"
+
+	"github.com/grafana/alerting/notify/nfstatus"
+)
+
+// ReceiverStatus is the health of the integrations of a receiver, see GrafanaAlertmanager.GetReceiversStatus.
+type ReceiverStatus struct {
+	Name string `json:"name"`
+	// Active is whether the receiver is used by a route.
+	Active       bool                `json:"active"`
+	Integrations []IntegrationStatus `json:"integrations"`
+}
+
+// IntegrationStatus is the health of an integration of a receiver. Times are zero if there was no such notification
+// attempt since the configuration was applied.
+type IntegrationStatus struct {
+	// Name is the type of the integration, such as slack.
+	Name string `json:"name"`
+	// UID is the UID of the configuration of the integration, if it is known.
+	UID          string `json:"uid,omitempty"`
+	Index        int    `json:"index"`
+	SendResolved bool   `json:"sendResolved"`
+
+	LastNotifyAttempt         time.Time     `json:"lastNotifyAttempt"`
+	LastNotifyAttemptDuration time.Duration `json:"lastNotifyAttemptDuration"`
+	LastNotifyAttemptError    string        `json:"lastNotifyAttemptError,omitempty"`
+	LastSuccessfulNotify      time.Time     `json:"lastSuccessfulNotify"`
+}
+
+// GetReceiversStatus returns the health of the integrations of the receivers of the applied configuration, such as
+// to drive a dashboard of the health of contact points. Unlike GetReceivers, it is not the API model of the upstream
+// Alertmanager, and it has the last successful notification of each integration.
+func (am *GrafanaAlertmanager) GetReceiversStatus() []ReceiverStatus {
+	am.reloadConfigMtx.RLock()
+	receivers := am.receivers
+	am.reloadConfigMtx.RUnlock()
+
+	return GetReceiversStatus(receivers)
+}
+
+// GetReceiversStatus converts the internal receiver status into ReceiverStatus.
+func GetReceiversStatus(receivers []*nfstatus.Receiver) []ReceiverStatus {
+	res := make([]ReceiverStatus, 0, len(receivers))
+	for _, rcv := range receivers {
+		integrations := make([]IntegrationStatus, 0, len(rcv.Integrations()))
+		for _, integration := range rcv.Integrations() {
+			status := integration.Status()
+			var lastErr string
+			if status.LastNotifyAttemptError != nil {
+				lastErr = status.LastNotifyAttemptError.Error()
+			}
+			integrations = append(integrations, IntegrationStatus{
+				Name:                      integration.Name(),
+				UID:                       integration.UID(),
+				Index:                     integration.Index(),
+				SendResolved:              integration.SendResolved(),
+				LastNotifyAttempt:         status.LastNotifyAttempt,
+				LastNotifyAttemptDuration: time.Duration(status.LastNotifyAttemptDuration),
+				LastNotifyAttemptError:    lastErr,
+				LastSuccessfulNotify:      status.LastSuccessfulNotify,
+			})
+		}
+
+		res = append(res, ReceiverStatus{
+			Name:         rcv.Name(),
+			Active:       rcv.Active(),
+			Integrations: integrations,
+		})
+	}
+	return res
+}
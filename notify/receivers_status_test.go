@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/notify/nfstatus"
+)
+
+type failingNotifier struct {
+	fakeNotifier
+}
+
+func (f *failingNotifier) Notify(context.Context, ...*types.Alert) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestGetReceiversStatus(t *testing.T) {
+	healthy := nfstatus.NewIntegration(&fakeNotifier{}, &fakeNotifier{}, "slack", 0, "team-a", nfstatus.WithUID("slack-uid"))
+	failing := nfstatus.NewIntegration(&failingNotifier{}, &fakeNotifier{}, "webhook", 1, "team-a")
+	unused := nfstatus.NewIntegration(&fakeNotifier{}, &fakeNotifier{}, "email", 0, "team-b")
+
+	_, err := healthy.Notify(context.Background())
+	require.NoError(t, err)
+	_, err = failing.Notify(context.Background())
+	require.Error(t, err)
+
+	status := GetReceiversStatus([]*nfstatus.Receiver{
+		nfstatus.NewReceiver("team-a", true, []*nfstatus.Integration{healthy, failing}),
+		nfstatus.NewReceiver("team-b", false, []*nfstatus.Integration{unused}),
+	})
+	require.Len(t, status, 2)
+
+	require.Equal(t, "team-a", status[0].Name)
+	require.True(t, status[0].Active)
+	require.Len(t, status[0].Integrations, 2)
+
+	slack := status[0].Integrations[0]
+	require.Equal(t, "slack", slack.Name)
+	require.Equal(t, "slack-uid", slack.UID)
+	require.True(t, slack.SendResolved)
+	require.False(t, slack.LastNotifyAttempt.IsZero())
+	require.Equal(t, slack.LastNotifyAttempt, slack.LastSuccessfulNotify)
+	require.Empty(t, slack.LastNotifyAttemptError)
+
+	webhook := status[0].Integrations[1]
+	require.Equal(t, 1, webhook.Index)
+	require.False(t, webhook.LastNotifyAttempt.IsZero())
+	require.True(t, webhook.LastSuccessfulNotify.IsZero())
+	require.Equal(t, "connection refused", webhook.LastNotifyAttemptError)
+
+	require.Equal(t, ReceiverStatus{
+		Name: "team-b",
+		Integrations: []IntegrationStatus{{
+			Name:         "email",
+			SendResolved: true,
+		}},
+	}, status[1])
+}
@@ -9,7 +9,9 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/alerting/receivers"
@@ -167,11 +169,15 @@ func TestBuildReceiverConfiguration(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, recCfg.Name, parsed.Name)
 		require.Len(t, parsed.AlertmanagerConfigs, 1)
+		require.Len(t, parsed.BlackholeConfigs, 1)
 		require.Len(t, parsed.DingdingConfigs, 1)
 		require.Len(t, parsed.DiscordConfigs, 1)
+		require.Len(t, parsed.EchoConfigs, 1)
 		require.Len(t, parsed.EmailConfigs, 1)
+		require.Len(t, parsed.FeishuConfigs, 1)
 		require.Len(t, parsed.GooglechatConfigs, 1)
 		require.Len(t, parsed.KafkaConfigs, 1)
+		require.Len(t, parsed.MatrixConfigs, 1)
 		require.Len(t, parsed.LineConfigs, 1)
 		require.Len(t, parsed.OpsgenieConfigs, 1)
 		require.Len(t, parsed.PagerdutyConfigs, 1)
@@ -186,15 +192,20 @@ func TestBuildReceiverConfiguration(t *testing.T) {
 		require.Len(t, parsed.WebhookConfigs, 1)
 		require.Len(t, parsed.WecomConfigs, 1)
 		require.Len(t, parsed.WebexConfigs, 1)
+		require.Len(t, parsed.ZendutyConfigs, 1)
 
 		t.Run("should populate metadata", func(t *testing.T) {
 			var all []receivers.Metadata
 			all = append(all, getMetadata(parsed.AlertmanagerConfigs)...)
+			all = append(all, getMetadata(parsed.BlackholeConfigs)...)
 			all = append(all, getMetadata(parsed.DingdingConfigs)...)
 			all = append(all, getMetadata(parsed.DiscordConfigs)...)
+			all = append(all, getMetadata(parsed.EchoConfigs)...)
 			all = append(all, getMetadata(parsed.EmailConfigs)...)
+			all = append(all, getMetadata(parsed.FeishuConfigs)...)
 			all = append(all, getMetadata(parsed.GooglechatConfigs)...)
 			all = append(all, getMetadata(parsed.KafkaConfigs)...)
+			all = append(all, getMetadata(parsed.MatrixConfigs)...)
 			all = append(all, getMetadata(parsed.LineConfigs)...)
 			all = append(all, getMetadata(parsed.OpsgenieConfigs)...)
 			all = append(all, getMetadata(parsed.PagerdutyConfigs)...)
@@ -209,6 +220,7 @@ func TestBuildReceiverConfiguration(t *testing.T) {
 			all = append(all, getMetadata(parsed.WebhookConfigs)...)
 			all = append(all, getMetadata(parsed.WecomConfigs)...)
 			all = append(all, getMetadata(parsed.WebexConfigs)...)
+			all = append(all, getMetadata(parsed.ZendutyConfigs)...)
 
 			for idx, meta := range all {
 				require.NotEmptyf(t, meta.Type, "%s notifier (idx: %d) '%s' uid: '%s'.", meta.Type, idx, meta.Name, meta.UID)
@@ -236,11 +248,15 @@ func TestBuildReceiverConfiguration(t *testing.T) {
 		parsed, err := BuildReceiverConfiguration(context.Background(), recCfg, DecodeSecretsFromBase64, decrypt)
 		require.NoError(t, err)
 		require.Len(t, parsed.AlertmanagerConfigs, 1)
+		require.Len(t, parsed.BlackholeConfigs, 1)
 		require.Len(t, parsed.DingdingConfigs, 1)
 		require.Len(t, parsed.DiscordConfigs, 1)
+		require.Len(t, parsed.EchoConfigs, 1)
 		require.Len(t, parsed.EmailConfigs, 1)
+		require.Len(t, parsed.FeishuConfigs, 1)
 		require.Len(t, parsed.GooglechatConfigs, 1)
 		require.Len(t, parsed.KafkaConfigs, 1)
+		require.Len(t, parsed.MatrixConfigs, 1)
 		require.Len(t, parsed.LineConfigs, 1)
 		require.Len(t, parsed.OpsgenieConfigs, 1)
 		require.Len(t, parsed.PagerdutyConfigs, 1)
@@ -255,8 +271,102 @@ func TestBuildReceiverConfiguration(t *testing.T) {
 		require.Len(t, parsed.WebhookConfigs, 1)
 		require.Len(t, parsed.WecomConfigs, 1)
 		require.Len(t, parsed.WebexConfigs, 1)
+		require.Len(t, parsed.ZendutyConfigs, 1)
 
 	})
+	t.Run("should parse retry policy", func(t *testing.T) {
+		recCfg := &APIReceiver{ConfigReceiver: ConfigReceiver{Name: "test-receiver"}}
+		integration := AllKnownConfigsForTesting["webhook"].GetRawNotifierConfig("webhook")
+		integration.RetryPolicy = &RetryPolicy{MaxAttempts: 10, InitialBackoff: model.Duration(time.Second), MaxBackoff: model.Duration(time.Minute), Jitter: 0.2}
+		recCfg.Integrations = append(recCfg.Integrations, integration)
+
+		parsed, err := BuildReceiverConfiguration(context.Background(), recCfg, DecodeSecretsFromBase64, decrypt)
+		require.NoError(t, err)
+		require.Len(t, parsed.WebhookConfigs, 1)
+		require.Equal(t, integration.RetryPolicy, parsed.WebhookConfigs[0].RetryPolicy)
+	})
+	t.Run("should validate failover chain", func(t *testing.T) {
+		newReceiver := func(failover ...string) *APIReceiver {
+			recCfg := &APIReceiver{ConfigReceiver: ConfigReceiver{Name: "test-receiver"}}
+			for _, uid := range []string{"primary", "secondary"} {
+				integration := AllKnownConfigsForTesting["webhook"].GetRawNotifierConfig(uid)
+				integration.UID = uid
+				recCfg.Integrations = append(recCfg.Integrations, integration)
+			}
+			recCfg.Failover = failover
+			return recCfg
+		}
+
+		_, err := BuildReceiverConfiguration(context.Background(), newReceiver("primary", "secondary"), DecodeSecretsFromBase64, decrypt)
+		require.NoError(t, err)
+
+		_, err = BuildReceiverConfiguration(context.Background(), newReceiver("primary", "unknown"), DecodeSecretsFromBase64, decrypt)
+		require.ErrorContains(t, err, `integration with UID "unknown" does not exist`)
+
+		_, err = BuildReceiverConfiguration(context.Background(), newReceiver("primary", "primary"), DecodeSecretsFromBase64, decrypt)
+		require.ErrorContains(t, err, `integration with UID "primary" is listed more than once`)
+
+		_, err = BuildReceiverConfiguration(context.Background(), newReceiver("primary", ""), DecodeSecretsFromBase64, decrypt)
+		require.ErrorContains(t, err, "integrations must be referenced by a non-empty UID")
+
+		// Integrations without a UID cannot be referenced by the failover chain.
+		recCfg := newReceiver("primary", "secondary")
+		recCfg.Integrations[1].UID = ""
+		_, err = BuildReceiverConfiguration(context.Background(), recCfg, DecodeSecretsFromBase64, decrypt)
+		require.ErrorContains(t, err, `integration with UID "secondary" does not exist`)
+		recCfg.Failover = []string{"primary", ""}
+		_, err = BuildReceiverConfiguration(context.Background(), recCfg, DecodeSecretsFromBase64, decrypt)
+		require.ErrorContains(t, err, "integrations must be referenced by a non-empty UID")
+	})
+	t.Run("should validate saved receivers", func(t *testing.T) {
+		recCfg := &APIReceiver{ConfigReceiver: ConfigReceiver{Name: "test-receiver"}}
+		for notifierType, cfg := range AllKnownConfigsForTesting {
+			recCfg.Integrations = append(recCfg.Integrations, cfg.GetRawNotifierConfig(notifierType))
+		}
+		_, err := BuildReceiverConfiguration(WithSavedReceiverValidation(context.Background()), recCfg, DecodeSecretsFromBase64, decrypt)
+		require.NoError(t, err)
+
+		newReceiver := func(notifierType string, settings string) *APIReceiver {
+			integration := AllKnownConfigsForTesting[notifierType].GetRawNotifierConfig(notifierType)
+			integration.Settings = json.RawMessage(settings)
+			integration.SecureSettings = nil
+			recCfg := &APIReceiver{ConfigReceiver: ConfigReceiver{Name: "test-receiver"}}
+			recCfg.Integrations = append(recCfg.Integrations, integration)
+			return recCfg
+		}
+		for _, tc := range []struct {
+			notifierType string
+			settings     string
+			expErr       string
+		}{
+			{
+				notifierType: "discord",
+				settings:     `{"url": "https://discord.com/channels/123/456"}`,
+				expErr:       "invalid webhook url: must be in the form https://discord.com/api/webhooks/<id>/<token>",
+			},
+			{
+				notifierType: "pagerduty",
+				settings:     `{"integrationKey": "0123456789abcdef"}`,
+				expErr:       "invalid integration key: must be 32 characters long",
+			},
+		} {
+			// Existing receivers that do not pass the checks are still loaded.
+			_, err = BuildReceiverConfiguration(context.Background(), newReceiver(tc.notifierType, tc.settings), DecodeSecretsFromBase64, decrypt)
+			require.NoError(t, err)
+			_, err = BuildReceiverConfiguration(WithSavedReceiverValidation(context.Background()), newReceiver(tc.notifierType, tc.settings), DecodeSecretsFromBase64, decrypt)
+			require.ErrorAs(t, err, &IntegrationValidationError{})
+			require.ErrorContains(t, err, tc.expErr)
+		}
+	})
+	t.Run("should fail if retry policy is invalid", func(t *testing.T) {
+		recCfg := &APIReceiver{ConfigReceiver: ConfigReceiver{Name: "test-receiver"}}
+		integration := AllKnownConfigsForTesting["webhook"].GetRawNotifierConfig("webhook")
+		integration.RetryPolicy = &RetryPolicy{Jitter: 2}
+		recCfg.Integrations = append(recCfg.Integrations, integration)
+
+		_, err := BuildReceiverConfiguration(context.Background(), recCfg, DecodeSecretsFromBase64, decrypt)
+		require.ErrorContains(t, err, "invalid retry policy")
+	})
 }
 
 func getMetadata[T any](notifiers []*NotifierConfig[T]) []receivers.Metadata {
@@ -0,0 +1,36 @@
+package notify
+
+import (
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// resolveTimeoutCallback counts the alerts resolved by the resolve timeout that are deleted from the store without
+// being received again. It calls the AlertStoreCallback of the configuration, if there is one.
+type resolveTimeoutCallback struct {
+	mem.AlertStoreCallback
+	expired prometheus.Counter
+}
+
+func (c *resolveTimeoutCallback) PreStore(alert *types.Alert, existing bool) error {
+	if c.AlertStoreCallback == nil {
+		return nil
+	}
+	return c.AlertStoreCallback.PreStore(alert, existing)
+}
+
+func (c *resolveTimeoutCallback) PostStore(alert *types.Alert, existing bool) {
+	if c.AlertStoreCallback != nil {
+		c.AlertStoreCallback.PostStore(alert, existing)
+	}
+}
+
+func (c *resolveTimeoutCallback) PostDelete(alert *types.Alert) {
+	if alert.Timeout {
+		c.expired.Inc()
+	}
+	if c.AlertStoreCallback != nil {
+		c.AlertStoreCallback.PostDelete(alert)
+	}
+}
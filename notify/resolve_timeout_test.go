@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+)
+
+// resolveTimeoutConfig is a Configuration with a resolve timeout.
+type resolveTimeoutConfig struct {
+	previewConfig
+	resolveTimeout time.Duration
+}
+
+func (c *resolveTimeoutConfig) ResolveTimeout() time.Duration { return c.resolveTimeout }
+
+func TestResolveTimeout(t *testing.T) {
+	t.Run("the resolve timeout of the Alertmanager must not be negative", func(t *testing.T) {
+		cfg := &GrafanaAlertmanagerConfig{
+			Silences:       newFakeMaintanenceOptions(t),
+			Nflog:          newFakeMaintanenceOptions(t),
+			ResolveTimeout: -time.Minute,
+		}
+		require.EqualError(t, cfg.Validate(), "resolve timeout must not be negative")
+	})
+
+	t.Run("the resolve timeout of the Alertmanager is the default of its configurations", func(t *testing.T) {
+		m := NewGrafanaAlertmanagerMetrics(prometheus.NewPedanticRegistry(), log.NewNopLogger())
+		am, err := NewGrafanaAlertmanager("org", 1, &GrafanaAlertmanagerConfig{
+			Silences:       newFakeMaintanenceOptions(t),
+			Nflog:          newFakeMaintanenceOptions(t),
+			ResolveTimeout: time.Hour,
+		}, &NilPeer{}, log.NewNopLogger(), m)
+		require.NoError(t, err)
+		require.Equal(t, time.Hour, am.ResolveTimeout())
+	})
+
+	cfg := func(timeout time.Duration) *resolveTimeoutConfig {
+		return &resolveTimeoutConfig{
+			previewConfig: previewConfig{
+				grafanaRoutingTreeConfig: grafanaRoutingTreeConfig{route: &definition.Route{Receiver: "default"}},
+				receivers:                []string{"default"},
+			},
+			resolveTimeout: timeout,
+		}
+	}
+	// setup returns an Alertmanager with the configuration and an alert without an end time, once the alert is
+	// dispatched.
+	setup := func(t *testing.T, timeout time.Duration) (*GrafanaAlertmanager, *types.Alert) {
+		am, _ := setupAMTest(t)
+		require.Equal(t, DefaultResolveTimeout, am.ResolveTimeout())
+		require.NoError(t, am.ApplyConfig(cfg(timeout)))
+		require.NoError(t, am.PutAlerts(amv2.PostableAlerts{{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "test"}}}}))
+		require.Eventually(t, func() bool {
+			groups, err := am.GetAlertGroupSnapshots(AlertGroupsFilter{Active: true})
+			require.NoError(t, err)
+			return len(groups) == 1
+		}, time.Second, 10*time.Millisecond)
+		alert, err := am.alerts.Get(model.LabelSet{"alertname": "test"}.Fingerprint())
+		require.NoError(t, err)
+		require.True(t, alert.Timeout)
+		return am, alert
+	}
+
+	t.Run("configurations without a resolve timeout use the resolve timeout of the Alertmanager", func(t *testing.T) {
+		am, alert := setup(t, 0)
+		require.Equal(t, DefaultResolveTimeout, am.ResolveTimeout())
+		require.Equal(t, alert.UpdatedAt.Add(DefaultResolveTimeout), alert.EndsAt)
+	})
+
+	am, alert := setup(t, 30*time.Minute)
+	require.Equal(t, 30*time.Minute, am.ResolveTimeout())
+	require.Equal(t, alert.UpdatedAt.Add(30*time.Minute), alert.EndsAt)
+
+	// Invalid configurations are not applied.
+	require.EqualError(t, am.ApplyConfig(cfg(-time.Minute)), "invalid resolve_timeout: resolve timeout must not be negative")
+	require.Equal(t, 30*time.Minute, am.ResolveTimeout())
+
+	t.Run("alerts resolved by the resolve timeout are counted", func(t *testing.T) {
+		expired := am.Metrics.resolveTimeoutExpired.WithLabelValues(am.tenantString())
+		now := time.Now()
+		require.NoError(t, am.alerts.Put(&types.Alert{
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "expired"},
+				StartsAt: now.Add(-time.Hour),
+				EndsAt:   now.Add(-time.Minute),
+			},
+			UpdatedAt: now.Add(-time.Hour),
+			Timeout:   true,
+		}))
+
+		// Alerts that are received again after they were resolved are counted when they are received.
+		require.NoError(t, am.PutAlerts(amv2.PostableAlerts{{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "expired"}}}}))
+		require.Equal(t, 1.0, testutil.ToFloat64(expired))
+		require.NoError(t, am.PutAlerts(amv2.PostableAlerts{{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "expired"}}}}))
+		require.Equal(t, 1.0, testutil.ToFloat64(expired))
+
+		// Other alerts are counted when they are deleted.
+		callback := &resolveTimeoutCallback{expired: expired}
+		callback.PostDelete(&types.Alert{Timeout: true})
+		callback.PostDelete(&types.Alert{})
+		require.Equal(t, 2.0, testutil.ToFloat64(expired))
+	})
+}
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alerting/definition"
+)
+
+// RouteMatch is a route of the routing tree that an alert matches, with its options after inheritance from its parents.
+type RouteMatch struct {
+	// Path is the path of the route in the routing tree, such as "route.routes[0]".
+	Path     string `json:"path"`
+	Receiver string `json:"receiver"`
+	// GroupBy are the labels the alerts of the route are grouped by. If GroupByAll is true, they are grouped by all labels.
+	GroupBy    []string `json:"groupBy"`
+	GroupByAll bool     `json:"groupByAll"`
+	// GroupLabels are the labels of the group the alert would be part of.
+	GroupLabels         model.LabelSet `json:"groupLabels"`
+	GroupWait           time.Duration  `json:"groupWait"`
+	GroupInterval       time.Duration  `json:"groupInterval"`
+	RepeatInterval      time.Duration  `json:"repeatInterval"`
+	MuteTimeIntervals   []string       `json:"muteTimeIntervals"`
+	ActiveTimeIntervals []string       `json:"activeTimeIntervals"`
+	Continue            bool           `json:"continue"`
+	NotifyOnce          bool           `json:"notifyOnce"`
+	// OrderedNotifications is true if the notifications of the alert groups of the route are ordered.
+	OrderedNotifications bool `json:"orderedNotifications"`
+}
+
+// TestRoute returns the routes of the routing tree of the configuration that an alert with the labels would be
+// routed to, in the order the dispatcher would notify them. It evaluates the routing tree only, silences,
+// inhibitions and time intervals are not taken into account. The configuration is expected to be valid.
+func TestRoute(cfg *definition.PostableApiAlertingConfig, labels model.LabelSet) ([]RouteMatch, error) {
+	if cfg == nil || cfg.Route == nil {
+		return nil, errors.New("configuration has no routing tree")
+	}
+	if err := labels.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid labels: %w", err)
+	}
+
+	route := dispatch.NewRoute(cfg.Route.AsAMRoute(), nil)
+	routes := make(map[*dispatch.Route]routeInfo)
+	indexRoutes("route", cfg.Route, route, routes)
+
+	matched := route.Match(labels)
+	res := make([]RouteMatch, 0, len(matched))
+	for _, r := range matched {
+		info := routes[r]
+		m := RouteMatch{
+			Path:                 info.path,
+			Receiver:             r.RouteOpts.Receiver,
+			GroupByAll:           r.RouteOpts.GroupByAll,
+			GroupLabels:          groupLabels(labels, r),
+			GroupWait:            r.RouteOpts.GroupWait,
+			GroupInterval:        r.RouteOpts.GroupInterval,
+			RepeatInterval:       r.RouteOpts.RepeatInterval,
+			MuteTimeIntervals:    r.RouteOpts.MuteTimeIntervals,
+			ActiveTimeIntervals:  r.RouteOpts.ActiveTimeIntervals,
+			Continue:             r.Continue,
+			NotifyOnce:           info.notifyOnce,
+			OrderedNotifications: info.orderedNotifications,
+		}
+		if !r.RouteOpts.GroupByAll {
+			for ln := range r.RouteOpts.GroupBy {
+				m.GroupBy = append(m.GroupBy, string(ln))
+			}
+			sort.Strings(m.GroupBy)
+		}
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+type routeInfo struct {
+	path                 string
+	notifyOnce           bool
+	orderedNotifications bool
+}
+
+// indexRoutes adds the information of the route r, built from the Grafana route gr, and of its children to routes.
+func indexRoutes(path string, gr *definition.Route, r *dispatch.Route, routes map[*dispatch.Route]routeInfo) {
+	routes[r] = routeInfo{path: path, notifyOnce: gr.NotifyOnce, orderedNotifications: gr.OrderedNotifications}
+	for i := range gr.Routes {
+		indexRoutes(fmt.Sprintf("%s.routes[%d]", path, i), gr.Routes[i], r.Routes[i], routes)
+	}
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+)
+
+func TestTestRoute(t *testing.T) {
+	cfg, err := definition.Load([]byte(`
+route:
+  receiver: default
+  group_by: [alertname]
+  routes:
+  - receiver: team-a
+    group_by: [alertname, cluster]
+    group_wait: 10s
+    continue: true
+    object_matchers:
+    - [team, =, a]
+  - receiver: team-a-critical
+    notify_once: true
+    mute_time_intervals: [weekends]
+    object_matchers:
+    - [team, =, a]
+    - [severity, =, critical]
+    routes:
+    - receiver: all
+      group_by: ['...']
+      object_matchers:
+      - [cluster, =, prod]
+time_intervals:
+- name: weekends
+receivers:
+- name: default
+- name: team-a
+- name: team-a-critical
+- name: all
+`))
+	require.NoError(t, err)
+
+	t.Run("alerts not matching any route use the root route", func(t *testing.T) {
+		matches, err := TestRoute(cfg, model.LabelSet{"alertname": "test", "team": "b"})
+		require.NoError(t, err)
+		require.Equal(t, []RouteMatch{{
+			Path:           "route",
+			Receiver:       "default",
+			GroupBy:        []string{"alertname"},
+			GroupLabels:    model.LabelSet{"alertname": "test"},
+			GroupWait:      30 * time.Second,
+			GroupInterval:  5 * time.Minute,
+			RepeatInterval: 4 * time.Hour,
+		}}, matches)
+	})
+
+	t.Run("alerts match all routes until one does not continue", func(t *testing.T) {
+		matches, err := TestRoute(cfg, model.LabelSet{"alertname": "test", "team": "a", "severity": "critical", "cluster": "dev"})
+		require.NoError(t, err)
+		require.Equal(t, []RouteMatch{{
+			Path:           "route.routes[0]",
+			Receiver:       "team-a",
+			GroupBy:        []string{"alertname", "cluster"},
+			GroupLabels:    model.LabelSet{"alertname": "test", "cluster": "dev"},
+			GroupWait:      10 * time.Second,
+			GroupInterval:  5 * time.Minute,
+			RepeatInterval: 4 * time.Hour,
+			Continue:       true,
+		}, {
+			Path:              "route.routes[1]",
+			Receiver:          "team-a-critical",
+			GroupBy:           []string{"alertname"},
+			GroupLabels:       model.LabelSet{"alertname": "test"},
+			GroupWait:         30 * time.Second,
+			GroupInterval:     5 * time.Minute,
+			RepeatInterval:    4 * time.Hour,
+			MuteTimeIntervals: []string{"weekends"},
+			NotifyOnce:        true,
+		}}, matches)
+	})
+
+	t.Run("nested routes inherit the timing options of their parents", func(t *testing.T) {
+		labels := model.LabelSet{"alertname": "test", "team": "a", "severity": "critical", "cluster": "prod"}
+		matches, err := TestRoute(cfg, labels)
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+		require.Equal(t, RouteMatch{
+			Path:           "route.routes[1].routes[0]",
+			Receiver:       "all",
+			GroupByAll:     true,
+			GroupLabels:    labels,
+			GroupWait:      30 * time.Second,
+			GroupInterval:  5 * time.Minute,
+			RepeatInterval: 4 * time.Hour,
+		}, matches[1])
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := TestRoute(&definition.PostableApiAlertingConfig{}, model.LabelSet{})
+		require.EqualError(t, err, "configuration has no routing tree")
+
+		_, err = TestRoute(cfg, model.LabelSet{"": "test"})
+		require.ErrorContains(t, err, "invalid labels")
+	})
+}
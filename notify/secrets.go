@@ -0,0 +1,167 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretRefPrefix is the prefix of the values of settings that reference a secret instead of containing it.
+const SecretRefPrefix = "$ref:"
+
+// DefaultSecretCacheTTL is for how long resolved secrets are cached if the resolver does not say when they expire.
+const DefaultSecretCacheTTL = 5 * time.Minute
+
+// SecretRef references a secret in an external secret store, such as Vault or a KMS. It is written as
+// $ref:<provider>:<path>#<key>, where the key is optional, for example $ref:vault:secret/data/slack#token.
+type SecretRef struct {
+	Provider string
+	Path     string
+	Key      string
+}
+
+func (r SecretRef) String() string {
+	if r.Key == "" {
+		return fmt.Sprintf("%s%s:%s", SecretRefPrefix, r.Provider, r.Path)
+	}
+	return fmt.Sprintf("%s%s:%s#%s", SecretRefPrefix, r.Provider, r.Path, r.Key)
+}
+
+// IsSecretRef returns true if the value is a reference to a secret.
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, SecretRefPrefix)
+}
+
+// ParseSecretRef parses a reference to a secret.
+func ParseSecretRef(value string) (SecretRef, error) {
+	if !IsSecretRef(value) {
+		return SecretRef{}, errors.New("secret reference must start with " + SecretRefPrefix)
+	}
+	provider, rest, ok := strings.Cut(strings.TrimPrefix(value, SecretRefPrefix), ":")
+	if !ok || provider == "" {
+		return SecretRef{}, errors.New("secret reference must specify a provider")
+	}
+	path, key, _ := strings.Cut(rest, "#")
+	if path == "" {
+		return SecretRef{}, errors.New("secret reference must specify a path")
+	}
+	return SecretRef{Provider: provider, Path: path, Key: key}, nil
+}
+
+// Secret is a secret resolved from a secret store.
+type Secret struct {
+	Value string
+	// ExpiresAt is when the secret is rotated, if known. The secret is resolved again after it expires.
+	ExpiresAt time.Time
+}
+
+// SecretResolver resolves references to secrets of a secret store.
+type SecretResolver interface {
+	ResolveSecret(ctx context.Context, ref SecretRef) (Secret, error)
+}
+
+type cachedSecret struct {
+	secret    Secret
+	expiresAt time.Time
+}
+
+// SecretReferenceResolver resolves references to secrets with the resolvers of their providers and caches them,
+// so that the secrets are not stored in the configuration of the Alertmanager.
+type SecretReferenceResolver struct {
+	resolvers map[string]SecretResolver
+	ttl       time.Duration
+	now       func() time.Time
+
+	mtx   sync.Mutex
+	cache map[SecretRef]cachedSecret
+}
+
+// NewSecretReferenceResolver returns a SecretReferenceResolver with the resolvers of each provider. Resolved
+// secrets are cached until they expire, and for no longer than the TTL. If the TTL is 0, DefaultSecretCacheTTL is used.
+func NewSecretReferenceResolver(resolvers map[string]SecretResolver, ttl time.Duration) *SecretReferenceResolver {
+	if ttl == 0 {
+		ttl = DefaultSecretCacheTTL
+	}
+	return &SecretReferenceResolver{
+		resolvers: resolvers,
+		ttl:       ttl,
+		now:       time.Now,
+		cache:     make(map[SecretRef]cachedSecret),
+	}
+}
+
+// Resolve returns the value of the secret referenced by the value.
+func (r *SecretReferenceResolver) Resolve(ctx context.Context, value string) (string, error) {
+	ref, err := ParseSecretRef(value)
+	if err != nil {
+		return "", err
+	}
+
+	now := r.now()
+	r.mtx.Lock()
+	cached, ok := r.cache[ref]
+	r.mtx.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.secret.Value, nil
+	}
+
+	resolver, ok := r.resolvers[ref.Provider]
+	if !ok {
+		return "", fmt.Errorf("no secret resolver for provider %q", ref.Provider)
+	}
+	secret, err := resolver.ResolveSecret(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s: %w", ref, err)
+	}
+
+	expiresAt := now.Add(r.ttl)
+	if !secret.ExpiresAt.IsZero() && secret.ExpiresAt.Before(expiresAt) {
+		expiresAt = secret.ExpiresAt
+	}
+	r.mtx.Lock()
+	r.cache[ref] = cachedSecret{secret: secret, expiresAt: expiresAt}
+	r.mtx.Unlock()
+	return secret.Value, nil
+}
+
+// Invalidate removes the secret of the reference from the cache, such as when the secret store reports that
+// the secret was rotated.
+func (r *SecretReferenceResolver) Invalidate(ref SecretRef) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	delete(r.cache, ref)
+}
+
+// NextRotation returns when the first of the secrets that were resolved expires, or the zero time if no secret
+// expires. Receivers hold the secrets they were built with, so the configuration must be applied again after
+// the rotation of a secret for the receivers to use its new value.
+func (r *SecretReferenceResolver) NextRotation() time.Time {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	var next time.Time
+	for _, c := range r.cache {
+		if c.secret.ExpiresAt.IsZero() {
+			continue
+		}
+		if next.IsZero() || c.secret.ExpiresAt.Before(next) {
+			next = c.secret.ExpiresAt
+		}
+	}
+	return next
+}
+
+type secretResolverKey struct{}
+
+// WithSecretResolver returns a context with the resolver of the secret references of the receivers built by
+// BuildReceiverConfiguration.
+func WithSecretResolver(ctx context.Context, r *SecretReferenceResolver) context.Context {
+	return context.WithValue(ctx, secretResolverKey{}, r)
+}
+
+func secretResolverFromContext(ctx context.Context) *SecretReferenceResolver {
+	r, _ := ctx.Value(secretResolverKey{}).(*SecretReferenceResolver)
+	return r
+}
@@ -0,0 +1,114 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSecretResolver struct {
+	secrets map[string]Secret
+	calls   int
+}
+
+func (r *fakeSecretResolver) ResolveSecret(_ context.Context, ref SecretRef) (Secret, error) {
+	r.calls++
+	s, ok := r.secrets[ref.Path+"#"+ref.Key]
+	if !ok {
+		return Secret{}, errors.New("secret not found")
+	}
+	return s, nil
+}
+
+func TestParseSecretRef(t *testing.T) {
+	ref, err := ParseSecretRef("$ref:vault:secret/data/slack#token")
+	require.NoError(t, err)
+	require.Equal(t, SecretRef{Provider: "vault", Path: "secret/data/slack", Key: "token"}, ref)
+	require.Equal(t, "$ref:vault:secret/data/slack#token", ref.String())
+
+	ref, err = ParseSecretRef("$ref:kms:projects/p/secrets/s")
+	require.NoError(t, err)
+	require.Equal(t, SecretRef{Provider: "kms", Path: "projects/p/secrets/s"}, ref)
+	require.Equal(t, "$ref:kms:projects/p/secrets/s", ref.String())
+
+	_, err = ParseSecretRef("secret")
+	require.EqualError(t, err, "secret reference must start with $ref:")
+	_, err = ParseSecretRef("$ref:vault")
+	require.EqualError(t, err, "secret reference must specify a provider")
+	_, err = ParseSecretRef("$ref:vault:#key")
+	require.EqualError(t, err, "secret reference must specify a path")
+}
+
+func TestSecretReferenceResolver(t *testing.T) {
+	now := time.Now()
+	vault := &fakeSecretResolver{secrets: map[string]Secret{
+		"slack#token":   {Value: "slack-token"},
+		"webhook#token": {Value: "webhook-token", ExpiresAt: now.Add(time.Minute)},
+	}}
+	r := NewSecretReferenceResolver(map[string]SecretResolver{"vault": vault}, time.Hour)
+	r.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	v, err := r.Resolve(ctx, "$ref:vault:slack#token")
+	require.NoError(t, err)
+	require.Equal(t, "slack-token", v)
+	_, err = r.Resolve(ctx, "$ref:vault:webhook#token")
+	require.NoError(t, err)
+	require.Equal(t, 2, vault.calls)
+	require.Equal(t, now.Add(time.Minute), r.NextRotation())
+
+	// Secrets are cached until they expire.
+	now = now.Add(2 * time.Minute)
+	_, err = r.Resolve(ctx, "$ref:vault:slack#token")
+	require.NoError(t, err)
+	require.Equal(t, 2, vault.calls)
+	vault.secrets["webhook#token"] = Secret{Value: "rotated-token"}
+	v, err = r.Resolve(ctx, "$ref:vault:webhook#token")
+	require.NoError(t, err)
+	require.Equal(t, "rotated-token", v)
+	require.Equal(t, 3, vault.calls)
+	require.True(t, r.NextRotation().IsZero())
+
+	// Invalidated secrets are resolved again.
+	r.Invalidate(SecretRef{Provider: "vault", Path: "slack", Key: "token"})
+	_, err = r.Resolve(ctx, "$ref:vault:slack#token")
+	require.NoError(t, err)
+	require.Equal(t, 4, vault.calls)
+
+	_, err = r.Resolve(ctx, "$ref:kms:slack#token")
+	require.EqualError(t, err, `no secret resolver for provider "kms"`)
+	_, err = r.Resolve(ctx, "$ref:vault:missing#token")
+	require.EqualError(t, err, "failed to resolve secret $ref:vault:missing#token: secret not found")
+}
+
+func TestBuildReceiverConfiguration_SecretReferences(t *testing.T) {
+	vault := &fakeSecretResolver{secrets: map[string]Secret{
+		"slack#url": {Value: "https://hooks.slack.com/services/secret"},
+	}}
+	ctx := WithSecretResolver(context.Background(), NewSecretReferenceResolver(map[string]SecretResolver{"vault": vault}, 0))
+
+	newReceiver := func(ref string) *APIReceiver {
+		return &APIReceiver{
+			ConfigReceiver: config.Receiver{Name: "slack"},
+			GrafanaIntegrations: GrafanaIntegrations{Integrations: []*GrafanaIntegrationConfig{{
+				UID:            "uid",
+				Name:           "slack",
+				Type:           "slack",
+				Settings:       []byte(`{"recipient": "#alerts"}`),
+				SecureSettings: map[string]string{"url": ref},
+			}}},
+		}
+	}
+
+	cfg, err := BuildReceiverConfiguration(ctx, newReceiver("$ref:vault:slack#url"), NoopDecode, NoopDecrypt)
+	require.NoError(t, err)
+	require.Len(t, cfg.SlackConfigs, 1)
+	require.Equal(t, "https://hooks.slack.com/services/secret", cfg.SlackConfigs[0].Settings.URL)
+
+	_, err = BuildReceiverConfiguration(ctx, newReceiver("$ref:vault:missing#url"), NoopDecode, NoopDecrypt)
+	require.ErrorContains(t, err, "invalid secret reference in url: failed to resolve secret $ref:vault:missing#url: secret not found")
+}
@@ -0,0 +1,225 @@
+package notify
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/silence/silencepb"
+)
+
+// DefaultDeletedSilenceRetention is for how long deleted silences can be restored by default.
+const DefaultDeletedSilenceRetention = 24 * time.Hour
+
+type SilenceAuditAction string
+
+const (
+	SilenceAuditDeleted  SilenceAuditAction = "deleted"
+	SilenceAuditRestored SilenceAuditAction = "restored"
+)
+
+// SilenceAuditEvent is an entry of the audit trail of the deleted silences, see GrafanaAlertmanager.SilenceAuditTrail.
+type SilenceAuditEvent struct {
+	Action    SilenceAuditAction `json:"action"`
+	SilenceID string             `json:"silenceId"`
+	// RestoredID is the ID of the silence that restored the deleted silence. Expired silences cannot be updated,
+	// so restored silences have a new ID.
+	RestoredID string    `json:"restoredId,omitempty"`
+	Actor      string    `json:"actor,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// deletedSilence is a silence as it was before it was deleted, and whether it was restored.
+type deletedSilence struct {
+	Silence    *silencepb.Silence `json:"silence"`
+	DeletedAt  time.Time          `json:"deletedAt"`
+	DeletedBy  string             `json:"deletedBy,omitempty"`
+	RestoredAt time.Time          `json:"restoredAt,omitempty"`
+	RestoredBy string             `json:"restoredBy,omitempty"`
+	RestoredID string             `json:"restoredId,omitempty"`
+}
+
+func (d *deletedSilence) restored() bool {
+	return !d.RestoredAt.IsZero()
+}
+
+func (d *deletedSilence) updatedAt() time.Time {
+	if d.restored() {
+		return d.RestoredAt
+	}
+	return d.DeletedAt
+}
+
+// deletedSilences are the silences deleted in the cluster within the retention, gossiped so that any member can
+// restore them. Deleted silences are expired like before, so that they stop muting alerts at once, and are kept
+// as they were before they were deleted, because expired silences cannot be updated. They are not part of the
+// snapshot of the silences, but have their own if GrafanaAlertmanagerConfig.DeletedSilences is set. Otherwise, they
+// are lost when all the members of the cluster restart.
+type deletedSilences struct {
+	retention time.Duration
+	now       func() time.Time
+
+	mtx       sync.Mutex
+	deleted   map[string]*deletedSilence
+	broadcast func([]byte)
+}
+
+func newDeletedSilences(retention time.Duration, now func() time.Time) *deletedSilences {
+	return &deletedSilences{
+		retention: retention,
+		now:       now,
+		deleted:   make(map[string]*deletedSilence),
+		broadcast: func([]byte) {},
+	}
+}
+
+// SetBroadcast sets the function that gossips the deleted silences to the other members of the cluster.
+func (d *deletedSilences) SetBroadcast(f func([]byte)) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.broadcast = f
+}
+
+// delete records the silence as it was before it was deleted by expire, and gossips it.
+func (d *deletedSilences) delete(sil *silencepb.Silence, deletedBy string, expire func() error) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if err := expire(); err != nil {
+		return err
+	}
+	now := d.now()
+	d.gc(now)
+	rec := &deletedSilence{Silence: sil, DeletedAt: now, DeletedBy: deletedBy}
+	d.deleted[sil.Id] = rec
+	d.gossip(rec)
+	return nil
+}
+
+// restore restores the deleted silence with create, which returns the ID of the restored silence, and gossips it.
+// It returns false if the silence is not deleted within the retention or was restored already.
+func (d *deletedSilences) restore(id, restoredBy string, create func(*silencepb.Silence) (string, error)) (string, bool, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	now := d.now()
+	d.gc(now)
+	rec, ok := d.deleted[id]
+	if !ok || rec.restored() {
+		return "", false, nil
+	}
+	restoredID, err := create(rec.Silence)
+	if err != nil {
+		return "", true, err
+	}
+	restored := *rec
+	restored.RestoredAt, restored.RestoredBy, restored.RestoredID = now, restoredBy, restoredID
+	d.deleted[id] = &restored
+	d.gossip(&restored)
+	return restoredID, true, nil
+}
+
+// gossip must be called with the lock held.
+func (d *deletedSilences) gossip(rec *deletedSilence) {
+	b, err := json.Marshal([]*deletedSilence{rec})
+	if err != nil {
+		return
+	}
+	d.broadcast(b)
+}
+
+// list returns the silences that are deleted and not restored.
+func (d *deletedSilences) list() []*silencepb.Silence {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.gc(d.now())
+	res := make([]*silencepb.Silence, 0, len(d.deleted))
+	for _, rec := range d.deleted {
+		if !rec.restored() {
+			res = append(res, rec.Silence)
+		}
+	}
+	return res
+}
+
+// isDeleted returns whether the silence is deleted and not restored.
+func (d *deletedSilences) isDeleted(id string) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	rec, ok := d.deleted[id]
+	return ok && !rec.restored()
+}
+
+// auditTrail returns the deletions and restorations of the silences within the retention, ordered by time.
+func (d *deletedSilences) auditTrail() []SilenceAuditEvent {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.gc(d.now())
+	res := make([]SilenceAuditEvent, 0, len(d.deleted))
+	for id, rec := range d.deleted {
+		res = append(res, SilenceAuditEvent{Action: SilenceAuditDeleted, SilenceID: id, Actor: rec.DeletedBy, Time: rec.DeletedAt})
+		if rec.restored() {
+			res = append(res, SilenceAuditEvent{
+				Action:     SilenceAuditRestored,
+				SilenceID:  id,
+				RestoredID: rec.RestoredID,
+				Actor:      rec.RestoredBy,
+				Time:       rec.RestoredAt,
+			})
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if !res[i].Time.Equal(res[j].Time) {
+			return res[i].Time.Before(res[j].Time)
+		}
+		return res[i].SilenceID < res[j].SilenceID
+	})
+	return res
+}
+
+// GC deletes the silences that were deleted before the retention.
+func (d *deletedSilences) GC() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.gc(d.now())
+}
+
+func (d *deletedSilences) gc(now time.Time) {
+	for id, rec := range d.deleted {
+		if now.Sub(rec.DeletedAt) > d.retention {
+			delete(d.deleted, id)
+		}
+	}
+}
+
+// MarshalBinary implements the cluster.State interface.
+func (d *deletedSilences) MarshalBinary() ([]byte, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.gc(d.now())
+	recs := make([]*deletedSilence, 0, len(d.deleted))
+	for _, rec := range d.deleted {
+		recs = append(recs, rec)
+	}
+	return json.Marshal(recs)
+}
+
+// Merge implements the cluster.State interface. The latest change of each deleted silence wins, and silences that
+// were deleted before the retention are ignored.
+func (d *deletedSilences) Merge(b []byte) error {
+	var recs []*deletedSilence
+	if err := json.Unmarshal(b, &recs); err != nil {
+		return err
+	}
+	now := d.now()
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	for _, rec := range recs {
+		if rec.Silence == nil || now.Sub(rec.DeletedAt) > d.retention {
+			continue
+		}
+		if prev, ok := d.deleted[rec.Silence.Id]; !ok || rec.updatedAt().After(prev.updatedAt()) {
+			d.deleted[rec.Silence.Id] = rec
+		}
+	}
+	return nil
+}
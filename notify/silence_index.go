@@ -0,0 +1,326 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// indexedSilence is a silence together with its compiled matchers.
+type indexedSilence struct {
+	silence  *silencepb.Silence
+	matchers labels.Matchers
+	// anchor is the equality matcher the silence is indexed by. It is nil if the silence
+	// has no equality matcher with a non-empty value and must be checked for every alert.
+	anchor *labels.Matcher
+}
+
+// silenceIndex keeps an index of silences by the label name and value of one of their
+// equality matchers, so that only the silences that can possibly match an alert are evaluated
+// instead of all of them. Silences without such a matcher (for example, only regular expressions
+// or negative matchers) are kept in a fallback set that is evaluated for every alert.
+//
+// All changes to the silences that originate in this Alertmanager (the API) or in its peers
+// (gossip) go through the index, which updates the affected silences only. The index tracks the
+// version of the silences it was built from and rebuilds itself from scratch if it notices
+// that the silences changed without it.
+type silenceIndex struct {
+	silences *silence.Silences
+	logger   log.Logger
+
+	mtx      sync.RWMutex
+	entries  map[string]*indexedSilence
+	byLabel  map[model.LabelName]map[model.LabelValue]map[string]struct{}
+	fallback map[string]struct{}
+	version  int
+}
+
+func newSilenceIndex(silences *silence.Silences, logger log.Logger) (*silenceIndex, error) {
+	idx := &silenceIndex{
+		silences: silences,
+		logger:   logger,
+	}
+	if err := idx.Rebuild(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Rebuild discards the index and builds it again from all silences.
+func (idx *silenceIndex) Rebuild() error {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	return idx.rebuild()
+}
+
+func (idx *silenceIndex) rebuild() error {
+	sils, version, err := idx.silences.Query()
+	if err != nil {
+		return err
+	}
+	idx.entries = make(map[string]*indexedSilence, len(sils))
+	idx.byLabel = map[model.LabelName]map[model.LabelValue]map[string]struct{}{}
+	idx.fallback = map[string]struct{}{}
+	for _, sil := range sils {
+		idx.put(sil)
+	}
+	idx.version = version
+	return nil
+}
+
+// Set creates or updates a silence and updates the index accordingly.
+func (idx *silenceIndex) Set(sil *silencepb.Silence) error {
+	prevID := sil.Id
+	return idx.apply(func() error { return idx.silences.Set(sil) }, func() []string { return []string{prevID, sil.Id} })
+}
+
+// Upsert creates or updates a silence with a pre-set ID and updates the index accordingly.
+func (idx *silenceIndex) Upsert(sil *silencepb.Silence) error {
+	prevID := sil.Id
+	return idx.apply(func() error { return idx.silences.Upsert(sil) }, func() []string { return []string{prevID, sil.Id} })
+}
+
+// Expire expires the silence with the given ID and updates the index accordingly.
+func (idx *silenceIndex) Expire(id string) error {
+	return idx.apply(func() error { return idx.silences.Expire(id) }, func() []string { return []string{id} })
+}
+
+// GC removes expired silences past their retention and rebuilds the index if any were removed.
+func (idx *silenceIndex) GC() (int, error) {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	n, err := idx.silences.GC()
+	if n > 0 {
+		// GC does not change the version of the silences, so the index must be rebuilt explicitly.
+		if rerr := idx.rebuild(); rerr != nil {
+			return n, rerr
+		}
+	}
+	return n, err
+}
+
+// MarshalBinary implements cluster.State.
+func (idx *silenceIndex) MarshalBinary() ([]byte, error) {
+	return idx.silences.MarshalBinary()
+}
+
+// Merge implements cluster.State. It merges the silences received from a peer and
+// updates the index with the silences contained in the message.
+func (idx *silenceIndex) Merge(b []byte) error {
+	return idx.apply(func() error { return idx.silences.Merge(b) }, func() []string {
+		st, err := DecodeState(bytes.NewReader(b))
+		if err != nil {
+			// Merge would have failed already.
+			return nil
+		}
+		ids := make([]string, 0, len(st))
+		for id := range st {
+			ids = append(ids, id)
+		}
+		return ids
+	})
+}
+
+// apply runs the change to the silences and then updates the index entries of the silences
+// returned by affected. If the silences changed without the index since the last update, the
+// index is rebuilt instead.
+func (idx *silenceIndex) apply(change func() error, affected func() []string) error {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+
+	inSync := idx.silences.Version() == idx.version
+	if err := change(); err != nil {
+		return err
+	}
+	if !inSync {
+		return idx.rebuild()
+	}
+
+	ids := make([]string, 0, 2)
+	for _, id := range affected() {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		idx.version = idx.silences.Version()
+		return nil
+	}
+
+	sils, version, err := idx.silences.Query(silence.QIDs(ids...))
+	if err != nil {
+		// Force a rebuild on the next lookup.
+		idx.version = -1
+		return nil
+	}
+	for _, id := range ids {
+		idx.remove(id)
+	}
+	for _, sil := range sils {
+		idx.put(sil)
+	}
+	idx.version = version
+	return nil
+}
+
+// put adds the silence to the index. Silences with invalid matchers are not indexed,
+// in the same way the silencer of the Alertmanager ignores them.
+func (idx *silenceIndex) put(sil *silencepb.Silence) {
+	ms, err := silenceMatchers(sil)
+	if err != nil {
+		level.Warn(idx.logger).Log("msg", "Failed to index silence", "id", sil.Id, "err", err)
+		return
+	}
+	e := &indexedSilence{silence: sil, matchers: ms}
+	for _, m := range ms {
+		if m.Type == labels.MatchEqual && m.Value != "" {
+			e.anchor = m
+			break
+		}
+	}
+	idx.entries[sil.Id] = e
+
+	if e.anchor == nil {
+		idx.fallback[sil.Id] = struct{}{}
+		return
+	}
+	name, value := model.LabelName(e.anchor.Name), model.LabelValue(e.anchor.Value)
+	values, ok := idx.byLabel[name]
+	if !ok {
+		values = map[model.LabelValue]map[string]struct{}{}
+		idx.byLabel[name] = values
+	}
+	ids, ok := values[value]
+	if !ok {
+		ids = map[string]struct{}{}
+		values[value] = ids
+	}
+	ids[sil.Id] = struct{}{}
+}
+
+func (idx *silenceIndex) remove(id string) {
+	e, ok := idx.entries[id]
+	if !ok {
+		return
+	}
+	delete(idx.entries, id)
+	if e.anchor == nil {
+		delete(idx.fallback, id)
+		return
+	}
+	name, value := model.LabelName(e.anchor.Name), model.LabelValue(e.anchor.Value)
+	values := idx.byLabel[name]
+	delete(values[value], id)
+	if len(values[value]) == 0 {
+		delete(values, value)
+	}
+	if len(values) == 0 {
+		delete(idx.byLabel, name)
+	}
+}
+
+// Match returns the IDs of the active and pending silences that match the label set,
+// and the version of the silences the result is based on.
+func (idx *silenceIndex) Match(lset model.LabelSet, now time.Time) (activeIDs, pendingIDs []string, version int) {
+	idx.mtx.RLock()
+	if idx.version != idx.silences.Version() {
+		idx.mtx.RUnlock()
+		idx.mtx.Lock()
+		if idx.version != idx.silences.Version() {
+			if err := idx.rebuild(); err != nil {
+				level.Error(idx.logger).Log("msg", "Failed to rebuild silence index", "err", err)
+			}
+		}
+		idx.mtx.Unlock()
+		idx.mtx.RLock()
+	}
+	defer idx.mtx.RUnlock()
+
+	check := func(id string) {
+		e := idx.entries[id]
+		switch silenceStateAt(e.silence, now) {
+		case types.SilenceStateActive:
+			if e.matchers.Matches(lset) {
+				activeIDs = append(activeIDs, id)
+			}
+		case types.SilenceStatePending:
+			if e.matchers.Matches(lset) {
+				pendingIDs = append(pendingIDs, id)
+			}
+		}
+	}
+	for name, value := range lset {
+		for id := range idx.byLabel[name][value] {
+			check(id)
+		}
+	}
+	for id := range idx.fallback {
+		check(id)
+	}
+	return activeIDs, pendingIDs, idx.version
+}
+
+// silenceStateAt returns the state of the silence at the given time.
+func silenceStateAt(sil *silencepb.Silence, now time.Time) types.SilenceState {
+	if sil.EndsAt.Before(now) {
+		return types.SilenceStateExpired
+	}
+	if sil.StartsAt.After(now) {
+		return types.SilenceStatePending
+	}
+	return types.SilenceStateActive
+}
+
+// silenceMatchers compiles the matchers of a silence, as done in prometheus-alertmanager/silence/silence.go.
+func silenceMatchers(sil *silencepb.Silence) (labels.Matchers, error) {
+	ms := make(labels.Matchers, 0, len(sil.Matchers))
+	for _, m := range sil.Matchers {
+		var mt labels.MatchType
+		switch m.Type {
+		case silencepb.Matcher_EQUAL:
+			mt = labels.MatchEqual
+		case silencepb.Matcher_NOT_EQUAL:
+			mt = labels.MatchNotEqual
+		case silencepb.Matcher_REGEXP:
+			mt = labels.MatchRegexp
+		case silencepb.Matcher_NOT_REGEXP:
+			mt = labels.MatchNotRegexp
+		default:
+			return nil, fmt.Errorf("unknown matcher type %q", m.Type)
+		}
+		matcher, err := labels.NewMatcher(mt, m.Name, m.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, matcher)
+	}
+	return ms, nil
+}
+
+// indexedSilencer implements types.Muter using a silenceIndex. It replaces silence.Silencer,
+// which evaluates every silence for every alert.
+type indexedSilencer struct {
+	index  *silenceIndex
+	marker types.Marker
+	now    func() time.Time
+}
+
+func newIndexedSilencer(index *silenceIndex, marker types.Marker, now func() time.Time) *indexedSilencer {
+	return &indexedSilencer{index: index, marker: marker, now: now}
+}
+
+// Mutes implements types.Muter.
+func (s *indexedSilencer) Mutes(lset model.LabelSet) bool {
+	activeIDs, pendingIDs, version := s.index.Match(lset, s.now())
+	s.marker.SetActiveOrSilenced(lset.Fingerprint(), version, activeIDs, pendingIDs)
+	return len(activeIDs) > 0
+}
@@ -0,0 +1,218 @@
+package notify
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSilence(startsAt, endsAt time.Time, matchers ...*silencepb.Matcher) *silencepb.Silence {
+	return &silencepb.Silence{
+		Matchers:  matchers,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		CreatedBy: "test",
+		Comment:   "test",
+	}
+}
+
+func testMatcher(t silencepb.Matcher_Type, name, pattern string) *silencepb.Matcher {
+	return &silencepb.Matcher{Type: t, Name: name, Pattern: pattern}
+}
+
+// expectedSilenceIDs returns the IDs of the silences in the given state matching the label set,
+// as computed by the silences themselves.
+func expectedSilenceIDs(t *testing.T, s *silence.Silences, lset model.LabelSet, state types.SilenceState) []string {
+	t.Helper()
+	sils, _, err := s.Query(silence.QState(state), silence.QMatches(lset))
+	require.NoError(t, err)
+	ids := make([]string, 0, len(sils))
+	for _, sil := range sils {
+		ids = append(ids, sil.Id)
+	}
+	return ids
+}
+
+func requireIndexMatches(t *testing.T, s *silence.Silences, idx *silenceIndex, lset model.LabelSet) {
+	t.Helper()
+	active, pending, version := idx.Match(lset, time.Now())
+	require.ElementsMatch(t, expectedSilenceIDs(t, s, lset, types.SilenceStateActive), active, "active silences for %s", lset)
+	require.ElementsMatch(t, expectedSilenceIDs(t, s, lset, types.SilenceStatePending), pending, "pending silences for %s", lset)
+	require.Equal(t, s.Version(), version)
+}
+
+func TestSilenceIndex_Match(t *testing.T) {
+	s, err := silence.New(silence.Options{Retention: time.Hour})
+	require.NoError(t, err)
+	idx, err := newSilenceIndex(s, log.NewNopLogger())
+	require.NoError(t, err)
+
+	now := time.Now()
+	silences := []*silencepb.Silence{
+		newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_EQUAL, "alertname", "a")),
+		newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_EQUAL, "alertname", "a"), testMatcher(silencepb.Matcher_EQUAL, "team", "b")),
+		newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_REGEXP, "alertname", "a|b")),
+		newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_NOT_EQUAL, "team", "b")),
+		newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_EQUAL, "alertname", "b"), testMatcher(silencepb.Matcher_EQUAL, "team", "")),
+		newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_EQUAL, "team", ""), testMatcher(silencepb.Matcher_NOT_REGEXP, "alertname", "c")),
+		newTestSilence(now.Add(time.Hour), now.Add(2*time.Hour), testMatcher(silencepb.Matcher_EQUAL, "alertname", "a")),
+	}
+	for _, sil := range silences {
+		require.NoError(t, idx.Set(sil))
+	}
+
+	for _, lset := range []model.LabelSet{
+		{},
+		{"alertname": "a"},
+		{"alertname": "a", "team": "b"},
+		{"alertname": "b"},
+		{"alertname": "b", "team": "c"},
+		{"alertname": "c"},
+		{"team": "b"},
+	} {
+		requireIndexMatches(t, s, idx, lset)
+	}
+}
+
+func TestSilenceIndex_Updates(t *testing.T) {
+	now := time.Now()
+	lset := model.LabelSet{"alertname": "a", "team": "b"}
+
+	setup := func(t *testing.T) (*silence.Silences, *silenceIndex) {
+		s, err := silence.New(silence.Options{Retention: time.Hour})
+		require.NoError(t, err)
+		idx, err := newSilenceIndex(s, log.NewNopLogger())
+		require.NoError(t, err)
+		return s, idx
+	}
+
+	t.Run("expired silences no longer match", func(t *testing.T) {
+		s, idx := setup(t)
+		sil := newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_EQUAL, "alertname", "a"))
+		require.NoError(t, idx.Set(sil))
+		active, _, _ := idx.Match(lset, time.Now())
+		require.Equal(t, []string{sil.Id}, active)
+
+		require.NoError(t, idx.Expire(sil.Id))
+		active, _, _ = idx.Match(lset, time.Now())
+		require.Empty(t, active)
+		requireIndexMatches(t, s, idx, lset)
+	})
+
+	t.Run("updated matchers are re-indexed", func(t *testing.T) {
+		s, idx := setup(t)
+		sil := newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_EQUAL, "alertname", "a"))
+		require.NoError(t, idx.Set(sil))
+
+		sil.Matchers = []*silencepb.Matcher{testMatcher(silencepb.Matcher_EQUAL, "alertname", "other")}
+		require.NoError(t, idx.Set(sil))
+		active, _, _ := idx.Match(lset, time.Now())
+		require.Empty(t, active)
+		active, _, _ = idx.Match(model.LabelSet{"alertname": "other"}, time.Now())
+		require.Equal(t, []string{sil.Id}, active)
+		requireIndexMatches(t, s, idx, lset)
+	})
+
+	t.Run("upserted silences are indexed", func(t *testing.T) {
+		s, idx := setup(t)
+		sil := newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_EQUAL, "team", "b"))
+		sil.Id = "preset-id"
+		require.NoError(t, idx.Upsert(sil))
+		active, _, _ := idx.Match(lset, time.Now())
+		require.Equal(t, []string{"preset-id"}, active)
+		requireIndexMatches(t, s, idx, lset)
+	})
+
+	t.Run("silences merged from peers are indexed", func(t *testing.T) {
+		s, idx := setup(t)
+		peer, err := silence.New(silence.Options{Retention: time.Hour})
+		require.NoError(t, err)
+		sil := newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_REGEXP, "team", "b|c"))
+		require.NoError(t, peer.Set(sil))
+
+		b, err := peer.MarshalBinary()
+		require.NoError(t, err)
+		require.NoError(t, idx.Merge(b))
+		active, _, _ := idx.Match(lset, time.Now())
+		require.Equal(t, []string{sil.Id}, active)
+		requireIndexMatches(t, s, idx, lset)
+	})
+
+	t.Run("changes that bypass the index cause a rebuild", func(t *testing.T) {
+		s, idx := setup(t)
+		sil := newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_EQUAL, "alertname", "a"))
+		require.NoError(t, s.Set(sil))
+		active, _, _ := idx.Match(lset, time.Now())
+		require.Equal(t, []string{sil.Id}, active)
+		requireIndexMatches(t, s, idx, lset)
+	})
+}
+
+func TestIndexedSilencer_Mutes(t *testing.T) {
+	s, err := silence.New(silence.Options{Retention: time.Hour})
+	require.NoError(t, err)
+	idx, err := newSilenceIndex(s, log.NewNopLogger())
+	require.NoError(t, err)
+	marker := types.NewMarker(prometheus.NewRegistry())
+	silencer := newIndexedSilencer(idx, marker, time.Now)
+
+	now := time.Now()
+	active := newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_EQUAL, "alertname", "a"))
+	pending := newTestSilence(now.Add(time.Hour), now.Add(2*time.Hour), testMatcher(silencepb.Matcher_EQUAL, "alertname", "b"))
+	require.NoError(t, idx.Set(active))
+	require.NoError(t, idx.Set(pending))
+
+	lset := model.LabelSet{"alertname": "a"}
+	require.True(t, silencer.Mutes(lset))
+	activeIDs, pendingIDs, version, silenced := marker.Silenced(lset.Fingerprint())
+	require.True(t, silenced)
+	require.Equal(t, []string{active.Id}, activeIDs)
+	require.Empty(t, pendingIDs)
+	require.Equal(t, s.Version(), version)
+
+	lset = model.LabelSet{"alertname": "b"}
+	require.False(t, silencer.Mutes(lset))
+	activeIDs, pendingIDs, _, silenced = marker.Silenced(lset.Fingerprint())
+	require.False(t, silenced)
+	require.Empty(t, activeIDs)
+	require.Equal(t, []string{pending.Id}, pendingIDs)
+}
+
+func BenchmarkSilencer(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		s, err := silence.New(silence.Options{Retention: time.Hour})
+		require.NoError(b, err)
+		now := time.Now()
+		for i := 0; i < n; i++ {
+			sil := newTestSilence(now, now.Add(time.Hour),
+				testMatcher(silencepb.Matcher_EQUAL, "alertname", fmt.Sprintf("alert-%d", i)),
+				testMatcher(silencepb.Matcher_REGEXP, "instance", ".+"),
+			)
+			require.NoError(b, s.Set(sil))
+		}
+		idx, err := newSilenceIndex(s, log.NewNopLogger())
+		require.NoError(b, err)
+
+		muters := map[string]types.Muter{
+			"linear": silence.NewSilencer(s, types.NewMarker(prometheus.NewRegistry()), log.NewNopLogger()),
+			"index":  newIndexedSilencer(idx, types.NewMarker(prometheus.NewRegistry()), time.Now),
+		}
+		for _, name := range []string{"linear", "index"} {
+			muter := muters[name]
+			b.Run(fmt.Sprintf("%s/silences=%d", name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					// A new label set on every iteration so that results cached in the marker are not used.
+					muter.Mutes(model.LabelSet{"alertname": "alert-1", "instance": model.LabelValue(fmt.Sprintf("instance-%d", i))})
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/common/model"
+)
+
+// ErrSilencePreviewBadMatchers is returned by PreviewSilence for matchers that are not valid matchers of a silence.
+var ErrSilencePreviewBadMatchers = errors.New("invalid silence matchers")
+
+// SilencePreview describes the alerts that a silence with the matchers would silence if it was created now.
+type SilencePreview struct {
+	// Alerts are the matched alerts, firing alerts first.
+	Alerts []SilencePreviewAlert `json:"alerts"`
+	// Firing is the number of matched alerts that are firing.
+	Firing int `json:"firing"`
+	// Resolved is the number of matched alerts that were resolved recently, and would be silenced if they fire again.
+	Resolved int `json:"resolved"`
+	// CountsByAlertname are the numbers of matched alerts by alertname.
+	CountsByAlertname map[string]int `json:"countsByAlertname"`
+}
+
+// SilencePreviewAlert is an alert matched by a silence preview.
+type SilencePreviewAlert struct {
+	Fingerprint string         `json:"fingerprint"`
+	Labels      model.LabelSet `json:"labels"`
+	Firing      bool           `json:"firing"`
+	StartsAt    time.Time      `json:"startsAt"`
+	EndsAt      time.Time      `json:"endsAt"`
+	// SilencedBy are the IDs of the active silences that already silence the alert.
+	SilencedBy []string `json:"silencedBy"`
+}
+
+// PreviewSilence returns the firing and recently resolved alerts that a silence with the matchers would silence, so
+// that the silence can be verified before it is created. The matchers are validated as the matchers of silences.
+func (am *GrafanaAlertmanager) PreviewSilence(matchers amv2.Matchers) (*SilencePreview, error) {
+	ms, err := silencePreviewMatchers(matchers)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSilencePreviewBadMatchers, err)
+	}
+
+	now := am.clock.Now()
+	res := &SilencePreview{Alerts: []SilencePreviewAlert{}, CountsByAlertname: map[string]int{}}
+	it := am.alerts.GetPending()
+	defer it.Close()
+	for a := range it.Next() {
+		if !ms.Matches(a.Labels) {
+			continue
+		}
+		firing := !a.ResolvedAt(now)
+		if firing {
+			res.Firing++
+		} else {
+			res.Resolved++
+		}
+		res.CountsByAlertname[string(a.Labels[model.AlertNameLabel])]++
+		fp := a.Fingerprint()
+		res.Alerts = append(res.Alerts, SilencePreviewAlert{
+			Fingerprint: fp.String(),
+			Labels:      a.Labels,
+			Firing:      firing,
+			StartsAt:    a.StartsAt,
+			EndsAt:      a.EndsAt,
+			SilencedBy:  append([]string{}, am.marker.Status(fp).SilencedBy...),
+		})
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(res.Alerts, func(i, j int) bool {
+		if res.Alerts[i].Firing != res.Alerts[j].Firing {
+			return res.Alerts[i].Firing
+		}
+		return res.Alerts[i].Labels.Before(res.Alerts[j].Labels)
+	})
+	return res, nil
+}
+
+// silencePreviewMatchers compiles the matchers of a postable silence. At least one matcher must not match the empty
+// string, as silences that match all alerts are not valid.
+func silencePreviewMatchers(matchers amv2.Matchers) (labels.Matchers, error) {
+	if len(matchers) == 0 {
+		return nil, errors.New("at least one matcher must be specified")
+	}
+	ms := make(labels.Matchers, 0, len(matchers))
+	matchesNonEmpty := false
+	for _, m := range matchers {
+		if m == nil || m.Name == nil || m.Value == nil || *m.Name == "" {
+			return nil, errors.New("matchers must have a name and a value")
+		}
+		isEqual := m.IsEqual == nil || *m.IsEqual
+		isRegex := m.IsRegex != nil && *m.IsRegex
+		var mt labels.MatchType
+		switch {
+		case isEqual && !isRegex:
+			mt = labels.MatchEqual
+		case !isEqual && !isRegex:
+			mt = labels.MatchNotEqual
+		case isEqual && isRegex:
+			mt = labels.MatchRegexp
+		default:
+			mt = labels.MatchNotRegexp
+		}
+		matcher, err := labels.NewMatcher(mt, *m.Name, *m.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !matcher.Matches("") {
+			matchesNonEmpty = true
+		}
+		ms = append(ms, matcher)
+	}
+	if !matchesNonEmpty {
+		return nil, errors.New("at least one matcher must not match the empty string")
+	}
+	return ms, nil
+}
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewSilence(t *testing.T) {
+	am, _ := setupAMTest(t)
+	now := time.Now()
+	alert := func(alertname, team string, resolved bool) *PostableAlert {
+		a := &PostableAlert{
+			StartsAt: strfmt.DateTime(now.Add(-time.Hour)),
+			Alert:    amv2.Alert{Labels: amv2.LabelSet{"alertname": alertname, "team": team}},
+		}
+		if resolved {
+			a.EndsAt = strfmt.DateTime(now.Add(-time.Minute))
+		}
+		return a
+	}
+	require.NoError(t, am.PutAlerts(PostableAlerts{
+		alert("a", "team-a", false),
+		alert("b", "team-a", false),
+		alert("a", "team-b", true),
+		alert("c", "team-b", false),
+	}))
+	matcher := func(name, value string, isEqual, isRegex bool) *amv2.Matcher {
+		return &amv2.Matcher{Name: &name, Value: &value, IsEqual: &isEqual, IsRegex: &isRegex}
+	}
+
+	p, err := am.PreviewSilence(amv2.Matchers{matcher("team", "team-a", true, false)})
+	require.NoError(t, err)
+	require.Equal(t, 2, p.Firing)
+	require.Equal(t, 0, p.Resolved)
+	require.Equal(t, map[string]int{"a": 1, "b": 1}, p.CountsByAlertname)
+	require.Len(t, p.Alerts, 2)
+	require.Equal(t, model.LabelSet{"alertname": "a", "team": "team-a"}, p.Alerts[0].Labels)
+	require.Equal(t, model.LabelSet{"alertname": "a", "team": "team-a"}.Fingerprint().String(), p.Alerts[0].Fingerprint)
+	require.True(t, p.Alerts[0].Firing)
+	require.Empty(t, p.Alerts[0].SilencedBy)
+
+	// Recently resolved alerts are listed after firing alerts.
+	p, err = am.PreviewSilence(amv2.Matchers{matcher("alertname", "a|c", true, true), matcher("team", "team-a", false, false)})
+	require.NoError(t, err)
+	require.Equal(t, 1, p.Firing)
+	require.Equal(t, 1, p.Resolved)
+	require.Equal(t, map[string]int{"a": 1, "c": 1}, p.CountsByAlertname)
+	require.Equal(t, model.LabelSet{"alertname": "c", "team": "team-b"}, p.Alerts[0].Labels)
+	require.False(t, p.Alerts[1].Firing)
+
+	p, err = am.PreviewSilence(amv2.Matchers{matcher("team", "team-c", true, false)})
+	require.NoError(t, err)
+	require.Empty(t, p.Alerts)
+	require.Empty(t, p.CountsByAlertname)
+
+	for _, matchers := range []amv2.Matchers{
+		nil,
+		{matcher("team", ".*", true, true)},
+		{matcher("team", "(", true, true)},
+		{{Name: ptr("team")}},
+	} {
+		_, err := am.PreviewSilence(matchers)
+		require.ErrorIs(t, err, ErrSilencePreviewBadMatchers)
+	}
+}
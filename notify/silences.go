@@ -30,22 +30,51 @@ type PostableSilence = amv2.PostableSilence
 
 type Silence = amv2.Silence
 
-// ListSilences retrieves a list of stored silences. It supports a set of labels as filters.
-func (am *GrafanaAlertmanager) ListSilences(filter []string) (GettableSilences, error) {
+type listSilencesOptions struct {
+	deleted bool
+}
+
+// ListSilencesOption is an option of ListSilences.
+type ListSilencesOption func(*listSilencesOptions)
+
+// WithDeletedSilences lists the deleted silences that can be restored instead of the other silences. They are
+// listed as they were before they were deleted, in the expired state.
+func WithDeletedSilences() ListSilencesOption {
+	return func(o *listSilencesOptions) {
+		o.deleted = true
+	}
+}
+
+// ListSilences retrieves a list of stored silences. It supports a set of labels as filters. Deleted silences are
+// not listed, unless WithDeletedSilences is set.
+func (am *GrafanaAlertmanager) ListSilences(filter []string, opts ...ListSilencesOption) (GettableSilences, error) {
+	var o listSilencesOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	matchers, err := parseFilter(filter)
 	if err != nil {
 		level.Error(am.logger).Log("msg", "failed to parse matchers", "err", err)
 		return nil, fmt.Errorf("%s: %w", ErrListSilencesBadPayload.Error(), err)
 	}
 
-	psils, _, err := am.silences.Query()
-	if err != nil {
-		level.Error(am.logger).Log("msg", ErrGetSilencesInternal.Error(), "err", err)
-		return nil, fmt.Errorf("%s: %w", ErrGetSilencesInternal.Error(), err)
+	var psils []*silencepb.Silence
+	if o.deleted {
+		psils = am.deletedSilences.list()
+	} else {
+		psils, _, err = am.silences.Query()
+		if err != nil {
+			level.Error(am.logger).Log("msg", ErrGetSilencesInternal.Error(), "err", err)
+			return nil, fmt.Errorf("%s: %w", ErrGetSilencesInternal.Error(), err)
+		}
 	}
 
 	sils := GettableSilences{}
 	for _, ps := range psils {
+		if !o.deleted && am.deletedSilences.isDeleted(ps.Id) {
+			continue
+		}
 		if !v2.CheckSilenceMatchesFilterLabels(ps, matchers) {
 			continue
 		}
@@ -55,6 +84,9 @@ func (am *GrafanaAlertmanager) ListSilences(filter []string) (GettableSilences,
 			return GettableSilences{}, fmt.Errorf("%s: failed to convert internal silence to API silence: %w",
 				ErrGetSilencesInternal.Error(), err)
 		}
+		if o.deleted {
+			silence.Status.State = ptr(amv2.SilenceStatusStateExpired)
+		}
 		sils = append(sils, &silence)
 	}
 
@@ -98,7 +130,7 @@ func (am *GrafanaAlertmanager) CreateSilence(ps *PostableSilence) (string, error
 		return "", err
 	}
 
-	if err := am.silences.Set(sil); err != nil {
+	if err := am.silenceIndex.Set(sil); err != nil {
 		level.Error(am.logger).Log("msg", "unable to save silence", "err", err)
 		return "", fmt.Errorf("unable to save silence: %s: %w", err.Error(), ErrCreateSilenceBadPayload)
 	}
@@ -119,7 +151,7 @@ func (am *GrafanaAlertmanager) UpsertSilence(ps *PostableSilence) (string, error
 		return "", err
 	}
 
-	if err := am.silences.Upsert(sil); err != nil {
+	if err := am.silenceIndex.Upsert(sil); err != nil {
 		level.Error(am.logger).Log("msg", "unable to upsert silence", "err", err)
 		return "", fmt.Errorf("unable to upsert silence: %s: %w", err.Error(), ErrCreateSilenceBadPayload)
 	}
@@ -143,18 +175,75 @@ func (am *GrafanaAlertmanager) validateSilence(sil *silencepb.Silence) error {
 	return nil
 }
 
-// DeleteSilence looks for and expires the silence by the provided silenceID. It returns ErrSilenceNotFound if the silence is not present.
+// DeleteSilence looks for and deletes the silence by the provided silenceID, see DeleteSilenceBy. It returns
+// ErrSilenceNotFound if the silence is not present.
 func (am *GrafanaAlertmanager) DeleteSilence(silenceID string) error {
-	if err := am.silences.Expire(silenceID); err != nil {
+	return am.DeleteSilenceBy(silenceID, "")
+}
+
+// DeleteSilenceBy looks for and expires the silence by the provided silenceID on behalf of deletedBy. Unless it is
+// expired already, the silence can be restored with RestoreSilence within the deleted silence retention, and the
+// deletion is recorded in the audit trail. It returns ErrSilenceNotFound if the silence is not present.
+func (am *GrafanaAlertmanager) DeleteSilenceBy(silenceID, deletedBy string) error {
+	sils, _, err := am.silences.Query(silence.QIDs(silenceID))
+	if err != nil {
+		return fmt.Errorf("%s: %w", err.Error(), ErrDeleteSilenceInternal)
+	}
+	if len(sils) == 0 {
+		return ErrSilenceNotFound
+	}
+
+	expire := func() error { return am.silenceIndex.Expire(silenceID) }
+	if sils[0].EndsAt.After(am.clock.Now()) {
+		err = am.deletedSilences.delete(sils[0], deletedBy, expire)
+	} else {
+		err = expire()
+	}
+	if err != nil {
 		if errors.Is(err, silence.ErrNotFound) {
 			return ErrSilenceNotFound
 		}
 		return fmt.Errorf("%s: %w", err.Error(), ErrDeleteSilenceInternal)
 	}
 
+	level.Info(am.logger).Log("msg", "Silence deleted", "id", silenceID, "deleted_by", deletedBy)
 	return nil
 }
 
+// RestoreSilence restores the deleted silence by the provided silenceID on behalf of restoredBy, and returns the ID
+// of the restored silence. Expired silences cannot be updated, so the restored silence has a new ID, and it ends
+// when the deleted silence would have ended. The restoration is recorded in the audit trail. It returns
+// ErrSilenceNotFound if the silence was not deleted within the deleted silence retention or was restored already.
+func (am *GrafanaAlertmanager) RestoreSilence(silenceID, restoredBy string) (string, error) {
+	restoredID, ok, err := am.deletedSilences.restore(silenceID, restoredBy, func(deleted *silencepb.Silence) (string, error) {
+		sil := *deleted
+		sil.Id = ""
+		if err := am.validateSilence(&sil); err != nil {
+			return "", err
+		}
+		if err := am.silenceIndex.Set(&sil); err != nil {
+			level.Error(am.logger).Log("msg", "unable to save silence", "err", err)
+			return "", fmt.Errorf("unable to save silence: %s: %w", err.Error(), ErrCreateSilenceBadPayload)
+		}
+		return sil.Id, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrSilenceNotFound
+	}
+
+	level.Info(am.logger).Log("msg", "Silence restored", "id", silenceID, "restored_id", restoredID, "restored_by", restoredBy)
+	return restoredID, nil
+}
+
+// SilenceAuditTrail returns the deletions and restorations of silences within the deleted silence retention,
+// ordered by time.
+func (am *GrafanaAlertmanager) SilenceAuditTrail() []SilenceAuditEvent {
+	return am.deletedSilences.auditTrail()
+}
+
 func (am *GrafanaAlertmanager) SilenceState() (SilenceState, error) {
 	r, w := io.Pipe()
 	go func() {
@@ -2,12 +2,15 @@ package notify
 
 import (
 	"bytes"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-kit/log"
 	"github.com/go-openapi/strfmt"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 
 	amv2 "github.com/prometheus/alertmanager/api/v2/models"
@@ -123,3 +126,154 @@ func TestSilenceState(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteAndRestoreSilence(t *testing.T) {
+	am, _ := setupAMTest(t)
+	now := time.Now()
+	maintenance := PostableSilence{
+		Silence: amv2.Silence{
+			Comment:   ptr("Maintenance"),
+			CreatedBy: ptr("test"),
+			StartsAt:  ptr(strfmt.DateTime(now)),
+			EndsAt:    ptr(strfmt.DateTime(now.Add(time.Hour))),
+			Matchers:  amv2.Matchers{{IsEqual: ptr(true), IsRegex: ptr(false), Name: ptr("team"), Value: ptr("a")}},
+		},
+	}
+	sid, err := am.CreateSilence(&maintenance)
+	require.NoError(t, err)
+
+	require.NoError(t, am.DeleteSilenceBy(sid, "alice"))
+	sils, err := am.ListSilences(nil)
+	require.NoError(t, err)
+	require.Empty(t, sils, "deleted silences are not listed")
+
+	deleted, err := am.ListSilences([]string{`team="a"`}, WithDeletedSilences())
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	require.Equal(t, sid, *deleted[0].ID)
+	require.Equal(t, amv2.SilenceStatusStateExpired, *deleted[0].Status.State)
+	require.WithinDuration(t, now.Add(time.Hour), time.Time(*deleted[0].EndsAt), time.Millisecond, "deleted silences are listed as they were before they were deleted")
+
+	restoredID, err := am.RestoreSilence(sid, "bob")
+	require.NoError(t, err)
+	require.NotEqual(t, sid, restoredID)
+	restored, err := am.GetSilence(restoredID)
+	require.NoError(t, err)
+	require.Equal(t, amv2.SilenceStatusStateActive, *restored.Status.State)
+	require.Equal(t, "Maintenance", *restored.Comment)
+	require.WithinDuration(t, now.Add(time.Hour), time.Time(*restored.EndsAt), time.Millisecond)
+
+	deleted, err = am.ListSilences(nil, WithDeletedSilences())
+	require.NoError(t, err)
+	require.Empty(t, deleted)
+	_, err = am.RestoreSilence(sid, "bob")
+	require.ErrorIs(t, err, ErrSilenceNotFound, "silences are restored once")
+
+	trail := am.SilenceAuditTrail()
+	require.Len(t, trail, 2)
+	require.Equal(t, SilenceAuditEvent{Action: SilenceAuditDeleted, SilenceID: sid, Actor: "alice", Time: trail[0].Time}, trail[0])
+	require.Equal(t, SilenceAuditEvent{Action: SilenceAuditRestored, SilenceID: sid, RestoredID: restoredID, Actor: "bob", Time: trail[1].Time}, trail[1])
+}
+
+// snapshotMaintenanceOptions keep the last snapshot, and load it as the initial state.
+type snapshotMaintenanceOptions struct {
+	fakeMaintenanceOptions
+	mtx      sync.Mutex
+	snapshot string
+}
+
+func (o *snapshotMaintenanceOptions) InitialState() string {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	return o.snapshot
+}
+
+func (o *snapshotMaintenanceOptions) MaintenanceFunc(state State) (int64, error) {
+	b, err := state.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.snapshot = string(b)
+	return int64(len(b)), nil
+}
+
+func TestDeletedSilences_Snapshot(t *testing.T) {
+	snapshots := &snapshotMaintenanceOptions{}
+	newAM := func() *GrafanaAlertmanager {
+		am, err := NewGrafanaAlertmanager("org", 1, &GrafanaAlertmanagerConfig{
+			Silences:        newFakeMaintanenceOptions(t),
+			Nflog:           newFakeMaintanenceOptions(t),
+			DeletedSilences: snapshots,
+		}, &NilPeer{}, log.NewNopLogger(), NewGrafanaAlertmanagerMetrics(prometheus.NewPedanticRegistry(), log.NewNopLogger()))
+		require.NoError(t, err)
+		return am
+	}
+
+	am := newAM()
+	now := time.Now()
+	sid, err := am.CreateSilence(&PostableSilence{
+		Silence: amv2.Silence{
+			Comment:   ptr("Maintenance"),
+			CreatedBy: ptr("test"),
+			StartsAt:  ptr(strfmt.DateTime(now)),
+			EndsAt:    ptr(strfmt.DateTime(now.Add(time.Hour))),
+			Matchers:  amv2.Matchers{{IsEqual: ptr(true), IsRegex: ptr(false), Name: ptr("team"), Value: ptr("a")}},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, am.DeleteSilenceBy(sid, "alice"))
+	// The deleted silences are snapshotted when the Alertmanager stops.
+	am.StopAndWait()
+
+	// The deleted silences are loaded from the snapshot, as if all the members of the cluster restarted.
+	am = newAM()
+	t.Cleanup(am.StopAndWait)
+	require.Contains(t, am.SnapshotLoadResults(), SnapshotLoadResult{Kind: SnapshotKindDeletedSilences, Action: SnapshotLoaded, SizeBytes: len(snapshots.InitialState())})
+	deleted, err := am.ListSilences(nil, WithDeletedSilences())
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	require.Equal(t, sid, *deleted[0].ID)
+	_, err = am.RestoreSilence(sid, "bob")
+	require.NoError(t, err)
+
+	// Snapshots of deleted silences that cannot be decoded are recovered like the others.
+	snapshots.snapshot = "not json"
+	_, err = NewGrafanaAlertmanager("org", 1, &GrafanaAlertmanagerConfig{
+		Silences:        newFakeMaintanenceOptions(t),
+		Nflog:           newFakeMaintanenceOptions(t),
+		DeletedSilences: snapshots,
+	}, &NilPeer{}, log.NewNopLogger(), NewGrafanaAlertmanagerMetrics(prometheus.NewPedanticRegistry(), log.NewNopLogger()))
+	require.ErrorContains(t, err, "unable to initialize the deleted silences of alerting")
+}
+
+func TestDeletedSilences_Merge(t *testing.T) {
+	now := time.Now().UTC().Round(0)
+	local := newDeletedSilences(time.Hour, func() time.Time { return now })
+	remote := newDeletedSilences(time.Hour, func() time.Time { return now })
+	var gossiped [][]byte
+	remote.SetBroadcast(func(b []byte) { gossiped = append(gossiped, b) })
+
+	sil := &silencepb.Silence{Id: "a", EndsAt: now.Add(time.Hour)}
+	require.NoError(t, remote.delete(sil, "alice", func() error { return nil }))
+	_, ok, err := remote.restore("a", "bob", func(*silencepb.Silence) (string, error) { return "b", nil })
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, gossiped, 2)
+
+	// The restoration wins over the deletion, whatever the order they are received in.
+	require.NoError(t, local.Merge(gossiped[1]))
+	require.NoError(t, local.Merge(gossiped[0]))
+	require.False(t, local.isDeleted("a"))
+	require.Equal(t, remote.auditTrail(), local.auditTrail())
+
+	state, err := remote.MarshalBinary()
+	require.NoError(t, err)
+	now = now.Add(2 * time.Hour)
+	other := newDeletedSilences(time.Hour, func() time.Time { return now })
+	require.NoError(t, other.Merge(state))
+	require.Empty(t, other.auditTrail(), "silences deleted before the retention are ignored")
+	local.GC()
+	require.Empty(t, local.auditTrail())
+}
@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	"github.com/prometheus/alertmanager/nflog"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+)
+
+const (
+	// SnapshotKindSilences is the kind of the snapshot of silences.
+	SnapshotKindSilences = "silences"
+	// SnapshotKindNflog is the kind of the snapshot of the notification log.
+	SnapshotKindNflog = "nflog"
+	// SnapshotKindDeletedSilences is the kind of the snapshot of the deleted silences.
+	SnapshotKindDeletedSilences = "deleted_silences"
+)
+
+// SnapshotRecoveryPolicy is what the Alertmanager does when a snapshot of its state cannot be loaded at startup,
+// for example because it is corrupted or was written by an incompatible version.
+type SnapshotRecoveryPolicy string
+
+const (
+	// SnapshotRecoveryFail fails to create the Alertmanager. It is the default.
+	SnapshotRecoveryFail SnapshotRecoveryPolicy = "fail"
+	// SnapshotRecoveryStartEmpty discards the snapshot and starts with an empty state. The loss of the state is
+	// logged as an error and counted with the result started_empty, so that it can be alerted on.
+	SnapshotRecoveryStartEmpty SnapshotRecoveryPolicy = "start_empty"
+	// SnapshotRecoveryRestoreBackup loads the snapshot returned by SnapshotRecoveryOptions.Backup instead.
+	// If the backup cannot be loaded either, the Alertmanager fails to be created.
+	SnapshotRecoveryRestoreBackup SnapshotRecoveryPolicy = "restore_backup"
+)
+
+// SnapshotBackupFunc returns the backup of the snapshot of the kind, such as SnapshotKindSilences.
+type SnapshotBackupFunc func(kind string) (string, error)
+
+// SnapshotRecoveryOptions configure the recovery of snapshots that cannot be loaded.
+type SnapshotRecoveryOptions struct {
+	// Policy is what to do when a snapshot cannot be loaded. It defaults to SnapshotRecoveryFail.
+	Policy SnapshotRecoveryPolicy
+	// Backup returns the backup of a snapshot. It is required by SnapshotRecoveryRestoreBackup.
+	Backup SnapshotBackupFunc
+}
+
+func (o SnapshotRecoveryOptions) Validate() error {
+	switch o.Policy {
+	case "", SnapshotRecoveryFail, SnapshotRecoveryStartEmpty:
+	case SnapshotRecoveryRestoreBackup:
+		if o.Backup == nil {
+			return errors.New("a backup function is required to restore snapshots from backups")
+		}
+	default:
+		return fmt.Errorf("unknown snapshot recovery policy %q", o.Policy)
+	}
+	return nil
+}
+
+// SnapshotAction is the action taken to load a snapshot.
+type SnapshotAction string
+
+const (
+	// SnapshotLoaded means the snapshot was loaded as is.
+	SnapshotLoaded SnapshotAction = "loaded"
+	// SnapshotStartedEmpty means the snapshot could not be loaded and the state was discarded.
+	SnapshotStartedEmpty SnapshotAction = "started_empty"
+	// SnapshotRestoredFromBackup means the snapshot could not be loaded and its backup was loaded instead.
+	SnapshotRestoredFromBackup SnapshotAction = "restored_from_backup"
+	// snapshotFailed is only used as a label of the metrics, as the Alertmanager is not created.
+	snapshotFailed SnapshotAction = "failed"
+)
+
+// SnapshotLoadResult is the result of loading a snapshot at startup.
+type SnapshotLoadResult struct {
+	Kind   string
+	Action SnapshotAction
+	// SizeBytes is the size of the snapshot that was loaded, which is the backup when it was restored.
+	SizeBytes int
+	// Err is why the snapshot could not be loaded, if it was recovered.
+	Err error
+}
+
+// recoverSnapshot checks that the snapshot of the kind can be decoded, and applies the recovery policy if it cannot.
+// It returns the snapshot to load, which is empty when the state is discarded.
+func (o SnapshotRecoveryOptions) recoverSnapshot(kind, snapshot string, decode func(io.Reader) error, l log.Logger) (string, SnapshotLoadResult, error) {
+	res := SnapshotLoadResult{Kind: kind, Action: SnapshotLoaded, SizeBytes: len(snapshot)}
+	err := decode(strings.NewReader(snapshot))
+	if err == nil {
+		return snapshot, res, nil
+	}
+	res.Err = err
+
+	switch o.Policy {
+	case SnapshotRecoveryStartEmpty:
+		level.Error(l).Log("msg", "Failed to load snapshot, starting with an empty state", "kind", kind, "size", len(snapshot), "err", err)
+		res.Action, res.SizeBytes = SnapshotStartedEmpty, 0
+		return "", res, nil
+	case SnapshotRecoveryRestoreBackup:
+		backup, berr := o.Backup(kind)
+		if berr != nil {
+			return "", res, fmt.Errorf("failed to load snapshot: %w, and failed to get its backup: %w", err, berr)
+		}
+		if berr = decode(strings.NewReader(backup)); berr != nil {
+			return "", res, fmt.Errorf("failed to load snapshot: %w, and failed to load its backup: %w", err, berr)
+		}
+		level.Warn(l).Log("msg", "Failed to load snapshot, restored it from its backup", "kind", kind, "size", len(backup), "err", err)
+		res.Action, res.SizeBytes = SnapshotRestoredFromBackup, len(backup)
+		return backup, res, nil
+	default:
+		return "", res, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+}
+
+func decodeSilencesSnapshot(r io.Reader) error {
+	_, err := DecodeState(r)
+	return err
+}
+
+// decodeDeletedSilencesSnapshot decodes the snapshot of the deleted silences. An empty snapshot has no deleted silences.
+func decodeDeletedSilencesSnapshot(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil || len(b) == 0 {
+		return err
+	}
+	var recs []*deletedSilence
+	return json.Unmarshal(b, &recs)
+}
+
+// decodeNflogSnapshot is like decodeState in prometheus-alertmanager/nflog/nflog.go.
+func decodeNflogSnapshot(r io.Reader) error {
+	for {
+		var e nflogpb.MeshEntry
+		_, err := pbutil.ReadDelimited(r, &e)
+		if err == nil {
+			if e.Entry == nil || e.Entry.Receiver == nil {
+				return nflog.ErrInvalidState
+			}
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+}
+
+// SnapshotLoadResults returns the results of loading the snapshots of silences, the notification log and deleted
+// silences, so that operators can tell whether state was lost or restored from a backup at startup.
+func (am *GrafanaAlertmanager) SnapshotLoadResults() []SnapshotLoadResult {
+	return append([]SnapshotLoadResult(nil), am.snapshotResults...)
+}
+
+// loadSnapshot returns the snapshot of the kind to load into the Alertmanager and records the result.
+func (am *GrafanaAlertmanager) loadSnapshot(opts SnapshotRecoveryOptions, kind, snapshot string, decode func(io.Reader) error) (string, error) {
+	s, res, err := opts.recoverSnapshot(kind, snapshot, decode, am.logger)
+	if err != nil {
+		am.Metrics.snapshotLoads.WithLabelValues(am.tenantString(), kind, string(snapshotFailed)).Inc()
+		return "", err
+	}
+	am.Metrics.snapshotLoads.WithLabelValues(am.tenantString(), kind, string(res.Action)).Inc()
+	am.Metrics.snapshotSizeBytes.WithLabelValues(am.tenantString(), kind).Set(float64(res.SizeBytes))
+	am.snapshotResults = append(am.snapshotResults, res)
+	return s, nil
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRecovery(t *testing.T) {
+	now := time.Now()
+	sil := newTestSilence(now, now.Add(time.Hour), testMatcher(silencepb.Matcher_EQUAL, "foo", "bar"))
+	sil.Id = "backup"
+	valid, err := SilenceState{sil.Id: {Silence: sil, ExpiresAt: now.Add(2 * time.Hour)}}.MarshalBinary()
+	require.NoError(t, err)
+	// The length prefix of the entry is larger than the rest of the snapshot.
+	corrupted := "corrupted"
+
+	newAM := func(t *testing.T, silences string, recovery SnapshotRecoveryOptions) (*GrafanaAlertmanager, *prometheus.Registry, error) {
+		t.Helper()
+		reg := prometheus.NewPedanticRegistry()
+		am, err := NewGrafanaAlertmanager("org", 1, &GrafanaAlertmanagerConfig{
+			Silences:         &fakeMaintenanceOptions{initialState: silences},
+			Nflog:            newFakeMaintanenceOptions(t),
+			SnapshotRecovery: recovery,
+		}, &NilPeer{}, log.NewNopLogger(), NewGrafanaAlertmanagerMetrics(reg, log.NewNopLogger()))
+		return am, reg, err
+	}
+	countSilences := func(t *testing.T, am *GrafanaAlertmanager) int {
+		t.Helper()
+		sils, _, err := am.silences.Query()
+		require.NoError(t, err)
+		return len(sils)
+	}
+
+	t.Run("loads valid snapshots", func(t *testing.T) {
+		am, reg, err := newAM(t, string(valid), SnapshotRecoveryOptions{})
+		require.NoError(t, err)
+		require.Equal(t, 1, countSilences(t, am))
+		require.Equal(t, []SnapshotLoadResult{
+			{Kind: SnapshotKindSilences, Action: SnapshotLoaded, SizeBytes: len(valid)},
+			{Kind: SnapshotKindNflog, Action: SnapshotLoaded},
+		}, am.SnapshotLoadResults())
+		require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+# HELP grafana_alerting_alertmanager_state_snapshot_size_bytes Size of the snapshots of silences and the notification log loaded at startup.
+# TYPE grafana_alerting_alertmanager_state_snapshot_size_bytes gauge
+grafana_alerting_alertmanager_state_snapshot_size_bytes{kind="nflog",org="1"} 0
+grafana_alerting_alertmanager_state_snapshot_size_bytes{kind="silences",org="1"} `+strconv.Itoa(len(valid))+`
+`), "grafana_alerting_alertmanager_state_snapshot_size_bytes"))
+	})
+
+	t.Run("fails by default", func(t *testing.T) {
+		_, reg, err := newAM(t, corrupted, SnapshotRecoveryOptions{})
+		require.ErrorContains(t, err, "failed to load snapshot")
+		require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+# HELP grafana_alerting_alertmanager_state_snapshot_loads_total Number of snapshots of silences and the notification log loaded at startup by result: loaded, started_empty, restored_from_backup or failed.
+# TYPE grafana_alerting_alertmanager_state_snapshot_loads_total counter
+grafana_alerting_alertmanager_state_snapshot_loads_total{kind="silences",org="1",result="failed"} 1
+`), "grafana_alerting_alertmanager_state_snapshot_loads_total"))
+	})
+
+	t.Run("starts empty", func(t *testing.T) {
+		am, reg, err := newAM(t, corrupted, SnapshotRecoveryOptions{Policy: SnapshotRecoveryStartEmpty})
+		require.NoError(t, err)
+		require.Zero(t, countSilences(t, am))
+
+		res := am.SnapshotLoadResults()
+		require.Len(t, res, 2)
+		require.Equal(t, SnapshotStartedEmpty, res[0].Action)
+		require.Zero(t, res[0].SizeBytes)
+		require.Error(t, res[0].Err)
+		require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+# HELP grafana_alerting_alertmanager_state_snapshot_loads_total Number of snapshots of silences and the notification log loaded at startup by result: loaded, started_empty, restored_from_backup or failed.
+# TYPE grafana_alerting_alertmanager_state_snapshot_loads_total counter
+grafana_alerting_alertmanager_state_snapshot_loads_total{kind="nflog",org="1",result="loaded"} 1
+grafana_alerting_alertmanager_state_snapshot_loads_total{kind="silences",org="1",result="started_empty"} 1
+`), "grafana_alerting_alertmanager_state_snapshot_loads_total"))
+	})
+
+	t.Run("restores from backup", func(t *testing.T) {
+		var kinds []string
+		am, _, err := newAM(t, corrupted, SnapshotRecoveryOptions{
+			Policy: SnapshotRecoveryRestoreBackup,
+			Backup: func(kind string) (string, error) {
+				kinds = append(kinds, kind)
+				return string(valid), nil
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{SnapshotKindSilences}, kinds)
+		require.Equal(t, 1, countSilences(t, am))
+
+		res := am.SnapshotLoadResults()
+		require.Equal(t, SnapshotRestoredFromBackup, res[0].Action)
+		require.Equal(t, len(valid), res[0].SizeBytes)
+		require.Error(t, res[0].Err)
+	})
+
+	t.Run("fails if the backup cannot be loaded", func(t *testing.T) {
+		_, _, err := newAM(t, corrupted, SnapshotRecoveryOptions{
+			Policy: SnapshotRecoveryRestoreBackup,
+			Backup: func(string) (string, error) { return corrupted, nil },
+		})
+		require.ErrorContains(t, err, "failed to load its backup")
+
+		failing := errors.New("no backup")
+		_, _, err = newAM(t, corrupted, SnapshotRecoveryOptions{
+			Policy: SnapshotRecoveryRestoreBackup,
+			Backup: func(string) (string, error) { return "", failing },
+		})
+		require.ErrorIs(t, err, failing)
+	})
+}
+
+func TestSnapshotRecoveryOptions_Validate(t *testing.T) {
+	require.NoError(t, SnapshotRecoveryOptions{}.Validate())
+	require.NoError(t, SnapshotRecoveryOptions{Policy: SnapshotRecoveryStartEmpty}.Validate())
+	require.ErrorContains(t, SnapshotRecoveryOptions{Policy: SnapshotRecoveryRestoreBackup}.Validate(), "backup function is required")
+	require.ErrorContains(t, SnapshotRecoveryOptions{Policy: "ignore"}.Validate(), "unknown snapshot recovery policy")
+}
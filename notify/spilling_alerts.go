@@ -0,0 +1,474 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/provider"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/store"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// DefaultAlertColdAfter is for how long alerts must have been resolved to be spilled to disk by default, which
+	// is the default group interval, so that their resolved notifications were sent.
+	DefaultAlertColdAfter = 5 * time.Minute
+
+	// spillCheckInterval is the interval at which the alerts in memory are counted, and cold alerts are spilled to
+	// disk if there are too many of them.
+	spillCheckInterval = 10 * time.Second
+	// alertChannelLength is the length of the channels of the alert iterators, like in mem.Alerts.
+	alertChannelLength = 200
+)
+
+// alertProvider is the provider of the alerts of the Alertmanager: mem.Alerts, or spillingAlerts if
+// GrafanaAlertmanagerConfig.AlertStore is set.
+type alertProvider interface {
+	provider.Alerts
+	Close()
+}
+
+// AlertStoreOptions configure an alert store that bounds the number of alerts kept in memory, for tenants that burst
+// to very many alerts. Once there are more than MaxInMemoryAlerts alerts in memory, the cold alerts are spilled to a
+// file in Dir until they are garbage collected like the alerts in memory. Cold alerts are resolved for longer than
+// ColdAfter, so that their resolved notifications were sent and the dispatcher does not need them anymore. Firing
+// alerts are always kept in memory.
+type AlertStoreOptions struct {
+	MaxInMemoryAlerts int
+	// Dir is the directory of the file of the spilled alerts. The file is deleted when the Alertmanager stops.
+	Dir string
+	// ColdAfter defaults to DefaultAlertColdAfter.
+	ColdAfter time.Duration
+}
+
+func (o *AlertStoreOptions) Validate() error {
+	if o.MaxInMemoryAlerts <= 0 {
+		return errors.New("max in-memory alerts must be greater than zero")
+	}
+	if o.Dir == "" {
+		return errors.New("spill directory must be present")
+	}
+	if o.ColdAfter < 0 {
+		return errors.New("cold after must not be negative")
+	}
+	return nil
+}
+
+type listeningAlerts struct {
+	alerts chan *types.Alert
+	done   chan struct{}
+}
+
+// spillingAlerts is an alert provider like mem.Alerts, which spills cold alerts to disk, see AlertStoreOptions.
+// Spilled alerts are returned by Get and GetPending, and are moved back to memory when they are put again. They are
+// not returned by Subscribe: subscribers are the dispatchers and inhibitors of new configurations, to which resolved
+// alerts whose notifications were sent make no difference.
+type spillingAlerts struct {
+	opts     AlertStoreOptions
+	marker   types.Marker
+	callback mem.AlertStoreCallback
+	clock    clock.Clock
+	logger   log.Logger
+	cancel   context.CancelFunc
+
+	alerts *store.Alerts
+
+	// mtx serializes the changes to the alerts in memory and in the spill file, and protects the listeners.
+	mtx       sync.Mutex
+	listeners map[int]listeningAlerts
+	next      int
+	spilled   *alertSpillFile
+	// spilledCount is the number of spilled alerts, for the metrics.
+	spilledCount atomic.Int64
+}
+
+func newSpillingAlerts(opts AlertStoreOptions, m types.Marker, intervalGC time.Duration, callback mem.AlertStoreCallback, clk clock.Clock, l log.Logger, r prometheus.Registerer) (*spillingAlerts, error) {
+	if opts.ColdAfter == 0 {
+		opts.ColdAfter = DefaultAlertColdAfter
+	}
+	if callback == nil {
+		callback = noopAlertStoreCallback{}
+	}
+	spilled, err := newAlertSpillFile(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &spillingAlerts{
+		opts:      opts,
+		marker:    m,
+		callback:  callback,
+		clock:     clk,
+		logger:    log.With(l, "component", "provider"),
+		cancel:    cancel,
+		alerts:    store.NewAlerts(),
+		listeners: map[int]listeningAlerts{},
+		spilled:   spilled,
+	}
+	a.alerts.SetGCCallback(a.gc)
+	if r != nil {
+		a.registerMetrics(r)
+	}
+
+	go a.alerts.Run(ctx, intervalGC)
+	go a.runSpill(ctx)
+	return a, nil
+}
+
+func (a *spillingAlerts) registerMetrics(r prometheus.Registerer) {
+	newAlertsByState := func(s types.AlertState) prometheus.GaugeFunc {
+		return prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name:        "alertmanager_alerts",
+				Help:        "How many alerts by state.",
+				ConstLabels: prometheus.Labels{"state": string(s)},
+			},
+			func() float64 {
+				return float64(a.count(s))
+			},
+		)
+	}
+	r.MustRegister(newAlertsByState(types.AlertStateActive))
+	r.MustRegister(newAlertsByState(types.AlertStateSuppressed))
+	r.MustRegister(newAlertsByState(types.AlertStateUnprocessed))
+	r.MustRegister(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "alertmanager_alerts_spilled",
+			Help: "How many resolved alerts are spilled to disk.",
+		},
+		func() float64 {
+			return float64(a.spilledCount.Load())
+		},
+	))
+}
+
+// Close stops the garbage collection and the spilling of alerts, and deletes the spill file.
+func (a *spillingAlerts) Close() {
+	a.cancel()
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if err := a.spilled.close(); err != nil {
+		level.Warn(a.logger).Log("msg", "Failed to delete the file of spilled alerts", "err", err)
+	}
+}
+
+// Subscribe returns an iterator over the alerts in memory, and the alerts that are put afterwards.
+func (a *spillingAlerts) Subscribe() provider.AlertIterator {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	var (
+		done   = make(chan struct{})
+		alerts = a.alerts.List()
+		ch     = make(chan *types.Alert, max(len(alerts), alertChannelLength))
+	)
+	for _, alert := range alerts {
+		ch <- alert
+	}
+	a.listeners[a.next] = listeningAlerts{alerts: ch, done: done}
+	a.next++
+	return provider.NewAlertIterator(ch, done, nil)
+}
+
+// GetPending returns an iterator over the alerts in memory and the spilled alerts. Spilled alerts are read from disk
+// one at a time.
+func (a *spillingAlerts) GetPending() provider.AlertIterator {
+	var (
+		ch   = make(chan *types.Alert, alertChannelLength)
+		done = make(chan struct{})
+	)
+	a.mtx.Lock()
+	inMemory := a.alerts.List()
+	spilled := a.spilled.fingerprints()
+	a.mtx.Unlock()
+
+	go func() {
+		defer close(ch)
+		for _, alert := range inMemory {
+			select {
+			case ch <- alert:
+			case <-done:
+				return
+			}
+		}
+		for _, fp := range spilled {
+			a.mtx.Lock()
+			alert, err := a.spilled.get(fp)
+			a.mtx.Unlock()
+			if err != nil {
+				// The alert was put again, or garbage collected.
+				continue
+			}
+			select {
+			case ch <- alert:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return provider.NewAlertIterator(ch, done, nil)
+}
+
+// Get returns the alert for a given fingerprint, from memory or from disk.
+func (a *spillingAlerts) Get(fp model.Fingerprint) (*types.Alert, error) {
+	if alert, err := a.alerts.Get(fp); err == nil {
+		return alert, nil
+	}
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.spilled.get(fp)
+}
+
+// Put adds the given alerts to the alerts in memory, like mem.Alerts. Spilled alerts that are put again are moved
+// back to memory.
+func (a *spillingAlerts) Put(alerts ...*types.Alert) error {
+	for _, alert := range alerts {
+		a.put(alert)
+	}
+	return nil
+}
+
+func (a *spillingAlerts) put(alert *types.Alert) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	fp := alert.Fingerprint()
+	existing, spilled := false, false
+	old, err := a.alerts.Get(fp)
+	if err != nil {
+		if old, err = a.spilled.get(fp); err == nil {
+			spilled = true
+		}
+	}
+	if err == nil {
+		existing = true
+		// Merge alerts if there is an overlap in activity range.
+		if (alert.EndsAt.After(old.StartsAt) && alert.EndsAt.Before(old.EndsAt)) ||
+			(alert.StartsAt.After(old.StartsAt) && alert.StartsAt.Before(old.EndsAt)) {
+			alert = old.Merge(alert)
+		}
+	}
+
+	if err := a.callback.PreStore(alert, existing); err != nil {
+		level.Error(a.logger).Log("msg", "pre-store callback returned error on set alert", "err", err)
+		return
+	}
+	if err := a.alerts.Set(alert); err != nil {
+		level.Error(a.logger).Log("msg", "error on set alert", "err", err)
+		return
+	}
+	if spilled {
+		a.spilled.delete(fp)
+		a.spilledCount.Store(int64(a.spilled.len()))
+	}
+	a.callback.PostStore(alert, existing)
+
+	for _, l := range a.listeners {
+		select {
+		case l.alerts <- alert:
+		case <-l.done:
+		}
+	}
+}
+
+// runSpill spills cold alerts to disk if there are too many alerts in memory, until the context is canceled.
+func (a *spillingAlerts) runSpill(ctx context.Context) {
+	t := a.clock.Ticker(spillCheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			a.spill()
+		}
+	}
+}
+
+// spill spills the cold alerts in memory to disk, until there are at most MaxInMemoryAlerts alerts in memory, or
+// there are no more cold alerts.
+func (a *spillingAlerts) spill() {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	inMemory := a.alerts.List()
+	excess := len(inMemory) - a.opts.MaxInMemoryAlerts
+	if excess <= 0 {
+		return
+	}
+	cutoff := a.clock.Now().Add(-a.opts.ColdAfter)
+	var n int
+	for _, alert := range inMemory {
+		if n == excess {
+			break
+		}
+		if alert.EndsAt.IsZero() || !alert.EndsAt.Before(cutoff) {
+			continue
+		}
+		if err := a.spilled.put(alert); err != nil {
+			level.Error(a.logger).Log("msg", "Failed to spill alerts to disk", "err", err)
+			break
+		}
+		_ = a.alerts.Delete(alert.Fingerprint())
+		n++
+	}
+	a.spilledCount.Store(int64(a.spilled.len()))
+	if n < excess {
+		level.Warn(a.logger).Log("msg", "Alerts in memory exceed the limit, but there are no more cold alerts to spill to disk", "alerts", len(inMemory)-n, "limit", a.opts.MaxInMemoryAlerts)
+	} else {
+		level.Debug(a.logger).Log("msg", "Spilled cold alerts to disk", "spilled", n)
+	}
+}
+
+// gc is the garbage collection callback of the alerts in memory. Spilled alerts are resolved, so they are all
+// garbage collected with the resolved alerts in memory.
+func (a *spillingAlerts) gc(resolved []types.Alert) {
+	for _, alert := range resolved {
+		a.marker.Delete(alert.Fingerprint())
+		a.callback.PostDelete(&alert)
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	for _, fp := range a.spilled.fingerprints() {
+		alert, err := a.spilled.get(fp)
+		if err != nil {
+			level.Error(a.logger).Log("msg", "Failed to read spilled alert", "err", err)
+			continue
+		}
+		a.marker.Delete(fp)
+		a.callback.PostDelete(alert)
+	}
+	if err := a.spilled.reset(); err != nil {
+		level.Error(a.logger).Log("msg", "Failed to truncate the file of spilled alerts", "err", err)
+	}
+	a.spilledCount.Store(0)
+
+	for i, l := range a.listeners {
+		select {
+		case <-l.done:
+			delete(a.listeners, i)
+			close(l.alerts)
+		default:
+			// listener is not closed yet, hence proceed.
+		}
+	}
+}
+
+// count returns the number of non-resolved alerts in memory by state. Spilled alerts are resolved.
+func (a *spillingAlerts) count(state types.AlertState) int {
+	var count int
+	for _, alert := range a.alerts.List() {
+		if alert.Resolved() {
+			continue
+		}
+		if a.marker.Status(alert.Fingerprint()).State == state {
+			count++
+		}
+	}
+	return count
+}
+
+type noopAlertStoreCallback struct{}
+
+func (noopAlertStoreCallback) PreStore(*types.Alert, bool) error { return nil }
+func (noopAlertStoreCallback) PostStore(*types.Alert, bool)      {}
+func (noopAlertStoreCallback) PostDelete(*types.Alert)           {}
+
+// alertSpillFile is a file of alerts encoded in JSON, with an index of their position in memory. Alerts that are
+// spilled again or deleted leave their previous encoding in the file until it is truncated. It is not safe for
+// concurrent use.
+type alertSpillFile struct {
+	f     *os.File
+	size  int64
+	index map[model.Fingerprint]alertSpillEntry
+}
+
+type alertSpillEntry struct {
+	offset int64
+	length int
+}
+
+func newAlertSpillFile(dir string) (*alertSpillFile, error) {
+	f, err := os.CreateTemp(dir, "alerts-*.spill")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the file of spilled alerts: %w", err)
+	}
+	return &alertSpillFile{f: f, index: make(map[model.Fingerprint]alertSpillEntry)}, nil
+}
+
+func (s *alertSpillFile) put(alert *types.Alert) error {
+	b, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	if _, err := s.f.WriteAt(b, s.size); err != nil {
+		return err
+	}
+	s.index[alert.Fingerprint()] = alertSpillEntry{offset: s.size, length: len(b)}
+	s.size += int64(len(b))
+	return nil
+}
+
+// get returns the spilled alert, or store.ErrNotFound if it is not spilled, like store.Alerts.
+func (s *alertSpillFile) get(fp model.Fingerprint) (*types.Alert, error) {
+	e, ok := s.index[fp]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	b := make([]byte, e.length)
+	if _, err := s.f.ReadAt(b, e.offset); err != nil {
+		return nil, err
+	}
+	var alert types.Alert
+	if err := json.Unmarshal(b, &alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (s *alertSpillFile) delete(fp model.Fingerprint) {
+	delete(s.index, fp)
+}
+
+func (s *alertSpillFile) len() int {
+	return len(s.index)
+}
+
+func (s *alertSpillFile) fingerprints() []model.Fingerprint {
+	fps := make([]model.Fingerprint, 0, len(s.index))
+	for fp := range s.index {
+		fps = append(fps, fp)
+	}
+	return fps
+}
+
+// reset deletes all the spilled alerts and truncates the file.
+func (s *alertSpillFile) reset() error {
+	s.index = make(map[model.Fingerprint]alertSpillEntry)
+	s.size = 0
+	return s.f.Truncate(0)
+}
+
+func (s *alertSpillFile) close() error {
+	s.index = make(map[model.Fingerprint]alertSpillEntry)
+	if err := s.f.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return err
+	}
+	if err := os.Remove(s.f.Name()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
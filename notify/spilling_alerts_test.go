@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/store"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillingAlerts(t *testing.T) {
+	clk := clock.NewMock()
+	clk.Set(time.Now())
+	dir := t.TempDir()
+	reg := prometheus.NewPedanticRegistry()
+	marker := types.NewMarker(prometheus.NewRegistry())
+	alerts, err := newSpillingAlerts(AlertStoreOptions{MaxInMemoryAlerts: 2, Dir: dir}, marker, time.Hour, nil, clk, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	newAlert := func(name string, endsAt time.Time) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": model.LabelValue(name)},
+			StartsAt: clk.Now().Add(-time.Hour),
+			EndsAt:   endsAt,
+		}, UpdatedAt: clk.Now()}
+	}
+	firing := []*types.Alert{newAlert("firing1", clk.Now().Add(time.Hour)), newAlert("firing2", clk.Now().Add(time.Hour))}
+	cold := newAlert("cold", clk.Now().Add(-DefaultAlertColdAfter-time.Minute))
+	warm := newAlert("warm", clk.Now().Add(-time.Minute))
+	require.NoError(t, alerts.Put(append(firing, cold, warm)...))
+
+	pending := func() map[model.Fingerprint]*types.Alert {
+		res := map[model.Fingerprint]*types.Alert{}
+		it := alerts.GetPending()
+		defer it.Close()
+		for a := range it.Next() {
+			res[a.Fingerprint()] = a
+		}
+		require.NoError(t, it.Err())
+		return res
+	}
+
+	t.Run("only cold alerts are spilled to disk", func(t *testing.T) {
+		alerts.spill()
+		require.Len(t, alerts.alerts.List(), 3)
+		require.Equal(t, 1, alerts.spilled.len())
+		require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP alertmanager_alerts_spilled How many resolved alerts are spilled to disk.
+# TYPE alertmanager_alerts_spilled gauge
+alertmanager_alerts_spilled 1
+`), "alertmanager_alerts_spilled"))
+
+		_, err := alerts.alerts.Get(cold.Fingerprint())
+		require.ErrorIs(t, err, store.ErrNotFound)
+		got, err := alerts.Get(cold.Fingerprint())
+		require.NoError(t, err)
+		require.Equal(t, cold.Labels, got.Labels)
+		require.WithinDuration(t, cold.EndsAt, got.EndsAt, 0)
+
+		all := pending()
+		require.Len(t, all, 4)
+		require.Contains(t, all, cold.Fingerprint())
+	})
+
+	t.Run("spilled alerts are moved back to memory when they are put again", func(t *testing.T) {
+		refired := newAlert("cold", clk.Now().Add(time.Hour))
+		require.NoError(t, alerts.Put(refired))
+		require.Equal(t, 0, alerts.spilled.len())
+		got, err := alerts.alerts.Get(cold.Fingerprint())
+		require.NoError(t, err)
+		require.Equal(t, model.AlertFiring, got.Status())
+		require.Len(t, pending(), 4)
+	})
+
+	t.Run("spilled alerts are garbage collected with the resolved alerts", func(t *testing.T) {
+		require.NoError(t, alerts.Put(newAlert("cold", clk.Now().Add(-DefaultAlertColdAfter-time.Minute))))
+		alerts.spill()
+		require.Equal(t, 1, alerts.spilled.len())
+
+		alerts.gc(nil)
+		require.Equal(t, 0, alerts.spilled.len())
+		_, err := alerts.Get(cold.Fingerprint())
+		require.ErrorIs(t, err, store.ErrNotFound)
+		require.Len(t, pending(), 3)
+	})
+
+	t.Run("the spill file is deleted on close", func(t *testing.T) {
+		alerts.Close()
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+}
+
+func TestAlertStoreOptions_Validate(t *testing.T) {
+	require.NoError(t, (&AlertStoreOptions{MaxInMemoryAlerts: 1, Dir: "dir"}).Validate())
+	require.Error(t, (&AlertStoreOptions{Dir: "dir"}).Validate())
+	require.Error(t, (&AlertStoreOptions{MaxInMemoryAlerts: 1}).Validate())
+	require.Error(t, (&AlertStoreOptions{MaxInMemoryAlerts: 1, Dir: "dir", ColdAfter: -time.Minute}).Validate())
+}
@@ -0,0 +1,37 @@
+package state
+
+import (
+	"context"
+	"time"
+)
+
+// RedisClient is the client of Redis used by RedisStore, provided by the embedder with its own Redis library, such
+// as an adapter of the GET and SET commands of github.com/redis/go-redis.
+type RedisClient interface {
+	// Get returns the value of the key, or ErrNotFound if the key does not exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set sets the value of the key, which expires after the TTL. A zero TTL means the key does not expire.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// RedisStore is a Store that stores the snapshots in Redis.
+type RedisStore struct {
+	client RedisClient
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisStore returns a RedisStore whose keys have the prefix, such as "alerting:". The snapshots expire after
+// the TTL if it is not zero, so that the state of deleted tenants is eventually removed. It must be longer than
+// the maintenance frequency.
+func NewRedisStore(client RedisClient, prefix string, ttl time.Duration) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, ttl: ttl}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.client.Get(ctx, s.prefix+key)
+}
+
+func (s *RedisStore) Put(ctx context.Context, key string, value []byte) error {
+	return s.client.Set(ctx, s.prefix+key, value, s.ttl)
+}
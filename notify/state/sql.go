@@ -0,0 +1,88 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// SQLStore is a Store that stores the snapshots in a table of a SQL database, with the driver registered by the
+// embedder. The table must exist, with a primary key column state_key and a binary column state_value, such as:
+//
+//	CREATE TABLE alerting_state (state_key VARCHAR(255) PRIMARY KEY, state_value BLOB NOT NULL)
+//
+// The column of the values must be large enough for the snapshots, such as LONGBLOB in MySQL or BYTEA in PostgreSQL.
+type SQLStore struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+}
+
+var tableRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// QuestionPlaceholder returns the placeholders of the parameters of MySQL and SQLite, which are all ?.
+func QuestionPlaceholder(int) string {
+	return "?"
+}
+
+// DollarPlaceholder returns the placeholders of the parameters of PostgreSQL, which are $1, $2 and so on.
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// NewSQLStore returns a SQLStore that stores the snapshots in the table. placeholder returns the placeholder of the
+// nth parameter of the statements, starting at 1, see QuestionPlaceholder and DollarPlaceholder.
+func NewSQLStore(db *sql.DB, table string, placeholder func(n int) string) (*SQLStore, error) {
+	if !tableRegexp.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	if placeholder == nil {
+		placeholder = QuestionPlaceholder
+	}
+	return &SQLStore{db: db, table: table, placeholder: placeholder}, nil
+}
+
+func (s *SQLStore) Get(ctx context.Context, key string) ([]byte, error) {
+	var b []byte
+	q := fmt.Sprintf("SELECT state_value FROM %s WHERE state_key = %s", s.table, s.placeholder(1))
+	if err := s.db.QueryRowContext(ctx, q, key).Scan(&b); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// Put replaces the snapshot of the key in a transaction. Statements that are specific to a database, such as
+// INSERT ... ON CONFLICT, are not used so that the store works with any database.
+func (s *SQLStore) Put(ctx context.Context, key string, value []byte) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var exists int
+	q := fmt.Sprintf("SELECT 1 FROM %s WHERE state_key = %s", s.table, s.placeholder(1))
+	err = tx.QueryRowContext(ctx, q, key).Scan(&exists)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		q = fmt.Sprintf("INSERT INTO %s (state_key, state_value) VALUES (%s, %s)", s.table, s.placeholder(1), s.placeholder(2))
+		_, err = tx.ExecContext(ctx, q, key, value)
+	case err == nil:
+		q = fmt.Sprintf("UPDATE %s SET state_value = %s WHERE state_key = %s", s.table, s.placeholder(1), s.placeholder(2))
+		_, err = tx.ExecContext(ctx, q, value, key)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,245 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-openapi/strfmt"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/notify"
+)
+
+func TestNewMaintenanceOptions(t *testing.T) {
+	ctx := context.Background()
+	_, err := NewMaintenanceOptions(ctx, Options{Key: "silences/1"})
+	require.EqualError(t, err, "store must be present")
+	_, err = NewMaintenanceOptions(ctx, Options{Store: NewMemoryStore()})
+	require.EqualError(t, err, "key must be present")
+
+	store := NewMemoryStore()
+	opts, err := NewMaintenanceOptions(ctx, Options{Store: store, Key: "silences/1", Retention: time.Hour, MaintenanceFrequency: time.Minute})
+	require.NoError(t, err)
+	require.Empty(t, opts.InitialState())
+	require.Equal(t, time.Hour, opts.Retention())
+	require.Equal(t, time.Minute, opts.MaintenanceFrequency())
+
+	n, err := opts.MaintenanceFunc(fakeState("snapshot"))
+	require.NoError(t, err)
+	require.EqualValues(t, len("snapshot"), n)
+
+	opts, err = NewMaintenanceOptions(ctx, Options{Store: store, Key: "silences/1"})
+	require.NoError(t, err)
+	require.Equal(t, "snapshot", opts.InitialState())
+
+	_, err = NewMaintenanceOptions(ctx, Options{Store: failingStore{}, Key: "silences/1"})
+	require.EqualError(t, err, `failed to get the state of "silences/1": unavailable`)
+	opts.opts.Store = failingStore{}
+	_, err = opts.MaintenanceFunc(fakeState("snapshot"))
+	require.EqualError(t, err, `failed to put the state of "silences/1": unavailable`)
+}
+
+func TestMaintenanceOptions_Restart(t *testing.T) {
+	store := NewMemoryStore()
+	newAlertmanager := func() *notify.GrafanaAlertmanager {
+		t.Helper()
+		opts := func(key string) notify.MaintenanceOptions {
+			o, err := NewMaintenanceOptions(context.Background(), Options{Store: store, Key: key, Retention: time.Hour, MaintenanceFrequency: time.Hour})
+			require.NoError(t, err)
+			return o
+		}
+		reg := prometheus.NewPedanticRegistry()
+		am, err := notify.NewGrafanaAlertmanager("org", 1, &notify.GrafanaAlertmanagerConfig{
+			Silences: opts("silences/1"),
+			Nflog:    opts("nflog/1"),
+		}, &notify.NilPeer{}, log.NewNopLogger(), notify.NewGrafanaAlertmanagerMetrics(reg, log.NewNopLogger()))
+		require.NoError(t, err)
+		return am
+	}
+
+	am := newAlertmanager()
+	now := time.Now()
+	id, err := am.CreateSilence(&notify.PostableSilence{Silence: amv2.Silence{
+		Comment:   ptr("comment"),
+		CreatedBy: ptr("test"),
+		StartsAt:  ptr(strfmt.DateTime(now)),
+		EndsAt:    ptr(strfmt.DateTime(now.Add(time.Hour))),
+		Matchers:  amv2.Matchers{{Name: ptr("foo"), Value: ptr("bar"), IsEqual: ptr(true), IsRegex: ptr(false)}},
+	}})
+	require.NoError(t, err)
+	// The state is stored when the Alertmanager stops.
+	am.StopAndWait()
+
+	am = newAlertmanager()
+	defer am.StopAndWait()
+	silences, err := am.ListSilences(nil)
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	require.Equal(t, id, *silences[0].ID)
+}
+
+func TestRedisStore(t *testing.T) {
+	client := &fakeRedisClient{values: map[string][]byte{}}
+	s := NewRedisStore(client, "alerting:", time.Hour)
+
+	_, err := s.Get(context.Background(), "silences/1")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.NoError(t, s.Put(context.Background(), "silences/1", []byte("snapshot")))
+	require.Equal(t, map[string][]byte{"alerting:silences/1": []byte("snapshot")}, client.values)
+	require.Equal(t, time.Hour, client.ttl)
+	b, err := s.Get(context.Background(), "silences/1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("snapshot"), b)
+}
+
+func TestSQLStore(t *testing.T) {
+	_, err := NewSQLStore(nil, "state; DROP TABLE x", nil)
+	require.EqualError(t, err, `invalid table name "state; DROP TABLE x"`)
+
+	for name, placeholder := range map[string]func(int) string{"question": QuestionPlaceholder, "dollar": DollarPlaceholder} {
+		t.Run(name, func(t *testing.T) {
+			d := &fakeDriver{rows: map[string][]byte{}}
+			db := sql.OpenDB(d)
+			defer db.Close()
+			s, err := NewSQLStore(db, "alerting_state", placeholder)
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			_, err = s.Get(ctx, "silences/1")
+			require.ErrorIs(t, err, ErrNotFound)
+			require.NoError(t, s.Put(ctx, "silences/1", []byte("v1")))
+			require.NoError(t, s.Put(ctx, "silences/1", []byte("v2")))
+			require.NoError(t, s.Put(ctx, "nflog/1", []byte("v3")))
+			b, err := s.Get(ctx, "silences/1")
+			require.NoError(t, err)
+			require.Equal(t, []byte("v2"), b)
+			require.Equal(t, map[string][]byte{"silences/1": []byte("v2"), "nflog/1": []byte("v3")}, d.rows)
+			for _, q := range d.queries {
+				require.Equal(t, name == "dollar", strings.Contains(q, "$1"), q)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+type fakeState string
+
+func (s fakeState) MarshalBinary() ([]byte, error) {
+	return []byte(s), nil
+}
+
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, error) {
+	return nil, errors.New("unavailable")
+}
+
+func (failingStore) Put(context.Context, string, []byte) error {
+	return errors.New("unavailable")
+}
+
+type fakeRedisClient struct {
+	values map[string][]byte
+	ttl    time.Duration
+}
+
+func (c *fakeRedisClient) Get(_ context.Context, key string) ([]byte, error) {
+	b, ok := c.values[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return b, nil
+}
+
+func (c *fakeRedisClient) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.values[key] = value
+	c.ttl = ttl
+	return nil
+}
+
+// fakeDriver is a database/sql driver that runs the statements of SQLStore on a map.
+type fakeDriver struct {
+	mtx     sync.Mutex
+	rows    map[string][]byte
+	queries []string
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+func (d *fakeDriver) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{d: c.d, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeConn) Commit() error             { return nil }
+func (c *fakeConn) Rollback() error           { return nil }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mtx.Lock()
+	defer s.d.mtx.Unlock()
+	s.d.queries = append(s.d.queries, s.query)
+	switch {
+	case strings.HasPrefix(s.query, "INSERT"):
+		s.d.rows[args[0].(string)] = args[1].([]byte)
+	case strings.HasPrefix(s.query, "UPDATE"):
+		s.d.rows[args[1].(string)] = args[0].([]byte)
+	default:
+		return nil, errors.New("unsupported statement")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mtx.Lock()
+	defer s.d.mtx.Unlock()
+	s.d.queries = append(s.d.queries, s.query)
+	b, ok := s.d.rows[args[0].(string)]
+	if !ok {
+		return &fakeRows{}, nil
+	}
+	if strings.HasPrefix(s.query, "SELECT 1") {
+		return &fakeRows{values: []driver.Value{int64(1)}}, nil
+	}
+	return &fakeRows{values: []driver.Value{b}}, nil
+}
+
+type fakeRows struct {
+	values []driver.Value
+	done   bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"value"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done || r.values == nil {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.values)
+	return nil
+}
@@ -0,0 +1,135 @@
+// Package state stores the state of the Alertmanager, such as silences and the notification log, in external
+// storage, so that it is recovered after restarts without persistent volumes.
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/alerting/notify"
+)
+
+// DefaultTimeout is the timeout of the operations of the store if none is configured.
+const DefaultTimeout = 30 * time.Second
+
+// ErrNotFound is returned by stores for keys without state.
+var ErrNotFound = errors.New("state not found")
+
+// Store stores the snapshots of the state of the Alertmanager by key.
+type Store interface {
+	// Get returns the snapshot of the key, or ErrNotFound if there is none.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put replaces the snapshot of the key.
+	Put(ctx context.Context, key string, value []byte) error
+}
+
+// Options configure the maintenance of a state with a store.
+type Options struct {
+	// Store stores the snapshots. It is required.
+	Store Store
+	// Key is the key of the snapshots, such as "silences/1" for the silences of tenant 1. It is required.
+	Key string
+	// Retention is for how long the artefacts of the state are kept.
+	Retention time.Duration
+	// MaintenanceFrequency is how often the state is stored.
+	MaintenanceFrequency time.Duration
+	// Timeout is the timeout of the operations of the store. It defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+func (o *Options) Validate() error {
+	if o.Store == nil {
+		return errors.New("store must be present")
+	}
+	if o.Key == "" {
+		return errors.New("key must be present")
+	}
+	if o.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
+
+// MaintenanceOptions are notify.MaintenanceOptions that store the snapshots of the state in a store.
+type MaintenanceOptions struct {
+	opts         Options
+	initialState string
+}
+
+var _ notify.MaintenanceOptions = (*MaintenanceOptions)(nil)
+
+// NewMaintenanceOptions returns MaintenanceOptions whose initial state is the snapshot in the store. The state is
+// empty if the store has no snapshot for the key.
+func NewMaintenanceOptions(ctx context.Context, opts Options) (*MaintenanceOptions, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	b, err := opts.Store.Get(ctx, opts.Key)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("failed to get the state of %q: %w", opts.Key, err)
+	}
+	return &MaintenanceOptions{opts: opts, initialState: string(b)}, nil
+}
+
+func (m *MaintenanceOptions) InitialState() string {
+	return m.initialState
+}
+
+func (m *MaintenanceOptions) Retention() time.Duration {
+	return m.opts.Retention
+}
+
+func (m *MaintenanceOptions) MaintenanceFrequency() time.Duration {
+	return m.opts.MaintenanceFrequency
+}
+
+// MaintenanceFunc stores the snapshot of the state.
+func (m *MaintenanceOptions) MaintenanceFunc(state notify.State) (int64, error) {
+	b, err := state.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.opts.Timeout)
+	defer cancel()
+	if err := m.opts.Store.Put(ctx, m.opts.Key, b); err != nil {
+		return 0, fmt.Errorf("failed to put the state of %q: %w", m.opts.Key, err)
+	}
+	return int64(len(b)), nil
+}
+
+// MemoryStore is a Store for a single process. It can be used in tests, or to keep the state of Alertmanagers that
+// are restarted in the same process.
+type MemoryStore struct {
+	mtx    sync.RWMutex
+	states map[string][]byte
+}
+
+// NewMemoryStore returns a new MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string][]byte)}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	b, ok := s.states[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return b, nil
+}
+
+func (s *MemoryStore) Put(_ context.Context, key string, value []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.states[key] = append([]byte(nil), value...)
+	return nil
+}
@@ -0,0 +1,268 @@
+package notify
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// SuppressionReason is the reason an alert was suppressed.
+type SuppressionReason string
+
+const (
+	SuppressionReasonSilence          SuppressionReason = "silence"
+	SuppressionReasonInhibition       SuppressionReason = "inhibition"
+	SuppressionReasonMuteTimeInterval SuppressionReason = "mute_time_interval"
+)
+
+const (
+	// suppressionRetention is for how long the suppression of an alert is kept after the alert is deleted, so that
+	// it can be reported after the incident.
+	suppressionRetention = 24 * time.Hour
+	// maxSuppressedIntervals is the maximum number of intervals kept per alert. The oldest are discarded first,
+	// but their durations are still counted.
+	maxSuppressedIntervals = 100
+)
+
+// SuppressedInterval is an interval during which an alert was suppressed.
+type SuppressedInterval struct {
+	Reason SuppressionReason
+	// SuppressedBy are the IDs of the silences, the fingerprints of the inhibiting alerts or the names of the mute
+	// time intervals. A change of them starts a new interval.
+	SuppressedBy []string
+	StartsAt     time.Time
+	// EndsAt is zero while the alert is suppressed.
+	EndsAt time.Time
+}
+
+// AlertSuppression is the time an alert spent suppressed.
+type AlertSuppression struct {
+	Fingerprint model.Fingerprint
+	// Intervals are the intervals during which the alert was suppressed, the oldest first.
+	Intervals []SuppressedInterval
+	// Durations are the total durations of the intervals by reason, up to now for the intervals that have not ended.
+	Durations map[SuppressionReason]time.Duration
+	// DeletedAt is the time the alert was deleted from the Alertmanager, or zero if it was not.
+	DeletedAt time.Time
+}
+
+// suppressionTracker tracks the intervals during which alerts are suppressed. Suppression is observed as the
+// notification pipeline and the API evaluate the alerts, so intervals start and end when the change is observed.
+// Intervals are tracked per reason, an alert that is silenced and inhibited at the same time is in two intervals.
+type suppressionTracker struct {
+	mtx    sync.Mutex
+	alerts map[model.Fingerprint]*trackedSuppression
+	lastGC time.Time
+	now    func() time.Time
+	// seconds counts the duration of the intervals by reason, when they end.
+	seconds *prometheus.CounterVec
+}
+
+type trackedSuppression struct {
+	open      map[SuppressionReason]SuppressedInterval
+	closed    []SuppressedInterval
+	durations map[SuppressionReason]time.Duration
+	deletedAt time.Time
+}
+
+func newSuppressionTracker(seconds *prometheus.CounterVec) *suppressionTracker {
+	return &suppressionTracker{
+		alerts:  make(map[model.Fingerprint]*trackedSuppression),
+		now:     time.Now,
+		seconds: seconds,
+	}
+}
+
+// set records that the alert is suppressed for the reason by the IDs, or that it is not if there are none.
+func (t *suppressionTracker) set(fp model.Fingerprint, reason SuppressionReason, ids []string) {
+	now := t.now()
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	s, ok := t.alerts[fp]
+	if len(ids) == 0 {
+		if ok {
+			t.end(s, reason, now)
+		}
+		return
+	}
+	if !ok {
+		s = &trackedSuppression{
+			open:      make(map[SuppressionReason]SuppressedInterval),
+			durations: make(map[SuppressionReason]time.Duration),
+		}
+		t.alerts[fp] = s
+	}
+	s.deletedAt = time.Time{}
+
+	ids = slices.Clone(ids)
+	sort.Strings(ids)
+	if open, ok := s.open[reason]; ok {
+		if slices.Equal(open.SuppressedBy, ids) {
+			return
+		}
+		t.end(s, reason, now)
+	}
+	s.open[reason] = SuppressedInterval{
+		Reason:       reason,
+		SuppressedBy: ids,
+		StartsAt:     now,
+	}
+}
+
+// end ends the interval of the reason, if the alert is in one.
+func (t *suppressionTracker) end(s *trackedSuppression, reason SuppressionReason, now time.Time) {
+	interval, ok := s.open[reason]
+	if !ok {
+		return
+	}
+	delete(s.open, reason)
+	interval.EndsAt = now
+	d := interval.EndsAt.Sub(interval.StartsAt)
+	s.durations[reason] += d
+	t.seconds.WithLabelValues(string(reason)).Add(d.Seconds())
+
+	s.closed = append(s.closed, interval)
+	if len(s.closed) > maxSuppressedIntervals {
+		s.closed = s.closed[len(s.closed)-maxSuppressedIntervals:]
+	}
+}
+
+// delete ends the intervals of an alert deleted from the Alertmanager. The suppression of the alert is kept for
+// suppressionRetention.
+func (t *suppressionTracker) delete(fp model.Fingerprint) {
+	now := t.now()
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if s, ok := t.alerts[fp]; ok {
+		for reason := range s.open {
+			t.end(s, reason, now)
+		}
+		s.deletedAt = now
+	}
+
+	// Deletes happen in batches as the alerts are garbage collected, so retention is not enforced on every one.
+	if now.Sub(t.lastGC) < time.Minute {
+		return
+	}
+	t.lastGC = now
+	for fp, s := range t.alerts {
+		if !s.deletedAt.IsZero() && now.Sub(s.deletedAt) > suppressionRetention {
+			delete(t.alerts, fp)
+		}
+	}
+}
+
+// discard forgets the suppression of an alert without counting it, such as for alerts that were only previewed.
+func (t *suppressionTracker) discard(fp model.Fingerprint) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	delete(t.alerts, fp)
+}
+
+// get returns the suppression of the alerts, sorted by fingerprint, or of all tracked alerts if none is given.
+// Alerts that were never suppressed are left out.
+func (t *suppressionTracker) get(fps ...model.Fingerprint) []AlertSuppression {
+	now := t.now()
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if len(fps) == 0 {
+		fps = make([]model.Fingerprint, 0, len(t.alerts))
+		for fp := range t.alerts {
+			fps = append(fps, fp)
+		}
+	}
+	sort.Slice(fps, func(i, j int) bool { return fps[i] < fps[j] })
+
+	res := make([]AlertSuppression, 0, len(fps))
+	for _, fp := range fps {
+		s, ok := t.alerts[fp]
+		if !ok || (!s.deletedAt.IsZero() && now.Sub(s.deletedAt) > suppressionRetention) {
+			continue
+		}
+		r := AlertSuppression{
+			Fingerprint: fp,
+			Intervals:   make([]SuppressedInterval, 0, len(s.closed)+len(s.open)),
+			Durations:   make(map[SuppressionReason]time.Duration, len(s.durations)+len(s.open)),
+			DeletedAt:   s.deletedAt,
+		}
+		r.Intervals = append(r.Intervals, s.closed...)
+		for reason, d := range s.durations {
+			r.Durations[reason] = d
+		}
+		open := make([]SuppressedInterval, 0, len(s.open))
+		for reason, interval := range s.open {
+			open = append(open, interval)
+			r.Durations[reason] += now.Sub(interval.StartsAt)
+		}
+		sort.Slice(open, func(i, j int) bool {
+			if open[i].StartsAt.Equal(open[j].StartsAt) {
+				return open[i].Reason < open[j].Reason
+			}
+			return open[i].StartsAt.Before(open[j].StartsAt)
+		})
+		r.Intervals = append(r.Intervals, open...)
+		res = append(res, r)
+	}
+	return res
+}
+
+// suppressionMarker is a types.Marker that tracks the silencing and inhibition of alerts.
+type suppressionMarker struct {
+	types.Marker
+	tracker *suppressionTracker
+}
+
+func (m *suppressionMarker) SetActiveOrSilenced(fp model.Fingerprint, version int, activeSilenceIDs, pendingSilenceIDs []string) {
+	m.Marker.SetActiveOrSilenced(fp, version, activeSilenceIDs, pendingSilenceIDs)
+	m.tracker.set(fp, SuppressionReasonSilence, activeSilenceIDs)
+}
+
+func (m *suppressionMarker) SetInhibited(fp model.Fingerprint, alertIDs ...string) {
+	m.Marker.SetInhibited(fp, alertIDs...)
+	m.tracker.set(fp, SuppressionReasonInhibition, alertIDs)
+}
+
+func (m *suppressionMarker) Delete(fp model.Fingerprint) {
+	m.Marker.Delete(fp)
+	m.tracker.delete(fp)
+}
+
+// timeMuteTrackingStage tracks the alerts muted by the time mute stage that it wraps. Mute time intervals apply to
+// routes, so the alerts of several routes are tracked as muted by the last route that notified them.
+type timeMuteTrackingStage struct {
+	stage   notify.Stage
+	tracker *suppressionTracker
+}
+
+func (s *timeMuteTrackingStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	ctx, res, err := s.stage.Exec(ctx, l, alerts...)
+	if err != nil {
+		return ctx, res, err
+	}
+	var names []string
+	if len(res) == 0 {
+		names, _ = notify.MuteTimeIntervalNames(ctx)
+	}
+	for _, a := range alerts {
+		s.tracker.set(a.Fingerprint(), SuppressionReasonMuteTimeInterval, names)
+	}
+	return ctx, res, nil
+}
+
+// GetAlertSuppressions returns the time the alerts spent suppressed by silences, inhibitions and mute time
+// intervals, or of all alerts if no fingerprint is given. Alerts that were never suppressed are left out, and alerts
+// are kept for a day after they are deleted.
+func (am *GrafanaAlertmanager) GetAlertSuppressions(fps ...model.Fingerprint) []AlertSuppression {
+	return am.suppressions.get(fps...)
+}
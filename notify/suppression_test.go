@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/featurecontrol"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSuppressionTracker(now *time.Time) *suppressionTracker {
+	t := newSuppressionTracker(prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test"}, []string{"reason"}))
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func TestSuppressionTracker(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	tracker := newTestSuppressionTracker(&now)
+	marker := &suppressionMarker{Marker: types.NewMarker(prometheus.NewPedanticRegistry()), tracker: tracker}
+	fp := model.Fingerprint(1)
+
+	// Alerts that were never suppressed are not tracked.
+	marker.SetActiveOrSilenced(fp, 0, nil, nil)
+	marker.SetInhibited(fp)
+	require.Empty(t, tracker.get())
+
+	marker.SetActiveOrSilenced(fp, 1, []string{"silence-1"}, nil)
+	now = now.Add(time.Hour)
+	// The same silences continue the interval.
+	marker.SetActiveOrSilenced(fp, 1, []string{"silence-1"}, []string{"silence-2"})
+	marker.SetInhibited(fp, "2")
+	now = now.Add(time.Hour)
+	// Other silences start a new interval.
+	marker.SetActiveOrSilenced(fp, 2, []string{"silence-2", "silence-1"}, nil)
+	now = now.Add(time.Hour)
+	marker.SetActiveOrSilenced(fp, 3, nil, nil)
+	now = now.Add(time.Hour)
+
+	require.Equal(t, []AlertSuppression{{
+		Fingerprint: fp,
+		Intervals: []SuppressedInterval{
+			{Reason: SuppressionReasonSilence, SuppressedBy: []string{"silence-1"}, StartsAt: start, EndsAt: start.Add(2 * time.Hour)},
+			{Reason: SuppressionReasonSilence, SuppressedBy: []string{"silence-1", "silence-2"}, StartsAt: start.Add(2 * time.Hour), EndsAt: start.Add(3 * time.Hour)},
+			{Reason: SuppressionReasonInhibition, SuppressedBy: []string{"2"}, StartsAt: start.Add(time.Hour)},
+		},
+		Durations: map[SuppressionReason]time.Duration{
+			SuppressionReasonSilence:    3 * time.Hour,
+			SuppressionReasonInhibition: 3 * time.Hour,
+		},
+	}}, tracker.get(fp, model.Fingerprint(2)))
+	require.Equal(t, float64(3*60*60), testutil.ToFloat64(tracker.seconds.WithLabelValues("silence")))
+	require.Equal(t, 0.0, testutil.ToFloat64(tracker.seconds.WithLabelValues("inhibition")))
+
+	// Deleting the alert ends its intervals, and its suppression is kept for the retention.
+	marker.Delete(fp)
+	res := tracker.get()
+	require.Len(t, res, 1)
+	require.Equal(t, now, res[0].DeletedAt)
+	require.Equal(t, now, res[0].Intervals[2].EndsAt)
+	require.Equal(t, float64(3*60*60), testutil.ToFloat64(tracker.seconds.WithLabelValues("inhibition")))
+
+	now = now.Add(suppressionRetention + time.Minute)
+	require.Empty(t, tracker.get())
+	marker.Delete(model.Fingerprint(3))
+	require.Empty(t, tracker.alerts)
+}
+
+func TestSuppressionTracker_MaxIntervals(t *testing.T) {
+	now := time.Now()
+	tracker := newTestSuppressionTracker(&now)
+	for i := 0; i < maxSuppressedIntervals+10; i++ {
+		tracker.set(1, SuppressionReasonSilence, []string{"silence"})
+		now = now.Add(time.Minute)
+		tracker.set(1, SuppressionReasonSilence, nil)
+	}
+	res := tracker.get()
+	require.Len(t, res, 1)
+	require.Len(t, res[0].Intervals, maxSuppressedIntervals)
+	// The durations include the discarded intervals.
+	require.Equal(t, time.Duration(maxSuppressedIntervals+10)*time.Minute, res[0].Durations[SuppressionReasonSilence])
+}
+
+func TestTimeMuteTrackingStage(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tracker := newTestSuppressionTracker(&now)
+	intervener := timeinterval.NewIntervener(map[string][]timeinterval.TimeInterval{
+		"mornings": {{Times: []timeinterval.TimeRange{{StartMinute: 8 * 60, EndMinute: 12 * 60}}}},
+	})
+	stage := &timeMuteTrackingStage{
+		stage:   notify.NewTimeMuteStage(intervener, notify.NewMetrics(prometheus.NewPedanticRegistry(), featurecontrol.NoopFlags{})),
+		tracker: tracker,
+	}
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+
+	exec := func() []*types.Alert {
+		ctx := notify.WithMuteTimeIntervals(context.Background(), []string{"mornings"})
+		ctx = notify.WithNow(ctx, now)
+		_, res, err := stage.Exec(ctx, log.NewNopLogger(), alert)
+		require.NoError(t, err)
+		return res
+	}
+
+	require.Empty(t, exec())
+	now = now.Add(3 * time.Hour)
+	require.Len(t, exec(), 1)
+
+	res := tracker.get()
+	require.Len(t, res, 1)
+	require.Equal(t, []SuppressedInterval{{
+		Reason:       SuppressionReasonMuteTimeInterval,
+		SuppressedBy: []string{"mornings"},
+		StartsAt:     now.Add(-3 * time.Hour),
+		EndsAt:       now,
+	}}, res[0].Intervals)
+}
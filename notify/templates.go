@@ -3,7 +3,10 @@ package notify
 import (
 	"context"
 	"net/url"
+	"regexp"
+	"strconv"
 	tmpltext "text/template"
+	"time"
 
 	"github.com/go-kit/log/level"
 	"github.com/grafana/alerting/templates"
@@ -52,6 +55,57 @@ const (
 	ExecutionError  TemplateErrorKind = "execution_error"
 )
 
+// TemplateDiagnostics are the results of rendering the top-level definitions of a template, see DiagnoseTemplate.
+type TemplateDiagnostics struct {
+	// Definitions are the results of the top-level definitions, sorted by name. There are none if the template
+	// failed to parse.
+	Definitions []TemplateDefinitionDiagnostics `json:"definitions"`
+
+	// Error is the error that prevented the template from being parsed, if any.
+	Error *TemplateError `json:"error,omitempty"`
+}
+
+type TemplateDefinitionDiagnostics struct {
+	// Name of the definition.
+	Name string `json:"name"`
+
+	// Interpolated value of the definition. It is empty if the definition failed to render.
+	Text string `json:"text"`
+
+	// Duration is how long the definition took to render.
+	Duration time.Duration `json:"duration"`
+
+	// Error is the error that prevented the definition from being rendered, if any.
+	Error *TemplateError `json:"error,omitempty"`
+}
+
+// TemplateError is an error of a template, with its position if it is known.
+type TemplateError struct {
+	Kind TemplateErrorKind `json:"kind"`
+
+	// Message is the error as returned by the template engine, including its position.
+	Message string `json:"message"`
+
+	// Line and Column are the position of the error in the template text that it occurred in, starting at 1. They
+	// are zero if they are not known. Parse errors only have a line.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+// templateErrorPosition matches the position of the errors of text/template, such as "template: name:1:12: ".
+var templateErrorPosition = regexp.MustCompile(`^template: [^:]*:(\d+)(?::(\d+))?: `)
+
+func newTemplateError(kind TemplateErrorKind, err error) *TemplateError {
+	res := &TemplateError{Kind: kind, Message: err.Error()}
+	if m := templateErrorPosition.FindStringSubmatch(res.Message); m != nil {
+		res.Line, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			res.Column, _ = strconv.Atoi(m[2])
+		}
+	}
+	return res
+}
+
 const (
 	DefaultReceiverName    = "TestReceiver"
 	DefaultGroupLabel      = "group_label"
@@ -93,6 +147,60 @@ func (am *GrafanaAlertmanager) GetTemplate() (*template.Template, error) {
 	return tmpl, nil
 }
 
+// DiagnoseTemplate renders each top-level definition of the given template string against the given alerts, and
+// returns how long each of them took and the positions of the errors. Existing templates are used like in TestTemplate.
+func (am *GrafanaAlertmanager) DiagnoseTemplate(ctx context.Context, c TestTemplatesConfigBodyParams) (*TemplateDiagnostics, error) {
+	am.reloadConfigMtx.RLock()
+	tmpls := make([]templates.TemplateDefinition, len(am.templates))
+	copy(tmpls, am.templates)
+	am.reloadConfigMtx.RUnlock()
+
+	return DiagnoseTemplate(ctx, c, tmpls, am.ExternalURL(), am.logger)
+}
+
+// ApplyTemplates replaces the templates of the notifications without building the receivers and the routing tree
+// again, so that alert groups are not flushed. The templates are parsed before they replace the current ones, which
+// are kept if they fail to parse. The configuration and its hash are not changed. Like ApplyConfig, it is not safe to
+// call concurrently.
+func (am *GrafanaAlertmanager) ApplyTemplates(tmpls []templates.TemplateDefinition) error {
+	if am.stopped() {
+		return ErrAlertmanagerStopped
+	}
+	resolved, tmpl, err := am.buildTemplate(tmpls)
+	if err != nil {
+		return err
+	}
+	am.templates = resolved
+	am.template.Store(tmpl)
+	return nil
+}
+
+// buildTemplate resolves the external templates of the definitions, and parses them. Definitions with the same name
+// as an earlier one are skipped.
+func (am *GrafanaAlertmanager) buildTemplate(tmpls []templates.TemplateDefinition) ([]templates.TemplateDefinition, *templates.Template, error) {
+	resolved, err := am.resolveTemplates(tmpls)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := make(map[string]struct{})
+	contents := make([]string, 0, len(resolved))
+	for _, tc := range resolved {
+		if _, ok := seen[tc.Name]; ok {
+			level.Warn(am.logger).Log("msg", "template with same name is defined multiple times, skipping...", "template_name", tc.Name)
+			continue
+		}
+		contents = append(contents, tc.Template)
+		seen[tc.Name] = struct{}{}
+	}
+
+	tmpl, err := templateFromContent(contents, am.ExternalURL())
+	if err != nil {
+		return nil, nil, err
+	}
+	return resolved, tmpl, nil
+}
+
 // parseTestTemplate parses the test template and returns the top-level definitions that should be interpolated as results.
 func parseTestTemplate(name string, text string) ([]string, error) {
 	tmpl, err := tmpltext.New(name).Funcs(tmpltext.FuncMap(template.DefaultFuncs)).Parse(text)
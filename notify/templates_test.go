@@ -3,11 +3,16 @@ package notify
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/grafana/alerting/definition"
 	"github.com/grafana/alerting/templates"
 
+	"github.com/go-kit/log"
 	"github.com/go-openapi/strfmt"
 	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -419,3 +424,148 @@ CommonAnnotations: {{ range .CommonAnnotations.SortedPairs }}{{ .Name }}={{ .Val
 		})
 	}
 }
+
+func TestDiagnoseTemplate(t *testing.T) {
+	am, _ := setupAMTest(t)
+
+	t.Run("definitions are rendered with their duration and the positions of their errors", func(t *testing.T) {
+		res, err := am.DiagnoseTemplate(context.Background(), TestTemplatesConfigBodyParams{
+			Alerts: []*amv2.PostableAlert{&simpleAlert},
+			Name:   "slack",
+			Template: `{{ define "slack.title" }}{{ .Status }}{{ end }}
+{{ define "slack.text" }}
+  {{ template "missing" . }}{{ end }}`,
+		})
+		require.NoError(t, err)
+		require.Nil(t, res.Error)
+		require.Len(t, res.Definitions, 2)
+
+		title := res.Definitions[1]
+		require.Equal(t, "slack.title", title.Name)
+		require.Equal(t, "firing", title.Text)
+		require.Nil(t, title.Error)
+		require.Positive(t, title.Duration)
+
+		text := res.Definitions[0]
+		require.Equal(t, "slack.text", text.Name)
+		require.Empty(t, text.Text)
+		require.Equal(t, &TemplateError{
+			Kind:    ExecutionError,
+			Message: `template: :3:14: executing "slack.text" at <{{template "missing" .}}>: template "missing" not defined`,
+			Line:    3,
+			Column:  14,
+		}, text.Error)
+	})
+
+	t.Run("parse errors have a line", func(t *testing.T) {
+		res, err := am.DiagnoseTemplate(context.Background(), TestTemplatesConfigBodyParams{
+			Alerts:   []*amv2.PostableAlert{&simpleAlert},
+			Name:     "slack",
+			Template: "{{ define \"slack.title\" }}\n{{ .Status }\n{{ end }}",
+		})
+		require.NoError(t, err)
+		require.Empty(t, res.Definitions)
+		require.NotNil(t, res.Error)
+		require.Equal(t, InvalidTemplate, res.Error.Kind)
+		require.Equal(t, 2, res.Error.Line)
+		require.Zero(t, res.Error.Column)
+	})
+}
+
+// templatesConfig is a Configuration with templates and a receiver whose integration sends the rendered "msg"
+// template to sent.
+type templatesConfig struct {
+	grafanaRoutingTreeConfig
+	tmpls []templates.TemplateDefinition
+	sent  chan string
+}
+
+func (c *templatesConfig) Templates() []templates.TemplateDefinition { return c.tmpls }
+
+func (c *templatesConfig) Receivers() []*APIReceiver {
+	return []*APIReceiver{{ConfigReceiver: ConfigReceiver{Name: "default"}}}
+}
+
+func (c *templatesConfig) BuildReceiverIntegrationsFunc() func(*APIReceiver, *templates.Template) ([]*Integration, error) {
+	return func(r *APIReceiver, tmpl *templates.Template) ([]*Integration, error) {
+		n := &renderingNotifier{tmpl: tmpl, sent: c.sent}
+		return []*Integration{NewIntegration(n, sendResolved(true), "webhook", 0, r.Name)}, nil
+	}
+}
+
+type renderingNotifier struct {
+	tmpl *templates.Template
+	sent chan string
+}
+
+func (n *renderingNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	var tmplErr error
+	text, _ := templates.TmplText(ctx, n.tmpl, alerts, log.NewNopLogger(), &tmplErr)
+	msg := text(`{{ template "msg" . }}`)
+	if tmplErr != nil {
+		return false, tmplErr
+	}
+	select {
+	case n.sent <- msg:
+	default:
+	}
+	return false, nil
+}
+
+func TestApplyTemplates(t *testing.T) {
+	am, _ := setupAMTest(t)
+	interval := model.Duration(10 * time.Millisecond)
+	cfg := &templatesConfig{
+		grafanaRoutingTreeConfig: grafanaRoutingTreeConfig{route: &definition.Route{
+			Receiver:       "default",
+			GroupByStr:     []string{"alertname"},
+			GroupBy:        []model.LabelName{"alertname"},
+			GroupWait:      &interval,
+			GroupInterval:  &interval,
+			RepeatInterval: &interval,
+		}},
+		tmpls: []templates.TemplateDefinition{{Name: "msg", Template: `{{ define "msg" }}v1{{ end }}`}},
+		sent:  make(chan string, 1),
+	}
+	require.NoError(t, am.ApplyConfig(cfg))
+	dispatcher := am.dispatcher
+
+	alert := PostableAlert{Alert: amv2.Alert{Labels: amv2.LabelSet{"alertname": "test"}}}
+	require.NoError(t, am.PutAlerts(PostableAlerts{&alert}))
+	waitForMessage := func(expected string) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			select {
+			case msg := <-cfg.sent:
+				return msg == expected
+			default:
+				return false
+			}
+		}, 5*time.Second, time.Millisecond)
+	}
+	waitForMessage("v1")
+
+	require.NoError(t, am.ApplyTemplates([]templates.TemplateDefinition{{Name: "msg", Template: `{{ define "msg" }}v2{{ end }}`}}))
+	waitForMessage("v2")
+	require.Same(t, dispatcher, am.dispatcher, "the dispatcher should not be replaced")
+	require.Equal(t, `{{ define "msg" }}v2{{ end }}`, am.templates[0].Template)
+
+	// Templates that fail to parse do not replace the current ones.
+	require.Error(t, am.ApplyTemplates([]templates.TemplateDefinition{{Name: "msg", Template: `{{ define "msg" }}v3`}}))
+	require.Equal(t, `{{ define "msg" }}v2{{ end }}`, am.templates[0].Template)
+	waitForMessage("v2")
+
+	// Neither do the templates of a configuration that is rejected.
+	rejected := &rejectedTemplatesConfig{templatesConfig: *cfg}
+	rejected.tmpls = []templates.TemplateDefinition{{Name: "msg", Template: `{{ define "msg" }}v4{{ end }}`}}
+	require.Error(t, am.ApplyConfig(rejected))
+	require.Equal(t, `{{ define "msg" }}v2{{ end }}`, am.templates[0].Template)
+	waitForMessage("v2")
+}
+
+// rejectedTemplatesConfig is a templatesConfig with an invalid resolve timeout.
+type rejectedTemplatesConfig struct {
+	templatesConfig
+}
+
+func (c *rejectedTemplatesConfig) ResolveTimeout() time.Duration { return -time.Minute }
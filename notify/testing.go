@@ -5,18 +5,23 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/prometheus/alertmanager/types"
 
 	"github.com/grafana/alerting/receivers/alertmanager"
+	"github.com/grafana/alerting/receivers/blackhole"
 	"github.com/grafana/alerting/receivers/dinding"
 	"github.com/grafana/alerting/receivers/discord"
+	"github.com/grafana/alerting/receivers/echo"
 	"github.com/grafana/alerting/receivers/email"
+	"github.com/grafana/alerting/receivers/feishu"
 	"github.com/grafana/alerting/receivers/googlechat"
 	"github.com/grafana/alerting/receivers/kafka"
 	"github.com/grafana/alerting/receivers/line"
+	"github.com/grafana/alerting/receivers/matrix"
 	"github.com/grafana/alerting/receivers/mqtt"
 	"github.com/grafana/alerting/receivers/opsgenie"
 	"github.com/grafana/alerting/receivers/pagerduty"
@@ -32,6 +37,7 @@ import (
 	"github.com/grafana/alerting/receivers/webex"
 	"github.com/grafana/alerting/receivers/webhook"
 	"github.com/grafana/alerting/receivers/wecom"
+	"github.com/grafana/alerting/receivers/zenduty"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -46,10 +52,13 @@ func newFakeMaintanenceOptions(t *testing.T) *fakeMaintenanceOptions {
 }
 
 type fakeMaintenanceOptions struct {
+	initialState string
+	calls        atomic.Int32
+	err          error
 }
 
 func (f *fakeMaintenanceOptions) InitialState() string {
-	return ""
+	return f.initialState
 }
 
 func (f *fakeMaintenanceOptions) Retention() time.Duration {
@@ -61,7 +70,8 @@ func (f *fakeMaintenanceOptions) MaintenanceFrequency() time.Duration {
 }
 
 func (f *fakeMaintenanceOptions) MaintenanceFunc(_ State) (int64, error) {
-	return 0, nil
+	f.calls.Add(1)
+	return 0, f.err
 }
 
 type FakeConfig struct {
@@ -126,15 +136,25 @@ var AllKnownConfigsForTesting = map[string]NotifierConfigTest{
 		Config:       alertmanager.FullValidConfigForTesting,
 		Secrets:      alertmanager.FullValidSecretsForTesting,
 	},
+	"blackhole": {NotifierType: "blackhole",
+		Config: blackhole.FullValidConfigForTesting,
+	},
 	"dingding": {NotifierType: "dingding",
 		Config: dinding.FullValidConfigForTesting,
 	},
 	"discord": {NotifierType: "discord",
 		Config: discord.FullValidConfigForTesting,
 	},
+	"echo": {NotifierType: "echo",
+		Config: echo.FullValidConfigForTesting,
+	},
 	"email": {NotifierType: "email",
 		Config: email.FullValidConfigForTesting,
 	},
+	"feishu": {NotifierType: "feishu",
+		Config:  feishu.FullValidConfigForTesting,
+		Secrets: feishu.FullValidSecretsForTesting,
+	},
 	"googlechat": {NotifierType: "googlechat",
 		Config:  googlechat.FullValidConfigForTesting,
 		Secrets: googlechat.FullValidSecretsForTesting,
@@ -147,6 +167,10 @@ var AllKnownConfigsForTesting = map[string]NotifierConfigTest{
 		Config:  line.FullValidConfigForTesting,
 		Secrets: line.FullValidSecretsForTesting,
 	},
+	"matrix": {NotifierType: "matrix",
+		Config:  matrix.FullValidConfigForTesting,
+		Secrets: matrix.FullValidSecretsForTesting,
+	},
 	"mqtt": {NotifierType: "mqtt",
 		Config:  mqtt.FullValidConfigForTesting,
 		Secrets: mqtt.FullValidSecretsForTesting,
@@ -186,7 +210,8 @@ var AllKnownConfigsForTesting = map[string]NotifierConfigTest{
 		Secrets: threema.FullValidSecretsForTesting,
 	},
 	"victorops": {NotifierType: "victorops",
-		Config: victorops.FullValidConfigForTesting,
+		Config:  victorops.FullValidConfigForTesting,
+		Secrets: victorops.FullValidSecretsForTesting,
 	},
 	"webhook": {NotifierType: "webhook",
 		Config:  webhook.FullValidConfigForTesting,
@@ -200,6 +225,10 @@ var AllKnownConfigsForTesting = map[string]NotifierConfigTest{
 		Config:  webex.FullValidConfigForTesting,
 		Secrets: webex.FullValidSecretsForTesting,
 	},
+	"zenduty": {NotifierType: "zenduty",
+		Config:  zenduty.FullValidConfigForTesting,
+		Secrets: zenduty.FullValidSecretsForTesting,
+	},
 }
 
 type NotifierConfigTest struct {
@@ -0,0 +1,191 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/timeinterval"
+
+	"github.com/grafana/alerting/definition"
+)
+
+// MaxTimeIntervalsRange is the longest time range that time intervals can be evaluated for.
+const MaxTimeIntervalsRange = 92 * 24 * time.Hour
+
+// TimeWindow is a window of time, from Start inclusive to End exclusive.
+type TimeWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// RouteTimeWindows are the windows of time during which the notifications of a route are muted or active.
+type RouteTimeWindows struct {
+	// Path is the path of the route in the routing tree, such as "route.routes[0]".
+	Path                string   `json:"path"`
+	Receiver            string   `json:"receiver"`
+	MuteTimeIntervals   []string `json:"muteTimeIntervals"`
+	ActiveTimeIntervals []string `json:"activeTimeIntervals"`
+	// Muted are the windows covered by any of the mute time intervals of the route.
+	Muted []TimeWindow `json:"muted"`
+	// Active are the windows covered by any of the active time intervals of the route. It is nil if the route
+	// has no active time intervals, in which case it is always active.
+	Active []TimeWindow `json:"active"`
+	// Suppressed are the windows during which notifications of the route are not sent, because they are
+	// either muted or not active.
+	Suppressed []TimeWindow `json:"suppressed"`
+}
+
+// EvaluateTimeIntervals returns the windows of time between from and to during which the routes of the routing tree
+// of the configuration are muted or active, in the order of the routing tree. Routes without time intervals
+// are included with no windows. Time intervals have a resolution of a minute, and so do the windows, except for
+// the first and last windows which are cut to the range.
+func EvaluateTimeIntervals(cfg *definition.PostableApiAlertingConfig, from, to time.Time) ([]RouteTimeWindows, error) {
+	if cfg == nil || cfg.Route == nil {
+		return nil, errors.New("configuration has no routing tree")
+	}
+	if !from.Before(to) {
+		return nil, errors.New("start of the range must be before its end")
+	}
+	if to.Sub(from) > MaxTimeIntervalsRange {
+		return nil, fmt.Errorf("range must not be longer than %s", MaxTimeIntervalsRange)
+	}
+
+	intervals := make(map[string][]timeinterval.TimeInterval, len(cfg.TimeIntervals)+len(cfg.MuteTimeIntervals))
+	for _, ti := range cfg.TimeIntervals {
+		intervals[ti.Name] = ti.TimeIntervals
+	}
+	for _, ti := range cfg.MuteTimeIntervals {
+		intervals[ti.Name] = ti.TimeIntervals
+	}
+
+	windows := make(map[string][]TimeWindow)
+	windowsOf := func(names []string) ([]TimeWindow, error) {
+		var res []TimeWindow
+		for _, name := range names {
+			w, ok := windows[name]
+			if !ok {
+				ti, ok := intervals[name]
+				if !ok {
+					return nil, fmt.Errorf("time interval %s doesn't exist in config", name)
+				}
+				w = timeIntervalWindows(ti, from, to)
+				windows[name] = w
+			}
+			res = unionWindows(res, w)
+		}
+		return res, nil
+	}
+
+	route := dispatch.NewRoute(cfg.Route.AsAMRoute(), nil)
+	routes := make(map[*dispatch.Route]routeInfo)
+	indexRoutes("route", cfg.Route, route, routes)
+
+	var res []RouteTimeWindows
+	var err error
+	route.Walk(func(r *dispatch.Route) {
+		if err != nil {
+			return
+		}
+		w := RouteTimeWindows{
+			Path:                routes[r].path,
+			Receiver:            r.RouteOpts.Receiver,
+			MuteTimeIntervals:   r.RouteOpts.MuteTimeIntervals,
+			ActiveTimeIntervals: r.RouteOpts.ActiveTimeIntervals,
+		}
+		if w.Muted, err = windowsOf(r.RouteOpts.MuteTimeIntervals); err != nil {
+			err = fmt.Errorf("%s: %w", w.Path, err)
+			return
+		}
+		w.Suppressed = w.Muted
+		if len(r.RouteOpts.ActiveTimeIntervals) > 0 {
+			if w.Active, err = windowsOf(r.RouteOpts.ActiveTimeIntervals); err != nil {
+				err = fmt.Errorf("%s: %w", w.Path, err)
+				return
+			}
+			if w.Active == nil {
+				w.Active = []TimeWindow{}
+			}
+			w.Suppressed = unionWindows(w.Muted, complementWindows(w.Active, from, to))
+		}
+		res = append(res, w)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// timeIntervalWindows returns the windows between from and to that are contained in any of the time intervals.
+// It evaluates the time intervals at every minute, the resolution of time intervals.
+func timeIntervalWindows(intervals []timeinterval.TimeInterval, from, to time.Time) []TimeWindow {
+	contains := func(t time.Time) bool {
+		for _, ti := range intervals {
+			if ti.ContainsTime(t.UTC()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var res []TimeWindow
+	var start time.Time
+	in := false
+	for t := from; t.Before(to); t = t.Truncate(time.Minute).Add(time.Minute) {
+		c := contains(t)
+		if c && !in {
+			start = t
+		} else if !c && in {
+			res = append(res, TimeWindow{Start: start, End: t})
+		}
+		in = c
+	}
+	if in {
+		res = append(res, TimeWindow{Start: start, End: to})
+	}
+	return res
+}
+
+// unionWindows returns the union of two sorted lists of non-overlapping windows, merging adjacent windows.
+func unionWindows(a, b []TimeWindow) []TimeWindow {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	res := make([]TimeWindow, 0, len(a)+len(b))
+	for len(a) > 0 || len(b) > 0 {
+		var next TimeWindow
+		if len(b) == 0 || (len(a) > 0 && a[0].Start.Before(b[0].Start)) {
+			next, a = a[0], a[1:]
+		} else {
+			next, b = b[0], b[1:]
+		}
+		if n := len(res); n > 0 && !next.Start.After(res[n-1].End) {
+			if next.End.After(res[n-1].End) {
+				res[n-1].End = next.End
+			}
+			continue
+		}
+		res = append(res, next)
+	}
+	return res
+}
+
+// complementWindows returns the windows between from and to that are not covered by the sorted windows.
+func complementWindows(windows []TimeWindow, from, to time.Time) []TimeWindow {
+	var res []TimeWindow
+	start := from
+	for _, w := range windows {
+		if w.Start.After(start) {
+			res = append(res, TimeWindow{Start: start, End: w.Start})
+		}
+		start = w.End
+	}
+	if start.Before(to) {
+		res = append(res, TimeWindow{Start: start, End: to})
+	}
+	return res
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/definition"
+)
+
+func TestEvaluateTimeIntervals(t *testing.T) {
+	cfg, err := definition.Load([]byte(`
+route:
+  receiver: default
+  routes:
+  - receiver: team-a
+    mute_time_intervals: [weekends, nights]
+  - receiver: team-b
+    mute_time_intervals: [nights]
+    active_time_intervals: [business-hours]
+time_intervals:
+- name: weekends
+  time_intervals:
+  - weekdays: [saturday, sunday]
+- name: nights
+  time_intervals:
+  - times:
+    - start_time: "00:00"
+      end_time: "06:00"
+- name: business-hours
+  time_intervals:
+  - times:
+    - start_time: "05:00"
+      end_time: "17:30"
+    location: Europe/Berlin
+receivers:
+- name: default
+- name: team-a
+- name: team-b
+`))
+	require.NoError(t, err)
+
+	at := func(s string) time.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return tm
+	}
+	window := func(start, end string) TimeWindow {
+		return TimeWindow{Start: at(start), End: at(end)}
+	}
+
+	// Friday 12:00 to Sunday 12:00 UTC, Berlin is at UTC+2 in June.
+	from, to := at("2024-06-07T12:00:00Z"), at("2024-06-09T12:00:00Z")
+
+	t.Run("returns the windows of all routes", func(t *testing.T) {
+		res, err := EvaluateTimeIntervals(cfg, from, to)
+		require.NoError(t, err)
+		require.Equal(t, []RouteTimeWindows{{
+			Path:     "route",
+			Receiver: "default",
+		}, {
+			Path:              "route.routes[0]",
+			Receiver:          "team-a",
+			MuteTimeIntervals: []string{"weekends", "nights"},
+			// Friday night is merged with the weekend.
+			Muted:      []TimeWindow{window("2024-06-08T00:00:00Z", "2024-06-09T12:00:00Z")},
+			Suppressed: []TimeWindow{window("2024-06-08T00:00:00Z", "2024-06-09T12:00:00Z")},
+		}, {
+			Path:                "route.routes[1]",
+			Receiver:            "team-b",
+			MuteTimeIntervals:   []string{"nights"},
+			ActiveTimeIntervals: []string{"business-hours"},
+			Muted: []TimeWindow{
+				window("2024-06-08T00:00:00Z", "2024-06-08T06:00:00Z"),
+				window("2024-06-09T00:00:00Z", "2024-06-09T06:00:00Z"),
+			},
+			Active: []TimeWindow{
+				window("2024-06-07T12:00:00Z", "2024-06-07T15:30:00Z"),
+				window("2024-06-08T03:00:00Z", "2024-06-08T15:30:00Z"),
+				window("2024-06-09T03:00:00Z", "2024-06-09T12:00:00Z"),
+			},
+			Suppressed: []TimeWindow{
+				window("2024-06-07T15:30:00Z", "2024-06-08T06:00:00Z"),
+				window("2024-06-08T15:30:00Z", "2024-06-09T06:00:00Z"),
+			},
+		}}, res)
+	})
+
+	t.Run("windows are cut to the range", func(t *testing.T) {
+		res, err := EvaluateTimeIntervals(cfg, at("2024-06-08T05:30:30Z"), at("2024-06-08T05:45:00Z"))
+		require.NoError(t, err)
+		require.Equal(t, []TimeWindow{window("2024-06-08T05:30:30Z", "2024-06-08T05:45:00Z")}, res[2].Muted)
+	})
+
+	t.Run("invalid ranges are rejected", func(t *testing.T) {
+		_, err := EvaluateTimeIntervals(cfg, to, from)
+		require.EqualError(t, err, "start of the range must be before its end")
+		_, err = EvaluateTimeIntervals(cfg, from, from.Add(MaxTimeIntervalsRange+time.Minute))
+		require.EqualError(t, err, "range must not be longer than 2208h0m0s")
+	})
+
+	t.Run("unknown time intervals are rejected", func(t *testing.T) {
+		cfg := *cfg
+		cfg.TimeIntervals = cfg.TimeIntervals[:1]
+		_, err := EvaluateTimeIntervals(&cfg, from, to)
+		require.EqualError(t, err, "route.routes[0]: time interval nights doesn't exist in config")
+	})
+}
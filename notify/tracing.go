@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/grafana/alerting/notify")
+
+// tracingStage records a span for each execution of a stage of the notification pipeline.
+type tracingStage struct {
+	name  string
+	stage notify.Stage
+	attrs []attribute.KeyValue
+}
+
+func newTracingStage(name string, stage notify.Stage, attrs ...attribute.KeyValue) notify.Stage {
+	return &tracingStage{name: name, stage: stage, attrs: attrs}
+}
+
+// Exec implements the Stage interface.
+func (s *tracingStage) Exec(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	spanCtx, span := tracer.Start(ctx, s.name, trace.WithAttributes(s.attrs...))
+	defer span.End()
+	if gkey, ok := notify.GroupKey(ctx); ok {
+		span.SetAttributes(attribute.String("group_key", gkey))
+	}
+	span.SetAttributes(attribute.Int("alerts", len(alerts)))
+
+	resCtx, res, err := s.stage.Exec(spanCtx, l, alerts...)
+	span.SetAttributes(attribute.Int("alerts_out", len(res)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	// The context of the span is not returned, so that the spans of the next stages are its siblings.
+	if resCtx != nil {
+		resCtx = trace.ContextWithSpan(resCtx, trace.SpanFromContext(ctx))
+	}
+	return resCtx, res, err
+}
+
+// tracingNotifier wraps a notify.Notifier and records a span for each notification attempt. Unlike the spans of
+// notify.Integration, the spans have the receiver of the integration and the error of the attempt.
+type tracingNotifier struct {
+	upstream notify.Notifier
+	attrs    []attribute.KeyValue
+}
+
+// wrapTracing returns an integration that records a span for each notification attempt.
+func wrapTracing(integration *notify.Integration, receiver string) *notify.Integration {
+	n := &tracingNotifier{
+		upstream: integration,
+		attrs:    integrationAttributes(receiver, integration),
+	}
+	return notify.NewIntegration(n, integration, integration.Name(), integration.Index(), receiver)
+}
+
+// Notify implements the Notifier interface.
+func (n *tracingNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	ctx, span := tracer.Start(ctx, "notify.Attempt", trace.WithAttributes(n.attrs...))
+	defer span.End()
+	span.SetAttributes(attribute.Int("alerts", len(alerts)))
+	retry, err := n.upstream.Notify(ctx, alerts...)
+	if err != nil {
+		span.SetAttributes(attribute.Bool("retry", retry))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return retry, err
+}
+
+func integrationAttributes(receiver string, integration *notify.Integration) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("receiver", receiver),
+		attribute.String("integration", integration.Name()),
+		attribute.Int("integration_index", integration.Index()),
+	}
+}
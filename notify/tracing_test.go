@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracingStage(t *testing.T) {
+	tr := setupFakeTracer(t)
+
+	failing := errors.New("failed")
+	stage := newTracingStage("outer", notify.MultiStage{
+		newTracingStage("first", notify.StageFunc(func(ctx context.Context, _ log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+			return ctx, alerts[:1], nil
+		})),
+		newTracingStage("second", notify.StageFunc(func(ctx context.Context, _ log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+			return ctx, nil, failing
+		}), attribute.String("receiver", "team-a")),
+	})
+
+	ctx := notify.WithGroupKey(context.Background(), "{}:{}")
+	_, _, err := stage.Exec(ctx, log.NewNopLogger(), &types.Alert{}, &types.Alert{})
+	require.ErrorIs(t, err, failing)
+
+	spans := tr.finished()
+	require.Len(t, spans, 3)
+	first, second, outer := spans[0], spans[1], spans[2]
+
+	require.Equal(t, "outer", outer.name)
+	require.Nil(t, outer.parent)
+	require.Equal(t, codes.Error, outer.status)
+
+	// The stages of a pipeline are siblings, even though the context of the first is passed to the second.
+	require.Equal(t, "first", first.name)
+	require.Same(t, outer, first.parent)
+	require.Equal(t, codes.Unset, first.status)
+	require.Equal(t, attribute.StringValue("{}:{}"), first.attrs["group_key"])
+	require.Equal(t, attribute.IntValue(2), first.attrs["alerts"])
+	require.Equal(t, attribute.IntValue(1), first.attrs["alerts_out"])
+
+	require.Equal(t, "second", second.name)
+	require.Same(t, outer, second.parent)
+	require.Equal(t, codes.Error, second.status)
+	require.Equal(t, attribute.StringValue("team-a"), second.attrs["receiver"])
+	require.Equal(t, attribute.IntValue(1), second.attrs["alerts"])
+}
+
+func TestTracingNotifier(t *testing.T) {
+	tr := setupFakeTracer(t)
+
+	failing := errors.New("failed")
+	integration := wrapTracing(notify.NewIntegration(&fakeFailingNotifier{err: failing}, sendResolved(true), "webhook", 2, "team-a"), "team-a")
+
+	retry, err := integration.Notify(context.Background(), &types.Alert{})
+	require.False(t, retry)
+	require.ErrorIs(t, err, failing)
+
+	// The integrations of the Alertmanager record spans too.
+	var spans []*fakeSpan
+	for _, s := range tr.finished() {
+		if s.name == "notify.Attempt" {
+			spans = append(spans, s)
+		}
+	}
+	require.Len(t, spans, 1)
+	require.Equal(t, codes.Error, spans[0].status)
+	require.Equal(t, map[attribute.Key]attribute.Value{
+		"receiver":          attribute.StringValue("team-a"),
+		"integration":       attribute.StringValue("webhook"),
+		"integration_index": attribute.IntValue(2),
+		"alerts":            attribute.IntValue(1),
+		"retry":             attribute.BoolValue(false),
+	}, spans[0].attrs)
+}
+
+var (
+	fakeTracerOnce sync.Once
+	fakeTracerInst = &fakeTracer{}
+)
+
+// setupFakeTracer sets the global tracer provider to a fake that records the spans. The tracers of the packages
+// are created before the tests run, so they only delegate to the first tracer provider that is set.
+func setupFakeTracer(t *testing.T) *fakeTracer {
+	fakeTracerOnce.Do(func() {
+		otel.SetTracerProvider(&fakeTracerProvider{tracer: fakeTracerInst})
+	})
+	fakeTracerInst.reset()
+	t.Cleanup(fakeTracerInst.reset)
+	return fakeTracerInst
+}
+
+type fakeTracerProvider struct {
+	embedded.TracerProvider
+	tracer *fakeTracer
+}
+
+func (p *fakeTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return p.tracer
+}
+
+type fakeTracer struct {
+	embedded.Tracer
+	mtx   sync.Mutex
+	spans []*fakeSpan
+}
+
+func (tr *fakeTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	s := &fakeSpan{tracer: tr, name: name, attrs: map[attribute.Key]attribute.Value{}}
+	if parent, ok := trace.SpanFromContext(ctx).(*fakeSpan); ok {
+		s.parent = parent
+	}
+	s.SetAttributes(cfg.Attributes()...)
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+func (tr *fakeTracer) reset() {
+	tr.mtx.Lock()
+	defer tr.mtx.Unlock()
+	tr.spans = nil
+}
+
+// finished returns the spans in the order they ended.
+func (tr *fakeTracer) finished() []*fakeSpan {
+	tr.mtx.Lock()
+	defer tr.mtx.Unlock()
+	return append([]*fakeSpan(nil), tr.spans...)
+}
+
+type fakeSpan struct {
+	noop.Span
+	tracer *fakeTracer
+	name   string
+	parent *fakeSpan
+	attrs  map[attribute.Key]attribute.Value
+	status codes.Code
+}
+
+func (s *fakeSpan) End(...trace.SpanEndOption) {
+	s.tracer.mtx.Lock()
+	defer s.tracer.mtx.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
+
+func (s *fakeSpan) IsRecording() bool { return true }
+
+func (s *fakeSpan) SetStatus(code codes.Code, _ string) { s.status = code }
+
+func (s *fakeSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		s.attrs[a.Key] = a.Value
+	}
+}
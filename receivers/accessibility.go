@@ -0,0 +1,60 @@
+package receivers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/templates"
+)
+
+// StatusSummary returns a plain-language summary of the status of the alerts, such as
+// "2 alerts firing, 1 alert resolved". Notifiers put it first in screen reader friendly
+// messages so that the state of the group does not depend on colors or emoji alone.
+func StatusSummary(alerts []*types.Alert) string {
+	firing, resolved := 0, 0
+	for _, a := range alerts {
+		if a.Resolved() {
+			resolved++
+		} else {
+			firing++
+		}
+	}
+
+	parts := make([]string, 0, 2)
+	if firing > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s firing", firing, pluralizeAlert(firing)))
+	}
+	if resolved > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s resolved", resolved, pluralizeAlert(resolved)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func pluralizeAlert(n int) string {
+	if n == 1 {
+		return "alert"
+	}
+	return "alerts"
+}
+
+// TmplImageAltText executes the alt text template for the image of an alert. The template is executed
+// with the data of that alert only, so that the text describes the image rather than the whole group.
+// If the template cannot be executed the name of the alert is returned instead.
+func TmplImageAltText(ctx context.Context, tmpl *templates.Template, altText string, alert *types.Alert, l logging.Logger) string {
+	if altText == "" {
+		return ""
+	}
+
+	var tmplErr error
+	text, _ := templates.TmplText(ctx, tmpl, []*types.Alert{alert}, l, &tmplErr)
+	s := text(altText)
+	if tmplErr != nil {
+		l.Warn("failed to template image alt text", "error", tmplErr.Error(), "fallback", alert.Name())
+		return alert.Name()
+	}
+	return strings.TrimSpace(s)
+}
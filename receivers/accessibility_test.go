@@ -0,0 +1,82 @@
+package receivers
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/templates"
+)
+
+func TestStatusSummary(t *testing.T) {
+	firing := &types.Alert{Alert: model.Alert{EndsAt: time.Now().Add(time.Hour)}}
+	resolved := &types.Alert{Alert: model.Alert{EndsAt: time.Now().Add(-time.Hour)}}
+
+	tests := []struct {
+		name     string
+		alerts   []*types.Alert
+		expected string
+	}{
+		{
+			name:     "no alerts",
+			expected: "",
+		},
+		{
+			name:     "one firing alert",
+			alerts:   []*types.Alert{firing},
+			expected: "1 alert firing",
+		},
+		{
+			name:     "resolved alerts only",
+			alerts:   []*types.Alert{resolved, resolved},
+			expected: "2 alerts resolved",
+		},
+		{
+			name:     "firing and resolved alerts",
+			alerts:   []*types.Alert{firing, firing, resolved},
+			expected: "2 alerts firing, 1 alert resolved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, StatusSummary(tt.alerts))
+		})
+	}
+}
+
+func TestTmplImageAltText(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	alert := &types.Alert{Alert: model.Alert{
+		Labels: model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+		EndsAt: time.Now().Add(time.Hour),
+	}}
+
+	t.Run("empty template returns empty text", func(t *testing.T) {
+		require.Equal(t, "", TmplImageAltText(ctx, tmpl, "", alert, &logging.FakeLogger{}))
+	})
+
+	t.Run("default template describes the alert", func(t *testing.T) {
+		require.Equal(t, "Firing alert alert1", TmplImageAltText(ctx, tmpl, templates.DefaultImageAltTextEmbed, alert, &logging.FakeLogger{}))
+	})
+
+	t.Run("custom template is executed with the data of the alert", func(t *testing.T) {
+		require.Equal(t, "val1", TmplImageAltText(ctx, tmpl, "{{ .CommonLabels.lbl1 }}", alert, &logging.FakeLogger{}))
+	})
+
+	t.Run("invalid template falls back to the alert name", func(t *testing.T) {
+		require.Equal(t, "alert1", TmplImageAltText(ctx, tmpl, "{{ template \"undefined\" . }}", alert, &logging.FakeLogger{}))
+	})
+}
@@ -52,15 +52,21 @@ func (n *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 		return false, err
 	}
 
+	tlsConfig, err := receivers.ClientTLSConfig(n.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+
 	var (
 		lastErr error
 		numErrs int
 	)
 	for _, u := range n.settings.URLs {
 		if _, err := receivers.SendHTTPRequest(ctx, u, receivers.HTTPCfg{
-			User:     n.settings.User,
-			Password: n.settings.Password,
-			Body:     body,
+			User:      n.settings.User,
+			Password:  n.settings.Password,
+			Body:      body,
+			TLSConfig: tlsConfig,
 		}, n.logger); err != nil {
 			n.logger.Warn("failed to send to Alertmanager", "error", err, "alertmanager", n.Name, "url", u.String())
 			lastErr = err
@@ -11,16 +11,18 @@ import (
 )
 
 type Config struct {
-	URLs     []*url.URL
-	User     string
-	Password string
+	URLs      []*url.URL
+	User      string
+	Password  string
+	TLSConfig *receivers.TLSConfig
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
 	var settings struct {
-		URL      receivers.CommaSeparatedStrings `json:"url,omitempty" yaml:"url,omitempty"`
-		User     string                          `json:"basicAuthUser,omitempty" yaml:"basicAuthUser,omitempty"`
-		Password string                          `json:"basicAuthPassword,omitempty" yaml:"basicAuthPassword,omitempty"`
+		URL       receivers.CommaSeparatedStrings `json:"url,omitempty" yaml:"url,omitempty"`
+		User      string                          `json:"basicAuthUser,omitempty" yaml:"basicAuthUser,omitempty"`
+		Password  string                          `json:"basicAuthPassword,omitempty" yaml:"basicAuthPassword,omitempty"`
+		TLSConfig *receivers.TLSConfig            `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 	}
 	err := json.Unmarshal(jsonData, &settings)
 	if err != nil {
@@ -44,9 +46,14 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		return Config{}, errors.New("could not find url property in settings")
 	}
 	settings.Password = decryptFn("basicAuthPassword", settings.Password)
+	tlsConfig, err := receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
 	return Config{
-		URLs:     urls,
-		User:     settings.User,
-		Password: settings.Password,
+		URLs:      urls,
+		User:      settings.User,
+		Password:  settings.Password,
+		TLSConfig: tlsConfig,
 	}, nil
 }
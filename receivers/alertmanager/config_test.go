@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
 	receiversTesting "github.com/grafana/alerting/receivers/testing"
 )
 
@@ -102,6 +103,12 @@ func TestNewConfig(t *testing.T) {
 				},
 				User:     "grafana",
 				Password: "grafana-admin",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 	}
@@ -4,7 +4,13 @@ package alertmanager
 const FullValidConfigForTesting = `{
 	"url": "https://alertmanager-01.com",
 	"basicAuthUser": "grafana",
-	"basicAuthPassword": "admin"
+	"basicAuthPassword": "admin",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
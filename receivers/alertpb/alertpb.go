@@ -0,0 +1,198 @@
+// Package alertpb encodes the notifications of alert groups with Protocol Buffers, for the receivers whose
+// consumers prefer a compact and typed payload to JSON. The schema is published in alert_group.proto.
+package alertpb
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/grafana/alerting/templates"
+)
+
+// ContentType is the content type of the encoded AlertGroup messages.
+const ContentType = `application/x-protobuf; messageType="grafana.alerting.v1.AlertGroup"`
+
+// AlertGroup is the message AlertGroup of alert_group.proto.
+type AlertGroup struct {
+	Version           string
+	GroupKey          string
+	Receiver          string
+	Status            string
+	State             string
+	Title             string
+	Message           string
+	Alerts            []Alert
+	TruncatedAlerts   int
+	OrgID             int64
+	GroupLabels       map[string]string
+	CommonLabels      map[string]string
+	CommonAnnotations map[string]string
+	ExternalURL       string
+	ConfigGeneration  uint64
+	ConfigVersion     string
+	Sequence          uint64
+}
+
+// Alert is the message Alert of alert_group.proto.
+type Alert struct {
+	Status       string
+	Labels       map[string]string
+	Annotations  map[string]string
+	StartsAt     time.Time
+	EndsAt       time.Time
+	GeneratorURL string
+	Fingerprint  string
+	SilenceURL   string
+	DashboardURL string
+	PanelURL     string
+	Values       map[string]float64
+	ImageURL     string
+}
+
+// NewAlertGroup returns the AlertGroup of the template data of a notification. The fields that are not in the
+// template data, such as the title and the message, are left for the receiver to set.
+func NewAlertGroup(data *templates.ExtendedData) AlertGroup {
+	g := AlertGroup{
+		Receiver:          data.Receiver,
+		Status:            data.Status,
+		Alerts:            make([]Alert, 0, len(data.Alerts)),
+		GroupLabels:       data.GroupLabels,
+		CommonLabels:      data.CommonLabels,
+		CommonAnnotations: data.CommonAnnotations,
+		ExternalURL:       data.ExternalURL,
+	}
+	for _, a := range data.Alerts {
+		g.Alerts = append(g.Alerts, Alert{
+			Status:       a.Status,
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  a.Fingerprint,
+			SilenceURL:   a.SilenceURL,
+			DashboardURL: a.DashboardURL,
+			PanelURL:     a.PanelURL,
+			Values:       a.Values,
+			ImageURL:     a.ImageURL,
+		})
+	}
+	return g
+}
+
+// Marshal returns the wire format of the group. Fields with zero values are left out, as in proto3, and the
+// entries of maps are sorted by key, so that the same group is always encoded the same way.
+func (g AlertGroup) Marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, g.Version)
+	b = appendString(b, 2, g.GroupKey)
+	b = appendString(b, 3, g.Receiver)
+	b = appendString(b, 4, g.Status)
+	b = appendString(b, 5, g.State)
+	b = appendString(b, 6, g.Title)
+	b = appendString(b, 7, g.Message)
+	for _, a := range g.Alerts {
+		b = protowire.AppendTag(b, 8, protowire.BytesType)
+		b = protowire.AppendBytes(b, a.marshal())
+	}
+	if g.TruncatedAlerts != 0 {
+		b = protowire.AppendTag(b, 9, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(int32(g.TruncatedAlerts)))
+	}
+	if g.OrgID != 0 {
+		b = protowire.AppendTag(b, 10, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(g.OrgID))
+	}
+	b = appendStringMap(b, 11, g.GroupLabels)
+	b = appendStringMap(b, 12, g.CommonLabels)
+	b = appendStringMap(b, 13, g.CommonAnnotations)
+	b = appendString(b, 14, g.ExternalURL)
+	if g.ConfigGeneration != 0 {
+		b = protowire.AppendTag(b, 15, protowire.VarintType)
+		b = protowire.AppendVarint(b, g.ConfigGeneration)
+	}
+	b = appendString(b, 16, g.ConfigVersion)
+	if g.Sequence != 0 {
+		b = protowire.AppendTag(b, 17, protowire.VarintType)
+		b = protowire.AppendVarint(b, g.Sequence)
+	}
+	return b
+}
+
+func (a Alert) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, a.Status)
+	b = appendStringMap(b, 2, a.Labels)
+	b = appendStringMap(b, 3, a.Annotations)
+	b = appendTimestamp(b, 4, a.StartsAt)
+	b = appendTimestamp(b, 5, a.EndsAt)
+	b = appendString(b, 6, a.GeneratorURL)
+	b = appendString(b, 7, a.Fingerprint)
+	b = appendString(b, 8, a.SilenceURL)
+	b = appendString(b, 9, a.DashboardURL)
+	b = appendString(b, 10, a.PanelURL)
+	for _, k := range sortedKeys(a.Values) {
+		var entry []byte
+		entry = appendString(entry, 1, k)
+		if v := a.Values[k]; v != 0 || math.Signbit(v) {
+			entry = protowire.AppendTag(entry, 2, protowire.Fixed64Type)
+			entry = protowire.AppendFixed64(entry, math.Float64bits(v))
+		}
+		b = protowire.AppendTag(b, 11, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	b = appendString(b, 12, a.ImageURL)
+	return b
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+// appendStringMap appends the entries of a map<string, string>, each as a message with the key in field 1 and
+// the value in field 2.
+func appendStringMap(b []byte, num protowire.Number, m map[string]string) []byte {
+	for _, k := range sortedKeys(m) {
+		var entry []byte
+		entry = appendString(entry, 1, k)
+		entry = appendString(entry, 2, m[k])
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	return b
+}
+
+// appendTimestamp appends a google.protobuf.Timestamp. Zero times are left out, as the alerts that have not
+// ended have no end time.
+func appendTimestamp(b []byte, num protowire.Number, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	var ts []byte
+	if s := t.Unix(); s != 0 {
+		ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(s))
+	}
+	if n := t.Nanosecond(); n != 0 {
+		ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(n))
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, ts)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
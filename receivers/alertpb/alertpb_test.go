@@ -0,0 +1,117 @@
+package alertpb
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/grafana/alerting/templates"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the tests")
+
+func TestMarshal(t *testing.T) {
+	startsAt := time.Date(2024, 5, 1, 10, 0, 0, 500, time.UTC)
+	endsAt := startsAt.Add(time.Hour)
+
+	cases := []struct {
+		name   string
+		golden string
+		group  AlertGroup
+	}{
+		{
+			name:   "firing group",
+			golden: "firing.pb",
+			group: func() AlertGroup {
+				g := NewAlertGroup(&templates.ExtendedData{
+					Receiver: "test-receiver",
+					Status:   "firing",
+					Alerts: templates.ExtendedAlerts{
+						{
+							Status:       "firing",
+							Labels:       templates.KV{"alertname": "alert1", "lbl1": "val1"},
+							Annotations:  templates.KV{"ann1": "annv1"},
+							StartsAt:     startsAt,
+							GeneratorURL: "http://localhost/alert1",
+							Fingerprint:  "fac0861a85de433a",
+							SilenceURL:   "http://localhost/alerting/silence/new",
+							DashboardURL: "http://localhost/d/abcd",
+							PanelURL:     "http://localhost/d/abcd?viewPanel=1",
+							Values:       map[string]float64{"B": 42.5, "A": 0},
+							ImageURL:     "http://localhost/image.png",
+						},
+						{
+							Status:   "resolved",
+							Labels:   templates.KV{"alertname": "alert1", "lbl1": "val2"},
+							StartsAt: startsAt,
+							EndsAt:   endsAt,
+						},
+					},
+					GroupLabels:       templates.KV{"alertname": "alert1"},
+					CommonLabels:      templates.KV{"alertname": "alert1"},
+					CommonAnnotations: templates.KV{},
+					ExternalURL:       "http://localhost",
+				})
+				g.Version = "1"
+				g.GroupKey = `{}:{alertname="alert1"}`
+				g.State = "alerting"
+				g.Title = "[FIRING:1] alert1"
+				g.Message = "**Firing**"
+				g.TruncatedAlerts = 3
+				g.OrgID = 1
+				return g
+			}(),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b := c.group.Marshal()
+			path := filepath.Join("testdata", c.golden)
+			if *update {
+				require.NoError(t, os.WriteFile(path, b, 0o644))
+			}
+			expected, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, expected, b)
+
+			// The encoding must be deterministic.
+			require.Equal(t, b, c.group.Marshal())
+		})
+	}
+}
+
+func TestMarshal_Fields(t *testing.T) {
+	require.Empty(t, AlertGroup{}.Marshal())
+
+	g := AlertGroup{
+		Status: "firing",
+		Alerts: []Alert{{Status: "firing"}, {Status: "resolved"}},
+		OrgID:  2,
+		GroupLabels: map[string]string{
+			"b": "2",
+			"a": "1",
+		},
+		ConfigGeneration: 3,
+		Sequence:         4,
+	}
+
+	// The fields of the group, in the order they are encoded.
+	var fields []protowire.Number
+	b := g.Marshal()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		fields = append(fields, num)
+	}
+	require.Equal(t, []protowire.Number{4, 8, 8, 10, 11, 11, 15, 17}, fields)
+}
@@ -0,0 +1,224 @@
+package alertpb
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TestMarshal_Schema decodes the encoded groups with the messages of alert_group.proto, so that the encoding and
+// the published schema cannot drift apart.
+func TestMarshal_Schema(t *testing.T) {
+	fd := compileSchema(t, "alert_group.proto")
+	desc := fd.Messages().ByName("AlertGroup")
+	require.NotNil(t, desc)
+
+	startsAt := time.Date(2024, 5, 1, 10, 0, 0, 500, time.UTC)
+	// Every field is set, so that each field of the schema is checked.
+	g := AlertGroup{
+		Version:  "1",
+		GroupKey: `{}:{alertname="alert1"}`,
+		Receiver: "test-receiver",
+		Status:   "firing",
+		State:    "alerting",
+		Title:    "[FIRING:1] alert1",
+		Message:  "**Firing**",
+		Alerts: []Alert{{
+			Status:       "firing",
+			Labels:       map[string]string{"alertname": "alert1", "lbl1": "val1"},
+			Annotations:  map[string]string{"ann1": "annv1"},
+			StartsAt:     startsAt,
+			EndsAt:       startsAt.Add(time.Hour),
+			GeneratorURL: "http://localhost/alert1",
+			Fingerprint:  "fac0861a85de433a",
+			SilenceURL:   "http://localhost/alerting/silence/new",
+			DashboardURL: "http://localhost/d/abcd",
+			PanelURL:     "http://localhost/d/abcd?viewPanel=1",
+			Values:       map[string]float64{"A": -1.5, "B": 42},
+			ImageURL:     "http://localhost/image.png",
+		}},
+		TruncatedAlerts:   3,
+		OrgID:             1,
+		GroupLabels:       map[string]string{"alertname": "alert1"},
+		CommonLabels:      map[string]string{"alertname": "alert1"},
+		CommonAnnotations: map[string]string{"ann1": "annv1"},
+		ExternalURL:       "http://localhost",
+		ConfigGeneration:  4,
+		ConfigVersion:     "v5",
+		Sequence:          6,
+	}
+
+	msg := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(g.Marshal(), msg))
+	requireMessage(t, reflect.ValueOf(g), msg)
+}
+
+// requireMessage checks that each field of the message is set to the value of the field of the struct v with the
+// same name, and that the message has no fields that are not in the schema.
+func requireMessage(t *testing.T, v reflect.Value, msg protoreflect.Message) {
+	t.Helper()
+	require.Empty(t, msg.GetUnknown(), "fields of %s are not in the schema", msg.Descriptor().Name())
+
+	fields := msg.Descriptor().Fields()
+	require.Equal(t, v.NumField(), fields.Len(), "fields of %s", msg.Descriptor().Name())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		f := structField(v, fd)
+		require.True(t, f.IsValid(), "field %s of %s has no struct field", fd.Name(), msg.Descriptor().Name())
+		require.True(t, msg.Has(fd), "field %s of %s is not set", fd.Name(), msg.Descriptor().Name())
+		value := msg.Get(fd)
+
+		switch {
+		case fd.IsMap():
+			require.Equal(t, f.Len(), value.Map().Len(), "entries of %s", fd.Name())
+			value.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				require.Equal(t, f.MapIndex(reflect.ValueOf(k.String())).Interface(), v.Interface(), "entry %s of %s", k, fd.Name())
+				return true
+			})
+		case fd.IsList():
+			require.Equal(t, f.Len(), value.List().Len(), "elements of %s", fd.Name())
+			for j := 0; j < f.Len(); j++ {
+				requireMessage(t, f.Index(j), value.List().Get(j).Message())
+			}
+		case fd.Kind() == protoreflect.MessageKind && fd.Message().FullName() == "google.protobuf.Timestamp":
+			ts := value.Message()
+			expected := f.Interface().(time.Time)
+			require.Equal(t, expected.Unix(), ts.Get(ts.Descriptor().Fields().ByName("seconds")).Int(), "seconds of %s", fd.Name())
+			require.Equal(t, int64(expected.Nanosecond()), ts.Get(ts.Descriptor().Fields().ByName("nanos")).Int(), "nanos of %s", fd.Name())
+		default:
+			require.Equal(t, fmt.Sprint(f.Interface()), fmt.Sprint(value.Interface()), "value of %s", fd.Name())
+		}
+	}
+}
+
+// structField returns the field of the struct v whose name is the name of the field of the message without
+// underscores, ignoring case, such as OrgID for org_id.
+func structField(v reflect.Value, fd protoreflect.FieldDescriptor) reflect.Value {
+	name := strings.ReplaceAll(string(fd.Name()), "_", "")
+	return v.FieldByNameFunc(func(n string) bool { return strings.EqualFold(n, name) })
+}
+
+var (
+	messageRegexp = regexp.MustCompile(`^message (\w+) \{$`)
+	fieldRegexp   = regexp.MustCompile(`^(repeated )?([\w.]+) (\w+) = (\d+);$`)
+	mapRegexp     = regexp.MustCompile(`^map<(\w+), (\w+)> (\w+) = (\d+);$`)
+)
+
+// compileSchema compiles the messages of the .proto file. It only supports the syntax used by the schema:
+// messages with scalar, message and map fields, and imports of the well-known types.
+func compileSchema(t *testing.T, path string) protoreflect.FileDescriptor {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	file := &descriptorpb.FileDescriptorProto{Name: proto.String(path), Syntax: proto.String("proto3")}
+	var msg *descriptorpb.DescriptorProto
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case line == "", strings.HasPrefix(line, "syntax "), strings.HasPrefix(line, "option "):
+		case strings.HasPrefix(line, "package "):
+			file.Package = proto.String(strings.TrimSuffix(strings.TrimPrefix(line, "package "), ";"))
+		case strings.HasPrefix(line, "import "):
+			file.Dependency = append(file.Dependency, strings.Trim(strings.TrimPrefix(line, "import "), `";`))
+		case messageRegexp.MatchString(line):
+			msg = &descriptorpb.DescriptorProto{Name: proto.String(messageRegexp.FindStringSubmatch(line)[1])}
+			file.MessageType = append(file.MessageType, msg)
+		case line == "}":
+			msg = nil
+		case msg != nil && mapRegexp.MatchString(line):
+			m := mapRegexp.FindStringSubmatch(line)
+			entry := camelCase(m[3]) + "Entry"
+			msg.NestedType = append(msg.NestedType, &descriptorpb.DescriptorProto{
+				Name:    proto.String(entry),
+				Field:   []*descriptorpb.FieldDescriptorProto{schemaField(t, "", m[1], "key", 1), schemaField(t, "", m[2], "value", 2)},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			})
+			msg.Field = append(msg.Field, schemaField(t, "repeated ", *file.Package+"."+msg.GetName()+"."+entry, m[3], number(t, m[4])))
+		case msg != nil && fieldRegexp.MatchString(line):
+			m := fieldRegexp.FindStringSubmatch(line)
+			typ := m[2]
+			if _, ok := scalarTypes[typ]; !ok && !strings.Contains(typ, ".") {
+				typ = *file.Package + "." + typ
+			}
+			msg.Field = append(msg.Field, schemaField(t, m[1], typ, m[3], number(t, m[4])))
+		default:
+			t.Fatalf("unsupported line in %s: %q", path, line)
+		}
+	}
+	require.NoError(t, scanner.Err())
+
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	return fd
+}
+
+var scalarTypes = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"string": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"int32":  descriptorpb.FieldDescriptorProto_TYPE_INT32,
+	"int64":  descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"uint64": descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+	"double": descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
+}
+
+func schemaField(t *testing.T, label, typ, name string, num int32) *descriptorpb.FieldDescriptorProto {
+	t.Helper()
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(lowerCamelCase(name)),
+		Number:   proto.Int32(num),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	if label == "repeated " {
+		f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	}
+	if st, ok := scalarTypes[typ]; ok {
+		f.Type = st.Enum()
+	} else {
+		f.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		f.TypeName = proto.String("." + typ)
+	}
+	return f
+}
+
+func number(t *testing.T, s string) int32 {
+	t.Helper()
+	n, err := strconv.ParseInt(s, 10, 32)
+	require.NoError(t, err)
+	require.Less(t, n, int64(math.MaxInt32))
+	return int32(n)
+}
+
+func camelCase(s string) string {
+	parts := strings.Split(s, "_")
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+func lowerCamelCase(s string) string {
+	c := camelCase(s)
+	return strings.ToLower(c[:1]) + c[1:]
+}
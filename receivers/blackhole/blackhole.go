@@ -0,0 +1,36 @@
+package blackhole
+
+import (
+	"context"
+
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+)
+
+// Notifier accepts notifications and drops them. It can be used as an explicit "no notification" target
+// of a route, or to test routing without sending notifications anywhere.
+type Notifier struct {
+	*receivers.Base
+	log      logging.Logger
+	settings Config
+}
+
+func New(cfg Config, meta receivers.Metadata, logger logging.Logger) *Notifier {
+	return &Notifier{
+		Base:     receivers.NewBase(meta),
+		log:      logger,
+		settings: cfg,
+	}
+}
+
+// Notify drops the notification.
+func (n *Notifier) Notify(_ context.Context, as ...*types.Alert) (bool, error) {
+	n.log.Debug("dropping notification", "alerts", len(as))
+	return true, nil
+}
+
+func (n *Notifier) SendResolved() bool {
+	return !n.GetDisableResolveMessage()
+}
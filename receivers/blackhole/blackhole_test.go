@@ -0,0 +1,25 @@
+package blackhole
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+)
+
+func TestNotify(t *testing.T) {
+	n := New(Config{}, receivers.Metadata{Name: "blackhole"}, &logging.FakeLogger{})
+
+	ok, err := n.Notify(context.Background(), &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, n.SendResolved())
+
+	n = New(Config{}, receivers.Metadata{Name: "blackhole", DisableResolveMessage: true}, &logging.FakeLogger{})
+	require.False(t, n.SendResolved())
+}
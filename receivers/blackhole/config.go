@@ -0,0 +1,18 @@
+package blackhole
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Config of the blackhole integration. It has no settings.
+type Config struct{}
+
+func NewConfig(jsonData json.RawMessage) (Config, error) {
+	var settings Config
+	err := json.Unmarshal(jsonData, &settings)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	return settings, nil
+}
@@ -0,0 +1,56 @@
+// Package chatcard contains the card model shared by the receivers of chat providers that render notifications as
+// cards with a title, a markdown text and a link to Grafana, such as DingTalk, WeCom and Feishu.
+package chatcard
+
+import (
+	"fmt"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+)
+
+// DefaultButtonText is the text of the button of a card that links to Grafana.
+const DefaultButtonText = "More"
+
+// Card is a notification of a chat provider.
+type Card struct {
+	Title string
+	// Text is the markdown text of the card.
+	Text string
+	// Status is the status of the alerts of the card.
+	Status model.AlertStatus
+	// URL is the URL of the button of the card. The card has no button if empty.
+	URL        string
+	ButtonText string
+	// Notes are plain text notes shown below the text of the card, such as links to images that could not be loaded.
+	Notes []string
+}
+
+// New returns a card with the status of the alerts and a button linking to the URL.
+func New(title, text, url string, as ...*types.Alert) Card {
+	return Card{
+		Title:      title,
+		Text:       text,
+		Status:     types.Alerts(as...).Status(),
+		URL:        url,
+		ButtonText: DefaultButtonText,
+	}
+}
+
+// Markdown returns the card as a markdown message, with the title as heading.
+func (c Card) Markdown() string {
+	return fmt.Sprintf("# %s\n%s\n", c.Title, c.Text)
+}
+
+// PlainText returns the card as a plain text message.
+func (c Card) PlainText() string {
+	return fmt.Sprintf("%s\n%s\n", c.Title, c.Text)
+}
+
+// Color returns the name of the color of the card, red if the alerts are firing and green if they are resolved.
+func (c Card) Color() string {
+	if c.Status == model.AlertResolved {
+		return "green"
+	}
+	return "red"
+}
@@ -0,0 +1,49 @@
+package chatcard
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/models"
+	"github.com/grafana/alerting/templates"
+)
+
+// TemplateForTesting returns the default template with the external URL http://localhost, used by the tests of
+// the chat receivers.
+func TemplateForTesting(t *testing.T) *templates.Template {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+	return tmpl
+}
+
+// FiringAlertForTesting returns a firing alert with the labels and the annotations of an alert of a Grafana
+// dashboard panel.
+func FiringAlertForTesting(labels model.LabelSet) *types.Alert {
+	return &types.Alert{
+		Alert: model.Alert{
+			Labels:      labels,
+			Annotations: model.LabelSet{"ann1": "annv1", "__dashboardUid__": "abcd", "__panelId__": "efgh", "__values__": "{\"A\": 1234}", "__value_string__": "1234"},
+		},
+	}
+}
+
+// ResolvedAlertForTesting is like FiringAlertForTesting but returns an alert that is resolved.
+func ResolvedAlertForTesting(labels model.LabelSet) *types.Alert {
+	a := FiringAlertForTesting(labels)
+	a.EndsAt = time.Now().Add(-time.Minute)
+	return a
+}
+
+// AlertWithImageForTesting is like FiringAlertForTesting but returns an alert with the image token.
+func AlertWithImageForTesting(labels model.LabelSet, token string) *types.Alert {
+	a := FiringAlertForTesting(labels)
+	a.Annotations[models.ImageTokenAnnotation] = model.LabelValue(token)
+	return a
+}
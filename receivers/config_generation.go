@@ -0,0 +1,25 @@
+package receivers
+
+import "context"
+
+// ConfigGeneration identifies the configuration of the Alertmanager that sent a notification, so that notifications
+// can be correlated with the configuration that was live when they were sent.
+type ConfigGeneration struct {
+	// Generation is incremented every time a configuration is applied.
+	Generation uint64 `json:"generation"`
+	// Version is the version of the configuration supplied by the embedding application, if any.
+	Version string `json:"version,omitempty"`
+}
+
+type configGenerationKey struct{}
+
+// WithConfigGeneration returns a context with the configuration generation of the notification.
+func WithConfigGeneration(ctx context.Context, g ConfigGeneration) context.Context {
+	return context.WithValue(ctx, configGenerationKey{}, g)
+}
+
+// ConfigGenerationFromContext returns the configuration generation of the notification, if the context has one.
+func ConfigGenerationFromContext(ctx context.Context) (ConfigGeneration, bool) {
+	g, ok := ctx.Value(configGenerationKey{}).(ConfigGeneration)
+	return g, ok
+}
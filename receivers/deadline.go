@@ -0,0 +1,27 @@
+package receivers
+
+import (
+	"context"
+	"time"
+)
+
+// StepContext returns a context for one step of a notification that makes several requests, such as fetching
+// an access token before sending the message, or uploading images after it. The step can use up to share of the
+// time left before the deadline of ctx, so that a slow step leaves time for the steps after it. A step never
+// outlives the deadline of ctx, and a share of 1 or more, or a ctx without a deadline, does not limit the step.
+func StepContext(ctx context.Context, share float64) (context.Context, context.CancelFunc) {
+	remaining, ok := RemainingBudget(ctx)
+	if !ok || share >= 1 || remaining <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(float64(remaining)*share))
+}
+
+// RemainingBudget returns the time left before the deadline of ctx, and false if ctx has no deadline.
+func RemainingBudget(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
@@ -0,0 +1,65 @@
+package receivers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepContext(t *testing.T) {
+	t.Run("steps are not limited without a deadline", func(t *testing.T) {
+		ctx, cancel := StepContext(context.Background(), 0.5)
+		defer cancel()
+		_, ok := ctx.Deadline()
+		require.False(t, ok)
+	})
+
+	t.Run("steps use a share of the time left", func(t *testing.T) {
+		parent, cancelParent := context.WithTimeout(context.Background(), time.Minute)
+		defer cancelParent()
+		parentDeadline, _ := parent.Deadline()
+
+		ctx, cancel := StepContext(parent, 0.5)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		require.True(t, deadline.Before(parentDeadline))
+		require.WithinDuration(t, time.Now().Add(30*time.Second), deadline, time.Second)
+	})
+
+	t.Run("steps do not outlive the deadline", func(t *testing.T) {
+		parent, cancelParent := context.WithTimeout(context.Background(), time.Minute)
+		defer cancelParent()
+		parentDeadline, _ := parent.Deadline()
+
+		ctx, cancel := StepContext(parent, 2)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		require.Equal(t, parentDeadline, deadline)
+
+		cancelParent()
+		require.Error(t, ctx.Err())
+	})
+
+	t.Run("steps after the deadline are done", func(t *testing.T) {
+		parent, cancelParent := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancelParent()
+		ctx, cancel := StepContext(parent, 0.5)
+		defer cancel()
+		require.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	})
+}
+
+func TestRemainingBudget(t *testing.T) {
+	_, ok := RemainingBudget(context.Background())
+	require.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	remaining, ok := RemainingBudget(ctx)
+	require.True(t, ok)
+	require.InDelta(t, time.Minute, remaining, float64(time.Second))
+}
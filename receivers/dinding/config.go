@@ -5,19 +5,21 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/grafana/alerting/receivers"
 	"github.com/grafana/alerting/templates"
 )
 
 type Config struct {
-	URL         string `json:"url,omitempty" yaml:"url,omitempty"`
-	MessageType string `json:"msgType,omitempty" yaml:"msgType,omitempty"`
-	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
-	Message     string `json:"message,omitempty" yaml:"message,omitempty"`
+	URL         string               `json:"url,omitempty" yaml:"url,omitempty"`
+	MessageType string               `json:"msgType,omitempty" yaml:"msgType,omitempty"`
+	Title       string               `json:"title,omitempty" yaml:"title,omitempty"`
+	Message     string               `json:"message,omitempty" yaml:"message,omitempty"`
+	TLSConfig   *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
 const defaultDingdingMsgType = "link"
 
-func NewConfig(jsonData json.RawMessage) (Config, error) {
+func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
 	var settings Config
 	err := json.Unmarshal(jsonData, &settings)
 	if err != nil {
@@ -35,5 +37,9 @@ func NewConfig(jsonData json.RawMessage) (Config, error) {
 	if settings.Message == "" {
 		settings.Message = templates.DefaultMessageEmbed
 	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
 	return settings, nil
 }
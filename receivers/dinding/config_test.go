@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
+	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -60,12 +62,38 @@ func TestNewConfig(t *testing.T) {
 				MessageType: "actionCard",
 				Title:       "Alerts firing: {{ len .Alerts.Firing }}",
 				Message:     "{{ len .Alerts.Firing }} alerts are firing, {{ len .Alerts.Resolved }} are resolved",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
+			},
+		},
+		{
+			name:     "Extracts TLS secrets",
+			settings: FullValidConfigForTesting,
+			secrets: map[string][]byte{
+				"tlsConfig.clientCertificate": []byte("test-secret-client-certificate"),
+				"tlsConfig.clientKey":         []byte("test-secret-client-key"),
+			},
+			expectedConfig: Config{
+				URL:         "http://localhost",
+				MessageType: "actionCard",
+				Title:       "Alerts firing: {{ len .Alerts.Firing }}",
+				Message:     "{{ len .Alerts.Firing }} alerts are firing, {{ len .Alerts.Resolved }} are resolved",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-secret-client-certificate",
+					ClientKey:         "test-secret-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			actual, err := NewConfig(json.RawMessage(c.settings))
+			actual, err := NewConfig(json.RawMessage(c.settings), receiversTesting.DecryptForTesting(c.secrets))
 
 			if c.expectedInitError != "" {
 				require.ErrorContains(t, err, c.expectedInitError)
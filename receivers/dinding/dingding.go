@@ -10,6 +10,7 @@ import (
 
 	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/chatcard"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -34,34 +35,39 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify sends the alert notification to dingding.
 func (dd *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	dd.log.Info("sending dingding")
+	l := logging.FromContext(ctx, dd.log)
+	l.Info("sending dingding")
 
 	dingDingURL := buildDingDingURL(dd)
 
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, dd.tmpl, as, dd.log, &tmplErr)
+	tmpl, _ := templates.TmplText(ctx, dd.tmpl, as, l, &tmplErr)
 
 	message := tmpl(dd.settings.Message)
 	title := tmpl(dd.settings.Title)
 
 	msgType := tmpl(dd.settings.MessageType)
-	b, err := buildBody(dingDingURL, msgType, title, message)
+	b, err := buildBody(msgType, chatcard.New(title, message, dingDingURL, as...))
 	if err != nil {
 		return false, err
 	}
 
 	if tmplErr != nil {
-		dd.log.Warn("failed to template DingDing message", "error", tmplErr.Error())
+		l.Warn("failed to template DingDing message", "error", tmplErr.Error())
 		tmplErr = nil
 	}
 
 	u := tmpl(dd.settings.URL)
 	if tmplErr != nil {
-		dd.log.Warn("failed to template DingDing URL", "error", tmplErr.Error(), "fallback", dd.settings.URL)
+		l.Warn("failed to template DingDing URL", "error", tmplErr.Error(), "fallback", dd.settings.URL)
 		u = dd.settings.URL
 	}
 
-	cmd := &receivers.SendWebhookSettings{URL: u, Body: b}
+	tlsConfig, err := receivers.ClientTLSConfig(dd.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	cmd := &receivers.SendWebhookSettings{URL: u, Body: b, TLSConfig: tlsConfig}
 
 	if err := dd.ns.SendWebhook(ctx, cmd); err != nil {
 		return false, fmt.Errorf("send notification to dingding: %w", err)
@@ -85,25 +91,25 @@ func buildDingDingURL(dd *Notifier) string {
 	return "dingtalk://dingtalkclient/page/link?" + q.Encode()
 }
 
-func buildBody(url string, msgType string, title string, msg string) (string, error) {
+func buildBody(msgType string, card chatcard.Card) (string, error) {
 	var bodyMsg map[string]interface{}
 	if msgType == "actionCard" {
 		bodyMsg = map[string]interface{}{
 			"msgtype": "actionCard",
 			"actionCard": map[string]string{
-				"text":        msg,
-				"title":       title,
-				"singleTitle": "More",
-				"singleURL":   url,
+				"text":        card.Text,
+				"title":       card.Title,
+				"singleTitle": card.ButtonText,
+				"singleURL":   card.URL,
 			},
 		}
 	} else {
 		bodyMsg = map[string]interface{}{
 			"msgtype": "link",
 			"link": map[string]string{
-				"text":       msg,
-				"title":      title,
-				"messageUrl": url,
+				"text":       card.Text,
+				"title":      card.Title,
+				"messageUrl": card.URL,
 			},
 		}
 	}
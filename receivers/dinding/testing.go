@@ -5,5 +5,11 @@ const FullValidConfigForTesting = `{
 	"url": "http://localhost",
 	"message": "{{ len .Alerts.Firing }} alerts are firing, {{ len .Alerts.Resolved }} are resolved",
     "title": "Alerts firing: {{ len .Alerts.Firing }}",
-	"msgType": "actionCard"
+	"msgType": "actionCard",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
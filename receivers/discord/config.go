@@ -4,17 +4,36 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
 
 	"github.com/grafana/alerting/receivers"
 	"github.com/grafana/alerting/templates"
 )
 
+// webhookPathRegexp matches the path of Discord webhook URLs, /api[/v<version>]/webhooks/<id>/<token>. The host is
+// not checked so that webhooks can be sent through proxies.
+var webhookPathRegexp = regexp.MustCompile(`^/api(?:/v\d+)?/webhooks/(\d+)/[^/]+/?$`)
+
 type Config struct {
 	Title              string `json:"title,omitempty" yaml:"title,omitempty"`
 	Message            string `json:"message,omitempty" yaml:"message,omitempty"`
 	AvatarURL          string `json:"avatar_url,omitempty" yaml:"avatar_url,omitempty"`
 	WebhookURL         string `json:"url,omitempty" yaml:"url,omitempty"`
 	UseDiscordUsername bool   `json:"use_discord_username,omitempty" yaml:"use_discord_username,omitempty"`
+	// ImageAltText is the template for the description of uploaded images, which Discord uses as alt text.
+	// It is executed once per image with the data of the alert the image belongs to.
+	ImageAltText string `json:"image_alt_text,omitempty" yaml:"image_alt_text,omitempty"`
+	// AccessibleMessage starts the content with a plain-language status summary so that screen readers
+	// do not have to rely on the color of the embeds to convey the state of the alerts.
+	AccessibleMessage bool `json:"accessible_message,omitempty" yaml:"accessible_message,omitempty"`
+	// ThreadID is the ID of the thread that messages are posted in, in a text or forum channel.
+	ThreadID string `json:"thread_id,omitempty" yaml:"thread_id,omitempty"`
+	// ThreadName is the template of the name of the post that the first message of an alert group creates in a
+	// forum channel. Subsequent messages of the group are posted in its thread until all of its alerts are resolved.
+	ThreadName string               `json:"thread_name,omitempty" yaml:"thread_name,omitempty"`
+	TLSConfig  *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -27,11 +46,53 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	if settings.WebhookURL == "" {
 		return Config{}, errors.New("could not find webhook url property in settings")
 	}
+	if settings.ThreadID != "" && settings.ThreadName != "" {
+		return Config{}, errors.New("thread_id and thread_name cannot be used together")
+	}
 	if settings.Title == "" {
 		settings.Title = templates.DefaultMessageTitleEmbed
 	}
 	if settings.Message == "" {
 		settings.Message = templates.DefaultMessageEmbed
 	}
+	if settings.ImageAltText == "" {
+		settings.ImageAltText = templates.DefaultImageAltTextEmbed
+	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
 	return settings, nil
 }
+
+// ValidateWebhookURL checks that the URL has the shape of a Discord webhook URL, so that mistakes are reported when
+// the receiver is saved. This is not part of NewConfig, as URLs of other shapes, such as those of relays, are accepted
+// in existing configurations. Templated URLs are only known when notifications are sent and are not checked. The URL
+// is not part of the error since it contains the token of the webhook.
+func ValidateWebhookURL(u string) error {
+	if strings.Contains(u, "{{") {
+		return nil
+	}
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("invalid webhook url: must be an http or https URL")
+	}
+	if !webhookPathRegexp.MatchString(parsed.Path) {
+		return errors.New("invalid webhook url: must be in the form https://discord.com/api/webhooks/<id>/<token>")
+	}
+	return nil
+}
+
+// webhookID returns the ID of the webhook of a Discord webhook URL, or an empty string if the URL is not one. Unlike
+// the token, the ID is not a secret and can be used to identify the webhook in logs and metrics.
+func webhookID(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	m := webhookPathRegexp.FindStringSubmatch(parsed.Path)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
 	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
@@ -33,40 +34,68 @@ func TestNewConfig(t *testing.T) {
 			settings:          `{ "url": "" }`,
 			expectedInitError: `could not find webhook url property in settings`,
 		},
+		{
+			name:              "Error if both thread_id and thread_name are set",
+			settings:          `{ "url": "http://localhost/api/webhooks/123/token", "thread_id": "1", "thread_name": "test" }`,
+			expectedInitError: `thread_id and thread_name cannot be used together`,
+		},
+		{
+			name:     "URL of another shape is accepted",
+			settings: `{"url": "https://relay.example.com/discord/123"}`,
+			expectedConfig: Config{
+				Title:        templates.DefaultMessageTitleEmbed,
+				Message:      templates.DefaultMessageEmbed,
+				WebhookURL:   "https://relay.example.com/discord/123",
+				ImageAltText: templates.DefaultImageAltTextEmbed,
+			},
+		},
+		{
+			name:     "Templated URL",
+			settings: `{"url": "https://discord.com/api/webhooks/{{ .CommonLabels.webhook }}"}`,
+			expectedConfig: Config{
+				Title:        templates.DefaultMessageTitleEmbed,
+				Message:      templates.DefaultMessageEmbed,
+				WebhookURL:   "https://discord.com/api/webhooks/{{ .CommonLabels.webhook }}",
+				ImageAltText: templates.DefaultImageAltTextEmbed,
+			},
+		},
 		{
 			name:     "Minimal valid configuration",
-			settings: `{"url": "http://localhost"}`,
+			settings: `{"url": "https://discord.com/api/webhooks/123/token"}`,
 			expectedConfig: Config{
 				Title:              templates.DefaultMessageTitleEmbed,
 				Message:            templates.DefaultMessageEmbed,
 				AvatarURL:          "",
-				WebhookURL:         "http://localhost",
+				WebhookURL:         "https://discord.com/api/webhooks/123/token",
 				UseDiscordUsername: false,
+				ImageAltText:       templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
 			name:     "Minimal valid configuration from secure settings",
 			settings: `{}`,
 			secrets: map[string][]byte{
-				"url": []byte("http://localhost"),
+				"url": []byte("https://discord.com/api/v10/webhooks/123/token"),
 			},
 			expectedConfig: Config{
 				Title:              templates.DefaultMessageTitleEmbed,
 				Message:            templates.DefaultMessageEmbed,
 				AvatarURL:          "",
-				WebhookURL:         "http://localhost",
+				WebhookURL:         "https://discord.com/api/v10/webhooks/123/token",
 				UseDiscordUsername: false,
+				ImageAltText:       templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
 			name:     "All empty fields = minimal valid configuration",
-			settings: `{"url": "http://localhost", "title": "", "message": "", "avatar_url" : "", "use_discord_username": null}`,
+			settings: `{"url": "http://localhost/api/webhooks/123/token/", "title": "", "message": "", "avatar_url" : "", "use_discord_username": null}`,
 			expectedConfig: Config{
 				Title:              templates.DefaultMessageTitleEmbed,
 				Message:            templates.DefaultMessageEmbed,
 				AvatarURL:          "",
-				WebhookURL:         "http://localhost",
+				WebhookURL:         "http://localhost/api/webhooks/123/token/",
 				UseDiscordUsername: false,
+				ImageAltText:       templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
@@ -76,8 +105,17 @@ func TestNewConfig(t *testing.T) {
 				Title:              "test-title",
 				Message:            "test-message",
 				AvatarURL:          "http://avatar",
-				WebhookURL:         "http://localhost",
+				WebhookURL:         "http://localhost/api/webhooks/123/test-token",
 				UseDiscordUsername: true,
+				ImageAltText:       "test-image-alt-text",
+				AccessibleMessage:  true,
+				ThreadName:         "test-thread-name",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 	}
@@ -94,3 +132,19 @@ func TestNewConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateWebhookURL(t *testing.T) {
+	require.NoError(t, ValidateWebhookURL("https://discord.com/api/webhooks/123/token"))
+	require.NoError(t, ValidateWebhookURL("https://discord.com/api/v10/webhooks/123/token/"))
+	require.NoError(t, ValidateWebhookURL("https://discord.com/api/webhooks/{{ .CommonLabels.webhook }}"))
+	require.EqualError(t, ValidateWebhookURL("https://discord.com/channels/123/456"), "invalid webhook url: must be in the form https://discord.com/api/webhooks/<id>/<token>")
+	require.EqualError(t, ValidateWebhookURL("https://discord.com/api/webhooks/abc/token"), "invalid webhook url: must be in the form https://discord.com/api/webhooks/<id>/<token>")
+	require.EqualError(t, ValidateWebhookURL("discord.com/api/webhooks/123/token"), "invalid webhook url: must be an http or https URL")
+}
+
+func TestWebhookID(t *testing.T) {
+	require.Equal(t, "123", webhookID("https://discord.com/api/webhooks/123/token"))
+	require.Equal(t, "123", webhookID("https://discord.com/api/v10/webhooks/123/token?thread_id=1"))
+	require.Equal(t, "", webhookID("https://discord.com/channels/123/456"))
+	require.Equal(t, "", webhookID("http://localhost"))
+}
@@ -10,6 +10,7 @@ import (
 	"mime/multipart"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 
@@ -31,21 +32,35 @@ const (
 
 	discordMaxEmbeds     = 10
 	discordMaxMessageLen = 2000
+	// discordMaxDescriptionLen is the maximum length of the description of an embed.
+	discordMaxDescriptionLen = 4096
 )
 
 type discordMessage struct {
-	Username  string             `json:"username,omitempty"`
-	Content   string             `json:"content"`
-	AvatarURL string             `json:"avatar_url,omitempty"`
-	Embeds    []discordLinkEmbed `json:"embeds,omitempty"`
+	Username    string                      `json:"username,omitempty"`
+	Content     string                      `json:"content"`
+	AvatarURL   string                      `json:"avatar_url,omitempty"`
+	Embeds      []discordLinkEmbed          `json:"embeds,omitempty"`
+	Attachments []discordAttachmentMetadata `json:"attachments,omitempty"`
+	// ThreadName creates a post with the message in a forum channel.
+	ThreadName string `json:"thread_name,omitempty"`
+}
+
+// discordAttachmentMetadata implements https://discord.com/developers/docs/resources/message#attachment-object
+// for files uploaded in the files[n] fields of the request. The description is used as alt text.
+type discordAttachmentMetadata struct {
+	ID          int    `json:"id"`
+	Filename    string `json:"filename"`
+	Description string `json:"description,omitempty"`
 }
 
 // discordLinkEmbed implements https://discord.com/developers/docs/resources/channel#embed-object
 type discordLinkEmbed struct {
-	Title string           `json:"title,omitempty"`
-	Type  discordEmbedType `json:"type,omitempty"`
-	URL   string           `json:"url,omitempty"`
-	Color int64            `json:"color,omitempty"`
+	Title       string           `json:"title,omitempty"`
+	Type        discordEmbedType `json:"type,omitempty"`
+	Description string           `json:"description,omitempty"`
+	URL         string           `json:"url,omitempty"`
+	Color       int64            `json:"color,omitempty"`
 
 	Footer *discordFooter `json:"footer,omitempty"`
 
@@ -75,6 +90,8 @@ type Notifier struct {
 	ns         receivers.WebhookSender
 	images     images.Provider
 	tmpl       *templates.Template
+	threads    *threadStore
+	now        func() time.Time
 	settings   Config
 	appVersion string
 }
@@ -84,7 +101,11 @@ type discordAttachment struct {
 	reader    io.ReadCloser
 	name      string
 	alertName string
+	altText   string
 	state     model.AlertStatus
+	// fallbackURL and fallbackText replace the image if it could not be resolved.
+	fallbackURL  string
+	fallbackText string
 }
 
 func New(cfg Config, meta receivers.Metadata, template *templates.Template, sender receivers.WebhookSender, images images.Provider, logger logging.Logger, appVersion string) *Notifier {
@@ -94,12 +115,15 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 		ns:         sender,
 		images:     images,
 		tmpl:       template,
+		threads:    defaultThreads,
+		now:        time.Now,
 		settings:   cfg,
 		appVersion: appVersion,
 	}
 }
 
 func (d Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, d.log)
 	alerts := types.Alerts(as...)
 
 	var msg discordMessage
@@ -109,28 +133,32 @@ func (d Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 	}
 
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, d.tmpl, as, d.log, &tmplErr)
+	tmpl, _ := templates.TmplText(ctx, d.tmpl, as, l, &tmplErr)
 
 	msg.Content = tmpl(d.settings.Message)
 	if tmplErr != nil {
-		d.log.Warn("failed to template Discord notification content", "error", tmplErr.Error())
+		l.Warn("failed to template Discord notification content", "error", tmplErr.Error())
 		// Reset tmplErr for templating other fields.
 		tmplErr = nil
 	}
+	if d.settings.AccessibleMessage {
+		// Screen readers announce the content before the embeds, so start with the status in words.
+		msg.Content = receivers.StatusSummary(as) + "\n\n" + msg.Content
+	}
 	truncatedMsg, truncated := receivers.TruncateInRunes(msg.Content, discordMaxMessageLen)
 	if truncated {
 		key, err := notify.ExtractGroupKey(ctx)
 		if err != nil {
 			return false, err
 		}
-		d.log.Warn("Truncated content", "key", key, "max_runes", discordMaxMessageLen)
+		l.Warn("Truncated content", "key", key, "max_runes", discordMaxMessageLen)
 		msg.Content = truncatedMsg
 	}
 
 	if d.settings.AvatarURL != "" {
 		msg.AvatarURL = tmpl(d.settings.AvatarURL)
 		if tmplErr != nil {
-			d.log.Warn("failed to template Discord Avatar URL", "error", tmplErr.Error(), "fallback", d.settings.AvatarURL)
+			l.Warn("failed to template Discord Avatar URL", "error", tmplErr.Error(), "fallback", d.settings.AvatarURL)
 			msg.AvatarURL = d.settings.AvatarURL
 			tmplErr = nil
 		}
@@ -145,7 +173,7 @@ func (d Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 
 	linkEmbed.Title = tmpl(d.settings.Title)
 	if tmplErr != nil {
-		d.log.Warn("failed to template Discord notification title", "error", tmplErr.Error())
+		l.Warn("failed to template Discord notification title", "error", tmplErr.Error())
 		// Reset tmplErr for templating other fields.
 		tmplErr = nil
 	}
@@ -155,14 +183,24 @@ func (d Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 	color, _ := strconv.ParseInt(strings.TrimLeft(receivers.GetAlertStatusColor(alerts.Status()), "#"), 16, 0)
 	linkEmbed.Color = color
 
-	ruleURL := receivers.JoinURLPath(d.tmpl.ExternalURL.String(), "/alerting/list", d.log)
+	ruleURL := receivers.JoinURLPath(d.tmpl.ExternalURL.String(), "/alerting/list", l)
 	linkEmbed.URL = ruleURL
+	linkEmbed.Description = silenceLinks(d.tmpl, as)
 
 	embeds := []discordLinkEmbed{linkEmbed}
 
 	attachments := d.constructAttachments(ctx, as, discordMaxEmbeds-1)
 	for _, a := range attachments {
 		color, _ := strconv.ParseInt(strings.TrimLeft(receivers.GetAlertStatusColor(alerts.Status()), "#"), 16, 0)
+		if a.fallbackText != "" {
+			embeds = append(embeds, discordLinkEmbed{
+				Title:       a.alertName,
+				Description: a.fallbackText,
+				URL:         a.fallbackURL,
+				Color:       color,
+			})
+			continue
+		}
 		embed := discordLinkEmbed{
 			Image: &discordImage{
 				URL: a.url,
@@ -171,19 +209,65 @@ func (d Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 			Title: a.alertName,
 		}
 		embeds = append(embeds, embed)
+		if a.reader != nil {
+			msg.Attachments = append(msg.Attachments, discordAttachmentMetadata{
+				ID:          len(msg.Attachments),
+				Filename:    a.name,
+				Description: a.altText,
+			})
+		}
 	}
 
 	msg.Embeds = embeds
 
 	if tmplErr != nil {
-		d.log.Warn("failed to template Discord message", "error", tmplErr.Error())
+		l.Warn("failed to template Discord message", "error", tmplErr.Error())
 		tmplErr = nil
 	}
 
 	u := tmpl(d.settings.WebhookURL)
 	if tmplErr != nil {
-		d.log.Warn("failed to template Discord URL", "error", tmplErr.Error(), "fallback", d.settings.WebhookURL)
+		l.Warn("failed to template Discord URL", "error", tmplErr.Error(), "fallback", d.settings.WebhookURL)
 		u = d.settings.WebhookURL
+		tmplErr = nil
+	}
+	id := webhookID(u)
+	if id == "" {
+		id = unknownWebhookID
+	}
+	l = l.New("webhook_id", id)
+
+	// threadKey is the key of the thread of the alert group, if the messages of the group are posted in a thread
+	// created by its first message.
+	var threadKey string
+	var err error
+	createThread := false
+	threadID := d.settings.ThreadID
+	if d.settings.ThreadName != "" {
+		key, err := notify.ExtractGroupKey(ctx)
+		if err != nil {
+			return false, err
+		}
+		threadKey = d.UID + "/" + key.String()
+		var ok bool
+		if threadID, ok = d.threads.get(threadKey); !ok {
+			createThread = true
+			msg.ThreadName = tmpl(d.settings.ThreadName)
+			if tmplErr != nil || msg.ThreadName == "" {
+				l.Warn("failed to template Discord thread name, using the title", "error", tmplErr)
+				msg.ThreadName = linkEmbed.Title
+			}
+			msg.ThreadName, _ = receivers.TruncateInRunes(msg.ThreadName, discordMaxThreadNameLen)
+			// Wait for the message to be created to know the ID of its thread.
+			if u, err = withQuery(u, "wait", "true"); err != nil {
+				return false, err
+			}
+		}
+	}
+	if threadID != "" {
+		if u, err = withQuery(u, "thread_id", threadID); err != nil {
+			return false, err
+		}
 	}
 
 	body, err := json.Marshal(msg)
@@ -198,46 +282,97 @@ func (d Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 
 	cmd.Validation = func(body []byte, statusCode int) error {
 		if statusCode/100 != 2 {
-			d.log.Error("failed to send notification to Discord", "statusCode", statusCode, "responseBody", string(body))
+			l.Error("failed to send notification to Discord", "statusCode", statusCode, "responseBody", string(body))
 			errBody := discordError{}
 			if err := json.Unmarshal(body, &errBody); err == nil {
 				return fmt.Errorf("the Discord API responded (status %d) with error code %d: %s", statusCode, errBody.Code, errBody.Message)
 			}
 			return fmt.Errorf("unexpected status code %d from Discord", statusCode)
 		}
+		if createThread {
+			if threadID, err = threadIDFromResponse(body); err != nil {
+				// The message was sent, so the next message of the group creates another thread instead of failing.
+				l.Warn("failed to get the thread of the Discord message", "error", err)
+			}
+		}
 		return nil
 	}
-	if err := d.ns.SendWebhook(ctx, cmd); err != nil {
+	err = d.ns.SendWebhook(ctx, cmd)
+	if m := metricsFromContext(ctx); m != nil {
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		m.Requests.WithLabelValues(id, result).Inc()
+	}
+	if err != nil {
 		return false, err
 	}
+
+	if threadKey != "" {
+		if alerts.Status() == model.AlertResolved {
+			d.threads.delete(threadKey)
+		} else if threadID != "" {
+			d.threads.set(threadKey, threadID, d.now())
+		}
+	}
 	return true, nil
 }
 
+// silenceLinks returns the links to silence each firing alert, one per line. Webhooks cannot send buttons, so the
+// links are in the description of the embed, and the links that do not fit are left out.
+func silenceLinks(tmpl *templates.Template, alerts []*types.Alert) string {
+	var b strings.Builder
+	for _, link := range receivers.AlertSilenceLinks(tmpl, alerts, 0) {
+		line := fmt.Sprintf("[%s](%s)", link.Title, link.URL)
+		if b.Len() > 0 {
+			line = "\n" + line
+		}
+		if b.Len()+len(line) > discordMaxDescriptionLen {
+			break
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
 func (d Notifier) SendResolved() bool {
 	return !d.GetDisableResolveMessage()
 }
 
 func (d Notifier) constructAttachments(ctx context.Context, alerts []*types.Alert, embedQuota int) []discordAttachment {
+	l := logging.FromContext(ctx, d.log)
 	attachments := make([]discordAttachment, 0, embedQuota)
 	embedsUsed := 0
 	for _, alert := range alerts {
 		// Check if the image limit has been reached at the start of each iteration.
 		if embedsUsed >= embedQuota {
-			d.log.Warn("Discord embed quota reached, not creating more attachments for this notification", "embedQuota", embedQuota)
+			l.Warn("Discord embed quota reached, not creating more attachments for this notification", "embedQuota", embedQuota)
 			break
 		}
 
 		attachment, err := d.getAttachment(ctx, alert)
 		if err != nil {
-			if errors.Is(err, images.ErrNoImageForAlert) {
+			if errors.Is(err, images.ErrNoImageForAlert) || errors.Is(err, images.ErrImagesUnavailable) {
 				// There's no image for this alert, continue.
 				continue
 			}
-			d.log.Error("failed to create an attachment for Discord", "alert", alert, "error", err)
+			// Link to the dashboard in place of the image, so that the image is not dropped silently.
+			l.Warn("failed to create an attachment for Discord, linking to the dashboard instead", "alert", alert, "error", err)
+			images.RecordFallback(ctx, "discord", err)
+			url := receivers.ImageFallbackURL(ctx, d.tmpl, alert, l)
+			attachments = append(attachments, discordAttachment{
+				alertName:    alert.Name(),
+				state:        alert.Status(),
+				fallbackURL:  url,
+				fallbackText: receivers.ImageFallbackText(alert, url),
+			})
+			embedsUsed++
 			continue
 		}
 
 		// We got an attachment, either using the image URL or bytes.
+		attachment.altText = receivers.TmplImageAltText(ctx, d.tmpl, d.settings.ImageAltText, alert, l)
 		attachments = append(attachments, attachment)
 		embedsUsed++
 	}
@@ -285,9 +420,14 @@ func (d Notifier) getAttachmentFromBytes(ctx context.Context, alert *types.Alert
 }
 
 func (d Notifier) buildRequest(url string, body []byte, attachments []discordAttachment) (*receivers.SendWebhookSettings, error) {
+	tlsConfig, err := receivers.ClientTLSConfig(d.settings.TLSConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        url,
 		HTTPMethod: "POST",
+		TLSConfig:  tlsConfig,
 	}
 	if len(attachments) == 0 {
 		cmd.ContentType = "application/json"
@@ -313,11 +453,13 @@ func (d Notifier) buildRequest(url string, body []byte, attachments []discordAtt
 		return nil, err
 	}
 
+	// The index of each file must match the ID of its attachment in the payload.
+	var idx int
 	for _, a := range attachments {
 		if a.reader != nil { // We have an image to upload.
 			err = func() error {
 				defer func() { _ = a.reader.Close() }()
-				part, err := w.CreateFormFile("", a.name)
+				part, err := w.CreateFormFile(fmt.Sprintf("files[%d]", idx), a.name)
 				if err != nil {
 					return err
 				}
@@ -327,6 +469,7 @@ func (d Notifier) buildRequest(url string, body []byte, attachments []discordAtt
 			if err != nil {
 				return nil, err
 			}
+			idx++
 		}
 	}
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"mime"
@@ -15,6 +16,8 @@ import (
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
@@ -25,6 +28,11 @@ import (
 	"github.com/grafana/alerting/templates"
 )
 
+// silenceLink returns the link to silence the alert alert1 with the value of lbl1.
+func silenceLink(title, lbl1 string) string {
+	return "[" + title + "](http://localhost/alerting/silence/new?alertmanager=grafana&duration=2h&matcher=alertname%3D%22alert1%22&matcher=lbl1%3D%22" + lbl1 + "%22)"
+}
+
 func TestNotify(t *testing.T) {
 	tmpl := templates.ForTests(t)
 
@@ -65,9 +73,43 @@ func TestNotify(t *testing.T) {
 						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 						"text":     "Grafana v" + appVersion,
 					},
-					"title": "[FIRING:1]  (val1)",
-					"url":   "http://localhost/alerting/list",
-					"type":  "rich",
+					"title":       "[FIRING:1]  (val1)",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
+				}},
+				"username": "Grafana",
+			},
+			expMsgError: nil,
+		},
+		{
+			name: "Accessible message with one alert",
+			settings: Config{
+				Title:             templates.DefaultMessageTitleEmbed,
+				Message:           "message",
+				WebhookURL:        "http://localhost",
+				AccessibleMessage: true,
+			},
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1"},
+					},
+				},
+			},
+			expMsg: map[string]interface{}{
+				"content": "1 alert firing\n\nmessage",
+				"embeds": []interface{}{map[string]interface{}{
+					"color": 1.4037554e+07,
+					"footer": map[string]interface{}{
+						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
+						"text":     "Grafana v" + appVersion,
+					},
+					"title":       "[FIRING:1]  (val1)",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
 				}},
 				"username": "Grafana",
 			},
@@ -98,9 +140,10 @@ func TestNotify(t *testing.T) {
 						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 						"text":     "Grafana v" + appVersion,
 					},
-					"title": "Alerts firing: 1",
-					"url":   "http://localhost/alerting/list",
-					"type":  "rich",
+					"title":       "Alerts firing: 1",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
 				}},
 				"username": "Grafana",
 			},
@@ -132,9 +175,10 @@ func TestNotify(t *testing.T) {
 						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 						"text":     "Grafana v" + appVersion,
 					},
-					"title": "[FIRING:1]  (val1)",
-					"url":   "http://localhost/alerting/list",
-					"type":  "rich",
+					"title":       "[FIRING:1]  (val1)",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
 				}},
 				"username": "Grafana",
 			},
@@ -166,9 +210,10 @@ func TestNotify(t *testing.T) {
 						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 						"text":     "Grafana v" + appVersion,
 					},
-					"title": "[FIRING:1]  (val1)",
-					"url":   "http://localhost/alerting/list",
-					"type":  "rich",
+					"title":       "[FIRING:1]  (val1)",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
 				}},
 				"username": "Grafana",
 			},
@@ -200,9 +245,10 @@ func TestNotify(t *testing.T) {
 						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 						"text":     "Grafana v" + appVersion,
 					},
-					"title": "[FIRING:1]  (val1)",
-					"url":   "http://localhost/alerting/list",
-					"type":  "rich",
+					"title":       "[FIRING:1]  (val1)",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
 				}},
 				"username": "Grafana",
 			},
@@ -234,9 +280,10 @@ func TestNotify(t *testing.T) {
 						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 						"text":     "Grafana v" + appVersion,
 					},
-					"title": "[FIRING:1]  (val1)",
-					"url":   "http://localhost/alerting/list",
-					"type":  "rich",
+					"title":       "[FIRING:1]  (val1)",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
 				}},
 				"username": "Grafana",
 			},
@@ -273,9 +320,10 @@ func TestNotify(t *testing.T) {
 						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 						"text":     "Grafana v" + appVersion,
 					},
-					"title": "[FIRING:2]  ",
-					"url":   "http://localhost/alerting/list",
-					"type":  "rich",
+					"title":       "[FIRING:2]  ",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence alert1", "val1") + "\n" + silenceLink("Silence alert1", "val2"),
+					"type":        "rich",
 				}},
 				"username": "Grafana",
 			},
@@ -306,9 +354,10 @@ func TestNotify(t *testing.T) {
 						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 						"text":     "Grafana v" + appVersion,
 					},
-					"title": "[FIRING:1]  (val1)",
-					"url":   "http://localhost/alerting/list",
-					"type":  "rich",
+					"title":       "[FIRING:1]  (val1)",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
 				}},
 			},
 			expMsgError: nil,
@@ -338,9 +387,10 @@ func TestNotify(t *testing.T) {
 						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 						"text":     "Grafana v" + appVersion,
 					},
-					"title": "[FIRING:1]  (val1)",
-					"url":   "http://localhost/alerting/list",
-					"type":  "rich",
+					"title":       "[FIRING:1]  (val1)",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
 				}},
 			},
 			expMsgError: nil,
@@ -434,9 +484,10 @@ func TestNotify_WithImages(t *testing.T) {
 						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 						"text":     "Grafana v" + appVersion,
 					},
-					"title": "[FIRING:1]  (val1)",
-					"url":   "http://localhost/alerting/list",
-					"type":  "rich",
+					"title":       "[FIRING:1]  (val1)",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
 				},
 					map[string]interface{}{
 						"image": map[string]interface{}{
@@ -457,6 +508,7 @@ func TestNotify_WithImages(t *testing.T) {
 				AvatarURL:          "",
 				WebhookURL:         "http://localhost",
 				UseDiscordUsername: false,
+				ImageAltText:       templates.DefaultImageAltTextEmbed,
 			},
 			alerts: []*types.Alert{
 				{
@@ -474,9 +526,10 @@ func TestNotify_WithImages(t *testing.T) {
 						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 						"text":     "Grafana v" + appVersion,
 					},
-					"title": "[FIRING:1]  (val1)",
-					"url":   "http://localhost/alerting/list",
-					"type":  "rich",
+					"title":       "[FIRING:1]  (val1)",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
 				},
 					map[string]interface{}{
 						"image": map[string]interface{}{
@@ -485,11 +538,56 @@ func TestNotify_WithImages(t *testing.T) {
 						"title": "alert1",
 						"color": 1.4037554e+07,
 					}},
+				"attachments": []interface{}{map[string]interface{}{
+					"id":          0,
+					"filename":    "test-image-2.jpg",
+					"description": "Firing alert alert1",
+				}},
 				"username": "Grafana",
 			},
 			expMsgError: nil,
 			expBytes:    expectedBytes,
 		},
+		{
+			name: "Default config with one alert, image not found",
+			settings: Config{
+				Title:              templates.DefaultMessageTitleEmbed,
+				Message:            templates.DefaultMessageEmbed,
+				AvatarURL:          "",
+				WebhookURL:         "http://localhost",
+				UseDiscordUsername: false,
+			},
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+						Annotations: model.LabelSet{"ann1": "annv1", "__dashboardUid__": "abcd", "__panelId__": "efgh", models.ImageTokenAnnotation: "missing"},
+					},
+				},
+			},
+			expMsg: map[string]interface{}{
+				"content": "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1\nDashboard: http://localhost/d/abcd\nPanel: http://localhost/d/abcd?viewPanel=efgh\n",
+				"embeds": []interface{}{map[string]interface{}{
+					"color": 1.4037554e+07,
+					"footer": map[string]interface{}{
+						"icon_url": "https://grafana.com/static/assets/img/fav32.png",
+						"text":     "Grafana v" + appVersion,
+					},
+					"title":       "[FIRING:1]  (val1)",
+					"url":         "http://localhost/alerting/list",
+					"description": silenceLink("Silence", "val1"),
+					"type":        "rich",
+				},
+					map[string]interface{}{
+						"description": "The image of alert1 could not be loaded, view it in Grafana: http://localhost/d/abcd?viewPanel=efgh",
+						"url":         "http://localhost/d/abcd?viewPanel=efgh",
+						"title":       "alert1",
+						"color":       1.4037554e+07,
+					}},
+				"username": "Grafana",
+			},
+			expMsgError: nil,
+		},
 	}
 
 	for _, c := range cases {
@@ -568,6 +666,11 @@ func TestNotify_WithImages(t *testing.T) {
 			alerts = append(alerts, &alert)
 		}
 
+		// Each alert has a silence link in the description.
+		var links []string
+		for i := 0; i < 15; i++ {
+			links = append(links, fmt.Sprintf("[Silence alert-%d](http://localhost/alerting/silence/new?alertmanager=grafana&duration=2h&matcher=alertname%%3D%%22alert-%d%%22&matcher=lbl1%%3D%%22val%%22)", i, i))
+		}
 		expEmbeds := []interface{}{
 			map[string]interface{}{
 				"color": 1.4037554e+07,
@@ -575,9 +678,10 @@ func TestNotify_WithImages(t *testing.T) {
 					"icon_url": "https://grafana.com/static/assets/img/fav32.png",
 					"text":     "Grafana v" + appVersion,
 				},
-				"title": "[FIRING:15]  ",
-				"url":   "http://localhost/alerting/list",
-				"type":  "rich",
+				"title":       "[FIRING:15]  ",
+				"url":         "http://localhost/alerting/list",
+				"description": strings.Join(links, "\n"),
+				"type":        "rich",
 			}}
 
 		for i := 0; i < 9; i++ {
@@ -628,3 +732,173 @@ func TestNotify_WithImages(t *testing.T) {
 		require.Equal(tt, expEmbeds, embeds)
 	})
 }
+
+// threadWebhookSender responds to the webhooks with a message in the thread.
+type threadWebhookSender struct {
+	receivers.NotificationServiceMock
+	threadID string
+}
+
+func (s *threadWebhookSender) SendWebhook(ctx context.Context, cmd *receivers.SendWebhookSettings) error {
+	if err := s.NotificationServiceMock.SendWebhook(ctx, cmd); err != nil {
+		return err
+	}
+	return cmd.Validation([]byte(`{"id":"1","channel_id":"`+s.threadID+`"}`), 200)
+}
+
+func TestNotify_Threads(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+		StartsAt: time.Now(),
+		EndsAt:   time.Now().Add(time.Hour),
+	}}
+	resolved := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+		StartsAt: time.Now().Add(-time.Hour),
+		EndsAt:   time.Now().Add(-time.Minute),
+	}}
+
+	newNotifier := func(settings Config, sender receivers.WebhookSender) *Notifier {
+		return &Notifier{
+			Base:     &receivers.Base{},
+			log:      &logging.FakeLogger{},
+			ns:       sender,
+			tmpl:     tmpl,
+			settings: settings,
+			images:   &images.UnavailableProvider{},
+			threads:  newThreadStore(),
+			now:      time.Now,
+		}
+	}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+
+	threadName := func(t *testing.T, body string) string {
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(body), &m))
+		name, _ := m["thread_name"].(string)
+		return name
+	}
+
+	t.Run("messages of a group are posted in the thread of the first message", func(t *testing.T) {
+		sender := &threadWebhookSender{threadID: "T1"}
+		dn := newNotifier(Config{
+			WebhookURL: "http://localhost/webhook",
+			Title:      templates.DefaultMessageTitleEmbed,
+			Message:    templates.DefaultMessageEmbed,
+			ThreadName: `{{ .CommonLabels.lbl1 }}`,
+		}, sender)
+
+		ok, err := dn.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "http://localhost/webhook?wait=true", sender.Webhook.URL)
+		require.Equal(t, "val1", threadName(t, sender.Webhook.Body))
+
+		ok, err = dn.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "http://localhost/webhook?thread_id=T1", sender.Webhook.URL)
+		require.Empty(t, threadName(t, sender.Webhook.Body))
+
+		// The thread is forgotten once the group is resolved.
+		ok, err = dn.Notify(ctx, resolved)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "http://localhost/webhook?thread_id=T1", sender.Webhook.URL)
+
+		sender.threadID = "T2"
+		ok, err = dn.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "http://localhost/webhook?wait=true", sender.Webhook.URL)
+		require.Equal(t, "val1", threadName(t, sender.Webhook.Body))
+
+		id, ok := dn.threads.get(dn.UID + "/" + "alertname")
+		require.True(t, ok)
+		require.Equal(t, "T2", id)
+	})
+
+	t.Run("thread name falls back to the title", func(t *testing.T) {
+		sender := &threadWebhookSender{threadID: "T1"}
+		dn := newNotifier(Config{
+			WebhookURL: "http://localhost/webhook",
+			Title:      "title",
+			Message:    templates.DefaultMessageEmbed,
+			ThreadName: `{{ .CommonLabels.missing }}`,
+		}, sender)
+
+		ok, err := dn.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "title", threadName(t, sender.Webhook.Body))
+	})
+
+	t.Run("messages are posted in the configured thread", func(t *testing.T) {
+		sender := receivers.MockNotificationService()
+		dn := newNotifier(Config{
+			WebhookURL: "http://localhost/webhook",
+			Title:      templates.DefaultMessageTitleEmbed,
+			Message:    templates.DefaultMessageEmbed,
+			ThreadID:   "123",
+		}, sender)
+
+		ok, err := dn.Notify(ctx, firing)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "http://localhost/webhook?thread_id=123", sender.Webhook.URL)
+		require.Empty(t, threadName(t, sender.Webhook.Body))
+	})
+}
+
+func TestNotify_Metrics(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	m := NewMetrics(prometheus.NewPedanticRegistry())
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	ctx = WithMetrics(ctx, m)
+	alert := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "alert1", "webhook": "456/token"},
+		StartsAt: time.Now(),
+	}}
+
+	send := func(webhookURL string, sendErr error) {
+		sender := receivers.MockNotificationService()
+		sender.ShouldError = sendErr
+		dn := &Notifier{
+			Base: &receivers.Base{},
+			log:  &logging.FakeLogger{},
+			ns:   sender,
+			tmpl: tmpl,
+			settings: Config{
+				WebhookURL: webhookURL,
+				Title:      templates.DefaultMessageTitleEmbed,
+				Message:    templates.DefaultMessageEmbed,
+			},
+			images:  &images.UnavailableProvider{},
+			threads: newThreadStore(),
+			now:     time.Now,
+		}
+		_, _ = dn.Notify(ctx, alert)
+	}
+
+	send("https://discord.com/api/webhooks/123/token", nil)
+	send("https://discord.com/api/webhooks/123/token", errors.New("failed"))
+	// The ID of templated URLs is known once they are executed.
+	send("https://discord.com/api/webhooks/{{ .CommonLabels.webhook }}", nil)
+	send("http://localhost/{{ .CommonLabels.webhook }}", nil)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("123", "success")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("123", "failure")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues("456", "success")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.Requests.WithLabelValues(unknownWebhookID, "success")))
+}
@@ -0,0 +1,42 @@
+package discord
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// unknownWebhookID is the webhook ID of templated URLs that do not have the shape of a Discord webhook URL.
+const unknownWebhookID = "unknown"
+
+// Metrics are the metrics of the requests sent to Discord webhooks. Each webhook is a series of the metrics, so they
+// are only updated by notifiers whose context has them, see WithMetrics.
+type Metrics struct {
+	// Requests is the number of requests by webhook ID and result, "success" or "failure".
+	Requests *prometheus.CounterVec
+}
+
+// NewMetrics creates the metrics of the requests sent to Discord webhooks.
+func NewMetrics(r prometheus.Registerer) *Metrics {
+	return &Metrics{
+		Requests: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "discord_requests_total",
+			Help:      "Number of requests sent to Discord webhooks by webhook ID and result.",
+		}, []string{"webhook_id", "result"}),
+	}
+}
+
+type metricsKey struct{}
+
+// WithMetrics returns a context with the metrics updated by notifiers.
+func WithMetrics(ctx context.Context, m *Metrics) context.Context {
+	return context.WithValue(ctx, metricsKey{}, m)
+}
+
+func metricsFromContext(ctx context.Context) *Metrics {
+	m, _ := ctx.Value(metricsKey{}).(*Metrics)
+	return m
+}
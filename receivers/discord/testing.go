@@ -2,9 +2,18 @@ package discord
 
 // FullValidConfigForTesting is a string representation of a JSON object that contains all fields supported by the notifier Config. It can be used without secrets.
 const FullValidConfigForTesting = `{
-	"url": "http://localhost", 
+	"url": "http://localhost/api/webhooks/123/test-token",
 	"title": "test-title", 
 	"message": "test-message", 
 	"avatar_url" : "http://avatar", 
-	"use_discord_username": true
+	"use_discord_username": true,
+	"image_alt_text": "test-image-alt-text",
+	"accessible_message": true,
+	"thread_name": "test-thread-name",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
@@ -0,0 +1,87 @@
+package discord
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// threadTTL is for how long the thread of an alert group is kept after its last message. Alert groups are
+	// usually forgotten once all of their alerts are resolved, the TTL covers groups that are never resolved.
+	threadTTL = 7 * 24 * time.Hour
+	// discordMaxThreadNameLen is the maximum length of the name of a thread, in characters.
+	discordMaxThreadNameLen = 100
+)
+
+// thread is the thread that a forum post created for an alert group.
+type thread struct {
+	ID        string
+	UpdatedAt time.Time
+}
+
+// threadStore stores the threads of alert groups. It is kept in memory, and shared by all notifiers, so that the
+// threads are kept when the configuration is reloaded.
+type threadStore struct {
+	mtx     sync.Mutex
+	threads map[string]thread
+}
+
+var defaultThreads = newThreadStore()
+
+func newThreadStore() *threadStore {
+	return &threadStore{threads: make(map[string]thread)}
+}
+
+func (s *threadStore) get(key string) (string, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	t, ok := s.threads[key]
+	return t.ID, ok
+}
+
+func (s *threadStore) set(key, id string, now time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for k, v := range s.threads {
+		if now.Sub(v.UpdatedAt) > threadTTL {
+			delete(s.threads, k)
+		}
+	}
+	s.threads[key] = thread{ID: id, UpdatedAt: now}
+}
+
+func (s *threadStore) delete(key string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.threads, key)
+}
+
+// withQuery returns the URL of the webhook with the query parameter added.
+func withQuery(webhookURL, key, value string) (string, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse webhook URL: %w", err)
+	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// threadIDFromResponse returns the ID of the thread of the message that the webhook responded with. Messages of
+// threads are in the channel of the thread.
+func threadIDFromResponse(body []byte) (string, error) {
+	var m struct {
+		ChannelID string `json:"channel_id"`
+	}
+	if err := json.Unmarshal(body, &m); err != nil {
+		return "", fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	if m.ChannelID == "" {
+		return "", fmt.Errorf("message has no channel")
+	}
+	return m.ChannelID, nil
+}
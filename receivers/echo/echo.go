@@ -0,0 +1,49 @@
+package echo
+
+import (
+	"context"
+
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+// Notifier renders notifications and writes them to the log instead of sending them anywhere.
+// It can be used to test routes and templates.
+type Notifier struct {
+	*receivers.Base
+	log      logging.Logger
+	tmpl     *templates.Template
+	settings Config
+}
+
+func New(cfg Config, meta receivers.Metadata, template *templates.Template, logger logging.Logger) *Notifier {
+	return &Notifier{
+		Base:     receivers.NewBase(meta),
+		log:      logger,
+		tmpl:     template,
+		settings: cfg,
+	}
+}
+
+// Notify writes the rendered notification to the log.
+func (n *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, n.log)
+	var tmplErr error
+	tmpl, _ := templates.TmplText(ctx, n.tmpl, as, l, &tmplErr)
+
+	title := tmpl(n.settings.Title)
+	message := tmpl(n.settings.Message)
+	if tmplErr != nil {
+		l.Warn("failed to template echo message", "error", tmplErr.Error())
+	}
+
+	l.Info("echo notification", "alerts", len(as), "title", title, "message", message)
+	return true, nil
+}
+
+func (n *Notifier) SendResolved() bool {
+	return !n.GetDisableResolveMessage()
+}
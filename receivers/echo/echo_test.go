@@ -0,0 +1,55 @@
+package echo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+func TestNotify(t *testing.T) {
+	tmpl := templates.ForTests(t)
+
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	var buf bytes.Buffer
+	logger := logging.NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil))).New(logging.KeyReceiver, "echo")
+	n := New(Config{
+		Title:   "{{ len .Alerts.Firing }} firing",
+		Message: "{{ range .Alerts }}{{ .Labels.alertname }}{{ end }}",
+	}, receivers.Metadata{Name: "echo"}, tmpl, logger)
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	ctx = logging.WithContextFields(ctx, logging.KeyGroupKey, "alertname")
+	ok, err := n.Notify(ctx, &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var line map[string]interface{}
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &line))
+	delete(line, "time")
+	require.Equal(t, map[string]interface{}{
+		"level":     "INFO",
+		"msg":       "echo notification",
+		"receiver":  "echo",
+		"group_key": "alertname",
+		"alerts":    float64(1),
+		"title":     "1 firing",
+		"message":   "alert1",
+	}, line)
+}
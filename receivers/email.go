@@ -11,6 +11,9 @@ type SendEmailSettings struct {
 	Data          map[string]interface{}
 	ReplyTo       []string
 	EmbeddedFiles []string
+	AttachedFiles []string
+	// ContentTypes overrides the content types of the sender, in descending order of preference, if set.
+	ContentTypes []string
 }
 
 type EmailSender interface {
@@ -0,0 +1,57 @@
+package email
+
+import (
+	"fmt"
+	"sort"
+)
+
+// embeddedImage is an image on disk that can be embedded in or attached to the email.
+type embeddedImage struct {
+	index  int
+	path   string
+	url    string
+	size   int64
+	firing bool
+}
+
+// selectEmbeddedImages returns the images that fit within the total size and count limits and the images
+// that were omitted, both in alert order. Images of firing alerts take precedence over images of resolved
+// alerts, and otherwise images are selected in alert order. A limit of zero means no limit.
+func selectEmbeddedImages(candidates []embeddedImage, maxSize int64, maxCount int) ([]embeddedImage, []embeddedImage) {
+	prioritized := make([]embeddedImage, len(candidates))
+	copy(prioritized, candidates)
+	sort.SliceStable(prioritized, func(i, j int) bool {
+		return prioritized[i].firing && !prioritized[j].firing
+	})
+
+	var (
+		selected, omitted []embeddedImage
+		totalSize         int64
+	)
+	for _, img := range prioritized {
+		if (maxCount > 0 && len(selected) >= maxCount) || (maxSize > 0 && totalSize+img.size > maxSize) {
+			omitted = append(omitted, img)
+			continue
+		}
+		selected = append(selected, img)
+		totalSize += img.size
+	}
+
+	byIndex := func(images []embeddedImage) {
+		sort.Slice(images, func(i, j int) bool {
+			return images[i].index < images[j].index
+		})
+	}
+	byIndex(selected)
+	byIndex(omitted)
+	return selected, omitted
+}
+
+// appendOmittedImagesNote appends a note to the message explaining that some images were not included.
+func appendOmittedImagesNote(message string, omitted int) string {
+	note := fmt.Sprintf("%d image(s) were not included in this email because it would exceed the attachment limits. Open the alert in Grafana to view them.", omitted)
+	if message == "" {
+		return note
+	}
+	return message + "\n\n" + note
+}
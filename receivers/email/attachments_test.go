@@ -0,0 +1,72 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectEmbeddedImages(t *testing.T) {
+	candidates := []embeddedImage{
+		{index: 0, path: "a.png", size: 40, firing: false},
+		{index: 1, path: "b.png", size: 50, firing: true},
+		{index: 2, path: "c.png", size: 30, firing: true},
+	}
+
+	cases := []struct {
+		name            string
+		maxSize         int64
+		maxCount        int
+		expectedPaths   []string
+		expectedOmitted []string
+	}{
+		{
+			name:          "no limits",
+			expectedPaths: []string{"a.png", "b.png", "c.png"},
+		},
+		{
+			name:            "count limit prefers firing alerts",
+			maxCount:        2,
+			expectedPaths:   []string{"b.png", "c.png"},
+			expectedOmitted: []string{"a.png"},
+		},
+		{
+			name:            "size limit prefers firing alerts",
+			maxSize:         80,
+			expectedPaths:   []string{"b.png", "c.png"},
+			expectedOmitted: []string{"a.png"},
+		},
+		{
+			name:            "size limit skips images that do not fit",
+			maxSize:         70,
+			expectedPaths:   []string{"b.png"},
+			expectedOmitted: []string{"a.png", "c.png"},
+		},
+		{
+			name:            "size limit smaller than every image",
+			maxSize:         10,
+			expectedOmitted: []string{"a.png", "b.png", "c.png"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			selected, omitted := selectEmbeddedImages(candidates, c.maxSize, c.maxCount)
+			require.Equal(t, c.expectedPaths, imagePaths(selected))
+			require.Equal(t, c.expectedOmitted, imagePaths(omitted))
+		})
+	}
+}
+
+func imagePaths(images []embeddedImage) []string {
+	var paths []string
+	for _, img := range images {
+		paths = append(paths, img.path)
+	}
+	return paths
+}
+
+func TestAppendOmittedImagesNote(t *testing.T) {
+	require.Equal(t, "1 image(s) were not included in this email because it would exceed the attachment limits. Open the alert in Grafana to view them.", appendOmittedImagesNote("", 1))
+	require.Equal(t, "message\n\n2 image(s) were not included in this email because it would exceed the attachment limits. Open the alert in Grafana to view them.", appendOmittedImagesNote("message", 2))
+}
@@ -9,11 +9,49 @@ import (
 	"github.com/grafana/alerting/templates"
 )
 
+// EmbedImagesMode controls how images stored on disk are added to the email.
+type EmbedImagesMode string
+
+const (
+	// EmbedImagesInline embeds images as inline attachments referenced from the HTML body by their Content-ID.
+	EmbedImagesInline EmbedImagesMode = "inline"
+	// EmbedImagesAttach adds images as regular file attachments.
+	EmbedImagesAttach EmbedImagesMode = "attach"
+	// EmbedImagesNone does not add images stored on disk to the email. Images are only linked by URL.
+	EmbedImagesNone EmbedImagesMode = "none"
+)
+
+// Profile controls the format of the emails.
+type Profile string
+
+const (
+	// ProfileDefault sends HTML emails with the details of the alerts.
+	ProfileDefault Profile = "default"
+	// ProfileShortMessage sends plain text emails with a single line subject and no body, for email-to-SMS gateways
+	// that mangle HTML emails.
+	ProfileShortMessage Profile = "shortMessage"
+)
+
+// DefaultShortMessageMaxLength is the maximum length of short messages if none is configured, which is the
+// length of a single SMS.
+const DefaultShortMessageMaxLength = 160
+
 type Config struct {
 	SingleEmail bool
 	Addresses   []string
 	Message     string
 	Subject     string
+	// MaxAttachmentSize is the maximum total size in bytes of the images embedded in a single email.
+	// Zero means no limit.
+	MaxAttachmentSize int64
+	// MaxEmbeddedImages is the maximum number of images embedded in a single email. Zero means no limit.
+	MaxEmbeddedImages int
+	// EmbedImages controls how images stored on disk are added to the email.
+	EmbedImages EmbedImagesMode
+	// Profile controls the format of the emails.
+	Profile Profile
+	// MaxLength is the maximum length in characters of the subject of short messages.
+	MaxLength int
 }
 
 func NewConfig(jsonData json.RawMessage) (Config, error) {
@@ -22,6 +60,14 @@ func NewConfig(jsonData json.RawMessage) (Config, error) {
 		Addresses   string `json:"addresses,omitempty" yaml:"addresses,omitempty"`
 		Message     string `json:"message,omitempty" yaml:"message,omitempty"`
 		Subject     string `json:"subject,omitempty" yaml:"subject,omitempty"`
+
+		MaxAttachmentSize int64 `json:"maxAttachmentSize,omitempty" yaml:"maxAttachmentSize,omitempty"`
+		MaxEmbeddedImages int   `json:"maxEmbeddedImages,omitempty" yaml:"maxEmbeddedImages,omitempty"`
+
+		EmbedImages EmbedImagesMode `json:"embedImages,omitempty" yaml:"embedImages,omitempty"`
+
+		Profile   Profile `json:"profile,omitempty" yaml:"profile,omitempty"`
+		MaxLength int     `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
 	}
 
 	var settings emailSettingsRaw
@@ -35,6 +81,35 @@ func NewConfig(jsonData json.RawMessage) (Config, error) {
 	// split addresses with a few different ways
 	addresses := splitEmails(settings.Addresses)
 
+	if settings.MaxAttachmentSize < 0 {
+		return Config{}, errors.New("maxAttachmentSize must not be negative")
+	}
+	if settings.MaxEmbeddedImages < 0 {
+		return Config{}, errors.New("maxEmbeddedImages must not be negative")
+	}
+
+	switch settings.EmbedImages {
+	case "":
+		settings.EmbedImages = EmbedImagesInline
+	case EmbedImagesInline, EmbedImagesAttach, EmbedImagesNone:
+	default:
+		return Config{}, fmt.Errorf("invalid embedImages %q, must be one of %q, %q or %q", settings.EmbedImages, EmbedImagesInline, EmbedImagesAttach, EmbedImagesNone)
+	}
+
+	switch settings.Profile {
+	case "":
+		settings.Profile = ProfileDefault
+	case ProfileDefault, ProfileShortMessage:
+	default:
+		return Config{}, fmt.Errorf("invalid profile %q, must be one of %q or %q", settings.Profile, ProfileDefault, ProfileShortMessage)
+	}
+	if settings.MaxLength < 0 {
+		return Config{}, errors.New("maxLength must not be negative")
+	}
+	if settings.Profile == ProfileShortMessage && settings.MaxLength == 0 {
+		settings.MaxLength = DefaultShortMessageMaxLength
+	}
+
 	if settings.Subject == "" {
 		settings.Subject = templates.DefaultMessageTitleEmbed
 	}
@@ -44,6 +119,13 @@ func NewConfig(jsonData json.RawMessage) (Config, error) {
 		Message:     settings.Message,
 		Subject:     settings.Subject,
 		Addresses:   addresses,
+
+		MaxAttachmentSize: settings.MaxAttachmentSize,
+		MaxEmbeddedImages: settings.MaxEmbeddedImages,
+		EmbedImages:       settings.EmbedImages,
+
+		Profile:   settings.Profile,
+		MaxLength: settings.MaxLength,
 	}, nil
 }
 
@@ -39,8 +39,10 @@ func TestNewConfig(t *testing.T) {
 				Addresses: []string{
 					"test@grafana.com",
 				},
-				Message: "",
-				Subject: templates.DefaultMessageTitleEmbed,
+				Message:     "",
+				Subject:     templates.DefaultMessageTitleEmbed,
+				EmbedImages: EmbedImagesInline,
+				Profile:     ProfileDefault,
 			},
 		},
 		{
@@ -54,8 +56,10 @@ func TestNewConfig(t *testing.T) {
 					"test3@grafana.com",
 					"test4@granafa.com",
 				},
-				Message: "",
-				Subject: templates.DefaultMessageTitleEmbed,
+				Message:     "",
+				Subject:     templates.DefaultMessageTitleEmbed,
+				EmbedImages: EmbedImagesInline,
+				Profile:     ProfileDefault,
 			},
 		},
 		{
@@ -66,8 +70,10 @@ func TestNewConfig(t *testing.T) {
 				Addresses: []string{
 					"test@grafana.com",
 				},
-				Message: "",
-				Subject: templates.DefaultMessageTitleEmbed,
+				Message:     "",
+				Subject:     templates.DefaultMessageTitleEmbed,
+				EmbedImages: EmbedImagesInline,
+				Profile:     ProfileDefault,
 			},
 		},
 		{
@@ -78,10 +84,61 @@ func TestNewConfig(t *testing.T) {
 				Addresses: []string{
 					"test@grafana.com",
 				},
-				Message: "test-message",
-				Subject: "test-subject",
+				Message:           "test-message",
+				Subject:           "test-subject",
+				MaxAttachmentSize: 10485760,
+				MaxEmbeddedImages: 5,
+				EmbedImages:       EmbedImagesAttach,
+				Profile:           ProfileShortMessage,
+				MaxLength:         140,
 			},
 		},
+		{
+			name:              "Error if maxAttachmentSize is negative",
+			settings:          `{"addresses": "test@grafana.com", "maxAttachmentSize": -1}`,
+			expectedInitError: `maxAttachmentSize must not be negative`,
+		},
+		{
+			name:              "Error if maxEmbeddedImages is negative",
+			settings:          `{"addresses": "test@grafana.com", "maxEmbeddedImages": -1}`,
+			expectedInitError: `maxEmbeddedImages must not be negative`,
+		},
+		{
+			name:     "Embed images can be disabled",
+			settings: `{"addresses": "test@grafana.com", "embedImages": "none"}`,
+			expectedConfig: Config{
+				Addresses:   []string{"test@grafana.com"},
+				Subject:     templates.DefaultMessageTitleEmbed,
+				EmbedImages: EmbedImagesNone,
+				Profile:     ProfileDefault,
+			},
+		},
+		{
+			name:              "Error if embedImages is invalid",
+			settings:          `{"addresses": "test@grafana.com", "embedImages": "link"}`,
+			expectedInitError: `invalid embedImages "link", must be one of "inline", "attach" or "none"`,
+		},
+		{
+			name:     "Short messages have a maximum length by default",
+			settings: `{"addresses": "test@grafana.com", "profile": "shortMessage"}`,
+			expectedConfig: Config{
+				Addresses:   []string{"test@grafana.com"},
+				Subject:     templates.DefaultMessageTitleEmbed,
+				EmbedImages: EmbedImagesInline,
+				Profile:     ProfileShortMessage,
+				MaxLength:   DefaultShortMessageMaxLength,
+			},
+		},
+		{
+			name:              "Error if profile is invalid",
+			settings:          `{"addresses": "test@grafana.com", "profile": "sms"}`,
+			expectedInitError: `invalid profile "sms", must be one of "default" or "shortMessage"`,
+		},
+		{
+			name:              "Error if maxLength is negative",
+			settings:          `{"addresses": "test@grafana.com", "profile": "shortMessage", "maxLength": -1}`,
+			expectedInitError: `maxLength must not be negative`,
+		},
 	}
 
 	for _, c := range cases {
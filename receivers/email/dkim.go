@@ -0,0 +1,225 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultDKIMHeaders are the headers signed by DKIM if none are configured.
+var DefaultDKIMHeaders = []string{"From", "To", "Subject", "Date", "Reply-To", "MIME-Version", "Content-Type"}
+
+// DKIMConfig configures the DKIM signatures of the emails (RFC 6376).
+type DKIMConfig struct {
+	// Domain is the signing domain, whose DNS zone publishes the public key.
+	Domain string
+	// Selector is the selector of the public key in the DNS zone of the domain.
+	Selector string
+	// PrivateKeyFile is the path of the PEM encoded RSA or Ed25519 private key, in PKCS #1 or PKCS #8.
+	PrivateKeyFile string
+	// Headers are the headers to sign. Headers that are not in an email are not signed.
+	Headers []string
+}
+
+// dkimSigner signs emails with relaxed canonicalization of the headers and the body.
+type dkimSigner struct {
+	domain    string
+	selector  string
+	headers   []string
+	key       crypto.Signer
+	algorithm string
+	now       func() time.Time
+}
+
+func newDKIMSigner(cfg DKIMConfig) (*dkimSigner, error) {
+	if cfg.Domain == "" {
+		return nil, errors.New("domain must be present")
+	}
+	if cfg.Selector == "" {
+		return nil, errors.New("selector must be present")
+	}
+	b, err := os.ReadFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read private key file: %w", err)
+	}
+	key, err := parseDKIMKey(b)
+	if err != nil {
+		return nil, err
+	}
+	s := &dkimSigner{
+		domain:   cfg.Domain,
+		selector: cfg.Selector,
+		headers:  cfg.Headers,
+		key:      key,
+		now:      time.Now,
+	}
+	if len(s.headers) == 0 {
+		s.headers = DefaultDKIMHeaders
+	}
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		s.algorithm = "rsa-sha256"
+	case ed25519.PrivateKey:
+		s.algorithm = "ed25519-sha256"
+	}
+	return s, nil
+}
+
+func parseDKIMKey(b []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse private key: %w", err)
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// sign returns the message with a DKIM-Signature header.
+func (s *dkimSigner) sign(msg io.WriterTo) (io.WriterTo, error) {
+	var buf bytes.Buffer
+	if _, err := msg.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	header, body, _ := bytes.Cut(buf.Bytes(), []byte("\r\n\r\n"))
+	fields := parseHeader(string(header))
+
+	bodyHash := sha256.Sum256(relaxedBody(body))
+	// The last instances of the headers are signed first (RFC 6376, section 5.4.2).
+	signed := make(map[string]int)
+	h := sha256.New()
+	var names []string
+	for _, name := range s.headers {
+		key := strings.ToLower(name)
+		n := 0
+		for i := len(fields) - 1; i >= 0; i-- {
+			if strings.ToLower(fields[i].name) != key {
+				continue
+			}
+			if n == signed[key] {
+				_, _ = io.WriteString(h, relaxedHeader(fields[i].name, fields[i].value))
+				names = append(names, key)
+				signed[key]++
+				break
+			}
+			n++
+		}
+	}
+	if signed["from"] == 0 {
+		return nil, errors.New("email has no From header")
+	}
+
+	value := fmt.Sprintf("v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s; b=",
+		s.algorithm, s.domain, s.selector, s.now().Unix(), strings.Join(names, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]))
+	// The signature header is hashed without its signature and trailing CRLF.
+	_, _ = io.WriteString(h, strings.TrimSuffix(relaxedHeader("DKIM-Signature", value), "\r\n"))
+
+	var sig []byte
+	var err error
+	switch s.algorithm {
+	case "ed25519-sha256":
+		// Ed25519 signs the hash of the header rather than the header itself (RFC 8463).
+		sig, err = s.key.Sign(rand.Reader, h.Sum(nil), crypto.Hash(0))
+	default:
+		sig, err = s.key.Sign(rand.Reader, h.Sum(nil), crypto.SHA256)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.Grow(buf.Len() + len(value) + 512)
+	out.WriteString("DKIM-Signature: " + value + base64.StdEncoding.EncodeToString(sig) + "\r\n")
+	out.Write(buf.Bytes())
+	return &out, nil
+}
+
+type headerField struct {
+	name  string
+	value string
+}
+
+// parseHeader returns the fields of the header, with the continuation lines of the folded fields.
+func parseHeader(header string) []headerField {
+	var fields []headerField
+	for _, line := range strings.Split(header, "\r\n") {
+		if len(fields) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			fields[len(fields)-1].value += "\r\n" + line
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields = append(fields, headerField{name: name, value: value})
+	}
+	return fields
+}
+
+// relaxedHeader returns the header field in relaxed canonicalization (RFC 6376, section 3.4.2).
+func relaxedHeader(name, value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "")
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.TrimSpace(compactWhitespace(value)) + "\r\n"
+}
+
+// relaxedBody returns the body in relaxed canonicalization (RFC 6376, section 3.4.4).
+func relaxedBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(compactWhitespace(line), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// compactWhitespace replaces each sequence of spaces and tabs with a single space.
+func compactWhitespace(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
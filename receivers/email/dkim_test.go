@@ -0,0 +1,144 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelaxedCanonicalization(t *testing.T) {
+	// The example of RFC 6376, section 3.4.5.
+	fields := parseHeader("A: X\r\nB : Y\t\r\n\tZ  ")
+	require.Len(t, fields, 2)
+	require.Equal(t, "a:X\r\n", relaxedHeader(fields[0].name, fields[0].value))
+	require.Equal(t, "b:Y Z\r\n", relaxedHeader(fields[1].name, fields[1].value))
+	require.Equal(t, " C\r\nD E\r\n", string(relaxedBody([]byte(" C \r\nD \t E\r\n\r\n\r\n"))))
+	require.Empty(t, relaxedBody([]byte("\r\n\r\n")))
+}
+
+func TestDKIMSigner_Sign(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	msg := "From: Grafana <alerts@example.com>\r\n" +
+		"To: ops@example.com\r\n" +
+		"Subject: [FIRING:1]  alert1\r\n" +
+		"Received: x\r\n" +
+		"\r\n" +
+		"Body  line \r\n\r\n"
+
+	tests := []struct {
+		name   string
+		pem    *pem.Block
+		public crypto.PublicKey
+	}{{
+		name:   "RSA",
+		pem:    &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)},
+		public: &rsaKey.PublicKey,
+	}, {
+		name:   "Ed25519",
+		pem:    &pem.Block{Type: "PRIVATE KEY", Bytes: edDER},
+		public: edKey.Public(),
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "key.pem")
+			require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(test.pem), 0600))
+			s, err := newDKIMSigner(DKIMConfig{Domain: "example.com", Selector: "grafana", PrivateKeyFile: path})
+			require.NoError(t, err)
+			s.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+			signed, err := s.sign(bytes.NewBufferString(msg))
+			require.NoError(t, err)
+			var buf bytes.Buffer
+			_, err = signed.WriteTo(&buf)
+			require.NoError(t, err)
+
+			tags := requireDKIMSignature(t, buf.String(), test.public)
+			require.Equal(t, "example.com", tags["d"])
+			require.Equal(t, "grafana", tags["s"])
+			require.Equal(t, "1700000000", tags["t"])
+			require.Equal(t, "from:to:subject", tags["h"])
+		})
+	}
+
+	t.Run("should fail without From header", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "key.pem")
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(tests[0].pem), 0600))
+		s, err := newDKIMSigner(DKIMConfig{Domain: "example.com", Selector: "grafana", PrivateKeyFile: path})
+		require.NoError(t, err)
+		_, err = s.sign(bytes.NewBufferString("To: ops@example.com\r\n\r\nBody\r\n"))
+		require.EqualError(t, err, "email has no From header")
+	})
+}
+
+func TestNewDKIMSigner(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a key"), 0600))
+
+	_, err := newDKIMSigner(DKIMConfig{Selector: "grafana", PrivateKeyFile: path})
+	require.EqualError(t, err, "domain must be present")
+	_, err = newDKIMSigner(DKIMConfig{Domain: "example.com", PrivateKeyFile: path})
+	require.EqualError(t, err, "selector must be present")
+	_, err = newDKIMSigner(DKIMConfig{Domain: "example.com", Selector: "grafana", PrivateKeyFile: path})
+	require.EqualError(t, err, "private key is not PEM encoded")
+}
+
+// requireDKIMSignature verifies the DKIM-Signature header of the email with the public key, and returns its tags.
+func requireDKIMSignature(t *testing.T, email string, public crypto.PublicKey) map[string]string {
+	t.Helper()
+	header, body, ok := strings.Cut(email, "\r\n\r\n")
+	require.True(t, ok)
+	fields := parseHeader(header)
+	require.NotEmpty(t, fields)
+	require.Equal(t, "DKIM-Signature", fields[0].name)
+
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(fields[0].value, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(tag), "=")
+		tags[k] = v
+	}
+	bodyHash := sha256.Sum256(relaxedBody([]byte(body)))
+	require.Equal(t, base64.StdEncoding.EncodeToString(bodyHash[:]), tags["bh"])
+
+	h := sha256.New()
+	for _, name := range strings.Split(tags["h"], ":") {
+		for i := len(fields) - 1; i > 0; i-- {
+			if strings.EqualFold(fields[i].name, name) {
+				h.Write([]byte(relaxedHeader(fields[i].name, fields[i].value)))
+				break
+			}
+		}
+	}
+	unsigned := fields[0].value[:strings.LastIndex(fields[0].value, "b=")+2]
+	h.Write([]byte(strings.TrimSuffix(relaxedHeader(fields[0].name, unsigned), "\r\n")))
+
+	sig, err := base64.StdEncoding.DecodeString(tags["b"])
+	require.NoError(t, err)
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		require.Equal(t, "rsa-sha256", tags["a"])
+		require.NoError(t, rsa.VerifyPKCS1v15(k, crypto.SHA256, h.Sum(nil), sig))
+	case ed25519.PublicKey:
+		require.Equal(t, "ed25519-sha256", tags["a"])
+		require.True(t, ed25519.Verify(k, h.Sum(nil), sig))
+	}
+	return tags
+}
@@ -6,8 +6,10 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/alerting/images"
 	"github.com/grafana/alerting/logging"
@@ -39,10 +41,18 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify sends the alert notification.
 func (en *Notifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, en.log)
 	var tmplErr error
-	tmpl, data := templates.TmplText(ctx, en.tmpl, alerts, en.log, &tmplErr)
+	tmpl, data := templates.TmplText(ctx, en.tmpl, alerts, l, &tmplErr)
 
 	subject := tmpl(en.settings.Subject)
+	if en.settings.Profile == ProfileShortMessage {
+		if tmplErr != nil {
+			l.Warn("failed to template email message", "error", tmplErr.Error())
+		}
+		return en.sendShortMessage(ctx, subject)
+	}
+
 	alertPageURL := en.tmpl.ExternalURL.String()
 	ruleURL := en.tmpl.ExternalURL.String()
 	u, err := url.Parse(en.tmpl.ExternalURL.String())
@@ -53,32 +63,71 @@ func (en *Notifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, e
 		u.RawQuery = "alertState=firing&view=state"
 		alertPageURL = u.String()
 	} else {
-		en.log.Debug("failed to parse external URL", "url", en.tmpl.ExternalURL.String(), "error", err.Error())
+		l.Debug("failed to parse external URL", "url", en.tmpl.ExternalURL.String(), "error", err.Error())
 	}
 
-	// Extend alerts data with images, if available.
-	var embeddedFiles []string
-	_ = images.WithStoredImages(ctx, en.log, en.images,
+	// Extend alerts data with images, if available. Images stored on disk are added to the email
+	// unless disabled, otherwise images are linked by URL.
+	var candidates []embeddedImage
+	_ = images.WithStoredImages(ctx, l, en.images,
 		func(index int, image images.Image) error {
-			if len(image.URL) != 0 {
-				data.Alerts[index].ImageURL = image.URL
-			} else if len(image.Path) != 0 {
-				_, err := os.Stat(image.Path)
+			if len(image.Path) != 0 && en.settings.EmbedImages != EmbedImagesNone {
+				fi, err := os.Stat(image.Path)
 				if err == nil {
-					data.Alerts[index].EmbeddedImage = filepath.Base(image.Path)
-					embeddedFiles = append(embeddedFiles, image.Path)
-				} else {
-					en.log.Warn("failed to get image file for email attachment", "file", image.Path, "error", err)
+					candidates = append(candidates, embeddedImage{
+						index:  index,
+						path:   image.Path,
+						url:    image.URL,
+						size:   fi.Size(),
+						firing: alerts[index].Status() == model.AlertFiring,
+					})
+					return nil
 				}
+				l.Warn("failed to get image file for email attachment", "file", image.Path, "error", err)
+			}
+			if len(image.URL) != 0 {
+				data.Alerts[index].ImageURL = image.URL
 			}
 			return nil
 		}, alerts...)
 
+	selected, omitted := selectEmbeddedImages(candidates, en.settings.MaxAttachmentSize, en.settings.MaxEmbeddedImages)
+	var files []string
+	for _, img := range selected {
+		if en.settings.EmbedImages == EmbedImagesInline {
+			data.Alerts[img.index].EmbeddedImage = filepath.Base(img.path)
+		}
+		files = append(files, img.path)
+	}
+	// Images that do not fit within the limits are linked instead, if possible.
+	dropped := 0
+	for _, img := range omitted {
+		if len(img.url) != 0 {
+			data.Alerts[img.index].ImageURL = img.url
+		} else {
+			dropped++
+		}
+	}
+	var embeddedFiles, attachedFiles []string
+	if en.settings.EmbedImages == EmbedImagesAttach {
+		attachedFiles = files
+	} else {
+		embeddedFiles = files
+	}
+
+	message := tmpl(en.settings.Message)
+	if len(omitted) > 0 {
+		l.Warn("omitted images from email because the attachment limits were exceeded", "omitted", len(omitted), "dropped", dropped, "included", len(selected))
+	}
+	if dropped > 0 {
+		message = appendOmittedImagesNote(message, dropped)
+	}
+
 	cmd := &receivers.SendEmailSettings{
 		Subject: subject,
 		Data: map[string]interface{}{
 			"Title":             subject,
-			"Message":           tmpl(en.settings.Message),
+			"Message":           message,
 			"Status":            data.Status,
 			"Alerts":            data.Alerts,
 			"GroupLabels":       data.GroupLabels,
@@ -89,22 +138,52 @@ func (en *Notifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, e
 			"AlertPageUrl":      alertPageURL,
 		},
 		EmbeddedFiles: embeddedFiles,
+		AttachedFiles: attachedFiles,
 		To:            en.settings.Addresses,
 		SingleEmail:   en.settings.SingleEmail,
 		Template:      "ng_alert_notification",
 	}
 
 	if tmplErr != nil {
-		en.log.Warn("failed to template email message", "error", tmplErr.Error())
+		l.Warn("failed to template email message", "error", tmplErr.Error())
 	}
 
 	if err := en.ns.SendEmail(ctx, cmd); err != nil {
-		return false, err
+		return IsTemporary(err), err
 	}
 
 	return true, nil
 }
 
+// sendShortMessage sends the subject as a plain text email without body. The subject is reduced to a single line
+// and truncated to the maximum length, as email-to-SMS gateways split or drop longer messages.
+func (en *Notifier) sendShortMessage(ctx context.Context, subject string) (bool, error) {
+	cmd := &receivers.SendEmailSettings{
+		Subject:      shortMessage(subject, en.settings.MaxLength),
+		To:           en.settings.Addresses,
+		SingleEmail:  en.settings.SingleEmail,
+		Template:     "ng_alert_short_message",
+		ContentTypes: []string{"text/plain"},
+	}
+	if err := en.ns.SendEmail(ctx, cmd); err != nil {
+		return IsTemporary(err), err
+	}
+	return true, nil
+}
+
+// shortMessage returns s on a single line, truncated to n characters. Truncated messages end with "..." rather than
+// an ellipsis, which not all carriers can encode.
+func shortMessage(s string, n int) string {
+	r := []rune(strings.Join(strings.Fields(s), " "))
+	if len(r) <= n {
+		return string(r)
+	}
+	if n <= 3 {
+		return string(r[:n])
+	}
+	return string(r[:n-3]) + "..."
+}
+
 func (en *Notifier) SendResolved() bool {
 	return !en.GetDisableResolveMessage()
 }
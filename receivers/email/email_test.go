@@ -2,7 +2,10 @@ package email
 
 import (
 	"context"
+	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/prometheus/alertmanager/types"
@@ -11,6 +14,7 @@ import (
 
 	"github.com/grafana/alerting/images"
 	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/models"
 	"github.com/grafana/alerting/receivers"
 	"github.com/grafana/alerting/templates"
 )
@@ -98,4 +102,147 @@ func TestNotify(t *testing.T) {
 			},
 		}, expected)
 	})
+
+	t.Run("images over the attachment limits are omitted with a note", func(t *testing.T) {
+		dir := t.TempDir()
+		provider := &images.FakeProvider{}
+		alerts := make([]*types.Alert, 0, 3)
+		for i, size := range []int{10, 20, 30} {
+			file := filepath.Join(dir, fmt.Sprintf("image-%d.png", i))
+			require.NoError(t, os.WriteFile(file, make([]byte, size), 0o600))
+			token := fmt.Sprintf("test-image-%d", i)
+			provider.Images = append(provider.Images, &images.Image{Token: token, Path: file})
+			alerts = append(alerts, &types.Alert{
+				Alert: model.Alert{
+					Labels:      model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("alert-%d", i))},
+					Annotations: model.LabelSet{models.ImageTokenAnnotation: model.LabelValue(token)},
+				},
+			})
+		}
+
+		emailSender := receivers.MockNotificationService()
+		emailNotifier := &Notifier{
+			Base:   &receivers.Base{},
+			log:    &logging.FakeLogger{},
+			ns:     emailSender,
+			tmpl:   tmpl,
+			images: provider,
+			settings: Config{
+				Addresses:         []string{"someops@example.com"},
+				Message:           "message",
+				Subject:           templates.DefaultMessageTitleEmbed,
+				MaxAttachmentSize: 40,
+				MaxEmbeddedImages: 2,
+				EmbedImages:       EmbedImagesInline,
+			},
+		}
+
+		ok, err := emailNotifier.Notify(context.Background(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Equal(t, []string{provider.Images[0].Path, provider.Images[1].Path}, emailSender.EmailSync.EmbeddedFiles)
+		require.Equal(t, "message\n\n1 image(s) were not included in this email because it would exceed the attachment limits. Open the alert in Grafana to view them.", emailSender.EmailSync.Data["Message"])
+		extendedAlerts := emailSender.EmailSync.Data["Alerts"].(templates.ExtendedAlerts)
+		require.Equal(t, "image-0.png", extendedAlerts[0].EmbeddedImage)
+		require.Equal(t, "image-1.png", extendedAlerts[1].EmbeddedImage)
+		require.Empty(t, extendedAlerts[2].EmbeddedImage)
+	})
+
+	t.Run("images are added according to the embed images mode", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "image.png")
+		require.NoError(t, os.WriteFile(file, make([]byte, 10), 0o600))
+		provider := &images.FakeProvider{
+			Images: []*images.Image{{Token: "test-image", Path: file, URL: "https://www.example.com/image.png"}},
+		}
+		alert := &types.Alert{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert"},
+				Annotations: model.LabelSet{models.ImageTokenAnnotation: "test-image"},
+			},
+		}
+
+		cases := []struct {
+			mode              EmbedImagesMode
+			maxAttachmentSize int64
+			expEmbeddedFiles  []string
+			expAttachedFiles  []string
+			expEmbeddedImage  string
+			expImageURL       string
+		}{
+			{mode: EmbedImagesInline, expEmbeddedFiles: []string{file}, expEmbeddedImage: "image.png"},
+			{mode: EmbedImagesAttach, expAttachedFiles: []string{file}},
+			{mode: EmbedImagesNone, expImageURL: "https://www.example.com/image.png"},
+			{mode: EmbedImagesInline, maxAttachmentSize: 1, expImageURL: "https://www.example.com/image.png"},
+		}
+
+		for _, c := range cases {
+			emailSender := receivers.MockNotificationService()
+			emailNotifier := &Notifier{
+				Base:   &receivers.Base{},
+				log:    &logging.FakeLogger{},
+				ns:     emailSender,
+				tmpl:   tmpl,
+				images: provider,
+				settings: Config{
+					Addresses:         []string{"someops@example.com"},
+					Subject:           templates.DefaultMessageTitleEmbed,
+					EmbedImages:       c.mode,
+					MaxAttachmentSize: c.maxAttachmentSize,
+				},
+			}
+
+			ok, err := emailNotifier.Notify(context.Background(), alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expEmbeddedFiles, emailSender.EmailSync.EmbeddedFiles)
+			require.Equal(t, c.expAttachedFiles, emailSender.EmailSync.AttachedFiles)
+			extendedAlerts := emailSender.EmailSync.Data["Alerts"].(templates.ExtendedAlerts)
+			require.Equal(t, c.expEmbeddedImage, extendedAlerts[0].EmbeddedImage)
+			require.Equal(t, c.expImageURL, extendedAlerts[0].ImageURL)
+			// The image is linked when it exceeds the limits, so there is nothing to note.
+			require.Empty(t, emailSender.EmailSync.Data["Message"])
+		}
+	})
+	t.Run("short messages are a single line of plain text", func(t *testing.T) {
+		emailSender := receivers.MockNotificationService()
+		emailNotifier := &Notifier{
+			Base:   &receivers.Base{},
+			log:    &logging.FakeLogger{},
+			ns:     emailSender,
+			tmpl:   tmpl,
+			images: &images.UnavailableProvider{},
+			settings: Config{
+				Addresses: []string{"5551234567@sms.example.com"},
+				Subject:   "[{{ .Status | toUpper }}]\n{{ .CommonLabels.alertname }}:   {{ .CommonAnnotations.summary }}",
+				Profile:   ProfileShortMessage,
+				MaxLength: 30,
+			},
+		}
+		alert := &types.Alert{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "HighLatency"},
+				Annotations: model.LabelSet{"summary": "p99 latency is above 2s"},
+			},
+		}
+
+		ok, err := emailNotifier.Notify(context.Background(), alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Equal(t, receivers.SendEmailSettings{
+			To:           []string{"5551234567@sms.example.com"},
+			Subject:      "[FIRING] HighLatency: p99 l...",
+			Template:     "ng_alert_short_message",
+			ContentTypes: []string{"text/plain"},
+		}, emailSender.EmailSync)
+	})
+}
+
+func TestShortMessage(t *testing.T) {
+	require.Equal(t, "a b c", shortMessage(" a\n b\t\tc ", 5))
+	require.Equal(t, "abcd...", shortMessage("abcdefgh", 7))
+	require.Equal(t, "ab", shortMessage("abcdefgh", 2))
+	require.Equal(t, "äöü...", shortMessage("äöüäöüä", 6))
 }
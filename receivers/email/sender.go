@@ -0,0 +1,212 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"sync"
+	"time"
+
+	gomail "gopkg.in/mail.v2"
+
+	"github.com/grafana/alerting/receivers"
+)
+
+const (
+	// DefaultMaxIdleConnections is the maximum number of idle SMTP connections if none is configured.
+	DefaultMaxIdleConnections = 2
+	// DefaultIdleTimeout is the time after which idle SMTP connections are closed if none is configured.
+	DefaultIdleTimeout = 30 * time.Second
+	// DefaultTimeout is the timeout of each email if none is configured.
+	DefaultTimeout = 10 * time.Second
+)
+
+// SenderConfig configures the senders of NewSenderFactory.
+type SenderConfig struct {
+	receivers.EmailSenderConfig
+	// ImplicitTLS connects to the SMTP server over TLS rather than upgrading the connection with STARTTLS. The
+	// connections to port 465 always use implicit TLS.
+	ImplicitTLS bool
+	// MaxIdleConnections is the maximum number of idle connections kept open to send the next emails.
+	MaxIdleConnections int
+	// IdleTimeout is the time after which idle connections are closed.
+	IdleTimeout time.Duration
+	// Timeout is the timeout of each email, including dialing the SMTP server if no connection is idle.
+	Timeout time.Duration
+	// DKIM signs the emails, if set.
+	DKIM *DKIMConfig
+}
+
+// NewSenderFactory returns an EmailSender factory function whose senders share a pool of connections to the SMTP
+// server of the configuration. The errors of the senders tell whether the emails should be sent again, see
+// IsTemporary.
+func NewSenderFactory(cfg SenderConfig) (func(receivers.Metadata) (receivers.EmailSender, error), error) {
+	p, err := newSMTPPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return receivers.NewEmailSenderFactoryWithTransport(cfg.EmailSenderConfig, p), nil
+}
+
+// SendError is the error of an email that could not be sent by the senders of NewSenderFactory.
+type SendError struct {
+	Err error
+	// Temporary is true if sending the email again may succeed, such as after transient SMTP replies (4xx) and
+	// network errors, and false after permanent SMTP replies (5xx).
+	Temporary bool
+}
+
+func (e *SendError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+// IsTemporary returns whether err is a temporary error of a sender of NewSenderFactory, after which the email
+// should be sent again.
+func IsTemporary(err error) bool {
+	var sendErr *SendError
+	return errors.As(err, &sendErr) && sendErr.Temporary
+}
+
+// classify returns the SendError of err.
+func classify(err error) error {
+	var (
+		protoErr *textproto.Error
+		netErr   net.Error
+	)
+	switch {
+	case errors.As(err, &protoErr):
+		return &SendError{Err: err, Temporary: protoErr.Code >= 400 && protoErr.Code < 500}
+	case errors.As(err, &netErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return &SendError{Err: err, Temporary: true}
+	default:
+		return &SendError{Err: err}
+	}
+}
+
+// smtpPool is an EmailTransport that sends the emails over a pool of connections to an SMTP server.
+type smtpPool struct {
+	dialer      *gomail.Dialer
+	dkim        *dkimSigner
+	maxIdle     int
+	idleTimeout time.Duration
+
+	// dialMtx serializes dialing, as the dialer sets its authentication mechanism on the first connection.
+	dialMtx sync.Mutex
+
+	mtx  sync.Mutex
+	idle []*idleConn
+}
+
+// idleConn is an idle connection of the pool, closed by its timer after the idle timeout.
+type idleConn struct {
+	gomail.SendCloser
+	timer *time.Timer
+}
+
+func newSMTPPool(cfg SenderConfig) (*smtpPool, error) {
+	d, err := receivers.NewSMTPDialer(cfg.EmailSenderConfig)
+	if err != nil {
+		return nil, err
+	}
+	d.SSL = d.SSL || cfg.ImplicitTLS
+	d.Timeout = cfg.Timeout
+	if d.Timeout <= 0 {
+		d.Timeout = DefaultTimeout
+	}
+
+	p := &smtpPool{
+		dialer:      d,
+		maxIdle:     cfg.MaxIdleConnections,
+		idleTimeout: cfg.IdleTimeout,
+	}
+	if p.maxIdle <= 0 {
+		p.maxIdle = DefaultMaxIdleConnections
+	}
+	if p.idleTimeout <= 0 {
+		p.idleTimeout = DefaultIdleTimeout
+	}
+	if cfg.DKIM != nil {
+		if p.dkim, err = newDKIMSigner(*cfg.DKIM); err != nil {
+			return nil, fmt.Errorf("invalid DKIM configuration: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// Send implements the receivers.EmailTransport interface. The connection is closed if the email cannot be sent.
+func (p *smtpPool) Send(ctx context.Context, from string, to []string, msg io.WriterTo) error {
+	if err := ctx.Err(); err != nil {
+		return classify(err)
+	}
+	if p.dkim != nil {
+		signed, err := p.dkim.sign(msg)
+		if err != nil {
+			return &SendError{Err: fmt.Errorf("failed to sign email: %w", err)}
+		}
+		msg = signed
+	}
+
+	c, err := p.get()
+	if err != nil {
+		return classify(err)
+	}
+	if err := c.Send(from, to, msg); err != nil {
+		_ = c.Close()
+		return classify(err)
+	}
+	p.put(c)
+	return nil
+}
+
+// get returns an idle connection, or a new connection if none is idle.
+func (p *smtpPool) get() (gomail.SendCloser, error) {
+	p.mtx.Lock()
+	if n := len(p.idle); n > 0 {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		c.timer.Stop()
+		p.mtx.Unlock()
+		return c.SendCloser, nil
+	}
+	p.mtx.Unlock()
+
+	p.dialMtx.Lock()
+	defer p.dialMtx.Unlock()
+	return p.dialer.Dial()
+}
+
+// put returns the connection to the pool, or closes it if the pool is full.
+func (p *smtpPool) put(sc gomail.SendCloser) {
+	p.mtx.Lock()
+	if len(p.idle) >= p.maxIdle {
+		p.mtx.Unlock()
+		_ = sc.Close()
+		return
+	}
+	c := &idleConn{SendCloser: sc}
+	c.timer = time.AfterFunc(p.idleTimeout, func() { p.expire(c) })
+	p.idle = append(p.idle, c)
+	p.mtx.Unlock()
+}
+
+// expire closes the connection if it is still idle.
+func (p *smtpPool) expire(c *idleConn) {
+	p.mtx.Lock()
+	for i, ic := range p.idle {
+		if ic == c {
+			p.idle = append(p.idle[:i], p.idle[i+1:]...)
+			p.mtx.Unlock()
+			_ = c.Close()
+			return
+		}
+	}
+	p.mtx.Unlock()
+}
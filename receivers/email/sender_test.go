@@ -0,0 +1,212 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/receivers"
+)
+
+func TestSender(t *testing.T) {
+	cmd := func(to ...string) *receivers.SendEmailSettings {
+		return &receivers.SendEmailSettings{
+			To:       to,
+			Subject:  "[FIRING:1] alert1",
+			Template: "ng_alert_short_message",
+		}
+	}
+	cfg := func(s *smtpServer) SenderConfig {
+		return SenderConfig{EmailSenderConfig: receivers.EmailSenderConfig{
+			Host:         s.addr,
+			FromAddress:  "alerts@example.com",
+			ContentTypes: []string{"text/plain"},
+		}}
+	}
+
+	t.Run("should reuse connections", func(t *testing.T) {
+		s := newSMTPServer(t, nil)
+		f, err := NewSenderFactory(cfg(s))
+		require.NoError(t, err)
+		sender, err := f(receivers.Metadata{})
+		require.NoError(t, err)
+
+		require.NoError(t, sender.SendEmail(context.Background(), cmd("ops@example.com")))
+		require.NoError(t, sender.SendEmail(context.Background(), cmd("dev@example.com")))
+
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		require.Equal(t, 1, s.connections)
+		require.Len(t, s.emails, 2)
+		require.Contains(t, s.emails[0], "To: ops@example.com")
+		require.Contains(t, s.emails[1], "To: dev@example.com")
+	})
+
+	t.Run("should classify SMTP replies", func(t *testing.T) {
+		s := newSMTPServer(t, map[string]string{
+			"<busy@example.com>":    "450 mailbox busy",
+			"<unknown@example.com>": "550 no such user",
+		})
+		f, err := NewSenderFactory(cfg(s))
+		require.NoError(t, err)
+		sender, err := f(receivers.Metadata{})
+		require.NoError(t, err)
+
+		err = sender.SendEmail(context.Background(), cmd("busy@example.com"))
+		require.ErrorContains(t, err, `450 "mailbox busy"`)
+		require.True(t, IsTemporary(err))
+
+		err = sender.SendEmail(context.Background(), cmd("unknown@example.com"))
+		require.ErrorContains(t, err, `550 "no such user"`)
+		require.False(t, IsTemporary(err))
+
+		// The connections of failed emails are closed.
+		require.NoError(t, sender.SendEmail(context.Background(), cmd("ops@example.com")))
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		require.Equal(t, 3, s.connections)
+	})
+
+	t.Run("should classify network errors as temporary", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+		require.NoError(t, l.Close())
+
+		f, err := NewSenderFactory(SenderConfig{EmailSenderConfig: receivers.EmailSenderConfig{
+			Host:         addr,
+			FromAddress:  "alerts@example.com",
+			ContentTypes: []string{"text/plain"},
+		}})
+		require.NoError(t, err)
+		sender, err := f(receivers.Metadata{})
+		require.NoError(t, err)
+		err = sender.SendEmail(context.Background(), cmd("ops@example.com"))
+		require.Error(t, err)
+		require.True(t, IsTemporary(err))
+	})
+
+	t.Run("should sign emails", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "key.pem")
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+
+		s := newSMTPServer(t, nil)
+		c := cfg(s)
+		c.DKIM = &DKIMConfig{Domain: "example.com", Selector: "grafana", PrivateKeyFile: path}
+		f, err := NewSenderFactory(c)
+		require.NoError(t, err)
+		sender, err := f(receivers.Metadata{})
+		require.NoError(t, err)
+		require.NoError(t, sender.SendEmail(context.Background(), cmd("ops@example.com")))
+
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		require.Len(t, s.emails, 1)
+		tags := requireDKIMSignature(t, s.emails[0], &key.PublicKey)
+		require.Equal(t, "from:to:subject:date:reply-to:mime-version:content-type", tags["h"])
+	})
+
+	t.Run("should fail with invalid DKIM configuration", func(t *testing.T) {
+		_, err := NewSenderFactory(SenderConfig{
+			EmailSenderConfig: receivers.EmailSenderConfig{Host: "localhost:25"},
+			DKIM:              &DKIMConfig{Selector: "grafana"},
+		})
+		require.EqualError(t, err, "invalid DKIM configuration: domain must be present")
+	})
+}
+
+// smtpServer is an SMTP server that accepts the emails of all recipients, except for those with replies.
+type smtpServer struct {
+	addr    string
+	replies map[string]string
+
+	mtx         sync.Mutex
+	connections int
+	emails      []string
+}
+
+func newSMTPServer(t *testing.T, replies map[string]string) *smtpServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	s := &smtpServer{addr: l.Addr().String(), replies: replies}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mtx.Lock()
+			s.connections++
+			s.mtx.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = fmt.Fprintf(conn, "%s\r\n", line)
+	}
+	reply("220 localhost")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case "RCPT":
+			_, addr, _ := strings.Cut(arg, ":")
+			if r, ok := s.replies[addr]; ok {
+				reply(r)
+				continue
+			}
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			var email strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				email.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.mtx.Lock()
+			s.emails = append(s.emails, email.String())
+			s.mtx.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
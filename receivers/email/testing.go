@@ -5,5 +5,10 @@ const FullValidConfigForTesting = `{
 	"addresses": "test@grafana.com", 
 	"subject": "test-subject", 
 	"message": "test-message", 
-	"singleEmail": true
+	"singleEmail": true,
+	"maxAttachmentSize": 10485760,
+	"maxEmbeddedImages": 5,
+	"embedImages": "attach",
+	"profile": "shortMessage",
+	"maxLength": 140
 }`
@@ -0,0 +1,146 @@
+package receivers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EmailRoutingConfig configures multiple SMTP senders, and which of them send the emails of each recipient domain.
+type EmailRoutingConfig struct {
+	// Senders are the SMTP senders by name.
+	Senders map[string]EmailSenderConfig
+	// Domains are the names of the senders of the recipients of each domain, in order. The next sender is used if an
+	// email cannot be sent with the previous one. Domains match their subdomains, unless the subdomains have their
+	// own senders.
+	Domains map[string][]string
+	// Default are the names of the senders of the recipients of other domains, in order.
+	Default []string
+}
+
+func (c *EmailRoutingConfig) Validate() error {
+	if len(c.Default) == 0 {
+		return errors.New("default senders must be present")
+	}
+	if err := c.validateSenders(c.Default); err != nil {
+		return fmt.Errorf("invalid default senders: %w", err)
+	}
+	for domain, senders := range c.Domains {
+		if domain == "" || strings.Contains(domain, "@") {
+			return fmt.Errorf("invalid domain %q", domain)
+		}
+		if len(senders) == 0 {
+			return fmt.Errorf("senders of domain %q must be present", domain)
+		}
+		if err := c.validateSenders(senders); err != nil {
+			return fmt.Errorf("invalid senders of domain %q: %w", domain, err)
+		}
+	}
+	return nil
+}
+
+func (c *EmailRoutingConfig) validateSenders(names []string) error {
+	for _, name := range names {
+		if _, ok := c.Senders[name]; !ok {
+			return fmt.Errorf("sender %q does not exist", name)
+		}
+	}
+	return nil
+}
+
+// NewEmailRouterFactory returns an EmailSender factory function whose senders send the emails of each recipient with
+// the senders of its domain. The configuration must be valid.
+func NewEmailRouterFactory(cfg EmailRoutingConfig) func(Metadata) (EmailSender, error) {
+	factories := make(map[string]func(Metadata) (EmailSender, error), len(cfg.Senders))
+	for name, senderCfg := range cfg.Senders {
+		factories[name] = NewEmailSenderFactory(senderCfg)
+	}
+	domains := make(map[string][]string, len(cfg.Domains))
+	for domain, senders := range cfg.Domains {
+		domains[strings.ToLower(domain)] = senders
+	}
+	return func(meta Metadata) (EmailSender, error) {
+		senders := make(map[string]EmailSender, len(factories))
+		for name, f := range factories {
+			s, err := f(meta)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create sender %q: %w", name, err)
+			}
+			senders[name] = s
+		}
+		return &emailRouter{senders: senders, domains: domains, defaults: cfg.Default}, nil
+	}
+}
+
+// emailRouter sends emails with the senders of the domains of the recipients.
+type emailRouter struct {
+	senders  map[string]EmailSender
+	domains  map[string][]string
+	defaults []string
+}
+
+// SendEmail implements the EmailSender interface. The recipients are split by their senders, and each part is sent
+// as its own email.
+func (r *emailRouter) SendEmail(ctx context.Context, cmd *SendEmailSettings) error {
+	recipients := make(map[string][]string)
+	routes := make(map[string][]string)
+	for _, to := range cmd.To {
+		senders := r.sendersOf(to)
+		key := strings.Join(senders, ",")
+		recipients[key] = append(recipients[key], to)
+		routes[key] = senders
+	}
+	keys := make([]string, 0, len(routes))
+	for key := range routes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		c := *cmd
+		c.To = recipients[key]
+		if err := r.send(ctx, &c, routes[key]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send sends the email with the first sender that succeeds.
+func (r *emailRouter) send(ctx context.Context, cmd *SendEmailSettings, senders []string) error {
+	var errs []error
+	for _, name := range senders {
+		err := r.senders[name].SendEmail(ctx, cmd)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("sender %s: %w", name, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendersOf returns the senders of the domain of the address, or of its closest parent domain.
+func (r *emailRouter) sendersOf(address string) []string {
+	address = strings.TrimSuffix(strings.TrimSpace(address), ">")
+	i := strings.LastIndex(address, "@")
+	if i < 0 {
+		return r.defaults
+	}
+	domain := strings.ToLower(address[i+1:])
+	for {
+		if senders, ok := r.domains[domain]; ok {
+			return senders
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			return r.defaults
+		}
+		domain = parent
+	}
+}
@@ -0,0 +1,120 @@
+package receivers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEmailSender struct {
+	err  error
+	sent [][]string
+}
+
+func (s *fakeEmailSender) SendEmail(_ context.Context, cmd *SendEmailSettings) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, cmd.To)
+	return nil
+}
+
+func TestEmailRouter(t *testing.T) {
+	internal, ses, backup := &fakeEmailSender{}, &fakeEmailSender{}, &fakeEmailSender{}
+	r := &emailRouter{
+		senders: map[string]EmailSender{"internal": internal, "ses": ses, "backup": backup},
+		domains: map[string][]string{
+			"example.com":          {"internal"},
+			"external.example.com": {"ses", "backup"},
+		},
+		defaults: []string{"ses", "backup"},
+	}
+
+	require.Equal(t, []string{"internal"}, r.sendersOf("a@example.com"))
+	require.Equal(t, []string{"internal"}, r.sendersOf("Team A <a@Team.EXAMPLE.com>"))
+	require.Equal(t, []string{"ses", "backup"}, r.sendersOf("a@external.example.com"))
+	require.Equal(t, []string{"ses", "backup"}, r.sendersOf("a@example.org"))
+	require.Equal(t, []string{"ses", "backup"}, r.sendersOf("invalid"))
+
+	cmd := &SendEmailSettings{To: []string{"a@example.com", "b@example.org", "c@team.example.com"}, Subject: "test"}
+	require.NoError(t, r.SendEmail(context.Background(), cmd))
+	require.Equal(t, [][]string{{"a@example.com", "c@team.example.com"}}, internal.sent)
+	require.Equal(t, [][]string{{"b@example.org"}}, ses.sent)
+	require.Empty(t, backup.sent)
+	// The command is not modified.
+	require.Len(t, cmd.To, 3)
+
+	// The next sender is used if the email cannot be sent.
+	ses.err = errors.New("unavailable")
+	require.NoError(t, r.SendEmail(context.Background(), &SendEmailSettings{To: []string{"b@example.org"}}))
+	require.Equal(t, [][]string{{"b@example.org"}}, backup.sent)
+
+	backup.err = errors.New("rejected")
+	err := r.SendEmail(context.Background(), &SendEmailSettings{To: []string{"a@example.com", "b@example.org"}})
+	require.EqualError(t, err, "sender ses: unavailable\nsender backup: rejected")
+	require.Len(t, internal.sent, 2)
+}
+
+func TestEmailRoutingConfig_Validate(t *testing.T) {
+	senders := map[string]EmailSenderConfig{"internal": {}, "ses": {}}
+	cases := []struct {
+		name   string
+		cfg    EmailRoutingConfig
+		expErr string
+	}{
+		{
+			name: "valid",
+			cfg:  EmailRoutingConfig{Senders: senders, Domains: map[string][]string{"example.com": {"internal", "ses"}}, Default: []string{"ses"}},
+		},
+		{
+			name:   "no default senders",
+			cfg:    EmailRoutingConfig{Senders: senders},
+			expErr: "default senders must be present",
+		},
+		{
+			name:   "unknown default sender",
+			cfg:    EmailRoutingConfig{Senders: senders, Default: []string{"smtp"}},
+			expErr: `invalid default senders: sender "smtp" does not exist`,
+		},
+		{
+			name:   "invalid domain",
+			cfg:    EmailRoutingConfig{Senders: senders, Domains: map[string][]string{"a@example.com": {"internal"}}, Default: []string{"ses"}},
+			expErr: `invalid domain "a@example.com"`,
+		},
+		{
+			name:   "no domain senders",
+			cfg:    EmailRoutingConfig{Senders: senders, Domains: map[string][]string{"example.com": {}}, Default: []string{"ses"}},
+			expErr: `senders of domain "example.com" must be present`,
+		},
+		{
+			name:   "unknown domain sender",
+			cfg:    EmailRoutingConfig{Senders: senders, Domains: map[string][]string{"example.com": {"smtp"}}, Default: []string{"ses"}},
+			expErr: `invalid senders of domain "example.com": sender "smtp" does not exist`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.cfg.Validate()
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNewEmailRouterFactory(t *testing.T) {
+	s, err := NewEmailRouterFactory(EmailRoutingConfig{
+		Senders: map[string]EmailSenderConfig{"internal": {Host: "relay.internal:25"}, "ses": {Host: "email-smtp.amazonaws.com:587"}},
+		Domains: map[string][]string{"Example.com": {"internal"}},
+		Default: []string{"ses"},
+	})(Metadata{})
+	require.NoError(t, err)
+	r, ok := s.(*emailRouter)
+	require.True(t, ok)
+	require.Equal(t, "relay.internal:25", r.senders["internal"].(*defaultEmailSender).cfg.Host)
+	require.Equal(t, []string{"internal"}, r.sendersOf("a@example.com"))
+}
@@ -7,6 +7,7 @@ import (
 	"embed"
 	"fmt"
 	"html/template"
+	"io"
 	"net"
 	"net/mail"
 	"strconv"
@@ -39,10 +40,24 @@ type EmailSenderConfig struct {
 type defaultEmailSender struct {
 	cfg  EmailSenderConfig
 	tmpl *template.Template
+	// transport sends the emails, if set. Otherwise, the SMTP server is dialed for each email.
+	transport EmailTransport
+}
+
+// EmailTransport sends the emails rendered by an EmailSender.
+type EmailTransport interface {
+	// Send sends the message from the address to the recipients.
+	Send(ctx context.Context, from string, to []string, msg io.WriterTo) error
 }
 
 // NewEmailSenderFactory takes a configuration and returns a new EmailSender factory function.
 func NewEmailSenderFactory(cfg EmailSenderConfig) func(Metadata) (EmailSender, error) {
+	return NewEmailSenderFactoryWithTransport(cfg, nil)
+}
+
+// NewEmailSenderFactoryWithTransport returns an EmailSender factory function like NewEmailSenderFactory, whose
+// senders send the emails with the transport rather than dialing the SMTP server for each email.
+func NewEmailSenderFactoryWithTransport(cfg EmailSenderConfig, transport EmailTransport) func(Metadata) (EmailSender, error) {
 	return func(_ Metadata) (EmailSender, error) {
 		tmpl, err := template.New("templates").
 			Funcs(template.FuncMap{
@@ -54,8 +69,9 @@ func NewEmailSenderFactory(cfg EmailSenderConfig) func(Metadata) (EmailSender, e
 			return nil, err
 		}
 		return &defaultEmailSender{
-			cfg:  cfg,
-			tmpl: tmpl,
+			cfg:       cfg,
+			tmpl:      tmpl,
+			transport: transport,
 		}, nil
 	}
 }
@@ -67,21 +83,47 @@ type Message struct {
 	Subject       string
 	Body          map[string]string
 	EmbeddedFiles []string
+	AttachedFiles []string
 	ReplyTo       []string
 	SingleEmail   bool
+	// ContentTypes are the content types of the body, in descending order of preference.
+	ContentTypes []string
 }
 
 // SendEmail implements the EmailSender interface.
-func (s *defaultEmailSender) SendEmail(_ context.Context, cmd *SendEmailSettings) error {
+func (s *defaultEmailSender) SendEmail(ctx context.Context, cmd *SendEmailSettings) error {
 	message, err := s.buildEmailMessage(cmd)
 	if err != nil {
 		return err
 	}
 
+	if s.transport != nil {
+		return s.sendWithTransport(ctx, message)
+	}
 	_, err = s.Send(message)
 	return err
 }
 
+// sendWithTransport sends the message with the transport of the sender.
+func (s *defaultEmailSender) sendWithTransport(ctx context.Context, msg *Message) error {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid from address %q: %w", msg.From, err)
+	}
+	to := make([]string, 0, len(msg.To))
+	for _, addr := range msg.To {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+		to = append(to, a.Address)
+	}
+	if err := s.transport.Send(ctx, from.Address, to, s.buildEmail(msg)); err != nil {
+		return fmt.Errorf("failed to send notification to email addresses: %s: %w", strings.Join(msg.To, ";"), err)
+	}
+	return nil
+}
+
 func (s *defaultEmailSender) buildEmailMessage(cmd *SendEmailSettings) (*Message, error) {
 	data := cmd.Data
 	if data == nil {
@@ -90,8 +132,12 @@ func (s *defaultEmailSender) buildEmailMessage(cmd *SendEmailSettings) (*Message
 
 	s.setDefaultTemplateData(data)
 
+	contentTypes := s.cfg.ContentTypes
+	if len(cmd.ContentTypes) > 0 {
+		contentTypes = cmd.ContentTypes
+	}
 	body := make(map[string]string)
-	for _, contentType := range s.cfg.ContentTypes {
+	for _, contentType := range contentTypes {
 		fileExtension, err := getFileExtensionByContentType(contentType)
 		if err != nil {
 			return nil, err
@@ -122,8 +168,10 @@ func (s *defaultEmailSender) buildEmailMessage(cmd *SendEmailSettings) (*Message
 		Subject:       subject,
 		Body:          body,
 		EmbeddedFiles: cmd.EmbeddedFiles,
+		AttachedFiles: cmd.AttachedFiles,
 		ReplyTo:       cmd.ReplyTo,
 		SingleEmail:   cmd.SingleEmail,
+		ContentTypes:  contentTypes,
 	}, nil
 }
 
@@ -161,7 +209,12 @@ func (s *defaultEmailSender) Send(messages ...*Message) (int, error) {
 }
 
 func (s *defaultEmailSender) createDialer() (*gomail.Dialer, error) {
-	host, port, err := net.SplitHostPort(s.cfg.Host)
+	return NewSMTPDialer(s.cfg)
+}
+
+// NewSMTPDialer returns the dialer of the SMTP server of the configuration.
+func NewSMTPDialer(cfg EmailSenderConfig) (*gomail.Dialer, error) {
+	host, port, err := net.SplitHostPort(cfg.Host)
 	if err != nil {
 		return nil, err
 	}
@@ -171,22 +224,22 @@ func (s *defaultEmailSender) createDialer() (*gomail.Dialer, error) {
 	}
 
 	tlsconfig := &tls.Config{
-		InsecureSkipVerify: s.cfg.SkipVerify,
+		InsecureSkipVerify: cfg.SkipVerify,
 		ServerName:         host,
 	}
 
-	if s.cfg.CertFile != "" {
-		cert, err := tls.LoadX509KeyPair(s.cfg.CertFile, s.cfg.KeyFile)
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
 		if err != nil {
 			return nil, fmt.Errorf("could not load cert or key file: %w", err)
 		}
 		tlsconfig.Certificates = []tls.Certificate{cert}
 	}
 
-	d := gomail.NewDialer(host, iPort, s.cfg.AuthUser, s.cfg.AuthPassword)
-	d.TLSConfig = tlsconfig
-	d.StartTLSPolicy = getStartTLSPolicy(s.cfg.StartTLSPolicy)
-	d.LocalName = s.cfg.EhloIdentity
+	d := gomail.NewDialer(host, iPort, cfg.AuthUser, cfg.AuthPassword)
+	d.TLSConfig = RestrictTLSConfig(tlsconfig)
+	d.StartTLSPolicy = getStartTLSPolicy(cfg.StartTLSPolicy)
+	d.LocalName = cfg.EhloIdentity
 
 	return d, nil
 }
@@ -218,6 +271,11 @@ func (s *defaultEmailSender) buildEmail(msg *Message) *gomail.Message {
 		m.Embed(file)
 	}
 
+	// Add attached files.
+	for _, file := range msg.AttachedFiles {
+		m.Attach(file)
+	}
+
 	// Add reply-to addresses to the email message.
 	replyTo := make([]string, 0, len(msg.ReplyTo))
 	for _, address := range msg.ReplyTo {
@@ -225,13 +283,17 @@ func (s *defaultEmailSender) buildEmail(msg *Message) *gomail.Message {
 	}
 	m.SetHeader("Reply-To", strings.Join(replyTo, ", "))
 
-	// Loop over content types from settings in reverse order as they are ordered in according to descending
+	contentTypes := msg.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = s.cfg.ContentTypes
+	}
+	// Loop over content types in reverse order as they are ordered in according to descending
 	// preference while the alternatives should be ordered according to ascending preference.
-	for i := len(s.cfg.ContentTypes) - 1; i >= 0; i-- {
-		if i == len(s.cfg.ContentTypes)-1 {
-			m.SetBody(s.cfg.ContentTypes[i], msg.Body[s.cfg.ContentTypes[i]])
+	for i := len(contentTypes) - 1; i >= 0; i-- {
+		if i == len(contentTypes)-1 {
+			m.SetBody(contentTypes[i], msg.Body[contentTypes[i]])
 		} else {
-			m.AddAlternative(s.cfg.ContentTypes[i], msg.Body[s.cfg.ContentTypes[i]])
+			m.AddAlternative(contentTypes[i], msg.Body[contentTypes[i]])
 		}
 	}
 
@@ -21,6 +21,7 @@ func TestEmbedTemplate(t *testing.T) {
 	definedTmpls := ds.tmpl.DefinedTemplates()
 	require.Contains(t, definedTmpls, "\"ng_alert_notification.html\"")
 	require.Contains(t, definedTmpls, "\"ng_alert_notification.txt\"")
+	require.Contains(t, definedTmpls, "\"ng_alert_short_message.txt\"")
 }
 
 func TestBuildEmailMessage(t *testing.T) {
@@ -37,6 +38,7 @@ func TestBuildEmailMessage(t *testing.T) {
 		template      string
 		templateName  string
 		embeddedFiles []string
+		attachedFiles []string
 		expErr        string
 		expSubject    string
 		expBody       string
@@ -54,6 +56,7 @@ func TestBuildEmailMessage(t *testing.T) {
 			template:      fmt.Sprintf("{{ define %q -}} {{ Subject .Subject .TemplateData %q }} {{ .AppUrl }} {{ .SentBy }} {{- end }}", "test_template.txt", "{{ .Value }}"),
 			templateName:  "test_template",
 			embeddedFiles: []string{"embedded-1", "embedded-2"},
+			attachedFiles: []string{"attached-1"},
 			expSubject:    testValue,
 			expBody:       fmt.Sprintf("%s %s %s", testValue, externalURL, sentBy),
 		},
@@ -106,6 +109,7 @@ func TestBuildEmailMessage(t *testing.T) {
 				Data:          test.data,
 				ReplyTo:       []string{"test2@test.com"},
 				EmbeddedFiles: test.embeddedFiles,
+				AttachedFiles: test.attachedFiles,
 				Subject:       test.subject,
 			}
 			m, err := ds.buildEmailMessage(&cfg)
@@ -117,6 +121,7 @@ func TestBuildEmailMessage(t *testing.T) {
 				require.Equal(t, cfg.SingleEmail, m.SingleEmail)
 				require.Equal(t, cfg.ReplyTo, m.ReplyTo)
 				require.Equal(t, cfg.EmbeddedFiles, m.EmbeddedFiles)
+				require.Equal(t, cfg.AttachedFiles, m.AttachedFiles)
 				require.Equal(t, test.expSubject, m.Subject)
 
 				for _, ct := range test.contentTypes {
@@ -187,6 +192,30 @@ func TestCreateDialer(t *testing.T) {
 	}
 }
 
+func TestBuildEmailMessage_ContentTypes(t *testing.T) {
+	s, err := NewEmailSenderFactory(EmailSenderConfig{ContentTypes: []string{"text/html", "text/plain"}})(Metadata{})
+	require.NoError(t, err)
+	ds, ok := s.(*defaultEmailSender)
+	require.True(t, ok)
+
+	m, err := ds.buildEmailMessage(&SendEmailSettings{
+		To:           []string{"5551234567@sms.example.com"},
+		Template:     "ng_alert_short_message",
+		Subject:      "[FIRING] HighLatency",
+		ContentTypes: []string{"text/plain"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"text/plain"}, m.ContentTypes)
+	require.Equal(t, map[string]string{"text/plain": ""}, m.Body)
+
+	var buf bytes.Buffer
+	_, err = ds.buildEmail(m).WriteTo(&buf)
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "Content-Type: text/plain")
+	require.NotContains(t, buf.String(), "text/html")
+	require.NotContains(t, buf.String(), "multipart/alternative")
+}
+
 func TestBuildEmail(t *testing.T) {
 	cfg := EmailSenderConfig{
 		ContentTypes: []string{"text/html", "text/plain"},
@@ -0,0 +1,37 @@
+package receivers
+
+import "context"
+
+// FeatureFlags are the feature flags of a tenant. They are set by the embedding application, so that new behaviors
+// of receivers can be rolled out gradually without adding a parameter to the constructors of receivers for each flag.
+// Flags are scoped to receivers by their names, such as "slackBlockKit".
+type FeatureFlags interface {
+	IsEnabled(flag string) bool
+}
+
+// StaticFeatureFlags are feature flags that do not change, the flags in the map with a true value are enabled.
+type StaticFeatureFlags map[string]bool
+
+func (f StaticFeatureFlags) IsEnabled(flag string) bool {
+	return f[flag]
+}
+
+type featureFlagsKey struct{}
+
+// WithFeatureFlags returns a context with the feature flags used by receivers to send a notification.
+func WithFeatureFlags(ctx context.Context, f FeatureFlags) context.Context {
+	return context.WithValue(ctx, featureFlagsKey{}, f)
+}
+
+// FeatureFlagsFromContext returns the feature flags of the context, if it has any.
+func FeatureFlagsFromContext(ctx context.Context) (FeatureFlags, bool) {
+	f, ok := ctx.Value(featureFlagsKey{}).(FeatureFlags)
+	return f, ok
+}
+
+// IsFeatureEnabled returns true if the flag is enabled in the feature flags of the context. Flags are disabled if
+// the context has no feature flags.
+func IsFeatureEnabled(ctx context.Context, flag string) bool {
+	f, ok := FeatureFlagsFromContext(ctx)
+	return ok && f != nil && f.IsEnabled(flag)
+}
@@ -0,0 +1,18 @@
+package receivers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsFeatureEnabled(t *testing.T) {
+	require.False(t, IsFeatureEnabled(context.Background(), "flag"))
+	require.False(t, IsFeatureEnabled(WithFeatureFlags(context.Background(), nil), "flag"))
+
+	ctx := WithFeatureFlags(context.Background(), StaticFeatureFlags{"flag": true, "disabled": false})
+	require.True(t, IsFeatureEnabled(ctx, "flag"))
+	require.False(t, IsFeatureEnabled(ctx, "disabled"))
+	require.False(t, IsFeatureEnabled(ctx, "unknown"))
+}
@@ -0,0 +1,78 @@
+package feishu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+// DefaultEndpointURL is the endpoint of the open platform of Feishu. Lark uses https://open.larksuite.com.
+const DefaultEndpointURL = "https://open.feishu.cn"
+
+type MsgType string
+
+const (
+	MsgTypeInteractive MsgType = "interactive"
+	MsgTypeText        MsgType = "text"
+)
+
+// IsValid checks feishu message type
+func (mt MsgType) IsValid() bool {
+	return mt == MsgTypeInteractive || mt == MsgTypeText
+}
+
+type Config struct {
+	// URL is the webhook URL of the custom bot.
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// Secret signs the requests of the custom bot if signature verification is enabled.
+	Secret      string  `json:"secret,omitempty" yaml:"secret,omitempty"`
+	MessageType MsgType `json:"msgType,omitempty" yaml:"msgType,omitempty"`
+	Title       string  `json:"title,omitempty" yaml:"title,omitempty"`
+	Message     string  `json:"message,omitempty" yaml:"message,omitempty"`
+	// EndpointURL, AppID and AppSecret are the endpoint and the credentials of the app that uploads the images of
+	// the alerts. Images are not sent if AppID is empty.
+	EndpointURL string               `json:"endpointUrl,omitempty" yaml:"endpointUrl,omitempty"`
+	AppID       string               `json:"appId,omitempty" yaml:"appId,omitempty"`
+	AppSecret   string               `json:"appSecret,omitempty" yaml:"appSecret,omitempty"`
+	TLSConfig   *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
+}
+
+func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
+	var settings Config
+	err := json.Unmarshal(jsonData, &settings)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	settings.URL = decryptFn("url", settings.URL)
+	if settings.URL == "" {
+		return Config{}, errors.New("could not find url property in settings")
+	}
+	settings.Secret = decryptFn("secret", settings.Secret)
+	if settings.MessageType == "" {
+		settings.MessageType = MsgTypeInteractive
+	}
+	if !settings.MessageType.IsValid() {
+		return Config{}, fmt.Errorf("invalid message type %q, must be %q or %q", settings.MessageType, MsgTypeInteractive, MsgTypeText)
+	}
+	if settings.Title == "" {
+		settings.Title = templates.DefaultMessageTitleEmbed
+	}
+	if settings.Message == "" {
+		settings.Message = templates.DefaultMessageEmbed
+	}
+	if settings.EndpointURL == "" {
+		settings.EndpointURL = DefaultEndpointURL
+	}
+	settings.AppSecret = decryptFn("appSecret", settings.AppSecret)
+	if settings.AppID != "" && settings.AppSecret == "" {
+		return Config{}, errors.New("appSecret is required to upload images with appId")
+	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
+	return settings, nil
+}
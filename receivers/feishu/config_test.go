@@ -0,0 +1,121 @@
+package feishu
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/receivers"
+	receiversTesting "github.com/grafana/alerting/receivers/testing"
+	"github.com/grafana/alerting/templates"
+)
+
+func TestNewConfig(t *testing.T) {
+	cases := []struct {
+		name              string
+		settings          string
+		secrets           map[string][]byte
+		expectedConfig    Config
+		expectedInitError string
+	}{
+		{
+			name:              "Error if empty",
+			settings:          "",
+			expectedInitError: `failed to unmarshal settings`,
+		},
+		{
+			name:              "Error if empty JSON object",
+			settings:          `{}`,
+			expectedInitError: `could not find url property in settings`,
+		},
+		{
+			name:              "Error if message type is invalid",
+			settings:          `{"url": "http://localhost", "msgType": "post"}`,
+			expectedInitError: `invalid message type "post"`,
+		},
+		{
+			name:              "Error if app secret is missing",
+			settings:          `{"url": "http://localhost", "appId": "test-app-id"}`,
+			expectedInitError: `appSecret is required`,
+		},
+		{
+			name:     "Minimal valid configuration",
+			settings: `{"url": "http://localhost"}`,
+			expectedConfig: Config{
+				URL:         "http://localhost",
+				MessageType: MsgTypeInteractive,
+				Title:       templates.DefaultMessageTitleEmbed,
+				Message:     templates.DefaultMessageEmbed,
+				EndpointURL: DefaultEndpointURL,
+			},
+		},
+		{
+			name:     "Minimal valid configuration from secrets",
+			settings: `{}`,
+			secrets: map[string][]byte{
+				"url": []byte("http://localhost/secret"),
+			},
+			expectedConfig: Config{
+				URL:         "http://localhost/secret",
+				MessageType: MsgTypeInteractive,
+				Title:       templates.DefaultMessageTitleEmbed,
+				Message:     templates.DefaultMessageEmbed,
+				EndpointURL: DefaultEndpointURL,
+			},
+		},
+		{
+			name:     "All supported fields",
+			settings: FullValidConfigForTesting,
+			expectedConfig: Config{
+				URL:         "http://localhost",
+				Secret:      "test-secret",
+				MessageType: MsgTypeInteractive,
+				Title:       "Alerts firing: {{ len .Alerts.Firing }}",
+				Message:     "{{ len .Alerts.Firing }} alerts are firing, {{ len .Alerts.Resolved }} are resolved",
+				EndpointURL: "https://open.larksuite.com",
+				AppID:       "test-app-id",
+				AppSecret:   "test-app-secret",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
+			},
+		},
+		{
+			name:     "All supported fields with secrets",
+			settings: FullValidConfigForTesting,
+			secrets:  receiversTesting.ReadSecretsJSONForTesting(FullValidSecretsForTesting),
+			expectedConfig: Config{
+				URL:         "http://localhost/secret",
+				Secret:      "test-secret-secret",
+				MessageType: MsgTypeInteractive,
+				Title:       "Alerts firing: {{ len .Alerts.Firing }}",
+				Message:     "{{ len .Alerts.Firing }} alerts are firing, {{ len .Alerts.Resolved }} are resolved",
+				EndpointURL: "https://open.larksuite.com",
+				AppID:       "test-app-id",
+				AppSecret:   "test-app-secret-secret",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := NewConfig(json.RawMessage(c.settings), receiversTesting.DecryptForTesting(c.secrets))
+
+			if c.expectedInitError != "" {
+				require.ErrorContains(t, err, c.expectedInitError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expectedConfig, actual)
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package feishu
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/chatcard"
+	"github.com/grafana/alerting/templates"
+)
+
+// timeNow makes it possible to test the signature of the requests.
+var timeNow = time.Now
+
+// Notifier is responsible for sending alert notifications to Feishu (Lark).
+// It uses the webhook of a custom bot to send the message, and if the app credentials are configured,
+// the APIs of the open platform to upload the images of the alerts:
+// - https://open.feishu.cn/document/server-docs/authentication-management/access-token/tenant_access_token_internal
+// - https://open.feishu.cn/document/server-docs/im-v1/image/create
+type Notifier struct {
+	*receivers.Base
+	log      logging.Logger
+	images   images.Provider
+	ns       receivers.WebhookSender
+	tmpl     *templates.Template
+	settings Config
+}
+
+func New(cfg Config, meta receivers.Metadata, template *templates.Template, sender receivers.WebhookSender, images images.Provider, logger logging.Logger) *Notifier {
+	return &Notifier{
+		Base:     receivers.NewBase(meta),
+		log:      logger,
+		images:   images,
+		ns:       sender,
+		tmpl:     template,
+		settings: cfg,
+	}
+}
+
+// Notify sends the alert notification to Feishu.
+func (fn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, fn.log)
+	l.Info("sending feishu")
+
+	var tmplErr error
+	tmpl, _ := templates.TmplText(ctx, fn.tmpl, as, l, &tmplErr)
+
+	message := tmpl(fn.settings.Message)
+	title := tmpl(fn.settings.Title)
+	if tmplErr != nil {
+		l.Warn("failed to template Feishu message", "error", tmplErr.Error())
+	}
+
+	card := chatcard.New(title, message, receivers.JoinURLPath(fn.tmpl.ExternalURL.String(), "/alerting/list", l), as...)
+
+	tlsConfig, err := receivers.ClientTLSConfig(fn.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+
+	var imageKeys []string
+	if fn.settings.MessageType == MsgTypeInteractive && fn.settings.AppID != "" {
+		imageKeys = fn.uploadImages(ctx, &card, as)
+	}
+
+	body, err := fn.buildBody(card, imageKeys)
+	if err != nil {
+		return false, err
+	}
+
+	cmd := &receivers.SendWebhookSettings{
+		URL:        fn.settings.URL,
+		Body:       body,
+		HTTPMethod: "POST",
+		HTTPHeader: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Validation: validateResponse,
+		TLSConfig:  tlsConfig,
+	}
+	if err := fn.ns.SendWebhook(ctx, cmd); err != nil {
+		return false, fmt.Errorf("send notification to feishu: %w", err)
+	}
+	return true, nil
+}
+
+func (fn *Notifier) SendResolved() bool {
+	return !fn.GetDisableResolveMessage()
+}
+
+func (fn *Notifier) buildBody(card chatcard.Card, imageKeys []string) (string, error) {
+	bodyMsg := map[string]interface{}{
+		"msg_type": fn.settings.MessageType,
+	}
+	if fn.settings.Secret != "" {
+		timestamp := strconv.FormatInt(timeNow().Unix(), 10)
+		bodyMsg["timestamp"] = timestamp
+		bodyMsg["sign"] = sign(timestamp, fn.settings.Secret)
+	}
+
+	if fn.settings.MessageType == MsgTypeText {
+		bodyMsg["content"] = map[string]string{
+			"text": card.PlainText(),
+		}
+	} else {
+		bodyMsg["card"] = buildCard(card, imageKeys)
+	}
+
+	body, err := json.Marshal(bodyMsg)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func buildCard(card chatcard.Card, imageKeys []string) map[string]interface{} {
+	elements := []interface{}{
+		map[string]string{
+			"tag":     "markdown",
+			"content": card.Text,
+		},
+	}
+	for _, key := range imageKeys {
+		elements = append(elements, map[string]interface{}{
+			"tag":     "img",
+			"img_key": key,
+			"alt":     plainText(""),
+		})
+	}
+	if len(card.Notes) > 0 {
+		elements = append(elements, map[string]interface{}{
+			"tag": "note",
+			"elements": []interface{}{
+				plainText(strings.Join(card.Notes, "\n")),
+			},
+		})
+	}
+	if card.URL != "" {
+		elements = append(elements, map[string]interface{}{
+			"tag": "action",
+			"actions": []interface{}{
+				map[string]interface{}{
+					"tag":  "button",
+					"text": plainText(card.ButtonText),
+					"type": "primary",
+					"url":  card.URL,
+				},
+			},
+		})
+	}
+	return map[string]interface{}{
+		"config": map[string]bool{
+			"wide_screen_mode": true,
+		},
+		"header": map[string]interface{}{
+			"title":    plainText(card.Title),
+			"template": card.Color(),
+		},
+		"elements": elements,
+	}
+}
+
+func plainText(content string) map[string]string {
+	return map[string]string{
+		"tag":     "plain_text",
+		"content": content,
+	}
+}
+
+// sign returns the signature of the request of a custom bot with signature verification, which is the HMAC-SHA256
+// of an empty message with the timestamp and the secret as key.
+func sign(timestamp, secret string) string {
+	h := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// uploadImages uploads the images of the alerts and returns their keys. The images that could not be resolved or
+// uploaded are replaced with a note of the card that links to the dashboard of the alert.
+func (fn *Notifier) uploadImages(ctx context.Context, card *chatcard.Card, as []*types.Alert) []string {
+	l := logging.FromContext(ctx, fn.log)
+	var (
+		keys  []string
+		token string
+	)
+	fallback := func(index int, err error) {
+		l.Warn("failed to get image, linking to the dashboard instead", "alert", as[index].String(), "error", err)
+		images.RecordFallback(ctx, "feishu", err)
+		card.Notes = append(card.Notes, receivers.ImageFallbackText(as[index], receivers.ImageFallbackURL(ctx, fn.tmpl, as[index], l)))
+	}
+	_ = images.WithStoredImagesOrFallback(ctx, l, fn.images, func(index int, image images.Image) error {
+		if image.Path == "" {
+			return nil
+		}
+		var err error
+		if token == "" {
+			if token, err = fn.tenantAccessToken(ctx); err != nil {
+				fallback(index, err)
+				return nil
+			}
+		}
+		key, err := fn.uploadImage(ctx, token, image.Path)
+		if err != nil {
+			fallback(index, err)
+			return nil
+		}
+		keys = append(keys, key)
+		return nil
+	}, fallback, as...)
+	return keys
+}
+
+type apiResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	// StatusCode and StatusMessage are returned by the webhooks of custom bots of older versions.
+	StatusCode    int    `json:"StatusCode"`
+	StatusMessage string `json:"StatusMessage"`
+}
+
+func (r apiResponse) err() error {
+	if r.Code != 0 {
+		return fmt.Errorf("feishu returned code %d: %s", r.Code, r.Msg)
+	}
+	if r.StatusCode != 0 {
+		return fmt.Errorf("feishu returned code %d: %s", r.StatusCode, r.StatusMessage)
+	}
+	return nil
+}
+
+// validateResponse returns an error if the response is not successful. Feishu responds with status code 200
+// and a non-zero code in the body to most errors.
+func validateResponse(body []byte, statusCode int) error {
+	if statusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %d: %s", statusCode, body)
+	}
+	var resp apiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return resp.err()
+}
+
+func (fn *Notifier) tenantAccessToken(ctx context.Context) (string, error) {
+	body, err := json.Marshal(map[string]string{
+		"app_id":     fn.settings.AppID,
+		"app_secret": fn.settings.AppSecret,
+	})
+	if err != nil {
+		return "", err
+	}
+	var token string
+	err = fn.sendAPI(ctx, "/open-apis/auth/v3/tenant_access_token/internal", string(body), map[string]string{
+		"Content-Type": "application/json; charset=utf-8",
+	}, func(b []byte) error {
+		var resp struct {
+			apiResponse
+			TenantAccessToken string `json:"tenant_access_token"`
+		}
+		if err := json.Unmarshal(b, &resp); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+		if err := resp.err(); err != nil {
+			return err
+		}
+		token = resp.TenantAccessToken
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get tenant access token: %w", err)
+	}
+	if token == "" {
+		return "", errors.New("failed to get tenant access token: empty token")
+	}
+	return token, nil
+}
+
+func (fn *Notifier) uploadImage(ctx context.Context, token, path string) (string, error) {
+	l := logging.FromContext(ctx, fn.log)
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open image: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			l.Warn("failed to close image", "error", err)
+		}
+	}()
+
+	b := bytes.Buffer{}
+	w := multipart.NewWriter(&b)
+	if boundary := receivers.GetBoundary(); boundary != "" {
+		if err := w.SetBoundary(boundary); err != nil {
+			return "", err
+		}
+	}
+	if err := w.WriteField("image_type", "message"); err != nil {
+		return "", fmt.Errorf("failed to create form field: %w", err)
+	}
+	fw, err := w.CreateFormFile("image", filepath.Base(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(fw, f); err != nil {
+		return "", fmt.Errorf("failed to write to form file: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to close multipart: %w", err)
+	}
+
+	var key string
+	err = fn.sendAPI(ctx, "/open-apis/im/v1/images", b.String(), map[string]string{
+		"Authorization": "Bearer " + token,
+		"Content-Type":  w.FormDataContentType(),
+	}, func(b []byte) error {
+		var resp struct {
+			apiResponse
+			Data struct {
+				ImageKey string `json:"image_key"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(b, &resp); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+		if err := resp.err(); err != nil {
+			return err
+		}
+		key = resp.Data.ImageKey
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %w", err)
+	}
+	if key == "" {
+		return "", errors.New("failed to upload image: empty image key")
+	}
+	return key, nil
+}
+
+// sendAPI sends a request to the open platform and calls parse with the body of the successful response.
+func (fn *Notifier) sendAPI(ctx context.Context, path, body string, header map[string]string, parse func([]byte) error) error {
+	tlsConfig, err := receivers.ClientTLSConfig(fn.settings.TLSConfig)
+	if err != nil {
+		return fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	return fn.ns.SendWebhook(ctx, &receivers.SendWebhookSettings{
+		URL:        strings.TrimSuffix(fn.settings.EndpointURL, "/") + path,
+		Body:       body,
+		HTTPMethod: "POST",
+		HTTPHeader: header,
+		Validation: func(b []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				return fmt.Errorf("unexpected status code %d: %s", statusCode, b)
+			}
+			return parse(b)
+		},
+		TLSConfig: tlsConfig,
+	})
+}
@@ -0,0 +1,233 @@
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/chatcard"
+	"github.com/grafana/alerting/templates"
+)
+
+const defaultMessage = "**Firing**\n\nValue: A=1234\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1\nDashboard: http://localhost/d/abcd\nPanel: http://localhost/d/abcd?viewPanel=efgh\n"
+
+func TestNotify(t *testing.T) {
+	tmpl := chatcard.TemplateForTesting(t)
+
+	now := time.Unix(1700000000, 0)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	cases := []struct {
+		name     string
+		settings Config
+		alerts   []*types.Alert
+		expMsg   string
+	}{
+		{
+			name: "Default config with one alert",
+			settings: Config{
+				URL:         "http://localhost",
+				MessageType: MsgTypeInteractive,
+				Title:       templates.DefaultMessageTitleEmbed,
+				Message:     templates.DefaultMessageEmbed,
+			},
+			alerts: []*types.Alert{
+				chatcard.FiringAlertForTesting(model.LabelSet{"alertname": "alert1", "lbl1": "val1"}),
+			},
+			expMsg: `{
+				"msg_type": "interactive",
+				"card": {
+					"config": {"wide_screen_mode": true},
+					"header": {"title": {"tag": "plain_text", "content": "[FIRING:1]  (val1)"}, "template": "red"},
+					"elements": [
+						{"tag": "markdown", "content": ` + quote(defaultMessage) + `},
+						{"tag": "action", "actions": [{"tag": "button", "text": {"tag": "plain_text", "content": "More"}, "type": "primary", "url": "http://localhost/alerting/list"}]}
+					]
+				}
+			}`,
+		},
+		{
+			name: "Signed text message of resolved alerts",
+			settings: Config{
+				URL:         "http://localhost",
+				Secret:      "test-secret",
+				MessageType: MsgTypeText,
+				Title:       "Alerts resolved: {{ len .Alerts.Resolved }}",
+				Message:     "{{ len .Alerts.Firing }} alerts are firing, {{ len .Alerts.Resolved }} are resolved",
+			},
+			alerts: []*types.Alert{
+				chatcard.ResolvedAlertForTesting(model.LabelSet{"alertname": "alert1", "lbl1": "val1"}),
+				chatcard.ResolvedAlertForTesting(model.LabelSet{"alertname": "alert1", "lbl1": "val2"}),
+			},
+			expMsg: `{
+				"msg_type": "text",
+				"timestamp": "1700000000",
+				"sign": "mbm4Y4oluIPQ00qlBIhX8vAZ0EKv3nw0LuTb91jPL84=",
+				"content": {"text": "Alerts resolved: 2\n0 alerts are firing, 2 are resolved\n"}
+			}`,
+		},
+		{
+			name: "Resolved alerts are green",
+			settings: Config{
+				URL:         "http://localhost",
+				MessageType: MsgTypeInteractive,
+				Title:       "Alerts resolved",
+				Message:     "customMessage",
+			},
+			alerts: []*types.Alert{
+				chatcard.ResolvedAlertForTesting(model.LabelSet{"alertname": "alert1", "lbl1": "val1"}),
+			},
+			expMsg: `{
+				"msg_type": "interactive",
+				"card": {
+					"config": {"wide_screen_mode": true},
+					"header": {"title": {"tag": "plain_text", "content": "Alerts resolved"}, "template": "green"},
+					"elements": [
+						{"tag": "markdown", "content": "customMessage"},
+						{"tag": "action", "actions": [{"tag": "button", "text": {"tag": "plain_text", "content": "More"}, "type": "primary", "url": "http://localhost/alerting/list"}]}
+					]
+				}
+			}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			webhookSender := receivers.MockNotificationService()
+			fn := New(c.settings, receivers.Metadata{}, tmpl, webhookSender, &images.UnavailableProvider{}, &logging.FakeLogger{})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+
+			ok, err := fn.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.settings.URL, webhookSender.Webhook.URL)
+			require.JSONEq(t, c.expMsg, webhookSender.Webhook.Body)
+		})
+	}
+}
+
+func TestNotify_Images(t *testing.T) {
+	tmpl := chatcard.TemplateForTesting(t)
+	imageProvider := images.NewFakeProviderWithFile(t, 1)
+
+	const (
+		tokenURL  = "https://open.larksuite.com/open-apis/auth/v3/tenant_access_token/internal"
+		imagesURL = "https://open.larksuite.com/open-apis/im/v1/images"
+	)
+	settings := Config{
+		URL:         "http://localhost",
+		MessageType: MsgTypeInteractive,
+		Title:       "Alerts firing: {{ len .Alerts.Firing }}",
+		Message:     "customMessage",
+		EndpointURL: "https://open.larksuite.com/",
+		AppID:       "test-app-id",
+		AppSecret:   "test-app-secret",
+	}
+	alerts := []*types.Alert{
+		chatcard.AlertWithImageForTesting(model.LabelSet{"alertname": "alert1"}, "test-image-1"),
+		chatcard.AlertWithImageForTesting(model.LabelSet{"alertname": "alert2"}, "test-image-missing"),
+	}
+
+	t.Run("uploads images and links to the dashboard of missing ones", func(t *testing.T) {
+		sender := &fakeSender{responses: map[string]fakeResponse{
+			tokenURL:           {statusCode: 200, body: `{"code": 0, "msg": "ok", "tenant_access_token": "t-abcd", "expire": 7200}`},
+			imagesURL:          {statusCode: 200, body: `{"code": 0, "msg": "success", "data": {"image_key": "img_v2_1"}}`},
+			"http://localhost": {statusCode: 200, body: `{"code": 0, "msg": "success"}`},
+		}}
+		fn := New(settings, receivers.Metadata{}, tmpl, sender, imageProvider, &logging.FakeLogger{})
+
+		ok, err := fn.Notify(context.Background(), alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Len(t, sender.calls, 3)
+		require.Equal(t, tokenURL, sender.calls[0].URL)
+		require.JSONEq(t, `{"app_id": "test-app-id", "app_secret": "test-app-secret"}`, sender.calls[0].Body)
+		require.Equal(t, imagesURL, sender.calls[1].URL)
+		require.Equal(t, "Bearer t-abcd", sender.calls[1].HTTPHeader["Authorization"])
+		require.Contains(t, sender.calls[1].Body, `name="image_type"`)
+		require.Contains(t, sender.calls[1].HTTPHeader["Content-Type"], "multipart/form-data")
+		require.JSONEq(t, `{
+			"msg_type": "interactive",
+			"card": {
+				"config": {"wide_screen_mode": true},
+				"header": {"title": {"tag": "plain_text", "content": "Alerts firing: 2"}, "template": "red"},
+				"elements": [
+					{"tag": "markdown", "content": "customMessage"},
+					{"tag": "img", "img_key": "img_v2_1", "alt": {"tag": "plain_text", "content": ""}},
+					{"tag": "note", "elements": [{"tag": "plain_text", "content": "The image of alert2 could not be loaded, view it in Grafana: http://localhost/d/abcd?viewPanel=efgh"}]},
+					{"tag": "action", "actions": [{"tag": "button", "text": {"tag": "plain_text", "content": "More"}, "type": "primary", "url": "http://localhost/alerting/list"}]}
+				]
+			}
+		}`, sender.calls[2].Body)
+	})
+
+	t.Run("links to the dashboard if images cannot be uploaded", func(t *testing.T) {
+		sender := &fakeSender{responses: map[string]fakeResponse{
+			tokenURL:           {statusCode: 200, body: `{"code": 10003, "msg": "invalid param"}`},
+			"http://localhost": {statusCode: 200, body: `{"code": 0, "msg": "success"}`},
+		}}
+		fn := New(settings, receivers.Metadata{}, tmpl, sender, imageProvider, &logging.FakeLogger{})
+
+		ok, err := fn.Notify(context.Background(), alerts[0])
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Len(t, sender.calls, 2)
+		require.Contains(t, sender.calls[1].Body, "The image of alert1 could not be loaded")
+		require.NotContains(t, sender.calls[1].Body, "img_key")
+	})
+}
+
+func TestNotify_Error(t *testing.T) {
+	sender := &fakeSender{responses: map[string]fakeResponse{
+		"http://localhost": {statusCode: 200, body: `{"code": 19021, "msg": "sign match fail or timestamp is not within one hour from current time"}`},
+	}}
+	fn := New(Config{URL: "http://localhost", MessageType: MsgTypeInteractive}, receivers.Metadata{}, chatcard.TemplateForTesting(t), sender, &images.UnavailableProvider{}, &logging.FakeLogger{})
+
+	ok, err := fn.Notify(context.Background(), chatcard.FiringAlertForTesting(model.LabelSet{"alertname": "alert1"}))
+	require.False(t, ok)
+	require.ErrorContains(t, err, "feishu returned code 19021: sign match fail")
+}
+
+type fakeResponse struct {
+	statusCode int
+	body       string
+}
+
+// fakeSender is a WebhookSender that validates the requests with the responses configured for their URLs.
+type fakeSender struct {
+	responses map[string]fakeResponse
+	calls     []receivers.SendWebhookSettings
+}
+
+func (s *fakeSender) SendWebhook(_ context.Context, cmd *receivers.SendWebhookSettings) error {
+	s.calls = append(s.calls, *cmd)
+	res, ok := s.responses[cmd.URL]
+	if !ok {
+		return errors.New("unexpected request to " + cmd.URL)
+	}
+	if cmd.Validation != nil {
+		return cmd.Validation([]byte(res.body), res.statusCode)
+	}
+	return nil
+}
+
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,26 @@
+package feishu
+
+// FullValidConfigForTesting is a string representation of a JSON object that contains all fields supported by the notifier Config. It can be used without secrets.
+const FullValidConfigForTesting = `{
+	"url": "http://localhost",
+	"secret": "test-secret",
+	"msgType": "interactive",
+	"title": "Alerts firing: {{ len .Alerts.Firing }}",
+	"message": "{{ len .Alerts.Firing }} alerts are firing, {{ len .Alerts.Resolved }} are resolved",
+	"endpointUrl": "https://open.larksuite.com",
+	"appId": "test-app-id",
+	"appSecret": "test-app-secret",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
+}`
+
+// FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
+const FullValidSecretsForTesting = `{
+	"url": "http://localhost/secret",
+	"secret": "test-secret-secret",
+	"appSecret": "test-app-secret-secret"
+}`
@@ -0,0 +1,70 @@
+package receivers
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrNotFIPSApproved is returned for configurations that require algorithms not approved by FIPS 140 in FIPS mode.
+var ErrNotFIPSApproved = errors.New("not approved in FIPS mode")
+
+// fipsMode restricts the cryptography of integrations to algorithms approved by FIPS 140. It is enabled by default
+// in binaries built with the fips build tag.
+var fipsMode atomic.Bool
+
+// SetFIPSMode enables or disables FIPS mode. In FIPS mode, TLS connections of integrations only use TLS 1.2 or later,
+// cipher suites in FIPSCipherSuites and NIST curves, and configurations that require other algorithms are invalid.
+// It must be set before integrations are built.
+func SetFIPSMode(enabled bool) {
+	fipsMode.Store(enabled)
+}
+
+// FIPSMode returns true if FIPS mode is enabled.
+func FIPSMode() bool {
+	return fipsMode.Load()
+}
+
+// FIPSCipherSuites are the TLS 1.2 cipher suites approved by FIPS 140. The cipher suites of TLS 1.3 are not
+// configurable in crypto/tls.
+var FIPSCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// fipsCurves are the key exchange curves approved by FIPS 140.
+var fipsCurves = []tls.CurveID{tls.CurveP256, tls.CurveP384, tls.CurveP521}
+
+// fipsMinHMACKeyLength is the minimum length in bytes of the keys of HMACs approved by FIPS 140, which is 112 bits.
+const fipsMinHMACKeyLength = 14
+
+// RestrictTLSConfig returns the TLS configuration restricted to the algorithms approved by FIPS 140 if FIPS mode is
+// enabled, or cfg as is otherwise. A nil configuration is restricted as the default configuration. cfg is not
+// modified.
+func RestrictTLSConfig(cfg *tls.Config) *tls.Config {
+	if !FIPSMode() {
+		return cfg
+	}
+	if cfg == nil {
+		cfg = &tls.Config{}
+	} else {
+		cfg = cfg.Clone()
+	}
+	if cfg.MinVersion < tls.VersionTLS12 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	cfg.CipherSuites = FIPSCipherSuites
+	cfg.CurvePreferences = fipsCurves
+	return cfg
+}
+
+// ValidateHMACKey returns an error if the key of an HMAC is too short to be approved by FIPS 140 in FIPS mode.
+func ValidateHMACKey(key string) error {
+	if FIPSMode() && len(key) < fipsMinHMACKeyLength {
+		return fmt.Errorf("HMAC keys shorter than 112 bits are %w", ErrNotFIPSApproved)
+	}
+	return nil
+}
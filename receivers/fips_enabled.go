@@ -0,0 +1,7 @@
+//go:build fips
+
+package receivers
+
+func init() {
+	SetFIPSMode(true)
+}
@@ -0,0 +1,68 @@
+package receivers
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setFIPSMode sets FIPS mode for the test, which may be enabled by default with the fips build tag.
+func setFIPSMode(t *testing.T, enabled bool) {
+	t.Helper()
+	prev := FIPSMode()
+	SetFIPSMode(enabled)
+	t.Cleanup(func() { SetFIPSMode(prev) })
+}
+
+func TestRestrictTLSConfig(t *testing.T) {
+	setFIPSMode(t, false)
+	cfg := &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS10}
+	require.Same(t, cfg, RestrictTLSConfig(cfg))
+	require.Nil(t, RestrictTLSConfig(nil))
+
+	setFIPSMode(t, true)
+	restricted := RestrictTLSConfig(cfg)
+	require.Equal(t, uint16(tls.VersionTLS12), restricted.MinVersion)
+	require.Equal(t, FIPSCipherSuites, restricted.CipherSuites)
+	require.Equal(t, fipsCurves, restricted.CurvePreferences)
+	require.True(t, restricted.InsecureSkipVerify)
+	// The configuration is not modified.
+	require.Equal(t, uint16(tls.VersionTLS10), cfg.MinVersion)
+	require.Nil(t, cfg.CipherSuites)
+
+	require.Equal(t, uint16(tls.VersionTLS13), RestrictTLSConfig(&tls.Config{MinVersion: tls.VersionTLS13}).MinVersion)
+
+	tlsCfg, err := ClientTLSConfig(nil)
+	require.NoError(t, err)
+	require.Equal(t, FIPSCipherSuites, tlsCfg.CipherSuites)
+	tlsCfg, err = (&TLSConfig{ServerName: "example.com"}).ToCryptoTLSConfig()
+	require.NoError(t, err)
+	require.Equal(t, "example.com", tlsCfg.ServerName)
+	require.Equal(t, FIPSCipherSuites, tlsCfg.CipherSuites)
+	require.Equal(t, FIPSCipherSuites, NewTLSClient(nil).Transport.(*http.Transport).TLSClientConfig.CipherSuites)
+}
+
+func TestParseTLSConfig_FIPSMode(t *testing.T) {
+	setFIPSMode(t, false)
+	noSecrets := func(_ string, fallback string) string { return fallback }
+	_, err := ParseTLSConfig(&TLSConfig{MinVersion: "TLS11"}, noSecrets)
+	require.NoError(t, err)
+
+	setFIPSMode(t, true)
+	_, err = ParseTLSConfig(&TLSConfig{MinVersion: "TLS11"}, noSecrets)
+	require.ErrorIs(t, err, ErrNotFIPSApproved)
+	require.EqualError(t, err, "invalid value for tlsConfig.minVersion: TLS11 is not approved in FIPS mode")
+	_, err = ParseTLSConfig(&TLSConfig{MinVersion: "TLS12"}, noSecrets)
+	require.NoError(t, err)
+}
+
+func TestValidateHMACKey(t *testing.T) {
+	setFIPSMode(t, false)
+	require.NoError(t, ValidateHMACKey("short"))
+
+	setFIPSMode(t, true)
+	require.ErrorIs(t, ValidateHMACKey("short"), ErrNotFIPSApproved)
+	require.NoError(t, ValidateHMACKey("at-least-112-bits"))
+}
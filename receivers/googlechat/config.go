@@ -10,9 +10,10 @@ import (
 )
 
 type Config struct {
-	URL     string `json:"url,omitempty" yaml:"url,omitempty"`
-	Title   string `json:"title,omitempty" yaml:"title,omitempty"`
-	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	URL       string               `json:"url,omitempty" yaml:"url,omitempty"`
+	Title     string               `json:"title,omitempty" yaml:"title,omitempty"`
+	Message   string               `json:"message,omitempty" yaml:"message,omitempty"`
+	TLSConfig *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -32,5 +33,9 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	if settings.Message == "" {
 		settings.Message = templates.DefaultMessageEmbed
 	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
 	return settings, nil
 }
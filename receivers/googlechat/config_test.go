@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
 	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
@@ -85,6 +86,12 @@ func TestNewConfig(t *testing.T) {
 				Title:   "test-title",
 				Message: "test-message",
 				URL:     "http://localhost/url-secret",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 	}
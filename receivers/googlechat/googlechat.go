@@ -46,10 +46,11 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify send an alert notification to Google Chat.
 func (gcn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	gcn.log.Debug("executing Google Chat notification")
+	l := logging.FromContext(ctx, gcn.log)
+	l.Debug("executing Google Chat notification")
 
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, gcn.tmpl, as, gcn.log, &tmplErr)
+	tmpl, _ := templates.TmplText(ctx, gcn.tmpl, as, l, &tmplErr)
 
 	var widgets []widget
 
@@ -60,11 +61,11 @@ func (gcn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, erro
 	}
 
 	if tmplErr != nil {
-		gcn.log.Warn("failed to template Google Chat message", "error", tmplErr.Error())
+		l.Warn("failed to template Google Chat message", "error", tmplErr.Error())
 		tmplErr = nil
 	}
 
-	ruleURL := receivers.JoinURLPath(gcn.tmpl.ExternalURL.String(), "/alerting/list", gcn.log)
+	ruleURL := receivers.JoinURLPath(gcn.tmpl.ExternalURL.String(), "/alerting/list", l)
 	if gcn.isURLAbsolute(ruleURL) {
 		// Add a button widget (link to Grafana).
 		widgets = append(widgets, buttonWidget{
@@ -82,7 +83,7 @@ func (gcn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, erro
 			},
 		})
 	} else {
-		gcn.log.Warn("Grafana external URL setting is missing or invalid. Skipping 'open in grafana' button to prevent Google from displaying empty alerts.", "ruleURL", ruleURL)
+		l.Warn("Grafana external URL setting is missing or invalid. Skipping 'open in grafana' button to prevent Google from displaying empty alerts.", "ruleURL", ruleURL)
 	}
 
 	// Add text paragraph widget for the build version and timestamp.
@@ -111,13 +112,13 @@ func (gcn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, erro
 	}
 
 	if tmplErr != nil {
-		gcn.log.Warn("failed to template GoogleChat message", "error", tmplErr.Error())
+		l.Warn("failed to template GoogleChat message", "error", tmplErr.Error())
 		tmplErr = nil
 	}
 
 	u := tmpl(gcn.settings.URL)
 	if tmplErr != nil {
-		gcn.log.Warn("failed to template GoogleChat URL", "error", tmplErr.Error(), "fallback", gcn.settings.URL)
+		l.Warn("failed to template GoogleChat URL", "error", tmplErr.Error(), "fallback", gcn.settings.URL)
 		u = gcn.settings.URL
 	}
 
@@ -126,17 +127,22 @@ func (gcn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, erro
 		return false, fmt.Errorf("marshal json: %w", err)
 	}
 
+	tlsConfig, err := receivers.ClientTLSConfig(gcn.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        u,
 		HTTPMethod: "POST",
 		HTTPHeader: map[string]string{
 			"Content-Type": "application/json; charset=UTF-8",
 		},
-		Body: string(body),
+		Body:      string(body),
+		TLSConfig: tlsConfig,
 	}
 
 	if err := gcn.ns.SendWebhook(ctx, cmd); err != nil {
-		gcn.log.Error("Failed to send Google Hangouts Chat alert", "error", err, "webhook", gcn.Name)
+		l.Error("Failed to send Google Hangouts Chat alert", "error", err, "webhook", gcn.Name)
 		return false, err
 	}
 
@@ -158,12 +164,13 @@ func (gcn *Notifier) isURLAbsolute(urlToCheck string) bool {
 }
 
 func (gcn *Notifier) buildScreenshotCard(ctx context.Context, alerts []*types.Alert) *card {
+	l := logging.FromContext(ctx, gcn.log)
 	card := card{
 		Header:   header{Title: "Screenshots"},
 		Sections: []section{},
 	}
 
-	_ = images.WithStoredImages(ctx, gcn.log, gcn.images,
+	_ = images.WithStoredImages(ctx, l, gcn.images,
 		func(index int, image images.Image) error {
 			if len(image.URL) == 0 {
 				return nil
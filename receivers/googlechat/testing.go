@@ -6,7 +6,13 @@ const FullValidConfigForTesting = `{
 	"title": "test-title", 
 	"message": "test-message", 
 	"avatar_url" : "http://avatar", 
-	"use_discord_username": true
+	"use_discord_username": true,
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets.
@@ -0,0 +1,35 @@
+package receivers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/templates"
+)
+
+// ImageFallbackURL returns the URL of the panel of the alert, or of its dashboard if the alert has no panel, that
+// notifiers link to in place of an image of the alert that could not be resolved. It returns an empty string if
+// the alert has neither.
+func ImageFallbackURL(ctx context.Context, tmpl *templates.Template, alert *types.Alert, l logging.Logger) string {
+	var tmplErr error
+	_, data := templates.TmplText(ctx, tmpl, []*types.Alert{alert}, l, &tmplErr)
+	if len(data.Alerts) == 0 {
+		return ""
+	}
+	if data.Alerts[0].PanelURL != "" {
+		return data.Alerts[0].PanelURL
+	}
+	return data.Alerts[0].DashboardURL
+}
+
+// ImageFallbackText returns the text that notifiers show in place of an image of the alert that could not be
+// resolved, so that the recipient knows that the image is missing and where to find it.
+func ImageFallbackText(alert *types.Alert, url string) string {
+	if url == "" {
+		return fmt.Sprintf("The image of %s could not be loaded.", alert.Name())
+	}
+	return fmt.Sprintf("The image of %s could not be loaded, view it in Grafana: %s", alert.Name(), url)
+}
@@ -11,12 +11,21 @@ import (
 )
 
 // The user can choose which API version to use when sending
-// messages to Kafka. The default is v2.
+// messages to Kafka. The default is v2. With auto, v3 is used
+// if the REST proxy supports it, otherwise v2.
 // Details on how these versions differ can be found here:
 // https://docs.confluent.io/platform/current/kafka-rest/api.html
 const (
-	apiVersionV2 = "v2"
-	apiVersionV3 = "v3"
+	apiVersionV2   = "v2"
+	apiVersionV3   = "v3"
+	apiVersionAuto = "auto"
+)
+
+// The encoding of the records. With protobuf, records are AlertGroup messages of the schema in package alertpb,
+// sent as binary values.
+const (
+	encodingJSON     = "json"
+	encodingProtobuf = "protobuf"
 )
 
 type Config struct {
@@ -28,6 +37,11 @@ type Config struct {
 	Password       string `json:"password,omitempty" yaml:"password,omitempty"`
 	APIVersion     string `json:"apiVersion,omitempty" yaml:"apiVersion,omitempty"`
 	KafkaClusterID string `json:"kafkaClusterId,omitempty" yaml:"kafkaClusterId,omitempty"`
+	// Headers are added to the records sent with the v3 API. The values are templated.
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Encoding is the encoding of the records, either json or protobuf. Empty is json.
+	Encoding  string               `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+	TLSConfig *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -53,14 +67,22 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	}
 	settings.Password = decryptFn("password", settings.Password)
 
-	if settings.APIVersion == "" {
+	// If the cluster ID is not provided, it is discovered when using api version 3.
+	switch settings.APIVersion {
+	case "":
 		settings.APIVersion = apiVersionV2
-	} else if settings.APIVersion == apiVersionV3 {
-		if settings.KafkaClusterID == "" {
-			return Config{}, errors.New("kafka cluster id must be provided when using api version 3")
-		}
-	} else if settings.APIVersion != apiVersionV2 && settings.APIVersion != apiVersionV3 {
+	case apiVersionV2, apiVersionV3, apiVersionAuto:
+	default:
 		return Config{}, fmt.Errorf("unsupported api version: %s", settings.APIVersion)
 	}
+	switch settings.Encoding {
+	case "", encodingJSON, encodingProtobuf:
+	default:
+		return Config{}, fmt.Errorf("unsupported encoding: %s", settings.Encoding)
+	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
 	return settings, nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
 	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
@@ -118,6 +119,14 @@ func TestNewConfig(t *testing.T) {
 				Password:       "password",
 				APIVersion:     "v2",
 				KafkaClusterID: "12345",
+				Headers:        map[string]string{"source": "grafana"},
+				Encoding:       "json",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 		{
@@ -133,6 +142,14 @@ func TestNewConfig(t *testing.T) {
 				Password:       "test-password",
 				APIVersion:     "v2",
 				KafkaClusterID: "12345",
+				Headers:        map[string]string{"source": "grafana"},
+				Encoding:       "json",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 		{
@@ -145,13 +162,59 @@ func TestNewConfig(t *testing.T) {
 			expectedInitError: "unsupported api version: test-1235",
 		},
 		{
-			name: "Error if clusterId is not specified for api version 3",
+			name: "Error if encoding is unknown",
+			settings: `{
+				"kafkaRestProxy": "http://localhost/", 
+				"kafkaTopic" : "test-topic", 
+				"encoding": "avro" 
+			}`,
+			expectedInitError: "unsupported encoding: avro",
+		},
+		{
+			name: "Records can be encoded with protobuf",
+			settings: `{
+				"kafkaRestProxy": "http://localhost/", 
+				"kafkaTopic" : "test-topic", 
+				"encoding": "protobuf" 
+			}`,
+			expectedConfig: Config{
+				Endpoint:    "http://localhost",
+				Topic:       "test-topic",
+				Description: templates.DefaultMessageTitleEmbed,
+				Details:     templates.DefaultMessageEmbed,
+				APIVersion:  "v2",
+				Encoding:    "protobuf",
+			},
+		},
+		{
+			name: "Cluster ID is optional for api version 3",
 			settings: `{
 				"kafkaRestProxy": "http://localhost/", 
 				"kafkaTopic" : "test-topic", 
 				"apiVersion": "v3" 
 			}`,
-			expectedInitError: "kafka cluster id must be provided when using api version 3",
+			expectedConfig: Config{
+				Endpoint:    "http://localhost",
+				Topic:       "test-topic",
+				Description: templates.DefaultMessageTitleEmbed,
+				Details:     templates.DefaultMessageEmbed,
+				APIVersion:  "v3",
+			},
+		},
+		{
+			name: "API version can be detected automatically",
+			settings: `{
+				"kafkaRestProxy": "http://localhost/", 
+				"kafkaTopic" : "test-topic", 
+				"apiVersion": "auto" 
+			}`,
+			expectedConfig: Config{
+				Endpoint:    "http://localhost",
+				Topic:       "test-topic",
+				Description: templates.DefaultMessageTitleEmbed,
+				Details:     templates.DefaultMessageEmbed,
+				APIVersion:  "auto",
+			},
 		},
 	}
 
@@ -2,8 +2,12 @@ package kafka
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/prometheus/alertmanager/notify"
 
@@ -13,6 +17,7 @@ import (
 	"github.com/grafana/alerting/images"
 	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/alertpb"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -34,11 +39,42 @@ type kafkaRecord struct {
 	IncidentKey string                   `json:"incident_key,omitempty"`
 }
 
+type kafkaV3Body struct {
+	Headers []kafkaV3Header `json:"headers,omitempty"`
+	Value   kafkaV3Record   `json:"value"`
+}
+
+type kafkaV3Header struct {
+	Name string `json:"name"`
+	// Value is base64 encoded.
+	Value string `json:"value"`
+}
+
 type kafkaV3Record struct {
 	Type string      `json:"type"`
 	Data kafkaRecord `json:"data"`
 }
 
+// kafkaBinaryBody is the body of the v2 API with binary values, which are base64 encoded.
+type kafkaBinaryBody struct {
+	Records []kafkaBinaryRecordEnvelope `json:"records"`
+}
+
+type kafkaBinaryRecordEnvelope struct {
+	Value string `json:"value"`
+}
+
+type kafkaV3BinaryBody struct {
+	Headers []kafkaV3Header     `json:"headers,omitempty"`
+	Value   kafkaV3BinaryRecord `json:"value"`
+}
+
+type kafkaV3BinaryRecord struct {
+	Type string `json:"type"`
+	// Data is base64 encoded.
+	Data string `json:"data"`
+}
+
 type kafkaContext struct {
 	Type   string `json:"type"`
 	Source string `json:"src"`
@@ -53,6 +89,11 @@ type Notifier struct {
 	ns       receivers.WebhookSender
 	tmpl     *templates.Template
 	settings Config
+
+	// apiVersion and clusterID are the API version and cluster ID resolved by resolveAPI.
+	mtx        sync.Mutex
+	apiVersion string
+	clusterID  string
 }
 
 func New(cfg Config, meta receivers.Metadata, template *templates.Template, sender receivers.WebhookSender, images images.Provider, logger logging.Logger) *Notifier {
@@ -68,67 +109,173 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify sends the alert notification.
 func (kn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	if kn.settings.APIVersion == apiVersionV3 {
-		return kn.notifyWithAPIV3(ctx, as...)
+	apiVersion, clusterID, err := kn.resolveAPI(ctx)
+	if err != nil {
+		return false, err
+	}
+	if apiVersion == apiVersionV3 {
+		return kn.notifyWithAPIV3(ctx, clusterID, as...)
 	}
 	return kn.notifyWithAPIV2(ctx, as...)
 }
 
+// errV3Unavailable is returned by discoverClusterID if the REST proxy does not expose the v3 API.
+var errV3Unavailable = errors.New("kafka rest proxy does not support api version 3")
+
+// resolveAPI returns the API version to use and, for v3, the ID of the cluster. With auto, v3 is used if the REST proxy
+// exposes it, otherwise v2. If the cluster ID is not configured, the first cluster of the REST proxy is used.
+// The results of the discovery are kept for subsequent notifications.
+func (kn *Notifier) resolveAPI(ctx context.Context) (string, string, error) {
+	l := logging.FromContext(ctx, kn.log)
+	if kn.settings.APIVersion == apiVersionV2 {
+		return apiVersionV2, "", nil
+	}
+	if kn.settings.APIVersion == apiVersionV3 && kn.settings.KafkaClusterID != "" {
+		return apiVersionV3, kn.settings.KafkaClusterID, nil
+	}
+
+	kn.mtx.Lock()
+	defer kn.mtx.Unlock()
+	if kn.apiVersion != "" {
+		return kn.apiVersion, kn.clusterID, nil
+	}
+
+	clusterID, err := kn.discoverClusterID(ctx)
+	switch {
+	case errors.Is(err, errV3Unavailable) && kn.settings.APIVersion == apiVersionAuto:
+		l.Debug("kafka rest proxy does not support api version 3, using api version 2")
+		kn.apiVersion = apiVersionV2
+		return kn.apiVersion, "", nil
+	case err != nil:
+		return "", "", err
+	}
+	if kn.settings.KafkaClusterID != "" {
+		clusterID = kn.settings.KafkaClusterID
+	}
+	l.Debug("using kafka api version 3", "cluster_id", clusterID)
+	kn.apiVersion, kn.clusterID = apiVersionV3, clusterID
+	return kn.apiVersion, kn.clusterID, nil
+}
+
+/*
+A sample of V3 list clusters response looks like this,
+
+	{
+		"kind": "KafkaClusterList",
+		"data": [
+			{ "kind": "KafkaCluster", "cluster_id": "lkc-abcd" }
+		]
+	}
+*/
+type kafkaV3ClusterList struct {
+	Data []struct {
+		ClusterID string `json:"cluster_id"`
+	} `json:"data"`
+}
+
+// sendWebhook sends the request with the TLS configuration of the notifier.
+func (kn *Notifier) sendWebhook(ctx context.Context, cmd *receivers.SendWebhookSettings) error {
+	tlsConfig, err := receivers.ClientTLSConfig(kn.settings.TLSConfig)
+	if err != nil {
+		return fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	cmd.TLSConfig = tlsConfig
+	return kn.ns.SendWebhook(ctx, cmd)
+}
+
+// discoverClusterID returns the ID of the first cluster listed by the v3 API of the REST proxy.
+func (kn *Notifier) discoverClusterID(ctx context.Context) (string, error) {
+	var clusterID string
+	cmd := &receivers.SendWebhookSettings{
+		URL:        kn.settings.Endpoint + "/v3/clusters",
+		HTTPMethod: "GET",
+		HTTPHeader: map[string]string{
+			"Accept": "application/json",
+		},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				return fmt.Errorf("%w: unexpected status code %d", errV3Unavailable, statusCode)
+			}
+			var clusters kafkaV3ClusterList
+			if err := json.Unmarshal(body, &clusters); err != nil {
+				return fmt.Errorf("failed to parse kafka cluster list: %w", err)
+			}
+			if len(clusters.Data) == 0 || clusters.Data[0].ClusterID == "" {
+				return errors.New("kafka rest proxy has no clusters")
+			}
+			clusterID = clusters.Data[0].ClusterID
+			return nil
+		},
+		User:     kn.settings.Username,
+		Password: kn.settings.Password,
+	}
+	if err := kn.sendWebhook(ctx, cmd); err != nil {
+		return "", fmt.Errorf("failed to discover kafka cluster id: %w", err)
+	}
+	return clusterID, nil
+}
+
 // Use the v2 API to send the alert notification.
 func (kn *Notifier) notifyWithAPIV2(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, kn.log)
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, kn.tmpl, as, kn.log, &tmplErr)
+	tmpl, data := templates.TmplText(ctx, kn.tmpl, as, l, &tmplErr)
 
 	topicURL := kn.settings.Endpoint + "/topics/" + tmpl(kn.settings.Topic)
 	if tmplErr != nil {
-		kn.log.Warn("failed to template Kafka url", "error", tmplErr.Error())
+		l.Warn("failed to template Kafka url", "error", tmplErr.Error())
 	}
 
-	body, err := kn.buildBody(ctx, tmpl, as...)
+	body, err := kn.buildV2Body(ctx, tmpl, data, as...)
 	if err != nil {
 		return false, err
 	}
 	if tmplErr != nil {
-		kn.log.Warn("failed to template Kafka message", "error", tmplErr.Error())
+		l.Warn("failed to template Kafka message", "error", tmplErr.Error())
 	}
 
+	contentType := "application/vnd.kafka.json.v2+json"
+	if kn.settings.Encoding == encodingProtobuf {
+		contentType = "application/vnd.kafka.binary.v2+json"
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        topicURL,
 		Body:       body,
 		HTTPMethod: "POST",
 		HTTPHeader: map[string]string{
-			"Content-Type": "application/vnd.kafka.json.v2+json",
+			"Content-Type": contentType,
 			"Accept":       "application/vnd.kafka.v2+json",
 		},
 		User:     kn.settings.Username,
 		Password: kn.settings.Password,
 	}
 
-	if err := kn.ns.SendWebhook(ctx, cmd); err != nil {
-		kn.log.Error("Failed to send notification to Kafka", "error", err, "body", body)
+	if err := kn.sendWebhook(ctx, cmd); err != nil {
+		l.Error("Failed to send notification to Kafka", "error", err, "body", body)
 		return false, err
 	}
 	return true, nil
 }
 
 // Use the v3 API to send the alert notification.
-func (kn *Notifier) notifyWithAPIV3(ctx context.Context, as ...*types.Alert) (bool, error) {
+func (kn *Notifier) notifyWithAPIV3(ctx context.Context, clusterID string, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, kn.log)
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, kn.tmpl, as, kn.log, &tmplErr)
+	tmpl, data := templates.TmplText(ctx, kn.tmpl, as, l, &tmplErr)
 
 	// For v3 the Produce URL is like this,
 	// <Endpoint>/v3/clusters/<KafkaClusterID>/topics/<Topic>/records
-	topicURL := kn.settings.Endpoint + "/v3/clusters/" + tmpl(kn.settings.KafkaClusterID) + "/topics/" + tmpl(kn.settings.Topic) + "/records"
+	topicURL := kn.settings.Endpoint + "/v3/clusters/" + tmpl(clusterID) + "/topics/" + tmpl(kn.settings.Topic) + "/records"
 	if tmplErr != nil {
-		kn.log.Warn("failed to template Kafka url", "error", tmplErr.Error())
+		l.Warn("failed to template Kafka url", "error", tmplErr.Error())
 	}
 
-	body, err := kn.buildV3Body(ctx, tmpl, as...)
+	body, err := kn.buildV3Body(ctx, tmpl, data, as...)
 	if err != nil {
 		return false, err
 	}
 	if tmplErr != nil {
-		kn.log.Warn("failed to template Kafka message", "error", tmplErr.Error())
+		l.Warn("failed to template Kafka message", "error", tmplErr.Error())
 	}
 
 	cmd := &receivers.SendWebhookSettings{
@@ -139,7 +286,7 @@ func (kn *Notifier) notifyWithAPIV3(ctx context.Context, as ...*types.Alert) (bo
 			"Content-Type": "application/json",
 			"Accept":       "application/json",
 		},
-		Validation: validateKafkaV3Response,
+		Validation: kn.validateKafkaV3Response,
 		User:       kn.settings.Username,
 		Password:   kn.settings.Password,
 	}
@@ -148,8 +295,8 @@ func (kn *Notifier) notifyWithAPIV3(ctx context.Context, as ...*types.Alert) (bo
 	// Can be implemented nicely using receivers. The v3 API can be used in streaming mode
 	// by setting “Transfer-Encoding: chunked” header.
 	// For as long as the connection is kept open, the server will keep accepting records.
-	if err := kn.ns.SendWebhook(ctx, cmd); err != nil {
-		kn.log.Error("Failed to send notification to Kafka", "error", err, "body", body)
+	if err := kn.sendWebhook(ctx, cmd); err != nil {
+		l.Error("Failed to send notification to Kafka", "error", err, "body", body)
 		return false, err
 	}
 	return true, nil
@@ -169,10 +316,16 @@ A sample of V3 response looks like this,
 	}
 */
 type kafkaV3Response struct {
-	ErrorCode int `json:"error_code"`
+	ErrorCode   int    `json:"error_code"`
+	Message     string `json:"message,omitempty"`
+	ClusterID   string `json:"cluster_id"`
+	TopicName   string `json:"topic_name"`
+	PartitionID int    `json:"partition_id"`
+	Offset      int64  `json:"offset"`
 }
 
-func validateKafkaV3Response(rawResponse []byte, statusCode int) error {
+// validateKafkaV3Response parses the delivery report of the record and returns an error if it was not delivered.
+func (kn *Notifier) validateKafkaV3Response(rawResponse []byte, statusCode int) error {
 	if statusCode/100 != 2 {
 		return fmt.Errorf("unexpected status code %d", statusCode)
 	}
@@ -185,6 +338,7 @@ func validateKafkaV3Response(rawResponse []byte, statusCode int) error {
 	if response.ErrorCode/100 != 2 {
 		return fmt.Errorf("failed to publish message to Kafka. response: %s", string(rawResponse))
 	}
+	kn.log.Debug("record delivered to Kafka", "cluster_id", response.ClusterID, "topic", response.TopicName, "partition", response.PartitionID, "offset", response.Offset)
 	return nil
 }
 
@@ -192,23 +346,27 @@ func (kn *Notifier) SendResolved() bool {
 	return !kn.GetDisableResolveMessage()
 }
 
-func (kn *Notifier) buildBody(ctx context.Context, tmpl func(string) string, as ...*types.Alert) (string, error) {
-	if kn.settings.APIVersion == apiVersionV3 {
-		return kn.buildV3Body(ctx, tmpl, as...)
-	}
-	return kn.buildV2Body(ctx, tmpl, as...)
-}
-
-func (kn *Notifier) buildV2Body(ctx context.Context, tmpl func(string) string, as ...*types.Alert) (string, error) {
+func (kn *Notifier) buildV2Body(ctx context.Context, tmpl func(string) string, data *templates.ExtendedData, as ...*types.Alert) (string, error) {
 	var record kafkaRecord
-	if err := kn.buildKafkaRecord(ctx, &record, tmpl, as...); err != nil {
+	if err := kn.buildKafkaRecord(ctx, &record, tmpl, data, as...); err != nil {
 		return "", err
 	}
-	records := kafkaBody{
+	var records any = kafkaBody{
 		Records: []kafkaRecordEnvelope{
 			{Value: record},
 		},
 	}
+	if kn.settings.Encoding == encodingProtobuf {
+		value, err := buildProtobufValue(ctx, data, record)
+		if err != nil {
+			return "", err
+		}
+		records = kafkaBinaryBody{
+			Records: []kafkaBinaryRecordEnvelope{
+				{Value: value},
+			},
+		}
+	}
 	body, err := json.Marshal(records)
 	if err != nil {
 		return "", err
@@ -216,17 +374,39 @@ func (kn *Notifier) buildV2Body(ctx context.Context, tmpl func(string) string, a
 	return string(body), nil
 }
 
-func (kn *Notifier) buildV3Body(ctx context.Context, tmpl func(string) string, as ...*types.Alert) (string, error) {
+func (kn *Notifier) buildV3Body(ctx context.Context, tmpl func(string) string, data *templates.ExtendedData, as ...*types.Alert) (string, error) {
 	var record kafkaRecord
-	if err := kn.buildKafkaRecord(ctx, &record, tmpl, as...); err != nil {
+	if err := kn.buildKafkaRecord(ctx, &record, tmpl, data, as...); err != nil {
 		return "", err
 	}
-	records := map[string]kafkaV3Record{
-		"value": {
+	headers := buildV3Headers(tmpl, kn.settings.Headers)
+	var records any = kafkaV3Body{
+		Headers: headers,
+		Value: kafkaV3Record{
 			Type: "JSON",
 			Data: record,
 		},
 	}
+	if kn.settings.Encoding == encodingProtobuf {
+		value, err := buildProtobufValue(ctx, data, record)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := kn.settings.Headers["content-type"]; !ok {
+			// Consumers read the content type of the records from the header, as the values are binary.
+			headers = append(headers, kafkaV3Header{
+				Name:  "content-type",
+				Value: base64.StdEncoding.EncodeToString([]byte(alertpb.ContentType)),
+			})
+		}
+		records = kafkaV3BinaryBody{
+			Headers: headers,
+			Value: kafkaV3BinaryRecord{
+				Type: "BINARY",
+				Data: value,
+			},
+		}
+	}
 	body, err := json.Marshal(records)
 	if err != nil {
 		return "", err
@@ -234,19 +414,38 @@ func (kn *Notifier) buildV3Body(ctx context.Context, tmpl func(string) string, a
 	return string(body), nil
 }
 
-func (kn *Notifier) buildKafkaRecord(ctx context.Context, record *kafkaRecord, tmpl func(string) string, as ...*types.Alert) error {
+// buildV3Headers returns the templated record headers sorted by name.
+func buildV3Headers(tmpl func(string) string, headers map[string]string) []kafkaV3Header {
+	if len(headers) == 0 {
+		return nil
+	}
+	res := make([]kafkaV3Header, 0, len(headers))
+	for name, value := range headers {
+		res = append(res, kafkaV3Header{
+			Name:  name,
+			Value: base64.StdEncoding.EncodeToString([]byte(tmpl(value))),
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+func (kn *Notifier) buildKafkaRecord(ctx context.Context, record *kafkaRecord, tmpl func(string) string, data *templates.ExtendedData, as ...*types.Alert) error {
+	l := logging.FromContext(ctx, kn.log)
 	record.Client = "Grafana"
 	record.Description = tmpl(kn.settings.Description)
 	record.Details = tmpl(kn.settings.Details)
 
 	state := buildState(as...)
-	kn.log.Debug("notifying Kafka", "alert_state", state)
+	l.Debug("notifying Kafka", "alert_state", state)
 	record.AlertState = state
 
-	ruleURL := receivers.JoinURLPath(kn.tmpl.ExternalURL.String(), "/alerting/list", kn.log)
+	ruleURL := receivers.JoinURLPath(kn.tmpl.ExternalURL.String(), "/alerting/list", l)
 	record.ClientURL = ruleURL
 
-	contexts := buildContextImages(ctx, kn.log, kn.images, as...)
+	contexts := buildContextImages(ctx, l, kn.images, data, as...)
 	if len(contexts) > 0 {
 		record.Contexts = contexts
 	}
@@ -268,11 +467,13 @@ func buildState(as ...*types.Alert) receivers.AlertStateType {
 	return receivers.AlertStateAlerting
 }
 
-func buildContextImages(ctx context.Context, l logging.Logger, imageProvider images.Provider, as ...*types.Alert) []kafkaContext {
+// buildContextImages returns the contexts of the images of the alerts, and sets the image URLs of the alerts of data.
+func buildContextImages(ctx context.Context, l logging.Logger, imageProvider images.Provider, data *templates.ExtendedData, as ...*types.Alert) []kafkaContext {
 	var contexts []kafkaContext
 	_ = images.WithStoredImages(ctx, l, imageProvider,
-		func(_ int, image images.Image) error {
+		func(index int, image images.Image) error {
 			if image.URL != "" {
+				data.Alerts[index].ImageURL = image.URL
 				contexts = append(contexts, kafkaContext{
 					Type:   "image",
 					Source: image.URL,
@@ -282,3 +483,19 @@ func buildContextImages(ctx context.Context, l logging.Logger, imageProvider ima
 		}, as...)
 	return contexts
 }
+
+// buildProtobufValue returns the record and the alerts of the notification encoded as an alertpb.AlertGroup, in
+// base64 as the REST proxy expects for binary values.
+func buildProtobufValue(ctx context.Context, data *templates.ExtendedData, record kafkaRecord) (string, error) {
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	g := alertpb.NewAlertGroup(data)
+	g.Version = "1"
+	g.GroupKey = groupKey.String()
+	g.State = string(record.AlertState)
+	g.Title = record.Description
+	g.Message = record.Details
+	return base64.StdEncoding.EncodeToString(g.Marshal()), nil
+}
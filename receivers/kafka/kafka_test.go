@@ -2,9 +2,14 @@ package kafka
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"net/url"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -14,9 +19,12 @@ import (
 	images2 "github.com/grafana/alerting/images"
 	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/alertpb"
 	"github.com/grafana/alerting/templates"
 )
 
+var update = flag.Bool("update", false, "update the golden files of the tests")
+
 func TestNotify(t *testing.T) {
 	tmpl := templates.ForTests(t)
 
@@ -415,3 +423,214 @@ func TestNotify(t *testing.T) {
 		})
 	}
 }
+
+type fakeResponse struct {
+	statusCode int
+	body       string
+}
+
+// fakeRESTProxy is a WebhookSender that validates the requests with the responses configured for their URLs.
+type fakeRESTProxy struct {
+	responses map[string]fakeResponse
+	calls     []receivers.SendWebhookSettings
+}
+
+func (p *fakeRESTProxy) SendWebhook(_ context.Context, cmd *receivers.SendWebhookSettings) error {
+	p.calls = append(p.calls, *cmd)
+	res, ok := p.responses[cmd.URL]
+	if !ok {
+		res = fakeResponse{statusCode: 404, body: `{"error_code": 404, "message": "HTTP 404 Not Found"}`}
+	}
+	if cmd.Validation != nil {
+		return cmd.Validation([]byte(res.body), res.statusCode)
+	}
+	return nil
+}
+
+func TestNotify_APIV3Discovery(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	const (
+		clustersURL = "http://localhost:882/v3/clusters"
+		v2URL       = "http://localhost:882/topics/myTopic"
+		v3URL       = "http://localhost:882/v3/clusters/lkc-abcd/topics/myTopic/records"
+		clusters    = `{"kind": "KafkaClusterList", "data": [{"kind": "KafkaCluster", "cluster_id": "lkc-abcd"}]}`
+		delivered   = `{"error_code": 200, "cluster_id": "lkc-abcd", "topic_name": "myTopic", "partition_id": 0, "offset": 1}`
+	)
+
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+	newNotifier := func(settings Config, proxy *fakeRESTProxy) *Notifier {
+		settings.Endpoint = "http://localhost:882"
+		settings.Topic = "myTopic"
+		settings.Description = templates.DefaultMessageTitleEmbed
+		settings.Details = templates.DefaultMessageEmbed
+		return &Notifier{
+			Base:     &receivers.Base{},
+			log:      &logging.FakeLogger{},
+			ns:       proxy,
+			tmpl:     tmpl,
+			settings: settings,
+			images:   &images2.UnavailableProvider{},
+		}
+	}
+	notifyTwice := func(t *testing.T, n *Notifier) {
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		for i := 0; i < 2; i++ {
+			ok, err := n.Notify(ctx, alert)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+	}
+	urls := func(proxy *fakeRESTProxy) []string {
+		var res []string
+		for _, c := range proxy.calls {
+			res = append(res, c.HTTPMethod+" "+c.URL)
+		}
+		return res
+	}
+
+	t.Run("v3 discovers the cluster ID once", func(t *testing.T) {
+		proxy := &fakeRESTProxy{responses: map[string]fakeResponse{
+			clustersURL: {statusCode: 200, body: clusters},
+			v3URL:       {statusCode: 200, body: delivered},
+		}}
+		notifyTwice(t, newNotifier(Config{APIVersion: apiVersionV3}, proxy))
+		require.Equal(t, []string{"GET " + clustersURL, "POST " + v3URL, "POST " + v3URL}, urls(proxy))
+	})
+
+	t.Run("auto uses v3 if it is available", func(t *testing.T) {
+		proxy := &fakeRESTProxy{responses: map[string]fakeResponse{
+			clustersURL: {statusCode: 200, body: clusters},
+			v3URL:       {statusCode: 200, body: delivered},
+		}}
+		notifyTwice(t, newNotifier(Config{APIVersion: apiVersionAuto}, proxy))
+		require.Equal(t, []string{"GET " + clustersURL, "POST " + v3URL, "POST " + v3URL}, urls(proxy))
+	})
+
+	t.Run("auto falls back to v2 if v3 is not available", func(t *testing.T) {
+		proxy := &fakeRESTProxy{}
+		notifyTwice(t, newNotifier(Config{APIVersion: apiVersionAuto}, proxy))
+		require.Equal(t, []string{"GET " + clustersURL, "POST " + v2URL, "POST " + v2URL}, urls(proxy))
+	})
+
+	t.Run("v3 fails if the cluster ID cannot be discovered", func(t *testing.T) {
+		proxy := &fakeRESTProxy{responses: map[string]fakeResponse{
+			clustersURL: {statusCode: 200, body: `{"kind": "KafkaClusterList", "data": []}`},
+		}}
+		_, err := newNotifier(Config{APIVersion: apiVersionV3}, proxy).Notify(context.Background(), alert)
+		require.EqualError(t, err, "failed to discover kafka cluster id: kafka rest proxy has no clusters")
+	})
+
+	t.Run("v3 records have headers", func(t *testing.T) {
+		proxy := &fakeRESTProxy{responses: map[string]fakeResponse{
+			v3URL: {statusCode: 200, body: delivered},
+		}}
+		n := newNotifier(Config{
+			APIVersion:     apiVersionV3,
+			KafkaClusterID: "lkc-abcd",
+			Headers:        map[string]string{"source": "grafana", "alertname": `{{ .CommonLabels.alertname }}`},
+		}, proxy)
+		ok, err := n.Notify(notify.WithGroupKey(context.Background(), "alertname"), alert)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		var body kafkaV3Body
+		require.NoError(t, json.Unmarshal([]byte(proxy.calls[0].Body), &body))
+		require.Equal(t, []kafkaV3Header{
+			{Name: "alertname", Value: base64.StdEncoding.EncodeToString([]byte("alert1"))},
+			{Name: "source", Value: base64.StdEncoding.EncodeToString([]byte("grafana"))},
+		}, body.Headers)
+	})
+
+	t.Run("v3 fails if the record was not delivered", func(t *testing.T) {
+		proxy := &fakeRESTProxy{responses: map[string]fakeResponse{
+			v3URL: {statusCode: 200, body: `{"error_code": 40403, "message": "This server does not host this topic-partition."}`},
+		}}
+		n := newNotifier(Config{APIVersion: apiVersionV3, KafkaClusterID: "lkc-abcd"}, proxy)
+		ok, err := n.Notify(notify.WithGroupKey(context.Background(), "alertname"), alert)
+		require.False(t, ok)
+		require.ErrorContains(t, err, "failed to publish message to Kafka")
+	})
+}
+
+func TestNotify_Protobuf(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{"ann1": "annv1", "__alertImageToken__": "test-image-1"},
+			StartsAt:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+
+	newNotifier := func(apiVersion string) (*Notifier, *receivers.NotificationServiceMock) {
+		sender := receivers.MockNotificationService()
+		return &Notifier{
+			Base:   &receivers.Base{},
+			log:    &logging.FakeLogger{},
+			ns:     sender,
+			tmpl:   tmpl,
+			images: images2.NewFakeProvider(1),
+			settings: Config{
+				Endpoint:       "http://localhost",
+				Topic:          "sometopic",
+				Description:    "customDescription",
+				Details:        "customDetails",
+				APIVersion:     apiVersion,
+				KafkaClusterID: "lkc-abcd",
+				Headers:        map[string]string{"source": "grafana"},
+				Encoding:       encodingProtobuf,
+			},
+		}, sender
+	}
+
+	// The records of both API versions are the same.
+	golden := func(t *testing.T, value string) {
+		b, err := base64.StdEncoding.DecodeString(value)
+		require.NoError(t, err)
+		if *update {
+			require.NoError(t, os.WriteFile("testdata/protobuf.pb", b, 0o644))
+		}
+		expected, err := os.ReadFile("testdata/protobuf.pb")
+		require.NoError(t, err)
+		require.Equal(t, expected, b)
+	}
+
+	t.Run("v2", func(t *testing.T) {
+		n, sender := newNotifier(apiVersionV2)
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Equal(t, "application/vnd.kafka.binary.v2+json", sender.Webhook.HTTPHeader["Content-Type"])
+		var body kafkaBinaryBody
+		require.NoError(t, json.Unmarshal([]byte(sender.Webhook.Body), &body))
+		require.Len(t, body.Records, 1)
+		golden(t, body.Records[0].Value)
+	})
+
+	t.Run("v3", func(t *testing.T) {
+		n, sender := newNotifier(apiVersionV3)
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		var body kafkaV3BinaryBody
+		require.NoError(t, json.Unmarshal([]byte(sender.Webhook.Body), &body))
+		require.Equal(t, "BINARY", body.Value.Type)
+		require.Equal(t, []kafkaV3Header{
+			{Name: "source", Value: base64.StdEncoding.EncodeToString([]byte("grafana"))},
+			{Name: "content-type", Value: base64.StdEncoding.EncodeToString([]byte(alertpb.ContentType))},
+		}, body.Headers)
+		golden(t, body.Value.Data)
+	})
+}
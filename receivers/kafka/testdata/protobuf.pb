@@ -0,0 +1,10 @@
+
+1	alertname"firing*alerting2customDescription:customDetailsB
+firing
+	alertnamealert1
+lbl1val1
+ann1annv1"ȱ:fac0861a85de433aBihttp://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1b(https://www.example.com/test-image-1.jpgZ
+	alertnameb
+	alertnamealert1b
+lbl1val1j
+ann1annv1rhttp://localhost
\ No newline at end of file
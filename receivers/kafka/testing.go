@@ -9,7 +9,15 @@ const FullValidConfigForTesting = `{
 	"username": "test-user", 
 	"password": "password", 
 	"apiVersion": "v2", 
-	"kafkaClusterId": "12345"
+	"kafkaClusterId": "12345",
+	"headers": {"source": "grafana"},
+	"encoding": "json",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
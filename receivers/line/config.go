@@ -13,6 +13,21 @@ type Config struct {
 	Token       string `json:"token,omitempty" yaml:"token,omitempty"`
 	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
 	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// SilentSeverities are the values of the severity label of the alerts whose notifications do not notify the users
+	// of LINE. Notifications are silent if all their firing alerts have one of the severities, or all their alerts
+	// if none is firing.
+	SilentSeverities []string `json:"silentSeverities,omitempty" yaml:"silentSeverities,omitempty"`
+	// Stickers are the stickers sent with the notifications by the severity of their alerts. Notifications have the
+	// sticker of the first severity of one of their firing alerts, or of their alerts if none is firing.
+	Stickers  []Sticker            `json:"stickers,omitempty" yaml:"stickers,omitempty"`
+	TLSConfig *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
+}
+
+// Sticker is a sticker of LINE, see https://developers.line.biz/en/docs/messaging-api/sticker-list/.
+type Sticker struct {
+	Severity  string `json:"severity,omitempty" yaml:"severity,omitempty"`
+	PackageID int    `json:"packageId,omitempty" yaml:"packageId,omitempty"`
+	StickerID int    `json:"stickerId,omitempty" yaml:"stickerId,omitempty"`
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -31,5 +46,17 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	if settings.Description == "" {
 		settings.Description = templates.DefaultMessageEmbed
 	}
+	for i, sticker := range settings.Stickers {
+		if sticker.Severity == "" {
+			return Config{}, fmt.Errorf("severity of sticker %d must be present", i)
+		}
+		if sticker.PackageID <= 0 || sticker.StickerID <= 0 {
+			return Config{}, fmt.Errorf("sticker of severity %q must have a package ID and a sticker ID", sticker.Severity)
+		}
+	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
 	return settings, nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
 	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
@@ -33,6 +34,16 @@ func TestNewConfig(t *testing.T) {
 			settings:          `{ "token": "" }`,
 			expectedInitError: `could not find token in settings`,
 		},
+		{
+			name:              "Error if sticker has no severity",
+			settings:          `{ "token": "test", "stickers": [{"packageId": 446, "stickerId": 1988}] }`,
+			expectedInitError: `severity of sticker 0 must be present`,
+		},
+		{
+			name:              "Error if sticker has no sticker ID",
+			settings:          `{ "token": "test", "stickers": [{"severity": "critical", "packageId": 446}] }`,
+			expectedInitError: `sticker of severity "critical" must have a package ID and a sticker ID`,
+		},
 		{
 			name:     "Minimal valid configuration",
 			settings: `{"token": "test"}`,
@@ -83,9 +94,17 @@ func TestNewConfig(t *testing.T) {
 			settings:       FullValidConfigForTesting,
 			secureSettings: map[string][]byte{},
 			expectedConfig: Config{
-				Title:       "test-title",
-				Description: "test-description",
-				Token:       "test",
+				Title:            "test-title",
+				Description:      "test-description",
+				Token:            "test",
+				SilentSeverities: []string{"info"},
+				Stickers:         []Sticker{{Severity: "critical", PackageID: 446, StickerID: 1988}},
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 		{
@@ -93,9 +112,17 @@ func TestNewConfig(t *testing.T) {
 			settings:       FullValidConfigForTesting,
 			secureSettings: receiversTesting.ReadSecretsJSONForTesting(FullValidSecretsForTesting),
 			expectedConfig: Config{
-				Title:       "test-title",
-				Description: "test-description",
-				Token:       "test-secret-token",
+				Title:            "test-title",
+				Description:      "test-description",
+				Token:            "test-secret-token",
+				SilentSeverities: []string{"info"},
+				Stickers:         []Sticker{{Severity: "critical", PackageID: 446, StickerID: 1988}},
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 	}
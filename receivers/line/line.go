@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"slices"
+	"strconv"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/receivers"
@@ -44,7 +47,8 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify send an alert notification to LINE
 func (ln *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	ln.log.Debug("executing line notification", "notification", ln.Name)
+	l := logging.FromContext(ctx, ln.log)
+	l.Debug("executing line notification")
 
 	body, err := ln.buildLineMessage(ctx, as...)
 	if err != nil {
@@ -53,7 +57,19 @@ func (ln *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 
 	form := url.Values{}
 	form.Add("message", body)
+	severities := severitiesOf(as...)
+	if ln.silent(severities) {
+		form.Add("notificationDisabled", "true")
+	}
+	if sticker, ok := ln.sticker(severities); ok {
+		form.Add("stickerPackageId", strconv.Itoa(sticker.PackageID))
+		form.Add("stickerId", strconv.Itoa(sticker.StickerID))
+	}
 
+	tlsConfig, err := receivers.ClientTLSConfig(ln.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        APIURL,
 		HTTPMethod: "POST",
@@ -61,11 +77,12 @@ func (ln *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 			"Authorization": fmt.Sprintf("Bearer %s", ln.settings.Token),
 			"Content-Type":  "application/x-www-form-urlencoded;charset=UTF-8",
 		},
-		Body: form.Encode(),
+		Body:      form.Encode(),
+		TLSConfig: tlsConfig,
 	}
 
 	if err := ln.ns.SendWebhook(ctx, cmd); err != nil {
-		ln.log.Error("failed to send notification to LINE", "error", err, "body", body)
+		l.Error("failed to send notification to LINE", "error", err, "body", body)
 		return false, err
 	}
 
@@ -77,8 +94,9 @@ func (ln *Notifier) SendResolved() bool {
 }
 
 func (ln *Notifier) buildLineMessage(ctx context.Context, as ...*types.Alert) (string, error) {
+	l := logging.FromContext(ctx, ln.log)
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, ln.tmpl, as, ln.log, &tmplErr)
+	tmpl, _ := templates.TmplText(ctx, ln.tmpl, as, l, &tmplErr)
 
 	body := fmt.Sprintf(
 		"%s\n%s",
@@ -86,7 +104,7 @@ func (ln *Notifier) buildLineMessage(ctx context.Context, as ...*types.Alert) (s
 		tmpl(ln.settings.Description),
 	)
 	if tmplErr != nil {
-		ln.log.Warn("failed to template Line message", "error", tmplErr.Error())
+		l.Warn("failed to template Line message", "error", tmplErr.Error())
 	}
 
 	message, truncated := receivers.TruncateInRunes(body, lineMaxMessageLenRunes)
@@ -95,7 +113,43 @@ func (ln *Notifier) buildLineMessage(ctx context.Context, as ...*types.Alert) (s
 		if err != nil {
 			return "", err
 		}
-		ln.log.Warn("Truncated message", "alert", key, "max_runes", lineMaxMessageLenRunes)
+		l.Warn("Truncated message", "alert", key, "max_runes", lineMaxMessageLenRunes)
 	}
 	return message, nil
 }
+
+// severitiesOf returns the values of the severity label of the firing alerts, or of all the alerts if none is firing.
+func severitiesOf(as ...*types.Alert) []string {
+	firing := types.Alerts(as...).Status() == model.AlertFiring
+	severities := make([]string, 0, len(as))
+	for _, a := range as {
+		if firing && a.Resolved() {
+			continue
+		}
+		severities = append(severities, string(a.Labels["severity"]))
+	}
+	return severities
+}
+
+// silent returns whether the notification of alerts of the severities does not notify the users.
+func (ln *Notifier) silent(severities []string) bool {
+	if len(ln.settings.SilentSeverities) == 0 || len(severities) == 0 {
+		return false
+	}
+	for _, s := range severities {
+		if !slices.Contains(ln.settings.SilentSeverities, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// sticker returns the sticker of the first severity that one of the severities of the alerts has.
+func (ln *Notifier) sticker(severities []string) (Sticker, bool) {
+	for _, sticker := range ln.settings.Stickers {
+		if slices.Contains(severities, sticker.Severity) {
+			return sticker, true
+		}
+	}
+	return Sticker{}, false
+}
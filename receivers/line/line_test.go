@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -139,6 +140,73 @@ func TestNotify(t *testing.T) {
 	}
 }
 
+func TestNotify_Severities(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+	settings := Config{
+		Title:            "title",
+		Description:      "description",
+		Token:            "sometoken",
+		SilentSeverities: []string{"info", "low"},
+		Stickers: []Sticker{
+			{Severity: "critical", PackageID: 446, StickerID: 1988},
+			{Severity: "warning", PackageID: 446, StickerID: 2005},
+		},
+	}
+	alert := func(severity string, resolved bool) *types.Alert {
+		a := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "severity": model.LabelValue(severity)}}}
+		if resolved {
+			a.EndsAt = time.Now().Add(-time.Minute)
+		}
+		return a
+	}
+
+	cases := []struct {
+		name   string
+		alerts []*types.Alert
+		expMsg string
+	}{{
+		name:   "Alerts without stickers notify the users",
+		alerts: []*types.Alert{alert("major", false)},
+		expMsg: "message=title%0Adescription",
+	}, {
+		name:   "Alerts of silent severities do not notify the users",
+		alerts: []*types.Alert{alert("info", false), alert("low", false)},
+		expMsg: "message=title%0Adescription&notificationDisabled=true",
+	}, {
+		name:   "Alerts of other severities notify the users",
+		alerts: []*types.Alert{alert("info", false), alert("warning", false)},
+		expMsg: "message=title%0Adescription&stickerId=2005&stickerPackageId=446",
+	}, {
+		name:   "Notifications have the sticker of the first severity",
+		alerts: []*types.Alert{alert("warning", false), alert("critical", false)},
+		expMsg: "message=title%0Adescription&stickerId=1988&stickerPackageId=446",
+	}, {
+		name:   "Resolved alerts of firing notifications are ignored",
+		alerts: []*types.Alert{alert("info", false), alert("critical", true)},
+		expMsg: "message=title%0Adescription&notificationDisabled=true",
+	}, {
+		name:   "Resolved notifications have the severities of their alerts",
+		alerts: []*types.Alert{alert("critical", true)},
+		expMsg: "message=title%0Adescription&stickerId=1988&stickerPackageId=446",
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			webhookSender := receivers.MockNotificationService()
+			pn := New(settings, receivers.Metadata{}, tmpl, webhookSender, &logging.FakeLogger{})
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ok, err := pn.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, c.expMsg, webhookSender.Webhook.Body)
+		})
+	}
+}
+
 func TestTruncatedNotify(t *testing.T) {
 	tmpl := templates.ForTests(t)
 
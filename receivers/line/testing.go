@@ -4,7 +4,15 @@ package line
 const FullValidConfigForTesting = `{
 	"token": "test", 
 	"title": "test-title", 
-	"description": "test-description" 
+	"description": "test-description",
+	"silentSeverities": ["info"],
+	"stickers": [{"severity": "critical", "packageId": 446, "stickerId": 1988}],
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
@@ -0,0 +1,131 @@
+// Package markdown converts the markdown of notification templates to the markdown dialects of chat providers, so
+// that one template renders acceptably in all of them.
+//
+// The input is a subset of CommonMark: **bold** or __bold__, *italic*, ~~strikethrough~~, `code`, fenced code blocks,
+// [links](https://example.com), # headings, - or * list items and > quotes. Code is not converted.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Dialect is the markdown dialect of a chat provider.
+type Dialect string
+
+const (
+	// DialectSlack is the mrkdwn of Slack messages.
+	DialectSlack Dialect = "slack"
+	// DialectTeams is the markdown of the text blocks of Microsoft Teams adaptive cards, which has no headings,
+	// strikethrough, code or quotes.
+	DialectTeams Dialect = "teams"
+	// DialectWebex is the markdown of Webex messages, which supports the whole subset.
+	DialectWebex Dialect = "webex"
+)
+
+var (
+	headingRegexp = regexp.MustCompile(`^#{1,6}[ \t]+(.*?)[ \t]*#*$`)
+	listRegexp    = regexp.MustCompile(`^([ \t]*)[-*+][ \t]+(.*)$`)
+	quoteRegexp   = regexp.MustCompile(`^>[ \t]?(.*)$`)
+	// inlineRegexp matches, in order, links, bold, strikethrough and italic text.
+	inlineRegexp = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)|\*\*(.+?)\*\*|__(.+?)__|~~(.+?)~~|\*([^*\s](?:[^*]*[^*\s])?)\*`)
+)
+
+// Convert converts markdown to the dialect. Text in other dialects, or in unknown dialects, is returned as is.
+func Convert(text string, d Dialect) string {
+	if d != DialectSlack && d != DialectTeams {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	res := make([]string, 0, len(lines))
+	inFence := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			// Teams has no code blocks, their lines are kept as they are.
+			if d != DialectTeams {
+				res = append(res, line)
+			}
+			continue
+		}
+		if inFence {
+			res = append(res, line)
+			continue
+		}
+		res = append(res, convertLine(line, d))
+	}
+	return strings.Join(res, "\n")
+}
+
+func convertLine(line string, d Dialect) string {
+	if m := headingRegexp.FindStringSubmatch(line); m != nil {
+		return bold(convertInline(m[1], d), d)
+	}
+	if m := listRegexp.FindStringSubmatch(line); m != nil {
+		bullet := "- "
+		if d == DialectSlack {
+			bullet = "• "
+		}
+		return m[1] + bullet + convertInline(m[2], d)
+	}
+	if m := quoteRegexp.FindStringSubmatch(line); m != nil && d == DialectTeams {
+		return convertInline(m[1], d)
+	}
+	return convertInline(line, d)
+}
+
+// convertInline converts the text outside of code spans.
+func convertInline(text string, d Dialect) string {
+	parts := strings.Split(text, "`")
+	for i := range parts {
+		// Parts with an odd index are code spans, unless the last backtick is not closed.
+		if i%2 == 1 && i < len(parts)-1 {
+			continue
+		}
+		parts[i] = inlineRegexp.ReplaceAllStringFunc(parts[i], func(s string) string {
+			return convertSpan(s, d)
+		})
+	}
+	if d == DialectTeams {
+		// Teams has no code spans, so the backticks of closed code spans are removed.
+		var b strings.Builder
+		unclosed := len(parts)%2 == 0
+		for i, p := range parts {
+			if i == len(parts)-1 && unclosed {
+				b.WriteString("`")
+			}
+			b.WriteString(p)
+		}
+		return b.String()
+	}
+	return strings.Join(parts, "`")
+}
+
+func convertSpan(s string, d Dialect) string {
+	m := inlineRegexp.FindStringSubmatch(s)
+	switch {
+	case m[1] != "":
+		if d == DialectSlack {
+			return "<" + m[2] + "|" + convertInline(m[1], d) + ">"
+		}
+		return "[" + convertInline(m[1], d) + "](" + m[2] + ")"
+	case m[3] != "":
+		return bold(convertInline(m[3], d), d)
+	case m[4] != "":
+		return bold(convertInline(m[4], d), d)
+	case m[5] != "":
+		if d == DialectSlack {
+			return "~" + convertInline(m[5], d) + "~"
+		}
+		return convertInline(m[5], d)
+	default:
+		return "_" + convertInline(m[6], d) + "_"
+	}
+}
+
+func bold(s string, d Dialect) string {
+	if d == DialectSlack {
+		return "*" + s + "*"
+	}
+	return "**" + s + "**"
+}
@@ -0,0 +1,56 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		name  string
+		text  string
+		slack string
+		teams string
+	}{
+		{
+			name:  "emphasis",
+			text:  "**bold**, __bold__, *italic* and ~~struck~~ with snake_case_names",
+			slack: "*bold*, *bold*, _italic_ and ~struck~ with snake_case_names",
+			teams: "**bold**, **bold**, _italic_ and struck with snake_case_names",
+		},
+		{
+			name:  "nested emphasis and links",
+			text:  "See **[the *dashboard*](http://localhost/d/abcd)** now",
+			slack: "See *<http://localhost/d/abcd|the _dashboard_>* now",
+			teams: "See **[the _dashboard_](http://localhost/d/abcd)** now",
+		},
+		{
+			name:  "code spans are not converted",
+			text:  "Run `**not bold**` and `x` *then* wait`",
+			slack: "Run `**not bold**` and `x` _then_ wait`",
+			teams: "Run **not bold** and x _then_ wait`",
+		},
+		{
+			name:  "headings, lists and quotes",
+			text:  "## Firing *alerts* ##\n- alert1\n  * alert2\n> quoted **text**\n2 * 3 * 4",
+			slack: "*Firing _alerts_*\n• alert1\n  • alert2\n> quoted *text*\n2 * 3 * 4",
+			teams: "**Firing _alerts_**\n- alert1\n  - alert2\nquoted **text**\n2 * 3 * 4",
+		},
+		{
+			name:  "fenced code blocks are not converted",
+			text:  "Logs:\n```\n**error** in *main*\n```\n**done**",
+			slack: "Logs:\n```\n**error** in *main*\n```\n*done*",
+			teams: "Logs:\n**error** in *main*\n**done**",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.slack, Convert(c.text, DialectSlack))
+			require.Equal(t, c.teams, Convert(c.text, DialectTeams))
+			// Webex supports the whole subset.
+			require.Equal(t, c.text, Convert(c.text, DialectWebex))
+		})
+	}
+}
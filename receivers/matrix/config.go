@@ -0,0 +1,82 @@
+package matrix
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+type MsgType string
+
+const (
+	// MsgTypeNotice sends the messages as notices, which clients show less prominently and bots do not reply to.
+	MsgTypeNotice MsgType = "m.notice"
+	MsgTypeText   MsgType = "m.text"
+)
+
+// IsValid checks matrix message type
+func (mt MsgType) IsValid() bool {
+	return mt == MsgTypeNotice || mt == MsgTypeText
+}
+
+type Config struct {
+	// HomeserverURL is the base URL of the client-server API of the homeserver, such as https://matrix.example.com.
+	HomeserverURL string `json:"homeserverUrl,omitempty" yaml:"homeserverUrl,omitempty"`
+	// RoomID is the ID of the room, such as !abc:example.com. The user of the access token must be a member of the room.
+	RoomID      string  `json:"roomId,omitempty" yaml:"roomId,omitempty"`
+	AccessToken string  `json:"accessToken,omitempty" yaml:"accessToken,omitempty"`
+	MessageType MsgType `json:"msgType,omitempty" yaml:"msgType,omitempty"`
+	Title       string  `json:"title,omitempty" yaml:"title,omitempty"`
+	Message     string  `json:"message,omitempty" yaml:"message,omitempty"`
+	// DisableThreading sends all messages to the main timeline of the room, instead of the threads of alert groups.
+	DisableThreading bool                 `json:"disableThreading,omitempty" yaml:"disableThreading,omitempty"`
+	TLSConfig        *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
+}
+
+func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
+	var settings Config
+	err := json.Unmarshal(jsonData, &settings)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	if settings.HomeserverURL == "" {
+		return Config{}, errors.New("could not find homeserverUrl property in settings")
+	}
+	u, err := url.Parse(settings.HomeserverURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Config{}, fmt.Errorf("invalid homeserverUrl %q, must be an absolute HTTP URL", settings.HomeserverURL)
+	}
+	settings.HomeserverURL = strings.TrimSuffix(settings.HomeserverURL, "/")
+	if settings.RoomID == "" {
+		return Config{}, errors.New("could not find roomId property in settings")
+	}
+	if !strings.HasPrefix(settings.RoomID, "!") {
+		return Config{}, fmt.Errorf("invalid roomId %q, must be the ID of a room, such as !abc:example.com", settings.RoomID)
+	}
+	settings.AccessToken = decryptFn("accessToken", settings.AccessToken)
+	if settings.AccessToken == "" {
+		return Config{}, errors.New("could not find accessToken property in settings")
+	}
+	if settings.MessageType == "" {
+		settings.MessageType = MsgTypeNotice
+	}
+	if !settings.MessageType.IsValid() {
+		return Config{}, fmt.Errorf("invalid message type %q, must be %q or %q", settings.MessageType, MsgTypeNotice, MsgTypeText)
+	}
+	if settings.Title == "" {
+		settings.Title = templates.DefaultMessageTitleEmbed
+	}
+	if settings.Message == "" {
+		settings.Message = templates.DefaultMessageEmbed
+	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
+	return settings, nil
+}
@@ -0,0 +1,136 @@
+package matrix
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/receivers"
+	receiversTesting "github.com/grafana/alerting/receivers/testing"
+	"github.com/grafana/alerting/templates"
+)
+
+func TestNewConfig(t *testing.T) {
+	cases := []struct {
+		name              string
+		settings          string
+		secrets           map[string][]byte
+		expectedConfig    Config
+		expectedInitError string
+	}{
+		{
+			name:              "Error if empty",
+			settings:          "",
+			expectedInitError: `failed to unmarshal settings`,
+		},
+		{
+			name:              "Error if empty JSON object",
+			settings:          `{}`,
+			expectedInitError: `could not find homeserverUrl property in settings`,
+		},
+		{
+			name:              "Error if homeserver URL is not an HTTP URL",
+			settings:          `{"homeserverUrl": "matrix.example.com", "roomId": "!room:example.com", "accessToken": "token"}`,
+			expectedInitError: `invalid homeserverUrl "matrix.example.com"`,
+		},
+		{
+			name:              "Error if room ID is missing",
+			settings:          `{"homeserverUrl": "https://matrix.example.com", "accessToken": "token"}`,
+			expectedInitError: `could not find roomId property in settings`,
+		},
+		{
+			name:              "Error if room ID is an alias",
+			settings:          `{"homeserverUrl": "https://matrix.example.com", "roomId": "#alerts:example.com", "accessToken": "token"}`,
+			expectedInitError: `invalid roomId "#alerts:example.com"`,
+		},
+		{
+			name:              "Error if access token is missing",
+			settings:          `{"homeserverUrl": "https://matrix.example.com", "roomId": "!room:example.com"}`,
+			expectedInitError: `could not find accessToken property in settings`,
+		},
+		{
+			name:              "Error if message type is invalid",
+			settings:          `{"homeserverUrl": "https://matrix.example.com", "roomId": "!room:example.com", "accessToken": "token", "msgType": "m.emote"}`,
+			expectedInitError: `invalid message type "m.emote"`,
+		},
+		{
+			name:     "Minimal valid configuration",
+			settings: `{"homeserverUrl": "https://matrix.example.com/", "roomId": "!room:example.com", "accessToken": "token"}`,
+			expectedConfig: Config{
+				HomeserverURL: "https://matrix.example.com",
+				RoomID:        "!room:example.com",
+				AccessToken:   "token",
+				MessageType:   MsgTypeNotice,
+				Title:         templates.DefaultMessageTitleEmbed,
+				Message:       templates.DefaultMessageEmbed,
+			},
+		},
+		{
+			name:     "Minimal valid configuration from secrets",
+			settings: `{"homeserverUrl": "https://matrix.example.com", "roomId": "!room:example.com"}`,
+			secrets: map[string][]byte{
+				"accessToken": []byte("token-secret"),
+			},
+			expectedConfig: Config{
+				HomeserverURL: "https://matrix.example.com",
+				RoomID:        "!room:example.com",
+				AccessToken:   "token-secret",
+				MessageType:   MsgTypeNotice,
+				Title:         templates.DefaultMessageTitleEmbed,
+				Message:       templates.DefaultMessageEmbed,
+			},
+		},
+		{
+			name:     "All supported fields",
+			settings: FullValidConfigForTesting,
+			expectedConfig: Config{
+				HomeserverURL:    "https://matrix.example.com",
+				RoomID:           "!test-room:example.com",
+				AccessToken:      "test-access-token",
+				MessageType:      MsgTypeText,
+				Title:            "Alerts firing: {{ len .Alerts.Firing }}",
+				Message:          "{{ len .Alerts.Firing }} alerts are firing, {{ len .Alerts.Resolved }} are resolved",
+				DisableThreading: true,
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
+			},
+		},
+		{
+			name:     "All supported fields with secrets",
+			settings: FullValidConfigForTesting,
+			secrets:  receiversTesting.ReadSecretsJSONForTesting(FullValidSecretsForTesting),
+			expectedConfig: Config{
+				HomeserverURL:    "https://matrix.example.com",
+				RoomID:           "!test-room:example.com",
+				AccessToken:      "test-access-token-secret",
+				MessageType:      MsgTypeText,
+				Title:            "Alerts firing: {{ len .Alerts.Firing }}",
+				Message:          "{{ len .Alerts.Firing }} alerts are firing, {{ len .Alerts.Resolved }} are resolved",
+				DisableThreading: true,
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := NewConfig(json.RawMessage(c.settings), receiversTesting.DecryptForTesting(c.secrets))
+
+			if c.expectedInitError != "" {
+				require.ErrorContains(t, err, c.expectedInitError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expectedConfig, actual)
+		})
+	}
+}
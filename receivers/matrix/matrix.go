@@ -0,0 +1,235 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+// txnCounter makes the transaction IDs of the messages sent at the same time unique.
+var txnCounter atomic.Uint64
+
+// Notifier is responsible for sending alert notifications to a Matrix room. It sends the messages with the
+// client-server API of the homeserver, as the user of the access token:
+// - https://spec.matrix.org/v1.11/client-server-api/#put_matrixclientv3roomsroomidsendeventtypetxnid
+// The first message of an alert group is posted to the room, and later messages of the group reply to it in a
+// thread. Once the group is resolved, the first message is edited to show the resolved alerts.
+type Notifier struct {
+	*receivers.Base
+	log      logging.Logger
+	ns       receivers.WebhookSender
+	tmpl     *templates.Template
+	settings Config
+	now      func() time.Time
+}
+
+func New(cfg Config, meta receivers.Metadata, template *templates.Template, sender receivers.WebhookSender, _ images.Provider, logger logging.Logger) *Notifier {
+	return &Notifier{
+		Base:     receivers.NewBase(meta),
+		log:      logger,
+		ns:       sender,
+		tmpl:     template,
+		settings: cfg,
+		now:      time.Now,
+	}
+}
+
+// content is the content of an m.room.message event.
+type content struct {
+	MsgType       MsgType    `json:"msgtype"`
+	Body          string     `json:"body"`
+	Format        string     `json:"format,omitempty"`
+	FormattedBody string     `json:"formatted_body,omitempty"`
+	NewContent    *content   `json:"m.new_content,omitempty"`
+	RelatesTo     *relatesTo `json:"m.relates_to,omitempty"`
+}
+
+type relatesTo struct {
+	RelType       string     `json:"rel_type,omitempty"`
+	EventID       string     `json:"event_id,omitempty"`
+	IsFallingBack bool       `json:"is_falling_back,omitempty"`
+	InReplyTo     *inReplyTo `json:"m.in_reply_to,omitempty"`
+}
+
+type inReplyTo struct {
+	EventID string `json:"event_id"`
+}
+
+// errorResponse is the response of the client-server API to requests that fail.
+type errorResponse struct {
+	ErrCode string `json:"errcode"`
+	Error   string `json:"error"`
+}
+
+// Notify sends the alert notification to Matrix.
+func (mn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, mn.log)
+	l.Info("sending matrix")
+
+	var tmplErr error
+	tmpl, _ := templates.TmplText(ctx, mn.tmpl, as, l, &tmplErr)
+
+	title := tmpl(mn.settings.Title)
+	message := tmpl(mn.settings.Message)
+	if tmplErr != nil {
+		l.Warn("failed to template Matrix message", "error", tmplErr.Error())
+	}
+	msg := mn.newContent(title, message)
+
+	if mn.settings.DisableThreading {
+		if _, err := mn.send(ctx, msg); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	key, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	storeKey := mn.UID + "/" + string(key)
+	store := ThreadStoreFromContext(ctx)
+
+	thread, ok, err := store.GetThread(ctx, storeKey)
+	if err != nil {
+		l.Warn("Failed to get the Matrix thread of the alert group, starting a new thread", "err", err)
+		ok = false
+	}
+	resolved := types.Alerts(as...).Status() == model.AlertResolved
+
+	switch {
+	case ok && resolved:
+		if _, err := mn.send(ctx, editOf(thread.RootEventID, msg)); err != nil {
+			return false, err
+		}
+	case ok:
+		reply := msg
+		reply.RelatesTo = &relatesTo{
+			RelType:       "m.thread",
+			EventID:       thread.RootEventID,
+			IsFallingBack: true,
+			InReplyTo:     &inReplyTo{EventID: thread.RootEventID},
+		}
+		if _, err := mn.send(ctx, reply); err != nil {
+			return false, err
+		}
+	default:
+		eventID, err := mn.send(ctx, msg)
+		if err != nil {
+			return false, err
+		}
+		thread = Thread{RootEventID: eventID}
+	}
+
+	if resolved {
+		err = store.DeleteThread(ctx, storeKey)
+	} else {
+		thread.UpdatedAt = mn.now()
+		err = store.SetThread(ctx, storeKey, thread)
+	}
+	if err != nil {
+		l.Warn("Failed to store the Matrix thread of the alert group", "err", err)
+	}
+	return true, nil
+}
+
+func (mn *Notifier) SendResolved() bool {
+	return !mn.GetDisableResolveMessage()
+}
+
+// newContent returns the content of a message with the title in bold, followed by the message. The HTML of the
+// formatted body is escaped, so that templates cannot inject markup.
+func (mn *Notifier) newContent(title, message string) content {
+	body := title
+	formatted := "<strong>" + html.EscapeString(title) + "</strong>"
+	if message != "" {
+		body += "\n\n" + message
+		formatted += "<br><br>" + strings.ReplaceAll(html.EscapeString(message), "\n", "<br>")
+	}
+	return content{
+		MsgType:       mn.settings.MessageType,
+		Body:          body,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatted,
+	}
+}
+
+// editOf returns an edit that replaces the content of the event with the message. Clients that do not support
+// edits show the message as a new message, prefixed with "* " as the specification recommends.
+func editOf(eventID string, msg content) content {
+	newContent := msg
+	return content{
+		MsgType:       msg.MsgType,
+		Body:          "* " + msg.Body,
+		Format:        msg.Format,
+		FormattedBody: "* " + msg.FormattedBody,
+		NewContent:    &newContent,
+		RelatesTo:     &relatesTo{RelType: "m.replace", EventID: eventID},
+	}
+}
+
+// send sends the message to the room, and returns the ID of its event.
+func (mn *Notifier) send(ctx context.Context, msg content) (string, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	tlsConfig, err := receivers.ClientTLSConfig(mn.settings.TLSConfig)
+	if err != nil {
+		return "", fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+
+	txnID := "grafana." + strconv.FormatInt(mn.now().UnixNano(), 10) + "." + strconv.FormatUint(txnCounter.Add(1), 10)
+	var eventID string
+	cmd := &receivers.SendWebhookSettings{
+		URL: mn.settings.HomeserverURL + "/_matrix/client/v3/rooms/" + url.PathEscape(mn.settings.RoomID) +
+			"/send/m.room.message/" + url.PathEscape(txnID),
+		Body:       string(body),
+		HTTPMethod: "PUT",
+		HTTPHeader: map[string]string{
+			"Content-Type":  "application/json",
+			"Authorization": "Bearer " + mn.settings.AccessToken,
+		},
+		Validation: func(body []byte, statusCode int) error {
+			if statusCode/100 != 2 {
+				var errResp errorResponse
+				if err := json.Unmarshal(body, &errResp); err == nil && errResp.ErrCode != "" {
+					return fmt.Errorf("unexpected status code %d: %s: %s", statusCode, errResp.ErrCode, errResp.Error)
+				}
+				return fmt.Errorf("unexpected status code %d", statusCode)
+			}
+			var resp struct {
+				EventID string `json:"event_id"`
+			}
+			if err := json.Unmarshal(body, &resp); err != nil {
+				return fmt.Errorf("failed to parse matrix response: %w", err)
+			}
+			if resp.EventID == "" {
+				return errors.New("matrix response has no event_id")
+			}
+			eventID = resp.EventID
+			return nil
+		},
+		TLSConfig: tlsConfig,
+	}
+	if err := mn.ns.SendWebhook(ctx, cmd); err != nil {
+		return "", fmt.Errorf("send notification to matrix: %w", err)
+	}
+	return eventID, nil
+}
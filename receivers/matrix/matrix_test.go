@@ -0,0 +1,173 @@
+package matrix
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+// fakeSender is a WebhookSender that responds to the requests with the configured response, or with new event IDs.
+type fakeSender struct {
+	calls      []receivers.SendWebhookSettings
+	statusCode int
+	body       string
+}
+
+func (s *fakeSender) SendWebhook(_ context.Context, cmd *receivers.SendWebhookSettings) error {
+	s.calls = append(s.calls, *cmd)
+	if s.statusCode != 0 {
+		return cmd.Validation([]byte(s.body), s.statusCode)
+	}
+	return cmd.Validation([]byte(`{"event_id":"$event`+strconv.Itoa(len(s.calls))+`"}`), 200)
+}
+
+func newNotifierForTesting(t *testing.T, cfg Config, sender receivers.WebhookSender) *Notifier {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	cfg.HomeserverURL = "https://matrix.example.com"
+	cfg.RoomID = "!room:example.com"
+	cfg.AccessToken = "token"
+	if cfg.MessageType == "" {
+		cfg.MessageType = MsgTypeNotice
+	}
+	n := New(cfg, receivers.Metadata{UID: t.Name()}, tmpl, sender, &images.UnavailableProvider{}, &logging.FakeLogger{})
+	n.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return n
+}
+
+func firingAlert() *types.Alert {
+	return &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "<val1>"},
+		StartsAt: time.Now(),
+		EndsAt:   time.Now().Add(time.Hour),
+	}}
+}
+
+func resolvedAlert() *types.Alert {
+	return &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "<val1>"},
+		StartsAt: time.Now().Add(-2 * time.Hour),
+		EndsAt:   time.Now().Add(-time.Hour),
+	}}
+}
+
+func TestNotify(t *testing.T) {
+	sender := &fakeSender{}
+	n := newNotifierForTesting(t, Config{Title: "{{ .Status }}: {{ .CommonLabels.lbl1 }}", Message: "line 1\nline 2"}, sender)
+
+	ok, err := n.Notify(notify.WithGroupKey(context.Background(), "group"), firingAlert())
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Len(t, sender.calls, 1)
+	cmd := sender.calls[0]
+	require.Equal(t, "PUT", cmd.HTTPMethod)
+	require.Regexp(t, `^https://matrix\.example\.com/_matrix/client/v3/rooms/%21room:example\.com/send/m\.room\.message/grafana\.1700000000000000000\.\d+$`, cmd.URL)
+	require.Equal(t, "Bearer token", cmd.HTTPHeader["Authorization"])
+	require.JSONEq(t, `{
+		"msgtype": "m.notice",
+		"body": "firing: <val1>\n\nline 1\nline 2",
+		"format": "org.matrix.custom.html",
+		"formatted_body": "<strong>firing: &lt;val1&gt;</strong><br><br>line 1<br>line 2"
+	}`, cmd.Body)
+}
+
+func TestNotify_Threads(t *testing.T) {
+	sender := &fakeSender{}
+	n := newNotifierForTesting(t, Config{MessageType: MsgTypeText, Title: "{{ .Status }}", Message: " "}, sender)
+	store := NewMemoryThreadStore()
+	ctx := WithThreadStore(notify.WithGroupKey(context.Background(), "group"), store)
+
+	_, err := n.Notify(ctx, firingAlert())
+	require.NoError(t, err)
+	thread, ok, err := store.GetThread(ctx, t.Name()+"/group")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "$event1", thread.RootEventID)
+
+	_, err = n.Notify(ctx, firingAlert())
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"msgtype": "m.text",
+		"body": "firing\n\n ",
+		"format": "org.matrix.custom.html",
+		"formatted_body": "<strong>firing</strong><br><br> ",
+		"m.relates_to": {
+			"rel_type": "m.thread",
+			"event_id": "$event1",
+			"is_falling_back": true,
+			"m.in_reply_to": {"event_id": "$event1"}
+		}
+	}`, sender.calls[1].Body)
+
+	_, err = n.Notify(ctx, resolvedAlert())
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"msgtype": "m.text",
+		"body": "* resolved\n\n ",
+		"format": "org.matrix.custom.html",
+		"formatted_body": "* <strong>resolved</strong><br><br> ",
+		"m.new_content": {
+			"msgtype": "m.text",
+			"body": "resolved\n\n ",
+			"format": "org.matrix.custom.html",
+			"formatted_body": "<strong>resolved</strong><br><br> "
+		},
+		"m.relates_to": {"rel_type": "m.replace", "event_id": "$event1"}
+	}`, sender.calls[2].Body)
+	_, ok, err = store.GetThread(ctx, t.Name()+"/group")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// The next incident of the group starts a new thread.
+	_, err = n.Notify(ctx, firingAlert())
+	require.NoError(t, err)
+	require.NotContains(t, sender.calls[3].Body, "m.relates_to")
+	thread, ok, err = store.GetThread(ctx, t.Name()+"/group")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "$event4", thread.RootEventID)
+}
+
+func TestNotify_DisableThreading(t *testing.T) {
+	sender := &fakeSender{}
+	n := newNotifierForTesting(t, Config{DisableThreading: true, Title: "{{ .Status }}"}, sender)
+	store := NewMemoryThreadStore()
+	ctx := WithThreadStore(notify.WithGroupKey(context.Background(), "group"), store)
+
+	for _, a := range []*types.Alert{firingAlert(), firingAlert(), resolvedAlert()} {
+		_, err := n.Notify(ctx, a)
+		require.NoError(t, err)
+	}
+	require.Len(t, sender.calls, 3)
+	for _, c := range sender.calls {
+		require.NotContains(t, c.Body, "m.relates_to")
+	}
+	_, ok, err := store.GetThread(ctx, t.Name()+"/group")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestNotify_Error(t *testing.T) {
+	sender := &fakeSender{statusCode: 403, body: `{"errcode":"M_FORBIDDEN","error":"User is not in the room"}`}
+	n := newNotifierForTesting(t, Config{}, sender)
+
+	ok, err := n.Notify(notify.WithGroupKey(context.Background(), "group"), firingAlert())
+	require.False(t, ok)
+	require.ErrorContains(t, err, "unexpected status code 403: M_FORBIDDEN: User is not in the room")
+}
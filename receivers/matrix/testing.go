@@ -0,0 +1,23 @@
+package matrix
+
+// FullValidConfigForTesting is a string representation of a JSON object that contains all fields supported by the notifier Config. It can be used without secrets.
+const FullValidConfigForTesting = `{
+	"homeserverUrl": "https://matrix.example.com",
+	"roomId": "!test-room:example.com",
+	"accessToken": "test-access-token",
+	"msgType": "m.text",
+	"title": "Alerts firing: {{ len .Alerts.Firing }}",
+	"message": "{{ len .Alerts.Firing }} alerts are firing, {{ len .Alerts.Resolved }} are resolved",
+	"disableThreading": true,
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
+}`
+
+// FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
+const FullValidSecretsForTesting = `{
+	"accessToken": "test-access-token-secret"
+}`
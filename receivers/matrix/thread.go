@@ -0,0 +1,87 @@
+package matrix
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// threadTTL is for how long the thread of an alert group is kept after its last message.
+const threadTTL = 7 * 24 * time.Hour
+
+// Thread is the first message of an alert group, which later notifications of the group reply to in its thread,
+// and which is edited once the group is resolved.
+type Thread struct {
+	RootEventID string `json:"rootEventId"`
+	// UpdatedAt is the time of the last message in the thread.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ThreadStore stores the threads of alert groups. Keys are unique per integration and alert group, so that a
+// store can be shared by all notifiers. Stores that are shared by the members of a cluster keep the threads
+// when another member sends the notifications of the group.
+type ThreadStore interface {
+	// GetThread returns the thread of the key, and false if there is none.
+	GetThread(ctx context.Context, key string) (Thread, bool, error)
+	// SetThread stores the thread of the key.
+	SetThread(ctx context.Context, key string, t Thread) error
+	// DeleteThread deletes the thread of the key, so that the next notification starts a new thread.
+	DeleteThread(ctx context.Context, key string) error
+}
+
+// MemoryThreadStore is a ThreadStore that keeps the threads in memory. Threads that have not been updated
+// for a week are deleted.
+type MemoryThreadStore struct {
+	mtx     sync.Mutex
+	threads map[string]Thread
+}
+
+// NewMemoryThreadStore returns an empty MemoryThreadStore.
+func NewMemoryThreadStore() *MemoryThreadStore {
+	return &MemoryThreadStore{threads: make(map[string]Thread)}
+}
+
+func (s *MemoryThreadStore) GetThread(_ context.Context, key string) (Thread, bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	t, ok := s.threads[key]
+	return t, ok, nil
+}
+
+func (s *MemoryThreadStore) SetThread(_ context.Context, key string, t Thread) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for k, v := range s.threads {
+		if t.UpdatedAt.Sub(v.UpdatedAt) > threadTTL {
+			delete(s.threads, k)
+		}
+	}
+	s.threads[key] = t
+	return nil
+}
+
+func (s *MemoryThreadStore) DeleteThread(_ context.Context, key string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.threads, key)
+	return nil
+}
+
+// defaultThreads is the store of the threads when none is in the context. It is shared by all notifiers,
+// so that the threads are kept when the configuration is reloaded.
+var defaultThreads ThreadStore = NewMemoryThreadStore()
+
+type threadStoreKey struct{}
+
+// WithThreadStore returns a context with the store of the threads of the notifiers.
+func WithThreadStore(ctx context.Context, s ThreadStore) context.Context {
+	return context.WithValue(ctx, threadStoreKey{}, s)
+}
+
+// ThreadStoreFromContext returns the store of the threads in the context, or an in-memory store if there is none.
+func ThreadStoreFromContext(ctx context.Context) ThreadStore {
+	if s, ok := ctx.Value(threadStoreKey{}).(ThreadStore); ok && s != nil {
+		return s
+	}
+	return defaultThreads
+}
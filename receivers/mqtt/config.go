@@ -16,12 +16,21 @@ const (
 	MessageFormatText string = "text"
 )
 
+const (
+	// EncodingJSON encodes messages of format json as JSON. It is the default.
+	EncodingJSON string = "json"
+	// EncodingProtobuf encodes messages of format json as an AlertGroup message of the schema in package alertpb.
+	// MQTT 3.1.1 has no message properties, so consumers know the encoding from the topic.
+	EncodingProtobuf string = "protobuf"
+)
+
 type Config struct {
 	BrokerURL     string                   `json:"brokerUrl,omitempty" yaml:"brokerUrl,omitempty"`
 	ClientID      string                   `json:"clientId,omitempty" yaml:"clientId,omitempty"`
 	Topic         string                   `json:"topic,omitempty" yaml:"topic,omitempty"`
 	Message       string                   `json:"message,omitempty" yaml:"message,omitempty"`
 	MessageFormat string                   `json:"messageFormat,omitempty" yaml:"messageFormat,omitempty"`
+	Encoding      string                   `json:"encoding,omitempty" yaml:"encoding,omitempty"`
 	Username      string                   `json:"username,omitempty" yaml:"username,omitempty"`
 	Password      string                   `json:"password,omitempty" yaml:"password,omitempty"`
 	QoS           receivers.OptionalNumber `json:"qos,omitempty" yaml:"qos,omitempty"`
@@ -59,6 +68,16 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		return Config{}, errors.New("Invalid message format, must be 'json' or 'text'")
 	}
 
+	if settings.Encoding == "" {
+		settings.Encoding = EncodingJSON
+	}
+	if settings.Encoding != EncodingJSON && settings.Encoding != EncodingProtobuf {
+		return Config{}, errors.New("Invalid encoding, must be 'json' or 'protobuf'")
+	}
+	if settings.Encoding == EncodingProtobuf && settings.MessageFormat != MessageFormatJSON {
+		return Config{}, errors.New("Encoding 'protobuf' can only be used with message format 'json'")
+	}
+
 	qos, err := settings.QoS.Int64()
 	if err != nil {
 		return Config{}, fmt.Errorf("Failed to parse QoS: %w", err)
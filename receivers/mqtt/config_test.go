@@ -39,6 +39,16 @@ func TestNewConfig(t *testing.T) {
 			settings:          `{ "brokerUrl" : "tcp://localhost:1883", "topic": "grafana/alerts", "messageFormat": "invalid"}`,
 			expectedInitError: `Invalid message format, must be 'json' or 'text'`,
 		},
+		{
+			name:              "Invalid encoding",
+			settings:          `{ "brokerUrl" : "tcp://localhost:1883", "topic": "grafana/alerts", "encoding": "invalid"}`,
+			expectedInitError: `Invalid encoding, must be 'json' or 'protobuf'`,
+		},
+		{
+			name:              "Encoding protobuf with message format text",
+			settings:          `{ "brokerUrl" : "tcp://localhost:1883", "topic": "grafana/alerts", "messageFormat": "text", "encoding": "protobuf"}`,
+			expectedInitError: `Encoding 'protobuf' can only be used with message format 'json'`,
+		},
 		{
 			name:     "Minimal valid configuration",
 			settings: `{ "brokerUrl" : "tcp://localhost:1883", "topic": "grafana/alerts"}`,
@@ -47,6 +57,7 @@ func TestNewConfig(t *testing.T) {
 				BrokerURL:     "tcp://localhost:1883",
 				Topic:         "grafana/alerts",
 				MessageFormat: MessageFormatJSON,
+				Encoding:      EncodingJSON,
 				TLSConfig: &receivers.TLSConfig{
 					ServerName: "localhost",
 				},
@@ -60,6 +71,7 @@ func TestNewConfig(t *testing.T) {
 				BrokerURL:     "tcp://localhost:1883",
 				Topic:         "grafana/alerts",
 				MessageFormat: MessageFormatJSON,
+				Encoding:      EncodingJSON,
 				TLSConfig: &receivers.TLSConfig{
 					InsecureSkipVerify: true,
 					ServerName:         "localhost",
@@ -74,6 +86,7 @@ func TestNewConfig(t *testing.T) {
 				BrokerURL:     "tcp://localhost:1883",
 				Topic:         "grafana/alerts",
 				MessageFormat: MessageFormatJSON,
+				Encoding:      EncodingJSON,
 				ClientID:      "test-client-id",
 				TLSConfig: &receivers.TLSConfig{
 					ServerName: "localhost",
@@ -91,6 +104,7 @@ func TestNewConfig(t *testing.T) {
 				BrokerURL:     "tcp://localhost:1883",
 				Topic:         "grafana/alerts",
 				MessageFormat: MessageFormatJSON,
+				Encoding:      EncodingJSON,
 				Username:      "grafana",
 				Password:      "testpasswd",
 				TLSConfig: &receivers.TLSConfig{
@@ -111,6 +125,7 @@ func TestNewConfig(t *testing.T) {
 				BrokerURL:     "tcp://localhost:1883",
 				Topic:         "grafana/alerts",
 				MessageFormat: MessageFormatJSON,
+				Encoding:      EncodingJSON,
 				TLSConfig: &receivers.TLSConfig{
 					InsecureSkipVerify: false,
 					ServerName:         "localhost",
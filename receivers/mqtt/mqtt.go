@@ -12,6 +12,7 @@ import (
 
 	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/alertpb"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -61,38 +62,36 @@ type mqttMessage struct {
 }
 
 func (n *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	n.log.Debug("Sending an MQTT message", "topic", n.settings.Topic, "qos", n.settings.QoS, "retain", n.settings.Retain)
+	l := logging.FromContext(ctx, n.log)
+	l.Debug("Sending an MQTT message", "topic", n.settings.Topic, "qos", n.settings.QoS, "retain", n.settings.Retain)
 
 	msg, err := n.buildMessage(ctx, as...)
 	if err != nil {
-		n.log.Error("Failed to build MQTT message", "error", err.Error())
+		l.Error("Failed to build MQTT message", "error", err.Error())
 		return false, err
 	}
 
-	var tlsCfg *tls.Config
-	if n.settings.TLSConfig != nil {
-		tlsCfg, err = n.settings.TLSConfig.ToCryptoTLSConfig()
-	}
+	tlsCfg, err := receivers.ClientTLSConfig(n.settings.TLSConfig)
 	if err != nil {
-		n.log.Error("Failed to build TLS config", "error", err.Error())
+		l.Error("Failed to build TLS config", "error", err.Error())
 		return false, fmt.Errorf("failed to build TLS config: %s", err.Error())
 	}
 
 	err = n.client.Connect(ctx, n.settings.BrokerURL, n.settings.ClientID, n.settings.Username, n.settings.Password, tlsCfg)
 	if err != nil {
-		n.log.Error("Failed to connect to MQTT broker", "error", err.Error())
+		l.Error("Failed to connect to MQTT broker", "error", err.Error())
 		return false, fmt.Errorf("Failed to connect to MQTT broker: %s", err.Error())
 	}
 	defer func() {
 		err := n.client.Disconnect(ctx)
 		if err != nil {
-			n.log.Error("Failed to disconnect from MQTT broker", "error", err.Error())
+			l.Error("Failed to disconnect from MQTT broker", "error", err.Error())
 		}
 	}()
 
 	qos, err := n.settings.QoS.Int64()
 	if err != nil {
-		n.log.Error("Failed to parse QoS", "error", err.Error())
+		l.Error("Failed to parse QoS", "error", err.Error())
 		return false, fmt.Errorf("Failed to parse QoS: %s", err.Error())
 	}
 
@@ -107,7 +106,7 @@ func (n *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 	)
 
 	if err != nil {
-		n.log.Error("Failed to publish MQTT message", "error", err.Error())
+		l.Error("Failed to publish MQTT message", "error", err.Error())
 		return false, fmt.Errorf("Failed to publish MQTT message: %s", err.Error())
 	}
 
@@ -115,16 +114,17 @@ func (n *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 }
 
 func (n *Notifier) buildMessage(ctx context.Context, as ...*types.Alert) (string, error) {
+	l := logging.FromContext(ctx, n.log)
 	groupKey, err := notify.ExtractGroupKey(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	var tmplErr error
-	tmpl, data := templates.TmplText(ctx, n.tmpl, as, n.log, &tmplErr)
+	tmpl, data := templates.TmplText(ctx, n.tmpl, as, l, &tmplErr)
 	messageText := tmpl(n.settings.Message)
 	if tmplErr != nil {
-		n.log.Warn("Failed to template MQTT message", "error", tmplErr.Error())
+		l.Warn("Failed to template MQTT message", "error", tmplErr.Error())
 	}
 
 	switch n.settings.MessageFormat {
@@ -137,6 +137,13 @@ func (n *Notifier) buildMessage(ctx context.Context, as ...*types.Alert) (string
 			GroupKey:     groupKey.String(),
 			Message:      messageText,
 		}
+		if n.settings.Encoding == EncodingProtobuf {
+			g := alertpb.NewAlertGroup(data)
+			g.Version = msg.Version
+			g.GroupKey = msg.GroupKey
+			g.Message = msg.Message
+			return string(g.Marshal()), nil
+		}
 
 		jsonMsg, err := json.Marshal(msg)
 		if err != nil {
@@ -4,8 +4,11 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"flag"
 	"net/url"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -19,6 +22,8 @@ import (
 	"github.com/grafana/alerting/templates"
 )
 
+var update = flag.Bool("update", false, "update the golden files of the tests")
+
 // Test certificates from https://github.com/golang/go/blob/4f852b9734249c063928b34a02dd689e03a8ab2c/src/crypto/tls/tls_test.go#L34
 const (
 	testRsaCertPem = `-----BEGIN CERTIFICATE-----
@@ -387,6 +392,51 @@ func TestNotify(t *testing.T) {
 	}
 }
 
+func TestNotify_Protobuf(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost/base")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	mockMQTTClient := new(mockMQTTClient)
+	mockMQTTClient.On("Connect", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockMQTTClient.On("Disconnect", mock.Anything).Return(nil)
+	mockMQTTClient.On("Publish", mock.Anything, mock.Anything).Return(nil)
+	n := &Notifier{
+		Base: &receivers.Base{},
+		log:  &logging.FakeLogger{},
+		tmpl: tmpl,
+		settings: Config{
+			Topic:         "alert1",
+			Message:       templates.DefaultMessageEmbed,
+			MessageFormat: MessageFormatJSON,
+			Encoding:      EncodingProtobuf,
+		},
+		client: mockMQTTClient,
+	}
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	_, err = n.Notify(ctx, &types.Alert{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations:  model.LabelSet{"ann1": "annv1", "__dashboardUid__": "abcd", "__panelId__": "efgh"},
+			StartsAt:     time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			GeneratorURL: "a URL",
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, mockMQTTClient.publishedMessages, 1)
+
+	payload := mockMQTTClient.publishedMessages[0].payload
+	if *update {
+		require.NoError(t, os.WriteFile("testdata/protobuf.pb", payload, 0o644))
+	}
+	expected, err := os.ReadFile("testdata/protobuf.pb")
+	require.NoError(t, err)
+	require.Equal(t, expected, payload)
+}
+
 func TestNew(t *testing.T) {
 	tmpl := templates.ForTests(t)
 	require.NotNil(t, tmpl)
@@ -0,0 +1,22 @@
+
+1	alertname"firing:**Firing**
+
+Value: [no value]
+Labels:
+ - alertname = alert1
+ - lbl1 = val1
+Annotations:
+ - ann1 = annv1
+Source: a URL
+Silence: http://localhost/base/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1
+Dashboard: http://localhost/base/d/abcd
+Panel: http://localhost/base/d/abcd?viewPanel=efgh
+B
+firing
+	alertnamealert1
+lbl1val1
+ann1annv1"ȱ2a URL:fac0861a85de433aBnhttp://localhost/base/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1Jhttp://localhost/base/d/abcdR+http://localhost/base/d/abcd?viewPanel=efghZ
+	alertnameb
+	alertnamealert1b
+lbl1val1j
+ann1annv1rhttp://localhost/base
\ No newline at end of file
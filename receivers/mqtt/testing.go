@@ -5,6 +5,7 @@ const FullValidConfigForTesting = `{
 	"brokerUrl": "tcp://localhost:1883",
 	"topic": "grafana/alerts",
 	"messageFormat": "json",
+	"encoding": "protobuf",
 	"clientId": "grafana-test-client-id",
 	"username": "test-username",
 	"qos": "0",
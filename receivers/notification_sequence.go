@@ -0,0 +1,19 @@
+package receivers
+
+import "context"
+
+type notificationSequenceKey struct{}
+
+// WithNotificationSequence returns a context with the sequence number of the notification. Sequence numbers are
+// assigned to the notifications of the alert groups of routes with ordered notifications, and increase with every
+// notification of the group that is delivered to the integration.
+func WithNotificationSequence(ctx context.Context, seq uint64) context.Context {
+	return context.WithValue(ctx, notificationSequenceKey{}, seq)
+}
+
+// NotificationSequenceFromContext returns the sequence number of the notification, if the context has one.
+// Receivers can send it to their destination, so that it can discard notifications that arrive out of order.
+func NotificationSequenceFromContext(ctx context.Context) (uint64, bool) {
+	seq, ok := ctx.Value(notificationSequenceKey{}).(uint64)
+	return seq, ok
+}
@@ -6,11 +6,19 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/grafana/alerting/receivers"
 	"github.com/grafana/alerting/templates"
 )
 
+const (
+	// ImportanceDefault pages with the default notification policy of the users.
+	ImportanceDefault = "default"
+	// ImportanceImportant pages with the important notification policy of the users.
+	ImportanceImportant = "important"
+)
+
 type Config struct {
 	URL        string
 	HTTPMethod string
@@ -24,6 +32,14 @@ type Config struct {
 
 	Title   string
 	Message string
+
+	TLSConfig *receivers.TLSConfig
+
+	// Team, EscalationChain and Importance are the templated options of Grafana OnCall direct paging. They are sent
+	// only if at least one of them is set. Importance must render to ImportanceDefault or ImportanceImportant.
+	Team            string
+	EscalationChain string
+	Importance      string
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -38,6 +54,10 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		Password                 string                   `json:"password,omitempty" yaml:"password,omitempty"`
 		Title                    string                   `json:"title,omitempty" yaml:"title,omitempty"`
 		Message                  string                   `json:"message,omitempty" yaml:"message,omitempty"`
+		TLSConfig                *receivers.TLSConfig     `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
+		Team                     string                   `json:"team,omitempty" yaml:"team,omitempty"`
+		EscalationChain          string                   `json:"escalationChain,omitempty" yaml:"escalationChain,omitempty"`
+		Importance               string                   `json:"importance,omitempty" yaml:"importance,omitempty"`
 	}{}
 
 	err := json.Unmarshal(jsonData, &rawSettings)
@@ -77,5 +97,20 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	if settings.Message == "" {
 		settings.Message = templates.DefaultMessageEmbed
 	}
-	return settings, err
+	settings.TLSConfig, err = receivers.ParseTLSConfig(rawSettings.TLSConfig, decryptFn)
+	if err != nil {
+		return settings, err
+	}
+
+	settings.Team = rawSettings.Team
+	settings.EscalationChain = rawSettings.EscalationChain
+	settings.Importance = rawSettings.Importance
+	if settings.Importance != "" && !strings.Contains(settings.Importance, "{{") && !validImportance(settings.Importance) {
+		return settings, fmt.Errorf("invalid value for importance: %q, must be %q or %q", settings.Importance, ImportanceDefault, ImportanceImportant)
+	}
+	return settings, nil
+}
+
+func validImportance(importance string) bool {
+	return importance == ImportanceDefault || importance == ImportanceImportant
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
 	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
@@ -82,6 +83,15 @@ func TestNewConfig(t *testing.T) {
 				Password:                 "test-pass",
 				Title:                    "test-title",
 				Message:                  "test-message",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
+				Team:            "test-team",
+				EscalationChain: "{{ .CommonLabels.escalation }}",
+				Importance:      ImportanceImportant,
 			},
 		},
 		{
@@ -98,6 +108,15 @@ func TestNewConfig(t *testing.T) {
 				Password:                 "test-secret-pass",
 				Title:                    "test-title",
 				Message:                  "test-message",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
+				Team:            "test-team",
+				EscalationChain: "{{ .CommonLabels.escalation }}",
+				Importance:      ImportanceImportant,
 			},
 		},
 		{
@@ -248,6 +267,22 @@ func TestNewConfig(t *testing.T) {
 			}`,
 			expectedInitError: "both HTTP Basic Authentication and Authorization Header are set, only 1 is permitted",
 		},
+		{
+			name:     "should accept a templated importance",
+			settings: `{"url": "http://localhost", "importance": "{{ if eq .CommonLabels.severity \"critical\" }}important{{ else }}default{{ end }}" }`,
+			expectedConfig: Config{
+				URL:        "http://localhost",
+				HTTPMethod: http.MethodPost,
+				Title:      templates.DefaultMessageTitleEmbed,
+				Message:    templates.DefaultMessageEmbed,
+				Importance: `{{ if eq .CommonLabels.severity "critical" }}important{{ else }}default{{ end }}`,
+			},
+		},
+		{
+			name:              "error if importance is not valid",
+			settings:          `{"url": "http://localhost", "importance": "urgent" }`,
+			expectedInitError: `invalid value for importance: "urgent", must be "default" or "important"`,
+		},
 	}
 
 	for _, c := range cases {
@@ -53,10 +53,20 @@ type oncallMessage struct {
 	State           string `json:"state"`
 	Message         string `json:"message"`
 	TruncatedAlerts uint64 `json:"truncatedAlerts"`
+	// DirectPaging is set if any of the direct paging options is configured.
+	DirectPaging *directPaging `json:"directPaging,omitempty"`
+}
+
+// directPaging are the options of Grafana OnCall direct paging for the alert group.
+type directPaging struct {
+	Team            string `json:"team,omitempty"`
+	EscalationChain string `json:"escalationChain,omitempty"`
+	Importance      string `json:"importance,omitempty"`
 }
 
 // Notify implements the Notifier interface.
 func (n *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, n.log)
 	groupKey, err := notify.ExtractGroupKey(ctx)
 	if err != nil {
 		return false, err
@@ -73,10 +83,10 @@ func (n *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 
 	as, numTruncated := truncateAlerts(n.settings.MaxAlerts, as)
 	var tmplErr error
-	tmpl, data := templates.TmplText(ctx, n.tmpl, as, n.log, &tmplErr)
+	tmpl, data := templates.TmplText(ctx, n.tmpl, as, l, &tmplErr)
 
 	// Augment our Alert data with ImageURLs if available.
-	_ = images.WithStoredImages(ctx, n.log, n.images,
+	_ = images.WithStoredImages(ctx, l, n.images,
 		func(index int, image images.Image) error {
 			if len(image.URL) != 0 {
 				data.Alerts[index].ImageURL = image.URL
@@ -100,8 +110,21 @@ func (n *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 		msg.State = string(receivers.AlertStateOK)
 	}
 
+	if n.settings.Team != "" || n.settings.EscalationChain != "" || n.settings.Importance != "" {
+		paging := &directPaging{
+			Team:            tmpl(n.settings.Team),
+			EscalationChain: tmpl(n.settings.EscalationChain),
+			Importance:      tmpl(n.settings.Importance),
+		}
+		if paging.Importance != "" && !validImportance(paging.Importance) {
+			l.Warn("Importance is not valid, using the default importance", "importance", paging.Importance)
+			paging.Importance = ImportanceDefault
+		}
+		msg.DirectPaging = paging
+	}
+
 	if tmplErr != nil {
-		n.log.Warn("failed to template oncall message", "error", tmplErr.Error())
+		l.Warn("failed to template oncall message", "error", tmplErr.Error())
 		tmplErr = nil
 	}
 
@@ -120,6 +143,10 @@ func (n *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 		return false, tmplErr
 	}
 
+	tlsConfig, err := receivers.ClientTLSConfig(n.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        parsedURL,
 		User:       n.settings.User,
@@ -127,6 +154,7 @@ func (n *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 		Body:       string(body),
 		HTTPMethod: n.settings.HTTPMethod,
 		HTTPHeader: headers,
+		TLSConfig:  tlsConfig,
 	}
 
 	if err := n.ns.SendWebhook(ctx, cmd); err != nil {
@@ -542,3 +542,55 @@ func TestNotify(t *testing.T) {
 		})
 	}
 }
+
+func TestNotify_DirectPaging(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := receivers.MockNotificationService()
+	n := &Notifier{
+		Base:   &receivers.Base{},
+		log:    &logging.FakeLogger{},
+		ns:     webhookSender,
+		tmpl:   tmpl,
+		images: &images.UnavailableProvider{},
+		settings: Config{
+			URL:             "http://localhost/test",
+			HTTPMethod:      http.MethodPost,
+			Title:           templates.DefaultMessageTitleEmbed,
+			Message:         templates.DefaultMessageEmbed,
+			Team:            "{{ .CommonLabels.team }}",
+			EscalationChain: "{{ .CommonLabels.team }}-escalation",
+			Importance:      `{{ if eq .CommonLabels.severity "critical" }}important{{ else }}default{{ end }}`,
+		},
+	}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	directPaging := func() interface{} {
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+		return body["directPaging"]
+	}
+
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "team": "payments", "severity": "critical"}}}
+	_, err = n.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"team":            "payments",
+		"escalationChain": "payments-escalation",
+		"importance":      "important",
+	}, directPaging())
+
+	// Importance falls back to the default if it does not render to a valid value.
+	n.settings.Importance = "{{ .CommonLabels.severity }}"
+	_, err = n.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.Equal(t, "default", directPaging().(map[string]interface{})["importance"])
+
+	// Direct paging options are not sent if none are configured.
+	n.settings.Team, n.settings.EscalationChain, n.settings.Importance = "", "", ""
+	_, err = n.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.Nil(t, directPaging())
+}
@@ -10,7 +10,16 @@ const FullValidConfigForTesting = `{
 	"username": "test-user",
 	"password": "test-pass",
 	"title": "test-title",
-	"message": "test-message"		
+	"message": "test-message",
+	"team": "test-team",
+	"escalationChain": "{{ .CommonLabels.escalation }}",
+	"importance": "important",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
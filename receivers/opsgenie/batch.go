@@ -0,0 +1,247 @@
+package opsgenie
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/alerting/logging"
+)
+
+// batchProgressTTL is for how long the progress of a batch that failed is kept to resume it.
+const batchProgressTTL = time.Hour
+
+// BatchMetrics are the metrics of the requests sent in per-alert mode.
+type BatchMetrics struct {
+	// Requests is the number of requests by result: "success", "failure", or "skipped" if the request was sent
+	// by a previous attempt of the batch.
+	Requests *prometheus.CounterVec
+	// Pending is the number of requests of batches in progress that are not sent yet.
+	Pending prometheus.Gauge
+	// ThrottledSeconds is the time spent waiting for the rate limit.
+	ThrottledSeconds prometheus.Counter
+}
+
+// NewBatchMetrics creates the metrics of the requests sent in per-alert mode.
+func NewBatchMetrics(r prometheus.Registerer) *BatchMetrics {
+	return &BatchMetrics{
+		Requests: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "opsgenie_batch_requests_total",
+			Help:      "Number of requests sent to Opsgenie in per-alert mode by result.",
+		}, []string{"result"}),
+		Pending: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "opsgenie_batch_pending_requests",
+			Help:      "Number of requests of batches in progress that are not sent to Opsgenie yet.",
+		}),
+		ThrottledSeconds: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "opsgenie_batch_throttled_seconds_total",
+			Help:      "Time spent waiting for the rate limit of Opsgenie in per-alert mode.",
+		}),
+	}
+}
+
+type batchMetricsKey struct{}
+
+// WithBatchMetrics returns a context with the metrics updated by notifiers in per-alert mode.
+func WithBatchMetrics(ctx context.Context, m *BatchMetrics) context.Context {
+	return context.WithValue(ctx, batchMetricsKey{}, m)
+}
+
+func batchMetricsFromContext(ctx context.Context) *BatchMetrics {
+	m, _ := ctx.Value(batchMetricsKey{}).(*BatchMetrics)
+	return m
+}
+
+// rateLimiter spreads requests evenly over time.
+type rateLimiter struct {
+	mtx      sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait waits until the next request can be sent, and returns for how long it waited.
+func (l *rateLimiter) wait(ctx context.Context, now time.Time) (time.Duration, error) {
+	l.mtx.Lock()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mtx.Unlock()
+
+	d := at.Sub(now)
+	if d <= 0 {
+		return 0, nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-t.C:
+		return d, nil
+	}
+}
+
+// rateLimiters are the rate limiters of API keys. Rate limits apply to API keys, so they are shared by all
+// notifiers that use the same API key.
+type rateLimiters struct {
+	mtx      sync.Mutex
+	limiters map[string]*rateLimiter
+}
+
+var defaultRateLimiters = &rateLimiters{limiters: make(map[string]*rateLimiter)}
+
+func (r *rateLimiters) get(apiKey string, perMinute int) *rateLimiter {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	interval := time.Minute / time.Duration(perMinute)
+	l, ok := r.limiters[apiKey]
+	if !ok {
+		l = &rateLimiter{}
+		r.limiters[apiKey] = l
+	}
+	l.mtx.Lock()
+	l.interval = interval
+	l.mtx.Unlock()
+	return l
+}
+
+// batchProgress is the requests of a batch that were sent, so that a batch that failed, such as because Opsgenie
+// started to reject requests, is resumed by the next attempt instead of being sent again from the start.
+type batchProgress struct {
+	sent      map[string]struct{}
+	updatedAt time.Time
+}
+
+type progressStore struct {
+	mtx     sync.Mutex
+	batches map[string]batchProgress
+}
+
+var defaultProgress = &progressStore{batches: make(map[string]batchProgress)}
+
+func (s *progressStore) get(key string, now time.Time) map[string]struct{} {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for k, p := range s.batches {
+		if now.Sub(p.updatedAt) > batchProgressTTL {
+			delete(s.batches, k)
+		}
+	}
+	if p, ok := s.batches[key]; ok {
+		return p.sent
+	}
+	return make(map[string]struct{})
+}
+
+func (s *progressStore) set(key string, sent map[string]struct{}, now time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.batches[key] = batchProgress{sent: sent, updatedAt: now}
+}
+
+func (s *progressStore) delete(key string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.batches, key)
+}
+
+type batchRequest struct {
+	// id identifies the request in the progress of the batch.
+	id   string
+	url  string
+	body []byte
+}
+
+// notifyPerAlert creates an Opsgenie alert for each firing alert, and closes the Opsgenie alert of each resolved
+// alert. Requests are spread over time according to the rate limit of the API key. If a request fails, the
+// requests that were sent are not sent again by the next attempt.
+func (on *Notifier) notifyPerAlert(ctx context.Context, as []*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, on.log)
+	key, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	requests := make([]batchRequest, 0, len(as))
+	for _, a := range as {
+		if a.Resolved() && !on.SendResolved() {
+			continue
+		}
+		alias := fmt.Sprintf("%s-%s", key.Hash(), a.Fingerprint())
+		body, url, err := on.buildOpsgenieMessage(ctx, alias, types.Alerts(a), []*types.Alert{a})
+		if err != nil {
+			return false, fmt.Errorf("build Opsgenie message: %w", err)
+		}
+		if url == "" {
+			// Resolved alert with no auto close.
+			continue
+		}
+		requests = append(requests, batchRequest{id: fmt.Sprintf("%s:%s", alias, a.Status()), url: url, body: body})
+	}
+
+	m := batchMetricsFromContext(ctx)
+	progressKey := on.UID + "/" + string(key)
+	sent := on.progress.get(progressKey, on.now())
+	rateLimit := on.settings.RateLimit
+	if rateLimit == 0 {
+		rateLimit = DefaultRateLimit
+	}
+	limiter := on.limiters.get(on.settings.APIKey, rateLimit)
+
+	pending := 0
+	for _, r := range requests {
+		if _, ok := sent[r.id]; !ok {
+			pending++
+		}
+	}
+	if m != nil {
+		m.Requests.WithLabelValues("skipped").Add(float64(len(requests) - pending))
+		m.Pending.Add(float64(pending))
+		defer func() { m.Pending.Sub(float64(pending)) }()
+	}
+	l.Debug("Sending Opsgenie alerts", "requests", len(requests), "pending", pending, "rate_limit", rateLimit)
+
+	for _, r := range requests {
+		if _, ok := sent[r.id]; ok {
+			continue
+		}
+		waited, err := limiter.wait(ctx, on.now())
+		if m != nil {
+			m.ThrottledSeconds.Add(waited.Seconds())
+		}
+		if err == nil {
+			err = on.send(ctx, r.url, r.body)
+		}
+		if err != nil {
+			if m != nil {
+				m.Requests.WithLabelValues("failure").Inc()
+			}
+			on.progress.set(progressKey, sent, on.now())
+			l.Warn("Failed to send Opsgenie alerts, the remaining alerts are sent by the next attempt", "sent", len(sent), "pending", pending, "err", err)
+			return false, err
+		}
+		sent[r.id] = struct{}{}
+		pending--
+		if m != nil {
+			m.Requests.WithLabelValues("success").Inc()
+			m.Pending.Dec()
+		}
+	}
+
+	on.progress.delete(progressKey)
+	return true, nil
+}
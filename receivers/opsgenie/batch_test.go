@@ -0,0 +1,160 @@
+package opsgenie
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+// failingSender fails the requests with the indexes in fail.
+type failingSender struct {
+	calls []receivers.SendWebhookSettings
+	fail  map[int]bool
+}
+
+func (s *failingSender) SendWebhook(_ context.Context, cmd *receivers.SendWebhookSettings) error {
+	idx := len(s.calls)
+	s.calls = append(s.calls, *cmd)
+	if s.fail[idx] {
+		return errors.New("429 Too Many Requests")
+	}
+	return nil
+}
+
+func TestNotifyPerAlert(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	key, err := notify.ExtractGroupKey(ctx)
+	require.NoError(t, err)
+
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert3"}, StartsAt: time.Now().Add(-time.Hour), EndsAt: time.Now().Add(-time.Minute)}},
+	}
+	alias := func(a *types.Alert) string {
+		return fmt.Sprintf("%s-%s", key.Hash(), a.Fingerprint())
+	}
+
+	newNotifier := func(sender receivers.WebhookSender) *Notifier {
+		n := New(Config{
+			APIKey:     "test-api-key",
+			APIUrl:     DefaultAlertsURL,
+			Message:    templates.DefaultMessageTitleEmbed,
+			AutoClose:  true,
+			SendTagsAs: SendTags,
+			PerAlert:   true,
+			// A request every millisecond.
+			RateLimit: 60000,
+		}, receivers.Metadata{UID: "uid"}, tmpl, sender, &images.UnavailableProvider{}, &logging.FakeLogger{})
+		n.limiters = &rateLimiters{limiters: make(map[string]*rateLimiter)}
+		n.progress = &progressStore{batches: make(map[string]batchProgress)}
+		return n
+	}
+
+	t.Run("sends a request for each alert", func(t *testing.T) {
+		sender := &failingSender{}
+		n := newNotifier(sender)
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Len(t, sender.calls, 3)
+		for i, a := range alerts[:2] {
+			require.Equal(t, DefaultAlertsURL, sender.calls[i].URL)
+			var msg opsGenieCreateMessage
+			require.NoError(t, json.Unmarshal([]byte(sender.calls[i].Body), &msg))
+			require.Equal(t, alias(a), msg.Alias)
+			require.Equal(t, []string{fmt.Sprintf("alertname:%s", a.Labels["alertname"])}, msg.Tags)
+		}
+		require.Equal(t, fmt.Sprintf("%s/%s/close?identifierType=alias", DefaultAlertsURL, alias(alerts[2])), sender.calls[2].URL)
+	})
+
+	t.Run("resolved alerts are not closed if resolve messages are disabled", func(t *testing.T) {
+		sender := &failingSender{}
+		n := newNotifier(sender)
+		n.DisableResolveMessage = true
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sender.calls, 2)
+	})
+
+	t.Run("failed batches are resumed", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		m := NewBatchMetrics(reg)
+		ctx := WithBatchMetrics(ctx, m)
+
+		sender := &failingSender{fail: map[int]bool{1: true}}
+		n := newNotifier(sender)
+		ok, err := n.Notify(ctx, alerts...)
+		require.EqualError(t, err, "send notification to Opsgenie: 429 Too Many Requests")
+		require.False(t, ok)
+		require.Len(t, sender.calls, 2)
+		require.Equal(t, float64(1), testutil.ToFloat64(m.Requests.WithLabelValues("success")))
+		require.Equal(t, float64(1), testutil.ToFloat64(m.Requests.WithLabelValues("failure")))
+		require.Equal(t, float64(0), testutil.ToFloat64(m.Pending))
+
+		// The first alert is not sent again.
+		ok, err = n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sender.calls, 4)
+		require.Equal(t, sender.calls[1], sender.calls[2])
+		require.Equal(t, float64(3), testutil.ToFloat64(m.Requests.WithLabelValues("success")))
+		require.Equal(t, float64(1), testutil.ToFloat64(m.Requests.WithLabelValues("skipped")))
+
+		// Once the batch is complete, the next notification sends all alerts.
+		ok, err = n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, sender.calls, 7)
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := &rateLimiter{interval: 20 * time.Millisecond}
+
+	waited, err := l.wait(context.Background(), now)
+	require.NoError(t, err)
+	require.Zero(t, waited)
+
+	waited, err = l.wait(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 20*time.Millisecond, waited)
+
+	// Requests are spread after the last reserved slot.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = l.wait(ctx, now)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, now.Add(60*time.Millisecond), l.next)
+
+	// Limiters are shared by API key.
+	r := &rateLimiters{limiters: make(map[string]*rateLimiter)}
+	require.Same(t, r.get("key", 60), r.get("key", 120))
+	require.Equal(t, 500*time.Millisecond, r.get("key", 120).interval)
+	require.NotSame(t, r.get("key", 60), r.get("other", 60))
+}
@@ -17,6 +17,10 @@ const (
 	SendBoth    = "both"
 
 	DefaultAlertsURL = "https://api.opsgenie.com/v2/alerts"
+
+	// DefaultRateLimit is the number of requests per minute sent with an API key in per-alert mode if no limit is
+	// configured. Opsgenie documents its rate limits per plan and API domain, the default is below all of them.
+	DefaultRateLimit = 100
 )
 
 var SupportedResponderTypes = []string{"team", "teams", "user", "escalation", "schedule"}
@@ -37,18 +41,38 @@ type Config struct {
 	OverridePriority bool
 	SendTagsAs       string
 	Responders       []MessageResponder
+	// Tags is a template of a comma-separated list of tags, which are added to the tags of the labels.
+	Tags string
+	// Entity is a template of the domain of the alert, such as a server or an application.
+	Entity string
+	// Actions is a template of a comma-separated list of the custom actions of the alert.
+	Actions string
+	// Details are templated custom properties of the alert. They override the details of the labels.
+	Details map[string]string
+	// PerAlert sends one Opsgenie alert for each alert of the group instead of one for the whole group.
+	PerAlert bool
+	// RateLimit is the number of requests per minute sent with the API key in per-alert mode. If 0, DefaultRateLimit is used.
+	RateLimit int
+	TLSConfig *receivers.TLSConfig
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
 	type rawSettings struct {
-		APIKey           string             `json:"apiKey,omitempty" yaml:"apiKey,omitempty"`
-		APIUrl           string             `json:"apiUrl,omitempty" yaml:"apiUrl,omitempty"`
-		Message          string             `json:"message,omitempty" yaml:"message,omitempty"`
-		Description      string             `json:"description,omitempty" yaml:"description,omitempty"`
-		AutoClose        *bool              `json:"autoClose,omitempty" yaml:"autoClose,omitempty"`
-		OverridePriority *bool              `json:"overridePriority,omitempty" yaml:"overridePriority,omitempty"`
-		SendTagsAs       string             `json:"sendTagsAs,omitempty" yaml:"sendTagsAs,omitempty"`
-		Responders       []MessageResponder `json:"responders,omitempty" yaml:"responders,omitempty"`
+		APIKey           string               `json:"apiKey,omitempty" yaml:"apiKey,omitempty"`
+		APIUrl           string               `json:"apiUrl,omitempty" yaml:"apiUrl,omitempty"`
+		Message          string               `json:"message,omitempty" yaml:"message,omitempty"`
+		Description      string               `json:"description,omitempty" yaml:"description,omitempty"`
+		AutoClose        *bool                `json:"autoClose,omitempty" yaml:"autoClose,omitempty"`
+		OverridePriority *bool                `json:"overridePriority,omitempty" yaml:"overridePriority,omitempty"`
+		SendTagsAs       string               `json:"sendTagsAs,omitempty" yaml:"sendTagsAs,omitempty"`
+		Responders       []MessageResponder   `json:"responders,omitempty" yaml:"responders,omitempty"`
+		Tags             string               `json:"tags,omitempty" yaml:"tags,omitempty"`
+		Entity           string               `json:"entity,omitempty" yaml:"entity,omitempty"`
+		Actions          string               `json:"actions,omitempty" yaml:"actions,omitempty"`
+		Details          map[string]string    `json:"details,omitempty" yaml:"details,omitempty"`
+		PerAlert         bool                 `json:"perAlert,omitempty" yaml:"perAlert,omitempty"`
+		RateLimit        int                  `json:"rateLimit,omitempty" yaml:"rateLimit,omitempty"`
+		TLSConfig        *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 	}
 
 	raw := rawSettings{}
@@ -86,6 +110,10 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		raw.OverridePriority = &overridePriority
 	}
 
+	if raw.RateLimit < 0 {
+		return Config{}, errors.New("rateLimit must not be negative")
+	}
+
 	for idx, r := range raw.Responders {
 		if r.ID == "" && r.Username == "" && r.Name == "" {
 			return Config{}, fmt.Errorf("responder at index [%d] must have at least one of id, username or name specified", idx)
@@ -113,6 +141,17 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		}
 	}
 
+	for k := range raw.Details {
+		if strings.TrimSpace(k) == "" {
+			return Config{}, errors.New("details must not have empty keys")
+		}
+	}
+
+	tlsConfig, err := receivers.ParseTLSConfig(raw.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
+
 	return Config{
 		APIKey:           raw.APIKey,
 		APIUrl:           raw.APIUrl,
@@ -122,5 +161,12 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		OverridePriority: *raw.OverridePriority,
 		SendTagsAs:       raw.SendTagsAs,
 		Responders:       raw.Responders,
+		Tags:             raw.Tags,
+		Entity:           raw.Entity,
+		Actions:          raw.Actions,
+		Details:          raw.Details,
+		PerAlert:         raw.PerAlert,
+		RateLimit:        raw.RateLimit,
+		TLSConfig:        tlsConfig,
 	}, nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
 	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
@@ -169,6 +170,19 @@ func TestNewConfig(t *testing.T) {
 			},
 			expectedInitError: `responder at index [0] must have at least one of id, username or name specified`,
 		},
+		{
+			name:     "Error if rate limit is negative",
+			settings: `{ "rateLimit": -1 }`,
+			secureSettings: map[string][]byte{
+				"apiKey": []byte("test-api-key"),
+			},
+			expectedInitError: `rateLimit must not be negative`,
+		},
+		{
+			name:              "Error if details have an empty key",
+			settings:          `{"apiKey": "test-api-key", "details": {" ": "value"}}`,
+			expectedInitError: `details must not have empty keys`,
+		},
 		{
 			name:     "Should use default message if all spaces",
 			settings: `{ "message" : " " }`,
@@ -237,6 +251,18 @@ func TestNewConfig(t *testing.T) {
 						Type: "schedule",
 					},
 				},
+				Tags:      "test-tag1, test-tag2",
+				Entity:    "test-entity",
+				Actions:   "test-action1,test-action2",
+				Details:   map[string]string{"test-detail": "test-detail-value"},
+				PerAlert:  true,
+				RateLimit: 60,
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 		{
@@ -265,6 +291,18 @@ func TestNewConfig(t *testing.T) {
 						Type: "schedule",
 					},
 				},
+				Tags:      "test-tag1, test-tag2",
+				Entity:    "test-entity",
+				Actions:   "test-action1,test-action2",
+				Details:   map[string]string{"test-detail": "test-detail-value"},
+				PerAlert:  true,
+				RateLimit: 60,
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 	}
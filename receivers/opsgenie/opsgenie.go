@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/prometheus/alertmanager/notify"
 
@@ -22,6 +24,13 @@ import (
 const (
 	// https://docs.opsgenie.com/docs/alert-api - 130 characters meaning runes.
 	opsGenieMaxMessageLenRunes = 130
+	// Limits of the other fields of the Create Alert API, in runes.
+	opsGenieMaxTags            = 20
+	opsGenieMaxTagLenRunes     = 50
+	opsGenieMaxActions         = 10
+	opsGenieMaxActionLenRunes  = 50
+	opsGenieMaxEntityLenRunes  = 512
+	opsGenieMaxDetailsLenRunes = 8000
 )
 
 var (
@@ -35,6 +44,8 @@ var (
 // 1. Setting Config.AutoClose is set to `true`
 // 2. Setting DisableResolveMessage is set to false.
 // 3. All alerts in the aggregation group are resolved.
+// If Config.PerAlert is set, an OpsGenie alert is created for each alert of the aggregation group instead,
+// with an alias that is a hash of the aggregation group and the fingerprint of the alert.
 type Notifier struct {
 	*receivers.Base
 	tmpl     *templates.Template
@@ -42,6 +53,9 @@ type Notifier struct {
 	ns       receivers.WebhookSender
 	images   images.Provider
 	settings Config
+	limiters *rateLimiters
+	progress *progressStore
+	now      func() time.Time
 }
 
 func New(cfg Config, meta receivers.Metadata, template *templates.Template, sender receivers.WebhookSender, images images.Provider, logger logging.Logger) *Notifier {
@@ -52,20 +66,33 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 		images:   images,
 		tmpl:     template,
 		settings: cfg,
+		limiters: defaultRateLimiters,
+		progress: defaultProgress,
+		now:      time.Now,
 	}
 }
 
 // Notify sends an alert notification to Opsgenie
 func (on *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	on.log.Debug("executing Opsgenie notification", "notification", on.Name)
+	l := logging.FromContext(ctx, on.log)
+	l.Debug("executing Opsgenie notification")
+
+	if on.settings.PerAlert {
+		return on.notifyPerAlert(ctx, as)
+	}
 
 	alerts := types.Alerts(as...)
 	if alerts.Status() == model.AlertResolved && !on.SendResolved() {
-		on.log.Debug("not sending a trigger to Opsgenie", "status", alerts.Status(), "auto resolve", on.SendResolved())
+		l.Debug("not sending a trigger to Opsgenie", "status", alerts.Status(), "auto resolve", on.SendResolved())
 		return true, nil
 	}
 
-	body, url, err := on.buildOpsgenieMessage(ctx, alerts, as)
+	key, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	body, url, err := on.buildOpsgenieMessage(ctx, key.Hash(), alerts, as)
 	if err != nil {
 		return false, fmt.Errorf("build Opsgenie message: %w", err)
 	}
@@ -76,6 +103,18 @@ func (on *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		return true, nil
 	}
 
+	if err := on.send(ctx, url, body); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (on *Notifier) send(ctx context.Context, url string, body []byte) error {
+	tlsConfig, err := receivers.ClientTLSConfig(on.settings.TLSConfig)
+	if err != nil {
+		return fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        url,
 		Body:       string(body),
@@ -84,21 +123,18 @@ func (on *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 			"Content-Type":  "application/json",
 			"Authorization": fmt.Sprintf("GenieKey %s", on.settings.APIKey),
 		},
+		TLSConfig: tlsConfig,
 	}
 
 	if err := on.ns.SendWebhook(ctx, cmd); err != nil {
-		return false, fmt.Errorf("send notification to Opsgenie: %w", err)
+		return fmt.Errorf("send notification to Opsgenie: %w", err)
 	}
-
-	return true, nil
+	return nil
 }
 
-func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alerts model.Alerts, as []*types.Alert) (payload []byte, apiURL string, err error) {
-	key, err := notify.ExtractGroupKey(ctx)
-	if err != nil {
-		return nil, "", err
-	}
-
+// buildOpsgenieMessage builds the request for the alerts to create or close the Opsgenie alert with the alias.
+func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alias string, alerts model.Alerts, as []*types.Alert) (payload []byte, apiURL string, err error) {
+	l := logging.FromContext(ctx, on.log)
 	if alerts.Status() == model.AlertResolved {
 		// For resolved notification, we only need the source.
 		// Don't need to run other templates.
@@ -109,18 +145,18 @@ func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alerts model.Alert
 			Source: "Grafana",
 		}
 		data, err := json.Marshal(msg)
-		apiURL = fmt.Sprintf("%s/%s/close?identifierType=alias", on.settings.APIUrl, key.Hash())
+		apiURL = fmt.Sprintf("%s/%s/close?identifierType=alias", on.settings.APIUrl, alias)
 		return data, apiURL, err
 	}
 
-	ruleURL := receivers.JoinURLPath(on.tmpl.ExternalURL.String(), "/alerting/list", on.log)
+	ruleURL := receivers.JoinURLPath(on.tmpl.ExternalURL.String(), "/alerting/list", l)
 
 	var tmplErr error
-	tmpl, data := templates.TmplText(ctx, on.tmpl, as, on.log, &tmplErr)
+	tmpl, data := templates.TmplText(ctx, on.tmpl, as, l, &tmplErr)
 
 	message, truncated := receivers.TruncateInRunes(tmpl(on.settings.Message), opsGenieMaxMessageLenRunes)
 	if truncated {
-		on.log.Warn("Truncated message", "alert", key, "max_runes", opsGenieMaxMessageLenRunes)
+		l.Warn("Truncated message", "alias", alias, "max_runes", opsGenieMaxMessageLenRunes)
 	}
 
 	description := tmpl(on.settings.Description)
@@ -146,9 +182,20 @@ func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alerts model.Alert
 		}
 	}
 
+	entity, truncated := receivers.TruncateInRunes(tmpl(on.settings.Entity), opsGenieMaxEntityLenRunes)
+	if truncated {
+		l.Warn("Truncated entity", "alias", alias, "max_runes", opsGenieMaxEntityLenRunes)
+	}
+	actions := limitList(l, "actions", splitList(tmpl(on.settings.Actions)), opsGenieMaxActions, opsGenieMaxActionLenRunes)
+	configuredTags := splitList(tmpl(on.settings.Tags))
+	configuredDetails := make(map[string]string, len(on.settings.Details))
+	for k, v := range on.settings.Details {
+		configuredDetails[k] = tmpl(v)
+	}
+
 	// Check for templating errors
 	if tmplErr != nil {
-		on.log.Warn("failed to template Opsgenie message", "error", tmplErr.Error())
+		l.Warn("failed to template Opsgenie message", "error", tmplErr.Error())
 		tmplErr = nil
 	}
 
@@ -159,7 +206,7 @@ func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alerts model.Alert
 			details[k] = v
 		}
 		var imageUrls []string
-		_ = images.WithStoredImages(ctx, on.log, on.images,
+		_ = images.WithStoredImages(ctx, l, on.images,
 			func(_ int, image images.Image) error {
 				if len(image.URL) == 0 {
 					return nil
@@ -173,6 +220,7 @@ func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alerts model.Alert
 			details["image_urls"] = strings.Join(imageUrls, ", ")
 		}
 	}
+	addDetails(l, details, configuredDetails)
 
 	tags := make([]string, 0, len(lbls))
 	if on.sendTags() {
@@ -181,6 +229,8 @@ func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alerts model.Alert
 		}
 	}
 	sort.Strings(tags)
+	// The configured tags go first, so that they are kept if there are too many tags.
+	tags = limitList(l, "tags", append(configuredTags, tags...), opsGenieMaxTags, opsGenieMaxTagLenRunes)
 
 	responders := make([]opsGenieCreateMessageResponder, 0, len(on.settings.Responders))
 	for idx, r := range on.settings.Responders {
@@ -192,7 +242,7 @@ func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alerts model.Alert
 		}
 
 		if responder == (opsGenieCreateMessageResponder{}) {
-			on.log.Warn("templates in the responder were expanded to empty responder. Skipping it", "idx", idx)
+			l.Warn("templates in the responder were expanded to empty responder. Skipping it", "idx", idx)
 			// Filter out empty responders. This is useful if you want to fill
 			// responders dynamically from alert's common labels.
 			continue
@@ -212,7 +262,7 @@ func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alerts model.Alert
 				teamResponders = append(teamResponders, newResponder)
 			}
 			if len(teamResponders) == 0 {
-				on.log.Warn("teams responder were expanded to 0 team responders. Skipping it", "idx", idx)
+				l.Warn("teams responder were expanded to 0 team responders. Skipping it", "idx", idx)
 			}
 			responders = append(responders, teamResponders...)
 			continue
@@ -221,7 +271,7 @@ func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alerts model.Alert
 	}
 
 	result := opsGenieCreateMessage{
-		Alias:       key.Hash(),
+		Alias:       alias,
 		Description: description,
 		Tags:        tags,
 		Source:      "Grafana",
@@ -229,11 +279,13 @@ func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alerts model.Alert
 		Details:     details,
 		Priority:    priority,
 		Responders:  responders,
+		Entity:      entity,
+		Actions:     actions,
 	}
 
 	apiURL = tmpl(on.settings.APIUrl)
 	if tmplErr != nil {
-		on.log.Warn("failed to template Opsgenie URL", "error", tmplErr.Error(), "fallback", on.settings.APIUrl)
+		l.Warn("failed to template Opsgenie URL", "error", tmplErr.Error(), "fallback", on.settings.APIUrl)
 		apiURL = on.settings.APIUrl
 	}
 
@@ -241,6 +293,71 @@ func (on *Notifier) buildOpsgenieMessage(ctx context.Context, alerts model.Alert
 	return b, apiURL, err
 }
 
+// splitList returns the items of a comma-separated list, without spaces around them. Empty items are left out.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// limitList truncates the items of a list to maxLen runes, removes the duplicates and keeps the first maxItems.
+func limitList(l logging.Logger, name string, items []string, maxItems, maxLen int) []string {
+	res := make([]string, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		item, truncated := receivers.TruncateInRunes(item, maxLen)
+		if truncated {
+			l.Warn("Truncated item of Opsgenie list", "list", name, "max_runes", maxLen)
+		}
+		if _, ok := seen[item]; ok {
+			continue
+		}
+		seen[item] = struct{}{}
+		res = append(res, item)
+	}
+	if len(res) > maxItems {
+		l.Warn("Opsgenie list has too many items, leaving out the last ones", "list", name, "items", len(res), "max_items", maxItems)
+		res = res[:maxItems]
+	}
+	return res
+}
+
+// addDetails adds the configured details to the details, replacing the details of the same keys. Opsgenie limits
+// the total length of the keys and values of the details, so configured values are truncated in the order of their
+// keys to fit in the space left by the other details. Details with empty values are left out.
+func addDetails(l logging.Logger, details map[string]interface{}, configured map[string]string) {
+	keys := make([]string, 0, len(configured))
+	for k, v := range configured {
+		delete(details, k)
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	left := opsGenieMaxDetailsLenRunes
+	for k, v := range details {
+		left -= utf8.RuneCountInString(k) + utf8.RuneCountInString(fmt.Sprint(v))
+	}
+	for _, k := range keys {
+		maxLen := left - utf8.RuneCountInString(k)
+		if maxLen <= 0 {
+			l.Warn("Opsgenie details are too long, leaving out detail", "key", k, "max_runes", opsGenieMaxDetailsLenRunes)
+			continue
+		}
+		v, truncated := receivers.TruncateInRunes(configured[k], maxLen)
+		if truncated {
+			l.Warn("Truncated Opsgenie detail", "key", k, "max_runes", opsGenieMaxDetailsLenRunes)
+		}
+		details[k] = v
+		left -= utf8.RuneCountInString(k) + utf8.RuneCountInString(v)
+	}
+}
+
 func (on *Notifier) SendResolved() bool {
 	return !on.GetDisableResolveMessage()
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -390,6 +391,50 @@ func TestNotify(t *testing.T) {
 			}`, groupKeyHash),
 			expMsgError: nil,
 		},
+		{
+			name: "Config with tags, entity, actions and details",
+			settings: Config{
+				APIKey:           "abcdefgh0123456789",
+				APIUrl:           DefaultAlertsURL,
+				Message:          "test message",
+				Description:      "test description",
+				AutoClose:        true,
+				OverridePriority: true,
+				SendTagsAs:       SendBoth,
+				Tags:             "team:{{ .CommonLabels.team }}, lbl1:val1,,static",
+				Entity:           "{{ .CommonLabels.alertname }}",
+				Actions:          "Restart, {{ .CommonAnnotations.action }}",
+				Details: map[string]string{
+					"lbl1":    "{{ .CommonLabels.lbl1 }}-overridden",
+					"runbook": "http://runbook/{{ .CommonLabels.alertname }}",
+					"empty":   "{{ .CommonLabels.missing }}",
+				},
+			},
+			alerts: []*types.Alert{
+				{
+					Alert: model.Alert{
+						Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1", "team": "infra"},
+						Annotations: model.LabelSet{"action": "Ping"},
+					},
+				},
+			},
+			expMsg: fmt.Sprintf(`{
+				"alias": "%s",
+				"description": "test description",
+				"details": {
+					"alertname": "alert1",
+					"lbl1": "val1-overridden",
+					"runbook": "http://runbook/alert1",
+					"team": "infra",
+					"url": "http://localhost/alerting/list"
+				},
+				"message": "test message",
+				"source": "Grafana",
+				"tags": ["team:infra", "lbl1:val1", "static", "alertname:alert1"],
+				"entity": "alert1",
+				"actions": ["Restart", "Ping"]
+			}`, groupKeyHash),
+		},
 		{
 			name: "Resolved is not sent when auto close is false",
 			settings: Config{
@@ -541,3 +586,34 @@ func TestNotify(t *testing.T) {
 		})
 	}
 }
+
+func TestLimits(t *testing.T) {
+	l := &logging.FakeLogger{}
+
+	t.Run("lists are truncated and deduplicated", func(t *testing.T) {
+		items := []string{"a", strings.Repeat("b", 60), "a", "c", "d"}
+		require.Equal(t, []string{"a", strings.Repeat("b", 9) + "…", "c"}, limitList(l, "tags", items, 3, 10))
+	})
+
+	t.Run("details are truncated to the space left by the other details", func(t *testing.T) {
+		details := map[string]interface{}{
+			"url": strings.Repeat("u", opsGenieMaxDetailsLenRunes-20),
+			"lbl": "val",
+		}
+		addDetails(l, details, map[string]string{
+			"a":   "12345",
+			"b":   "1234567890",
+			"c":   "123",
+			"lbl": "",
+		})
+		require.Equal(t, map[string]interface{}{
+			"url": strings.Repeat("u", opsGenieMaxDetailsLenRunes-20),
+			"a":   "12345",
+			"b":   "1234567890",
+		}, details)
+
+		details = map[string]interface{}{"url": strings.Repeat("u", opsGenieMaxDetailsLenRunes-10)}
+		addDetails(l, details, map[string]string{"a": "1234567890"})
+		require.Equal(t, "12345…", details["a"])
+	})
+}
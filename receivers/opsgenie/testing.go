@@ -22,7 +22,21 @@ const FullValidConfigForTesting = `{
       "type": "schedule",
       "name": "test-schedule"
     }
-  ]
+  ],
+  "tags": "test-tag1, test-tag2",
+  "entity": "test-entity",
+  "actions": "test-action1,test-action2",
+  "details": {
+    "test-detail": "test-detail-value"
+  },
+  "perAlert": true,
+  "rateLimit": 60,
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
@@ -16,6 +16,13 @@ const (
 	DefaultGroup    = "default"
 	DefaultClient   = "Grafana"
 	DefaultURL      = "https://events.pagerduty.com/v2/enqueue"
+	EUURL           = "https://events.eu.pagerduty.com/v2/enqueue"
+
+	RegionUS = "us"
+	RegionEU = "eu"
+
+	// routingKeyLength is the length of the integration and routing keys issued by PagerDuty.
+	routingKeyLength = 32
 )
 
 var defaultDetails = map[string]string{
@@ -43,17 +50,19 @@ var getHostname = func() (string, error) {
 }
 
 type Config struct {
-	Key       string            `json:"integrationKey,omitempty" yaml:"integrationKey,omitempty"`
-	Severity  string            `json:"severity,omitempty" yaml:"severity,omitempty"`
-	Details   map[string]string `json:"details,omitempty" yaml:"details,omitempty"`
-	Class     string            `json:"class,omitempty" yaml:"class,omitempty"`
-	Component string            `json:"component,omitempty" yaml:"component,omitempty"`
-	Group     string            `json:"group,omitempty" yaml:"group,omitempty"`
-	Summary   string            `json:"summary,omitempty" yaml:"summary,omitempty"`
-	Source    string            `json:"source,omitempty" yaml:"source,omitempty"`
-	Client    string            `json:"client,omitempty" yaml:"client,omitempty"`
-	ClientURL string            `json:"client_url,omitempty" yaml:"client_url,omitempty"`
-	URL       string            `json:"url,omitempty" yaml:"url,omitempty"`
+	Key       string               `json:"integrationKey,omitempty" yaml:"integrationKey,omitempty"`
+	Severity  string               `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Details   map[string]string    `json:"details,omitempty" yaml:"details,omitempty"`
+	Class     string               `json:"class,omitempty" yaml:"class,omitempty"`
+	Component string               `json:"component,omitempty" yaml:"component,omitempty"`
+	Group     string               `json:"group,omitempty" yaml:"group,omitempty"`
+	Summary   string               `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Source    string               `json:"source,omitempty" yaml:"source,omitempty"`
+	Client    string               `json:"client,omitempty" yaml:"client,omitempty"`
+	ClientURL string               `json:"client_url,omitempty" yaml:"client_url,omitempty"`
+	URL       string               `json:"url,omitempty" yaml:"url,omitempty"`
+	Region    string               `json:"region,omitempty" yaml:"region,omitempty"`
+	TLSConfig *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -91,8 +100,18 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	if settings.ClientURL == "" {
 		settings.ClientURL = "{{ .ExternalURL }}"
 	}
+	switch settings.Region {
+	case "":
+		settings.Region = RegionUS
+	case RegionUS, RegionEU:
+	default:
+		return Config{}, fmt.Errorf("invalid value for region: %q, must be %q or %q", settings.Region, RegionUS, RegionEU)
+	}
 	if settings.URL == "" {
 		settings.URL = DefaultURL
+		if settings.Region == RegionEU {
+			settings.URL = EUURL
+		}
 	}
 	if settings.Source == "" {
 		source, err := getHostname()
@@ -101,5 +120,24 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		}
 		settings.Source = source
 	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
 	return settings, nil
 }
+
+// ValidateRoutingKey checks that the key looks like an integration or routing key issued by PagerDuty,
+// so that typos are reported when the receiver is saved rather than when the first event is rejected.
+// This is not part of NewConfig, as keys in other formats are accepted in existing configurations.
+func ValidateRoutingKey(key string) error {
+	if len(key) != routingKeyLength {
+		return fmt.Errorf("invalid integration key: must be %d characters long", routingKeyLength)
+	}
+	for _, c := range key {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return errors.New("invalid integration key: must contain only letters and digits")
+		}
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
 	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
@@ -42,9 +43,9 @@ func TestNewConfig(t *testing.T) {
 		},
 		{
 			name:     "Minimal valid configuration",
-			settings: `{"integrationKey": "test-api-key" }`,
+			settings: `{"integrationKey": "0123456789abcdef0123456789abcdef" }`,
 			expectedConfig: Config{
-				Key:       "test-api-key",
+				Key:       "0123456789abcdef0123456789abcdef",
 				Severity:  DefaultSeverity,
 				Details:   defaultDetails,
 				Class:     DefaultClass,
@@ -55,16 +56,17 @@ func TestNewConfig(t *testing.T) {
 				Client:    DefaultClient,
 				ClientURL: "{{ .ExternalURL }}",
 				URL:       DefaultURL,
+				Region:    RegionUS,
 			},
 		},
 		{
 			name:     "Minimal valid configuration",
 			settings: `{}`,
 			secureSettings: map[string][]byte{
-				"integrationKey": []byte("test-api-key"),
+				"integrationKey": []byte("0123456789abcdef0123456789abcdef"),
 			},
 			expectedConfig: Config{
-				Key:       "test-api-key",
+				Key:       "0123456789abcdef0123456789abcdef",
 				Severity:  DefaultSeverity,
 				Details:   defaultDetails,
 				Class:     DefaultClass,
@@ -75,16 +77,17 @@ func TestNewConfig(t *testing.T) {
 				Client:    DefaultClient,
 				ClientURL: "{{ .ExternalURL }}",
 				URL:       DefaultURL,
+				Region:    RegionUS,
 			},
 		},
 		{
 			name:     "Should overwrite token from secrets",
 			settings: `{ "integrationKey": "test" }`,
 			secureSettings: map[string][]byte{
-				"integrationKey": []byte("test-api-key"),
+				"integrationKey": []byte("0123456789abcdef0123456789abcdef"),
 			},
 			expectedConfig: Config{
-				Key:       "test-api-key",
+				Key:       "0123456789abcdef0123456789abcdef",
 				Severity:  DefaultSeverity,
 				Details:   defaultDetails,
 				Class:     DefaultClass,
@@ -95,12 +98,13 @@ func TestNewConfig(t *testing.T) {
 				Client:    DefaultClient,
 				ClientURL: "{{ .ExternalURL }}",
 				URL:       DefaultURL,
+				Region:    RegionUS,
 			},
 		},
 		{
 			name: "All empty fields = minimal valid configuration",
 			secureSettings: map[string][]byte{
-				"integrationKey": []byte("test-api-key"),
+				"integrationKey": []byte("0123456789abcdef0123456789abcdef"),
 			},
 			settings: `{
 				"integrationKey": "", 
@@ -114,7 +118,7 @@ func TestNewConfig(t *testing.T) {
 				"client_url": ""
 			}`,
 			expectedConfig: Config{
-				Key:       "test-api-key",
+				Key:       "0123456789abcdef0123456789abcdef",
 				Severity:  DefaultSeverity,
 				Details:   defaultDetails,
 				Class:     DefaultClass,
@@ -125,13 +129,14 @@ func TestNewConfig(t *testing.T) {
 				Client:    DefaultClient,
 				ClientURL: "{{ .ExternalURL }}",
 				URL:       DefaultURL,
+				Region:    RegionUS,
 			},
 		},
 		{
 			name:     "Extract all fields",
 			settings: FullValidConfigForTesting,
 			expectedConfig: Config{
-				Key:       "test-api-key",
+				Key:       "0123456789abcdef0123456789abcdef",
 				Severity:  "test-severity",
 				Details:   defaultDetails,
 				Class:     "test-class",
@@ -142,6 +147,13 @@ func TestNewConfig(t *testing.T) {
 				Client:    "test-client",
 				ClientURL: "test-client-url",
 				URL:       "test-api-url",
+				Region:    RegionEU,
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 		{
@@ -149,7 +161,7 @@ func TestNewConfig(t *testing.T) {
 			settings:       FullValidConfigForTesting,
 			secureSettings: receiversTesting.ReadSecretsJSONForTesting(FullValidSecretsForTesting),
 			expectedConfig: Config{
-				Key:       "test-secret-api-key",
+				Key:       "fedcba9876543210fedcba9876543210",
 				Severity:  "test-severity",
 				Details:   defaultDetails,
 				Class:     "test-class",
@@ -160,12 +172,78 @@ func TestNewConfig(t *testing.T) {
 				Client:    "test-client",
 				ClientURL: "test-client-url",
 				URL:       "test-api-url",
+				Region:    RegionEU,
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
+			},
+		},
+		{
+			name:     "Should use the EU endpoint for the EU region",
+			settings: `{"integrationKey": "0123456789abcdef0123456789abcdef", "region": "eu"}`,
+			expectedConfig: Config{
+				Key:       "0123456789abcdef0123456789abcdef",
+				Severity:  DefaultSeverity,
+				Details:   defaultDetails,
+				Class:     DefaultClass,
+				Component: "Grafana",
+				Group:     DefaultGroup,
+				Summary:   templates.DefaultMessageTitleEmbed,
+				Source:    hostName,
+				Client:    DefaultClient,
+				ClientURL: "{{ .ExternalURL }}",
+				URL:       EUURL,
+				Region:    RegionEU,
+			},
+		},
+		{
+			name:     "Should prefer the URL over the region",
+			settings: `{"integrationKey": "0123456789abcdef0123456789abcdef", "region": "eu", "url": "http://localhost/enqueue"}`,
+			expectedConfig: Config{
+				Key:       "0123456789abcdef0123456789abcdef",
+				Severity:  DefaultSeverity,
+				Details:   defaultDetails,
+				Class:     DefaultClass,
+				Component: "Grafana",
+				Group:     DefaultGroup,
+				Summary:   templates.DefaultMessageTitleEmbed,
+				Source:    hostName,
+				Client:    DefaultClient,
+				ClientURL: "{{ .ExternalURL }}",
+				URL:       "http://localhost/enqueue",
+				Region:    RegionEU,
+			},
+		},
+		{
+			name:              "Error if region is unknown",
+			settings:          `{"integrationKey": "0123456789abcdef0123456789abcdef", "region": "ap"}`,
+			expectedInitError: `invalid value for region: "ap", must be "us" or "eu"`,
+		},
+		{
+			name:     "Should accept integration key in another format",
+			settings: `{"integrationKey": "legacy-key"}`,
+			expectedConfig: Config{
+				Key:       "legacy-key",
+				Severity:  DefaultSeverity,
+				Details:   defaultDetails,
+				Class:     DefaultClass,
+				Component: "Grafana",
+				Group:     DefaultGroup,
+				Summary:   templates.DefaultMessageTitleEmbed,
+				Source:    hostName,
+				Client:    DefaultClient,
+				ClientURL: "{{ .ExternalURL }}",
+				URL:       DefaultURL,
+				Region:    RegionUS,
 			},
 		},
 		{
 			name: "Should merge default details with user-defined ones",
 			secureSettings: map[string][]byte{
-				"integrationKey": []byte("test-api-key"),
+				"integrationKey": []byte("0123456789abcdef0123456789abcdef"),
 			},
 			settings: `{
 				"details" : {
@@ -174,7 +252,7 @@ func TestNewConfig(t *testing.T) {
 				}
 			}`,
 			expectedConfig: Config{
-				Key:      "test-api-key",
+				Key:      "0123456789abcdef0123456789abcdef",
 				Severity: DefaultSeverity,
 				Details: map[string]string{
 					"firing":       `{{ template "__text_alert_list" .Alerts.Firing }}`,
@@ -192,12 +270,13 @@ func TestNewConfig(t *testing.T) {
 				Client:    DefaultClient,
 				ClientURL: "{{ .ExternalURL }}",
 				URL:       DefaultURL,
+				Region:    RegionUS,
 			},
 		},
 		{
 			name: "Should overwrite default details with user-defined ones when keys are duplicated",
 			secureSettings: map[string][]byte{
-				"integrationKey": []byte("test-api-key"),
+				"integrationKey": []byte("0123456789abcdef0123456789abcdef"),
 			},
 			settings: `{
 				"details" : {
@@ -208,7 +287,7 @@ func TestNewConfig(t *testing.T) {
 				}
 			}`,
 			expectedConfig: Config{
-				Key:      "test-api-key",
+				Key:      "0123456789abcdef0123456789abcdef",
 				Severity: DefaultSeverity,
 				Details: map[string]string{
 					"firing":       "test",
@@ -224,12 +303,13 @@ func TestNewConfig(t *testing.T) {
 				Client:    DefaultClient,
 				ClientURL: "{{ .ExternalURL }}",
 				URL:       DefaultURL,
+				Region:    RegionUS,
 			},
 		},
 		{
 			name: "Custom details should be case-sensitive",
 			secureSettings: map[string][]byte{
-				"integrationKey": []byte("test-api-key"),
+				"integrationKey": []byte("0123456789abcdef0123456789abcdef"),
 			},
 			settings: `{
 				"details" : {
@@ -240,7 +320,7 @@ func TestNewConfig(t *testing.T) {
 				}
 			}`,
 			expectedConfig: Config{
-				Key:      "test-api-key",
+				Key:      "0123456789abcdef0123456789abcdef",
 				Severity: DefaultSeverity,
 				Details: map[string]string{
 					"firing":       `{{ template "__text_alert_list" .Alerts.Firing }}`,
@@ -260,12 +340,13 @@ func TestNewConfig(t *testing.T) {
 				Client:    DefaultClient,
 				ClientURL: "{{ .ExternalURL }}",
 				URL:       DefaultURL,
+				Region:    RegionUS,
 			},
 		},
 		{
 			name: "Source should fallback to client if hostname cannot be resolved",
 			secureSettings: map[string][]byte{
-				"integrationKey": []byte("test-api-key"),
+				"integrationKey": []byte("0123456789abcdef0123456789abcdef"),
 			},
 			settings: `{
 				"client" : "test-client"
@@ -274,7 +355,7 @@ func TestNewConfig(t *testing.T) {
 				return "", errors.New("test")
 			},
 			expectedConfig: Config{
-				Key:       "test-api-key",
+				Key:       "0123456789abcdef0123456789abcdef",
 				Severity:  DefaultSeverity,
 				Details:   defaultDetails,
 				Class:     DefaultClass,
@@ -285,6 +366,7 @@ func TestNewConfig(t *testing.T) {
 				Client:    "test-client",
 				ClientURL: "{{ .ExternalURL }}",
 				URL:       DefaultURL,
+				Region:    RegionUS,
 			},
 		},
 	}
@@ -309,3 +391,10 @@ func TestNewConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRoutingKey(t *testing.T) {
+	require.NoError(t, ValidateRoutingKey("0123456789abcdef0123456789ABCDEF"))
+	require.EqualError(t, ValidateRoutingKey("0123456789abcdef"), "invalid integration key: must be 32 characters long")
+	require.EqualError(t, ValidateRoutingKey("0123456789abcdef0123456789abcde-"), "invalid integration key: must contain only letters and digits")
+	require.EqualError(t, ValidateRoutingKey(" 0123456789abcdef0123456789abcde"), "invalid integration key: must contain only letters and digits")
+}
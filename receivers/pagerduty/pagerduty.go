@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/alecthomas/units"
@@ -61,9 +62,10 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify sends an alert notification to PagerDuty
 func (pn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, pn.log)
 	alerts := types.Alerts(as...)
 	if alerts.Status() == model.AlertResolved && !pn.SendResolved() {
-		pn.log.Debug("not sending a trigger to Pagerduty", "status", alerts.Status(), "auto resolve", pn.SendResolved())
+		l.Debug("not sending a trigger to Pagerduty", "status", alerts.Status(), "auto resolve", pn.SendResolved())
 		return true, nil
 	}
 
@@ -84,7 +86,7 @@ func (pn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		maxEventSize := units.MetricBytes(pagerDutyMaxEventSize).String()
 		truncatedMsg := fmt.Sprintf("Custom details have been removed because the original event exceeds the maximum size of %s", maxEventSize)
 		msg.Payload.CustomDetails = map[string]string{"error": truncatedMsg}
-		pn.log.Warn("Truncated details", "maxSize", maxEventSize, "actualSize", bufSize)
+		l.Warn("Truncated details", "maxSize", maxEventSize, "actualSize", bufSize)
 
 		buf.Reset()
 		if err := json.NewEncoder(&buf).Encode(msg); err != nil {
@@ -92,7 +94,11 @@ func (pn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		}
 	}
 
-	pn.log.Info("notifying Pagerduty", "event_type", eventType)
+	l.Info("notifying Pagerduty", "event_type", eventType)
+	tlsConfig, err := receivers.ClientTLSConfig(pn.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        pn.settings.URL,
 		Body:       buf.String(),
@@ -100,6 +106,8 @@ func (pn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		HTTPHeader: map[string]string{
 			"Content-Type": "application/json",
 		},
+		TLSConfig:  tlsConfig,
+		Validation: validateResponse,
 	}
 	if err := pn.ns.SendWebhook(ctx, cmd); err != nil {
 		return false, fmt.Errorf("send notification to Pagerduty: %w", err)
@@ -108,7 +116,34 @@ func (pn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 	return true, nil
 }
 
+// pagerDutyErrorResponse is the body PagerDuty returns when it rejects an event.
+// https://developer.pagerduty.com/docs/events-api-v2/overview/#response-codes--retry-logic
+type pagerDutyErrorResponse struct {
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Errors  []string `json:"errors"`
+}
+
+// validateResponse returns an error if PagerDuty did not accept the event. For rejected events it includes
+// the details reported by PagerDuty, which usually point at the offending field.
+func validateResponse(body []byte, statusCode int) error {
+	if statusCode/100 == 2 {
+		return nil
+	}
+	if statusCode == http.StatusBadRequest {
+		var resp pagerDutyErrorResponse
+		if err := json.Unmarshal(body, &resp); err == nil && resp.Message != "" {
+			if len(resp.Errors) == 0 {
+				return fmt.Errorf("unexpected status code %d: %s", statusCode, resp.Message)
+			}
+			return fmt.Errorf("unexpected status code %d: %s: %s", statusCode, resp.Message, strings.Join(resp.Errors, "; "))
+		}
+	}
+	return fmt.Errorf("unexpected status code %d: %s", statusCode, body)
+}
+
 func (pn *Notifier) buildPagerdutyMessage(ctx context.Context, alerts model.Alerts, as []*types.Alert) (*pagerDutyMessage, string, error) {
+	l := logging.FromContext(ctx, pn.log)
 	key, err := notify.ExtractGroupKey(ctx)
 	if err != nil {
 		return nil, "", err
@@ -120,11 +155,11 @@ func (pn *Notifier) buildPagerdutyMessage(ctx context.Context, alerts model.Aler
 	}
 
 	var tmplErr error
-	tmpl, data := templates.TmplText(ctx, pn.tmpl, as, pn.log, &tmplErr)
+	tmpl, data := templates.TmplText(ctx, pn.tmpl, as, l, &tmplErr)
 
 	details := make(map[string]string, len(pn.settings.Details))
 	for k, v := range pn.settings.Details {
-		detail, err := pn.tmpl.ExecuteTextString(v, data)
+		detail, err := templates.ExecuteTextString(ctx, templates.TemplateFromContext(ctx, pn.tmpl), v, data)
 		if err != nil {
 			return nil, "", fmt.Errorf("%q: failed to template %q: %w", k, v, err)
 		}
@@ -133,7 +168,7 @@ func (pn *Notifier) buildPagerdutyMessage(ctx context.Context, alerts model.Aler
 
 	severity := strings.ToLower(tmpl(pn.settings.Severity))
 	if _, ok := knownSeverity[severity]; !ok {
-		pn.log.Warn("Severity is not in the list of known values - using default severity", "actualSeverity", severity, "defaultSeverity", DefaultSeverity)
+		l.Warn("Severity is not in the list of known values - using default severity", "actualSeverity", severity, "defaultSeverity", DefaultSeverity)
 		severity = DefaultSeverity
 	}
 
@@ -158,7 +193,7 @@ func (pn *Notifier) buildPagerdutyMessage(ctx context.Context, alerts model.Aler
 		},
 	}
 
-	_ = images.WithStoredImages(ctx, pn.log, pn.images,
+	_ = images.WithStoredImages(ctx, l, pn.images,
 		func(_ int, image images.Image) error {
 			if len(image.URL) != 0 {
 				msg.Images = append(msg.Images, pagerDutyImage{Src: image.URL})
@@ -170,12 +205,12 @@ func (pn *Notifier) buildPagerdutyMessage(ctx context.Context, alerts model.Aler
 
 	summary, truncated := receivers.TruncateInRunes(msg.Payload.Summary, pagerDutyMaxV2SummaryLenRunes)
 	if truncated {
-		pn.log.Warn("Truncated summary", "key", key, "runes", pagerDutyMaxV2SummaryLenRunes)
+		l.Warn("Truncated summary", "key", key, "runes", pagerDutyMaxV2SummaryLenRunes)
 	}
 	msg.Payload.Summary = summary
 
 	if tmplErr != nil {
-		pn.log.Warn("failed to template PagerDuty message", "error", tmplErr.Error())
+		l.Warn("failed to template PagerDuty message", "error", tmplErr.Error())
 	}
 
 	return msg, eventType, nil
@@ -515,3 +515,53 @@ func TestNotify(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateResponse(t *testing.T) {
+	cases := []struct {
+		name       string
+		body       string
+		statusCode int
+		expErr     string
+	}{
+		{
+			name:       "accepted event",
+			body:       `{"status":"success","message":"Event processed","dedup_key":"alertname"}`,
+			statusCode: 202,
+		},
+		{
+			name:       "invalid event with details",
+			body:       `{"status":"invalid event","message":"Event object is invalid","errors":["Length of 'routing_key' is incorrect (should be 32 characters)"]}`,
+			statusCode: 400,
+			expErr:     "unexpected status code 400: Event object is invalid: Length of 'routing_key' is incorrect (should be 32 characters)",
+		},
+		{
+			name:       "invalid event without details",
+			body:       `{"status":"invalid event","message":"Event object is invalid"}`,
+			statusCode: 400,
+			expErr:     "unexpected status code 400: Event object is invalid",
+		},
+		{
+			name:       "bad request with unexpected body",
+			body:       `Bad Request`,
+			statusCode: 400,
+			expErr:     "unexpected status code 400: Bad Request",
+		},
+		{
+			name:       "rate limited",
+			body:       `Too Many Requests`,
+			statusCode: 429,
+			expErr:     "unexpected status code 429: Too Many Requests",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateResponse([]byte(c.body), c.statusCode)
+			if c.expErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, c.expErr)
+		})
+	}
+}
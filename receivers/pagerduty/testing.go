@@ -2,7 +2,7 @@ package pagerduty
 
 // FullValidConfigForTesting is a string representation of a JSON object that contains all fields supported by the notifier Config. It can be used without secrets.
 const FullValidConfigForTesting = `{
-	"integrationKey": "test-api-key", 
+	"integrationKey": "0123456789abcdef0123456789abcdef", 
 	"severity" : "test-severity", 
 	"class" : "test-class", 
 	"component": "test-component", 
@@ -11,10 +11,17 @@ const FullValidConfigForTesting = `{
 	"source": "test-source",
 	"client" : "test-client",
 	"client_url": "test-client-url",
-	"url": "test-api-url"
+	"url": "test-api-url",
+	"region": "eu",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
 const FullValidSecretsForTesting = `{
-	"integrationKey": "test-secret-api-key"
+	"integrationKey": "fedcba9876543210fedcba9876543210"
 }`
@@ -0,0 +1,23 @@
+package receivers
+
+import (
+	"context"
+)
+
+// PayloadObserver is called with the size in bytes of the body of each request sent by an integration.
+type PayloadObserver func(bytes int)
+
+type payloadObserverKey struct{}
+
+// WithPayloadObserver returns a context whose requests report the size of their bodies to observe.
+func WithPayloadObserver(ctx context.Context, observe PayloadObserver) context.Context {
+	return context.WithValue(ctx, payloadObserverKey{}, observe)
+}
+
+// ObservePayload reports the size of the body of a request to the observer of the context. It does nothing if the
+// context has no observer.
+func ObservePayload(ctx context.Context, bytes int) {
+	if observe, ok := ctx.Value(payloadObserverKey{}).(PayloadObserver); ok && observe != nil {
+		observe(bytes)
+	}
+}
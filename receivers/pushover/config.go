@@ -22,6 +22,7 @@ type Config struct {
 	Upload           bool
 	Title            string
 	Message          string
+	TLSConfig        *receivers.TLSConfig
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -39,6 +40,7 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		Upload           *bool                    `json:"uploadImage,omitempty" yaml:"uploadImage,omitempty"`
 		Title            string                   `json:"title,omitempty" yaml:"title,omitempty"`
 		Message          string                   `json:"message,omitempty" yaml:"message,omitempty"`
+		TLSConfig        *receivers.TLSConfig     `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 	}{}
 
 	err := json.Unmarshal(jsonData, &rawSettings)
@@ -96,5 +98,10 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		settings.Title = templates.DefaultMessageTitleEmbed
 	}
 
+	settings.TLSConfig, err = receivers.ParseTLSConfig(rawSettings.TLSConfig, decryptFn)
+	if err != nil {
+		return settings, err
+	}
+
 	return settings, nil
 }
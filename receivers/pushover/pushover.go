@@ -61,21 +61,27 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify sends an alert notification to Slack.
 func (pn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, pn.log)
 	headers, uploadBody, err := pn.genPushoverBody(ctx, as...)
 	if err != nil {
-		pn.log.Error("Failed to generate body for pushover", "error", err)
+		l.Error("Failed to generate body for pushover", "error", err)
 		return false, err
 	}
 
+	tlsConfig, err := receivers.ClientTLSConfig(pn.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        APIURL,
 		HTTPMethod: "POST",
 		HTTPHeader: headers,
 		Body:       uploadBody.String(),
+		TLSConfig:  tlsConfig,
 	}
 
 	if err := pn.ns.SendWebhook(ctx, cmd); err != nil {
-		pn.log.Error("failed to send pushover notification", "error", err, "webhook", pn.Name)
+		l.Error("failed to send pushover notification", "error", err, "webhook", pn.Name)
 		return false, err
 	}
 
@@ -86,6 +92,7 @@ func (pn *Notifier) SendResolved() bool {
 }
 
 func (pn *Notifier) genPushoverBody(ctx context.Context, as ...*types.Alert) (map[string]string, bytes.Buffer, error) {
+	l := logging.FromContext(ctx, pn.log)
 	key, err := notify.ExtractGroupKey(ctx)
 	if err != nil {
 		return nil, bytes.Buffer{}, err
@@ -103,7 +110,7 @@ func (pn *Notifier) genPushoverBody(ctx context.Context, as ...*types.Alert) (ma
 	}
 
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, pn.tmpl, as, pn.log, &tmplErr)
+	tmpl, _ := templates.TmplText(ctx, pn.tmpl, as, l, &tmplErr)
 
 	if err := w.WriteField("user", tmpl(pn.settings.UserKey)); err != nil {
 		return nil, b, fmt.Errorf("failed to write the user: %w", err)
@@ -115,12 +122,12 @@ func (pn *Notifier) genPushoverBody(ctx context.Context, as ...*types.Alert) (ma
 
 	title, truncated := receivers.TruncateInRunes(tmpl(pn.settings.Title), pushoverMaxTitleLenRunes)
 	if truncated {
-		pn.log.Warn("Truncated title", "incident", key, "max_runes", pushoverMaxTitleLenRunes)
+		l.Warn("Truncated title", "incident", key, "max_runes", pushoverMaxTitleLenRunes)
 	}
 	message := tmpl(pn.settings.Message)
 	message, truncated = receivers.TruncateInRunes(message, pushoverMaxMessageLenRunes)
 	if truncated {
-		pn.log.Warn("Truncated message", "incident", key, "max_runes", pushoverMaxMessageLenRunes)
+		l.Warn("Truncated message", "incident", key, "max_runes", pushoverMaxMessageLenRunes)
 	}
 	message = strings.TrimSpace(message)
 	if message == "" {
@@ -128,10 +135,10 @@ func (pn *Notifier) genPushoverBody(ctx context.Context, as ...*types.Alert) (ma
 		message = "(no details)"
 	}
 
-	supplementaryURL := receivers.JoinURLPath(pn.tmpl.ExternalURL.String(), "/alerting/list", pn.log)
+	supplementaryURL := receivers.JoinURLPath(pn.tmpl.ExternalURL.String(), "/alerting/list", l)
 	supplementaryURL, truncated = receivers.TruncateInRunes(supplementaryURL, pushoverMaxURLLenRunes)
 	if truncated {
-		pn.log.Warn("Truncated URL", "incident", key, "max_runes", pushoverMaxURLLenRunes)
+		l.Warn("Truncated URL", "incident", key, "max_runes", pushoverMaxURLLenRunes)
 	}
 
 	status := types.Alerts(as...).Status()
@@ -178,7 +185,7 @@ func (pn *Notifier) genPushoverBody(ctx context.Context, as ...*types.Alert) (ma
 	if pn.settings.Upload {
 		pn.writeImageParts(ctx, w, as...)
 	} else {
-		pn.log.Debug("skip uploading image because of the configuration")
+		l.Debug("skip uploading image because of the configuration")
 	}
 
 	var sound string
@@ -202,7 +209,7 @@ func (pn *Notifier) genPushoverBody(ctx context.Context, as ...*types.Alert) (ma
 	}
 
 	if tmplErr != nil {
-		pn.log.Warn("failed to template pushover message", "error", tmplErr.Error())
+		l.Warn("failed to template pushover message", "error", tmplErr.Error())
 	}
 
 	headers := map[string]string{
@@ -213,16 +220,17 @@ func (pn *Notifier) genPushoverBody(ctx context.Context, as ...*types.Alert) (ma
 }
 
 func (pn *Notifier) writeImageParts(ctx context.Context, w *multipart.Writer, as ...*types.Alert) {
+	l := logging.FromContext(ctx, pn.log)
 	// Pushover supports at most one image attachment with a maximum size of pushoverMaxFileSize.
 	// If the image is larger than pushoverMaxFileSize then return an error.
-	err := images.WithStoredImages(ctx, pn.log, pn.images, func(_ int, image images.Image) error {
+	err := images.WithStoredImages(ctx, l, pn.images, func(_ int, image images.Image) error {
 		f, err := os.Open(image.Path)
 		if err != nil {
 			return fmt.Errorf("failed to open the image: %w", err)
 		}
 		defer func() {
 			if err := f.Close(); err != nil {
-				pn.log.Error("failed to close the image", "file", image.Path)
+				l.Error("failed to close the image", "file", image.Path)
 			}
 		}()
 
@@ -247,6 +255,6 @@ func (pn *Notifier) writeImageParts(ctx context.Context, w *multipart.Writer, as
 		return images.ErrImagesDone
 	}, as...)
 	if err != nil {
-		pn.log.Error("failed to fetch image for the notification", "error", err)
+		l.Error("failed to fetch image for the notification", "error", err)
 	}
 }
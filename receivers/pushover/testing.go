@@ -13,7 +13,13 @@ const FullValidConfigForTesting = `{
 	"title": "test-title",
 	"message": "test-message",
 	"userKey": "test-user-key",
-	"apiToken": "test-api-token"
+	"apiToken": "test-api-token",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
@@ -0,0 +1,74 @@
+package receivers
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// MaxDecodedResponseBodySize is the maximum size of a response body after it is decompressed by DecodeResponseBody.
+const MaxDecodedResponseBodySize = 64 * 1024
+
+// ReadResponseBody reads at most limit bytes of the body of the response and decodes them with DecodeResponseBody.
+// It is meant for the bodies of responses that are logged, returned in errors, or passed to the Validation of
+// SendWebhookSettings.
+func ReadResponseBody(resp *http.Response, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+	return DecodeResponseBody(resp.Header, body), nil
+}
+
+// DecodeResponseBody returns the body of a response as UTF-8 text. Some proxies return compressed error pages even
+// if the request did not accept them, so bodies encoded with gzip or deflate are decompressed, up to
+// MaxDecodedResponseBodySize. Bodies with other encodings, such as zstd, are replaced by a description of the body.
+// Bodies in a charset other than UTF-8, as declared by the Content-Type header, are converted to UTF-8, and
+// invalid UTF-8 sequences are replaced with the Unicode replacement character.
+func DecodeResponseBody(header http.Header, body []byte) []byte {
+	switch encoding := strings.ToLower(strings.TrimSpace(header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return undecodableBody(encoding, body)
+		}
+		body = readDecompressed(r)
+	case "deflate":
+		body = readDecompressed(flate.NewReader(bytes.NewReader(body)))
+	default:
+		return undecodableBody(encoding, body)
+	}
+
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Type")); err == nil && params["charset"] != "" {
+		if enc, err := htmlindex.Get(params["charset"]); err == nil {
+			if decoded, err := enc.NewDecoder().Bytes(body); err == nil {
+				body = decoded
+			}
+		}
+	}
+	if !utf8.Valid(body) {
+		body = bytes.ToValidUTF8(body, []byte("�"))
+	}
+	return body
+}
+
+// readDecompressed returns what can be decompressed from the reader. Bodies are often truncated before they are
+// decoded, so the data decompressed before an error is kept.
+func readDecompressed(r io.ReadCloser) []byte {
+	defer r.Close()
+	b, _ := io.ReadAll(io.LimitReader(r, MaxDecodedResponseBodySize))
+	return b
+}
+
+func undecodableBody(encoding string, body []byte) []byte {
+	return []byte(fmt.Sprintf("<%d bytes of %s-encoded body>", len(body), encoding))
+}
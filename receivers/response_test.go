@@ -0,0 +1,129 @@
+package receivers
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeResponseBody(t *testing.T) {
+	const page = "<html><body>502 Bad Gateway</body></html>"
+	gzipped := func(s string) []byte {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+	deflated := func(s string) []byte {
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(s))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	cases := []struct {
+		name     string
+		header   http.Header
+		body     []byte
+		expected string
+	}{
+		{
+			name:     "plain body",
+			header:   http.Header{"Content-Type": []string{"text/html"}},
+			body:     []byte(page),
+			expected: page,
+		},
+		{
+			name:     "gzip body",
+			header:   http.Header{"Content-Encoding": []string{"gzip"}},
+			body:     gzipped(page),
+			expected: page,
+		},
+		{
+			name:     "x-gzip body",
+			header:   http.Header{"Content-Encoding": []string{"X-Gzip"}},
+			body:     gzipped(page),
+			expected: page,
+		},
+		{
+			name:     "deflate body",
+			header:   http.Header{"Content-Encoding": []string{"deflate"}},
+			body:     deflated(page),
+			expected: page,
+		},
+		{
+			name:     "invalid gzip body",
+			header:   http.Header{"Content-Encoding": []string{"gzip"}},
+			body:     []byte(page),
+			expected: "<41 bytes of gzip-encoded body>",
+		},
+		{
+			name:     "unsupported encoding",
+			header:   http.Header{"Content-Encoding": []string{"zstd"}},
+			body:     []byte{0x28, 0xb5, 0x2f, 0xfd},
+			expected: "<4 bytes of zstd-encoded body>",
+		},
+		{
+			name:     "latin-1 body",
+			header:   http.Header{"Content-Type": []string{"text/plain; charset=ISO-8859-1"}},
+			body:     []byte("Acc\xe8s refus\xe9"),
+			expected: "Accès refusé",
+		},
+		{
+			name:     "gzip latin-1 body",
+			header:   http.Header{"Content-Encoding": []string{"gzip"}, "Content-Type": []string{"text/plain; charset=windows-1252"}},
+			body:     gzipped("Acc\xe8s refus\xe9"),
+			expected: "Accès refusé",
+		},
+		{
+			name:     "invalid UTF-8 body",
+			header:   http.Header{},
+			body:     []byte("bad \xff\xfe byte"),
+			expected: "bad � byte",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.expected, string(DecodeResponseBody(c.header, c.body)))
+		})
+	}
+
+	t.Run("truncated gzip body", func(t *testing.T) {
+		body := DecodeResponseBody(http.Header{"Content-Encoding": []string{"gzip"}}, gzipped(strings.Repeat("error ", 1000))[:40])
+		require.NotEmpty(t, body)
+		require.True(t, strings.HasPrefix(strings.Repeat("error ", 1000), string(body)))
+	})
+}
+
+func TestDecodeResponseBody_Limit(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(bytes.Repeat([]byte("a"), 10*MaxDecodedResponseBodySize))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	body := DecodeResponseBody(http.Header{"Content-Encoding": []string{"gzip"}}, buf.Bytes())
+	require.Len(t, body, MaxDecodedResponseBodySize)
+}
+
+func TestReadResponseBody(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"text/plain; charset=iso-8859-1"}},
+		Body:   io.NopCloser(strings.NewReader("Acc\xe8s refus\xe9")),
+	}
+	body, err := ReadResponseBody(resp, 4)
+	require.NoError(t, err)
+	require.Equal(t, "Accè", string(body))
+}
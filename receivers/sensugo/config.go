@@ -10,13 +10,14 @@ import (
 )
 
 type Config struct {
-	URL       string `json:"url,omitempty" yaml:"url,omitempty"`
-	Entity    string `json:"entity,omitempty" yaml:"entity,omitempty"`
-	Check     string `json:"check,omitempty" yaml:"check,omitempty"`
-	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
-	Handler   string `json:"handler,omitempty" yaml:"handler,omitempty"`
-	APIKey    string `json:"apikey,omitempty" yaml:"apikey,omitempty"`
-	Message   string `json:"message,omitempty" yaml:"message,omitempty"`
+	URL       string               `json:"url,omitempty" yaml:"url,omitempty"`
+	Entity    string               `json:"entity,omitempty" yaml:"entity,omitempty"`
+	Check     string               `json:"check,omitempty" yaml:"check,omitempty"`
+	Namespace string               `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Handler   string               `json:"handler,omitempty" yaml:"handler,omitempty"`
+	APIKey    string               `json:"apikey,omitempty" yaml:"apikey,omitempty"`
+	Message   string               `json:"message,omitempty" yaml:"message,omitempty"`
+	TLSConfig *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -35,5 +36,9 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	if settings.Message == "" {
 		settings.Message = templates.DefaultMessageEmbed
 	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return settings, err
+	}
 	return settings, nil
 }
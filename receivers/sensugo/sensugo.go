@@ -44,10 +44,11 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify sends an alert notification to Sensu Go
 func (sn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	sn.log.Debug("sending Sensu Go result")
+	l := logging.FromContext(ctx, sn.log)
+	l.Debug("sending Sensu Go result")
 
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, sn.tmpl, as, sn.log, &tmplErr)
+	tmpl, _ := templates.TmplText(ctx, sn.tmpl, as, l, &tmplErr)
 
 	// Sensu Go alerts require an entity and a check. We set it to the user-specified
 	// value (optional), else we fallback and use the grafana rule anme  and ruleID.
@@ -80,7 +81,7 @@ func (sn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 
 	labels := make(map[string]string)
 
-	_ = images.WithStoredImages(ctx, sn.log, sn.images,
+	_ = images.WithStoredImages(ctx, l, sn.images,
 		func(_ int, image images.Image) error {
 			// If there is an image for this alert and the image has been uploaded
 			// to a public URL then add it to the request. We cannot add more than
@@ -92,7 +93,7 @@ func (sn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 			return nil
 		}, as...)
 
-	ruleURL := receivers.JoinURLPath(sn.tmpl.ExternalURL.String(), "/alerting/list", sn.log)
+	ruleURL := receivers.JoinURLPath(sn.tmpl.ExternalURL.String(), "/alerting/list", l)
 	labels["ruleURL"] = ruleURL
 
 	bodyMsgType := map[string]interface{}{
@@ -117,7 +118,7 @@ func (sn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 	}
 
 	if tmplErr != nil {
-		sn.log.Warn("failed to template sensugo message", "error", tmplErr.Error())
+		l.Warn("failed to template sensugo message", "error", tmplErr.Error())
 	}
 
 	body, err := json.Marshal(bodyMsgType)
@@ -125,6 +126,10 @@ func (sn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		return false, err
 	}
 
+	tlsConfig, err := receivers.ClientTLSConfig(sn.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        fmt.Sprintf("%s/api/core/v2/namespaces/%s/events", strings.TrimSuffix(sn.settings.URL, "/"), namespace),
 		Body:       string(body),
@@ -133,9 +138,10 @@ func (sn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 			"Content-Type":  "application/json",
 			"Authorization": fmt.Sprintf("Key %s", sn.settings.APIKey),
 		},
+		TLSConfig: tlsConfig,
 	}
 	if err := sn.ns.SendWebhook(ctx, cmd); err != nil {
-		sn.log.Error("failed to send Sensu Go event", "error", err, "sensugo", sn.Name)
+		l.Error("failed to send Sensu Go event", "error", err, "sensugo", sn.Name)
 		return false, err
 	}
 
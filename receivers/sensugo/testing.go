@@ -8,7 +8,13 @@ const FullValidConfigForTesting = `{
 	"check" : "test-check",
 	"namespace" : "test-namespace",
 	"handler" : "test-handler",
-	"message" : "test-message"
+	"message" : "test-message",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
@@ -0,0 +1,50 @@
+package receivers
+
+import (
+	"fmt"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alerting/models"
+	"github.com/grafana/alerting/templates"
+)
+
+// AlertSilenceLink is the link of a notification to silence one of its alerts.
+type AlertSilenceLink struct {
+	// Title is the title of the link, such as of a button. It has the name of the alert if the notification has
+	// several links.
+	Title string
+	URL   string
+}
+
+// AlertSilenceLinks returns the links to silence exactly each firing alert of a notification, see
+// templates.ExactSilenceLink. There are at most limit links, or no limit if limit is not positive. There are no
+// links if the template has no external URL.
+func AlertSilenceLinks(tmpl *templates.Template, alerts []*types.Alert, limit int) []AlertSilenceLink {
+	if tmpl == nil || tmpl.ExternalURL == nil {
+		return nil
+	}
+	var firing []*types.Alert
+	for _, a := range alerts {
+		if a.Status() == model.AlertFiring {
+			firing = append(firing, a)
+		}
+	}
+	if limit > 0 && len(firing) > limit {
+		firing = firing[:limit]
+	}
+
+	links := make([]AlertSilenceLink, 0, len(firing))
+	for _, a := range firing {
+		link := AlertSilenceLink{
+			Title: "Silence",
+			URL:   templates.ExactSilenceLink(a.Labels, string(a.Annotations[models.OrgIDAnnotation])).URL(*tmpl.ExternalURL),
+		}
+		if len(firing) > 1 {
+			link.Title = fmt.Sprintf("Silence %s", a.Name())
+		}
+		links = append(links, link)
+	}
+	return links
+}
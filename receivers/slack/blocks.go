@@ -0,0 +1,95 @@
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+const (
+	// slackMaxBlocks is the maximum number of blocks of a message, https://api.slack.com/reference/block-kit/blocks.
+	slackMaxBlocks = 50
+	// slackMaxActions is the maximum number of buttons of an attachment.
+	slackMaxActions = 5
+	// slackMaxButtonURLLen is the maximum length of the URL of a button, https://api.slack.com/reference/block-kit/block-elements#button.
+	slackMaxButtonURLLen = 3000
+)
+
+// parseBlocks parses the executed template of the blocks of a message, which must be a JSON array of Block Kit
+// blocks. Blocks are only checked to be objects with a type, Slack rejects messages with other invalid blocks.
+func parseBlocks(s string) ([]map[string]interface{}, error) {
+	var blocks []map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &blocks); err != nil {
+		return nil, fmt.Errorf("blocks must be a JSON array of objects: %w", err)
+	}
+	if len(blocks) == 0 {
+		return nil, errors.New("blocks must not be empty")
+	}
+	for i, b := range blocks {
+		if t, _ := b["type"].(string); t == "" {
+			return nil, fmt.Errorf("block %d has no type", i)
+		}
+	}
+	return blocks, nil
+}
+
+// silenceActions returns the buttons to silence each firing alert. Links that are too long for a button are left
+// out, as Slack would reject the message.
+func silenceActions(tmpl *templates.Template, alerts []*types.Alert) []attachmentAction {
+	var actions []attachmentAction
+	for _, link := range receivers.AlertSilenceLinks(tmpl, alerts, slackMaxActions) {
+		if len(link.URL) > slackMaxButtonURLLen {
+			continue
+		}
+		actions = append(actions, attachmentAction{Type: "button", Text: link.Title, URL: link.URL})
+	}
+	return actions
+}
+
+// withBlocks replaces the attachment of the message with the blocks. The mentions, the image, the links to
+// the dashboards of the images and the buttons of the attachment are added as blocks, and the title of the
+// attachment is the text of the message, which Slack shows in notifications.
+func withBlocks(m *slackMessage, blocks []map[string]interface{}) error {
+	a := m.Attachments[0]
+	var res []map[string]interface{}
+	if a.Pretext != "" {
+		res = append(res, mrkdwnSection(a.Pretext))
+	}
+	res = append(res, blocks...)
+	if a.ImageURL != "" {
+		res = append(res, map[string]interface{}{"type": "image", "image_url": a.ImageURL, "alt_text": a.Title})
+	}
+	for _, f := range a.Fields {
+		res = append(res, mrkdwnSection(fmt.Sprintf("*%s*\n%s", f.Title, f.Value)))
+	}
+	if len(a.Actions) > 0 {
+		elements := make([]interface{}, 0, len(a.Actions))
+		for _, action := range a.Actions {
+			elements = append(elements, map[string]interface{}{
+				"type": "button",
+				"text": map[string]interface{}{"type": "plain_text", "text": action.Text},
+				"url":  action.URL,
+			})
+		}
+		res = append(res, map[string]interface{}{"type": "actions", "elements": elements})
+	}
+	if len(res) > slackMaxBlocks {
+		return fmt.Errorf("messages can have at most %d blocks, got %d", slackMaxBlocks, len(res))
+	}
+
+	m.Blocks = res
+	if m.Text == "" {
+		m.Text = a.Fallback
+	}
+	m.Attachments = nil
+	return nil
+}
+
+func mrkdwnSection(text string) map[string]interface{} {
+	return map[string]interface{}{"type": "section", "text": map[string]interface{}{"type": "mrkdwn", "text": text}}
+}
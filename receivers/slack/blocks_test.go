@@ -0,0 +1,115 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/templates"
+)
+
+func TestParseBlocks(t *testing.T) {
+	blocks, err := parseBlocks(`[{"type": "divider"}]`)
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{{"type": "divider"}}, blocks)
+
+	_, err = parseBlocks(`{"type": "divider"}`)
+	require.ErrorContains(t, err, "blocks must be a JSON array of objects")
+	_, err = parseBlocks(`[]`)
+	require.EqualError(t, err, "blocks must not be empty")
+	_, err = parseBlocks(`[{"type": "divider"}, {"text": "no type"}]`)
+	require.EqualError(t, err, "block 1 has no type")
+}
+
+func TestNotify_Blocks(t *testing.T) {
+	settings := Config{
+		EndpointURL:  APIURL,
+		URL:          APIURL,
+		Token:        "1234",
+		Recipient:    "#test",
+		Text:         templates.DefaultMessageEmbed,
+		Title:        "{{ .CommonLabels.alertname }} is {{ .Status }}",
+		MentionUsers: []string{"user"},
+	}
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels: model.LabelSet{"alertname": "alert1"},
+		},
+	}}
+	notifyMessage := func(t *testing.T, blocks string) map[string]interface{} {
+		s := settings
+		s.Blocks = blocks
+		sn, recorder, err := setupSlackForTests(t, s)
+		require.NoError(t, err)
+
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+		ok, err := sn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.Len(t, recorder.requests, 1)
+		b, err := io.ReadAll(recorder.requests[0].Body)
+		require.NoError(t, err)
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal(b, &m))
+		return m
+	}
+
+	t.Run("blocks replace the attachment", func(t *testing.T) {
+		m := notifyMessage(t, `[{"type": "header", "text": {"type": "plain_text", "text": "{{ .CommonLabels.alertname }}"}}]`)
+		require.NotContains(t, m, "attachments")
+		require.Equal(t, "alert1 is firing", m["text"])
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"type": "section", "text": map[string]interface{}{"type": "mrkdwn", "text": "<@user>"}},
+			map[string]interface{}{"type": "header", "text": map[string]interface{}{"type": "plain_text", "text": "alert1"}},
+			map[string]interface{}{"type": "actions", "elements": []interface{}{map[string]interface{}{
+				"type": "button",
+				"text": map[string]interface{}{"type": "plain_text", "text": "Silence"},
+				"url":  "http://localhost/alerting/silence/new?alertmanager=grafana&duration=2h&matcher=alertname%3D%22alert1%22",
+			}}},
+		}, m["blocks"])
+	})
+
+	t.Run("invalid blocks fall back to the attachment", func(t *testing.T) {
+		m := notifyMessage(t, `{{ .CommonLabels.alertname }}`)
+		require.NotContains(t, m, "blocks")
+		require.Len(t, m["attachments"], 1)
+	})
+
+	t.Run("blocks that fail to template fall back to the attachment", func(t *testing.T) {
+		m := notifyMessage(t, `[{{ template "missing" . }}]`)
+		require.NotContains(t, m, "blocks")
+		require.Len(t, m["attachments"], 1)
+	})
+}
+
+func TestSilenceActions(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	var alerts []*types.Alert
+	for i := 0; i < slackMaxActions+1; i++ {
+		alerts = append(alerts, &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("alert%d", i))}}})
+	}
+	actions := silenceActions(tmpl, alerts)
+	require.Len(t, actions, slackMaxActions)
+	require.Equal(t, "Silence alert0", actions[0].Text)
+
+	// Links that are too long for a button are left out.
+	alerts[1].Labels["description"] = model.LabelValue(strings.Repeat("a", slackMaxButtonURLLen))
+	actions = silenceActions(tmpl, alerts)
+	require.Len(t, actions, slackMaxActions-1)
+	require.Equal(t, "Silence alert2", actions[1].Text)
+}
@@ -24,8 +24,32 @@ type Config struct {
 	MentionChannel string                          `json:"mentionChannel,omitempty" yaml:"mentionChannel,omitempty"`
 	MentionUsers   receivers.CommaSeparatedStrings `json:"mentionUsers,omitempty" yaml:"mentionUsers,omitempty"`
 	MentionGroups  receivers.CommaSeparatedStrings `json:"mentionGroups,omitempty" yaml:"mentionGroups,omitempty"`
+	// ImageAltText is the template for the alternative text of uploaded images. It is executed once per image
+	// with the data of the alert the image belongs to.
+	ImageAltText string `json:"imageAltText,omitempty" yaml:"imageAltText,omitempty"`
+	// AccessibleMessage sets the message text to a plain-language status summary followed by the title,
+	// so that screen readers do not have to rely on the color of the attachment to convey the state of the alerts.
+	AccessibleMessage bool `json:"accessibleMessage,omitempty" yaml:"accessibleMessage,omitempty"`
+	// UpdateMode, if set to "summary", posts a single summary message per alert group and updates it on subsequent
+	// notifications instead of posting new messages. Changes of the alerts of the group are posted as replies in its thread.
+	// It requires the Slack chat API. If set to "thread", the first message of an alert group starts a thread, and
+	// subsequent notifications of the group are posted as replies in it until all of its alerts are resolved.
+	UpdateMode string `json:"updateMode,omitempty" yaml:"updateMode,omitempty"`
+	// Blocks is a template of a JSON array of Block Kit blocks. If set, messages have these blocks instead of an
+	// attachment with the title and the text, and the title is the text that Slack shows in notifications. Messages
+	// have an attachment if the blocks fail to template or are invalid.
+	Blocks string `json:"blocks,omitempty" yaml:"blocks,omitempty"`
+	// ConvertMarkdown converts the markdown of the text to Slack mrkdwn, see markdown.Convert.
+	ConvertMarkdown bool                 `json:"convertMarkdown,omitempty" yaml:"convertMarkdown,omitempty"`
+	TLSConfig       *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
+// UpdateModeSummary is the update mode that updates a summary message per alert group.
+const UpdateModeSummary = "summary"
+
+// UpdateModeThread is the update mode that posts the notifications of an alert group in a thread.
+const UpdateModeThread = "thread"
+
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
 	var settings Config
 	err := json.Unmarshal(jsonData, &settings)
@@ -58,6 +82,15 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	if settings.Token == "" && settings.URL == APIURL {
 		return Config{}, errors.New("token must be specified when using the Slack chat API")
 	}
+	if settings.UpdateMode != "" && settings.UpdateMode != UpdateModeSummary && settings.UpdateMode != UpdateModeThread {
+		return Config{}, fmt.Errorf("invalid value for updateMode: %q", settings.UpdateMode)
+	}
+	if settings.UpdateMode != "" && settings.Token == "" {
+		return Config{}, fmt.Errorf("updateMode %s requires the Slack chat API, token must be specified", settings.UpdateMode)
+	}
+	if settings.UpdateMode == UpdateModeSummary && settings.Blocks != "" {
+		return Config{}, errors.New("blocks are not supported with updateMode summary")
+	}
 	if settings.Username == "" {
 		settings.Username = "Grafana"
 	}
@@ -67,6 +100,13 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	if settings.Title == "" {
 		settings.Title = templates.DefaultMessageTitleEmbed
 	}
+	if settings.ImageAltText == "" {
+		settings.ImageAltText = templates.DefaultImageAltTextEmbed
+	}
 
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
 	return settings, nil
 }
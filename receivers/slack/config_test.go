@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
 	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
@@ -54,6 +55,7 @@ func TestNewConfig(t *testing.T) {
 				MentionChannel: "",
 				MentionUsers:   nil,
 				MentionGroups:  nil,
+				ImageAltText:   templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
@@ -75,6 +77,7 @@ func TestNewConfig(t *testing.T) {
 				MentionChannel: "",
 				MentionUsers:   nil,
 				MentionGroups:  nil,
+				ImageAltText:   templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
@@ -93,6 +96,7 @@ func TestNewConfig(t *testing.T) {
 				MentionChannel: "",
 				MentionUsers:   nil,
 				MentionGroups:  nil,
+				ImageAltText:   templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
@@ -114,6 +118,7 @@ func TestNewConfig(t *testing.T) {
 				MentionChannel: "",
 				MentionUsers:   nil,
 				MentionGroups:  nil,
+				ImageAltText:   templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
@@ -149,6 +154,7 @@ func TestNewConfig(t *testing.T) {
 				MentionChannel: "",
 				MentionUsers:   nil,
 				MentionGroups:  nil,
+				ImageAltText:   templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
@@ -178,6 +184,7 @@ func TestNewConfig(t *testing.T) {
 				MentionChannel: "here",
 				MentionUsers:   nil,
 				MentionGroups:  nil,
+				ImageAltText:   templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
@@ -199,6 +206,7 @@ func TestNewConfig(t *testing.T) {
 				MentionChannel: "channel",
 				MentionUsers:   nil,
 				MentionGroups:  nil,
+				ImageAltText:   templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
@@ -224,6 +232,7 @@ func TestNewConfig(t *testing.T) {
 					"user-3",
 				},
 				MentionGroups: nil,
+				ImageAltText:  templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
@@ -249,24 +258,55 @@ func TestNewConfig(t *testing.T) {
 					"users-2",
 					"users-3",
 				},
+				ImageAltText: templates.DefaultImageAltTextEmbed,
 			},
 		},
+		{
+			name:              "Error if invalid updateMode",
+			settings:          `{ "recipient" : "test-recipient", "token": "test-token", "updateMode": "edit" }`,
+			expectedInitError: `invalid value for updateMode: "edit"`,
+		},
+		{
+			name:              "Error if updateMode summary with incoming webhook",
+			settings:          `{ "url": "http://localhost/webhook", "updateMode": "summary" }`,
+			expectedInitError: `updateMode summary requires the Slack chat API, token must be specified`,
+		},
+		{
+			name:              "Error if updateMode thread without token",
+			settings:          `{ "url": "http://localhost/webhook", "updateMode": "thread" }`,
+			expectedInitError: `updateMode thread requires the Slack chat API, token must be specified`,
+		},
+		{
+			name:              "Error if blocks with updateMode summary",
+			settings:          `{ "token": "test-token", "recipient": "test-recipient", "updateMode": "summary", "blocks": "[]" }`,
+			expectedInitError: `blocks are not supported with updateMode summary`,
+		},
 		{
 			name:     "Extract all fields",
 			settings: FullValidConfigForTesting,
 			expectedConfig: Config{
-				EndpointURL:    "http://localhost/endpoint_url",
-				URL:            "http://localhost/url",
-				Token:          "test-token",
-				Recipient:      "test-recipient",
-				Text:           "test-text",
-				Title:          "test-title",
-				Username:       "test-username",
-				IconEmoji:      "test-icon",
-				IconURL:        "http://localhost/icon_url",
-				MentionChannel: "channel",
-				MentionUsers:   []string{"test-mentionUsers"},
-				MentionGroups:  []string{"test-mentionGroups"},
+				EndpointURL:       "http://localhost/endpoint_url",
+				URL:               "http://localhost/url",
+				Token:             "test-token",
+				Recipient:         "test-recipient",
+				Text:              "test-text",
+				Title:             "test-title",
+				Username:          "test-username",
+				IconEmoji:         "test-icon",
+				IconURL:           "http://localhost/icon_url",
+				MentionChannel:    "channel",
+				MentionUsers:      []string{"test-mentionUsers"},
+				MentionGroups:     []string{"test-mentionGroups"},
+				ImageAltText:      "test-image-alt-text",
+				AccessibleMessage: true,
+				UpdateMode:        UpdateModeSummary,
+				ConvertMarkdown:   true,
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 		{
@@ -274,18 +314,28 @@ func TestNewConfig(t *testing.T) {
 			settings:       FullValidConfigForTesting,
 			secureSettings: receiversTesting.ReadSecretsJSONForTesting(FullValidSecretsForTesting),
 			expectedConfig: Config{
-				EndpointURL:    "http://localhost/endpoint_url",
-				URL:            "http://localhost/url-secret",
-				Token:          "test-secret-token",
-				Recipient:      "test-recipient",
-				Text:           "test-text",
-				Title:          "test-title",
-				Username:       "test-username",
-				IconEmoji:      "test-icon",
-				IconURL:        "http://localhost/icon_url",
-				MentionChannel: "channel",
-				MentionUsers:   []string{"test-mentionUsers"},
-				MentionGroups:  []string{"test-mentionGroups"},
+				EndpointURL:       "http://localhost/endpoint_url",
+				URL:               "http://localhost/url-secret",
+				Token:             "test-secret-token",
+				Recipient:         "test-recipient",
+				Text:              "test-text",
+				Title:             "test-title",
+				Username:          "test-username",
+				IconEmoji:         "test-icon",
+				IconURL:           "http://localhost/icon_url",
+				MentionChannel:    "channel",
+				MentionUsers:      []string{"test-mentionUsers"},
+				MentionGroups:     []string{"test-mentionGroups"},
+				ImageAltText:      "test-image-alt-text",
+				AccessibleMessage: true,
+				UpdateMode:        UpdateModeSummary,
+				ConvertMarkdown:   true,
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 	}
@@ -25,6 +25,7 @@ import (
 	"github.com/grafana/alerting/images"
 	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/markdown"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -35,8 +36,16 @@ const (
 	maxImagesPerThreadTs        = 5
 	maxImagesPerThreadTsMessage = "There are more images than can be shown here. To see the panels for all firing and resolved alerts please check Grafana"
 	footerIconURL               = "https://grafana.com/static/assets/img/fav32.png"
+	// imageUploadsShare is the share of the time left to the notification that uploading images can use.
+	imageUploadsShare = 0.75
+	// maxUploadAttempts is the number of attempts of each step of the upload of an image.
+	maxUploadAttempts = 3
 )
 
+// uploadRetryBackoff is the time to wait between the attempts of a step of the upload of an image.
+// It is a variable to be overridable in tests.
+var uploadRetryBackoff = time.Second
+
 // APIURL of where the notification payload is sent. It is public to be overridable in integration tests.
 var APIURL = "https://slack.com/api/chat.postMessage"
 
@@ -56,6 +65,21 @@ var (
 	}
 )
 
+type httpClientKey struct{}
+
+// withHTTPClient returns a context with the HTTP client that sends the requests of a notification, such as a
+// client with the TLS configuration of the integration.
+func withHTTPClient(ctx context.Context, client *http.Client) context.Context {
+	return context.WithValue(ctx, httpClientKey{}, client)
+}
+
+func httpClient(ctx context.Context) *http.Client {
+	if client, ok := ctx.Value(httpClientKey{}).(*http.Client); ok {
+		return client
+	}
+	return slackClient
+}
+
 type sendMessageFunc func(ctx context.Context, req *http.Request, logger logging.Logger) (string, error)
 
 type initFileUploadFunc func(ctx context.Context, req *http.Request, logger logging.Logger) (*FileUploadURLResponse, error)
@@ -79,6 +103,9 @@ type Notifier struct {
 	initFileUploadFn     initFileUploadFunc
 	uploadFileFn         uploadFileFunc
 	completeFileUploadFn completeFileUploadFunc
+	chatAPIFn            chatAPIFunc
+	summaries            *summaryStore
+	now                  func() time.Time
 	settings             Config
 	appVersion           string
 }
@@ -109,6 +136,9 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 		initFileUploadFn:     initFileUpload,
 		uploadFileFn:         uploadFile,
 		completeFileUploadFn: completeFileUpload,
+		chatAPIFn:            sendChatAPIRequest,
+		summaries:            defaultSummaries,
+		now:                  time.Now,
 		log:                  logger,
 		tmpl:                 template,
 		appVersion:           appVersion,
@@ -122,7 +152,7 @@ type slackMessage struct {
 	Username    string                   `json:"username,omitempty"`
 	IconEmoji   string                   `json:"icon_emoji,omitempty"`
 	IconURL     string                   `json:"icon_url,omitempty"`
-	Attachments []attachment             `json:"attachments"`
+	Attachments []attachment             `json:"attachments,omitempty"`
 	Blocks      []map[string]interface{} `json:"blocks,omitempty"`
 	ThreadTs    string                   `json:"thread_ts,omitempty"`
 }
@@ -141,6 +171,14 @@ type attachment struct {
 	Ts         int64                 `json:"ts,omitempty"`
 	Pretext    string                `json:"pretext,omitempty"`
 	MrkdwnIn   []string              `json:"mrkdwn_in,omitempty"`
+	Actions    []attachmentAction    `json:"actions,omitempty"`
+}
+
+// attachmentAction is a link button of an attachment.
+type attachmentAction struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	URL  string `json:"url"`
 }
 
 // generic api response from slack
@@ -172,44 +210,101 @@ type CompleteFileUploadRequest struct {
 
 // Notify sends an alert notification to Slack.
 func (sn *Notifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
-	sn.log.Debug("Creating slack message", "alerts", len(alerts))
+	l := logging.FromContext(ctx, sn.log)
+	l.Debug("Creating slack message", "alerts", len(alerts))
+
+	tlsConfig, err := receivers.ClientTLSConfig(sn.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	if tlsConfig != nil {
+		ctx = withHTTPClient(ctx, receivers.NewTLSClient(tlsConfig))
+	}
+
+	switch sn.settings.UpdateMode {
+	case UpdateModeSummary:
+		return sn.notifySummary(ctx, alerts)
+	case UpdateModeThread:
+		return sn.notifyThread(ctx, alerts)
+	}
 
 	m, err := sn.createSlackMessage(ctx, alerts)
 	if err != nil {
-		sn.log.Error("Failed to create Slack message", "err", err)
+		l.Error("Failed to create Slack message", "err", err)
 		return false, fmt.Errorf("failed to create Slack message: %w", err)
 	}
 
 	threadTs, err := sn.sendSlackMessage(ctx, m)
 	if err != nil {
-		sn.log.Error("Failed to send Slack message", "err", err)
+		l.Error("Failed to send Slack message", "err", err)
 		return false, fmt.Errorf("failed to send Slack message: %w", err)
 	}
 
 	// Do not upload images if using an incoming webhook as incoming webhooks cannot upload files
 	if !isIncomingWebhook(sn.settings) {
-		if err := images.WithStoredImages(ctx, sn.log, sn.images, func(index int, image images.Image) error {
-			// If we have exceeded the maximum number of images for this threadTs
-			// then tell the recipient and stop iterating subsequent images
-			if index >= maxImagesPerThreadTs {
-				if _, err := sn.sendSlackMessage(ctx, &slackMessage{
-					Channel:  sn.settings.Recipient,
-					Text:     maxImagesPerThreadTsMessage,
-					ThreadTs: threadTs,
-				}); err != nil {
-					sn.log.Error("Failed to send Slack message", "err", err)
-				}
-				return images.ErrImagesDone
+		sn.uploadImages(ctx, alerts, threadTs)
+	}
+
+	return true, nil
+}
+
+// uploadImages uploads the images of the alerts as replies in the thread of the message. The images that
+// could not be resolved are replaced with a reply that links to their dashboards.
+func (sn *Notifier) uploadImages(ctx context.Context, alerts []*types.Alert, threadTs string) {
+	l := logging.FromContext(ctx, sn.log)
+	var fallbacks []string
+	// Images are uploaded with part of the time left, so that the fallback reply can still be sent after slow uploads.
+	uploadCtx, cancel := receivers.StepContext(ctx, imageUploadsShare)
+	defer cancel()
+	if err := images.WithStoredImagesOrFallback(uploadCtx, l, sn.images, func(index int, image images.Image) error {
+		// If we have exceeded the maximum number of images for this threadTs
+		// then tell the recipient and stop iterating subsequent images
+		if index >= maxImagesPerThreadTs {
+			if _, err := sn.sendSlackMessage(ctx, &slackMessage{
+				Channel:  sn.settings.Recipient,
+				Text:     maxImagesPerThreadTsMessage,
+				ThreadTs: threadTs,
+			}); err != nil {
+				l.Error("Failed to send Slack message", "err", err)
 			}
-			comment := initialCommentForImage(alerts[index])
-			return sn.uploadImage(ctx, image, sn.settings.Recipient, comment, threadTs)
-		}, alerts...); err != nil {
-			// Do not return an error here as we might have exceeded the rate limit for uploading files
-			sn.log.Error("Failed to upload image", "err", err)
+			return images.ErrImagesDone
+		}
+		comment := initialCommentForImage(alerts[index])
+		altText := receivers.TmplImageAltText(ctx, sn.tmpl, sn.settings.ImageAltText, alerts[index], l)
+		if err := sn.uploadImage(uploadCtx, image, sn.settings.Recipient, comment, altText, threadTs); err != nil {
+			// The message was already sent, so the notification must not fail and be sent again. The image is
+			// replaced with a link, and the next images are still uploaded.
+			fallbacks = append(fallbacks, sn.imageFallback(ctx, alerts[index], fmt.Errorf("%w: %w", images.ErrImageUploadFailed, err)))
+		}
+		return nil
+	}, func(index int, err error) {
+		if index >= maxImagesPerThreadTs {
+			return
+		}
+		fallbacks = append(fallbacks, sn.imageFallback(ctx, alerts[index], err))
+	}, alerts...); err != nil {
+		// Do not return an error here as we might have exceeded the rate limit for uploading files
+		l.Error("Failed to upload image", "err", err)
+	}
+
+	if len(fallbacks) > 0 {
+		if _, err := sn.sendSlackMessage(ctx, &slackMessage{
+			Channel:  sn.settings.Recipient,
+			Text:     strings.Join(fallbacks, "\n"),
+			ThreadTs: threadTs,
+		}); err != nil {
+			l.Error("Failed to send Slack message", "err", err)
 		}
 	}
+}
 
-	return true, nil
+// imageFallback records that the image of the alert could not be resolved, and returns the text that links
+// to its dashboard instead.
+func (sn *Notifier) imageFallback(ctx context.Context, alert *types.Alert, err error) string {
+	l := logging.FromContext(ctx, sn.log)
+	l.Warn("Failed to get or upload image, linking to the dashboard instead", "alert", alert.String(), "err", err)
+	images.RecordFallback(ctx, "slack", err)
+	return receivers.ImageFallbackText(alert, receivers.ImageFallbackURL(ctx, sn.tmpl, alert, l))
 }
 
 func (sn *Notifier) commonAlertGeneratorURL(_ context.Context, alerts []*types.Alert) bool {
@@ -226,10 +321,11 @@ func (sn *Notifier) commonAlertGeneratorURL(_ context.Context, alerts []*types.A
 }
 
 func (sn *Notifier) createSlackMessage(ctx context.Context, alerts []*types.Alert) (*slackMessage, error) {
+	l := logging.FromContext(ctx, sn.log)
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, sn.tmpl, alerts, sn.log, &tmplErr)
+	tmpl, _ := templates.TmplText(ctx, sn.tmpl, alerts, l, &tmplErr)
 
-	ruleURL := receivers.JoinURLPath(sn.tmpl.ExternalURL.String(), "/alerting/list", sn.log)
+	ruleURL := receivers.JoinURLPath(sn.tmpl.ExternalURL.String(), "/alerting/list", l)
 
 	// If all alerts have the same GeneratorURL, use that.
 	if sn.commonAlertGeneratorURL(ctx, alerts) {
@@ -242,10 +338,10 @@ func (sn *Notifier) createSlackMessage(ctx context.Context, alerts []*types.Aler
 		if err != nil {
 			return nil, err
 		}
-		sn.log.Warn("Truncated title", "key", key, "max_runes", slackMaxTitleLenRunes)
+		l.Warn("Truncated title", "key", key, "max_runes", slackMaxTitleLenRunes)
 	}
 	if tmplErr != nil {
-		sn.log.Warn("failed to template Slack title", "error", tmplErr.Error())
+		l.Warn("failed to template Slack title", "error", tmplErr.Error())
 		tmplErr = nil
 	}
 
@@ -267,23 +363,53 @@ func (sn *Notifier) createSlackMessage(ctx context.Context, alerts []*types.Aler
 				TitleLink:  ruleURL,
 				Text:       tmpl(sn.settings.Text),
 				Fields:     nil, // TODO. Should be a config.
+				Actions:    silenceActions(sn.tmpl, alerts),
 			},
 		},
 	}
 
+	if sn.settings.ConvertMarkdown {
+		req.Attachments[0].Text = markdown.Convert(req.Attachments[0].Text, markdown.DialectSlack)
+	}
+
+	if sn.settings.AccessibleMessage {
+		// The message text is read before the attachments, so start with the status in words
+		// instead of relying on the color of the attachment.
+		req.Text = receivers.StatusSummary(alerts) + ": " + title
+		req.Attachments[0].Fallback = req.Text
+	}
+
 	if isIncomingWebhook(sn.settings) {
 		// Incoming webhooks cannot upload files, instead share images via their URL
-		_ = images.WithStoredImages(ctx, sn.log, sn.images, func(_ int, image images.Image) error {
+		type failedImage struct {
+			alert *types.Alert
+			err   error
+		}
+		var failed []failedImage
+		_ = images.WithStoredImagesOrFallback(ctx, l, sn.images, func(_ int, image images.Image) error {
 			if image.URL != "" {
 				req.Attachments[0].ImageURL = image.URL
 				return images.ErrImagesDone
 			}
 			return nil
+		}, func(index int, err error) {
+			failed = append(failed, failedImage{alert: alerts[index], err: err})
 		}, alerts...)
+		// Only the first image is shared, so link to the dashboard only if there is no image at all.
+		if req.Attachments[0].ImageURL == "" && len(failed) > 0 {
+			texts := make([]string, 0, len(failed))
+			for _, f := range failed {
+				texts = append(texts, sn.imageFallback(ctx, f.alert, f.err))
+			}
+			req.Attachments[0].Fields = append(req.Attachments[0].Fields, amConfig.SlackField{
+				Title: "Images",
+				Value: strings.Join(texts, "\n"),
+			})
+		}
 	}
 
 	if tmplErr != nil {
-		sn.log.Warn("failed to template Slack message", "error", tmplErr.Error())
+		l.Warn("failed to template Slack message", "error", tmplErr.Error())
 	}
 
 	mentionsBuilder := strings.Builder{}
@@ -318,16 +444,32 @@ func (sn *Notifier) createSlackMessage(ctx context.Context, alerts []*types.Aler
 		req.Attachments[0].Pretext = mentionsBuilder.String()
 	}
 
+	if sn.settings.Blocks != "" {
+		tmplErr = nil
+		blocks, err := parseBlocks(tmpl(sn.settings.Blocks))
+		if tmplErr != nil {
+			err = tmplErr
+		}
+		if err == nil {
+			err = withBlocks(req, blocks)
+		}
+		if err != nil {
+			l.Warn("Failed to create Slack blocks, sending an attachment instead", "err", err)
+		}
+	}
+
 	return req, nil
 }
 
 func (sn *Notifier) sendSlackMessage(ctx context.Context, m *slackMessage) (string, error) {
+	l := logging.FromContext(ctx, sn.log)
 	b, err := json.Marshal(m)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal Slack message: %w", err)
 	}
 
-	sn.log.Debug("sending Slack API request", "url", sn.settings.URL, "data", string(b))
+	l.Debug("sending Slack API request", "url", sn.settings.URL, "data", string(b))
+	receivers.ObservePayload(ctx, len(b))
 	request, err := http.NewRequestWithContext(ctx, http.MethodPost, sn.settings.URL, bytes.NewReader(b))
 	if err != nil {
 		return "", fmt.Errorf("failed to create HTTP request: %w", err)
@@ -339,13 +481,13 @@ func (sn *Notifier) sendSlackMessage(ctx context.Context, m *slackMessage) (stri
 		if sn.settings.URL == APIURL {
 			panic("Token should be set when using the Slack chat API")
 		}
-		sn.log.Debug("Looks like we are using an incoming webhook, no Authorization header required")
+		l.Debug("Looks like we are using an incoming webhook, no Authorization header required")
 	} else {
-		sn.log.Debug("Looks like we are using the Slack API, have set the Bearer token for this request")
+		l.Debug("Looks like we are using the Slack API, have set the Bearer token for this request")
 		request.Header.Set("Authorization", "Bearer "+sn.settings.Token)
 	}
 
-	threadTs, err := sn.sendMessageFn(ctx, request, sn.log)
+	threadTs, err := sn.sendMessageFn(ctx, request, l)
 	if err != nil {
 		return "", err
 	}
@@ -395,7 +537,8 @@ func (sn *Notifier) createImageMultipart(image images.Image) (http.Header, []byt
 }
 
 func (sn *Notifier) sendMultipart(ctx context.Context, uploadURL string, headers http.Header, data io.Reader) error {
-	sn.log.Debug("Sending multipart request", "url", uploadURL)
+	l := logging.FromContext(ctx, sn.log)
+	l.Debug("Sending multipart request", "url", uploadURL)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, data)
 	if err != nil {
@@ -406,23 +549,28 @@ func (sn *Notifier) sendMultipart(ctx context.Context, uploadURL string, headers
 	}
 	req.Header.Set("Authorization", "Bearer "+sn.settings.Token)
 
-	return sn.uploadFileFn(ctx, req, sn.log)
+	return sn.uploadFileFn(ctx, req, l)
 }
 
 // uploadImage shares the image to the channel names or IDs. It returns an error if the file
 // does not exist, or if there was an error either preparing or sending the multipart/form-data
 // request.
-func (sn *Notifier) uploadImage(ctx context.Context, image images.Image, channel, comment, threadTs string) error {
-	sn.log.Debug("Uploading image", "image", image.Token)
+func (sn *Notifier) uploadImage(ctx context.Context, image images.Image, channel, comment, altText, threadTs string) error {
+	l := logging.FromContext(ctx, sn.log)
+	l.Debug("Uploading image", "image", image.Token)
 
 	imageData, err := os.Stat(image.Path)
 	if err != nil {
 		return fmt.Errorf("failed to get image info: %w", err)
 	}
 
+	// Each step is retried on its own, so that a failed step does not repeat the steps before it.
 	// get the upload url
-	uploadURLResponse, err := sn.getUploadURL(ctx, image.Path, imageData.Size())
-	if err != nil {
+	var uploadURLResponse *FileUploadURLResponse
+	if err := retryUploadStep(ctx, l, "get upload URL", func() error {
+		uploadURLResponse, err = sn.getUploadURL(ctx, image.Path, altText, imageData.Size())
+		return err
+	}); err != nil {
 		return fmt.Errorf("failed to get upload URL: %w", err)
 	}
 
@@ -432,17 +580,45 @@ func (sn *Notifier) uploadImage(ctx context.Context, image images.Image, channel
 		return fmt.Errorf("failed to create multipart form: %w", err)
 	}
 
-	uploadErr := sn.sendMultipart(ctx, uploadURLResponse.UploadURL, headers, bytes.NewReader(data))
-	if uploadErr != nil {
-		return fmt.Errorf("failed to upload image: %w", uploadErr)
+	if err := retryUploadStep(ctx, l, "send file", func() error {
+		return sn.sendMultipart(ctx, uploadURLResponse.UploadURL, headers, bytes.NewReader(data))
+	}); err != nil {
+		return fmt.Errorf("failed to send file: %w", err)
 	}
 	// complete file upload to upload the image to the channel/thread with the comment
 	// need to use uploadURLResponse.FileID to complete the upload
-	return sn.finalizeUpload(ctx, uploadURLResponse.FileID, channel, threadTs, comment)
+	if err := retryUploadStep(ctx, l, "complete upload", func() error {
+		return sn.finalizeUpload(ctx, uploadURLResponse.FileID, channel, threadTs, comment)
+	}); err != nil {
+		return fmt.Errorf("failed to complete upload: %w", err)
+	}
+	return nil
+}
+
+// retryUploadStep runs a step of the upload of an image until it succeeds, up to maxUploadAttempts times.
+// It does not wait to retry if the context would be done before the next attempt.
+func retryUploadStep(ctx context.Context, l logging.Logger, step string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= maxUploadAttempts || ctx.Err() != nil {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < uploadRetryBackoff {
+			return err
+		}
+		l.Debug("Failed to upload image, retrying", "step", step, "attempt", attempt, "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(uploadRetryBackoff):
+		}
+	}
 }
 
 // getUploadURL returns the URL to upload the image to. It returns an error if the image cannot be uploaded.
-func (sn *Notifier) getUploadURL(ctx context.Context, filename string, imageSize int64) (*FileUploadURLResponse, error) {
+// The alt text, if not empty, is read by screen readers in place of the image.
+func (sn *Notifier) getUploadURL(ctx context.Context, filename, altText string, imageSize int64) (*FileUploadURLResponse, error) {
+	l := logging.FromContext(ctx, sn.log)
 	apiEndpoint, err := endpointURL(sn.settings, "files.getUploadURLExternal")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get URL for files.getUploadURLExternal: %w", err)
@@ -451,6 +627,9 @@ func (sn *Notifier) getUploadURL(ctx context.Context, filename string, imageSize
 	data := url.Values{}
 	data.Set("filename", filename)
 	data.Set("length", fmt.Sprintf("%d", imageSize))
+	if altText != "" {
+		data.Set("alt_txt", altText)
+	}
 
 	url := fmt.Sprintf("%s?%s", apiEndpoint, data.Encode())
 
@@ -460,10 +639,11 @@ func (sn *Notifier) getUploadURL(ctx context.Context, filename string, imageSize
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
 	req.Header.Set("Authorization", "Bearer "+sn.settings.Token)
-	return sn.initFileUploadFn(ctx, req, sn.log)
+	return sn.initFileUploadFn(ctx, req, l)
 }
 
 func (sn *Notifier) finalizeUpload(ctx context.Context, fileID, channel, threadTs, comment string) error {
+	l := logging.FromContext(ctx, sn.log)
 	completeUploadEndpoint, err := endpointURL(sn.settings, "files.completeUploadExternal")
 	if err != nil {
 		return fmt.Errorf("failed to get URL for files.completeUploadExternal: %w", err)
@@ -489,7 +669,7 @@ func (sn *Notifier) finalizeUpload(ctx context.Context, fileID, channel, threadT
 	}
 	completeUploadReq.Header.Set("Content-Type", "application/json; charset=utf-8")
 	completeUploadReq.Header.Set("Authorization", "Bearer "+sn.settings.Token)
-	return sn.completeFileUploadFn(ctx, completeUploadReq, sn.log)
+	return sn.completeFileUploadFn(ctx, completeUploadReq, l)
 }
 
 func (sn *Notifier) SendResolved() bool {
@@ -541,15 +721,15 @@ func errorForStatusCode(logger logging.Logger, statusCode int) error {
 		return fmt.Errorf("unexpected 3xx status code: %d", statusCode)
 	} else if statusCode >= http.StatusInternalServerError {
 		logger.Error("Unexpected 5xx response", "status", statusCode)
-		return fmt.Errorf("unexpected 5xx status code: %d", statusCode)
+		return notify.NewErrorWithReason(notify.ServerErrorReason, fmt.Errorf("unexpected 5xx status code: %d", statusCode))
 	}
 	return nil
 }
 
 // sendSlackMessage sends a request to the Slack API.
 // Stubbable by tests.
-func sendSlackMessage(_ context.Context, req *http.Request, logger logging.Logger) (string, error) {
-	resp, err := slackClient.Do(req)
+func sendSlackMessage(ctx context.Context, req *http.Request, logger logging.Logger) (string, error) {
+	resp, err := httpClient(ctx).Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
@@ -564,27 +744,35 @@ func sendSlackMessage(_ context.Context, req *http.Request, logger logging.Logge
 		return "", err
 	}
 
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Requests to webhooks are not authorized with a token. Workflow webhooks respond with an empty body on
+	// success, which the chat API never does, so empty responses are only accepted from webhooks.
+	isWebhook := req.Header.Get("Authorization") == ""
+	if isWebhook && resp.StatusCode < 300 && len(bytes.TrimSpace(b)) == 0 {
+		logger.Debug("The webhook was successful", "status", resp.StatusCode)
+		return "", nil
+	}
+
 	content := resp.Header.Get("Content-Type")
 	if strings.HasPrefix(content, "application/json") {
-		return handleSlackMessageJSONResponse(resp, logger)
+		return handleSlackMessageJSONResponse(b, logger)
 	}
 	// If the response is not JSON it could be the response to an incoming webhook
-	return handleSlackIncomingWebhookResponse(resp, logger)
+	return handleSlackIncomingWebhookResponse(b, resp.StatusCode, logger)
 }
 
-func handleSlackIncomingWebhookResponse(resp *http.Response, logger logging.Logger) (string, error) {
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
+func handleSlackIncomingWebhookResponse(b []byte, statusCode int, logger logging.Logger) (string, error) {
 	// Incoming webhooks return the string "ok" on success
 	if bytes.Equal(b, []byte("ok")) {
 		logger.Debug("The incoming webhook was successful")
 		return "", nil
 	}
 
-	logger.Debug("Incoming webhook was unsuccessful", "status", resp.StatusCode, "body", string(b))
+	logger.Debug("Incoming webhook was unsuccessful", "status", statusCode, "body", string(b))
 
 	// There are a number of known errors that we can check. The documentation incoming webhooks
 	// errors can be found at https://api.slack.com/messaging/webhooks#handling_errors and
@@ -616,12 +804,7 @@ func handleSlackIncomingWebhookResponse(resp *http.Response, logger logging.Logg
 	return "", fmt.Errorf("failed incoming webhook: %s", string(b))
 }
 
-func handleSlackMessageJSONResponse(resp *http.Response, logger logging.Logger) (string, error) {
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
+func handleSlackMessageJSONResponse(b []byte, logger logging.Logger) (string, error) {
 	if len(b) == 0 {
 		logger.Error("Expected JSON but got empty response")
 		return "", errors.New("unexpected empty response")
@@ -647,8 +830,8 @@ func handleSlackMessageJSONResponse(resp *http.Response, logger logging.Logger)
 	return result.Ts, nil
 }
 
-func initFileUpload(_ context.Context, req *http.Request, logger logging.Logger) (*FileUploadURLResponse, error) {
-	resp, err := slackClient.Do(req)
+func initFileUpload(ctx context.Context, req *http.Request, logger logging.Logger) (*FileUploadURLResponse, error) {
+	resp, err := httpClient(ctx).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -698,8 +881,8 @@ func initFileUpload(_ context.Context, req *http.Request, logger logging.Logger)
 	return nil, fmt.Errorf("unexpected content type: %s", content)
 }
 
-func uploadFile(_ context.Context, req *http.Request, logger logging.Logger) error {
-	resp, err := slackClient.Do(req)
+func uploadFile(ctx context.Context, req *http.Request, logger logging.Logger) error {
+	resp, err := httpClient(ctx).Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
@@ -707,8 +890,8 @@ func uploadFile(_ context.Context, req *http.Request, logger logging.Logger) err
 	return errorForStatusCode(logger, resp.StatusCode)
 }
 
-func completeFileUpload(_ context.Context, req *http.Request, logger logging.Logger) error {
-	resp, err := slackClient.Do(req)
+func completeFileUpload(ctx context.Context, req *http.Request, logger logging.Logger) error {
+	resp, err := httpClient(ctx).Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
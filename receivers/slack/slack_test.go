@@ -3,6 +3,7 @@ package slack
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -12,6 +13,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -27,6 +29,15 @@ import (
 
 var appVersion = fmt.Sprintf("%d.0.0", rand.Uint32())
 
+// silenceAction returns the button to silence the alert alert1 with the value of lbl1.
+func silenceAction(text, lbl1 string) attachmentAction {
+	return attachmentAction{
+		Type: "button",
+		Text: text,
+		URL:  "http://localhost/alerting/silence/new?alertmanager=grafana&duration=2h&matcher=alertname%3D%22alert1%22&matcher=lbl1%3D%22" + lbl1 + "%22",
+	}
+}
+
 func TestNotify_IncomingWebhook(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -70,6 +81,7 @@ func TestNotify_IncomingWebhook(t *testing.T) {
 					Footer:     "Grafana v" + appVersion,
 					FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
 					Color:      "#D63232",
+					Actions:    []attachmentAction{silenceAction("Silence", "val1")},
 				},
 			},
 		},
@@ -109,6 +121,7 @@ func TestNotify_IncomingWebhook(t *testing.T) {
 					Footer:     "Grafana v" + appVersion,
 					FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
 					Color:      "#D63232",
+					Actions:    []attachmentAction{silenceAction("Silence", "val1")},
 					ImageURL:   "https://www.example.com/test.png",
 				},
 			},
@@ -149,6 +162,7 @@ func TestNotify_IncomingWebhook(t *testing.T) {
 					Footer:     "Grafana v" + appVersion,
 					FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
 					Color:      "#D63232",
+					Actions:    []attachmentAction{silenceAction("Silence", "val1")},
 				},
 			},
 		},
@@ -239,6 +253,41 @@ func TestNotify_PostMessage(t *testing.T) {
 					Footer:     "Grafana v" + appVersion,
 					FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
 					Color:      "#D63232",
+					Actions:    []attachmentAction{silenceAction("Silence", "val1")},
+				},
+			},
+		},
+	}, {
+		name: "Message is sent with markdown converted to mrkdwn",
+		settings: Config{
+			EndpointURL:     APIURL,
+			URL:             APIURL,
+			Token:           "1234",
+			Recipient:       "#test",
+			Text:            "**{{ .CommonLabels.lbl1 }}** is firing, see [the dashboard](http://localhost/d/abcd)",
+			Title:           templates.DefaultMessageTitleEmbed,
+			Username:        "Grafana",
+			ConvertMarkdown: true,
+		},
+		alerts: []*types.Alert{{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			},
+		}},
+		expectedMessage: &slackMessage{
+			Channel:  "#test",
+			Username: "Grafana",
+			Attachments: []attachment{
+				{
+					Title:      "[FIRING:1]  (val1)",
+					TitleLink:  "http://localhost/alerting/list",
+					Text:       "*val1* is firing, see <http://localhost/d/abcd|the dashboard>",
+					Fallback:   "[FIRING:1]  (val1)",
+					Fields:     nil,
+					Footer:     "Grafana v" + appVersion,
+					FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
+					Color:      "#D63232",
+					Actions:    []attachmentAction{silenceAction("Silence", "val1")},
 				},
 			},
 		},
@@ -279,6 +328,7 @@ func TestNotify_PostMessage(t *testing.T) {
 					Footer:     "Grafana v" + appVersion,
 					FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
 					Color:      "#D63232",
+					Actions:    []attachmentAction{silenceAction("Silence", "val1")},
 				},
 			},
 		},
@@ -325,6 +375,7 @@ func TestNotify_PostMessage(t *testing.T) {
 					Footer:     "Grafana v" + appVersion,
 					FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
 					Color:      "#D63232",
+					Actions:    []attachmentAction{silenceAction("Silence alert1", "val1"), silenceAction("Silence alert1", "val2")},
 				},
 			},
 		},
@@ -371,6 +422,7 @@ func TestNotify_PostMessage(t *testing.T) {
 					Footer:     "Grafana v" + appVersion,
 					FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
 					Color:      "#D63232",
+					Actions:    []attachmentAction{silenceAction("Silence alert1", "val1"), silenceAction("Silence alert1", "val2")},
 				},
 			},
 		},
@@ -415,6 +467,7 @@ func TestNotify_PostMessage(t *testing.T) {
 					Footer:     "Grafana v" + appVersion,
 					FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
 					Color:      "#D63232",
+					Actions:    []attachmentAction{silenceAction("Silence alert1", "val1"), silenceAction("Silence alert1", "val2")},
 				},
 			},
 		},
@@ -454,6 +507,7 @@ func TestNotify_PostMessage(t *testing.T) {
 					Footer:     "Grafana v" + appVersion,
 					FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
 					Color:      "#D63232",
+					Actions:    []attachmentAction{silenceAction("Silence", "val1")},
 				},
 			},
 		},
@@ -493,6 +547,7 @@ func TestNotify_PostMessage(t *testing.T) {
 					Footer:     "Grafana v" + appVersion,
 					FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
 					Color:      "#D63232",
+					Actions:    []attachmentAction{silenceAction("Silence", "val1")},
 				},
 			},
 		},
@@ -543,6 +598,7 @@ func TestNotify_PostMessageWithImage(t *testing.T) {
 		alerts               []*types.Alert
 		expectedMessage      *slackMessage
 		expectedImageUploads int
+		expectedAltText      string
 		expectedError        string
 		settings             Config
 	}{
@@ -582,11 +638,52 @@ func TestNotify_PostMessageWithImage(t *testing.T) {
 						Footer:     "Grafana v" + appVersion,
 						FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
 						Color:      "#D63232",
+						Actions:    []attachmentAction{silenceAction("Silence", "val1")},
 					},
 				},
 			},
 			expectedImageUploads: 1,
 		},
+		{
+			name: "Accessible message is sent and image is uploaded with alt text",
+			settings: Config{
+				EndpointURL:       APIURL,
+				URL:               APIURL,
+				Token:             "1234",
+				Recipient:         "#test",
+				Text:              templates.DefaultMessageEmbed,
+				Title:             templates.DefaultMessageTitleEmbed,
+				Username:          "Grafana",
+				ImageAltText:      templates.DefaultImageAltTextEmbed,
+				AccessibleMessage: true,
+			},
+			alerts: []*types.Alert{{
+				Alert: model.Alert{
+					Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+					Annotations: model.LabelSet{"ann1": "annv1", "__alertImageToken__": "image-on-disk"},
+				},
+			}},
+			expectedMessage: &slackMessage{
+				Channel:  "#test",
+				Username: "Grafana",
+				Text:     "1 alert firing: [FIRING:1]  (val1)",
+				Attachments: []attachment{
+					{
+						Title:      "[FIRING:1]  (val1)",
+						TitleLink:  "http://localhost/alerting/list",
+						Text:       "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1\n",
+						Fallback:   "1 alert firing: [FIRING:1]  (val1)",
+						Fields:     nil,
+						Footer:     "Grafana v" + appVersion,
+						FooterIcon: "https://grafana.com/static/assets/img/fav32.png",
+						Color:      "#D63232",
+						Actions:    []attachmentAction{silenceAction("Silence", "val1")},
+					},
+				},
+			},
+			expectedImageUploads: 1,
+			expectedAltText:      "Firing alert alert1",
+		},
 	}
 
 	for _, test := range tests {
@@ -638,6 +735,7 @@ func TestNotify_PostMessageWithImage(t *testing.T) {
 					assert.Contains(t, strings.Split(initRequest.Header.Get("Content-Type"), ";"), "application/x-www-form-urlencoded")
 					assert.Contains(t, initRequest.URL.Query(), "filename")
 					assert.Contains(t, initRequest.URL.Query(), "length")
+					assert.Equal(t, test.expectedAltText, initRequest.URL.Query().Get("alt_txt"))
 					assert.Equal(t, tokenHeader, initRequest.Header.Get("Authorization"))
 					// check second request is to upload the image
 					uploadRequest := recorder.requests[i*3+2]
@@ -659,6 +757,116 @@ func TestNotify_PostMessageWithImage(t *testing.T) {
 	}
 }
 
+func TestNotify_PostMessageWithImageDeadline(t *testing.T) {
+	sn, recorder, err := setupSlackForTests(t, Config{
+		EndpointURL: APIURL,
+		URL:         APIURL,
+		Token:       "1234",
+		Recipient:   "#test",
+		Text:        templates.DefaultMessageEmbed,
+		Title:       templates.DefaultMessageTitleEmbed,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	ctx = notify.WithGroupKey(ctx, "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+
+	_, err = sn.Notify(ctx, &types.Alert{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{"__alertImageToken__": "image-on-disk"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, recorder.requests, 4)
+
+	// The message can use all the time left, the uploads of the image only a part of it.
+	messageDeadline, ok := recorder.requests[0].Context().Deadline()
+	require.True(t, ok)
+	require.Equal(t, deadline, messageDeadline)
+	for _, r := range recorder.requests[1:] {
+		uploadDeadline, ok := r.Context().Deadline()
+		require.True(t, ok)
+		require.True(t, uploadDeadline.Before(deadline.Add(-10*time.Second)))
+	}
+}
+
+func TestNotify_PostMessageWithImageUploadFailure(t *testing.T) {
+	backoff := uploadRetryBackoff
+	uploadRetryBackoff = time.Millisecond
+	t.Cleanup(func() { uploadRetryBackoff = backoff })
+
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{"__alertImageToken__": "image-on-disk"},
+		},
+	}, {
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert2"},
+			Annotations: model.LabelSet{"__alertImageToken__": "image-on-disk"},
+		},
+	}}
+	setup := func(t *testing.T) (*Notifier, *slackRequestRecorder, context.Context) {
+		sn, recorder, err := setupSlackForTests(t, Config{
+			EndpointURL: APIURL,
+			URL:         APIURL,
+			Token:       "1234",
+			Recipient:   "#test",
+			Text:        templates.DefaultMessageEmbed,
+			Title:       templates.DefaultMessageTitleEmbed,
+		})
+		require.NoError(t, err)
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+		return sn, recorder, ctx
+	}
+
+	t.Run("failed steps are retried without the steps before them", func(t *testing.T) {
+		sn, recorder, ctx := setup(t)
+		completions := 0
+		sn.completeFileUploadFn = func(ctx context.Context, r *http.Request, l logging.Logger) error {
+			completions++
+			if completions == 1 {
+				return errors.New("temporary error")
+			}
+			return recorder.recordFileUploadRequest(ctx, r, l)
+		}
+
+		ok, err := sn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 3, completions)
+		// The message, and the upload URL, the file and the completion of each image.
+		require.Len(t, recorder.requests, 7)
+	})
+
+	t.Run("images that cannot be uploaded are replaced with a link", func(t *testing.T) {
+		sn, recorder, ctx := setup(t)
+		attempts := 0
+		sn.initFileUploadFn = func(context.Context, *http.Request, logging.Logger) (*FileUploadURLResponse, error) {
+			attempts++
+			return nil, errors.New("failed to send request: ratelimited")
+		}
+
+		ok, err := sn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		// Both images are attempted.
+		require.Equal(t, 2*maxUploadAttempts, attempts)
+		// The message, and the reply with the links of both images.
+		require.Len(t, recorder.requests, 2)
+		b, err := io.ReadAll(recorder.requests[1].Body)
+		require.NoError(t, err)
+		var reply slackMessage
+		require.NoError(t, json.Unmarshal(b, &reply))
+		require.Len(t, strings.Split(reply.Text, "\n"), 2)
+	})
+}
+
 // slackRequestRecorder is used in tests to record all requests.
 type slackRequestRecorder struct {
 	requests []*http.Request
@@ -739,6 +947,8 @@ func TestSendSlackRequest(t *testing.T) {
 		statusCode  int
 		contentType string
 		expectError bool
+		// webhook is true if the request is sent to a webhook, without a token.
+		webhook bool
 	}{
 		{
 			name: "Example error",
@@ -815,6 +1025,24 @@ func TestSendSlackRequest(t *testing.T) {
 			contentType: "application/json",
 			expectError: true,
 		},
+		{
+			name:        "Webhook, no JSON response body",
+			statusCode:  http.StatusOK,
+			contentType: "application/json",
+			webhook:     true,
+		},
+		{
+			name:       "Webhook, no response body",
+			statusCode: http.StatusOK,
+			webhook:    true,
+		},
+		{
+			name:        "Webhook, no response body with 4xx status code",
+			statusCode:  http.StatusNotFound,
+			contentType: "text/html",
+			webhook:     true,
+			expectError: true,
+		},
 		{
 			name:        "Success case, HTML ok",
 			statusCode:  http.StatusOK,
@@ -844,6 +1072,9 @@ func TestSendSlackRequest(t *testing.T) {
 			defer server.Close()
 			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
 			require.NoError(tt, err)
+			if !test.webhook {
+				req.Header.Set("Authorization", "Bearer test-token")
+			}
 
 			_, err = sendSlackMessage(context.Background(), req, &logging.FakeLogger{})
 			if !test.expectError {
@@ -0,0 +1,268 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	amConfig "github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+)
+
+// summaryTTL is for how long the summary message of an alert group is kept after its last update. Alert groups
+// are usually deleted once all of their alerts are resolved, the TTL covers groups that are never resolved, such
+// as when the receiver is removed from the configuration.
+const summaryTTL = 7 * 24 * time.Hour
+
+type chatAPIFunc func(ctx context.Context, req *http.Request, logger logging.Logger) (*slackMessageResponse, error)
+
+// summaryMessage is the summary message of an alert group.
+type summaryMessage struct {
+	Channel string
+	Ts      string
+	// Alerts is the state of the alerts of the group at the last notification, true if the alert is firing.
+	Alerts     map[model.Fingerprint]bool
+	LastChange time.Time
+	UpdatedAt  time.Time
+}
+
+// summaryStore stores the summary messages of alert groups. It is kept in memory, and shared by all notifiers,
+// so that the summary messages are kept when the configuration is reloaded.
+type summaryStore struct {
+	mtx      sync.Mutex
+	messages map[string]summaryMessage
+}
+
+var defaultSummaries = newSummaryStore()
+
+func newSummaryStore() *summaryStore {
+	return &summaryStore{messages: make(map[string]summaryMessage)}
+}
+
+func (s *summaryStore) get(key string) (summaryMessage, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	m, ok := s.messages[key]
+	return m, ok
+}
+
+func (s *summaryStore) set(key string, m summaryMessage) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for k, v := range s.messages {
+		if m.UpdatedAt.Sub(v.UpdatedAt) > summaryTTL {
+			delete(s.messages, k)
+		}
+	}
+	s.messages[key] = m
+}
+
+func (s *summaryStore) delete(key string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.messages, key)
+}
+
+// slackUpdateMessage is the request to update a message (chat.update).
+type slackUpdateMessage struct {
+	slackMessage
+	Ts string `json:"ts"`
+}
+
+// slackPinRequest is the request to pin a message to a channel (pins.add).
+type slackPinRequest struct {
+	Channel   string `json:"channel"`
+	Timestamp string `json:"timestamp"`
+}
+
+// notifySummary posts the summary message of the alert group, or updates it if it was already posted.
+// The alerts that started firing or were resolved since the last notification are posted in its thread.
+func (sn *Notifier) notifySummary(ctx context.Context, alerts []*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, sn.log)
+	key, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	storeKey := sn.UID + "/" + string(key)
+
+	m, err := sn.createSlackMessage(ctx, alerts)
+	if err != nil {
+		l.Error("Failed to create Slack message", "err", err)
+		return false, fmt.Errorf("failed to create Slack message: %w", err)
+	}
+
+	now := sn.now()
+	state := make(map[model.Fingerprint]bool, len(alerts))
+	firing, resolved := 0, 0
+	for _, a := range alerts {
+		state[a.Fingerprint()] = !a.Resolved()
+		if a.Resolved() {
+			resolved++
+		} else {
+			firing++
+		}
+	}
+
+	prev, ok := sn.summaries.get(storeKey)
+	var changed []*types.Alert
+	if ok {
+		for _, a := range alerts {
+			if wasFiring, seen := prev.Alerts[a.Fingerprint()]; !seen || wasFiring == a.Resolved() {
+				changed = append(changed, a)
+			}
+		}
+	}
+	lastChange := prev.LastChange
+	if !ok || len(changed) > 0 {
+		lastChange = now
+	}
+	m.Attachments[0].Fields = summaryFields(firing, resolved, lastChange)
+
+	msg := summaryMessage{Channel: prev.Channel, Ts: prev.Ts, Alerts: state, LastChange: lastChange, UpdatedAt: now}
+	if !ok {
+		resp, err := sn.callChatAPI(ctx, sn.settings.URL, m)
+		if err != nil {
+			l.Error("Failed to send Slack message", "err", err)
+			return false, fmt.Errorf("failed to send Slack message: %w", err)
+		}
+		msg.Channel, msg.Ts = resp.Channel, resp.Ts
+		if err := sn.pinMessage(ctx, msg.Channel, msg.Ts); err != nil {
+			l.Warn("Failed to pin Slack summary message", "err", err)
+		}
+		sn.uploadImages(ctx, alerts, msg.Ts)
+	} else {
+		update := slackUpdateMessage{slackMessage: *m, Ts: prev.Ts}
+		update.Channel = prev.Channel
+		u, err := endpointURL(sn.settings, "chat.update")
+		if err != nil {
+			return false, fmt.Errorf("failed to get URL for chat.update: %w", err)
+		}
+		if _, err := sn.callChatAPI(ctx, u, update); err != nil {
+			l.Error("Failed to update Slack summary message", "err", err)
+			return false, fmt.Errorf("failed to update Slack summary message: %w", err)
+		}
+		if len(changed) > 0 {
+			reply := &slackMessage{
+				Channel:  prev.Channel,
+				Text:     summaryDeltaText(changed),
+				ThreadTs: prev.Ts,
+			}
+			if _, err := sn.callChatAPI(ctx, sn.settings.URL, reply); err != nil {
+				l.Error("Failed to send Slack message", "err", err)
+				return false, fmt.Errorf("failed to send Slack message: %w", err)
+			}
+		}
+	}
+
+	if firing == 0 {
+		sn.summaries.delete(storeKey)
+	} else {
+		sn.summaries.set(storeKey, msg)
+	}
+	return true, nil
+}
+
+// summaryFields returns the fields of the summary message with the number of firing and resolved alerts,
+// and the time of the last change in the local time of the reader.
+func summaryFields(firing, resolved int, lastChange time.Time) []amConfig.SlackField {
+	short := true
+	return []amConfig.SlackField{
+		{Title: "Firing", Value: strconv.Itoa(firing), Short: &short},
+		{Title: "Resolved", Value: strconv.Itoa(resolved), Short: &short},
+		{
+			Title: "Last change",
+			Value: fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>", lastChange.Unix(), lastChange.UTC().Format(time.RFC1123)),
+			Short: &short,
+		},
+	}
+}
+
+// summaryDeltaText returns the text of the reply for the alerts that changed since the last notification.
+func summaryDeltaText(alerts []*types.Alert) string {
+	lines := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		lines = append(lines, initialCommentForImage(a))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (sn *Notifier) pinMessage(ctx context.Context, channel, ts string) error {
+	u, err := endpointURL(sn.settings, "pins.add")
+	if err != nil {
+		return fmt.Errorf("failed to get URL for pins.add: %w", err)
+	}
+	_, err = sn.callChatAPI(ctx, u, slackPinRequest{Channel: channel, Timestamp: ts})
+	return err
+}
+
+// callChatAPI sends the body to a method of the Slack chat API.
+func (sn *Notifier) callChatAPI(ctx context.Context, apiURL string, body interface{}) (*slackMessageResponse, error) {
+	l := logging.FromContext(ctx, sn.log)
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Slack request: %w", err)
+	}
+	l.Debug("sending Slack API request", "url", apiURL, "data", string(b))
+	receivers.ObservePayload(ctx, len(b))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", "Grafana")
+	req.Header.Set("Authorization", "Bearer "+sn.settings.Token)
+	return sn.chatAPIFn(ctx, req, l)
+}
+
+// sendChatAPIRequest sends a request to the Slack chat API and returns the message of the response.
+// Stubbable by tests.
+func sendChatAPIRequest(ctx context.Context, req *http.Request, logger logging.Logger) (*slackMessageResponse, error) {
+	resp, err := httpClient(ctx).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if err := errorForStatusCode(logger, resp.StatusCode); err != nil {
+		return nil, err
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	result := struct {
+		CommonAPIResponse
+		slackMessageResponse
+	}{}
+	if err := json.Unmarshal(b, &result); err != nil {
+		logger.Error("Failed to unmarshal response", "body", string(b), "err", err)
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if !result.OK {
+		logger.Error("The request was unsuccessful", "body", string(b), "err", result.Error)
+		if result.Error == "" {
+			return nil, errors.New("failed to send request")
+		}
+		return nil, fmt.Errorf("failed to send request: %s", result.Error)
+	}
+	return &result.slackMessageResponse, nil
+}
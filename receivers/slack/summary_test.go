@@ -0,0 +1,117 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/logging"
+)
+
+// chatAPIRecorder records the requests to the Slack chat API and responds with the channel ID and the timestamp of the message.
+type chatAPIRecorder struct {
+	methods []string
+	bodies  []map[string]interface{}
+}
+
+func (r *chatAPIRecorder) record(_ context.Context, req *http.Request, _ logging.Logger) (*slackMessageResponse, error) {
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return nil, err
+	}
+	r.methods = append(r.methods, req.URL.Path)
+	r.bodies = append(r.bodies, body)
+	return &slackMessageResponse{Channel: "C123", Ts: "1700000000.000100"}, nil
+}
+
+func TestNotify_SummaryUpdateMode(t *testing.T) {
+	sn, _, err := setupSlackForTests(t, Config{
+		Recipient:  "#alerts",
+		Token:      "test-token",
+		URL:        "https://slack.example.com/api/chat.postMessage",
+		Username:   "Grafana",
+		Title:      "{{ .Status }}",
+		Text:       "text",
+		UpdateMode: UpdateModeSummary,
+	})
+	require.NoError(t, err)
+	rec := &chatAPIRecorder{}
+	sn.chatAPIFn = rec.record
+	sn.summaries = newSummaryStore()
+	start := time.Now().Truncate(time.Second)
+	now := start
+	sn.now = func() time.Time { return now }
+
+	newAlert := func(name string, resolved bool) *types.Alert {
+		a := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(name)}}}
+		if resolved {
+			a.EndsAt = time.Now().Add(-time.Minute)
+		} else {
+			a.EndsAt = time.Now().Add(time.Hour)
+		}
+		return a
+	}
+	ctx := notify.WithGroupKey(context.Background(), "group")
+	fields := func(body map[string]interface{}) []interface{} {
+		return body["attachments"].([]interface{})[0].(map[string]interface{})["fields"].([]interface{})
+	}
+	fieldValue := func(body map[string]interface{}, i int) string {
+		return fields(body)[i].(map[string]interface{})["value"].(string)
+	}
+
+	// The first notification posts the summary message and pins it.
+	ok, err := sn.Notify(ctx, newAlert("a", false))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"/api/chat.postMessage", "/api/pins.add"}, rec.methods)
+	require.Equal(t, "1", fieldValue(rec.bodies[0], 0))
+	require.Equal(t, "0", fieldValue(rec.bodies[0], 1))
+	require.Equal(t, map[string]interface{}{"channel": "C123", "timestamp": "1700000000.000100"}, rec.bodies[1])
+
+	// Repeated notifications without changes only update the summary message.
+	rec.methods, rec.bodies = nil, nil
+	now = now.Add(time.Hour)
+	_, err = sn.Notify(ctx, newAlert("a", false))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/api/chat.update"}, rec.methods)
+	require.Equal(t, "C123", rec.bodies[0]["channel"])
+	require.Equal(t, "1700000000.000100", rec.bodies[0]["ts"])
+	// The last change is the time of the first notification.
+	require.Equal(t, fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>", start.Unix(), start.UTC().Format(time.RFC1123)), fieldValue(rec.bodies[0], 2))
+
+	// Changes are posted in the thread of the summary message.
+	rec.methods, rec.bodies = nil, nil
+	now = now.Add(time.Hour)
+	_, err = sn.Notify(ctx, newAlert("a", false), newAlert("b", false))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/api/chat.update", "/api/chat.postMessage"}, rec.methods)
+	require.Equal(t, "2", fieldValue(rec.bodies[0], 0))
+	require.Equal(t, "1700000000.000100", rec.bodies[1]["thread_ts"])
+	require.Equal(t, "*Firing*: b, *Labels*: alertname = b", rec.bodies[1]["text"])
+
+	// Once all alerts are resolved, the summary is updated and the next incident posts a new summary message.
+	rec.methods, rec.bodies = nil, nil
+	_, err = sn.Notify(ctx, newAlert("a", true), newAlert("b", true))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/api/chat.update", "/api/chat.postMessage"}, rec.methods)
+	require.Equal(t, "0", fieldValue(rec.bodies[0], 0))
+	require.Equal(t, "2", fieldValue(rec.bodies[0], 1))
+
+	rec.methods, rec.bodies = nil, nil
+	_, err = sn.Notify(ctx, newAlert("a", false))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/api/chat.postMessage", "/api/pins.add"}, rec.methods)
+}
@@ -13,7 +13,17 @@ const FullValidConfigForTesting = `{
 	"icon_url": "http://localhost/icon_url",
 	"mentionChannel": "channel",
 	"mentionUsers": "test-mentionUsers",
-	"mentionGroups": "test-mentionGroups"
+	"mentionGroups": "test-mentionGroups",
+	"imageAltText": "test-image-alt-text",
+	"accessibleMessage": true,
+	"updateMode": "summary",
+	"convertMarkdown": true,
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
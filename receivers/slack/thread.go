@@ -0,0 +1,143 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alerting/logging"
+)
+
+// threadTTL is for how long the thread of an alert group is kept after its last message, as for summary messages.
+const threadTTL = summaryTTL
+
+// Thread is the first message of an alert group in update mode "thread", which later notifications reply to.
+type Thread struct {
+	Channel string `json:"channel"`
+	Ts      string `json:"ts"`
+	// UpdatedAt is the time of the last message in the thread.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ThreadStore stores the threads of alert groups. Keys are unique per integration and alert group, so that a
+// store can be shared by all notifiers. Stores that are shared by the members of a cluster keep the threads
+// when another member sends the notifications of the group.
+type ThreadStore interface {
+	// GetThread returns the thread of the key, and false if there is none.
+	GetThread(ctx context.Context, key string) (Thread, bool, error)
+	// SetThread stores the thread of the key.
+	SetThread(ctx context.Context, key string, t Thread) error
+	// DeleteThread deletes the thread of the key, so that the next notification starts a new thread.
+	DeleteThread(ctx context.Context, key string) error
+}
+
+// MemoryThreadStore is a ThreadStore that keeps the threads in memory. Threads that have not been updated
+// for a week are deleted.
+type MemoryThreadStore struct {
+	mtx     sync.Mutex
+	threads map[string]Thread
+}
+
+// NewMemoryThreadStore returns an empty MemoryThreadStore.
+func NewMemoryThreadStore() *MemoryThreadStore {
+	return &MemoryThreadStore{threads: make(map[string]Thread)}
+}
+
+func (s *MemoryThreadStore) GetThread(_ context.Context, key string) (Thread, bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	t, ok := s.threads[key]
+	return t, ok, nil
+}
+
+func (s *MemoryThreadStore) SetThread(_ context.Context, key string, t Thread) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for k, v := range s.threads {
+		if t.UpdatedAt.Sub(v.UpdatedAt) > threadTTL {
+			delete(s.threads, k)
+		}
+	}
+	s.threads[key] = t
+	return nil
+}
+
+func (s *MemoryThreadStore) DeleteThread(_ context.Context, key string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.threads, key)
+	return nil
+}
+
+// defaultThreads is the store of the threads when none is in the context. It is shared by all notifiers,
+// so that the threads are kept when the configuration is reloaded.
+var defaultThreads ThreadStore = NewMemoryThreadStore()
+
+type threadStoreKey struct{}
+
+// WithThreadStore returns a context with the store of the threads of the notifiers in update mode "thread".
+func WithThreadStore(ctx context.Context, s ThreadStore) context.Context {
+	return context.WithValue(ctx, threadStoreKey{}, s)
+}
+
+// ThreadStoreFromContext returns the store of the threads in the context, or an in-memory store if there is none.
+func ThreadStoreFromContext(ctx context.Context) ThreadStore {
+	if s, ok := ctx.Value(threadStoreKey{}).(ThreadStore); ok && s != nil {
+		return s
+	}
+	return defaultThreads
+}
+
+// notifyThread posts the message of the alert group as a reply in its thread, or starts the thread with it if
+// the group has none. The thread is forgotten once all alerts of the group are resolved, so that the next
+// incident starts a new thread. Failures of the store are logged, and the message is still sent.
+func (sn *Notifier) notifyThread(ctx context.Context, alerts []*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, sn.log)
+	key, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	storeKey := sn.UID + "/" + string(key)
+	store := ThreadStoreFromContext(ctx)
+
+	m, err := sn.createSlackMessage(ctx, alerts)
+	if err != nil {
+		l.Error("Failed to create Slack message", "err", err)
+		return false, fmt.Errorf("failed to create Slack message: %w", err)
+	}
+
+	thread, ok, err := store.GetThread(ctx, storeKey)
+	if err != nil {
+		l.Warn("Failed to get the Slack thread of the alert group, starting a new thread", "err", err)
+		ok = false
+	}
+	if ok {
+		m.Channel = thread.Channel
+		m.ThreadTs = thread.Ts
+	}
+	resp, err := sn.callChatAPI(ctx, sn.settings.URL, m)
+	if err != nil {
+		l.Error("Failed to send Slack message", "err", err)
+		return false, fmt.Errorf("failed to send Slack message: %w", err)
+	}
+	if !ok {
+		thread = Thread{Channel: resp.Channel, Ts: resp.Ts}
+	}
+	sn.uploadImages(ctx, alerts, thread.Ts)
+
+	if types.Alerts(alerts...).Status() == model.AlertResolved {
+		err = store.DeleteThread(ctx, storeKey)
+	} else {
+		thread.UpdatedAt = sn.now()
+		err = store.SetThread(ctx, storeKey, thread)
+	}
+	if err != nil {
+		l.Warn("Failed to store the Slack thread of the alert group", "err", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,116 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+// failingThreadStore is a ThreadStore whose operations fail.
+type failingThreadStore struct{}
+
+func (failingThreadStore) GetThread(context.Context, string) (Thread, bool, error) {
+	return Thread{}, false, errors.New("unavailable")
+}
+
+func (failingThreadStore) SetThread(context.Context, string, Thread) error {
+	return errors.New("unavailable")
+}
+
+func (failingThreadStore) DeleteThread(context.Context, string) error {
+	return errors.New("unavailable")
+}
+
+func TestNotify_ThreadUpdateMode(t *testing.T) {
+	sn, _, err := setupSlackForTests(t, Config{
+		Recipient:  "#alerts",
+		Token:      "test-token",
+		URL:        "https://slack.example.com/api/chat.postMessage",
+		Username:   "Grafana",
+		Title:      "{{ .Status }}",
+		Text:       "text",
+		UpdateMode: UpdateModeThread,
+	})
+	require.NoError(t, err)
+	sn.UID = "uid"
+	rec := &chatAPIRecorder{}
+	sn.chatAPIFn = rec.record
+	sn.now = time.Now
+
+	newAlert := func(resolved bool) *types.Alert {
+		a := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "a"}}}
+		if resolved {
+			a.EndsAt = time.Now().Add(-time.Minute)
+		} else {
+			a.EndsAt = time.Now().Add(time.Hour)
+		}
+		return a
+	}
+	store := NewMemoryThreadStore()
+	ctx := WithThreadStore(notify.WithGroupKey(context.Background(), "group"), store)
+
+	// The first notification starts the thread.
+	_, err = sn.Notify(ctx, newAlert(false))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/api/chat.postMessage"}, rec.methods)
+	require.Equal(t, "#alerts", rec.bodies[0]["channel"])
+	require.NotContains(t, rec.bodies[0], "thread_ts")
+	thread, ok, err := store.GetThread(ctx, "uid/group")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "C123", thread.Channel)
+	require.Equal(t, "1700000000.000100", thread.Ts)
+
+	// Later notifications are replies in the thread.
+	rec.methods, rec.bodies = nil, nil
+	_, err = sn.Notify(ctx, newAlert(false))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/api/chat.postMessage"}, rec.methods)
+	require.Equal(t, "C123", rec.bodies[0]["channel"])
+	require.Equal(t, "1700000000.000100", rec.bodies[0]["thread_ts"])
+
+	// The resolved notification is the last reply in the thread.
+	rec.methods, rec.bodies = nil, nil
+	_, err = sn.Notify(ctx, newAlert(true))
+	require.NoError(t, err)
+	require.Equal(t, "1700000000.000100", rec.bodies[0]["thread_ts"])
+	_, ok, err = store.GetThread(ctx, "uid/group")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Other alert groups have their own threads.
+	rec.methods, rec.bodies = nil, nil
+	_, err = sn.Notify(WithThreadStore(notify.WithGroupKey(context.Background(), "other"), store), newAlert(false))
+	require.NoError(t, err)
+	require.NotContains(t, rec.bodies[0], "thread_ts")
+
+	t.Run("messages are sent if the store fails", func(t *testing.T) {
+		rec.methods, rec.bodies = nil, nil
+		ctx := WithThreadStore(notify.WithGroupKey(context.Background(), "group"), failingThreadStore{})
+		ok, err := sn.Notify(ctx, newAlert(false))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []string{"/api/chat.postMessage"}, rec.methods)
+		require.NotContains(t, rec.bodies[0], "thread_ts")
+	})
+}
+
+func TestMemoryThreadStore_TTL(t *testing.T) {
+	s := NewMemoryThreadStore()
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, s.SetThread(ctx, "old", Thread{Ts: "1", UpdatedAt: now.Add(-threadTTL - time.Minute)}))
+	require.NoError(t, s.SetThread(ctx, "new", Thread{Ts: "2", UpdatedAt: now}))
+
+	_, ok, _ := s.GetThread(ctx, "old")
+	require.False(t, ok)
+	thread, ok, _ := s.GetThread(ctx, "new")
+	require.True(t, ok)
+	require.Equal(t, "2", thread.Ts)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
 	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
@@ -194,6 +195,12 @@ func TestNewConfig(t *testing.T) {
 					Profile:   "default",
 					RoleARN:   "arn:aws:iam:us-east-1:0123456789:role/my-role",
 				},
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 		{
@@ -215,8 +222,19 @@ func TestNewConfig(t *testing.T) {
 					Profile:   "default",
 					RoleARN:   "arn:aws:iam:us-east-1:0123456789:role/my-role",
 				},
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
+		{
+			name:              "Error if the TLS configuration is invalid",
+			settings:          `{"topic_arn": "arn:aws:sns:us-east-1:0123456789:SNSTopicName", "tlsConfig": {"minVersion": "TLS14"}}`,
+			expectedInitError: `invalid value for tlsConfig.minVersion: "TLS14"`,
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
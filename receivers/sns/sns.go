@@ -41,12 +41,14 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, logg
 
 // Notify sends the alert notification to sns.
 func (s *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, s.log)
 	var (
 		tmplErr error
-		data    = notify.GetTemplateData(ctx, s.tmpl, as, s.log)
-		tmpl    = notify.TmplText(s.tmpl, data, &tmplErr)
+		t       = templates.TemplateFromContext(ctx, s.tmpl)
+		data    = notify.GetTemplateData(ctx, t, as, l)
+		tmpl    = notify.TmplText(t, data, &tmplErr)
 	)
-	s.log.Info("Sending notification")
+	l.Info("Sending notification")
 
 	publishInput, err := s.createPublishInput(ctx, tmpl)
 	if err != nil {
@@ -60,16 +62,16 @@ func (s *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 
 	// check template error after we use them
 	if tmplErr != nil {
-		s.log.Warn("failed to template message", "error", tmplErr.Error())
+		l.Warn("failed to template message", "error", tmplErr.Error())
 	}
 
 	publishOutput, err := snsClient.Publish(publishInput)
 	if err != nil {
-		s.log.Error("Failed to publish to Amazon SNS. ", "error", err)
+		l.Error("Failed to publish to Amazon SNS. ", "error", err)
 		return true, err
 	}
 
-	s.log.Debug("Message successfully published", "messageId", publishOutput.MessageId, "sequenceNumber", publishOutput.SequenceNumber)
+	l.Debug("Message successfully published", "messageId", publishOutput.MessageId, "sequenceNumber", publishOutput.SequenceNumber)
 	return true, nil
 }
 
@@ -92,10 +94,18 @@ func (s *Notifier) createSNSClient(tmpl func(string) string) (*sns.SNS, error) {
 	if s.settings.Sigv4.AccessKey != "" && s.settings.Sigv4.SecretKey != "" {
 		creds = credentials.NewStaticCredentials(s.settings.Sigv4.AccessKey, string(s.settings.Sigv4.SecretKey), "")
 	}
+	// The requests to SNS and STS are sent with the HTTP client of the integrations, so that their TLS configuration
+	// is restricted in FIPS mode.
+	tlsConfig, err := receivers.ClientTLSConfig(s.settings.TLSConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	httpClient := receivers.NewTLSClient(tlsConfig)
 	sess, err := session.NewSessionWithOptions(session.Options{
 		Config: aws.Config{
-			Region:   aws.String(s.settings.Sigv4.Region),
-			Endpoint: aws.String(tmpl(s.settings.APIUrl)),
+			Region:     aws.String(s.settings.Sigv4.Region),
+			Endpoint:   aws.String(tmpl(s.settings.APIUrl)),
+			HTTPClient: httpClient,
 		},
 		Profile: s.settings.Sigv4.Profile,
 	})
@@ -113,6 +123,7 @@ func (s *Notifier) createSNSClient(tmpl func(string) string) (*sns.SNS, error) {
 				Config: aws.Config{
 					Region:      aws.String(s.settings.Sigv4.Region),
 					Credentials: creds,
+					HTTPClient:  httpClient,
 				},
 				Profile: s.settings.Sigv4.Profile,
 			})
@@ -2,6 +2,12 @@ package sns
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
@@ -145,3 +151,84 @@ func TestCreatePublishInput(t *testing.T) {
 		require.Equal(t, "true", *snsInput.MessageAttributes["subject_truncated"].StringValue)
 	})
 }
+
+func TestNotify_FIPSMode(t *testing.T) {
+	// The server only accepts a cipher suite that is not approved by FIPS 140.
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = &tls.Config{
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305},
+	}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	send := func(t *testing.T) error {
+		t.Helper()
+		tmpl := templates.ForTests(t)
+		tmpl.ExternalURL, _ = url.Parse("http://localhost")
+		n := New(Config{
+			APIUrl:   srv.URL,
+			Sigv4:    SigV4Config{Region: "us-east-1", AccessKey: "access-key", SecretKey: "secret-key"},
+			TopicARN: "arn:aws:sns:us-east-1:123456789:test",
+			Subject:  "subject",
+			Message:  "message",
+		}, receivers.Metadata{}, tmpl, &logging.FakeLogger{})
+		_, err := n.Notify(context.Background(), &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}})
+		return err
+	}
+
+	prev := receivers.FIPSMode()
+	t.Cleanup(func() { receivers.SetFIPSMode(prev) })
+
+	// Without FIPS mode, the handshake only fails on the certificate of the server.
+	receivers.SetFIPSMode(false)
+	err := send(t)
+	require.ErrorContains(t, err, "certificate")
+
+	receivers.SetFIPSMode(true)
+	err = send(t)
+	require.ErrorContains(t, err, "handshake failure")
+}
+
+func TestNotify_TLSConfig(t *testing.T) {
+	// The SDK overwrites the CA certificates of the HTTP client with the bundle of AWS_CA_BUNDLE.
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<PublishResponse><PublishResult><MessageId>1</MessageId></PublishResult></PublishResponse>`))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	send := func(t *testing.T, tlsConfig *receivers.TLSConfig) error {
+		t.Helper()
+		tmpl := templates.ForTests(t)
+		tmpl.ExternalURL, _ = url.Parse("http://localhost")
+		n := New(Config{
+			APIUrl:    srv.URL,
+			Sigv4:     SigV4Config{Region: "us-east-1", AccessKey: "access-key", SecretKey: "secret-key"},
+			TopicARN:  "arn:aws:sns:us-east-1:123456789:test",
+			Subject:   "subject",
+			Message:   "message",
+			TLSConfig: tlsConfig,
+		}, receivers.Metadata{}, tmpl, &logging.FakeLogger{})
+		_, err := n.Notify(context.Background(), &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}})
+		return err
+	}
+
+	// Without a TLS configuration, the certificate of the server is not trusted.
+	err := send(t, nil)
+	require.ErrorContains(t, err, "certificate")
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	err = send(t, &receivers.TLSConfig{CACertificate: string(ca)})
+	require.NoError(t, err)
+
+	err = send(t, &receivers.TLSConfig{CACertificate: "invalid"})
+	require.ErrorContains(t, err, "invalid TLS configuration")
+}
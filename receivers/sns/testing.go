@@ -15,6 +15,12 @@ const FullValidConfigForTesting = `{
 		"secret_key": "secret-key",
 		"profile": "default",
 		"role_arn": "arn:aws:iam:us-east-1:0123456789:role/my-role"
+	},
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
 	}
 }`
 
@@ -6,17 +6,47 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/grafana/alerting/receivers"
 	"github.com/grafana/alerting/templates"
 )
 
+// Types of cards.
+const (
+	// CardTypeSimple cards have the title, the message and the images of the alerts, and a button to view the alerts.
+	CardTypeSimple = "simple"
+	// CardTypeAdaptive cards also have a header colored by the status of the alerts, facts, and buttons to silence
+	// the alerts and to open their dashboard and runbook.
+	CardTypeAdaptive = "adaptive"
+)
+
+// Fact is a fact of adaptive cards. The title and the value are templates.
+type Fact struct {
+	Title string `json:"title" yaml:"title"`
+	Value string `json:"value" yaml:"value"`
+}
+
 type Config struct {
 	URL          string `json:"url,omitempty" yaml:"url,omitempty"`
 	Message      string `json:"message,omitempty" yaml:"message,omitempty"`
 	Title        string `json:"title,omitempty" yaml:"title,omitempty"`
 	SectionTitle string `json:"sectiontitle,omitempty" yaml:"sectiontitle,omitempty"`
+	// ImageAltText is the template for the alternative text of each image. It is executed once per image
+	// with the data of the alert the image belongs to.
+	ImageAltText string `json:"imageAltText,omitempty" yaml:"imageAltText,omitempty"`
+	// AccessibleMessage starts the card with a plain-language status summary so that screen readers
+	// do not have to rely on the color of the title to convey the state of the alerts.
+	AccessibleMessage bool `json:"accessibleMessage,omitempty" yaml:"accessibleMessage,omitempty"`
+	// CardType is the type of the cards, CardTypeSimple or CardTypeAdaptive. Cards are simple if it is empty.
+	CardType string `json:"cardType,omitempty" yaml:"cardType,omitempty"`
+	// Facts are shown in adaptive cards. Facts whose title or value is empty are left out.
+	Facts []Fact `json:"facts,omitempty" yaml:"facts,omitempty"`
+	// ConvertMarkdown converts the markdown of the message to the markdown of adaptive card text blocks, see
+	// markdown.Convert.
+	ConvertMarkdown bool                 `json:"convertMarkdown,omitempty" yaml:"convertMarkdown,omitempty"`
+	TLSConfig       *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
-func NewConfig(jsonData json.RawMessage) (Config, error) {
+func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
 	settings := Config{}
 	err := json.Unmarshal(jsonData, &settings)
 	if err != nil {
@@ -31,5 +61,17 @@ func NewConfig(jsonData json.RawMessage) (Config, error) {
 	if settings.Title == "" {
 		settings.Title = templates.DefaultMessageTitleEmbed
 	}
+	if settings.ImageAltText == "" {
+		settings.ImageAltText = templates.DefaultImageAltTextEmbed
+	}
+	switch settings.CardType {
+	case "", CardTypeSimple, CardTypeAdaptive:
+	default:
+		return settings, fmt.Errorf("invalid card type %q, must be %q or %q", settings.CardType, CardTypeSimple, CardTypeAdaptive)
+	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return settings, err
+	}
 	return settings, nil
 }
@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
+	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -13,6 +15,7 @@ func TestNewConfig(t *testing.T) {
 	cases := []struct {
 		name              string
 		settings          string
+		secrets           map[string][]byte
 		expectedConfig    Config
 		expectedInitError string
 	}{
@@ -34,6 +37,7 @@ func TestNewConfig(t *testing.T) {
 				Message:      `{{ template "teams.default.message" .}}`,
 				Title:        templates.DefaultMessageTitleEmbed,
 				SectionTitle: "",
+				ImageAltText: templates.DefaultImageAltTextEmbed,
 			},
 		},
 		{
@@ -42,30 +46,73 @@ func TestNewConfig(t *testing.T) {
 				"url": "http://localhost",  
 				"message" : "",
 				"title" : "",
-				"sectiontitle" : ""
+				"sectiontitle" : "",
+				"imageAltText" : ""
 			}`,
 			expectedConfig: Config{
 				URL:          "http://localhost",
 				Message:      `{{ template "teams.default.message" .}}`,
 				Title:        templates.DefaultMessageTitleEmbed,
 				SectionTitle: "",
+				ImageAltText: templates.DefaultImageAltTextEmbed,
 			},
 		},
+		{
+			name:              "Error if card type is invalid",
+			settings:          `{"url": "http://localhost", "cardType": "hero"}`,
+			expectedInitError: `invalid card type "hero", must be "simple" or "adaptive"`,
+		},
 		{
 			name:     "Extracts all fields",
 			settings: FullValidConfigForTesting,
 			expectedConfig: Config{
-				URL:          "http://localhost",
-				Message:      `test-message`,
-				Title:        "test-title",
-				SectionTitle: "test-second-title",
+				URL:               "http://localhost",
+				Message:           `test-message`,
+				Title:             "test-title",
+				SectionTitle:      "test-second-title",
+				ImageAltText:      "test-image-alt-text",
+				AccessibleMessage: true,
+				CardType:          CardTypeAdaptive,
+				Facts:             []Fact{{Title: "test-fact-title", Value: "test-fact-value"}},
+				ConvertMarkdown:   true,
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
+			},
+		},
+		{
+			name:     "Extracts TLS secrets",
+			settings: FullValidConfigForTesting,
+			secrets: map[string][]byte{
+				"tlsConfig.clientCertificate": []byte("test-secret-client-certificate"),
+				"tlsConfig.clientKey":         []byte("test-secret-client-key"),
+			},
+			expectedConfig: Config{
+				URL:               "http://localhost",
+				Message:           `test-message`,
+				Title:             "test-title",
+				SectionTitle:      "test-second-title",
+				ImageAltText:      "test-image-alt-text",
+				AccessibleMessage: true,
+				CardType:          CardTypeAdaptive,
+				Facts:             []Fact{{Title: "test-fact-title", Value: "test-fact-value"}},
+				ConvertMarkdown:   true,
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-secret-client-certificate",
+					ClientKey:         "test-secret-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			actual, err := NewConfig(json.RawMessage(c.settings))
+			actual, err := NewConfig(json.RawMessage(c.settings), receiversTesting.DecryptForTesting(c.secrets))
 
 			if c.expectedInitError != "" {
 				require.ErrorContains(t, err, c.expectedInitError)
@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/alerting/images"
 	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/markdown"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -40,8 +41,18 @@ const (
 	TextWeightLighter = "lighter"
 	TextWeightBolder  = "bolder"
 	TextWeightDefault = "default"
+
+	ContainerStyleGood      = "good"
+	ContainerStyleAttention = "attention"
+
+	// RunbookURLAnnotation is the annotation of the runbook that adaptive cards link to.
+	RunbookURLAnnotation = "runbook_url"
 )
 
+// maxSilenceActions is the maximum number of buttons of adaptive cards to silence alerts. Teams shows at most six
+// buttons, and the other buttons must fit.
+const maxSilenceActions = 3
+
 // AdaptiveCardsMessage represents a message for adaptive cards.
 type AdaptiveCardsMessage struct {
 	Attachments []AdaptiveCardsAttachment `json:"attachments"`
@@ -187,6 +198,48 @@ func (i AdaptiveCardImageItem) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// AdaptiveCardContainerItem is a Container.
+type AdaptiveCardContainerItem struct {
+	Items []AdaptiveCardItem
+	Style string
+	Bleed bool
+}
+
+func (i AdaptiveCardContainerItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string             `json:"type"`
+		Items []AdaptiveCardItem `json:"items"`
+		Style string             `json:"style,omitempty"`
+		Bleed bool               `json:"bleed,omitempty"`
+	}{
+		Type:  "Container",
+		Items: i.Items,
+		Style: i.Style,
+		Bleed: i.Bleed,
+	})
+}
+
+// AdaptiveCardFactSetItem is a FactSet.
+type AdaptiveCardFactSetItem struct {
+	Facts []AdaptiveCardFact
+}
+
+// AdaptiveCardFact is a fact of a FactSet.
+type AdaptiveCardFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+func (i AdaptiveCardFactSetItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string             `json:"type"`
+		Facts []AdaptiveCardFact `json:"facts"`
+	}{
+		Type:  "FactSet",
+		Facts: i.Facts,
+	})
+}
+
 // AdaptiveCardActionSetItem is an ActionSet.
 type AdaptiveCardActionSetItem struct {
 	Actions []AdaptiveCardActionItem
@@ -248,27 +301,66 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 }
 
 func (tn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, tn.log)
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, tn.tmpl, as, tn.log, &tmplErr)
+	tmpl, data := templates.TmplText(ctx, tn.tmpl, as, l, &tmplErr)
+	adaptive := tn.settings.CardType == CardTypeAdaptive
 
 	card := NewAdaptiveCard()
-	card.AppendItem(AdaptiveCardTextBlockItem{
+	if tn.settings.AccessibleMessage {
+		// Screen readers announce the card from the top, so start with the status in words.
+		card.AppendItem(AdaptiveCardTextBlockItem{
+			Text:   receivers.StatusSummary(as),
+			Weight: TextWeightBolder,
+			Wrap:   true,
+		})
+	}
+	title := AdaptiveCardTextBlockItem{
 		Color:  getTeamsTextColor(types.Alerts(as...)),
 		Text:   tmpl(tn.settings.Title),
 		Size:   TextSizeLarge,
 		Weight: TextWeightBolder,
 		Wrap:   true,
-	})
+	}
+	if adaptive {
+		// The header is colored instead of the title.
+		title.Color = ""
+		card.AppendItem(AdaptiveCardContainerItem{
+			Items: []AdaptiveCardItem{title},
+			Style: getTeamsContainerStyle(types.Alerts(as...)),
+			Bleed: true,
+		})
+	} else {
+		card.AppendItem(title)
+	}
+	message := tmpl(tn.settings.Message)
+	if tn.settings.ConvertMarkdown {
+		message = markdown.Convert(message, markdown.DialectTeams)
+	}
 	card.AppendItem(AdaptiveCardTextBlockItem{
-		Text: tmpl(tn.settings.Message),
+		Text: message,
 		Wrap: true,
 	})
+	if adaptive {
+		var facts AdaptiveCardFactSetItem
+		for _, f := range tn.settings.Facts {
+			if fact := (AdaptiveCardFact{Title: tmpl(f.Title), Value: tmpl(f.Value)}); fact.Title != "" && fact.Value != "" {
+				facts.Facts = append(facts.Facts, fact)
+			}
+		}
+		if len(facts.Facts) > 0 {
+			card.AppendItem(facts)
+		}
+	}
 
 	var s AdaptiveCardImageSetItem
-	_ = images.WithStoredImages(ctx, tn.log, tn.images,
-		func(_ int, image images.Image) error {
+	_ = images.WithStoredImages(ctx, l, tn.images,
+		func(index int, image images.Image) error {
 			if image.URL != "" {
-				s.AppendImage(AdaptiveCardImageItem{URL: image.URL})
+				s.AppendImage(AdaptiveCardImageItem{
+					URL:     image.URL,
+					AltText: receivers.TmplImageAltText(ctx, tn.tmpl, tn.settings.ImageAltText, as[index], l),
+				})
 			}
 			return nil
 		},
@@ -282,27 +374,32 @@ func (tn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		card.AppendItem(s)
 	}
 
-	card.AppendItem(AdaptiveCardActionSetItem{
-		Actions: []AdaptiveCardActionItem{
-			AdaptiveCardOpenURLActionItem{
-				Title: "View URL",
-				URL:   receivers.JoinURLPath(tn.tmpl.ExternalURL.String(), "/alerting/list", tn.log),
-			},
+	actions := []AdaptiveCardActionItem{
+		AdaptiveCardOpenURLActionItem{
+			Title: "View URL",
+			URL:   receivers.JoinURLPath(tn.tmpl.ExternalURL.String(), "/alerting/list", l),
 		},
-	})
+	}
+	if adaptive {
+		actions = append(actions, adaptiveCardActions(tn.tmpl, as, data)...)
+	}
+	card.AppendItem(AdaptiveCardActionSetItem{Actions: actions})
 
 	msg := NewAdaptiveCardsMessage(card)
 	msg.Summary = tmpl(tn.settings.Title)
+	if tn.settings.AccessibleMessage {
+		msg.Summary = receivers.StatusSummary(as) + ": " + msg.Summary
+	}
 
 	// This check for tmplErr must happen before templating the URL
 	if tmplErr != nil {
-		tn.log.Warn("failed to template Teams message", "error", tmplErr.Error())
+		l.Warn("failed to template Teams message", "error", tmplErr.Error())
 		tmplErr = nil
 	}
 
 	u := tmpl(tn.settings.URL)
 	if tmplErr != nil {
-		tn.log.Warn("failed to template Teams URL", "error", tmplErr.Error(), "fallback", tn.settings.URL)
+		l.Warn("failed to template Teams URL", "error", tmplErr.Error(), "fallback", tn.settings.URL)
 		u = tn.settings.URL
 	}
 
@@ -311,7 +408,11 @@ func (tn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		return false, fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
-	cmd := &receivers.SendWebhookSettings{URL: u, Body: string(b)}
+	tlsConfig, err := receivers.ClientTLSConfig(tn.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	cmd := &receivers.SendWebhookSettings{URL: u, Body: string(b), TLSConfig: tlsConfig}
 	parsed, err := url.Parse(u)
 	if err != nil {
 		return false, fmt.Errorf("failed to parse URL: %w", err)
@@ -322,7 +423,7 @@ func (tn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		// response can contain an error message, irrespective of status code (i.e. https://docs.microsoft.com/en-us/microsoftteams/platform/webhooks-and-connectors/how-to/connectors-using?tabs=cURL#rate-limiting-for-connectors)
 		cmd.Validation = validateOfficeWebhookResponse
 	} else {
-		cmd.Validation = validateResponse(tn.log)
+		cmd.Validation = validateResponse(l)
 	}
 
 	if err := tn.ns.SendWebhook(ctx, cmd); err != nil {
@@ -361,6 +462,45 @@ func (tn *Notifier) SendResolved() bool {
 	return !tn.GetDisableResolveMessage()
 }
 
+// adaptiveCardActions returns the buttons of adaptive cards to silence each firing alert and to open the dashboard
+// and runbook of the alerts. The dashboard and runbook buttons are left out if the alerts do not have the same link,
+// so that they do not apply to some of them.
+func adaptiveCardActions(tmpl *templates.Template, as []*types.Alert, data *templates.ExtendedData) []AdaptiveCardActionItem {
+	var actions []AdaptiveCardActionItem
+	for _, link := range receivers.AlertSilenceLinks(tmpl, as, maxSilenceActions) {
+		actions = append(actions, AdaptiveCardOpenURLActionItem{Title: link.Title, URL: link.URL})
+	}
+	if u := commonAlertValue(data.Alerts, func(a templates.ExtendedAlert) string { return a.DashboardURL }); u != "" {
+		actions = append(actions, AdaptiveCardOpenURLActionItem{Title: "View dashboard", URL: u})
+	}
+	if u := data.CommonAnnotation(RunbookURLAnnotation); u != "" {
+		actions = append(actions, AdaptiveCardOpenURLActionItem{Title: "View runbook", URL: u})
+	}
+	return actions
+}
+
+// commonAlertValue returns the value of the alerts if they all have the same one.
+func commonAlertValue(alerts templates.ExtendedAlerts, value func(templates.ExtendedAlert) string) string {
+	if len(alerts) == 0 {
+		return ""
+	}
+	v := value(alerts[0])
+	for _, a := range alerts[1:] {
+		if value(a) != v {
+			return ""
+		}
+	}
+	return v
+}
+
+// getTeamsContainerStyle returns the style of the header of adaptive cards.
+func getTeamsContainerStyle(alerts model.Alerts) string {
+	if receivers.GetAlertStatusColor(alerts.Status()) == receivers.ColorAlertFiring {
+		return ContainerStyleAttention
+	}
+	return ContainerStyleGood
+}
+
 // getTeamsTextColor returns the text color for the message title.
 func getTeamsTextColor(alerts model.Alerts) string {
 	if receivers.GetAlertStatusColor(alerts.Status()) == receivers.ColorAlertFiring {
@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/grafana/alerting/images"
 	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/models"
 	"github.com/grafana/alerting/receivers"
 	"github.com/grafana/alerting/templates"
 )
@@ -82,6 +84,56 @@ func TestNotify(t *testing.T) {
 			"type":    "message",
 		},
 		expMsgError: nil,
+	}, {
+		name: "Markdown converted to text block markdown",
+		settings: Config{
+			URL:             "http://localhost",
+			Message:         "## {{ .CommonLabels.lbl1 }}\n~~old~~ *new* `code`",
+			Title:           templates.DefaultMessageTitleEmbed,
+			ConvertMarkdown: true,
+		},
+		alerts: []*types.Alert{
+			{
+				Alert: model.Alert{
+					Labels: model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+				},
+			},
+		},
+		expMsg: map[string]interface{}{
+			"attachments": []map[string]interface{}{{
+				"content": map[string]interface{}{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"body": []map[string]interface{}{{
+						"color":  "attention",
+						"size":   "large",
+						"text":   "[FIRING:1]  (val1)",
+						"type":   "TextBlock",
+						"weight": "bolder",
+						"wrap":   true,
+					}, {
+						"text": "**val1**\nold _new_ code",
+						"type": "TextBlock",
+						"wrap": true,
+					}, {
+						"actions": []map[string]interface{}{{
+							"title": "View URL",
+							"type":  "Action.OpenUrl",
+							"url":   "http://localhost/alerting/list",
+						}},
+						"type": "ActionSet",
+					}},
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"msTeams": map[string]interface{}{
+						"width": "Full",
+					},
+				},
+				"contentType": "application/vnd.microsoft.card.adaptive",
+			}},
+			"summary": "[FIRING:1]  (val1)",
+			"type":    "message",
+		},
+		expMsgError: nil,
 	}, {
 		name: "Custom config with multiple alerts",
 		settings: Config{
@@ -296,6 +348,200 @@ func TestNotify(t *testing.T) {
 	}
 }
 
+func TestNotify_AccessibleMessageWithImages(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := receivers.MockNotificationService()
+	pn := &Notifier{
+		Base: &receivers.Base{},
+		log:  &logging.FakeLogger{},
+		ns:   webhookSender,
+		tmpl: tmpl,
+		settings: Config{
+			URL:               "http://localhost",
+			Message:           "message",
+			Title:             "title",
+			ImageAltText:      templates.DefaultImageAltTextEmbed,
+			AccessibleMessage: true,
+		},
+		images: &images.FakeProvider{
+			Images: []*images.Image{{
+				Token: "test-image-1",
+				URL:   "https://www.example.com/test-image-1.jpg",
+			}},
+		},
+	}
+
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+			Annotations: model.LabelSet{models.ImageTokenAnnotation: "test-image-1"},
+		},
+	}, {
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert2", "lbl1": "val2"},
+			StartsAt: time.Now().Add(-time.Hour),
+			EndsAt:   time.Now().Add(-time.Minute),
+		},
+	}}
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	ok, err := pn.Notify(ctx, alerts...)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	var msg struct {
+		Summary     string `json:"summary"`
+		Attachments []struct {
+			Content struct {
+				Body []map[string]interface{} `json:"body"`
+			} `json:"content"`
+		} `json:"attachments"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+	require.Equal(t, "1 alert firing, 1 alert resolved: title", msg.Summary)
+
+	body := msg.Attachments[0].Content.Body
+	require.Equal(t, "1 alert firing, 1 alert resolved", body[0]["text"])
+	require.Equal(t, "title", body[1]["text"])
+	require.Equal(t, "ImageSet", body[3]["type"])
+	require.Equal(t, []interface{}{map[string]interface{}{
+		"type":    "Image",
+		"url":     "https://www.example.com/test-image-1.jpg",
+		"altText": "Firing alert alert1",
+		"msTeams": map[string]interface{}{"allowExpand": true},
+	}}, body[3]["images"])
+}
+
+func TestNotify_AdaptiveCard(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	webhookSender := receivers.MockNotificationService()
+	pn := &Notifier{
+		Base: &receivers.Base{},
+		log:  &logging.FakeLogger{},
+		ns:   webhookSender,
+		tmpl: tmpl,
+		settings: Config{
+			URL:      "http://localhost",
+			Message:  "message",
+			Title:    "title",
+			CardType: CardTypeAdaptive,
+			Facts: []Fact{
+				{Title: "Severity", Value: "{{ .CommonLabels.severity }}"},
+				{Title: "Team", Value: "{{ .CommonLabels.team }}"},
+			},
+		},
+		images: &images.FakeProvider{},
+	}
+
+	notifyAlerts := func(alerts ...*types.Alert) []map[string]interface{} {
+		ctx := notify.WithGroupKey(context.Background(), "alertname")
+		ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+		ok, err := pn.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		var msg struct {
+			Attachments []struct {
+				Content struct {
+					Body []map[string]interface{} `json:"body"`
+				} `json:"content"`
+			} `json:"attachments"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &msg))
+		return msg.Attachments[0].Content.Body
+	}
+
+	t.Run("one alert", func(t *testing.T) {
+		body := notifyAlerts(&types.Alert{
+			Alert: model.Alert{
+				Labels: model.LabelSet{"alertname": "alert1", "severity": "critical"},
+				Annotations: model.LabelSet{
+					models.DashboardUIDAnnotation: "abcd",
+					RunbookURLAnnotation:          "https://runbooks.example.com/alert1",
+				},
+			},
+		})
+		require.Len(t, body, 4)
+		require.Equal(t, map[string]interface{}{
+			"type":  "Container",
+			"style": "attention",
+			"bleed": true,
+			"items": []interface{}{map[string]interface{}{
+				"type":   "TextBlock",
+				"text":   "title",
+				"size":   "large",
+				"weight": "bolder",
+				"wrap":   true,
+			}},
+		}, body[0])
+		require.Equal(t, "message", body[1]["text"])
+		// Facts whose value is empty are left out.
+		require.Equal(t, map[string]interface{}{
+			"type":  "FactSet",
+			"facts": []interface{}{map[string]interface{}{"title": "Severity", "value": "critical"}},
+		}, body[2])
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "View URL", "url": "http://localhost/alerting/list"},
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "Silence", "url": "http://localhost/alerting/silence/new?alertmanager=grafana&duration=2h&matcher=alertname%3D%22alert1%22&matcher=severity%3D%22critical%22"},
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "View dashboard", "url": "http://localhost/d/abcd"},
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "View runbook", "url": "https://runbooks.example.com/alert1"},
+		}, body[3]["actions"])
+	})
+
+	t.Run("firing alerts are silenced one by one", func(t *testing.T) {
+		var alerts []*types.Alert
+		for _, name := range []string{"alert1", "alert2", "alert3", "alert4"} {
+			alerts = append(alerts, &types.Alert{
+				Alert: model.Alert{
+					Labels:      model.LabelSet{"alertname": model.LabelValue(name), models.RuleUIDLabel: "rule-" + model.LabelValue(name), "__private__": "x"},
+					Annotations: model.LabelSet{models.OrgIDAnnotation: "2"},
+				},
+			})
+		}
+		body := notifyAlerts(alerts...)
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "View URL", "url": "http://localhost/alerting/list"},
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "Silence alert1", "url": "http://localhost/alerting/silence/new?alertmanager=grafana&duration=2h&matcher=__alert_rule_uid__%3D%22rule-alert1%22&matcher=alertname%3D%22alert1%22&orgId=2"},
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "Silence alert2", "url": "http://localhost/alerting/silence/new?alertmanager=grafana&duration=2h&matcher=__alert_rule_uid__%3D%22rule-alert2%22&matcher=alertname%3D%22alert2%22&orgId=2"},
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "Silence alert3", "url": "http://localhost/alerting/silence/new?alertmanager=grafana&duration=2h&matcher=__alert_rule_uid__%3D%22rule-alert3%22&matcher=alertname%3D%22alert3%22&orgId=2"},
+		}, body[len(body)-1]["actions"])
+	})
+
+	t.Run("resolved alerts with different links", func(t *testing.T) {
+		body := notifyAlerts(&types.Alert{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1"},
+				Annotations: model.LabelSet{models.DashboardUIDAnnotation: "abcd"},
+				StartsAt:    time.Now().Add(-time.Hour),
+				EndsAt:      time.Now().Add(-time.Minute),
+			},
+		}, &types.Alert{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert2"},
+				Annotations: model.LabelSet{models.DashboardUIDAnnotation: "abcd"},
+				StartsAt:    time.Now().Add(-time.Hour),
+				EndsAt:      time.Now().Add(-time.Minute),
+			},
+		})
+		require.Len(t, body, 3)
+		require.Equal(t, "good", body[0]["style"])
+		// Resolved alerts have no silence buttons, and they have the same dashboard.
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "View URL", "url": "http://localhost/alerting/list"},
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "View dashboard", "url": "http://localhost/d/abcd"},
+		}, body[2]["actions"])
+	})
+}
+
 func TestValidateWebhookResponse(t *testing.T) {
 	require.NoError(t, validateOfficeWebhookResponse([]byte("1"), rand.Int()))
 	err := validateOfficeWebhookResponse([]byte("some error message"), rand.Int())
@@ -5,5 +5,16 @@ const FullValidConfigForTesting = `{
 	"url": "http://localhost",  
 	"message" : "test-message",
 	"title" : "test-title",
-	"sectiontitle" : "test-second-title"
+	"sectiontitle" : "test-second-title",
+	"imageAltText" : "test-image-alt-text",
+	"accessibleMessage" : true,
+	"cardType" : "adaptive",
+	"facts" : [{"title": "test-fact-title", "value": "test-fact-value"}],
+	"convertMarkdown" : true,
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
@@ -18,14 +18,15 @@ const DefaultTelegramParseMode = "HTML"
 var SupportedParseMode = map[string]string{"Markdown": "Markdown", "MarkdownV2": "MarkdownV2", DefaultTelegramParseMode: "HTML", "None": ""}
 
 type Config struct {
-	BotToken              string `json:"bottoken,omitempty" yaml:"bottoken,omitempty"`
-	ChatID                string `json:"chatid,omitempty" yaml:"chatid,omitempty"`
-	MessageThreadID       string `json:"message_thread_id,omitempty" yaml:"message_thread_id,omitempty"`
-	Message               string `json:"message,omitempty" yaml:"message,omitempty"`
-	ParseMode             string `json:"parse_mode,omitempty" yaml:"parse_mode,omitempty"`
-	DisableWebPagePreview bool   `json:"disable_web_page_preview,omitempty" yaml:"disable_web_page_preview,omitempty"`
-	ProtectContent        bool   `json:"protect_content,omitempty" yaml:"protect_content,omitempty"`
-	DisableNotifications  bool   `json:"disable_notifications,omitempty" yaml:"disable_notifications,omitempty"`
+	BotToken              string               `json:"bottoken,omitempty" yaml:"bottoken,omitempty"`
+	ChatID                string               `json:"chatid,omitempty" yaml:"chatid,omitempty"`
+	MessageThreadID       string               `json:"message_thread_id,omitempty" yaml:"message_thread_id,omitempty"`
+	Message               string               `json:"message,omitempty" yaml:"message,omitempty"`
+	ParseMode             string               `json:"parse_mode,omitempty" yaml:"parse_mode,omitempty"`
+	DisableWebPagePreview bool                 `json:"disable_web_page_preview,omitempty" yaml:"disable_web_page_preview,omitempty"`
+	ProtectContent        bool                 `json:"protect_content,omitempty" yaml:"protect_content,omitempty"`
+	DisableNotifications  bool                 `json:"disable_notifications,omitempty" yaml:"disable_notifications,omitempty"`
+	TLSConfig             *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -71,5 +72,9 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	if !found {
 		return settings, fmt.Errorf("unknown parse_mode, must be Markdown, MarkdownV2, HTML or None")
 	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return settings, err
+	}
 	return settings, nil
 }
@@ -7,6 +7,7 @@ import (
 	"io"
 	"mime/multipart"
 	"os"
+	"strings"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -53,6 +54,7 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify send an alert notification to Telegram.
 func (tn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, tn.log)
 	// Create the cmd for sendMessage
 	cmd, err := tn.newWebhookSyncCmd("sendMessage", func(w *multipart.Writer) error {
 		msg, err := tn.buildTelegramMessage(ctx, as)
@@ -74,7 +76,8 @@ func (tn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 	}
 
 	// Create the cmd to upload each image
-	_ = images.WithStoredImages(ctx, tn.log, tn.images, func(_ int, image images.Image) error {
+	var fallbacks []string
+	_ = images.WithStoredImagesOrFallback(ctx, l, tn.images, func(_ int, image images.Image) error {
 		cmd, err = tn.newWebhookSyncCmd("sendPhoto", func(w *multipart.Writer) error {
 			f, err := os.Open(image.Path)
 			if err != nil {
@@ -82,7 +85,7 @@ func (tn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 			}
 			defer func() {
 				if err := f.Close(); err != nil {
-					tn.log.Warn("failed to close image", "error", err)
+					l.Warn("failed to close image", "error", err)
 				}
 			}()
 			fw, err := w.CreateFormFile("photo", image.Path)
@@ -101,20 +104,40 @@ func (tn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 			return fmt.Errorf("failed to upload image to telegram: %w", err)
 		}
 		return nil
+	}, func(index int, err error) {
+		l.Warn("failed to get image, linking to the dashboard instead", "alert", as[index].String(), "error", err)
+		images.RecordFallback(ctx, "telegram", err)
+		fallbacks = append(fallbacks, receivers.ImageFallbackText(as[index], receivers.ImageFallbackURL(ctx, tn.tmpl, as[index], l)))
 	}, as...)
 
+	// Tell the recipient about the images that could not be uploaded. The message is sent without parse mode,
+	// as the links are not escaped.
+	if len(fallbacks) > 0 {
+		cmd, err = tn.newWebhookSyncCmd("sendMessage", func(w *multipart.Writer) error {
+			text, _ := receivers.TruncateInRunes(strings.Join(fallbacks, "\n"), telegramMaxMessageLenRunes)
+			return w.WriteField("text", text)
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to create telegram message: %w", err)
+		}
+		if err := tn.ns.SendWebhook(ctx, cmd); err != nil {
+			l.Error("failed to send telegram message about missing images", "error", err)
+		}
+	}
+
 	return true, nil
 }
 
 func (tn *Notifier) buildTelegramMessage(ctx context.Context, as []*types.Alert) (map[string]string, error) {
+	l := logging.FromContext(ctx, tn.log)
 	var tmplErr error
 	defer func() {
 		if tmplErr != nil {
-			tn.log.Warn("failed to template Telegram message", "error", tmplErr)
+			l.Warn("failed to template Telegram message", "error", tmplErr)
 		}
 	}()
 
-	tmpl, _ := templates.TmplText(ctx, tn.tmpl, as, tn.log, &tmplErr)
+	tmpl, _ := templates.TmplText(ctx, tn.tmpl, as, l, &tmplErr)
 	// Telegram supports 4096 chars max
 	messageText, truncated := receivers.TruncateInRunes(tmpl(tn.settings.Message), telegramMaxMessageLenRunes)
 	if truncated {
@@ -122,7 +145,7 @@ func (tn *Notifier) buildTelegramMessage(ctx context.Context, as []*types.Alert)
 		if err != nil {
 			return nil, err
 		}
-		tn.log.Warn("Truncated message", "alert", key, "max_runes", telegramMaxMessageLenRunes)
+		l.Warn("Truncated message", "alert", key, "max_runes", telegramMaxMessageLenRunes)
 	}
 
 	m := make(map[string]string)
@@ -172,6 +195,10 @@ func (tn *Notifier) newWebhookSyncCmd(action string, fn func(writer *multipart.W
 		return nil, fmt.Errorf("failed to close multipart: %w", err)
 	}
 
+	tlsConfig, err := receivers.ClientTLSConfig(tn.settings.TLSConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        fmt.Sprintf(APIURL, tn.settings.BotToken, action),
 		Body:       b.String(),
@@ -179,6 +206,7 @@ func (tn *Notifier) newWebhookSyncCmd(action string, fn func(writer *multipart.W
 		HTTPHeader: map[string]string{
 			"Content-Type": w.FormDataContentType(),
 		},
+		TLSConfig: tlsConfig,
 	}
 	return cmd, nil
 }
@@ -9,7 +9,13 @@ const FullValidConfigForTesting = `{
 	"parse_mode" :"html",
 	"disable_web_page_preview" :true,
 	"protect_content" :true,
-	"disable_notifications" :true
+	"disable_notifications" :true,
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
@@ -11,11 +11,12 @@ import (
 )
 
 type Config struct {
-	GatewayID   string `json:"gateway_id,omitempty" yaml:"gateway_id,omitempty"`
-	RecipientID string `json:"recipient_id,omitempty" yaml:"recipient_id,omitempty"`
-	APISecret   string `json:"api_secret,omitempty" yaml:"api_secret,omitempty"`
-	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
-	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	GatewayID   string               `json:"gateway_id,omitempty" yaml:"gateway_id,omitempty"`
+	RecipientID string               `json:"recipient_id,omitempty" yaml:"recipient_id,omitempty"`
+	APISecret   string               `json:"api_secret,omitempty" yaml:"api_secret,omitempty"`
+	Title       string               `json:"title,omitempty" yaml:"title,omitempty"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	TLSConfig   *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -54,5 +55,9 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		settings.Title = templates.DefaultMessageTitleEmbed
 	}
 
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return settings, err
+	}
 	return settings, nil
 }
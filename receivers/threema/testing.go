@@ -6,7 +6,13 @@ const FullValidConfigForTesting = `{
 	"recipient_id": "*1234567",
 	"api_secret": "test-secret",
 	"title" : "test-title",
-	"description": "test-description"
+	"description": "test-description",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
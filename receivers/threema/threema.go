@@ -44,7 +44,8 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify send an alert notification to Threema
 func (tn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	tn.log.Debug("sending threema alert notification", "from", tn.settings.GatewayID, "to", tn.settings.RecipientID)
+	l := logging.FromContext(ctx, tn.log)
+	l.Debug("sending threema alert notification", "from", tn.settings.GatewayID, "to", tn.settings.RecipientID)
 
 	// Set up basic API request data
 	data := url.Values{}
@@ -53,6 +54,10 @@ func (tn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 	data.Set("secret", tn.settings.APISecret)
 	data.Set("text", tn.buildMessage(ctx, as...))
 
+	tlsConfig, err := receivers.ClientTLSConfig(tn.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        APIURL,
 		Body:       data.Encode(),
@@ -60,9 +65,10 @@ func (tn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		HTTPHeader: map[string]string{
 			"Content-Type": "application/x-www-form-urlencoded",
 		},
+		TLSConfig: tlsConfig,
 	}
 	if err := tn.ns.SendWebhook(ctx, cmd); err != nil {
-		tn.log.Error("Failed to send threema notification", "error", err, "webhook", tn.Name)
+		l.Error("Failed to send threema notification", "error", err, "webhook", tn.Name)
 		return false, err
 	}
 
@@ -74,8 +80,9 @@ func (tn *Notifier) SendResolved() bool {
 }
 
 func (tn *Notifier) buildMessage(ctx context.Context, as ...*types.Alert) string {
+	l := logging.FromContext(ctx, tn.log)
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, tn.tmpl, as, tn.log, &tmplErr)
+	tmpl, _ := templates.TmplText(ctx, tn.tmpl, as, l, &tmplErr)
 
 	message := fmt.Sprintf("%s%s\n\n*Message:*\n%s\n*URL:* %s\n",
 		selectEmoji(as...),
@@ -85,10 +92,10 @@ func (tn *Notifier) buildMessage(ctx context.Context, as ...*types.Alert) string
 	)
 
 	if tmplErr != nil {
-		tn.log.Warn("failed to template Threema message", "error", tmplErr.Error())
+		l.Warn("failed to template Threema message", "error", tmplErr.Error())
 	}
 
-	_ = images.WithStoredImages(ctx, tn.log, tn.images,
+	_ = images.WithStoredImages(ctx, l, tn.images,
 		func(_ int, image images.Image) error {
 			if image.URL != "" {
 				message += fmt.Sprintf("*Image:* %s\n", image.URL)
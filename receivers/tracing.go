@@ -0,0 +1,83 @@
+package receivers
+
+import (
+	"context"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/grafana/alerting/receivers")
+
+// traceContext propagates the trace context in the W3C traceparent and tracestate headers.
+var traceContext = propagation.TraceContext{}
+
+// TracingWebhookSender is a WebhookSender that records a span for each request and propagates the trace context of
+// the notification to the receiving service with the traceparent header. The size of the body of each request is
+// reported to the PayloadObserver of the context.
+type TracingWebhookSender struct {
+	upstream WebhookSender
+}
+
+// NewTracingWebhookSender returns a TracingWebhookSender that sends the requests with s.
+func NewTracingWebhookSender(s WebhookSender) *TracingWebhookSender {
+	return &TracingWebhookSender{upstream: s}
+}
+
+func (s *TracingWebhookSender) SendWebhook(ctx context.Context, cmd *SendWebhookSettings) error {
+	method := cmd.HTTPMethod
+	if method == "" {
+		method = "POST"
+	}
+	// Only the host is recorded, as the URLs of many integrations have credentials in their path or query.
+	attrs := []attribute.KeyValue{attribute.String("http.request.method", method)}
+	if u, err := url.Parse(cmd.URL); err == nil {
+		attrs = append(attrs, attribute.String("server.address", u.Host))
+	}
+	ctx, span := tracer.Start(ctx, "receivers.SendWebhook", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	// The headers are copied, as the command can be sent again by the next attempt of the notification.
+	header := make(map[string]string, len(cmd.HTTPHeader)+2)
+	for k, v := range cmd.HTTPHeader {
+		header[k] = v
+	}
+	traceContext.Inject(ctx, propagation.MapCarrier(header))
+	traced := *cmd
+	traced.HTTPHeader = header
+	ObservePayload(ctx, len(cmd.Body))
+
+	if err := s.upstream.SendWebhook(ctx, &traced); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// TracingEmailSender is an EmailSender that records a span for each email.
+type TracingEmailSender struct {
+	upstream EmailSender
+}
+
+// NewTracingEmailSender returns a TracingEmailSender that sends the emails with s.
+func NewTracingEmailSender(s EmailSender) *TracingEmailSender {
+	return &TracingEmailSender{upstream: s}
+}
+
+func (s *TracingEmailSender) SendEmail(ctx context.Context, cmd *SendEmailSettings) error {
+	ctx, span := tracer.Start(ctx, "receivers.SendEmail", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.Int("email.recipients", len(cmd.To)),
+	))
+	defer span.End()
+	if err := s.upstream.SendEmail(ctx, cmd); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package receivers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingWebhookSender(t *testing.T) {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	t.Run("propagates the trace context in the traceparent header", func(t *testing.T) {
+		mock := MockNotificationService()
+		header := map[string]string{"Content-Type": "application/json"}
+		cmd := &SendWebhookSettings{URL: "https://example.com/hook?token=secret", HTTPHeader: header}
+
+		require.NoError(t, NewTracingWebhookSender(mock).SendWebhook(traced, cmd))
+		require.Equal(t, map[string]string{
+			"Content-Type": "application/json",
+			"traceparent":  "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		}, mock.Webhook.HTTPHeader)
+		require.Equal(t, map[string]string{"Content-Type": "application/json"}, cmd.HTTPHeader, "headers of the command should not change")
+	})
+
+	t.Run("does not add headers without trace context", func(t *testing.T) {
+		mock := MockNotificationService()
+		require.NoError(t, NewTracingWebhookSender(mock).SendWebhook(context.Background(), &SendWebhookSettings{URL: "https://example.com"}))
+		require.Empty(t, mock.Webhook.HTTPHeader)
+	})
+
+	t.Run("reports the size of the body to the payload observer", func(t *testing.T) {
+		var sizes []int
+		ctx := WithPayloadObserver(context.Background(), func(bytes int) { sizes = append(sizes, bytes) })
+		require.NoError(t, NewTracingWebhookSender(MockNotificationService()).SendWebhook(ctx, &SendWebhookSettings{URL: "https://example.com", Body: "body"}))
+		require.Equal(t, []int{4}, sizes)
+	})
+
+	t.Run("returns the error of the request", func(t *testing.T) {
+		mock := MockNotificationService()
+		mock.ShouldError = errors.New("failed")
+		require.ErrorIs(t, NewTracingWebhookSender(mock).SendWebhook(traced, &SendWebhookSettings{URL: "https://example.com"}), mock.ShouldError)
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/alerting/logging"
@@ -38,9 +39,10 @@ func GetAlertStatusColor(status model.AlertStatus) string {
 }
 
 type HTTPCfg struct {
-	Body     []byte
-	User     string
-	Password string
+	Body      []byte
+	User      string
+	Password  string
+	TLSConfig *tls.Config
 }
 
 type TLSConfig struct {
@@ -48,7 +50,44 @@ type TLSConfig struct {
 	ClientCertificate  string `json:"clientCertificate,omitempty" yaml:"clientCertificate,omitempty"`
 	ClientKey          string `json:"clientKey,omitempty" yaml:"clientKey,omitempty"`
 	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
-	ServerName         string
+	// MinVersion is the minimum TLS version, one of TLS10, TLS11, TLS12 or TLS13. Defaults to the minimum version
+	// of crypto/tls.
+	MinVersion string `json:"minVersion,omitempty" yaml:"minVersion,omitempty"`
+	ServerName string
+}
+
+var tlsVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+// ParseTLSConfig decrypts the secrets of the TLS configuration of an integration and validates it. The secrets are
+// read from the keys tlsConfig.caCertificate, tlsConfig.clientCertificate and tlsConfig.clientKey. It returns nil
+// if the integration has no TLS configuration.
+func ParseTLSConfig(raw *TLSConfig, decryptFn DecryptFunc) (*TLSConfig, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	cfg := &TLSConfig{
+		CACertificate:      decryptFn("tlsConfig.caCertificate", raw.CACertificate),
+		ClientCertificate:  decryptFn("tlsConfig.clientCertificate", raw.ClientCertificate),
+		ClientKey:          decryptFn("tlsConfig.clientKey", raw.ClientKey),
+		InsecureSkipVerify: raw.InsecureSkipVerify,
+		MinVersion:         raw.MinVersion,
+		ServerName:         raw.ServerName,
+	}
+	if (cfg.ClientCertificate == "") != (cfg.ClientKey == "") {
+		return nil, errors.New("tlsConfig.clientCertificate and tlsConfig.clientKey must be specified together")
+	}
+	if _, ok := tlsVersions[cfg.MinVersion]; cfg.MinVersion != "" && !ok {
+		return nil, fmt.Errorf("invalid value for tlsConfig.minVersion: %q", cfg.MinVersion)
+	}
+	if FIPSMode() && (cfg.MinVersion == "TLS10" || cfg.MinVersion == "TLS11") {
+		return nil, fmt.Errorf("invalid value for tlsConfig.minVersion: %s is %w", cfg.MinVersion, ErrNotFIPSApproved)
+	}
+	return cfg, nil
 }
 
 func (cfg *TLSConfig) ToCryptoTLSConfig() (*tls.Config, error) {
@@ -57,6 +96,14 @@ func (cfg *TLSConfig) ToCryptoTLSConfig() (*tls.Config, error) {
 		ServerName:         cfg.ServerName,
 	}
 
+	if cfg.MinVersion != "" {
+		v, ok := tlsVersions[cfg.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS version %q", cfg.MinVersion)
+		}
+		tlsCfg.MinVersion = v
+	}
+
 	if cfg.CACertificate != "" {
 		tlsCfg.RootCAs = x509.NewCertPool()
 		ok := tlsCfg.RootCAs.AppendCertsFromPEM([]byte(cfg.CACertificate))
@@ -73,7 +120,7 @@ func (cfg *TLSConfig) ToCryptoTLSConfig() (*tls.Config, error) {
 		tlsCfg.Certificates = append(tlsCfg.Certificates, cert)
 	}
 
-	return tlsCfg, nil
+	return RestrictTLSConfig(tlsCfg), nil
 }
 
 // NewTLSClient creates a new HTTP client with the provided TLS configuration or with default settings.
@@ -93,10 +140,19 @@ func NewTLSClient(tlsConfig *tls.Config) *http.Client {
 	}
 
 	if tlsConfig == nil {
-		return nc(&tls.Config{Renegotiation: tls.RenegotiateFreelyAsClient})
+		return nc(RestrictTLSConfig(&tls.Config{Renegotiation: tls.RenegotiateFreelyAsClient}))
 	}
 
-	return nc(tlsConfig)
+	return nc(RestrictTLSConfig(tlsConfig))
+}
+
+// ClientTLSConfig returns the crypto/tls configuration of an integration, or nil if the integration has no TLS
+// configuration and FIPS mode is disabled.
+func ClientTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	if cfg == nil {
+		return RestrictTLSConfig(nil), nil
+	}
+	return cfg.ToCryptoTLSConfig()
 }
 
 // SendHTTPRequest sends an HTTP request.
@@ -105,6 +161,7 @@ func NewTLSClient(tlsConfig *tls.Config) *http.Client {
 //nolint:unused, varcheck
 var SendHTTPRequest = func(ctx context.Context, url *url.URL, cfg HTTPCfg, logger logging.Logger) ([]byte, error) {
 	var reader io.Reader
+	ObservePayload(ctx, len(cfg.Body))
 	if len(cfg.Body) > 0 {
 		reader = bytes.NewReader(cfg.Body)
 	}
@@ -118,11 +175,16 @@ var SendHTTPRequest = func(ctx context.Context, url *url.URL, cfg HTTPCfg, logge
 
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("User-Agent", "Grafana")
-	netTransport := &http.Transport{
-		TLSClientConfig: &tls.Config{
+	tlsConfig := cfg.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{
 			Renegotiation: tls.RenegotiateFreelyAsClient,
-		},
-		Proxy: http.ProxyFromEnvironment,
+		}
+	}
+	tlsConfig = RestrictTLSConfig(tlsConfig)
+	netTransport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+		Proxy:           http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
 			Timeout: 30 * time.Second,
 		}).DialContext,
@@ -149,8 +211,8 @@ var SendHTTPRequest = func(ctx context.Context, url *url.URL, cfg HTTPCfg, logge
 
 	if resp.StatusCode/100 != 2 {
 		logger.Warn("HTTP request failed", "url", request.URL.String(), "statusCode", resp.Status, "Body",
-			string(respBody))
-		return nil, fmt.Errorf("failed to send HTTP request - status code %d", resp.StatusCode)
+			string(DecodeResponseBody(resp.Header, respBody)))
+		return nil, notify.NewErrorWithReason(notify.GetFailureReasonFromStatusCode(resp.StatusCode), fmt.Errorf("failed to send HTTP request - status code %d", resp.StatusCode))
 	}
 
 	logger.Debug("sending HTTP request succeeded", "url", request.URL.String(), "statusCode", resp.Status)
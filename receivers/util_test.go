@@ -74,6 +74,20 @@ func TestNewTLSConfig(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "valid min version",
+			cfg: TLSConfig{
+				MinVersion: "TLS12",
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid min version",
+			cfg: TLSConfig{
+				MinVersion: "SSL30",
+			},
+			expectError: true,
+		},
 		{
 			name: "set InsecureSkipVerify and ServerName",
 			cfg: TLSConfig{
@@ -101,6 +115,10 @@ func TestNewTLSConfig(t *testing.T) {
 					require.NotNil(t, tlsCfg.RootCAs, "expected RootCAs to be initialized, but it was nil")
 				}
 
+				if tt.cfg.MinVersion != "" {
+					require.Equal(t, tlsVersions[tt.cfg.MinVersion], tlsCfg.MinVersion, "MinVersion mismatch")
+				}
+
 				if tt.cfg.ClientCertificate != "" && tt.cfg.ClientKey != "" {
 					require.NotEmpty(t, tlsCfg.Certificates, "expected Certificates to be set, but it was empty")
 				}
@@ -109,6 +127,39 @@ func TestNewTLSConfig(t *testing.T) {
 	}
 }
 
+func TestParseTLSConfig(t *testing.T) {
+	decryptFn := func(key string, fallback string) string {
+		if key == "tlsConfig.clientKey" {
+			return "test-secret-client-key"
+		}
+		return fallback
+	}
+
+	cfg, err := ParseTLSConfig(nil, decryptFn)
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+
+	cfg, err = ParseTLSConfig(&TLSConfig{
+		CACertificate:     "test-ca-certificate",
+		ClientCertificate: "test-client-certificate",
+		MinVersion:        "TLS13",
+	}, decryptFn)
+	require.NoError(t, err)
+	require.Equal(t, &TLSConfig{
+		CACertificate:     "test-ca-certificate",
+		ClientCertificate: "test-client-certificate",
+		ClientKey:         "test-secret-client-key",
+		MinVersion:        "TLS13",
+	}, cfg)
+
+	noSecrets := func(_ string, fallback string) string { return fallback }
+	_, err = ParseTLSConfig(&TLSConfig{ClientCertificate: "test-client-certificate"}, noSecrets)
+	require.EqualError(t, err, "tlsConfig.clientCertificate and tlsConfig.clientKey must be specified together")
+
+	_, err = ParseTLSConfig(&TLSConfig{MinVersion: "TLS14"}, noSecrets)
+	require.EqualError(t, err, `invalid value for tlsConfig.minVersion: "TLS14"`)
+}
+
 func Test_NewTLSClient(t *testing.T) {
 	tc := []struct {
 		name   string
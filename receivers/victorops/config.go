@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
+	"github.com/grafana/alerting/receivers"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -13,22 +16,41 @@ const (
 	DefaultMessageType = "CRITICAL"
 )
 
+// severityMessageTypes are the message types that severities can be mapped to, from the most to the least severe.
+var severityMessageTypes = []string{"CRITICAL", "WARNING", "INFO"}
+
 type Config struct {
-	URL         string `json:"url,omitempty" yaml:"url,omitempty"`
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// RoutingKey is the template of the routing key of the incidents, which is appended to the URL. It can be left
+	// empty if the URL already ends with the routing key.
+	RoutingKey  string `json:"routingKey,omitempty" yaml:"routingKey,omitempty"`
 	MessageType string `json:"messageType,omitempty" yaml:"messageType,omitempty"`
-	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
-	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// MessageTypeBySeverity maps the values of the severity label of the alerts to message types, CRITICAL, WARNING
+	// or INFO. Firing notifications have the most severe message type of their alerts, or MessageType if none of
+	// their alerts has a mapped severity.
+	MessageTypeBySeverity map[string]string    `json:"messageTypeBySeverity,omitempty" yaml:"messageTypeBySeverity,omitempty"`
+	Title                 string               `json:"title,omitempty" yaml:"title,omitempty"`
+	Description           string               `json:"description,omitempty" yaml:"description,omitempty"`
+	TLSConfig             *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
-func NewConfig(jsonData json.RawMessage) (Config, error) {
+func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
 	settings := Config{}
 	err := json.Unmarshal(jsonData, &settings)
 	if err != nil {
 		return settings, fmt.Errorf("failed to unmarshal settings: %w", err)
 	}
+	settings.URL = decryptFn("url", settings.URL)
 	if settings.URL == "" {
 		return settings, errors.New("could not find victorops url property in settings")
 	}
+	for severity, messageType := range settings.MessageTypeBySeverity {
+		messageType = strings.ToUpper(messageType)
+		if !slices.Contains(severityMessageTypes, messageType) {
+			return settings, fmt.Errorf("invalid message type %q for severity %q, must be one of %s", messageType, severity, strings.Join(severityMessageTypes, ", "))
+		}
+		settings.MessageTypeBySeverity[severity] = messageType
+	}
 	if settings.MessageType == "" {
 		settings.MessageType = DefaultMessageType
 	}
@@ -38,5 +60,9 @@ func NewConfig(jsonData json.RawMessage) (Config, error) {
 	if settings.Description == "" {
 		settings.Description = templates.DefaultMessageEmbed
 	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return settings, err
+	}
 	return settings, nil
 }
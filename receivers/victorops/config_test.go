@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alerting/receivers"
+	receiversTesting "github.com/grafana/alerting/receivers/testing"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -13,6 +15,7 @@ func TestNewConfig(t *testing.T) {
 	cases := []struct {
 		name              string
 		settings          string
+		secrets           map[string][]byte
 		expectedConfig    Config
 		expectedInitError string
 	}{
@@ -51,21 +54,73 @@ func TestNewConfig(t *testing.T) {
 				Description: templates.DefaultMessageEmbed,
 			},
 		},
+		{
+			name:              "Error if message type of severity is invalid",
+			settings:          `{"url": "http://localhost", "messageTypeBySeverity": {"critical": "RECOVERY"}}`,
+			expectedInitError: `invalid message type "RECOVERY" for severity "critical", must be one of CRITICAL, WARNING, INFO`,
+		},
+		{
+			name:     "Extracts URL from secrets",
+			settings: `{}`,
+			secrets:  receiversTesting.ReadSecretsJSONForTesting(FullValidSecretsForTesting),
+			expectedConfig: Config{
+				URL:         "http://localhost/secret",
+				MessageType: DefaultMessageType,
+				Title:       templates.DefaultMessageTitleEmbed,
+				Description: templates.DefaultMessageEmbed,
+			},
+		},
 		{
 			name:     "Extracts all fields",
 			settings: FullValidConfigForTesting,
 			expectedConfig: Config{
 				URL:         "http://localhost",
+				RoutingKey:  "test-routing-key",
+				MessageType: "test-messagetype",
+				MessageTypeBySeverity: map[string]string{
+					"critical": "CRITICAL",
+					"warning":  "WARNING",
+				},
+				Title:       "test-title",
+				Description: "test-description",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
+			},
+		},
+		{
+			name:     "Extracts TLS secrets",
+			settings: FullValidConfigForTesting,
+			secrets: map[string][]byte{
+				"tlsConfig.clientCertificate": []byte("test-secret-client-certificate"),
+				"tlsConfig.clientKey":         []byte("test-secret-client-key"),
+			},
+			expectedConfig: Config{
+				URL:         "http://localhost",
+				RoutingKey:  "test-routing-key",
 				MessageType: "test-messagetype",
+				MessageTypeBySeverity: map[string]string{
+					"critical": "CRITICAL",
+					"warning":  "WARNING",
+				},
 				Title:       "test-title",
 				Description: "test-description",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-secret-client-certificate",
+					ClientKey:         "test-secret-client-key",
+					MinVersion:        "TLS12",
+				},
 			},
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			actual, err := NewConfig(json.RawMessage(c.settings))
+			actual, err := NewConfig(json.RawMessage(c.settings), receiversTesting.DecryptForTesting(c.secrets))
 
 			if c.expectedInitError != "" {
 				require.ErrorContains(t, err, c.expectedInitError)
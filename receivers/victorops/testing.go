@@ -3,7 +3,23 @@ package victorops
 // FullValidConfigForTesting is a string representation of a JSON object that contains all fields supported by the notifier Config. It can be used without secrets.
 const FullValidConfigForTesting = `{
 	"url" : "http://localhost",
+	"routingKey" :"test-routing-key",
 	"messageType" :"test-messagetype",
+	"messageTypeBySeverity" : {
+		"critical": "CRITICAL",
+		"warning": "warning"
+	},
 	"title" :"test-title",
-	"description" :"test-description"
+	"description" :"test-description",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
+}`
+
+// FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
+const FullValidSecretsForTesting = `{
+	"url": "http://localhost/secret"
 }`
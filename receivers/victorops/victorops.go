@@ -3,6 +3,8 @@ package victorops
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -54,12 +56,13 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify sends notification to Victorops via POST to URL endpoint
 func (vn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	vn.log.Debug("sending notification", "notification", vn.Name)
+	l := logging.FromContext(ctx, vn.log)
+	l.Debug("sending notification")
 
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, vn.tmpl, as, vn.log, &tmplErr)
+	tmpl, _ := templates.TmplText(ctx, vn.tmpl, as, l, &tmplErr)
 
-	messageType := buildMessageType(vn.log, tmpl, vn.settings.MessageType, as...)
+	messageType := buildMessageType(l, tmpl, vn.settings.MessageType, vn.settings.MessageTypeBySeverity, as...)
 
 	groupKey, err := notify.ExtractGroupKey(ctx)
 	if err != nil {
@@ -68,7 +71,7 @@ func (vn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 
 	stateMessage, truncated := receivers.TruncateInRunes(tmpl(vn.settings.Description), victorOpsMaxMessageLenRunes)
 	if truncated {
-		vn.log.Warn("Truncated stateMessage", "incident", groupKey, "max_runes", victorOpsMaxMessageLenRunes)
+		l.Warn("Truncated stateMessage", "incident", groupKey, "max_runes", victorOpsMaxMessageLenRunes)
 	}
 
 	bodyJSON := map[string]interface{}{
@@ -81,12 +84,12 @@ func (vn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 	}
 
 	if tmplErr != nil {
-		vn.log.Warn("failed to expand message template. "+
+		l.Warn("failed to expand message template. "+
 			"", "error", tmplErr.Error())
 		tmplErr = nil
 	}
 
-	_ = images.WithStoredImages(ctx, vn.log, vn.images,
+	_ = images.WithStoredImages(ctx, l, vn.images,
 		func(_ int, image images.Image) error {
 			if image.URL != "" {
 				bodyJSON["image_url"] = image.URL
@@ -95,26 +98,41 @@ func (vn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 			return nil
 		}, as...)
 
-	ruleURL := receivers.JoinURLPath(vn.tmpl.ExternalURL.String(), "/alerting/list", vn.log)
+	ruleURL := receivers.JoinURLPath(vn.tmpl.ExternalURL.String(), "/alerting/list", l)
 	bodyJSON["alert_url"] = ruleURL
 
 	u := tmpl(vn.settings.URL)
 	if tmplErr != nil {
-		vn.log.Info("failed to expand URL template", "error", tmplErr.Error(), "fallback", vn.settings.URL)
+		l.Info("failed to expand URL template", "error", tmplErr.Error(), "fallback", vn.settings.URL)
 		u = vn.settings.URL
+		tmplErr = nil
+	}
+	if vn.settings.RoutingKey != "" {
+		routingKey := tmpl(vn.settings.RoutingKey)
+		if tmplErr != nil || routingKey == "" {
+			// Without the routing key, the incident is routed by the routing key of the URL, or the default one.
+			l.Warn("failed to expand routing key template, sending without routing key", "error", tmplErr)
+		} else {
+			u = receivers.JoinURLPath(u, routingKey, l)
+		}
 	}
 
 	b, err := json.Marshal(bodyJSON)
 	if err != nil {
 		return false, err
 	}
+	tlsConfig, err := receivers.ClientTLSConfig(vn.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
-		URL:  u,
-		Body: string(b),
+		URL:       u,
+		Body:      string(b),
+		TLSConfig: tlsConfig,
 	}
 
 	if err := vn.ns.SendWebhook(ctx, cmd); err != nil {
-		vn.log.Error("failed to send notification", "error", err, "webhook", vn.Name)
+		l.Error("failed to send notification", "error", err, "webhook", vn.Name)
 		return false, err
 	}
 
@@ -125,13 +143,38 @@ func (vn *Notifier) SendResolved() bool {
 	return !vn.GetDisableResolveMessage()
 }
 
-func buildMessageType(l logging.Logger, tmpl func(string) string, msgType string, as ...*types.Alert) string {
+func buildMessageType(l logging.Logger, tmpl func(string) string, msgType string, bySeverity map[string]string, as ...*types.Alert) string {
 	if types.Alerts(as...).Status() == model.AlertResolved {
 		return victoropsAlertStateRecovery
 	}
+	if messageType := messageTypeBySeverity(bySeverity, as...); messageType != "" {
+		return messageType
+	}
 	if messageType := strings.ToUpper(tmpl(msgType)); messageType != "" {
 		return messageType
 	}
 	l.Warn("expansion of message type template resulted in an empty string. Using fallback", "fallback", DefaultMessageType, "template", msgType)
 	return DefaultMessageType
 }
+
+// messageTypeBySeverity returns the most severe message type that the severities of the firing alerts are mapped to,
+// or an empty string if none is mapped.
+func messageTypeBySeverity(bySeverity map[string]string, as ...*types.Alert) string {
+	res := len(severityMessageTypes)
+	for _, a := range as {
+		if a.Resolved() {
+			continue
+		}
+		messageType, ok := bySeverity[string(a.Labels["severity"])]
+		if !ok {
+			continue
+		}
+		if i := slices.Index(severityMessageTypes, messageType); i < res {
+			res = i
+		}
+	}
+	if res == len(severityMessageTypes) {
+		return ""
+	}
+	return severityMessageTypes[res]
+}
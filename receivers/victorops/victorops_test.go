@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -265,3 +266,80 @@ func TestNotify(t *testing.T) {
 		})
 	}
 }
+
+func TestNotify_RoutingKeyAndSeverity(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	alert := func(severity string, resolved bool) *types.Alert {
+		a := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "team": "db", "severity": model.LabelValue(severity)}}}
+		if resolved {
+			a.EndsAt = time.Now().Add(-time.Minute)
+		}
+		return a
+	}
+
+	cases := []struct {
+		name           string
+		alerts         []*types.Alert
+		expURL         string
+		expMessageType string
+	}{
+		{
+			name:           "most severe message type of the firing alerts",
+			alerts:         []*types.Alert{alert("info", false), alert("warning", false), alert("critical", true)},
+			expURL:         "http://localhost/api/key/team-db",
+			expMessageType: "WARNING",
+		},
+		{
+			name:           "message type if no severity is mapped",
+			alerts:         []*types.Alert{alert("unknown", false)},
+			expURL:         "http://localhost/api/key/team-db",
+			expMessageType: "ACKNOWLEDGEMENT",
+		},
+		{
+			name:           "recovery if all alerts are resolved",
+			alerts:         []*types.Alert{alert("critical", true)},
+			expURL:         "http://localhost/api/key/team-db",
+			expMessageType: "RECOVERY",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			webhookSender := receivers.MockNotificationService()
+			pn := &Notifier{
+				Base: &receivers.Base{},
+				log:  &logging.FakeLogger{},
+				ns:   webhookSender,
+				tmpl: tmpl,
+				settings: Config{
+					URL:         "http://localhost/api/key",
+					RoutingKey:  "team-{{ .CommonLabels.team }}",
+					MessageType: "ACKNOWLEDGEMENT",
+					MessageTypeBySeverity: map[string]string{
+						"critical": "CRITICAL",
+						"warning":  "WARNING",
+						"info":     "INFO",
+					},
+					Title:       templates.DefaultMessageTitleEmbed,
+					Description: templates.DefaultMessageEmbed,
+				},
+				images: &images2.UnavailableProvider{},
+			}
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := pn.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			require.Equal(t, c.expURL, webhookSender.Webhook.URL)
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(webhookSender.Webhook.Body), &body))
+			require.Equal(t, c.expMessageType, body["message_type"])
+		})
+	}
+}
@@ -17,10 +17,11 @@ const (
 // https://github.com/prometheus/alertmanager/blob/main/notify/webex/webex.go
 // Currently, the Alerting team is unifying channels and (upstream) receivers - any discrepancy is detrimental to that.
 type Config struct {
-	Message string `json:"message,omitempty" yaml:"message,omitempty"`
-	RoomID  string `json:"room_id,omitempty" yaml:"room_id,omitempty"`
-	APIURL  string `json:"api_url,omitempty" yaml:"api_url,omitempty"`
-	Token   string `json:"bot_token" yaml:"bot_token"`
+	Message   string               `json:"message,omitempty" yaml:"message,omitempty"`
+	RoomID    string               `json:"room_id,omitempty" yaml:"room_id,omitempty"`
+	APIURL    string               `json:"api_url,omitempty" yaml:"api_url,omitempty"`
+	Token     string               `json:"bot_token" yaml:"bot_token"`
+	TLSConfig *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
 // NewConfig is the constructor for the Webex notifier.
@@ -47,5 +48,9 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	}
 	settings.APIURL = u.String()
 
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
 	return settings, err
 }
@@ -5,7 +5,13 @@ const FullValidConfigForTesting = `{
 	"message" :"test-message",  
 	"room_id" :"test-room-id",
 	"api_url" :"http://localhost",
-	"bot_token" :"12345"
+	"bot_token" :"12345",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
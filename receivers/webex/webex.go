@@ -46,16 +46,17 @@ type webexMessage struct {
 
 // Notify implements the Notifier interface.
 func (wn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, wn.log)
 	var tmplErr error
-	tmpl, data := templates.TmplText(ctx, wn.tmpl, as, wn.log, &tmplErr)
+	tmpl, data := templates.TmplText(ctx, wn.tmpl, as, l, &tmplErr)
 
 	message, truncated := receivers.TruncateInBytes(tmpl(wn.settings.Message), 4096)
 	if truncated {
-		wn.log.Warn("Webex message too long, truncating message", "OriginalMessage", wn.settings.Message)
+		l.Warn("Webex message too long, truncating message", "OriginalMessage", wn.settings.Message)
 	}
 
 	if tmplErr != nil {
-		wn.log.Warn("Failed to template webex message", "Error", tmplErr.Error())
+		l.Warn("Failed to template webex message", "Error", tmplErr.Error())
 		tmplErr = nil
 	}
 
@@ -66,7 +67,7 @@ func (wn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 	}
 
 	// Augment our Alert data with ImageURLs if available.
-	_ = images.WithStoredImages(ctx, wn.log, wn.images, func(index int, image images.Image) error {
+	_ = images.WithStoredImages(ctx, l, wn.images, func(index int, image images.Image) error {
 		// Cisco Webex only supports a single image per request: https://developer.webex.com/docs/basics#message-attachments
 		if image.HasURL() {
 			data.Alerts[index].ImageURL = image.URL
@@ -87,10 +88,15 @@ func (wn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		return false, tmplErr
 	}
 
+	tlsConfig, err := receivers.ClientTLSConfig(wn.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
 		URL:        parsedURL,
 		Body:       string(body),
 		HTTPMethod: http.MethodPost,
+		TLSConfig:  tlsConfig,
 	}
 
 	if wn.settings.Token != "" {
@@ -13,8 +13,10 @@ type SendWebhookSettings struct {
 	HTTPMethod  string
 	HTTPHeader  map[string]string
 	ContentType string
-	Validation  func(body []byte, statusCode int) error
-	TLSConfig   *tls.Config
+	// Validation, if set, is called with the body and the status code of the response instead of failing on status
+	// codes other than 2xx. Senders pass it the body decoded with DecodeResponseBody.
+	Validation func(body []byte, statusCode int) error
+	TLSConfig  *tls.Config
 }
 
 type WebhookSender interface {
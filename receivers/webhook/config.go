@@ -5,14 +5,70 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/textproto"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
+	"text/template"
 
 	"github.com/grafana/alerting/receivers"
 	"github.com/grafana/alerting/templates"
 )
 
+const (
+	// FormatJSON sends the notification as a single JSON object with all of its alerts. It is the default.
+	FormatJSON = "json"
+	// FormatNDJSON streams the notification as newline-delimited JSON, one alert per line.
+	FormatNDJSON = "ndjson"
+)
+
+const (
+	// EncodingJSON encodes the notification as JSON. It is the default.
+	EncodingJSON = "json"
+	// EncodingProtobuf encodes the notification as an AlertGroup message of the schema in package alertpb.
+	EncodingProtobuf = "protobuf"
+)
+
+// DefaultHMACHeader is the header of the signature of requests if no header is configured.
+const DefaultHMACHeader = "X-Grafana-Alerting-Signature"
+
+// HMACConfig configures the signing of requests with HMAC-SHA256, so that receiving services can verify that
+// requests were sent by the Alertmanager.
+type HMACConfig struct {
+	// Secret is the key of the signature.
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+	// Header is the header of the signature. It defaults to DefaultHMACHeader.
+	Header string `json:"header,omitempty" yaml:"header,omitempty"`
+	// TimestampHeader, if set, is the header of the Unix timestamp of the request. The timestamp is then signed
+	// with the body, as "timestamp:body", so that receiving services can reject replayed requests.
+	TimestampHeader string `json:"timestampHeader,omitempty" yaml:"timestampHeader,omitempty"`
+}
+
+// blockedHeaders are the headers that cannot be configured, as they are set by the HTTP client or only apply to
+// a single connection.
+var blockedHeaders = map[string]struct{}{
+	"Connection":          {},
+	"Content-Length":      {},
+	"Host":                {},
+	"Keep-Alive":          {},
+	"Proxy-Authenticate":  {},
+	"Proxy-Authorization": {},
+	"Proxy-Connection":    {},
+	"Te":                  {},
+	"Trailer":             {},
+	"Transfer-Encoding":   {},
+	"Upgrade":             {},
+}
+
+// allowedHTTPMethods are the methods that notifications can be sent with.
+var allowedHTTPMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
+
 type Config struct {
-	URL        string
+	// URL is templated, so that, for example, label values can be part of the path. It must render to an absolute
+	// http or https URL, see validateURL.
+	URL string
+	// HTTPMethod is one of allowedHTTPMethods. Empty is POST.
 	HTTPMethod string
 	MaxAlerts  int
 	// Authorization Header.
@@ -22,9 +78,17 @@ type Config struct {
 	User     string
 	Password string
 
-	Title     string
-	Message   string
-	TLSConfig *receivers.TLSConfig
+	Title      string
+	Message    string
+	TLSConfig  *receivers.TLSConfig
+	HMACConfig *HMACConfig
+	// Format is the format of the request body, either FormatJSON or FormatNDJSON. Empty is FormatJSON.
+	Format string
+	// Encoding is the encoding of the request body, either EncodingJSON or EncodingProtobuf. Empty is EncodingJSON.
+	Encoding string
+	// Headers are added to the requests. The values are templated. The headers of the authorization and of the
+	// HMAC signature take precedence over them.
+	Headers map[string]string
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -40,6 +104,10 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		Title                    string                   `json:"title,omitempty" yaml:"title,omitempty"`
 		Message                  string                   `json:"message,omitempty" yaml:"message,omitempty"`
 		TLSConfig                *receivers.TLSConfig     `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
+		HMACConfig               *HMACConfig              `json:"hmacConfig,omitempty" yaml:"hmacConfig,omitempty"`
+		Format                   string                   `json:"format,omitempty" yaml:"format,omitempty"`
+		Encoding                 string                   `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+		Headers                  map[string]string        `json:"headers,omitempty" yaml:"headers,omitempty"`
 	}{}
 
 	err := json.Unmarshal(jsonData, &rawSettings)
@@ -49,13 +117,23 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 	if rawSettings.URL == "" {
 		return settings, errors.New("required field 'url' is not specified")
 	}
+	if strings.Contains(rawSettings.URL, "{{") {
+		if _, err := template.New("").Funcs(template.FuncMap(templates.DefaultFuncs)).Parse(rawSettings.URL); err != nil {
+			return settings, fmt.Errorf("url is not a valid template: %w", err)
+		}
+	} else if err := validateURL(rawSettings.URL); err != nil {
+		return settings, err
+	}
 	settings.URL = rawSettings.URL
 	settings.AuthorizationScheme = rawSettings.AuthorizationScheme
 
 	if rawSettings.HTTPMethod == "" {
 		rawSettings.HTTPMethod = http.MethodPost
 	}
-	settings.HTTPMethod = rawSettings.HTTPMethod
+	settings.HTTPMethod = strings.ToUpper(rawSettings.HTTPMethod)
+	if !slices.Contains(allowedHTTPMethods, settings.HTTPMethod) {
+		return settings, fmt.Errorf("invalid value for httpMethod: %q, must be one of %s", rawSettings.HTTPMethod, strings.Join(allowedHTTPMethods, ", "))
+	}
 
 	if rawSettings.MaxAlerts != "" {
 		settings.MaxAlerts, _ = strconv.Atoi(rawSettings.MaxAlerts.String())
@@ -80,14 +158,83 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		settings.Message = templates.DefaultMessageEmbed
 	}
 
-	if tlsConfig := rawSettings.TLSConfig; tlsConfig != nil {
-		settings.TLSConfig = &receivers.TLSConfig{
-			InsecureSkipVerify: tlsConfig.InsecureSkipVerify,
-			CACertificate:      decryptFn("tlsConfig.caCertificate", tlsConfig.CACertificate),
-			ClientCertificate:  decryptFn("tlsConfig.clientCertificate", tlsConfig.ClientCertificate),
-			ClientKey:          decryptFn("tlsConfig.clientKey", tlsConfig.ClientKey),
+	settings.TLSConfig, err = receivers.ParseTLSConfig(rawSettings.TLSConfig, decryptFn)
+	if err != nil {
+		return settings, err
+	}
+
+	if hmacConfig := rawSettings.HMACConfig; hmacConfig != nil {
+		settings.HMACConfig = &HMACConfig{
+			Secret:          decryptFn("hmacConfig.secret", hmacConfig.Secret),
+			Header:          hmacConfig.Header,
+			TimestampHeader: hmacConfig.TimestampHeader,
+		}
+		if settings.HMACConfig.Secret == "" {
+			return settings, errors.New("required field 'hmacConfig.secret' is not specified")
+		}
+		if err := receivers.ValidateHMACKey(settings.HMACConfig.Secret); err != nil {
+			return settings, fmt.Errorf("invalid value for hmacConfig.secret: %w", err)
+		}
+		if settings.HMACConfig.Header == "" {
+			settings.HMACConfig.Header = DefaultHMACHeader
 		}
 	}
 
+	switch rawSettings.Format {
+	case "", FormatJSON, FormatNDJSON:
+		settings.Format = rawSettings.Format
+	default:
+		return settings, fmt.Errorf("invalid value for format: %q", rawSettings.Format)
+	}
+
+	switch rawSettings.Encoding {
+	case "", EncodingJSON, EncodingProtobuf:
+		settings.Encoding = rawSettings.Encoding
+	default:
+		return settings, fmt.Errorf("invalid value for encoding: %q", rawSettings.Encoding)
+	}
+	if settings.Encoding == EncodingProtobuf && settings.Format == FormatNDJSON {
+		return settings, errors.New("format ndjson cannot be used with encoding protobuf")
+	}
+
+	for name := range rawSettings.Headers {
+		if !validHeaderName(name) {
+			return settings, fmt.Errorf("invalid header name %q", name)
+		}
+		if _, ok := blockedHeaders[textproto.CanonicalMIMEHeaderKey(name)]; ok {
+			return settings, fmt.Errorf("header %q cannot be configured", name)
+		}
+	}
+	settings.Headers = rawSettings.Headers
+
 	return settings, err
 }
+
+// validateURL checks that the URL, after templating, is an absolute http or https URL without whitespace. The URL
+// is not part of the error since it can contain credentials.
+func validateURL(u string) error {
+	if strings.ContainsAny(u, " \t\r\n") {
+		return errors.New("invalid url: must not contain whitespace")
+	}
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("invalid url: must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// validHeaderName returns whether the name is a token, as header names must be.
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
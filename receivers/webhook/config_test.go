@@ -75,7 +75,7 @@ func TestNewConfig(t *testing.T) {
 			settings: FullValidConfigForTesting,
 			expectedConfig: Config{
 				URL:                      "http://localhost",
-				HTTPMethod:               "test-httpMethod",
+				HTTPMethod:               http.MethodPut,
 				MaxAlerts:                2,
 				AuthorizationScheme:      "basic",
 				AuthorizationCredentials: "",
@@ -89,6 +89,14 @@ func TestNewConfig(t *testing.T) {
 					ClientKey:          "test-client-key",
 					CACertificate:      "test-ca-certificate",
 				},
+				HMACConfig: &HMACConfig{
+					Secret:          "test-hmac-secret",
+					Header:          "X-Test-Signature",
+					TimestampHeader: "X-Test-Timestamp",
+				},
+				Format:   FormatNDJSON,
+				Encoding: EncodingJSON,
+				Headers:  map[string]string{"X-Tenant": "{{ .CommonLabels.tenant }}"},
 			},
 		},
 		{
@@ -97,7 +105,7 @@ func TestNewConfig(t *testing.T) {
 			secretSettings: receiversTesting.ReadSecretsJSONForTesting(FullValidSecretsForTesting),
 			expectedConfig: Config{
 				URL:                      "http://localhost",
-				HTTPMethod:               "test-httpMethod",
+				HTTPMethod:               http.MethodPut,
 				MaxAlerts:                2,
 				AuthorizationScheme:      "basic",
 				AuthorizationCredentials: "",
@@ -111,6 +119,14 @@ func TestNewConfig(t *testing.T) {
 					ClientKey:          "test-client-key",
 					CACertificate:      "test-ca-certificate",
 				},
+				HMACConfig: &HMACConfig{
+					Secret:          "test-secret-hmac-secret",
+					Header:          "X-Test-Signature",
+					TimestampHeader: "X-Test-Timestamp",
+				},
+				Format:   FormatNDJSON,
+				Encoding: EncodingJSON,
+				Headers:  map[string]string{"X-Tenant": "{{ .CommonLabels.tenant }}"},
 			},
 		},
 		{
@@ -261,6 +277,133 @@ func TestNewConfig(t *testing.T) {
 			}`,
 			expectedInitError: "both HTTP Basic Authentication and Authorization Header are set, only 1 is permitted",
 		},
+		{
+			name:     "should parse format",
+			settings: `{"url": "http://localhost", "format": "json" }`,
+			expectedConfig: Config{
+				URL:        "http://localhost",
+				HTTPMethod: http.MethodPost,
+				Title:      templates.DefaultMessageTitleEmbed,
+				Message:    templates.DefaultMessageEmbed,
+				Format:     FormatJSON,
+			},
+		},
+		{
+			name:     "should parse encoding",
+			settings: `{"url": "http://localhost", "encoding": "protobuf" }`,
+			expectedConfig: Config{
+				URL:        "http://localhost",
+				HTTPMethod: http.MethodPost,
+				Title:      templates.DefaultMessageTitleEmbed,
+				Message:    templates.DefaultMessageEmbed,
+				Encoding:   EncodingProtobuf,
+			},
+		},
+		{
+			name:     "should default the HMAC header",
+			settings: `{"url": "http://localhost", "hmacConfig": {"secret": "test-secret"} }`,
+			expectedConfig: Config{
+				URL:        "http://localhost",
+				HTTPMethod: http.MethodPost,
+				Title:      templates.DefaultMessageTitleEmbed,
+				Message:    templates.DefaultMessageEmbed,
+				HMACConfig: &HMACConfig{
+					Secret: "test-secret",
+					Header: DefaultHMACHeader,
+				},
+			},
+		},
+		{
+			name:              "error if HMAC secret is missing",
+			settings:          `{"url": "http://localhost", "hmacConfig": {"header": "X-Signature"} }`,
+			expectedInitError: "required field 'hmacConfig.secret' is not specified",
+		},
+		{
+			name:              "error if format is not valid",
+			settings:          `{"url": "http://localhost", "format": "xml" }`,
+			expectedInitError: `invalid value for format: "xml"`,
+		},
+		{
+			name:              "error if encoding is not valid",
+			settings:          `{"url": "http://localhost", "encoding": "avro" }`,
+			expectedInitError: `invalid value for encoding: "avro"`,
+		},
+		{
+			name:              "error if format ndjson is used with encoding protobuf",
+			settings:          `{"url": "http://localhost", "format": "ndjson", "encoding": "protobuf" }`,
+			expectedInitError: "format ndjson cannot be used with encoding protobuf",
+		},
+		{
+			name:     "should parse headers",
+			settings: `{"url": "http://localhost", "headers": {"X-Scope-OrgID": "{{ .CommonLabels.tenant }}"} }`,
+			expectedConfig: Config{
+				URL:        "http://localhost",
+				HTTPMethod: http.MethodPost,
+				Title:      templates.DefaultMessageTitleEmbed,
+				Message:    templates.DefaultMessageEmbed,
+				Headers:    map[string]string{"X-Scope-OrgID": "{{ .CommonLabels.tenant }}"},
+			},
+		},
+		{
+			name:              "error if a header name is not valid",
+			settings:          `{"url": "http://localhost", "headers": {"X Tenant": "test"} }`,
+			expectedInitError: `invalid header name "X Tenant"`,
+		},
+		{
+			name:              "error if a header cannot be configured",
+			settings:          `{"url": "http://localhost", "headers": {"transfer-encoding": "chunked"} }`,
+			expectedInitError: `header "transfer-encoding" cannot be configured`,
+		},
+		{
+			name:     "should normalize the HTTP method",
+			settings: `{"url": "http://localhost", "httpMethod": "patch" }`,
+			expectedConfig: Config{
+				URL:        "http://localhost",
+				HTTPMethod: http.MethodPatch,
+				Title:      templates.DefaultMessageTitleEmbed,
+				Message:    templates.DefaultMessageEmbed,
+			},
+		},
+		{
+			name:              "error if the HTTP method is not allowed",
+			settings:          `{"url": "http://localhost", "httpMethod": "GET" }`,
+			expectedInitError: `invalid value for httpMethod: "GET", must be one of POST, PUT, PATCH`,
+		},
+		{
+			name:     "should accept a templated URL",
+			settings: `{"url": "http://localhost/teams/{{ .CommonLabels.team }}/alerts" }`,
+			expectedConfig: Config{
+				URL:        "http://localhost/teams/{{ .CommonLabels.team }}/alerts",
+				HTTPMethod: http.MethodPost,
+				Title:      templates.DefaultMessageTitleEmbed,
+				Message:    templates.DefaultMessageEmbed,
+			},
+		},
+		{
+			name:     "should accept a templated URL that uses template functions",
+			settings: `{"url": "http://localhost/teams/{{ .CommonLabels.team | toLower }}/alerts" }`,
+			expectedConfig: Config{
+				URL:        "http://localhost/teams/{{ .CommonLabels.team | toLower }}/alerts",
+				HTTPMethod: http.MethodPost,
+				Title:      templates.DefaultMessageTitleEmbed,
+				Message:    templates.DefaultMessageEmbed,
+			},
+		},
+		{
+			name:              "error if the URL is not a valid template",
+			settings:          `{"url": "http://localhost/teams/{{ .CommonLabels.team }/alerts" }`,
+			expectedInitError: "url is not a valid template",
+		},
+		{
+			name:              "error if the URL is not absolute",
+			settings:          `{"url": "localhost/alerts" }`,
+			expectedInitError: "invalid url: must be an absolute http or https URL",
+		},
+		{
+			name:              "error if the URL is not http",
+			settings:          `{"url": "ftp://localhost/alerts" }`,
+			expectedInitError: "invalid url: must be an absolute http or https URL",
+		},
 	}
 
 	for _, c := range cases {
@@ -276,3 +419,16 @@ func TestNewConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestNewConfig_FIPSMode(t *testing.T) {
+	prev := receivers.FIPSMode()
+	receivers.SetFIPSMode(true)
+	t.Cleanup(func() { receivers.SetFIPSMode(prev) })
+
+	_, err := NewConfig(json.RawMessage(`{"url": "http://localhost", "hmacConfig": {"secret": "test-secret"} }`), receiversTesting.DecryptForTesting(nil))
+	require.EqualError(t, err, "invalid value for hmacConfig.secret: HMAC keys shorter than 112 bits are not approved in FIPS mode")
+	_, err = NewConfig(json.RawMessage(`{"url": "http://localhost", "tlsConfig": {"minVersion": "TLS10"} }`), receiversTesting.DecryptForTesting(nil))
+	require.ErrorIs(t, err, receivers.ErrNotFIPSApproved)
+	_, err = NewConfig(json.RawMessage(`{"url": "http://localhost", "hmacConfig": {"secret": "a-long-enough-secret"} }`), receiversTesting.DecryptForTesting(nil))
+	require.NoError(t, err)
+}
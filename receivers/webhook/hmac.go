@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"strconv"
+	"time"
+)
+
+// hmacSigner computes the HMAC-SHA256 signature of a request body as it is written.
+type hmacSigner struct {
+	cfg       *HMACConfig
+	timestamp string
+	mac       hash.Hash
+}
+
+func newHMACSigner(cfg *HMACConfig, now time.Time) *hmacSigner {
+	s := &hmacSigner{cfg: cfg, mac: hmac.New(sha256.New, []byte(cfg.Secret))}
+	if cfg.TimestampHeader != "" {
+		s.timestamp = strconv.FormatInt(now.Unix(), 10)
+		s.mac.Write([]byte(s.timestamp + ":"))
+	}
+	return s
+}
+
+func (s *hmacSigner) Write(p []byte) (int, error) {
+	return s.mac.Write(p)
+}
+
+// signature returns the hex-encoded signature of the body written so far.
+func (s *hmacSigner) signature() string {
+	return hex.EncodeToString(s.mac.Sum(nil))
+}
+
+// setHeaders sets the headers of the signature and the timestamp.
+func (s *hmacSigner) setHeaders(headers map[string]string) {
+	headers[s.cfg.Header] = s.signature()
+	if s.cfg.TimestampHeader != "" {
+		headers[s.cfg.TimestampHeader] = s.timestamp
+	}
+}
@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+// ndjsonLine is a line of a notification in the NDJSON format. Each line has one alert of the notification and
+// the fields of the group, so that consumers can process lines independently. The message is left out, as it is
+// usually built from all alerts of the group and would be repeated on every line.
+type ndjsonLine struct {
+	Version         string                  `json:"version"`
+	GroupKey        string                  `json:"groupKey"`
+	TruncatedAlerts int                     `json:"truncatedAlerts"`
+	OrgID           int64                   `json:"orgId"`
+	Receiver        string                  `json:"receiver"`
+	Status          string                  `json:"status"`
+	State           string                  `json:"state"`
+	Title           string                  `json:"title"`
+	GroupLabels     templates.KV            `json:"groupLabels"`
+	ExternalURL     string                  `json:"externalURL"`
+	Alert           templates.ExtendedAlert `json:"alert"`
+
+	ConfigGeneration *receivers.ConfigGeneration `json:"configGeneration,omitempty"`
+	Sequence         uint64                      `json:"sequence,omitempty"`
+}
+
+// sendNDJSON streams the alerts of the message as newline-delimited JSON. The body is written while it is sent,
+// with chunked transfer encoding, so that the whole notification is never encoded in memory. If requests are
+// signed, the signature is sent in a trailer.
+func (wn *Notifier) sendNDJSON(ctx context.Context, url string, headers map[string]string, tlsConfig *tls.Config, msg *webhookMessage) error {
+	l := logging.FromContext(ctx, wn.log)
+	pr, pw := io.Pipe()
+	// Unblocks the writer if the request fails before the body is read.
+	defer pr.Close()
+
+	req, err := http.NewRequestWithContext(ctx, wn.settings.HTTPMethod, url, pr)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("User-Agent", "Grafana")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if wn.settings.User != "" && wn.settings.Password != "" {
+		req.SetBasicAuth(wn.settings.User, wn.settings.Password)
+	}
+
+	var w io.Writer = pw
+	var signer *hmacSigner
+	if wn.settings.HMACConfig != nil {
+		// The signature is known only once the body is written, so it is sent in a trailer.
+		signer = newHMACSigner(wn.settings.HMACConfig, time.Now())
+		if signer.cfg.TimestampHeader != "" {
+			req.Header.Set(signer.cfg.TimestampHeader, signer.timestamp)
+		}
+		req.Trailer = http.Header{http.CanonicalHeaderKey(signer.cfg.Header): nil}
+		w = io.MultiWriter(pw, signer)
+	}
+
+	go func() {
+		enc := json.NewEncoder(w)
+		for _, a := range msg.Alerts {
+			line := ndjsonLine{
+				Version:         msg.Version,
+				GroupKey:        msg.GroupKey,
+				TruncatedAlerts: msg.TruncatedAlerts,
+				OrgID:           msg.OrgID,
+				Receiver:        msg.Receiver,
+				Status:          msg.Status,
+				State:           msg.State,
+				Title:           msg.Title,
+				GroupLabels:     msg.GroupLabels,
+				ExternalURL:     msg.ExternalURL,
+				Alert:           a,
+
+				ConfigGeneration: msg.ConfigGeneration,
+				Sequence:         msg.Sequence,
+			}
+			// Encode writes a newline after each value.
+			if err := enc.Encode(line); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		if signer != nil {
+			// Trailers are read by the transport after the end of the body.
+			req.Trailer.Set(signer.cfg.Header, signer.signature())
+		}
+		pw.Close()
+	}()
+
+	resp, err := receivers.NewTLSClient(tlsConfig).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			l.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := receivers.ReadResponseBody(resp, 1024)
+		l.Warn("Webhook failed", "url", url, "statusCode", resp.Status, "body", string(body))
+		return notify.NewErrorWithReason(notify.GetFailureReasonFromStatusCode(resp.StatusCode), fmt.Errorf("webhook response status %v", resp.Status))
+	}
+	l.Debug("Webhook succeeded", "url", url, "statusCode", resp.Status)
+	return nil
+}
@@ -0,0 +1,11 @@
+
+1	alertnamemy_receiver"firing*alerting2[FIRING:1] alert1 :custom messageB
+firing
+	alertnamealert1
+lbl1val1
+ann1annv1"ȱ:fac0861a85de433aBihttp://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1B
+resolved
+	alertnamealert1
+lbl1val2"ȱ*ȱ:fab6861a85d5eeb5Bihttp://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval2HPZ
+	alertnamealert1b
+	alertnamealert1rhttp://localhost
\ No newline at end of file
@@ -3,7 +3,7 @@ package webhook
 // FullValidConfigForTesting is a string representation of a JSON object that contains all fields supported by the notifier Config. It can be used without secrets.
 const FullValidConfigForTesting = `{
 	"url": "http://localhost",
-	"httpMethod": "test-httpMethod",
+	"httpMethod": "PUT",
 	"maxAlerts": "2",
 	"authorization_scheme": "basic",
 	"authorization_credentials": "",
@@ -16,6 +16,16 @@ const FullValidConfigForTesting = `{
 		"clientCertificate": "test-client-certificate",
 		"clientKey": "test-client-key",
 		"caCertificate": "test-ca-certificate"
+	},
+	"hmacConfig": {
+		"secret": "test-hmac-secret",
+		"header": "X-Test-Signature",
+		"timestampHeader": "X-Test-Timestamp"
+	},
+	"format": "ndjson",
+	"encoding": "json",
+	"headers": {
+		"X-Tenant": "{{ .CommonLabels.tenant }}"
 	}
 }`
 
@@ -25,5 +35,6 @@ const FullValidSecretsForTesting = `{
 	"password": "test-secret-pass",
 	"clientCertificate": "test-client-certificate",
 	"clientKey": "test-client-key",
-	"caCertificate": "test-ca-certificate"
+	"caCertificate": "test-ca-certificate",
+	"hmacConfig.secret": "test-secret-hmac-secret"
 }`
@@ -2,9 +2,9 @@ package webhook
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/alerting/images"
 	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/alertpb"
 	"github.com/grafana/alerting/templates"
 )
 
@@ -54,10 +55,16 @@ type webhookMessage struct {
 	Title           string `json:"title"`
 	State           string `json:"state"`
 	Message         string `json:"message"`
+	// ConfigGeneration identifies the configuration of the Alertmanager that sent the notification, if it is known.
+	ConfigGeneration *receivers.ConfigGeneration `json:"configGeneration,omitempty"`
+	// Sequence is the sequence number of the notification in its alert group, if the notifications of the group are
+	// ordered. Notifications with a lower sequence number than one already received can be discarded.
+	Sequence uint64 `json:"sequence,omitempty"`
 }
 
 // Notify implements the Notifier interface.
 func (wn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, wn.log)
 	groupKey, err := notify.ExtractGroupKey(ctx)
 	if err != nil {
 		return false, err
@@ -65,10 +72,10 @@ func (wn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 
 	as, numTruncated := truncateAlerts(wn.settings.MaxAlerts, as)
 	var tmplErr error
-	tmpl, data := templates.TmplText(ctx, wn.tmpl, as, wn.log, &tmplErr)
+	tmpl, data := templates.TmplText(ctx, wn.tmpl, as, l, &tmplErr)
 
 	// Augment our Alert data with ImageURLs if available.
-	_ = images.WithStoredImages(ctx, wn.log, wn.images,
+	_ = images.WithStoredImages(ctx, l, wn.images,
 		func(index int, image images.Image) error {
 			if len(image.URL) != 0 {
 				data.Alerts[index].ImageURL = image.URL
@@ -86,6 +93,12 @@ func (wn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 		Title:           tmpl(wn.settings.Title),
 		Message:         tmpl(wn.settings.Message),
 	}
+	if g, ok := receivers.ConfigGenerationFromContext(ctx); ok {
+		msg.ConfigGeneration = &g
+	}
+	if seq, ok := receivers.NotificationSequenceFromContext(ctx); ok {
+		msg.Sequence = seq
+	}
 	if types.Alerts(as...).Status() == model.AlertFiring {
 		msg.State = string(receivers.AlertStateAlerting)
 	} else {
@@ -93,16 +106,14 @@ func (wn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 	}
 
 	if tmplErr != nil {
-		wn.log.Warn("failed to template webhook message", "error", tmplErr.Error())
+		l.Warn("failed to template webhook message", "error", tmplErr.Error())
 		tmplErr = nil
 	}
 
-	body, err := json.Marshal(msg)
-	if err != nil {
-		return false, err
+	headers := make(map[string]string, len(wn.settings.Headers))
+	for name, value := range wn.settings.Headers {
+		headers[name] = tmpl(value)
 	}
-
-	headers := make(map[string]string)
 	if wn.settings.AuthorizationScheme != "" && wn.settings.AuthorizationCredentials != "" {
 		headers["Authorization"] = fmt.Sprintf("%s %s", wn.settings.AuthorizationScheme, wn.settings.AuthorizationCredentials)
 	}
@@ -111,22 +122,45 @@ func (wn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 	if tmplErr != nil {
 		return false, tmplErr
 	}
+	if err := validateURL(parsedURL); err != nil {
+		return false, err
+	}
 
-	var tlsConfig *tls.Config
-	if wn.settings.TLSConfig != nil {
-		if tlsConfig, err = wn.settings.TLSConfig.ToCryptoTLSConfig(); err != nil {
+	tlsConfig, err := receivers.ClientTLSConfig(wn.settings.TLSConfig)
+	if err != nil {
+		return false, err
+	}
+
+	if wn.settings.Format == FormatNDJSON {
+		if err := wn.sendNDJSON(ctx, parsedURL, headers, tlsConfig, msg); err != nil {
 			return false, err
 		}
+		return true, nil
+	}
+
+	var body []byte
+	var contentType string
+	if wn.settings.Encoding == EncodingProtobuf {
+		body, contentType = msg.marshalProtobuf(), alertpb.ContentType
+	} else if body, err = json.Marshal(msg); err != nil {
+		return false, err
+	}
+
+	if wn.settings.HMACConfig != nil {
+		signer := newHMACSigner(wn.settings.HMACConfig, time.Now())
+		_, _ = signer.Write(body)
+		signer.setHeaders(headers)
 	}
 
 	cmd := &receivers.SendWebhookSettings{
-		URL:        parsedURL,
-		User:       wn.settings.User,
-		Password:   wn.settings.Password,
-		Body:       string(body),
-		HTTPMethod: wn.settings.HTTPMethod,
-		HTTPHeader: headers,
-		TLSConfig:  tlsConfig,
+		URL:         parsedURL,
+		User:        wn.settings.User,
+		Password:    wn.settings.Password,
+		Body:        string(body),
+		HTTPMethod:  wn.settings.HTTPMethod,
+		HTTPHeader:  headers,
+		ContentType: contentType,
+		TLSConfig:   tlsConfig,
 	}
 
 	if err := wn.ns.SendWebhook(ctx, cmd); err != nil {
@@ -136,6 +170,24 @@ func (wn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error
 	return true, nil
 }
 
+// marshalProtobuf returns the message encoded as an alertpb.AlertGroup.
+func (m *webhookMessage) marshalProtobuf() []byte {
+	g := alertpb.NewAlertGroup(m.ExtendedData)
+	g.Version = m.Version
+	g.GroupKey = m.GroupKey
+	g.State = m.State
+	g.Title = m.Title
+	g.Message = m.Message
+	g.TruncatedAlerts = m.TruncatedAlerts
+	g.OrgID = m.OrgID
+	if m.ConfigGeneration != nil {
+		g.ConfigGeneration = m.ConfigGeneration.Generation
+		g.ConfigVersion = m.ConfigGeneration.Version
+	}
+	g.Sequence = m.Sequence
+	return g.Marshal()
+}
+
 func truncateAlerts(maxAlerts int, alerts []*types.Alert) ([]*types.Alert, int) {
 	if maxAlerts > 0 && len(alerts) > maxAlerts {
 		return alerts[:maxAlerts], len(alerts) - maxAlerts
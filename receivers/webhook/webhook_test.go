@@ -1,13 +1,22 @@
 package webhook
 
 import (
+	"bufio"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
@@ -17,9 +26,12 @@ import (
 	"github.com/grafana/alerting/images"
 	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/alertpb"
 	"github.com/grafana/alerting/templates"
 )
 
+var update = flag.Bool("update", false, "update the golden files of the tests")
+
 //go:embed fixtures/ca.pem
 var caCert string
 
@@ -579,3 +591,387 @@ func TestNotify(t *testing.T) {
 		})
 	}
 }
+
+func TestNotify_NDJSON(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	var (
+		lines            []map[string]interface{}
+		contentType      string
+		transferEncoding []string
+		user, password   string
+	)
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		transferEncoding = r.TransferEncoding
+		user, password, _ = r.BasicAuth()
+		lines = nil
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			lines = append(lines, line)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	pn := &Notifier{
+		Base:   &receivers.Base{},
+		log:    &logging.FakeLogger{},
+		ns:     receivers.MockNotificationService(),
+		tmpl:   tmpl,
+		images: &images.UnavailableProvider{},
+		orgID:  1,
+		settings: Config{
+			URL:        srv.URL,
+			HTTPMethod: http.MethodPost,
+			MaxAlerts:  2,
+			User:       "user",
+			Password:   "password",
+			Title:      templates.DefaultMessageTitleEmbed,
+			Message:    templates.DefaultMessageEmbed,
+			Format:     FormatNDJSON,
+		},
+	}
+
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert2"}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert3"}}},
+	}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	ctx = notify.WithReceiverName(ctx, "my_receiver")
+
+	ok, err := pn.Notify(ctx, alerts...)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Equal(t, "application/x-ndjson", contentType)
+	require.Equal(t, []string{"chunked"}, transferEncoding)
+	require.Equal(t, "user", user)
+	require.Equal(t, "password", password)
+	require.Len(t, lines, 2)
+	for i, line := range lines {
+		require.Equal(t, "1", line["version"])
+		require.Equal(t, "alertname", line["groupKey"])
+		require.Equal(t, float64(1), line["truncatedAlerts"])
+		require.Equal(t, "my_receiver", line["receiver"])
+		require.Equal(t, "alerting", line["state"])
+		require.NotContains(t, line, "message")
+		alert := line["alert"].(map[string]interface{})
+		require.Equal(t, fmt.Sprintf("alert%d", i+1), alert["labels"].(map[string]interface{})["alertname"])
+	}
+
+	t.Run("returns error for unsuccessful status codes", func(t *testing.T) {
+		status = http.StatusBadGateway
+		ok, err := pn.Notify(ctx, alerts...)
+		require.False(t, ok)
+		require.EqualError(t, err, "webhook response status 502 Bad Gateway")
+	})
+}
+
+func TestNotify_HMAC(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	sign := func(secret, data string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(data))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+	ctx = notify.WithReceiverName(ctx, "my_receiver")
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+
+	newNotifier := func(hmacConfig *HMACConfig, format string, u string) (*Notifier, *receivers.NotificationServiceMock) {
+		sender := receivers.MockNotificationService()
+		return &Notifier{
+			Base:   &receivers.Base{},
+			log:    &logging.FakeLogger{},
+			ns:     sender,
+			tmpl:   tmpl,
+			images: &images.UnavailableProvider{},
+			orgID:  1,
+			settings: Config{
+				URL:        u,
+				HTTPMethod: http.MethodPost,
+				Title:      templates.DefaultMessageTitleEmbed,
+				Message:    templates.DefaultMessageEmbed,
+				HMACConfig: hmacConfig,
+				Format:     format,
+			},
+		}, sender
+	}
+
+	t.Run("signs the body", func(t *testing.T) {
+		n, sender := newNotifier(&HMACConfig{Secret: "secret", Header: DefaultHMACHeader}, "", "http://localhost")
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, map[string]string{
+			DefaultHMACHeader: sign("secret", sender.Webhook.Body),
+		}, sender.Webhook.HTTPHeader)
+	})
+
+	t.Run("signs the body with the timestamp", func(t *testing.T) {
+		n, sender := newNotifier(&HMACConfig{Secret: "secret", Header: "X-Signature", TimestampHeader: "X-Timestamp"}, "", "http://localhost")
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		ts := sender.Webhook.HTTPHeader["X-Timestamp"]
+		require.NotEmpty(t, ts)
+		require.Equal(t, sign("secret", ts+":"+sender.Webhook.Body), sender.Webhook.HTTPHeader["X-Signature"])
+	})
+
+	t.Run("signs NDJSON bodies in a trailer", func(t *testing.T) {
+		var body []byte
+		var ts, signature string
+		srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			ts = r.Header.Get("X-Timestamp")
+			body, _ = io.ReadAll(r.Body)
+			signature = r.Trailer.Get("X-Signature")
+		}))
+		t.Cleanup(srv.Close)
+
+		n, _ := newNotifier(&HMACConfig{Secret: "secret", Header: "X-Signature", TimestampHeader: "X-Timestamp"}, FormatNDJSON, srv.URL)
+		ok, err := n.Notify(ctx, alerts...)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.NotEmpty(t, ts)
+		require.NotEmpty(t, body)
+		require.Equal(t, sign("secret", ts+":"+string(body)), signature)
+	})
+}
+
+func TestNotify_Protobuf(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "alert1"})
+	ctx = notify.WithReceiverName(ctx, "my_receiver")
+	startsAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	alerts := []*types.Alert{
+		{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+				Annotations: model.LabelSet{"ann1": "annv1"},
+				StartsAt:    startsAt,
+			},
+		}, {
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "val2"},
+				StartsAt: startsAt,
+				EndsAt:   startsAt.Add(time.Hour),
+			},
+		}, {
+			Alert: model.Alert{
+				Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "val3"},
+				StartsAt: startsAt,
+			},
+		},
+	}
+
+	sender := receivers.MockNotificationService()
+	n := &Notifier{
+		Base:   &receivers.Base{},
+		log:    &logging.FakeLogger{},
+		ns:     sender,
+		tmpl:   tmpl,
+		images: &images.UnavailableProvider{},
+		orgID:  1,
+		settings: Config{
+			URL:        "http://localhost/webhook",
+			HTTPMethod: http.MethodPost,
+			MaxAlerts:  2,
+			Title:      templates.DefaultMessageTitleEmbed,
+			Message:    "custom message",
+			HMACConfig: &HMACConfig{Secret: "secret", Header: DefaultHMACHeader},
+			Encoding:   EncodingProtobuf,
+		},
+	}
+	ok, err := n.Notify(ctx, alerts...)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Equal(t, alertpb.ContentType, sender.Webhook.ContentType)
+	if *update {
+		require.NoError(t, os.WriteFile("testdata/protobuf.pb", []byte(sender.Webhook.Body), 0o644))
+	}
+	expected, err := os.ReadFile("testdata/protobuf.pb")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), sender.Webhook.Body)
+
+	// The encoded body is signed.
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(sender.Webhook.Body))
+	require.Equal(t, hex.EncodeToString(mac.Sum(nil)), sender.Webhook.HTTPHeader[DefaultHMACHeader])
+}
+
+func TestNotify_ConfigGeneration(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	sender := receivers.MockNotificationService()
+	n := &Notifier{
+		Base:   &receivers.Base{},
+		log:    &logging.FakeLogger{},
+		ns:     sender,
+		tmpl:   tmpl,
+		images: &images.UnavailableProvider{},
+		settings: Config{
+			URL:        "http://localhost/webhook",
+			HTTPMethod: http.MethodPost,
+			Title:      templates.DefaultMessageTitleEmbed,
+			Message:    templates.DefaultMessageEmbed,
+		},
+	}
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+	_, err = n.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.NotContains(t, sender.Webhook.Body, "configGeneration")
+
+	ctx = receivers.WithConfigGeneration(ctx, receivers.ConfigGeneration{Generation: 3, Version: "v42"})
+	_, err = n.Notify(ctx, alert)
+	require.NoError(t, err)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(sender.Webhook.Body), &body))
+	require.Equal(t, map[string]interface{}{"generation": float64(3), "version": "v42"}, body["configGeneration"])
+}
+
+func TestNotify_Headers(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	sender := receivers.MockNotificationService()
+	n := &Notifier{
+		Base:   &receivers.Base{},
+		log:    &logging.FakeLogger{},
+		ns:     sender,
+		tmpl:   tmpl,
+		images: &images.UnavailableProvider{},
+		settings: Config{
+			URL:                      "http://localhost/webhook",
+			HTTPMethod:               http.MethodPost,
+			Title:                    templates.DefaultMessageTitleEmbed,
+			Message:                  templates.DefaultMessageEmbed,
+			AuthorizationScheme:      "Bearer",
+			AuthorizationCredentials: "token",
+			Headers: map[string]string{
+				"X-Scope-OrgID": "{{ .CommonLabels.tenant }}",
+				"X-Route":       "alerts-{{ .Status }}",
+				"Authorization": "ignored",
+			},
+		},
+	}
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "tenant": "team-a"}}}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+	ok, err := n.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, map[string]string{
+		"X-Scope-OrgID": "team-a",
+		"X-Route":       "alerts-firing",
+		"Authorization": "Bearer token",
+	}, sender.Webhook.HTTPHeader)
+
+	n.settings.Headers = map[string]string{"X-Scope-OrgID": "{{ .Invalid }"}
+	_, err = n.Notify(ctx, alert)
+	require.Error(t, err)
+}
+
+func TestNotify_Sequence(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	sender := receivers.MockNotificationService()
+	n := &Notifier{
+		Base:   &receivers.Base{},
+		log:    &logging.FakeLogger{},
+		ns:     sender,
+		tmpl:   tmpl,
+		images: &images.UnavailableProvider{},
+		settings: Config{
+			URL:        "http://localhost/webhook",
+			HTTPMethod: http.MethodPost,
+			Title:      templates.DefaultMessageTitleEmbed,
+			Message:    templates.DefaultMessageEmbed,
+		},
+	}
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+	_, err = n.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.NotContains(t, sender.Webhook.Body, "sequence")
+
+	ctx = receivers.WithNotificationSequence(ctx, 7)
+	_, err = n.Notify(ctx, alert)
+	require.NoError(t, err)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(sender.Webhook.Body), &body))
+	require.Equal(t, float64(7), body["sequence"])
+}
+
+func TestNotify_TemplatedURL(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	sender := receivers.MockNotificationService()
+	n := &Notifier{
+		Base:   &receivers.Base{},
+		log:    &logging.FakeLogger{},
+		ns:     sender,
+		tmpl:   tmpl,
+		images: &images.UnavailableProvider{},
+		settings: Config{
+			URL:        "http://localhost/teams/{{ .CommonLabels.team }}/alerts",
+			HTTPMethod: http.MethodPut,
+			Title:      templates.DefaultMessageTitleEmbed,
+			Message:    templates.DefaultMessageEmbed,
+		},
+	}
+	ctx := notify.WithGroupKey(context.Background(), "alertname")
+
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "team": "payments"}}}
+	ok, err := n.Notify(ctx, alert)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "http://localhost/teams/payments/alerts", sender.Webhook.URL)
+	require.Equal(t, http.MethodPut, sender.Webhook.HTTPMethod)
+
+	// The URL is validated after templating.
+	sender.Webhook = receivers.SendWebhookSettings{}
+	alert = &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "team": "team a"}}}
+	_, err = n.Notify(ctx, alert)
+	require.EqualError(t, err, "invalid url: must not contain whitespace")
+	require.Empty(t, sender.Webhook.URL)
+
+	n.settings.URL = "{{ .CommonLabels.team }}"
+	alert = &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1", "team": "payments"}}}
+	_, err = n.Notify(ctx, alert)
+	require.EqualError(t, err, "invalid url: must be an absolute http or https URL")
+}
@@ -26,16 +26,17 @@ func (mt MsgType) IsValid() bool {
 }
 
 type Config struct {
-	Channel     string  `json:"-" yaml:"-"`
-	EndpointURL string  `json:"endpointUrl,omitempty" yaml:"endpointUrl,omitempty"`
-	URL         string  `json:"url" yaml:"url"`
-	AgentID     string  `json:"agent_id,omitempty" yaml:"agent_id,omitempty"`
-	CorpID      string  `json:"corp_id,omitempty" yaml:"corp_id,omitempty"`
-	Secret      string  `json:"secret,omitempty" yaml:"secret,omitempty"`
-	MsgType     MsgType `json:"msgtype,omitempty" yaml:"msgtype,omitempty"`
-	Message     string  `json:"message,omitempty" yaml:"message,omitempty"`
-	Title       string  `json:"title,omitempty" yaml:"title,omitempty"`
-	ToUser      string  `json:"touser,omitempty" yaml:"touser,omitempty"`
+	Channel     string               `json:"-" yaml:"-"`
+	EndpointURL string               `json:"endpointUrl,omitempty" yaml:"endpointUrl,omitempty"`
+	URL         string               `json:"url" yaml:"url"`
+	AgentID     string               `json:"agent_id,omitempty" yaml:"agent_id,omitempty"`
+	CorpID      string               `json:"corp_id,omitempty" yaml:"corp_id,omitempty"`
+	Secret      string               `json:"secret,omitempty" yaml:"secret,omitempty"`
+	MsgType     MsgType              `json:"msgtype,omitempty" yaml:"msgtype,omitempty"`
+	Message     string               `json:"message,omitempty" yaml:"message,omitempty"`
+	Title       string               `json:"title,omitempty" yaml:"title,omitempty"`
+	ToUser      string               `json:"touser,omitempty" yaml:"touser,omitempty"`
+	TLSConfig   *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
 }
 
 func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
@@ -83,5 +84,9 @@ func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Confi
 		}
 	}
 
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return settings, err
+	}
 	return settings, nil
 }
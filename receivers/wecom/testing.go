@@ -10,7 +10,13 @@ const FullValidConfigForTesting = `{
 	"msgtype" : "markdown",
 	"message" : "test-message",
 	"title" : "test-title",
-	"touser" : "test-touser"
+	"touser" : "test-touser",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
 }`
 
 // FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
@@ -7,14 +7,19 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/types"
 	"golang.org/x/sync/singleflight"
 
 	"github.com/grafana/alerting/logging"
 	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/receivers/chatcard"
 	"github.com/grafana/alerting/templates"
 )
 
+// accessTokenShare is the share of the time left to the notification that fetching the access token can use.
+const accessTokenShare = 0.5
+
 // Notifier is responsible for sending alert notifications to WeCom.
 type Notifier struct {
 	*receivers.Base
@@ -39,23 +44,20 @@ func New(cfg Config, meta receivers.Metadata, template *templates.Template, send
 
 // Notify send an alert notification to WeCom.
 func (w *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
-	w.log.Info("executing WeCom notification", "notification", w.Name)
+	l := logging.FromContext(ctx, w.log)
+	l.Info("executing WeCom notification")
 
 	var tmplErr error
-	tmpl, _ := templates.TmplText(ctx, w.tmpl, as, w.log, &tmplErr)
+	tmpl, _ := templates.TmplText(ctx, w.tmpl, as, l, &tmplErr)
 
 	bodyMsg := map[string]interface{}{
 		"msgtype": w.settings.MsgType,
 	}
-	content := fmt.Sprintf("# %s\n%s\n",
-		tmpl(w.settings.Title),
-		tmpl(w.settings.Message),
-	)
+	title := tmpl(w.settings.Title)
+	card := chatcard.New(title, tmpl(w.settings.Message), "", as...)
+	content := card.Markdown()
 	if w.settings.MsgType != DefaultsgType {
-		content = fmt.Sprintf("%s\n%s\n",
-			tmpl(w.settings.Title),
-			tmpl(w.settings.Message),
-		)
+		content = card.PlainText()
 	}
 
 	msgType := string(w.settings.MsgType)
@@ -67,7 +69,10 @@ func (w *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 	if w.settings.Channel != DefaultChannelType {
 		bodyMsg["agentid"] = w.settings.AgentID
 		bodyMsg["touser"] = w.settings.ToUser
-		token, err := w.GetAccessToken(ctx)
+		// The token is fetched with part of the time left, so that the message can still be sent after a slow fetch.
+		tokenCtx, cancel := receivers.StepContext(ctx, accessTokenShare)
+		token, err := w.GetAccessToken(tokenCtx)
+		cancel()
 		if err != nil {
 			return false, err
 		}
@@ -80,16 +85,21 @@ func (w *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error)
 	}
 
 	if tmplErr != nil {
-		w.log.Warn("failed to template WeCom message", "error", tmplErr.Error())
+		l.Warn("failed to template WeCom message", "error", tmplErr.Error())
 	}
 
+	tlsConfig, err := receivers.ClientTLSConfig(w.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
 	cmd := &receivers.SendWebhookSettings{
-		URL:  url,
-		Body: string(body),
+		URL:       url,
+		Body:      string(body),
+		TLSConfig: tlsConfig,
 	}
 
 	if err = w.ns.SendWebhook(ctx, cmd); err != nil {
-		w.log.Error("failed to send WeCom webhook", "error", err, "notification", w.Name)
+		l.Error("failed to send WeCom webhook", "error", err)
 		return false, err
 	}
 
@@ -133,13 +143,21 @@ func (w *Notifier) getAccessToken(ctx context.Context) (*accessToken, error) {
 	request.Header.Add("Content-Type", "application/json")
 	request.Header.Add("User-Agent", "Grafana")
 
-	resp, err := http.DefaultClient.Do(request)
+	client := http.DefaultClient
+	tlsConfig, err := receivers.ClientTLSConfig(w.settings.TLSConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	if tlsConfig != nil {
+		client = receivers.NewTLSClient(tlsConfig)
+	}
+	resp, err := client.Do(request)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode/100 != 2 {
-		return nil, fmt.Errorf("WeCom returned statuscode invalid status code: %v", resp.Status)
+		return nil, notify.NewErrorWithReason(notify.GetFailureReasonFromStatusCode(resp.StatusCode), fmt.Errorf("WeCom returned statuscode invalid status code: %v", resp.Status))
 	}
 	defer func() {
 		_ = resp.Body.Close()
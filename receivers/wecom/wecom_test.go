@@ -518,3 +518,33 @@ func TestGetAccessToken(t *testing.T) {
 		})
 	}
 }
+
+func TestNotify_ApiAppAccessTokenDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		// The token is never returned, the request ends when its context is done.
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	pn := &Notifier{
+		Base:     &receivers.Base{},
+		log:      &logging.FakeLogger{},
+		ns:       receivers.MockNotificationService(),
+		tmpl:     tmpl,
+		settings: Config{Channel: "apiapp", EndpointURL: server.URL, CorpID: "corpid", Secret: "secret"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	_, err = pn.Notify(ctx, &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	// The fetch of the token used half of the time left, leaving time to send the message.
+	require.True(t, time.Now().Before(deadline.Add(-250*time.Millisecond)))
+}
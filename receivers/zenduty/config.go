@@ -0,0 +1,56 @@
+package zenduty
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+const (
+	DefaultAlertType = "critical"
+	DefaultURL       = "https://www.zenduty.com/api/events/"
+)
+
+type Config struct {
+	IntegrationKey string `json:"integrationKey,omitempty" yaml:"integrationKey,omitempty"`
+	// URL is the URL of the events API, to which the integration key is appended.
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// AlertType is the template of the type of the alerts of firing notifications, critical, error, warning or info.
+	// Resolved notifications have the type resolved.
+	AlertType string               `json:"alertType,omitempty" yaml:"alertType,omitempty"`
+	Message   string               `json:"message,omitempty" yaml:"message,omitempty"`
+	Summary   string               `json:"summary,omitempty" yaml:"summary,omitempty"`
+	TLSConfig *receivers.TLSConfig `json:"tlsConfig,omitempty" yaml:"tlsConfig,omitempty"`
+}
+
+func NewConfig(jsonData json.RawMessage, decryptFn receivers.DecryptFunc) (Config, error) {
+	settings := Config{}
+	err := json.Unmarshal(jsonData, &settings)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	settings.IntegrationKey = decryptFn("integrationKey", settings.IntegrationKey)
+	if settings.IntegrationKey == "" {
+		return Config{}, errors.New("could not find integration key property in settings")
+	}
+	if settings.URL == "" {
+		settings.URL = DefaultURL
+	}
+	if settings.AlertType == "" {
+		settings.AlertType = DefaultAlertType
+	}
+	if settings.Message == "" {
+		settings.Message = templates.DefaultMessageTitleEmbed
+	}
+	if settings.Summary == "" {
+		settings.Summary = templates.DefaultMessageEmbed
+	}
+	settings.TLSConfig, err = receivers.ParseTLSConfig(settings.TLSConfig, decryptFn)
+	if err != nil {
+		return Config{}, err
+	}
+	return settings, nil
+}
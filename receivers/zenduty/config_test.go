@@ -0,0 +1,86 @@
+package zenduty
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/receivers"
+	receiversTesting "github.com/grafana/alerting/receivers/testing"
+	"github.com/grafana/alerting/templates"
+)
+
+func TestNewConfig(t *testing.T) {
+	cases := []struct {
+		name              string
+		settings          string
+		secrets           map[string][]byte
+		expectedConfig    Config
+		expectedInitError string
+	}{
+		{
+			name:              "Error if empty",
+			settings:          "",
+			expectedInitError: `failed to unmarshal settings`,
+		},
+		{
+			name:              "Error if integration key is missing",
+			settings:          `{}`,
+			expectedInitError: `could not find integration key property in settings`,
+		},
+		{
+			name:     "Minimal valid configuration",
+			settings: `{"integrationKey": "test-key"}`,
+			expectedConfig: Config{
+				IntegrationKey: "test-key",
+				URL:            DefaultURL,
+				AlertType:      DefaultAlertType,
+				Message:        templates.DefaultMessageTitleEmbed,
+				Summary:        templates.DefaultMessageEmbed,
+			},
+		},
+		{
+			name:     "Minimal valid configuration from secrets",
+			settings: `{}`,
+			secrets:  receiversTesting.ReadSecretsJSONForTesting(FullValidSecretsForTesting),
+			expectedConfig: Config{
+				IntegrationKey: "test-secret-integration-key",
+				URL:            DefaultURL,
+				AlertType:      DefaultAlertType,
+				Message:        templates.DefaultMessageTitleEmbed,
+				Summary:        templates.DefaultMessageEmbed,
+			},
+		},
+		{
+			name:     "Extracts all fields",
+			settings: FullValidConfigForTesting,
+			expectedConfig: Config{
+				IntegrationKey: "test-integration-key",
+				URL:            "http://localhost/api/events",
+				AlertType:      "warning",
+				Message:        "test-message",
+				Summary:        "test-summary",
+				TLSConfig: &receivers.TLSConfig{
+					CACertificate:     "test-ca-certificate",
+					ClientCertificate: "test-client-certificate",
+					ClientKey:         "test-client-key",
+					MinVersion:        "TLS12",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := NewConfig(json.RawMessage(c.settings), receiversTesting.DecryptForTesting(c.secrets))
+
+			if c.expectedInitError != "" {
+				require.ErrorContains(t, err, c.expectedInitError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expectedConfig, actual)
+		})
+	}
+}
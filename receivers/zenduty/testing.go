@@ -0,0 +1,21 @@
+package zenduty
+
+// FullValidConfigForTesting is a string representation of a JSON object that contains all fields supported by the notifier Config. It can be used without secrets.
+const FullValidConfigForTesting = `{
+	"integrationKey": "test-integration-key",
+	"url": "http://localhost/api/events",
+	"alertType": "warning",
+	"message": "test-message",
+	"summary": "test-summary",
+	"tlsConfig": {
+		"caCertificate": "test-ca-certificate",
+		"clientCertificate": "test-client-certificate",
+		"clientKey": "test-client-key",
+		"minVersion": "TLS12"
+	}
+}`
+
+// FullValidSecretsForTesting is a string representation of JSON object that contains all fields that can be overridden from secrets
+const FullValidSecretsForTesting = `{
+	"integrationKey": "test-secret-integration-key"
+}`
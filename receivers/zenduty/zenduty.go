@@ -0,0 +1,141 @@
+package zenduty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+const zendutyAlertTypeResolved = "resolved"
+
+var knownAlertTypes = map[string]struct{}{DefaultAlertType: {}, "error": {}, "warning": {}, "info": {}}
+
+// Notifier sends the notifications of alert groups to the events API of Zenduty. The events of an alert group have
+// the same entity ID, so that Zenduty groups them in one incident, which is resolved when the group is resolved.
+type Notifier struct {
+	*receivers.Base
+	tmpl     *templates.Template
+	log      logging.Logger
+	ns       receivers.WebhookSender
+	images   images.Provider
+	settings Config
+}
+
+func New(cfg Config, meta receivers.Metadata, template *templates.Template, sender receivers.WebhookSender, images images.Provider, logger logging.Logger) *Notifier {
+	return &Notifier{
+		Base:     receivers.NewBase(meta),
+		log:      logger,
+		ns:       sender,
+		images:   images,
+		tmpl:     template,
+		settings: cfg,
+	}
+}
+
+type zendutyLink struct {
+	LinkURL  string `json:"link_url"`
+	LinkText string `json:"link_text"`
+}
+
+type zendutyEvent struct {
+	AlertType string            `json:"alert_type"`
+	Message   string            `json:"message"`
+	Summary   string            `json:"summary"`
+	EntityID  string            `json:"entity_id"`
+	Payload   map[string]string `json:"payload,omitempty"`
+	URLs      []zendutyLink     `json:"urls,omitempty"`
+}
+
+// Notify sends an event to Zenduty.
+func (zn *Notifier) Notify(ctx context.Context, as ...*types.Alert) (bool, error) {
+	l := logging.FromContext(ctx, zn.log)
+	alerts := types.Alerts(as...)
+	if alerts.Status() == model.AlertResolved && !zn.SendResolved() {
+		return true, nil
+	}
+
+	key, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var tmplErr error
+	tmpl, data := templates.TmplText(ctx, zn.tmpl, as, l, &tmplErr)
+
+	alertType := zendutyAlertTypeResolved
+	if alerts.Status() == model.AlertFiring {
+		alertType = strings.ToLower(tmpl(zn.settings.AlertType))
+		if _, ok := knownAlertTypes[alertType]; !ok {
+			l.Warn("Alert type is not in the list of known values - using default alert type", "actualAlertType", alertType, "defaultAlertType", DefaultAlertType)
+			alertType = DefaultAlertType
+		}
+	}
+
+	event := zendutyEvent{
+		AlertType: alertType,
+		Message:   tmpl(zn.settings.Message),
+		Summary:   tmpl(zn.settings.Summary),
+		EntityID:  key.Hash(),
+		Payload: map[string]string{
+			"status":       data.Status,
+			"group_key":    string(key),
+			"num_firing":   fmt.Sprint(len(data.Alerts.Firing())),
+			"num_resolved": fmt.Sprint(len(data.Alerts.Resolved())),
+		},
+		URLs: []zendutyLink{{
+			LinkURL:  receivers.JoinURLPath(zn.tmpl.ExternalURL.String(), "/alerting/list", l),
+			LinkText: "Alert rules",
+		}},
+	}
+	for k, v := range data.CommonLabels {
+		event.Payload["label_"+k] = v
+	}
+	if tmplErr != nil {
+		l.Warn("failed to template Zenduty message", "error", tmplErr.Error())
+	}
+
+	_ = images.WithStoredImages(ctx, l, zn.images,
+		func(_ int, image images.Image) error {
+			if image.URL != "" {
+				event.URLs = append(event.URLs, zendutyLink{LinkURL: image.URL, LinkText: "Image"})
+			}
+			return nil
+		}, as...)
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode Zenduty event: %w", err)
+	}
+	tlsConfig, err := receivers.ClientTLSConfig(zn.settings.TLSConfig)
+	if err != nil {
+		return false, fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	l.Debug("notifying Zenduty", "alert_type", alertType)
+	cmd := &receivers.SendWebhookSettings{
+		URL:        strings.TrimSuffix(zn.settings.URL, "/") + "/" + zn.settings.IntegrationKey + "/",
+		Body:       string(body),
+		HTTPMethod: "POST",
+		HTTPHeader: map[string]string{
+			"Content-Type": "application/json",
+		},
+		TLSConfig: tlsConfig,
+	}
+	if err := zn.ns.SendWebhook(ctx, cmd); err != nil {
+		return false, fmt.Errorf("send notification to Zenduty: %w", err)
+	}
+	return true, nil
+}
+
+func (zn *Notifier) SendResolved() bool {
+	return !zn.GetDisableResolveMessage()
+}
@@ -0,0 +1,173 @@
+package zenduty
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/images"
+	"github.com/grafana/alerting/logging"
+	"github.com/grafana/alerting/receivers"
+	"github.com/grafana/alerting/templates"
+)
+
+func TestNotify(t *testing.T) {
+	tmpl := templates.ForTests(t)
+	externalURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+	tmpl.ExternalURL = externalURL
+
+	firing := &types.Alert{Alert: model.Alert{
+		Labels:      model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+		Annotations: model.LabelSet{"ann1": "annv1", "__alertImageToken__": "test-image-1"},
+	}}
+	resolved := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "alert1", "lbl1": "val1"},
+		StartsAt: time.Now().Add(-time.Hour),
+		EndsAt:   time.Now().Add(-time.Minute),
+	}}
+
+	cases := []struct {
+		name                  string
+		settings              Config
+		alerts                []*types.Alert
+		disableResolveMessage bool
+		expURL                string
+		expMsg                string
+		expNoRequest          bool
+	}{
+		{
+			name: "Firing alert with default settings",
+			settings: Config{
+				IntegrationKey: "abcd",
+				URL:            DefaultURL,
+				AlertType:      DefaultAlertType,
+				Message:        templates.DefaultMessageTitleEmbed,
+				Summary:        templates.DefaultMessageEmbed,
+			},
+			alerts: []*types.Alert{firing},
+			expURL: "https://www.zenduty.com/api/events/abcd/",
+			expMsg: `{
+				"alert_type": "critical",
+				"message": "[FIRING:1]  (val1)",
+				"summary": "**Firing**\n\nValue: [no value]\nLabels:\n - alertname = alert1\n - lbl1 = val1\nAnnotations:\n - ann1 = annv1\nSilence: http://localhost/alerting/silence/new?alertmanager=grafana&matcher=alertname%3Dalert1&matcher=lbl1%3Dval1\n",
+				"entity_id": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+				"payload": {
+					"status": "firing",
+					"group_key": "alertname",
+					"num_firing": "1",
+					"num_resolved": "0",
+					"label_alertname": "alert1",
+					"label_lbl1": "val1"
+				},
+				"urls": [
+					{"link_url": "http://localhost/alerting/list", "link_text": "Alert rules"},
+					{"link_url": "https://www.example.com/test-image-1.jpg", "link_text": "Image"}
+				]
+			}`,
+		},
+		{
+			name: "Templated alert type, unknown values use the default",
+			settings: Config{
+				IntegrationKey: "abcd",
+				URL:            "http://localhost/events",
+				AlertType:      "{{ .CommonLabels.lbl1 }}",
+				Message:        "msg",
+				Summary:        "summary",
+			},
+			alerts: []*types.Alert{firing},
+			expURL: "http://localhost/events/abcd/",
+			expMsg: `{
+				"alert_type": "critical",
+				"message": "msg",
+				"summary": "summary",
+				"entity_id": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+				"payload": {
+					"status": "firing",
+					"group_key": "alertname",
+					"num_firing": "1",
+					"num_resolved": "0",
+					"label_alertname": "alert1",
+					"label_lbl1": "val1"
+				},
+				"urls": [
+					{"link_url": "http://localhost/alerting/list", "link_text": "Alert rules"},
+					{"link_url": "https://www.example.com/test-image-1.jpg", "link_text": "Image"}
+				]
+			}`,
+		},
+		{
+			name: "Resolved alerts resolve the incident of the group",
+			settings: Config{
+				IntegrationKey: "abcd",
+				URL:            DefaultURL,
+				AlertType:      "warning",
+				Message:        "msg",
+				Summary:        "summary",
+			},
+			alerts: []*types.Alert{resolved},
+			expURL: "https://www.zenduty.com/api/events/abcd/",
+			expMsg: `{
+				"alert_type": "resolved",
+				"message": "msg",
+				"summary": "summary",
+				"entity_id": "6e3538104c14b583da237e9693b76debbc17f0f8058ef20492e5853096cf8733",
+				"payload": {
+					"status": "resolved",
+					"group_key": "alertname",
+					"num_firing": "0",
+					"num_resolved": "1",
+					"label_alertname": "alert1",
+					"label_lbl1": "val1"
+				},
+				"urls": [
+					{"link_url": "http://localhost/alerting/list", "link_text": "Alert rules"}
+				]
+			}`,
+		},
+		{
+			name: "Resolved alerts are not sent if resolve messages are disabled",
+			settings: Config{
+				IntegrationKey: "abcd",
+				URL:            DefaultURL,
+				AlertType:      DefaultAlertType,
+			},
+			alerts:                []*types.Alert{resolved},
+			disableResolveMessage: true,
+			expNoRequest:          true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			webhookSender := receivers.MockNotificationService()
+			zn := &Notifier{
+				Base:     &receivers.Base{DisableResolveMessage: c.disableResolveMessage},
+				log:      &logging.FakeLogger{},
+				ns:       webhookSender,
+				tmpl:     tmpl,
+				settings: c.settings,
+				images:   images.NewFakeProvider(1),
+			}
+
+			ctx := notify.WithGroupKey(context.Background(), "alertname")
+			ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": ""})
+			ok, err := zn.Notify(ctx, c.alerts...)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			if c.expNoRequest {
+				require.Empty(t, webhookSender.Webhook.URL)
+				return
+			}
+			require.Equal(t, c.expURL, webhookSender.Webhook.URL)
+			require.JSONEq(t, c.expMsg, webhookSender.Webhook.Body)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// truncatedSuffix is appended to the annotations that are truncated when rendered.
+const truncatedSuffix = "…"
+
+// AnnotationLimits configures how large annotations, such as embedded query results, are passed to templates.
+// Large annotations are not part of the Annotations and CommonAnnotations of the template data, which keeps them out
+// of functions such as SortedPairs and of the JSON payload of integrations. They can be referenced with the Annotation
+// function of the alert, {{ .Annotation "name" }}, which only materializes the value when it is called.
+type AnnotationLimits struct {
+	// LazySize is the size in bytes above which annotations are large. Zero means no annotation is large.
+	LazySize int
+	// MaxRenderedSize is the maximum size in bytes of large annotations returned by the Annotation function.
+	// Longer annotations are truncated. Zero means no limit.
+	MaxRenderedSize int
+	// Metrics, if set, counts the large annotations and their truncations.
+	Metrics *AnnotationMetrics
+}
+
+// Validate returns an error if any of the limits is negative.
+func (l AnnotationLimits) Validate() error {
+	if l.LazySize < 0 {
+		return errors.New("lazy size must not be negative")
+	}
+	if l.MaxRenderedSize < 0 {
+		return errors.New("max rendered size must not be negative")
+	}
+	return nil
+}
+
+// AnnotationMetrics are the metrics of large annotations.
+type AnnotationMetrics struct {
+	// Deferred is the number of large annotations that were left out of the template data.
+	Deferred prometheus.Counter
+	// Materialized is the number of large annotations that were referenced by templates.
+	Materialized prometheus.Counter
+	// Truncated is the number of large annotations that were truncated when referenced by templates.
+	Truncated prometheus.Counter
+}
+
+// NewAnnotationMetrics creates the metrics of large annotations.
+func NewAnnotationMetrics(r prometheus.Registerer) *AnnotationMetrics {
+	return &AnnotationMetrics{
+		Deferred: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "template_large_annotations_deferred_total",
+			Help:      "Number of large annotations that were left out of the template data.",
+		}),
+		Materialized: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "template_large_annotations_materialized_total",
+			Help:      "Number of large annotations that were referenced by templates.",
+		}),
+		Truncated: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "template_large_annotations_truncated_total",
+			Help:      "Number of large annotations that were truncated when referenced by templates.",
+		}),
+	}
+}
+
+type annotationLimitsKey struct{}
+
+// WithAnnotationLimits returns a context with the limits used by TmplText to build the template data.
+func WithAnnotationLimits(ctx context.Context, l AnnotationLimits) context.Context {
+	return context.WithValue(ctx, annotationLimitsKey{}, l)
+}
+
+// AnnotationLimitsFromContext returns the limits of the context, if it has them.
+func AnnotationLimitsFromContext(ctx context.Context) (AnnotationLimits, bool) {
+	l, ok := ctx.Value(annotationLimitsKey{}).(AnnotationLimits)
+	return l, ok
+}
+
+// largeAnnotations are the large annotations of an alert, or common to all alerts, that are materialized when referenced.
+type largeAnnotations struct {
+	values map[string]string
+	limits AnnotationLimits
+}
+
+// splitLargeAnnotations returns the annotations without the large ones, and the large annotations.
+// The annotations are not modified, and are returned as is if none of them is large.
+func splitLargeAnnotations(kv KV, limits AnnotationLimits) (KV, *largeAnnotations) {
+	if limits.LazySize <= 0 {
+		return kv, nil
+	}
+	var large *largeAnnotations
+	for k, v := range kv {
+		if len(v) <= limits.LazySize {
+			continue
+		}
+		if large == nil {
+			large = &largeAnnotations{values: make(map[string]string), limits: limits}
+		}
+		large.values[k] = v
+		if limits.Metrics != nil {
+			limits.Metrics.Deferred.Inc()
+		}
+	}
+	if large == nil {
+		return kv, nil
+	}
+	small := make(KV, len(kv)-len(large.values))
+	for k, v := range kv {
+		if _, ok := large.values[k]; !ok {
+			small[k] = v
+		}
+	}
+	return small, large
+}
+
+// get returns the large annotation, truncated to the maximum rendered size.
+func (a *largeAnnotations) get(name string) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+	v, ok := a.values[name]
+	if !ok {
+		return "", false
+	}
+	if a.limits.Metrics != nil {
+		a.limits.Metrics.Materialized.Inc()
+	}
+	if a.limits.MaxRenderedSize <= 0 || len(v) <= a.limits.MaxRenderedSize {
+		return v, true
+	}
+	if a.limits.Metrics != nil {
+		a.limits.Metrics.Truncated.Inc()
+	}
+	return truncateUTF8(v, a.limits.MaxRenderedSize) + truncatedSuffix, true
+}
+
+// truncateUTF8 truncates s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// Annotation returns the annotation of the alert with the name, including large annotations, or an empty string.
+func (a ExtendedAlert) Annotation(name string) string {
+	if v, ok := a.Annotations[name]; ok {
+		return v
+	}
+	v, _ := a.largeAnnotations.get(name)
+	return v
+}
+
+// CommonAnnotation returns the annotation common to all alerts with the name, including large annotations, or an empty string.
+func (d *ExtendedData) CommonAnnotation(name string) string {
+	if v, ok := d.CommonAnnotations[name]; ok {
+		return v
+	}
+	v, _ := d.largeCommonAnnotations.get(name)
+	return v
+}
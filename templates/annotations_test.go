@@ -0,0 +1,86 @@
+package templates
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/logging"
+)
+
+func TestTmplText_AnnotationLimits(t *testing.T) {
+	large := strings.Repeat("é", 20)
+	alerts := []*types.Alert{{
+		Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": "alert1"},
+			Annotations: model.LabelSet{"summary": "small", "query_result": model.LabelValue(large)},
+			StartsAt:    time.Now(),
+			EndsAt:      time.Now().Add(time.Hour),
+		},
+	}}
+	tmpl, err := FromContent([]string{
+		`{{ define "annotations" }}{{ range .Alerts }}{{ range .Annotations.SortedPairs }}{{ .Name }}={{ .Value }} {{ end }}{{ end }}{{ end }}`,
+		`{{ define "large" }}{{ range .Alerts }}{{ .Annotation "summary" }} {{ .Annotation "query_result" }}{{ end }}{{ end }}`,
+		`{{ define "common" }}{{ .CommonAnnotation "query_result" }}{{ end }}`,
+	})
+	require.NoError(t, err)
+	tmpl.ExternalURL, err = url.Parse("http://localhost/grafana")
+	require.NoError(t, err)
+
+	t.Run("without limits all annotations are in the template data", func(t *testing.T) {
+		var tmplErr error
+		expand, data := TmplText(context.Background(), tmpl, alerts, &logging.FakeLogger{}, &tmplErr)
+		require.Equal(t, "query_result="+large+" summary=small ", expand(`{{ template "annotations" . }}`))
+		require.Equal(t, "small "+large, expand(`{{ template "large" . }}`))
+		require.NoError(t, tmplErr)
+		require.Equal(t, KV{"summary": "small", "query_result": large}, data.Alerts[0].Annotations)
+	})
+
+	t.Run("large annotations are loaded when referenced and truncated", func(t *testing.T) {
+		metrics := NewAnnotationMetrics(prometheus.NewRegistry())
+		ctx := WithAnnotationLimits(context.Background(), AnnotationLimits{LazySize: 10, MaxRenderedSize: 5, Metrics: metrics})
+
+		var tmplErr error
+		expand, data := TmplText(ctx, tmpl, alerts, &logging.FakeLogger{}, &tmplErr)
+		require.Equal(t, KV{"summary": "small"}, data.Alerts[0].Annotations)
+		require.Equal(t, KV{"summary": "small"}, data.CommonAnnotations)
+		require.Equal(t, 2.0, testutil.ToFloat64(metrics.Deferred))
+
+		b, err := json.Marshal(data)
+		require.NoError(t, err)
+		require.NotContains(t, string(b), "query_result")
+
+		require.Equal(t, "summary=small ", expand(`{{ template "annotations" . }}`))
+		require.Equal(t, 0.0, testutil.ToFloat64(metrics.Materialized))
+
+		// The value is truncated without splitting the two bytes long runes.
+		require.Equal(t, "small éé…", expand(`{{ template "large" . }}`))
+		require.Equal(t, "éé…", expand(`{{ template "common" . }}`))
+		require.NoError(t, tmplErr)
+		require.Equal(t, 2.0, testutil.ToFloat64(metrics.Materialized))
+		require.Equal(t, 2.0, testutil.ToFloat64(metrics.Truncated))
+	})
+
+	t.Run("large annotations are not truncated without a max rendered size", func(t *testing.T) {
+		ctx := WithAnnotationLimits(context.Background(), AnnotationLimits{LazySize: 10})
+		var tmplErr error
+		expand, _ := TmplText(ctx, tmpl, alerts, &logging.FakeLogger{}, &tmplErr)
+		require.Equal(t, "small "+large, expand(`{{ template "large" . }}`))
+		require.NoError(t, tmplErr)
+	})
+}
+
+func TestAnnotationLimits_Validate(t *testing.T) {
+	require.NoError(t, AnnotationLimits{LazySize: 1024, MaxRenderedSize: 512}.Validate())
+	require.EqualError(t, AnnotationLimits{LazySize: -1}.Validate(), "lazy size must not be negative")
+	require.EqualError(t, AnnotationLimits{MaxRenderedSize: -1}.Validate(), "max rendered size must not be negative")
+}
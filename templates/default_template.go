@@ -9,6 +9,7 @@ import (
 const (
 	DefaultMessageTitleEmbed = `{{ template "default.title" . }}`
 	DefaultMessageEmbed      = `{{ template "default.message" . }}`
+	DefaultImageAltTextEmbed = `{{ template "default.image_alt_text" . }}`
 )
 
 var DefaultTemplateString = `
@@ -32,6 +33,8 @@ Labels:
 
 {{ define "default.title" }}{{ template "__subject" . }}{{ end }}
 
+{{ define "default.image_alt_text" }}{{ .Status | title }} alert {{ .CommonLabels.alertname }}{{ end }}
+
 {{ define "default.message" }}{{ if gt (len .Alerts.Firing) 0 }}**Firing**
 {{ template "__text_alert_list" .Alerts.Firing }}{{ if gt (len .Alerts.Resolved) 0 }}
 
@@ -90,6 +93,8 @@ Labels:
 
 {{ define "default.title" }}{{ template "__subject" . }}{{ end }}
 
+{{ define "default.image_alt_text" }}{{ .Status | title }} alert {{ .CommonLabels.alertname }}{{ end }}
+
 {{ define "default.message" }}{{ if gt (len .Alerts.Firing) 0 }}**Firing**
 {{ template "__text_alert_list" .Alerts.Firing }}{{ if gt (len .Alerts.Resolved) 0 }}
 
@@ -0,0 +1,292 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	tmpltext "text/template"
+	"text/template/parse"
+	"time"
+)
+
+// Limits of templates, see TemplateLimitError.
+const (
+	LimitTimeout    = "timeout"
+	LimitOutputSize = "output_size"
+)
+
+// ExecutionLimits limit the execution of templates by TmplText, so that a template with a runaway range or
+// recursion cannot block a notification indefinitely.
+type ExecutionLimits struct {
+	// Timeout is for how long a template can run. Zero means no limit.
+	Timeout time.Duration
+	// MaxOutputSize is the maximum size in bytes of the output of a template. Zero means no limit.
+	MaxOutputSize int
+}
+
+// Validate returns an error if any of the limits is negative.
+func (l ExecutionLimits) Validate() error {
+	if l.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	if l.MaxOutputSize < 0 {
+		return errors.New("max output size must not be negative")
+	}
+	return nil
+}
+
+// TemplateLimitError is returned when the execution of a template exceeds one of its limits. Executions are aborted
+// at the next iteration of a range, call of a template or write of output after exceeding a limit, so they are not
+// aborted while blocked on a function or a receive from a channel.
+type TemplateLimitError struct {
+	// Limit is the limit that was exceeded, LimitTimeout or LimitOutputSize.
+	Limit  string
+	Limits ExecutionLimits
+}
+
+func (e *TemplateLimitError) Error() string {
+	switch e.Limit {
+	case LimitTimeout:
+		return fmt.Sprintf("template execution exceeded the timeout of %s", e.Limits.Timeout)
+	case LimitOutputSize:
+		return fmt.Sprintf("template output exceeded the maximum size of %d bytes", e.Limits.MaxOutputSize)
+	default:
+		return fmt.Sprintf("template exceeded the %s limit", e.Limit)
+	}
+}
+
+type executionLimitsKey struct{}
+
+// WithExecutionLimits returns a context with the limits of the templates executed by TmplText.
+func WithExecutionLimits(ctx context.Context, l ExecutionLimits) context.Context {
+	return context.WithValue(ctx, executionLimitsKey{}, l)
+}
+
+// ExecutionLimitsFromContext returns the limits of the context, if it has them.
+func ExecutionLimitsFromContext(ctx context.Context) (ExecutionLimits, bool) {
+	l, ok := ctx.Value(executionLimitsKey{}).(ExecutionLimits)
+	return l, ok
+}
+
+// ExecuteTextString is like the ExecuteTextString method of tmpl, with the execution limits of the context.
+// The execution is aborted if the context is done. The limits are checked while the templates run only for templates
+// made by FromContent; the output size of other templates is checked once they are executed.
+func ExecuteTextString(ctx context.Context, tmpl *Template, text string, data interface{}) (string, error) {
+	limits, ok := ExecutionLimitsFromContext(ctx)
+	if !ok || limits == (ExecutionLimits{}) || text == "" {
+		return tmpl.ExecuteTextString(text, data)
+	}
+	l := limitedTemplateOf(tmpl)
+	if l == nil {
+		s, err := tmpl.ExecuteTextString(text, data)
+		if err == nil && limits.MaxOutputSize > 0 && len(s) > limits.MaxOutputSize {
+			return "", &TemplateLimitError{Limit: LimitOutputSize, Limits: limits}
+		}
+		return s, err
+	}
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+	return l.execute(ctx, text, data, limits)
+}
+
+// checkFuncName is the name of the function that limitedTemplate calls to check the limits of an execution.
+const checkFuncName = "__checkExecutionLimits"
+
+// limitedTemplate executes the text template of a Template with limits. Its templates are copies of the templates of
+// the Template that call the check function at the start of each iteration of a range and before each call of a
+// template, so that executions that loop without writing output are aborted too.
+type limitedTemplate struct {
+	text *tmpltext.Template
+
+	once sync.Once
+	base *tmpltext.Template
+	err  error
+	// executions are the clones of base that can be reused, so that base is not cloned for each execution.
+	executions sync.Pool
+}
+
+// limitedTemplates are the limitedTemplate of the templates made by FromContent, by the address of the Template.
+// They are removed when the Template is garbage collected.
+var limitedTemplates sync.Map
+
+// registerLimitedTemplate registers the text template of tmpl, captured when tmpl was made, to execute it with limits.
+func registerLimitedTemplate(tmpl *Template, text *tmpltext.Template) {
+	key := reflect.ValueOf(tmpl).Pointer()
+	limitedTemplates.Store(key, &limitedTemplate{text: text})
+	runtime.SetFinalizer(tmpl, func(*Template) {
+		limitedTemplates.Delete(key)
+	})
+}
+
+// limitedTemplateOf returns the limitedTemplate of tmpl, or nil if tmpl was not made by FromContent.
+func limitedTemplateOf(tmpl *Template) *limitedTemplate {
+	l, ok := limitedTemplates.Load(reflect.ValueOf(tmpl).Pointer())
+	if !ok {
+		return nil
+	}
+	return l.(*limitedTemplate)
+}
+
+// init copies the templates of the text template with the checks of the limits.
+func (l *limitedTemplate) init() {
+	base, err := l.text.Clone()
+	if err != nil {
+		l.err = err
+		return
+	}
+	base.Funcs(tmpltext.FuncMap{checkFuncName: func() string { return "" }})
+	for _, t := range base.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		tree := t.Tree.Copy()
+		addChecks(tree.Root)
+		if _, err := base.AddParseTree(t.Name(), tree); err != nil {
+			l.err = err
+			return
+		}
+	}
+	l.base = base
+}
+
+// limitedExecution is a clone of the templates of a limitedTemplate, whose check function checks the limits of the
+// writer of its current execution.
+type limitedExecution struct {
+	tmpl *tmpltext.Template
+	w    *limitedWriter
+}
+
+func (e *limitedExecution) check() (string, error) {
+	return "", e.w.check()
+}
+
+func (l *limitedTemplate) newExecution() (*limitedExecution, error) {
+	t, err := l.base.Clone()
+	if err != nil {
+		return nil, err
+	}
+	e := &limitedExecution{tmpl: t}
+	t.Funcs(tmpltext.FuncMap{checkFuncName: e.check})
+	return e, nil
+}
+
+// execute executes the text like the ExecuteTextString method of Template, aborting the execution once it exceeds
+// the limits or the context is done.
+func (l *limitedTemplate) execute(ctx context.Context, text string, data interface{}, limits ExecutionLimits) (string, error) {
+	l.once.Do(l.init)
+	if l.err != nil {
+		return "", l.err
+	}
+
+	// Texts that define templates add them to the templates they are parsed with, so they are executed with a clone
+	// that is not reused.
+	reuse := !strings.Contains(text, "define") && !strings.Contains(text, "block")
+	var e *limitedExecution
+	if reuse {
+		e, _ = l.executions.Get().(*limitedExecution)
+	}
+	if e == nil {
+		var err error
+		if e, err = l.newExecution(); err != nil {
+			return "", err
+		}
+	}
+
+	t, err := e.tmpl.New("").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	if reuse {
+		addChecks(t.Tree.Root)
+	} else {
+		// The templates parsed from the text are the ones that do not share the tree of the template of base.
+		for _, d := range e.tmpl.Templates() {
+			if b := l.base.Lookup(d.Name()); d.Tree != nil && (b == nil || b.Tree != d.Tree) {
+				addChecks(d.Tree.Root)
+			}
+		}
+	}
+
+	e.w = &limitedWriter{ctx: ctx, limits: limits}
+	err = t.Execute(e.w, data)
+	s := e.w.buf.String()
+	e.w = nil
+	if reuse {
+		l.executions.Put(e)
+	}
+	if err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// addChecks adds a call of the check function at the start of each iteration of the ranges of the node and before
+// each call of a template, as ranges and calls can run for long without writing output.
+func addChecks(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		nodes := make([]parse.Node, 0, len(n.Nodes))
+		for _, c := range n.Nodes {
+			if _, ok := c.(*parse.TemplateNode); ok {
+				nodes = append(nodes, newCheckNode(c.Position()))
+			}
+			addChecks(c)
+			nodes = append(nodes, c)
+		}
+		n.Nodes = nodes
+	case *parse.RangeNode:
+		addChecks(n.List)
+		addChecks(n.ElseList)
+		n.List.Nodes = append([]parse.Node{newCheckNode(n.Position())}, n.List.Nodes...)
+	case *parse.IfNode:
+		addChecks(n.List)
+		addChecks(n.ElseList)
+	case *parse.WithNode:
+		addChecks(n.List)
+		addChecks(n.ElseList)
+	}
+}
+
+// newCheckNode returns the action {{ __checkExecutionLimits }}, which writes no output.
+func newCheckNode(pos parse.Pos) parse.Node {
+	cmd := &parse.CommandNode{NodeType: parse.NodeCommand, Pos: pos, Args: []parse.Node{parse.NewIdentifier(checkFuncName).SetPos(pos)}}
+	return &parse.ActionNode{NodeType: parse.NodeAction, Pos: pos, Pipe: &parse.PipeNode{NodeType: parse.NodePipe, Pos: pos, Cmds: []*parse.CommandNode{cmd}}}
+}
+
+// limitedWriter returns an error when it is written to after the context is done, or when its output would exceed
+// the maximum size. Templates stop executing on the first error of their writer.
+type limitedWriter struct {
+	ctx    context.Context
+	limits ExecutionLimits
+	buf    bytes.Buffer
+}
+
+// check returns an error if the context is done, which is a TemplateLimitError if the timeout was exceeded.
+func (w *limitedWriter) check() error {
+	err := w.ctx.Err()
+	if err != nil && w.limits.Timeout > 0 && errors.Is(err, context.DeadlineExceeded) {
+		return &TemplateLimitError{Limit: LimitTimeout, Limits: w.limits}
+	}
+	return err
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if err := w.check(); err != nil {
+		return 0, err
+	}
+	if w.limits.MaxOutputSize > 0 && w.buf.Len()+len(p) > w.limits.MaxOutputSize {
+		return 0, &TemplateLimitError{Limit: LimitOutputSize, Limits: w.limits}
+	}
+	return w.buf.Write(p)
+}
@@ -0,0 +1,121 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/logging"
+)
+
+func TestExecutionLimits_Validate(t *testing.T) {
+	require.NoError(t, ExecutionLimits{}.Validate())
+	require.NoError(t, ExecutionLimits{Timeout: time.Second, MaxOutputSize: 100}.Validate())
+	require.Error(t, ExecutionLimits{Timeout: -time.Second}.Validate())
+	require.Error(t, ExecutionLimits{MaxOutputSize: -1}.Validate())
+}
+
+func TestExecuteTextString(t *testing.T) {
+	tmpl, err := FromContent([]string{
+		`{{ define "items" }}{{ range . }}0123456789{{ end }}{{ end }}`,
+		`{{ define "spin" }}{{ range . }}{{ range $ }}{{ end }}{{ end }}{{ end }}`,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, limitedTemplateOf(tmpl))
+
+	items := make([]int, 10)
+
+	t.Run("without limits templates are executed as before", func(t *testing.T) {
+		s, err := ExecuteTextString(context.Background(), tmpl, `{{ template "items" . }}`, items)
+		require.NoError(t, err)
+		require.Len(t, s, 100)
+	})
+
+	t.Run("templates within the limits are executed", func(t *testing.T) {
+		ctx := WithExecutionLimits(context.Background(), ExecutionLimits{Timeout: time.Second, MaxOutputSize: 100})
+		s, err := ExecuteTextString(ctx, tmpl, `{{ template "items" . }}`, items)
+		require.NoError(t, err)
+		require.Len(t, s, 100)
+	})
+
+	t.Run("templates that exceed the maximum output size are aborted", func(t *testing.T) {
+		ctx := WithExecutionLimits(context.Background(), ExecutionLimits{MaxOutputSize: 50})
+		s, err := ExecuteTextString(ctx, tmpl, `{{ template "items" . }}`, items)
+		var limitErr *TemplateLimitError
+		require.ErrorAs(t, err, &limitErr)
+		require.Equal(t, LimitOutputSize, limitErr.Limit)
+		require.Empty(t, s)
+	})
+
+	t.Run("templates that loop without output are aborted at the timeout", func(t *testing.T) {
+		goroutines := runtime.NumGoroutine()
+		ctx := WithExecutionLimits(context.Background(), ExecutionLimits{Timeout: 50 * time.Millisecond})
+		start := time.Now()
+		_, err := ExecuteTextString(ctx, tmpl, `{{ template "spin" . }}`, make([]int, 100000))
+		var limitErr *TemplateLimitError
+		require.ErrorAs(t, err, &limitErr)
+		require.Equal(t, LimitTimeout, limitErr.Limit)
+		require.Less(t, time.Since(start), 5*time.Second)
+		// The execution does not leave anything running.
+		require.Equal(t, goroutines, runtime.NumGoroutine())
+	})
+
+	t.Run("templates are aborted when the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(WithExecutionLimits(context.Background(), ExecutionLimits{MaxOutputSize: 100}))
+		cancel()
+		_, err := ExecuteTextString(ctx, tmpl, `{{ template "spin" . }}`, make([]int, 10))
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("templates defined by the text are not kept for other executions", func(t *testing.T) {
+		ctx := WithExecutionLimits(context.Background(), ExecutionLimits{MaxOutputSize: 100})
+		s, err := ExecuteTextString(ctx, tmpl, `{{ define "items" }}redefined{{ end }}{{ template "items" . }}`, items)
+		require.NoError(t, err)
+		require.Equal(t, "redefined", s)
+
+		s, err = ExecuteTextString(ctx, tmpl, `{{ template "items" . }}`, items)
+		require.NoError(t, err)
+		require.Len(t, s, 100)
+	})
+
+	t.Run("templates not made by FromContent are limited by their output", func(t *testing.T) {
+		other, err := newTemplate()
+		require.NoError(t, err)
+		ctx := WithExecutionLimits(context.Background(), ExecutionLimits{Timeout: time.Second, MaxOutputSize: 5})
+		s, err := ExecuteTextString(ctx, other, `{{ . }}`, "12345")
+		require.NoError(t, err)
+		require.Equal(t, "12345", s)
+		_, err = ExecuteTextString(ctx, other, `{{ . }}`, "123456")
+		var limitErr *TemplateLimitError
+		require.ErrorAs(t, err, &limitErr)
+		require.Equal(t, LimitOutputSize, limitErr.Limit)
+	})
+}
+
+func TestTmplText_ExecutionLimits(t *testing.T) {
+	tmpl, err := FromContent(nil)
+	require.NoError(t, err)
+	tmpl.ExternalURL, err = url.Parse("http://localhost/grafana")
+	require.NoError(t, err)
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "alert1"}}}}
+
+	ctx := WithExecutionLimits(context.Background(), ExecutionLimits{MaxOutputSize: 10})
+	var tmplErr error
+	expand, _ := TmplText(ctx, tmpl, alerts, &logging.FakeLogger{}, &tmplErr)
+	require.Equal(t, "alert1", expand(`{{ .CommonLabels.alertname }}`))
+	require.NoError(t, tmplErr)
+
+	require.Empty(t, expand(`{{ range .Alerts }}{{ .Labels.alertname }} {{ .Labels.alertname }}{{ end }}`))
+	var execErr ExecutionError
+	require.True(t, errors.As(tmplErr, &execErr))
+	var limitErr *TemplateLimitError
+	require.ErrorAs(t, tmplErr, &limitErr)
+	require.Equal(t, LimitOutputSize, limitErr.Limit)
+}
@@ -0,0 +1,74 @@
+package templates
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alerting/models"
+)
+
+// DefaultSilenceDuration is the duration of the silences created from the links of ExactSilenceLink.
+const DefaultSilenceDuration = 2 * time.Hour
+
+// SilenceLink is a link to the page of Grafana that creates a silence, pre-filled with the matchers and the
+// duration of the silence. It builds the silence links of the template data and of the notifiers, so that they
+// are the same.
+type SilenceLink struct {
+	// Matchers are the matchers of the silence, such as team="a".
+	Matchers []string
+	// Duration is the duration of the silence. Grafana uses its default duration if it is zero.
+	Duration time.Duration
+	// OrgID is the ID of the organization of the silence, if it is known.
+	OrgID string
+}
+
+// ExactSilenceLink returns the link to a silence that matches exactly the alert with the labels, with an equality
+// matcher for each label and the default duration. The private labels are left out, except for the UID of the rule
+// of the alert, because the alert can only be silenced with the UID of its rule by users with the minimal
+// permissions. The orgID is the organization of the alert, see models.OrgIDAnnotation.
+func ExactSilenceLink(lbls model.LabelSet, orgID string) SilenceLink {
+	names := make(model.LabelNames, 0, len(lbls))
+	for name := range lbls {
+		if isPrivateLabel(string(name)) && name != models.RuleUIDLabel {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Sort(names)
+
+	link := SilenceLink{Duration: DefaultSilenceDuration, OrgID: orgID}
+	for _, name := range names {
+		m := labels.Matcher{Type: labels.MatchEqual, Name: string(name), Value: string(lbls[name])}
+		link.Matchers = append(link.Matchers, m.String())
+	}
+	return link
+}
+
+// URL returns the URL of the link in the Grafana of the external URL.
+func (l SilenceLink) URL(externalURL url.URL) string {
+	externalURL.Path = path.Join(externalURL.Path, "/alerting/silence/new")
+
+	query := make(url.Values)
+	query.Add("alertmanager", "grafana")
+	for _, m := range l.Matchers {
+		query.Add("matcher", m)
+	}
+	if l.Duration > 0 {
+		query.Set("duration", model.Duration(l.Duration).String())
+	}
+	if l.OrgID != "" {
+		query.Set("orgId", l.OrgID)
+	}
+	externalURL.RawQuery = query.Encode()
+	return externalURL.String()
+}
+
+func isPrivateLabel(name string) bool {
+	return strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__")
+}
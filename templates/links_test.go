@@ -0,0 +1,50 @@
+package templates
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alerting/models"
+)
+
+func TestExactSilenceLink(t *testing.T) {
+	link := ExactSilenceLink(model.LabelSet{
+		"alertname":             "High CPU",
+		models.FolderTitleLabel: "Team A",
+		models.RuleUIDLabel:     "rule-uid",
+		"instance":              `a,b="c"`,
+		"__private__":           "x",
+	}, "2")
+	require.Equal(t, SilenceLink{
+		Matchers: []string{
+			`__alert_rule_uid__="rule-uid"`,
+			`alertname="High CPU"`,
+			`grafana_folder="Team A"`,
+			`instance="a,b=\"c\""`,
+		},
+		Duration: DefaultSilenceDuration,
+		OrgID:    "2",
+	}, link)
+
+	externalURL, err := url.Parse("http://localhost/grafana/")
+	require.NoError(t, err)
+	u, err := url.Parse(link.URL(*externalURL))
+	require.NoError(t, err)
+	require.Equal(t, "/grafana/alerting/silence/new", u.Path)
+	require.Equal(t, url.Values{
+		"alertmanager": {"grafana"},
+		"matcher":      link.Matchers,
+		"duration":     {"2h"},
+		"orgId":        {"2"},
+	}, u.Query())
+
+	// The duration and organization are left out if they are not known.
+	require.Equal(t, "http://localhost/grafana/alerting/silence/new?alertmanager=grafana&matcher=a%3Db",
+		SilenceLink{Matchers: []string{"a=b"}}.URL(*externalURL))
+	require.Equal(t, "http://localhost/grafana/alerting/silence/new?alertmanager=grafana&duration=30m",
+		SilenceLink{Duration: 30 * time.Minute}.URL(*externalURL))
+}
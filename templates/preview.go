@@ -0,0 +1,205 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	tmpltext "text/template"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alerting/models"
+)
+
+// DefaultPreviewExternalURL is the external URL of the data made by PreviewData when none is given.
+const DefaultPreviewExternalURL = "http://localhost:3000/"
+
+// PreviewErrorKind is the kind of a PreviewError.
+type PreviewErrorKind string
+
+const (
+	PreviewInvalidTemplate PreviewErrorKind = "invalid_template"
+	PreviewExecutionError  PreviewErrorKind = "execution_error"
+)
+
+// PreviewOptions configure Preview.
+type PreviewOptions struct {
+	// Name of the previewed template. A definition in Templates with the same name is replaced by the previewed
+	// template, so that the definitions removed from it are not defined.
+	Name string
+	// Templates are the definitions that the previewed template can use, in addition to the default templates.
+	Templates []TemplateDefinition
+	// Limits limit the execution of each definition of the previewed template.
+	Limits ExecutionLimits
+}
+
+// PreviewResult is the result of the previewed template.
+type PreviewResult struct {
+	Results []PreviewOutput `json:"results"`
+	Errors  []PreviewError  `json:"errors"`
+}
+
+// PreviewOutput is the output of a top-level definition of the previewed template.
+type PreviewOutput struct {
+	// Name of the definition, or of the previewed template for text outside of definitions.
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+// PreviewError is an error of the previewed template. Line and Column are zero if the error has no position.
+type PreviewError struct {
+	// Name of the definition that failed to execute. It is empty if the Kind is "invalid_template".
+	Name    string           `json:"name"`
+	Kind    PreviewErrorKind `json:"kind"`
+	Line    int              `json:"line,omitempty"`
+	Column  int              `json:"column,omitempty"`
+	Message string           `json:"message"`
+}
+
+// Preview executes the top-level definitions of templateText with data, in the same way as notifications do, and
+// returns the output of each definition. Definitions that are executed by other definitions are not top-level.
+// Text outside of definitions is executed as a definition with the name of the template.
+func Preview(templateText string, data ExtendedData, opts PreviewOptions) PreviewResult {
+	var res PreviewResult
+	tmpl, err := tmpltext.New(opts.Name).Option("missingkey=zero").Funcs(tmpltext.FuncMap(DefaultFuncs)).Parse(templateText)
+	if err != nil {
+		res.Errors = append(res.Errors, newPreviewError("", PreviewInvalidTemplate, err))
+		return res
+	}
+	definitions, err := TopTemplates(tmpl)
+	if err != nil {
+		res.Errors = append(res.Errors, newPreviewError("", PreviewInvalidTemplate, err))
+		return res
+	}
+
+	contents := make([]string, 0, len(opts.Templates)+1)
+	for _, td := range opts.Templates {
+		if td.Name != opts.Name {
+			contents = append(contents, td.Template)
+		}
+	}
+	contents = append(contents, templateText)
+	t, err := FromContent(contents)
+	if err != nil {
+		res.Errors = append(res.Errors, newPreviewError("", PreviewInvalidTemplate, err))
+		return res
+	}
+	if t.ExternalURL, err = url.Parse(data.ExternalURL); err != nil {
+		res.Errors = append(res.Errors, newPreviewError("", PreviewInvalidTemplate, fmt.Errorf("invalid external URL: %w", err)))
+		return res
+	}
+
+	ctx := WithExecutionLimits(context.Background(), opts.Limits)
+	for _, def := range definitions {
+		text := fmt.Sprintf("{{ template %q . }}", def)
+		if def == tmpl.ParseName {
+			text = templateText
+		}
+		s, err := ExecuteTextString(ctx, t, text, &data)
+		if err != nil {
+			res.Errors = append(res.Errors, newPreviewError(def, PreviewExecutionError, err))
+			continue
+		}
+		res.Results = append(res.Results, PreviewOutput{Name: def, Text: s})
+	}
+	return res
+}
+
+// templateErrorRe matches the position at the start of the errors of text/template, such as
+// "template: name:3:14: executing ...". The column is left out by some parse errors.
+var templateErrorRe = regexp.MustCompile(`^template: [^:]*:(\d+)(?::(\d+))?: (.*)$`)
+
+// newPreviewError returns the error with the position of err, if it has one.
+func newPreviewError(name string, kind PreviewErrorKind, err error) PreviewError {
+	res := PreviewError{Name: name, Kind: kind, Message: err.Error()}
+	m := templateErrorRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return res
+	}
+	res.Line, _ = strconv.Atoi(m[1])
+	res.Column, _ = strconv.Atoi(m[2])
+	res.Message = m[3]
+	return res
+}
+
+// PreviewData returns the data of a notification for a firing alert with each of the label sets, which can be
+// previewed with Preview. The data is made in the same way as for notifications, so the alerts have URLs to
+// silence them and values for their queries. Label sets without an alertname are given one.
+func PreviewData(externalURL string, labels ...model.LabelSet) (ExtendedData, error) {
+	if externalURL == "" {
+		externalURL = DefaultPreviewExternalURL
+	}
+	u, err := url.Parse(externalURL)
+	if err != nil {
+		return ExtendedData{}, fmt.Errorf("invalid external URL: %w", err)
+	}
+	t, err := newTemplate()
+	if err != nil {
+		return ExtendedData{}, err
+	}
+	t.ExternalURL = u
+
+	now := time.Now()
+	alerts := make([]*types.Alert, 0, len(labels))
+	for i, ls := range labels {
+		ls = ls.Clone()
+		if _, ok := ls[model.AlertNameLabel]; !ok {
+			ls[model.AlertNameLabel] = "PreviewAlert"
+		}
+		name := ls[model.AlertNameLabel]
+		values := labelsString(ls)
+		alerts = append(alerts, &types.Alert{Alert: model.Alert{
+			Labels: ls,
+			Annotations: model.LabelSet{
+				"summary":                     "Summary of " + name,
+				"description":                 "Description of " + name,
+				models.DashboardUIDAnnotation: "preview-dashboard",
+				models.PanelIDAnnotation:      "1",
+				models.OrgIDAnnotation:        "1",
+				models.ValuesAnnotation:       `{"A":1,"B":1}`,
+				models.ValueStringAnnotation:  model.LabelValue(fmt.Sprintf("[ var='A' labels={%s} value=1 ], [ var='B' labels={%s} value=1 ]", values, values)),
+			},
+			StartsAt:     now.Add(-5 * time.Minute),
+			GeneratorURL: u.JoinPath("alerting/grafana", fmt.Sprintf("preview-%d", i), "view").String(),
+		}})
+	}
+
+	// Alerts are grouped by alertname, as in the default policy, if they have the same one.
+	groupLabels := model.LabelSet{}
+	for i, a := range alerts {
+		if i == 0 {
+			groupLabels[model.AlertNameLabel] = a.Labels[model.AlertNameLabel]
+		} else if groupLabels[model.AlertNameLabel] != a.Labels[model.AlertNameLabel] {
+			groupLabels = model.LabelSet{}
+			break
+		}
+	}
+	data := t.Data("preview", groupLabels, alerts...)
+	return *extendData(data, AnnotationLimits{}, log.NewNopLogger()), nil
+}
+
+// labelsString returns the labels other than alertname in the format of the value string of Grafana.
+func labelsString(ls model.LabelSet) string {
+	pairs := make([]string, 0, len(ls))
+	for _, p := range removePrivateItems(kvFromLabels(ls)).SortedPairs() {
+		if p.Name != string(model.AlertNameLabel) {
+			pairs = append(pairs, p.Name+"="+p.Value)
+		}
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func kvFromLabels(ls model.LabelSet) template.KV {
+	kv := make(template.KV, len(ls))
+	for k, v := range ls {
+		kv[string(k)] = string(v)
+	}
+	return kv
+}
@@ -0,0 +1,109 @@
+package templates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewData(t *testing.T) {
+	data, err := PreviewData("", model.LabelSet{"team": "a"}, model.LabelSet{"team": "b"})
+	require.NoError(t, err)
+
+	require.Equal(t, "preview", data.Receiver)
+	require.Equal(t, "firing", data.Status)
+	require.Equal(t, DefaultPreviewExternalURL, data.ExternalURL)
+	require.Equal(t, KV{"alertname": "PreviewAlert"}, data.GroupLabels)
+	require.Equal(t, KV{"alertname": "PreviewAlert"}, data.CommonLabels)
+	require.Len(t, data.Alerts, 2)
+
+	a := data.Alerts[0]
+	require.Equal(t, KV{"alertname": "PreviewAlert", "team": "a"}, a.Labels)
+	require.Equal(t, KV{"summary": "Summary of PreviewAlert", "description": "Description of PreviewAlert"}, a.Annotations)
+	require.Equal(t, map[string]float64{"A": 1, "B": 1}, a.Values)
+	require.Equal(t, "[ var='A' labels={team=a} value=1 ], [ var='B' labels={team=a} value=1 ]", a.ValueString)
+	require.Equal(t, "http://localhost:3000/d/preview-dashboard?orgId=1", a.DashboardURL)
+	require.Equal(t, "http://localhost:3000/d/preview-dashboard?orgId=1&viewPanel=1", a.PanelURL)
+	require.Equal(t, "http://localhost:3000/alerting/grafana/preview-0/view?orgId=1", a.GeneratorURL)
+	require.Contains(t, a.SilenceURL, "http://localhost:3000/alerting/silence/new?")
+	require.WithinDuration(t, time.Now().Add(-5*time.Minute), a.StartsAt, time.Minute)
+
+	t.Run("alerts with different alertnames are not grouped by alertname", func(t *testing.T) {
+		data, err := PreviewData("http://grafana.example.com", model.LabelSet{"alertname": "a"}, model.LabelSet{"alertname": "b"})
+		require.NoError(t, err)
+		require.Empty(t, data.GroupLabels)
+		require.Equal(t, "http://grafana.example.com", data.ExternalURL)
+	})
+
+	t.Run("invalid external URL", func(t *testing.T) {
+		_, err := PreviewData("http://[::1")
+		require.Error(t, err)
+	})
+}
+
+func TestPreview(t *testing.T) {
+	data, err := PreviewData("", model.LabelSet{"team": "a"})
+	require.NoError(t, err)
+
+	t.Run("top-level definitions are executed", func(t *testing.T) {
+		res := Preview(`{{ define "title" }}{{ template "team" . }} {{ .CommonLabels.alertname }}{{ end }}{{ define "team" }}{{ .CommonLabels.team }}{{ end }}`, data, PreviewOptions{Name: "preview"})
+		require.Empty(t, res.Errors)
+		require.Equal(t, []PreviewOutput{{Name: "title", Text: "a PreviewAlert"}}, res.Results)
+	})
+
+	t.Run("text outside of definitions is executed with the name of the template", func(t *testing.T) {
+		res := Preview(`{{ len .Alerts }} alerts`, data, PreviewOptions{Name: "preview"})
+		require.Empty(t, res.Errors)
+		require.Equal(t, []PreviewOutput{{Name: "preview", Text: "1 alerts"}}, res.Results)
+	})
+
+	t.Run("definitions of other templates and the default templates can be used", func(t *testing.T) {
+		res := Preview(`{{ define "title" }}{{ template "other" . }}{{ template "default.title" . }}{{ end }}`, data, PreviewOptions{
+			Name: "preview",
+			Templates: []TemplateDefinition{
+				{Name: "other", Template: `{{ define "other" }}other {{ end }}`},
+				{Name: "preview", Template: `{{ define "removed" }}removed{{ end }}`},
+			},
+		})
+		require.Empty(t, res.Errors)
+		require.Equal(t, []PreviewOutput{{Name: "title", Text: "other [FIRING:1] PreviewAlert (a)"}}, res.Results)
+
+		// Definitions of the previous version of the template are not defined.
+		res = Preview(`{{ template "removed" . }}`, data, PreviewOptions{
+			Name:      "preview",
+			Templates: []TemplateDefinition{{Name: "preview", Template: `{{ define "removed" }}removed{{ end }}`}},
+		})
+		require.Len(t, res.Errors, 1)
+		require.Equal(t, PreviewExecutionError, res.Errors[0].Kind)
+	})
+
+	t.Run("parse errors have a position", func(t *testing.T) {
+		res := Preview("{{ define \"title\" }}\n{{ .Foo }\n{{ end }}", data, PreviewOptions{Name: "preview"})
+		require.Empty(t, res.Results)
+		require.Equal(t, []PreviewError{{
+			Kind:    PreviewInvalidTemplate,
+			Line:    2,
+			Message: `unexpected "}" in operand`,
+		}}, res.Errors)
+	})
+
+	t.Run("execution errors have a position", func(t *testing.T) {
+		res := Preview("{{ define \"title\" }}\n  {{ index .Alerts 5 }}{{ end }}{{ define \"ok\" }}ok{{ end }}", data, PreviewOptions{Name: "preview"})
+		require.Equal(t, []PreviewOutput{{Name: "ok", Text: "ok"}}, res.Results)
+		require.Len(t, res.Errors, 1)
+		require.Equal(t, "title", res.Errors[0].Name)
+		require.Equal(t, PreviewExecutionError, res.Errors[0].Kind)
+		require.Equal(t, 2, res.Errors[0].Line)
+		require.Equal(t, 5, res.Errors[0].Column)
+		require.Contains(t, res.Errors[0].Message, `executing "title" at <index .Alerts 5>`)
+	})
+
+	t.Run("execution is limited", func(t *testing.T) {
+		res := Preview(`{{ define "title" }}0123456789{{ end }}`, data, PreviewOptions{Name: "preview", Limits: ExecutionLimits{MaxOutputSize: 5}})
+		require.Empty(t, res.Results)
+		require.Len(t, res.Errors, 1)
+		require.Contains(t, res.Errors[0].Message, "template output exceeded the maximum size of 5 bytes")
+	})
+}
@@ -20,10 +20,14 @@ import (
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/common/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 
 	"github.com/grafana/alerting/models"
 )
 
+var tracer = otel.Tracer("github.com/grafana/alerting/templates")
+
 type Template = template.Template
 type KV = template.KV
 type Data = template.Data
@@ -52,6 +56,9 @@ type ExtendedAlert struct {
 	ValueString   string             `json:"valueString"` // TODO: Remove in Grafana 10
 	ImageURL      string             `json:"imageURL,omitempty"`
 	EmbeddedImage string             `json:"embeddedImage,omitempty"`
+
+	// largeAnnotations are the annotations left out of Annotations, see AnnotationLimits.
+	largeAnnotations *largeAnnotations
 }
 
 type ExtendedAlerts []ExtendedAlert
@@ -66,6 +73,9 @@ type ExtendedData struct {
 	CommonAnnotations KV `json:"commonAnnotations"`
 
 	ExternalURL string `json:"externalURL"`
+
+	// largeCommonAnnotations are the annotations left out of CommonAnnotations, see AnnotationLimits.
+	largeCommonAnnotations *largeAnnotations
 }
 
 var DefaultTemplateName = "__default__"
@@ -116,7 +126,12 @@ func DefaultTemplate(options ...template.Option) (TemplateDefinition, error) {
 
 // FromContent calls Parse on all provided template content and returns the resulting Template. Content equivalent to templates.FromGlobs.
 func FromContent(tmpls []string, options ...template.Option) (*Template, error) {
-	t, err := newTemplate(options...)
+	// The text template is captured, so that the templates can be executed with limits, see ExecuteTextString.
+	var text *tmpltext.Template
+	var captureText template.Option = func(t *tmpltext.Template, _ *tmplhtml.Template) {
+		text = t
+	}
+	t, err := newTemplate(append([]template.Option{captureText}, options...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -148,6 +163,7 @@ func FromContent(tmpls []string, options ...template.Option) (*Template, error)
 			return nil, err
 		}
 	}
+	registerLimitedTemplate(t, text)
 	return t, nil
 }
 
@@ -160,17 +176,17 @@ func removePrivateItems(kv template.KV) template.KV {
 	return kv
 }
 
-func extendAlert(alert template.Alert, externalURL string, logger log.Logger) *ExtendedAlert {
+func extendAlert(alert template.Alert, externalURL string, limits AnnotationLimits, logger log.Logger) *ExtendedAlert {
 	// remove "private" annotations & labels so they don't show up in the template
 	extended := &ExtendedAlert{
 		Status:       alert.Status,
 		Labels:       removePrivateItems(alert.Labels),
-		Annotations:  removePrivateItems(alert.Annotations),
 		StartsAt:     alert.StartsAt,
 		EndsAt:       alert.EndsAt,
 		GeneratorURL: alert.GeneratorURL,
 		Fingerprint:  alert.Fingerprint,
 	}
+	extended.Annotations, extended.largeAnnotations = splitLargeAnnotations(removePrivateItems(alert.Annotations), limits)
 
 	// fill in some grafana-specific urls
 	if len(externalURL) == 0 {
@@ -232,18 +248,15 @@ func extendAlert(alert template.Alert, externalURL string, logger log.Logger) *E
 
 // generateSilenceURL generates a URL to silence the given alert in Grafana.
 func generateSilenceURL(alert template.Alert, baseURL url.URL, externalPath string) string {
-	baseURL.Path = path.Join(externalPath, "/alerting/silence/new")
-
-	query := make(url.Values)
-	query.Add("alertmanager", "grafana")
+	link := SilenceLink{OrgID: alert.Annotations[models.OrgIDAnnotation]}
 
 	ruleUID := alert.Labels[models.RuleUIDLabel]
 	if ruleUID != "" {
-		query.Add("matcher", models.RuleUIDLabel+"="+ruleUID)
+		link.Matchers = append(link.Matchers, models.RuleUIDLabel+"="+ruleUID)
 	}
 
 	for _, pair := range alert.Labels.SortedPairs() {
-		if strings.HasPrefix(pair.Name, "__") && strings.HasSuffix(pair.Name, "__") {
+		if isPrivateLabel(pair.Name) {
 			continue
 		}
 
@@ -253,17 +266,11 @@ func generateSilenceURL(alert template.Alert, baseURL url.URL, externalPath stri
 			continue
 		}
 
-		query.Add("matcher", pair.Name+"="+pair.Value)
+		link.Matchers = append(link.Matchers, pair.Name+"="+pair.Value)
 	}
 
-	baseURL.RawQuery = query.Encode()
-
-	orgID := alert.Annotations[models.OrgIDAnnotation]
-	if len(orgID) > 0 {
-		_ = setOrgIDQueryParam(&baseURL, orgID)
-	}
-
-	return baseURL.String()
+	baseURL.Path = externalPath
+	return link.URL(baseURL)
 }
 
 func setOrgIDQueryParam(url *url.URL, orgID string) string {
@@ -275,39 +282,84 @@ func setOrgIDQueryParam(url *url.URL, orgID string) string {
 }
 
 func ExtendData(data *Data, logger log.Logger) *ExtendedData {
+	return extendData(data, AnnotationLimits{}, logger)
+}
+
+func extendData(data *Data, limits AnnotationLimits, logger log.Logger) *ExtendedData {
 	alerts := make([]ExtendedAlert, 0, len(data.Alerts))
 
 	for _, alert := range data.Alerts {
-		extendedAlert := extendAlert(alert, data.ExternalURL, logger)
+		extendedAlert := extendAlert(alert, data.ExternalURL, limits, logger)
 		alerts = append(alerts, *extendedAlert)
 	}
 
 	extended := &ExtendedData{
-		Receiver:          data.Receiver,
-		Status:            data.Status,
-		Alerts:            alerts,
-		GroupLabels:       data.GroupLabels,
-		CommonLabels:      removePrivateItems(data.CommonLabels),
-		CommonAnnotations: removePrivateItems(data.CommonAnnotations),
+		Receiver:     data.Receiver,
+		Status:       data.Status,
+		Alerts:       alerts,
+		GroupLabels:  data.GroupLabels,
+		CommonLabels: removePrivateItems(data.CommonLabels),
 
 		ExternalURL: data.ExternalURL,
 	}
+	extended.CommonAnnotations, extended.largeCommonAnnotations = splitLargeAnnotations(removePrivateItems(data.CommonAnnotations), limits)
 	return extended
 }
 
+// TmplText returns a function that executes the templates with the data of the alerts. If the context has
+// AnnotationLimits, large annotations are left out of the data and loaded when referenced.
 func TmplText(ctx context.Context, tmpl *Template, alerts []*types.Alert, l log.Logger, tmplErr *error) (func(string) string, *ExtendedData) {
+	tmpl = TemplateFromContext(ctx, tmpl)
 	promTmplData := notify.GetTemplateData(ctx, tmpl, alerts, l)
-	data := ExtendData(promTmplData, l)
+	limits, _ := AnnotationLimitsFromContext(ctx)
+	data := extendData(promTmplData, limits, l)
 
 	return func(name string) (s string) {
 		if *tmplErr != nil {
 			return
 		}
-		s, *tmplErr = tmpl.ExecuteTextString(name, data)
+		_, span := tracer.Start(ctx, "templates.ExecuteText")
+		defer span.End()
+		s, err := ExecuteTextString(ctx, tmpl, name, data)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			*tmplErr = ExecutionError{Err: err}
+		}
 		return s
 	}, data
 }
 
+type templateKey struct{}
+
+// WithTemplate returns a context whose notifications are rendered with tmpl by TmplText, instead of the template the
+// receivers were built with. It allows the templates to be replaced without building the receivers again.
+func WithTemplate(ctx context.Context, tmpl *Template) context.Context {
+	return context.WithValue(ctx, templateKey{}, tmpl)
+}
+
+// TemplateFromContext returns the template of the context, or tmpl if the context has none.
+func TemplateFromContext(ctx context.Context, tmpl *Template) *Template {
+	if t, ok := ctx.Value(templateKey{}).(*Template); ok && t != nil {
+		return t
+	}
+	return tmpl
+}
+
+// ExecutionError is the error of a template that failed to execute for a notification. Receivers return it as is,
+// so that the failures of notifications caused by templates can be told apart from the failures of requests.
+type ExecutionError struct {
+	Err error
+}
+
+func (e ExecutionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ExecutionError) Unwrap() error {
+	return e.Err
+}
+
 // Firing returns the subset of alerts that are firing.
 func (as ExtendedAlerts) Firing() []ExtendedAlert {
 	res := []ExtendedAlert{}